@@ -0,0 +1,117 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Install ledger actions
+const (
+	InstallActionInstall   = "Install"
+	InstallActionUpgrade   = "Upgrade"
+	InstallActionAdopt     = "Adopt"
+	InstallActionUninstall = "Uninstall"
+)
+
+// Install ledger action results
+const (
+	InstallResultSucceeded = "Succeeded"
+	InstallResultFailed    = "Failed"
+)
+
+// Labels set on InstalledComponent objects so they can be selected per integration or cluster
+const (
+	LabelIntegration = "ksit.io/integration"
+	LabelCluster     = "ksit.io/cluster"
+)
+
+// LabelIntegrationNamespace is set with LabelIntegration on cluster-scoped objects KSIT
+// applies, whose owner cannot be told apart by the object's namespace
+const LabelIntegrationNamespace = "ksit.io/integration-namespace"
+
+// InstalledComponentSpec records what KSIT installed for one integration on one cluster
+type InstalledComponentSpec struct {
+	// IntegrationName is the Integration (in the same namespace) that owns the install
+	IntegrationName string `json:"integrationName"`
+
+	// ClusterName is the target cluster the component is installed on
+	ClusterName string `json:"clusterName"`
+
+	// Type is the integration type (argocd, flux, prometheus, istio)
+	Type string `json:"type"`
+
+	// Method is how the component was installed (helm, manifest, operator)
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Version is the chart version or manifest reference that was installed
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ValuesHash is a hash of the install values, used to detect configuration changes
+	// +optional
+	ValuesHash string `json:"valuesHash,omitempty"`
+}
+
+// InstalledComponentStatus records the outcome of the most recent action
+type InstalledComponentStatus struct {
+	// InstalledAt is when the component was first installed or adopted
+	// +optional
+	InstalledAt *metav1.Time `json:"installedAt,omitempty"`
+
+	// LastActionTime is when the last action ran
+	// +optional
+	LastActionTime *metav1.Time `json:"lastActionTime,omitempty"`
+
+	// LastAction is the last action taken (Install, Upgrade, Adopt, Uninstall)
+	// +optional
+	LastAction string `json:"lastAction,omitempty"`
+
+	// LastActionResult is the result of the last action (Succeeded, Failed)
+	// +optional
+	LastActionResult string `json:"lastActionResult,omitempty"`
+
+	// Message holds details about the last action, such as the failure reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Adopted is true when the component existed before KSIT started managing it
+	// +optional
+	Adopted bool `json:"adopted,omitempty"`
+
+	// Ready is true when the component is installed and its last action succeeded
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=ic
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Integration",type=string,JSONPath=`.spec.integrationName`
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterName`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+// +kubebuilder:printcolumn:name="Last Action",type=string,JSONPath=`.status.lastAction`,priority=1
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// InstalledComponent is a ledger entry for an integration installed on a target cluster
+type InstalledComponent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InstalledComponentSpec   `json:"spec,omitempty"`
+	Status InstalledComponentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// InstalledComponentList contains a list of InstalledComponent
+type InstalledComponentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InstalledComponent `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InstalledComponent{}, &InstalledComponentList{})
+}
@@ -1,15 +1,38 @@
 package v1alpha1
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Integration type constants
 const (
-	IntegrationTypeArgoCD     = "argocd"
-	IntegrationTypeFlux       = "flux"
-	IntegrationTypePrometheus = "prometheus"
-	IntegrationTypeIstio      = "istio"
+	IntegrationTypeArgoCD      = "argocd"
+	IntegrationTypeFlux        = "flux"
+	IntegrationTypePrometheus  = "prometheus"
+	IntegrationTypeIstio       = "istio"
+	IntegrationTypeGrafana     = "grafana"
+	IntegrationTypeCertManager = "cert-manager"
+	IntegrationTypeKyverno     = "kyverno"
+)
+
+// Istio install profiles, named after the istioctl profiles they follow
+const (
+	IstioProfileDefault = "default"
+	IstioProfileDemo    = "demo"
+	IstioProfileMinimal = "minimal"
+	IstioProfileAmbient = "ambient"
+)
+
+// Scopes of an Integration
+const (
+	// ScopeNamespace targets only clusters registered in the Integration's namespace
+	ScopeNamespace = "Namespace"
+	// ScopeCluster targets clusters registered in any namespace
+	ScopeCluster = "Cluster"
 )
 
 // Phase constants
@@ -18,6 +41,16 @@ const (
 	PhaseRunning      = "Running"
 	PhaseFailed       = "Failed"
 	PhaseSucceeded    = "Succeeded"
+	PhaseDisabled     = "Disabled"
+)
+
+// Disable policies, applied when spec.enabled is set to false
+const (
+	// DisablePolicyRetain leaves the tool and the resources KSIT created in place
+	DisablePolicyRetain = "Retain"
+	// DisablePolicyUninstall removes the tool if KSIT installed it, along with the
+	// resources KSIT created
+	DisablePolicyUninstall = "Uninstall"
 )
 
 // Condition types
@@ -25,12 +58,111 @@ const (
 	ConditionTypeReady       = "Ready"
 	ConditionTypeProgressing = "Progressing"
 	ConditionTypeDegraded    = "Degraded"
+	// ConditionTypeWorkloadsApplied reports whether spec.workloads is applied on every target cluster
+	ConditionTypeWorkloadsApplied = "WorkloadsApplied"
+	// ConditionTypeDelivered reports whether the workloads of spec.kubeStellar.bindingPolicy
+	// landed on every WEC the BindingPolicy selects
+	ConditionTypeDelivered = "Delivered"
+	// ConditionTypeCertificatesExpiring reports whether a cert-manager Certificate on a
+	// target cluster expires within the Integration's expiry window
+	ConditionTypeCertificatesExpiring = "CertificatesExpiring"
+	// ConditionTypePolicyViolations reports whether the Kyverno policy reports of the
+	// target clusters have failed results
+	ConditionTypePolicyViolations = "PolicyViolations"
+	// ConditionTypeKubeconfigRotated is set on an IntegrationTarget when its kubeconfig
+	// Secret changed and the cluster was re-registered with the new credentials
+	ConditionTypeKubeconfigRotated = "KubeconfigRotated"
+)
+
+// ReconcileRequestAnnotation forces a reconcile when its value changes. The value is
+// echoed back in status.lastHandledReconcileAt once the request has been processed.
+const ReconcileRequestAnnotation = "ksit.io/reconcile-requested-at"
+
+// ForceDeleteAnnotation set to "true" on an Integration being deleted releases its
+// finalizer on the next attempt, leaving behind whatever could not be cleaned up
+const ForceDeleteAnnotation = "ksit.io/force-delete"
+
+// Targets returns the target clusters: spec.targetClusters, or status.selectedClusters
+// when spec.targetSelector is set
+func (i *Integration) Targets() []string {
+	if i.Spec.TargetSelector != nil {
+		return i.Status.SelectedClusters
+	}
+	return i.Spec.TargetClusters
+}
+
+// ClusterScoped reports whether the Integration may target clusters registered in any namespace
+func (i *Integration) ClusterScoped() bool {
+	return i.Spec.Scope == ScopeCluster
+}
+
+// UninstallAnnotation lists, comma-separated, the target clusters to uninstall the tool
+// from without deleting the Integration. The controller removes it once it has tried.
+const UninstallAnnotation = "ksit.io/uninstall"
+
+// Suffixes of the annotations cluster owners set on an IntegrationTarget to adjust the
+// Helm installs on their cluster. The annotation is ksit.io/<type><suffix>, e.g.
+// ksit.io/argocd-values-secret, and names a Secret in the IntegrationTarget's namespace
+// whose entries are Helm values in the format of helmConfig.values.
+const (
+	// ValuesSecretAnnotationSuffix values replace those of the Integration
+	ValuesSecretAnnotationSuffix = "-values-secret"
+	// DefaultsSecretAnnotationSuffix values are only used where the Integration sets none
+	DefaultsSecretAnnotationSuffix = "-defaults-secret"
+)
+
+// TargetAnnotation returns the IntegrationTarget annotation of an integration type
+func TargetAnnotation(integrationType, suffix string) string {
+	return "ksit.io/" + integrationType + suffix
+}
+
+// UninstallRequests returns the clusters listed in the UninstallAnnotation
+func (i *Integration) UninstallRequests() []string {
+	var clusters []string
+	for _, name := range strings.Split(i.Annotations[UninstallAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			clusters = append(clusters, name)
+		}
+	}
+	return clusters
+}
+
+// RequestUninstall adds clusterName to the UninstallAnnotation
+func (i *Integration) RequestUninstall(clusterName string) {
+	clusters := i.UninstallRequests()
+	for _, name := range clusters {
+		if name == clusterName {
+			return
+		}
+	}
+	if i.Annotations == nil {
+		i.Annotations = map[string]string{}
+	}
+	i.Annotations[UninstallAnnotation] = strings.Join(append(clusters, clusterName), ",")
+}
+
+// Condition reasons
+const (
+	// ReasonMissingCRDs is set when a target cluster does not serve the CRDs an integration needs
+	ReasonMissingCRDs = "MissingCRDs"
+	// ReasonDisabled is set while spec.enabled is false
+	ReasonDisabled = "Disabled"
+	// ReasonNotFound is set when an object or cluster the integration depends on does not exist
+	ReasonNotFound = "NotFound"
+	// ReasonUnauthorized is set when a cluster rejects the credentials or permissions of KSIT
+	ReasonUnauthorized = "Unauthorized"
+	// ReasonTimeout is set when a cluster or service did not answer in time
+	ReasonTimeout = "Timeout"
+	// ReasonHelmFailed is set when Helm fails to install, upgrade or uninstall a release
+	ReasonHelmFailed = "HelmFailed"
+	// ReasonReconcileFailed is set for failures that fit no other reason
+	ReasonReconcileFailed = "ReconcileFailed"
 )
 
 // IntegrationSpec defines the desired state of Integration
 type IntegrationSpec struct {
-	// Type specifies the integration type (argocd, flux, prometheus, istio)
-	// +kubebuilder:validation:Enum=argocd;flux;prometheus;istio
+	// Type specifies the integration type (argocd, flux, prometheus, istio, grafana, cert-manager, kyverno)
+	// +kubebuilder:validation:Enum=argocd;flux;prometheus;istio;grafana;cert-manager;kyverno
 	// +kubebuilder:validation:Required
 	Type string `json:"type"`
 
@@ -38,15 +170,275 @@ type IntegrationSpec struct {
 	// +kubebuilder:default=true
 	Enabled bool `json:"enabled,omitempty"`
 
+	// OnDisable decides what happens on the target clusters when the integration is
+	// disabled: Retain leaves everything in place, Uninstall removes what KSIT installed
+	// +kubebuilder:validation:Enum=Retain;Uninstall
+	// +kubebuilder:default=Retain
+	// +optional
+	OnDisable string `json:"onDisable,omitempty"`
+
+	// Scope decides where target clusters may be registered: Namespace allows only
+	// IntegrationTargets in the Integration's namespace, Cluster allows IntegrationTargets
+	// in any namespace. Cluster is only accepted in the namespaces the controller is
+	// configured to trust with it.
+	// +kubebuilder:validation:Enum=Namespace;Cluster
+	// +kubebuilder:default=Namespace
+	// +optional
+	Scope string `json:"scope,omitempty"`
+
 	// TargetClusters is the list of clusters to target
 	TargetClusters []string `json:"targetClusters,omitempty"`
 
+	// TargetSelector also targets the registered clusters whose IntegrationTarget, in the
+	// Integration's namespace or in any namespace with Cluster scope, has matching labels. Clusters that register later are
+	// picked up automatically. The selected clusters are listed in status.selectedClusters.
+	// +optional
+	TargetSelector *metav1.LabelSelector `json:"targetSelector,omitempty"`
+
+	// PausedClusters are target clusters KSIT leaves alone, e.g. while they are under
+	// maintenance: nothing is installed, applied or checked there until they are removed
+	// from the list, while the rest of the fleet keeps converging. Uninstall requests and
+	// the cleanup on delete still reach them.
+	// +optional
+	PausedClusters []string `json:"pausedClusters,omitempty"`
+
 	// Config holds integration-specific configuration
 	Config map[string]string `json:"config,omitempty"`
 
+	// ConfigSecretRefs set config keys from Secrets in the Integration's namespace, so
+	// credentials such as API tokens and passwords stay out of spec.config. They are read
+	// on every reconcile.
+	// +optional
+	ConfigSecretRefs []ConfigSecretRef `json:"configSecretRefs,omitempty"`
+
 	// AutoInstall configuration for automatic tool installation
 	// +optional
 	AutoInstall *InstallConfig `json:"autoInstall,omitempty"`
+
+	// Flux declares GitRepositories and Kustomizations that KSIT creates on every
+	// target cluster of a flux Integration
+	// +optional
+	Flux *FluxSpec `json:"flux,omitempty"`
+
+	// Kyverno declares ClusterPolicies that KSIT applies to every target cluster of a
+	// kyverno Integration
+	// +optional
+	Kyverno *KyvernoSpec `json:"kyverno,omitempty"`
+
+	// Workloads are objects KSIT server-side applies to every target cluster, and
+	// deletes from them once they are no longer declared
+	// +optional
+	Workloads *WorkloadsSpec `json:"workloads,omitempty"`
+
+	// KubeStellar links the Integration to the KubeStellar BindingPolicy that downsyncs
+	// its workloads, so status.delivery reports what landed on each WEC
+	// +optional
+	KubeStellar *KubeStellarSpec `json:"kubeStellar,omitempty"`
+
+	// Cleanup controls when deletion gives up on clusters where cleanup keeps failing
+	// +optional
+	Cleanup *CleanupPolicy `json:"cleanup,omitempty"`
+
+	// ImpersonateUser is the user KSIT impersonates on target clusters when acting for
+	// this Integration, so the clusters' RBAC bounds what it can do. The credentials of
+	// the IntegrationTargets need the impersonate permission.
+	// +optional
+	ImpersonateUser string `json:"impersonateUser,omitempty"`
+
+	// ImpersonateGroups are impersonated along with ImpersonateUser
+	// +optional
+	ImpersonateGroups []string `json:"impersonateGroups,omitempty"`
+
+	// HealthScoring adjusts how status.health is scored
+	// +optional
+	HealthScoring *HealthScoring `json:"healthScoring,omitempty"`
+
+	// ClusterTimeout bounds the health checks on one target cluster, so a hung cluster
+	// cannot stall the reconcile. Defaults to 30s, or 1m for flux.
+	// +optional
+	ClusterTimeout *metav1.Duration `json:"clusterTimeout,omitempty"`
+
+	// ReconcileInterval is how often the Integration is reconciled when nothing changes.
+	// Defaults to reconcile.interval of the controller config. Large fleets may still be
+	// reconciled less often; see reconcile.requeueBudget.
+	// +optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+}
+
+// HealthScoring sets the weights of the health score signals: controlPlane, endpoints,
+// apiProbe, versionSkew and alerts. Signals left out keep their default weights of
+// 40, 20, 20, 10 and 10; a weight of 0 leaves the signal out of the score.
+type HealthScoring struct {
+	// +optional
+	Weights map[string]int32 `json:"weights,omitempty"`
+}
+
+// CleanupPolicy decides when a deletion stops retrying cleanup and releases the finalizer.
+// Cleanup is forced when either limit is reached.
+type CleanupPolicy struct {
+	// MaxAttempts is the number of failed cleanup attempts after which cleanup is forced.
+	// Zero means no limit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+
+	// Timeout is how long after deletion was requested cleanup keeps retrying. Defaults to 1h.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// WorkloadsSpec declares objects to apply to every target cluster of an Integration
+type WorkloadsSpec struct {
+	// Manifests are inline YAML manifests of one or more documents
+	// +optional
+	Manifests []string `json:"manifests,omitempty"`
+
+	// ConfigMaps name ConfigMaps in the Integration's namespace. Each key of each
+	// ConfigMap holds a manifest; keys are read in sorted order.
+	// +optional
+	ConfigMaps []string `json:"configMaps,omitempty"`
+
+	// Namespace of namespaced objects that do not set one. Defaults to default.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// WorkloadRef identifies an object applied from spec.workloads
+type WorkloadRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// KubeStellarSpec names the KubeStellar objects an Integration reads its delivery status from
+type KubeStellarSpec struct {
+	// BindingPolicy is the name of the BindingPolicy in the WDS
+	// +kubebuilder:validation:MinLength=1
+	BindingPolicy string `json:"bindingPolicy"`
+}
+
+// FluxSpec declares Flux resources to create on each target cluster
+type FluxSpec struct {
+	// GitRepositories to create on each target cluster
+	// +optional
+	GitRepositories []FluxGitRepository `json:"gitRepositories,omitempty"`
+
+	// Kustomizations to create on each target cluster
+	// +optional
+	Kustomizations []FluxKustomization `json:"kustomizations,omitempty"`
+}
+
+// KyvernoSpec declares Kyverno policies to apply to each target cluster
+type KyvernoSpec struct {
+	// Policies are inline YAML manifests of kyverno.io ClusterPolicies. ClusterPolicies
+	// that KSIT applied and that are no longer declared are deleted.
+	// +optional
+	Policies []string `json:"policies,omitempty"`
+}
+
+// FluxGitRepository is a Flux GitRepository source
+type FluxGitRepository struct {
+	// Name of the GitRepository
+	Name string `json:"name"`
+
+	// Namespace of the GitRepository, defaults to the Integration's Flux namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// URL of the Git repository
+	URL string `json:"url"`
+
+	// Branch to track
+	// +kubebuilder:default=main
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Interval at which to check the repository for updates
+	// +kubebuilder:default="1m"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// SecretRef names the Secret on the target cluster with Git credentials
+	// +optional
+	SecretRef string `json:"secretRef,omitempty"`
+}
+
+// FluxKustomization is a Flux Kustomization applying a path of a GitRepository
+type FluxKustomization struct {
+	// Name of the Kustomization
+	Name string `json:"name"`
+
+	// Namespace of the Kustomization, defaults to the Integration's Flux namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SourceRef is the name of the GitRepository to apply, in the same namespace
+	SourceRef string `json:"sourceRef"`
+
+	// Path within the repository
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Interval at which to reconcile the Kustomization
+	// +kubebuilder:default="10m"
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Prune removes objects that were deleted from the source
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// TargetNamespace overrides the namespace of the applied objects
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// DependsOn lists Kustomizations in the same namespace that must be ready first
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// FluxResourceStatus is the readiness of one declared Flux resource on one cluster
+type FluxResourceStatus struct {
+	// Cluster the resource was created on
+	Cluster string `json:"cluster"`
+
+	// Kind is GitRepository or Kustomization
+	Kind string `json:"kind"`
+
+	// Name of the resource
+	Name string `json:"name"`
+
+	// Namespace of the resource
+	Namespace string `json:"namespace"`
+
+	// Ready mirrors the resource's Ready condition
+	Ready bool `json:"ready"`
+
+	// Message from the Ready condition, or the error that kept the resource from being applied
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ConfigSecretRef sets one config key from a key of a Secret
+type ConfigSecretRef struct {
+	// Key is the config key that is set
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// SecretName is the Secret in the Integration's namespace
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// SecretKey selects the entry of the Secret's data. Defaults to Key.
+	// +optional
+	SecretKey string `json:"secretKey,omitempty"`
+
+	// Optional leaves Key unset when the Secret or its entry does not exist, instead
+	// of failing the reconcile
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 // InstallConfig defines how to install an integration
@@ -64,14 +456,273 @@ type InstallConfig struct {
 	// +optional
 	HelmConfig *HelmInstallConfig `json:"helmConfig,omitempty"`
 
-	// ManifestURL for manifest-based installations
+	// OperatorConfig for operator-based installations through OLM
+	// +optional
+	OperatorConfig *OperatorInstallConfig `json:"operatorConfig,omitempty"`
+
+	// Namespace is the namespace the tool is installed into, which KSIT creates before
+	// installing. Health checks keep using config["namespace"].
+	// +optional
+	Namespace *InstallNamespaceConfig `json:"namespace,omitempty"`
+
+	// ManifestURL for manifest-based installations, which work for every integration type.
+	// For prometheus installed with Helm, it is the manifest of the monitoring.coreos.com
+	// CRDs that are applied before the chart is installed.
 	// +optional
 	ManifestURL string `json:"manifestUrl,omitempty"`
+
+	// ManifestDigest pins the content of manifestUrl as "sha256:<hex>". Installs fail if
+	// the downloaded manifest does not match, and a pinned manifest is downloaded only once.
+	// +kubebuilder:validation:Pattern=`^sha256:[a-fA-F0-9]{64}$`
+	// +optional
+	ManifestDigest string `json:"manifestDigest,omitempty"`
+
+	// Profile installs Istio as the set of charts of an istioctl profile: minimal is base
+	// and istiod, default adds an ingress gateway, demo adds an egress gateway, and ambient
+	// is base, istiod, the CNI node agent and ztunnel. When set, helmConfig only supplies
+	// the repository, the version and the istiod values. Only valid for istio.
+	// +kubebuilder:validation:Enum=default;demo;minimal;ambient
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// ReadinessTimeout is how long an install waits for the tool's controllers to become
+	// ready on one cluster. Defaults to 3m.
+	// +optional
+	ReadinessTimeout *metav1.Duration `json:"readinessTimeout,omitempty"`
+
+	// Overrides set resources and scheduling constraints on the installed components.
+	// They take precedence over the same settings in helmConfig.values.
+	// +optional
+	Overrides *ComponentOverrides `json:"overrides,omitempty"`
+
+	// ClusterOverrides adjust the Helm install for the clusters they select. Overrides are
+	// applied in order, so later entries win over earlier ones.
+	// +optional
+	ClusterOverrides []ClusterOverride `json:"clusterOverrides,omitempty"`
+
+	// SmokeTest exercises the tool after it is installed on a cluster. The Integration
+	// only becomes Running once the smoke test passes.
+	// +optional
+	SmokeTest *SmokeTestConfig `json:"smokeTest,omitempty"`
+
+	// PostInstall hooks run in order on a cluster after each successful install or
+	// upgrade there. A hook that still fails after its retries fails the install, and
+	// the hooks that have not succeeded run again on the next reconcile.
+	// +optional
+	PostInstall []PostInstallHook `json:"postInstall,omitempty"`
+
+	// Hardening makes the tool's namespace comply with hardened cluster policies before
+	// the tool is installed. Only valid for argocd and flux.
+	// +optional
+	Hardening *HardeningConfig `json:"hardening,omitempty"`
+
+	// SkipCRDs leaves the CRDs to be managed separately: KSIT neither applies them nor
+	// lets the chart install them. Only valid for prometheus.
+	// +optional
+	SkipCRDs bool `json:"skipCRDs,omitempty"`
+
+	// UninstallOnDelete uninstalls the tool from the target clusters where KSIT installed
+	// it when the Integration is deleted. Adopted installs are left in place. When unset,
+	// the tool is uninstalled on deletion if onDisable is Uninstall.
+	// +optional
+	UninstallOnDelete *bool `json:"uninstallOnDelete,omitempty"`
+
+	// SelfHeal reinstalls the tool on every reconcile where its install drifted: the
+	// method, version or values differ from the ones KSIT installed, or a release or
+	// workload was deleted from the cluster. Adopted installs are never reinstalled.
+	// +optional
+	SelfHeal bool `json:"selfHeal,omitempty"`
+}
+
+// InstallNamespaceConfig defines the namespace a tool is installed into
+type InstallNamespaceConfig struct {
+	// Name of the namespace. Defaults to config["namespace"], then to the namespace of the
+	// integration type. Flux is always installed into flux-system.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Labels set on the namespace, such as istio-injection or Pod Security levels. The
+	// Pod Security labels of hardening.podSecurity take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations set on the namespace
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Pod Security Standards a hardened namespace can enforce
+const (
+	PodSecurityRestricted = "restricted"
+	PodSecurityBaseline   = "baseline"
+)
+
+// HardeningConfig configures Pod Security admission and NetworkPolicies for the
+// namespace the tool is installed into
+type HardeningConfig struct {
+	// PodSecurity is the Pod Security Standard enforced on the namespace. The namespace
+	// also warns and audits against it.
+	// +kubebuilder:validation:Enum=restricted;baseline
+	// +optional
+	PodSecurity string `json:"podSecurity,omitempty"`
+
+	// NetworkPolicies installs a default-deny NetworkPolicy in the namespace, along with
+	// policies allowing traffic within the namespace, DNS, egress to the Kubernetes API
+	// and to Git and Helm repositories, and ingress to the tool's own endpoints
+	// +optional
+	NetworkPolicies bool `json:"networkPolicies,omitempty"`
+
+	// AllowedNamespaces may reach every pod of the tool when NetworkPolicies is set,
+	// e.g. monitoring for metrics scraping
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+// SmokeTestConfig configures the post-install smoke test. Argo CD creates an Application
+// and Flux a GitRepository and Kustomization from RepoURL, Prometheus answers an "up"
+// query and Istio injects its sidecar into a dry-run Pod.
+type SmokeTestConfig struct {
+	// Enabled turns the smoke test on
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Timeout for the smoke test on one cluster. Defaults to 3m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RepoURL is the Git repository used by the Argo CD and Flux smoke tests.
+	// Defaults to https://github.com/stefanprodan/podinfo.
+	// +optional
+	RepoURL string `json:"repoURL,omitempty"`
+
+	// Branch of RepoURL. Defaults to master.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Path within RepoURL to deploy. Defaults to kustomize.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// PostInstallHook is one step run after a tool is installed on a cluster. Exactly one
+// of job, webhook and manifests is set.
+type PostInstallHook struct {
+	// Name identifies the hook in status.postInstallHooks
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+
+	// Job runs a Job in the install namespace of the target cluster. The hook succeeds
+	// when the Job completes.
+	// +optional
+	Job *PostInstallJob `json:"job,omitempty"`
+
+	// Webhook is called from the hub with a JSON description of the install. The hook
+	// succeeds when it answers with a 2xx status.
+	// +optional
+	Webhook *PostInstallWebhook `json:"webhook,omitempty"`
+
+	// Manifests are inline YAML manifests server-side applied to the target cluster.
+	// Namespaced objects without a namespace go into the install namespace. They are
+	// not deleted when the hook is removed.
+	// +optional
+	Manifests []string `json:"manifests,omitempty"`
+
+	// Retries is how many more times a failed hook runs before the install fails.
+	// Defaults to 2.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Retries *int32 `json:"retries,omitempty"`
+
+	// Timeout of one run of the hook. Defaults to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// PostInstallJob is the single container of a post-install Job
+type PostInstallJob struct {
+	// Image of the container
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Command replaces the entrypoint of the image
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args of the command
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Env of the container
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// ServiceAccountName the Job's pod runs as. Defaults to the namespace's default
+	// ServiceAccount.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// PostInstallWebhook is an HTTP endpoint notified after an install
+type PostInstallWebhook struct {
+	// URL receives a POST with the Integration, cluster and install action
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// TokenSecretRef names a Secret in the Integration's namespace whose token key is
+	// sent as a bearer token
+	// +optional
+	TokenSecretRef string `json:"tokenSecretRef,omitempty"`
+}
+
+// ClusterOverride changes the Helm install on the clusters matching its selector
+type ClusterOverride struct {
+	// ClusterSelector matches the labels of the cluster's IntegrationTarget.
+	// An empty selector matches every cluster.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Values are merged over helmConfig.values
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+
+	// Version replaces helmConfig.version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Overrides replace the fields they set in autoInstall.overrides
+	// +optional
+	Overrides *ComponentOverrides `json:"overrides,omitempty"`
+
+	// SkipCRDs replaces autoInstall.skipCRDs
+	// +optional
+	SkipCRDs *bool `json:"skipCRDs,omitempty"`
+}
+
+// ComponentOverrides size and place the pods of an installed tool. They apply to every
+// component of the tool, e.g. the Argo CD server, repo server and application controller.
+type ComponentOverrides struct {
+	// Resources of the components' containers
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector of the components' pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the components' pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName of the components' pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // HelmInstallConfig defines Helm installation parameters
 type HelmInstallConfig struct {
-	// Repository URL
+	// Repository URL. An oci:// URL pulls the chart from an OCI registry, as
+	// <repository>/<chart>.
 	Repository string `json:"repository"`
 
 	// Chart name
@@ -85,9 +736,113 @@ type HelmInstallConfig struct {
 	// +optional
 	ReleaseName string `json:"releaseName,omitempty"`
 
-	// Values to override
+	// Values to override. They are strings set on top-level keys; use valuesObject for
+	// booleans, numbers, lists and nested values.
 	// +optional
 	Values map[string]string `json:"values,omitempty"`
+
+	// ValuesObject holds Helm values of any type and nesting, as in a values file. They
+	// are merged over valuesFrom, and values are set over them.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ValuesObject *apiextensionsv1.JSON `json:"valuesObject,omitempty"`
+
+	// ValuesFrom reads Helm values files from ConfigMaps and Secrets in the Integration's
+	// namespace. Later entries are merged over earlier ones.
+	// +optional
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
+	// Wait makes installs and upgrades wait until the release's pods, services and jobs
+	// are ready before they count as done
+	// +optional
+	Wait bool `json:"wait,omitempty"`
+
+	// Timeout bounds each Helm operation, including the wait. Defaults to 5m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Atomic uninstalls a release whose first install failed, and implies wait. Failed
+	// upgrades are rolled back to the last deployed revision either way.
+	// +optional
+	Atomic bool `json:"atomic,omitempty"`
+
+	// CredentialsSecretRef names a Secret in the Integration's namespace with the
+	// credentials of a private chart repository or OCI registry: username and password,
+	// or a token, which is sent as the password
+	// +optional
+	CredentialsSecretRef string `json:"credentialsSecretRef,omitempty"`
+
+	// CABundle is the PEM-encoded CA bundle that verifies the TLS certificate of the
+	// repository or registry, for those served with a private CA
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+
+	// Credentials are read from CredentialsSecretRef by the controller for each install
+	// and passed to its chart download only. They are not kept in the Helm settings the
+	// installs on a cluster share.
+	Credentials *RepositoryCredentials `json:"-"`
+}
+
+// RepositoryCredentials authenticate to a chart repository or OCI registry
+type RepositoryCredentials struct {
+	Username string
+	Password string
+}
+
+// ValuesReference names a ConfigMap or Secret entry that holds a Helm values file
+type ValuesReference struct {
+	// Kind of the object holding the values
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Name of the ConfigMap or Secret in the Integration's namespace
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ValuesKey is the entry holding the values file. Defaults to values.yaml.
+	// +optional
+	ValuesKey string `json:"valuesKey,omitempty"`
+
+	// Optional skips the reference when the object or its entry does not exist, instead
+	// of failing the install
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// OperatorInstallConfig defines the OLM Subscription of an operator-based installation
+type OperatorInstallConfig struct {
+	// Package is the operator's package in the catalog. Defaults to argocd-operator for
+	// argocd, prometheus for prometheus and grafana-operator for grafana.
+	// +optional
+	Package string `json:"package,omitempty"`
+
+	// Channel to subscribe to. Defaults to the channel KSIT is tested with for the
+	// default package, and to the package's default channel otherwise.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// CatalogSource providing the package. Defaults to operatorhubio-catalog.
+	// +optional
+	CatalogSource string `json:"catalogSource,omitempty"`
+
+	// CatalogSourceNamespace is the namespace of CatalogSource. Defaults to olm.
+	// +optional
+	CatalogSourceNamespace string `json:"catalogSourceNamespace,omitempty"`
+
+	// StartingCSV is the ClusterServiceVersion to install first, pinning the version
+	// +optional
+	StartingCSV string `json:"startingCSV,omitempty"`
+
+	// InstallPlanApproval is Automatic or Manual. With Manual, installs and upgrades wait
+	// for the InstallPlan to be approved on the cluster. Defaults to Automatic.
+	// +kubebuilder:validation:Enum=Automatic;Manual
+	// +optional
+	InstallPlanApproval string `json:"installPlanApproval,omitempty"`
+
+	// AllNamespaces makes the operator watch every namespace instead of only the one it
+	// is installed into
+	// +optional
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
 }
 
 // ClusterStatus represents the status of a target cluster
@@ -95,20 +850,64 @@ type ClusterStatus struct {
 	// Name of the cluster
 	Name string `json:"name"`
 
-	// Connected indicates if the cluster is reachable
+	// Connected indicates if the cluster is reachable and the integration's checks passed on it
 	Connected bool `json:"connected"`
 
-	// LastSeen is the last time the cluster was seen
+	// LastSeen is the last time the cluster answered, whether or not the checks passed
 	LastSeen metav1.Time `json:"lastSeen,omitempty"`
 
-	// Message provides additional information
+	// Message is the reason the checks failed on the cluster
+	Message string `json:"message,omitempty"`
+
+	// Paused indicates that the cluster is in spec.pausedClusters; the rest of the
+	// status is from before it was paused
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Checks are the health checks run on the cluster, in order. Checks after a failed
+	// one are not run.
+	// +optional
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of one health check on a cluster
+type HealthCheckResult struct {
+	// Name identifies the check, e.g. namespace or server-deployment
+	Name string `json:"name"`
+
+	// Passed indicates whether the check passed
+	Passed bool `json:"passed"`
+
+	// Message is the reason the check failed
+	// +optional
 	Message string `json:"message,omitempty"`
+
+	// Duration is how long the check took
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+}
+
+// ClusterSummary aggregates per-cluster results. It always covers every target cluster,
+// even when ClusterStatuses is truncated.
+type ClusterSummary struct {
+	// Total is the number of target clusters
+	Total int32 `json:"total"`
+
+	// Connected is the number of clusters that are reachable and healthy
+	Connected int32 `json:"connected"`
+
+	// Failing is the number of clusters that are unreachable or unhealthy
+	Failing int32 `json:"failing"`
+
+	// Omitted is the number of clusters left out of ClusterStatuses to bound the object size
+	// +optional
+	Omitted int32 `json:"omitted,omitempty"`
 }
 
 // IntegrationStatus defines the observed state of Integration
 type IntegrationStatus struct {
 	// Phase represents the current phase of the integration
-	// +kubebuilder:validation:Enum=Initializing;Running;Failed;Succeeded
+	// +kubebuilder:validation:Enum=Initializing;Running;Failed;Succeeded;Disabled
 	Phase string `json:"phase,omitempty"`
 
 	// Message provides additional status information
@@ -120,17 +919,353 @@ type IntegrationStatus struct {
 	// ObservedGeneration is the generation observed by the controller
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// ReconciledBy identifies the controller build (version+commit) that last reconciled the integration
+	// +optional
+	ReconciledBy string `json:"reconciledBy,omitempty"`
+
+	// LastHandledReconcileAt holds the value of the most recent
+	// ksit.io/reconcile-requested-at annotation handled by the controller
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
 	// Conditions represent the latest available observations
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
-	// ClusterStatuses shows status per cluster
+	// SelectedClusters are the target clusters of the last reconcile when targetSelector
+	// is set: targetClusters plus the clusters the selector matched
+	// +optional
+	SelectedClusters []string `json:"selectedClusters,omitempty"`
+
+	// PausedClusters are the target clusters skipped by the last reconcile because they
+	// are listed in spec.pausedClusters
+	// +optional
+	PausedClusters []string `json:"pausedClusters,omitempty"`
+
+	// ClusterStatuses shows status per cluster. For large fleets only the worst
+	// offenders are kept; see ClusterSummary for the totals.
 	ClusterStatuses []ClusterStatus `json:"clusterStatuses,omitempty"`
+
+	// ClusterSummary aggregates the status of all target clusters
+	// +optional
+	ClusterSummary *ClusterSummary `json:"clusterSummary,omitempty"`
+
+	// FluxResources reports the readiness of the resources declared in spec.flux on each cluster
+	// +optional
+	FluxResources []FluxResourceStatus `json:"fluxResources,omitempty"`
+
+	// AppliedWorkloads are the objects of spec.workloads that KSIT applied and has not
+	// yet deleted from every target cluster
+	// +optional
+	AppliedWorkloads []WorkloadRef `json:"appliedWorkloads,omitempty"`
+
+	// SmokeTests holds the result of the latest smoke test on each cluster
+	// +optional
+	SmokeTests []SmokeTestResult `json:"smokeTests,omitempty"`
+
+	// PostInstallHooks holds the result of the latest post-install hooks on each cluster
+	// +optional
+	PostInstallHooks []PostInstallHooksResult `json:"postInstallHooks,omitempty"`
+
+	// HelmReleases reports the Helm releases KSIT installed on each target cluster, as
+	// of the latest install or check
+	// +optional
+	HelmReleases []HelmReleaseStatus `json:"helmReleases,omitempty"`
+
+	// Delivery reports, per WEC selected by spec.kubeStellar.bindingPolicy, how many of
+	// the downsynced objects landed there
+	// +optional
+	Delivery []DeliveryStatus `json:"delivery,omitempty"`
+
+	// ExpiringCertificates lists, for cert-manager Integrations, the first Certificates on
+	// the target clusters that expire within the expiry window, soonest first
+	// +optional
+	ExpiringCertificates []ExpiringCertificate `json:"expiringCertificates,omitempty"`
+
+	// PolicyReports sums, for kyverno Integrations, the results of the policy reports on
+	// the target clusters
+	// +optional
+	PolicyReports *PolicyReportSummary `json:"policyReports,omitempty"`
+
+	// Cleanup tracks cleanup attempts while the Integration is being deleted
+	// +optional
+	Cleanup *CleanupStatus `json:"cleanup,omitempty"`
+
+	// SLO summarizes availability, reconcile error rate and time to recovery per calendar month
+	// +optional
+	SLO *SLOStatus `json:"slo,omitempty"`
+
+	// Health scores the integration from 0 (down) to 100 (fully healthy)
+	// +optional
+	Health *HealthScore `json:"health,omitempty"`
+}
+
+// HealthScore aggregates the health scores of the target clusters
+type HealthScore struct {
+	// Score is the fleet score: the mean of the cluster scores, but at most 50 above the worst cluster
+	Score int32 `json:"score"`
+
+	// Clusters holds the score of each target cluster, lowest first. Like clusterStatuses,
+	// it is truncated for large fleets.
+	// +optional
+	Clusters []ClusterHealthScore `json:"clusters,omitempty"`
+}
+
+// ClusterHealthScore is the health score of the integration on one cluster
+type ClusterHealthScore struct {
+	// Name of the cluster
+	Name string `json:"name"`
+
+	// Score from 0 to 100; unreachable clusters score 0
+	Score int32 `json:"score"`
+
+	// Signals holds the 0-100 score of each signal that was observed
+	// +optional
+	Signals map[string]int32 `json:"signals,omitempty"`
+}
+
+// SLOStatus tracks service level indicators derived from phase transitions
+type SLOStatus struct {
+	// Current covers the calendar month (UTC) in progress
+	Current SLOWindow `json:"current"`
+
+	// Previous is the last completed calendar month
+	// +optional
+	Previous *SLOWindow `json:"previous,omitempty"`
+
+	// LastObservedTime is when the last reconcile outcome was recorded
+	LastObservedTime metav1.Time `json:"lastObservedTime"`
+
+	// LastPhase is the phase recorded at LastObservedTime
+	// +optional
+	LastPhase string `json:"lastPhase,omitempty"`
+
+	// DownSince is when the Integration last left the Running phase; unset while Running
+	// +optional
+	DownSince *metav1.Time `json:"downSince,omitempty"`
+}
+
+// SLOWindow holds the service level indicators of one calendar month. Only time spent
+// Running or Failed is observed; time spent Initializing or disabled counts neither
+// for nor against availability.
+type SLOWindow struct {
+	// Start of the window
+	Start metav1.Time `json:"start"`
+
+	// End of the window; set once the month is over
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+
+	// ObservedSeconds is the time the Integration spent Running or Failed
+	ObservedSeconds int64 `json:"observedSeconds,omitempty"`
+
+	// RunningSeconds is the observed time spent Running
+	RunningSeconds int64 `json:"runningSeconds,omitempty"`
+
+	// Reconciles is the number of reconciles that ended Running or Failed
+	Reconciles int64 `json:"reconciles,omitempty"`
+
+	// FailedReconciles is the number of reconciles that ended in the Failed phase
+	FailedReconciles int64 `json:"failedReconciles,omitempty"`
+
+	// Incidents is the number of times the Integration left the Running phase
+	Incidents int32 `json:"incidents,omitempty"`
+
+	// Recoveries is the number of times the Integration returned to Running after an incident
+	Recoveries int32 `json:"recoveries,omitempty"`
+
+	// RecoverySeconds is the total time from leaving Running to returning to it, over all recoveries
+	RecoverySeconds int64 `json:"recoverySeconds,omitempty"`
+
+	// Availability is RunningSeconds over ObservedSeconds as a percentage, e.g. "99.95%"
+	// +optional
+	Availability string `json:"availability,omitempty"`
+
+	// ErrorRate is FailedReconciles over Reconciles as a percentage
+	// +optional
+	ErrorRate string `json:"errorRate,omitempty"`
+
+	// MTTR is the mean time to recovery, e.g. "4m30s"
+	// +optional
+	MTTR string `json:"mttr,omitempty"`
+}
+
+// DeliveryStatus tells which downsynced objects landed on one WEC, as reported by its WorkStatuses
+type DeliveryStatus struct {
+	// Cluster is the name of the WEC
+	Cluster string `json:"cluster"`
+
+	// Expected is the number of objects the Binding sends to the WEC
+	Expected int32 `json:"expected"`
+
+	// Delivered is the number of those objects the WEC reported status for
+	Delivered int32 `json:"delivered"`
+
+	// Missing lists the first objects the WEC has not reported yet
+	// +optional
+	Missing []string `json:"missing,omitempty"`
+}
+
+// ExpiringCertificate is a cert-manager Certificate close to or past its expiry
+type ExpiringCertificate struct {
+	// Cluster the Certificate is on
+	Cluster string `json:"cluster"`
+
+	// Namespace of the Certificate
+	Namespace string `json:"namespace"`
+
+	// Name of the Certificate
+	Name string `json:"name"`
+
+	// NotAfter is when the issued certificate expires
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// Message is the message of the Certificate's Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PolicyReportSummary counts the policy results of all target clusters by outcome
+type PolicyReportSummary struct {
+	Pass  int32 `json:"pass"`
+	Fail  int32 `json:"fail"`
+	Warn  int32 `json:"warn"`
+	Error int32 `json:"error"`
+	Skip  int32 `json:"skip"`
+
+	// Clusters holds the counts of the clusters with failed results, most failures
+	// first. Like clusterStatuses, it is truncated for large fleets.
+	// +optional
+	Clusters []ClusterPolicyReportSummary `json:"clusters,omitempty"`
+}
+
+// ClusterPolicyReportSummary counts the failed policy results of one cluster
+type ClusterPolicyReportSummary struct {
+	Cluster string `json:"cluster"`
+	Fail    int32  `json:"fail"`
+	Warn    int32  `json:"warn"`
+	Error   int32  `json:"error"`
+
+	// FailingPolicies are the policies with the most failed results, at most five
+	// +optional
+	FailingPolicies []string `json:"failingPolicies,omitempty"`
+}
+
+// SmokeTestResult is the outcome of a smoke test on one cluster
+type SmokeTestResult struct {
+	// Cluster the smoke test ran on
+	Cluster string `json:"cluster"`
+
+	// Passed is true when every check passed
+	Passed bool `json:"passed"`
+
+	// Checks are the steps of the smoke test, in order
+	// +optional
+	Checks []SmokeTestCheck `json:"checks,omitempty"`
+
+	// LastRunTime is when the smoke test last ran
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+}
+
+// SmokeTestCheck is one step of a smoke test
+type SmokeTestCheck struct {
+	// Name of the check
+	Name string `json:"name"`
+
+	// Passed is true when the check succeeded
+	Passed bool `json:"passed"`
+
+	// Message describes the result
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PostInstallHooksResult is the outcome of the post-install hooks on one cluster
+type PostInstallHooksResult struct {
+	// Cluster the hooks ran on
+	Cluster string `json:"cluster"`
+
+	// Succeeded is true when every hook succeeded
+	Succeeded bool `json:"succeeded"`
+
+	// Hooks are the hooks that ran, in order. Hooks after a failed one are not listed.
+	// +optional
+	Hooks []PostInstallHookRun `json:"hooks,omitempty"`
+
+	// LastRunTime is when the hooks last ran
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+}
+
+// HelmReleaseStatus is the state of a Helm release on a cluster
+type HelmReleaseStatus struct {
+	// Cluster the release is installed on
+	Cluster string `json:"cluster"`
+
+	// Name of the release
+	Name string `json:"name"`
+
+	// Revision of the release
+	// +optional
+	Revision int32 `json:"revision,omitempty"`
+
+	// Status of the release as Helm reports it, such as deployed, failed or pending-upgrade
+	Status string `json:"status"`
+
+	// Chart and its version, as <chart>-<version>
+	// +optional
+	Chart string `json:"chart,omitempty"`
+
+	// ChartVersion is the version of the chart the release runs. KSIT upgrades the
+	// release when it differs from helmConfig.version.
+	// +optional
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// Description is what Helm recorded about the last operation, such as the reason it failed
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// LastDeployed is when the revision was deployed
+	// +optional
+	LastDeployed *metav1.Time `json:"lastDeployed,omitempty"`
+}
+
+// PostInstallHookRun is the outcome of one post-install hook
+type PostInstallHookRun struct {
+	// Name of the hook
+	Name string `json:"name"`
+
+	// Succeeded is true when the hook succeeded
+	Succeeded bool `json:"succeeded"`
+
+	// Attempts is how many times the hook ran
+	Attempts int32 `json:"attempts"`
+
+	// Message describes the result, or the error of the last attempt
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CleanupStatus records failed cleanup attempts during deletion
+type CleanupStatus struct {
+	// Attempts is the number of cleanup attempts that failed on at least one cluster
+	Attempts int32 `json:"attempts"`
+
+	// LastAttemptTime is when cleanup was last attempted
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// FailedClusters are the clusters where the last attempt failed
+	// +optional
+	FailedClusters []string `json:"failedClusters,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Health",type=integer,JSONPath=`.status.health.score`
+// +kubebuilder:printcolumn:name="Scope",type=string,JSONPath=`.spec.scope`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Integration is the Schema for the integrations API
@@ -161,6 +1296,11 @@ type IntegrationTargetSpec struct {
 
 	// Labels to apply to resources
 	Labels map[string]string `json:"labels,omitempty"`
+
+	// KubeconfigContext selects a context of the kubeconfig secret, for secrets holding a
+	// merged kubeconfig with several clusters. Defaults to the kubeconfig's current context.
+	// +optional
+	KubeconfigContext string `json:"kubeconfigContext,omitempty"`
 }
 
 // IntegrationTargetStatus defines the observed state of IntegrationTarget
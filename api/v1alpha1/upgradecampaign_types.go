@@ -0,0 +1,168 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Campaign phase constants
+const (
+	CampaignPhasePending     = "Pending"
+	CampaignPhaseProgressing = "Progressing"
+	CampaignPhasePaused      = "Paused"
+	CampaignPhasePromoting   = "Promoting"
+	CampaignPhaseSucceeded   = "Succeeded"
+	CampaignPhaseFailed      = "Failed"
+	CampaignPhaseAborted     = "Aborted"
+)
+
+// Per-cluster upgrade states of a campaign
+const (
+	CampaignClusterPending   = "Pending"
+	CampaignClusterSucceeded = "Succeeded"
+	CampaignClusterFailed    = "Failed"
+	CampaignClusterSkipped   = "Skipped"
+)
+
+// UpgradeCampaignSpec defines a fleet-wide upgrade of one integration type
+type UpgradeCampaignSpec struct {
+	// IntegrationType selects the Integrations to upgrade
+	// +kubebuilder:validation:Enum=argocd;flux;prometheus;istio;grafana;cert-manager;kyverno
+	IntegrationType string `json:"integrationType"`
+
+	// Selector further restricts the Integrations by label. Empty selects all of the type.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Version is the chart version to roll out to Helm-installed Integrations
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ManifestURL is the manifest to roll out to manifest-installed Integrations
+	// +optional
+	ManifestURL string `json:"manifestUrl,omitempty"`
+
+	// Strategy controls how the upgrade is rolled out
+	// +optional
+	Strategy RolloutStrategy `json:"strategy,omitempty"`
+
+	// Paused stops the campaign before the next wave starts
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Abort ends the campaign; clusters that were already upgraded stay upgraded
+	// +optional
+	Abort bool `json:"abort,omitempty"`
+}
+
+// RolloutStrategy controls wave-by-wave rollouts
+type RolloutStrategy struct {
+	// WaveSize is the number of clusters upgraded per wave
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	WaveSize int32 `json:"waveSize,omitempty"`
+
+	// MaxFailures is the number of failed clusters tolerated before the campaign fails
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailures int32 `json:"maxFailures,omitempty"`
+
+	// WaveInterval is how long to wait between waves
+	// +optional
+	WaveInterval *metav1.Duration `json:"waveInterval,omitempty"`
+}
+
+// CampaignClusterStatus tracks the upgrade of one Integration on one cluster
+type CampaignClusterStatus struct {
+	// Integration is the name of the Integration being upgraded
+	Integration string `json:"integration"`
+
+	// Cluster is the target cluster
+	Cluster string `json:"cluster"`
+
+	// Wave is the zero-based wave the cluster is upgraded in
+	Wave int32 `json:"wave"`
+
+	// State is Pending, Succeeded, Failed or Skipped
+	State string `json:"state"`
+
+	// Message explains failures and skips
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is when State last changed
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// UpgradeCampaignStatus defines the observed state of UpgradeCampaign
+type UpgradeCampaignStatus struct {
+	// Phase is Pending, Progressing, Paused, Promoting, Succeeded, Failed or Aborted.
+	// Promoting means every wave completed and the Integrations are being updated.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Message provides additional status information
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CurrentWave is the zero-based wave being rolled out
+	// +optional
+	CurrentWave int32 `json:"currentWave,omitempty"`
+
+	// TotalWaves is the number of waves planned
+	// +optional
+	TotalWaves int32 `json:"totalWaves,omitempty"`
+
+	// Succeeded is the number of clusters upgraded successfully
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+
+	// Failed is the number of clusters whose upgrade failed
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// Clusters tracks each Integration and cluster in the campaign
+	// +optional
+	Clusters []CampaignClusterStatus `json:"clusters,omitempty"`
+
+	// LastWaveTime is when the last wave finished
+	// +optional
+	LastWaveTime *metav1.Time `json:"lastWaveTime,omitempty"`
+
+	// ObservedGeneration is the generation observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=uc
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.integrationType`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Wave",type=integer,JSONPath=`.status.currentWave`
+// +kubebuilder:printcolumn:name="Waves",type=integer,JSONPath=`.status.totalWaves`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// UpgradeCampaign rolls a new version of an integration out across the fleet, wave by wave
+type UpgradeCampaign struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UpgradeCampaignSpec   `json:"spec,omitempty"`
+	Status UpgradeCampaignStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UpgradeCampaignList contains a list of UpgradeCampaign
+type UpgradeCampaignList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UpgradeCampaign `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UpgradeCampaign{}, &UpgradeCampaignList{})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/sdk"
+)
+
+// clientOptions holds the connection flags shared by the commands that talk to the hub cluster
+type clientOptions struct {
+	kubeconfig  string
+	kubeContext string
+	namespace   string
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig of the KSIT hub cluster")
+	flags.StringVar(&o.kubeContext, "context", "", "Kubeconfig context to use")
+	flags.StringVarP(&o.namespace, "namespace", "n", "", "Namespace of the Integration (defaults to the context namespace)")
+}
+
+func (o *clientOptions) clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.kubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// newClient returns a controller-runtime client for the hub cluster and the namespace to operate in
+func (o *clientOptions) newClient() (client.Client, string, error) {
+	restConfig, namespace, err := o.restConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create client: %w", err)
+	}
+	return c, namespace, nil
+}
+
+// newSDKClient returns an SDK client for the hub cluster, operating in the namespace of the flags
+func (o *clientOptions) newSDKClient() (*sdk.Client, string, error) {
+	restConfig, namespace, err := o.restConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := sdk.New(restConfig, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	return c, namespace, nil
+}
+
+// restConfig returns the REST config of the hub cluster and the namespace to operate in
+func (o *clientOptions) restConfig() (*rest.Config, string, error) {
+	clientConfig := o.clientConfig()
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	namespace := o.namespace
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve namespace: %w", err)
+		}
+	}
+
+	return restConfig, namespace, nil
+}
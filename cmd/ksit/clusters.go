@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+type clustersOptions struct {
+	clientOptions
+
+	status        string
+	selector      string
+	sortBy        string
+	order         string
+	limit         int
+	continueToken string
+}
+
+func newClustersCommand() *cobra.Command {
+	o := &clustersOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "clusters",
+		Short: "Inspect the target clusters registered with IntegrationTargets",
+	}
+	o.addFlags(cmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List target clusters with their readiness and the Integrations that use them",
+		Example: `  ksit clusters list -n ksit-system
+  ksit clusters list -l region=eu --status Error --sort-by lastSeen --order desc
+  ksit clusters list --limit 50 --continue <token>`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runList(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	flags := listCmd.Flags()
+	flags.StringVar(&o.status, "status", "", "Only list clusters with this status: Active when their IntegrationTarget is ready, Error otherwise")
+	flags.StringVarP(&o.selector, "selector", "l", "", "Only list clusters whose labels match this selector")
+	flags.StringVar(&o.sortBy, "sort-by", string(cluster.SortByName), "Sort by name or lastSeen, the last sync of the IntegrationTarget")
+	flags.StringVar(&o.order, "order", "asc", "Sort order: asc or desc")
+	flags.IntVar(&o.limit, "limit", 0, "List at most this many clusters; 0 lists all")
+	flags.StringVar(&o.continueToken, "continue", "", "List the page after the one that printed this token")
+
+	cmd.AddCommand(listCmd)
+	return cmd
+}
+
+func (o *clustersOptions) runList(ctx context.Context, out io.Writer) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	query, err := o.query()
+	if err != nil {
+		return err
+	}
+
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := c.List(ctx, targets, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list integration targets: %w", err)
+	}
+	if len(targets.Items) == 0 {
+		fmt.Fprintf(out, "No integration targets found in namespace %s\n", namespace)
+		return nil
+	}
+	page, total, next, err := queryTargets(targets.Items, query)
+	if err != nil {
+		return err
+	}
+	if len(page) == 0 {
+		fmt.Fprintf(out, "No clusters in namespace %s match\n", namespace)
+		return nil
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.List(ctx, integrations, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list integrations: %w", err)
+	}
+	if err := printClusters(out, page, integrations.Items, time.Now()); err != nil {
+		return err
+	}
+	if next != "" {
+		fmt.Fprintf(out, "\nShowing %d of %d clusters. Next page: --continue %s\n", len(page), total, next)
+	}
+	return nil
+}
+
+// query builds the inventory query of the list flags
+func (o *clustersOptions) query() (cluster.InventoryQuery, error) {
+	query := cluster.InventoryQuery{
+		Status: o.status,
+		SortBy: cluster.SortField(o.sortBy),
+		Limit:  o.limit,
+	}
+	if o.selector != "" {
+		selector, err := labels.Parse(o.selector)
+		if err != nil {
+			return query, fmt.Errorf("invalid selector: %w", err)
+		}
+		query.Selector = selector
+	}
+	switch o.order {
+	case "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("invalid order %q, must be asc or desc", o.order)
+	}
+	if o.continueToken != "" {
+		offset, err := cluster.ParseContinue(o.continueToken)
+		if err != nil {
+			return query, err
+		}
+		query.Offset = offset
+	}
+	return query, nil
+}
+
+// queryTargets applies query to the clusters of targets, as the fleet API applies it to
+// the inventory. It returns the page of targets, the number of matches and the continue
+// token of the next page.
+func queryTargets(targets []ksitv1alpha1.IntegrationTarget, query cluster.InventoryQuery) ([]ksitv1alpha1.IntegrationTarget, int, string, error) {
+	byCluster := make(map[string]ksitv1alpha1.IntegrationTarget, len(targets))
+	infos := make([]*cluster.ClusterInfo, 0, len(targets))
+	for i := range targets {
+		target := &targets[i]
+		info := &cluster.ClusterInfo{
+			Name:      target.Spec.ClusterName,
+			Namespace: target.Namespace,
+			Status:    string(cluster.ClusterStatusError),
+			Labels:    cluster.TargetLabels(target),
+		}
+		if target.Status.Ready {
+			info.Status = string(cluster.ClusterStatusActive)
+		}
+		if target.Status.LastSyncTime != nil {
+			info.LastSeen = target.Status.LastSyncTime.Time
+		}
+		byCluster[info.Name] = *target
+		infos = append(infos, info)
+	}
+
+	result, err := cluster.QueryClusters(infos, query)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	page := make([]ksitv1alpha1.IntegrationTarget, 0, len(result.Items))
+	for _, info := range result.Items {
+		page = append(page, byCluster[info.Name])
+	}
+	return page, result.Total, result.Continue, nil
+}
+
+// printClusters prints targets in the order given
+func printClusters(out io.Writer, targets []ksitv1alpha1.IntegrationTarget, integrations []ksitv1alpha1.Integration, now time.Time) error {
+	users := make(map[string][]string)
+	for _, integration := range integrations {
+		for _, clusterName := range integration.Targets() {
+			users[clusterName] = append(users[clusterName], integration.Name)
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tTARGET\tREADY\tINTEGRATIONS\tLAST SYNC\tMESSAGE")
+	for _, target := range targets {
+		clusterName := target.Spec.ClusterName
+		names := users[clusterName]
+		sort.Strings(names)
+
+		integrationNames := "<none>"
+		if len(names) > 0 {
+			integrationNames = strings.Join(names, ",")
+		}
+		lastSync := "<never>"
+		if target.Status.LastSyncTime != nil {
+			lastSync = age(target.Status.LastSyncTime.Time, now) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", clusterName, target.Name, strconv.FormatBool(target.Status.Ready),
+			integrationNames, lastSync, target.Status.Message)
+	}
+	return w.Flush()
+}
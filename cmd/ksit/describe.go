@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+type describeOptions struct {
+	clientOptions
+}
+
+func newDescribeCommand() *cobra.Command {
+	o := &describeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Show the details of a KSIT resource",
+	}
+	o.addFlags(cmd)
+
+	integrationCmd := &cobra.Command{
+		Use:     "integration <name>",
+		Short:   "Show the spec, per-cluster state and conditions of an Integration",
+		Example: `  ksit describe integration argocd -n ksit-system`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegration(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	cmd.AddCommand(integrationCmd)
+	return cmd
+}
+
+func (o *describeOptions) runIntegration(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	integration, err := getIntegration(ctx, c, types.NamespacedName{Name: name, Namespace: namespace})
+	if err != nil {
+		return err
+	}
+	return printIntegration(out, integration, time.Now())
+}
+
+func printIntegration(out io.Writer, integration *ksitv1alpha1.Integration, now time.Time) error {
+	spec := integration.Spec
+	status := integration.Status
+
+	fmt.Fprintf(out, "Name:       %s\n", integration.Name)
+	fmt.Fprintf(out, "Namespace:  %s\n", integration.Namespace)
+	fmt.Fprintf(out, "Type:       %s\n", spec.Type)
+	fmt.Fprintf(out, "Enabled:    %t\n", spec.Enabled)
+	fmt.Fprintf(out, "Install:    %s\n", installSummary(spec.AutoInstall))
+	if integration.ClusterScoped() {
+		fmt.Fprintf(out, "Scope:      %s\n", spec.Scope)
+	}
+	if spec.TargetSelector != nil {
+		fmt.Fprintf(out, "Selector:   %s\n", metav1.FormatLabelSelector(spec.TargetSelector))
+	}
+	fmt.Fprintf(out, "Phase:      %s\n", valueOrNone(status.Phase))
+	if status.Message != "" {
+		fmt.Fprintf(out, "Message:    %s\n", status.Message)
+	}
+	fmt.Fprintf(out, "Health:     %s\n", healthScore(integration))
+	if status.LastReconcileTime != nil {
+		fmt.Fprintf(out, "Reconciled: %s ago\n", age(status.LastReconcileTime.Time, now))
+	}
+	fmt.Fprintf(out, "Age:        %s\n", age(integration.CreationTimestamp.Time, now))
+
+	if len(spec.Config) > 0 {
+		keys := make([]string, 0, len(spec.Config))
+		for key := range spec.Config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintln(out, "\nConfig:")
+		for _, key := range keys {
+			fmt.Fprintf(out, "  %s: %s\n", key, spec.Config[key])
+		}
+	}
+
+	if len(integration.Targets()) > 0 {
+		fmt.Fprintln(out, "\nClusters:")
+		if err := printClusterStates(out, integration); err != nil {
+			return err
+		}
+	}
+
+	if len(status.Delivery) > 0 {
+		fmt.Fprintln(out, "\nDelivery:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  WEC\tDELIVERED\tMISSING")
+		for _, d := range status.Delivery {
+			fmt.Fprintf(w, "  %s\t%d/%d\t%s\n", d.Cluster, d.Delivered, d.Expected, strings.Join(d.Missing, ", "))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if hasChecks(status.ClusterStatuses) {
+		fmt.Fprintln(out, "\nChecks:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  CLUSTER\tCHECK\tRESULT\tDURATION\tMESSAGE")
+		for _, cs := range status.ClusterStatuses {
+			for _, check := range cs.Checks {
+				result := "passed"
+				if !check.Passed {
+					result = "failed"
+				}
+				fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", cs.Name, check.Name, result, check.Duration.Duration, check.Message)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(status.Conditions) > 0 {
+		fmt.Fprintln(out, "\nConditions:")
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tAGE\tMESSAGE")
+		for _, condition := range status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason,
+				age(condition.LastTransitionTime.Time, now), condition.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasChecks reports whether any cluster status lists health check results
+func hasChecks(statuses []ksitv1alpha1.ClusterStatus) bool {
+	for _, cs := range statuses {
+		if len(cs.Checks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printClusterStates joins what the status reports about each target cluster into one row
+func printClusterStates(out io.Writer, integration *ksitv1alpha1.Integration) error {
+	statuses := make(map[string]ksitv1alpha1.ClusterStatus)
+	for _, status := range integration.Status.ClusterStatuses {
+		statuses[status.Name] = status
+	}
+	scores := make(map[string]int32)
+	if integration.Status.Health != nil {
+		for _, score := range integration.Status.Health.Clusters {
+			scores[score.Name] = score.Score
+		}
+	}
+	smokeTests := make(map[string]bool)
+	for _, result := range integration.Status.SmokeTests {
+		smokeTests[result.Cluster] = result.Passed
+	}
+	hooks := make(map[string]bool)
+	for _, result := range integration.Status.PostInstallHooks {
+		hooks[result.Cluster] = result.Succeeded
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  CLUSTER\tCONNECTED\tHEALTH\tSMOKE TEST\tHOOKS\tMESSAGE")
+	for _, clusterName := range integration.Targets() {
+		connected, message := "<unknown>", ""
+		if status, ok := statuses[clusterName]; ok {
+			connected, message = strconv.FormatBool(status.Connected), status.Message
+		}
+		if slices.Contains(integration.Spec.PausedClusters, clusterName) {
+			message = strings.TrimSpace("(paused) " + message)
+		}
+		health := "<none>"
+		if score, ok := scores[clusterName]; ok {
+			health = strconv.Itoa(int(score))
+		}
+		smokeTest := "<none>"
+		if passed, ok := smokeTests[clusterName]; ok {
+			smokeTest = "failed"
+			if passed {
+				smokeTest = "passed"
+			}
+		}
+		hookState := "<none>"
+		if succeeded, ok := hooks[clusterName]; ok {
+			hookState = "failed"
+			if succeeded {
+				hookState = "succeeded"
+			}
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", clusterName, connected, health, smokeTest, hookState, message)
+	}
+	return w.Flush()
+}
+
+// installSummary describes how KSIT installs the integration's tool
+func installSummary(install *ksitv1alpha1.InstallConfig) string {
+	if install == nil || !install.Enabled {
+		return "disabled"
+	}
+
+	switch install.Method {
+	case "manifest":
+		return "manifest " + install.ManifestURL
+	default:
+		parts := []string{"helm"}
+		if install.HelmConfig != nil {
+			parts = append(parts, install.HelmConfig.Chart)
+			if install.HelmConfig.Version != "" {
+				parts = append(parts, install.HelmConfig.Version)
+			}
+		} else {
+			parts = append(parts, "(built-in chart)")
+		}
+		if install.Profile != "" {
+			parts = append(parts, "profile "+install.Profile)
+		}
+		return strings.Join(parts, " ")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hubSource labels events recorded on the hub cluster
+const hubSource = "hub"
+
+type eventsOptions struct {
+	clientOptions
+	cluster string
+}
+
+// sourcedEvent is an event together with the cluster it was recorded on
+type sourcedEvent struct {
+	source string
+	event  corev1.Event
+}
+
+func newEventsCommand() *cobra.Command {
+	o := &eventsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show events related to KSIT resources",
+	}
+	o.addFlags(cmd)
+
+	integrationCmd := &cobra.Command{
+		Use:   "integration <name>",
+		Short: "Show the events of an Integration on the hub, and of its tool on a target cluster",
+		Example: `  # Events the controller recorded on the Integration
+  ksit events integration argocd -n ksit-system
+
+  # The same, merged with the events in the Argo CD namespace on cluster1
+  ksit events integration argocd -n ksit-system --cluster cluster1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegration(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	integrationCmd.Flags().StringVar(&o.cluster, "cluster", "", "Also show the events in the integration's namespace on this target cluster")
+
+	cmd.AddCommand(integrationCmd)
+	return cmd
+}
+
+func (o *eventsOptions) runIntegration(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	integration, err := getIntegration(ctx, c, types.NamespacedName{Name: name, Namespace: namespace})
+	if err != nil {
+		return err
+	}
+
+	hubEvents := &corev1.EventList{}
+	if err := c.List(ctx, hubEvents, client.InNamespace(namespace), client.MatchingFields{
+		"involvedObject.kind": "Integration",
+		"involvedObject.name": name,
+	}); err != nil {
+		return fmt.Errorf("failed to list events of integration %s: %w", name, err)
+	}
+
+	var events []sourcedEvent
+	for _, event := range hubEvents.Items {
+		events = append(events, sourcedEvent{source: hubSource, event: event})
+	}
+
+	if o.cluster != "" {
+		kubeClient, err := targetClusterClient(ctx, c, integration, o.cluster)
+		if err != nil {
+			return err
+		}
+		toolEvents, err := kubeClient.CoreV1().Events(toolNamespace(integration)).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list events on %s: %w", o.cluster, err)
+		}
+		for _, event := range toolEvents.Items {
+			events = append(events, sourcedEvent{source: o.cluster, event: event})
+		}
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintf(out, "No events found for integration %s/%s\n", namespace, name)
+		return nil
+	}
+	return printEvents(out, events, time.Now())
+}
+
+// lastSeen returns when an event last occurred, whichever API version recorded it
+func lastSeen(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func printEvents(out io.Writer, events []sourcedEvent, now time.Time) error {
+	sort.SliceStable(events, func(i, j int) bool {
+		return lastSeen(&events[i].event).Before(lastSeen(&events[j].event))
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tSOURCE\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, e := range events {
+		age := now.Sub(lastSeen(&e.event)).Truncate(time.Second)
+		object := fmt.Sprintf("%s/%s", e.event.InvolvedObject.Kind, e.event.InvolvedObject.Name)
+		fmt.Fprintf(w, "%s ago\t%s\t%s\t%s\t%s\t%s\n", age, e.source, e.event.Type, e.event.Reason, object, e.event.Message)
+	}
+	return w.Flush()
+}
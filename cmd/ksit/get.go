@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+type getOptions struct {
+	clientOptions
+	allNamespaces bool
+	selector      string
+}
+
+func newGetCommand() *cobra.Command {
+	o := &getOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "List KSIT resources",
+	}
+	o.addFlags(cmd)
+
+	integrationsCmd := &cobra.Command{
+		Use:     "integrations",
+		Aliases: []string{"integration"},
+		Short:   "List Integrations with their phase, health and clusters",
+		Example: `  # Integrations in the ksit-system namespace
+  ksit get integrations -n ksit-system
+
+  # Production Integrations in every namespace
+  ksit get integrations -A -l env=prod`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegrations(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	integrationsCmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "List Integrations in every namespace")
+	integrationsCmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Only list Integrations matching this label selector")
+
+	cmd.AddCommand(integrationsCmd)
+	return cmd
+}
+
+func (o *getOptions) runIntegrations(ctx context.Context, out io.Writer) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	opts := []client.ListOption{}
+	if !o.allNamespaces {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	if o.selector != "" {
+		selector, err := labels.Parse(o.selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.List(ctx, integrations, opts...); err != nil {
+		return fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	if len(integrations.Items) == 0 {
+		if o.allNamespaces {
+			fmt.Fprintln(out, "No integrations found")
+		} else {
+			fmt.Fprintf(out, "No integrations found in namespace %s\n", namespace)
+		}
+		return nil
+	}
+	return printIntegrations(out, integrations.Items, o.allNamespaces, time.Now())
+}
+
+func printIntegrations(out io.Writer, integrations []ksitv1alpha1.Integration, withNamespace bool, now time.Time) error {
+	sort.Slice(integrations, func(i, j int) bool {
+		if integrations[i].Namespace != integrations[j].Namespace {
+			return integrations[i].Namespace < integrations[j].Namespace
+		}
+		return integrations[i].Name < integrations[j].Name
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if withNamespace {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tTYPE\tPHASE\tHEALTH\tCLUSTERS\tAGE")
+	for i := range integrations {
+		integration := &integrations[i]
+		if withNamespace {
+			fmt.Fprintf(w, "%s\t", integration.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			integration.Name,
+			integration.Spec.Type,
+			valueOrNone(integration.Status.Phase),
+			healthScore(integration),
+			connectedClusters(integration),
+			age(integration.CreationTimestamp.Time, now))
+	}
+	return w.Flush()
+}
+
+// healthScore returns the overall health score of the integration, or <none> before it is scored
+func healthScore(integration *ksitv1alpha1.Integration) string {
+	if integration.Status.Health == nil {
+		return "<none>"
+	}
+	return strconv.Itoa(int(integration.Status.Health.Score))
+}
+
+// connectedClusters returns connected/total target clusters, falling back to the number of
+// targets while the controller has not summarized them yet
+func connectedClusters(integration *ksitv1alpha1.Integration) string {
+	if summary := integration.Status.ClusterSummary; summary != nil {
+		return fmt.Sprintf("%d/%d", summary.Connected, summary.Total)
+	}
+	return strconv.Itoa(len(integration.Targets()))
+}
+
+func age(created, now time.Time) string {
+	if created.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now.Sub(created))
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
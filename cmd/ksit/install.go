@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// fluxManifestURL is installed when a Flux install does not name a manifest
+const fluxManifestURL = "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"
+
+// requiredConfig lists the spec.config keys each integration type cannot do without
+var requiredConfig = map[string][]string{
+	ksitv1alpha1.IntegrationTypeArgoCD:     {"serverURL"},
+	ksitv1alpha1.IntegrationTypePrometheus: {"url"},
+}
+
+type installOptions struct {
+	clientOptions
+	name        string
+	clusters    []string
+	method      string
+	manifestURL string
+	profile     string
+	config      []string
+	dryRun      bool
+}
+
+func newInstallCommand() *cobra.Command {
+	o := &installOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "install <type>",
+		Short: "Create an Integration that installs a tool on target clusters",
+		Long: "install creates an Integration of the given type (argocd, flux, prometheus, istio) with autoInstall enabled.\n" +
+			"The controller installs the tool with its built-in chart or manifest unless --method or --manifest-url say otherwise.",
+		Example: `  # Install Flux on two clusters
+  ksit install flux --cluster cluster1 --cluster cluster2 -n ksit-system
+
+  # Install Argo CD and tell KSIT where its API server is
+  ksit install argocd --cluster cluster1 --config serverURL=https://argocd-server.argocd.svc
+
+  # Print the Integration instead of creating it
+  ksit install istio --cluster cluster1 --profile default --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	o.addFlags(cmd)
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.name, "name", "", "Name of the Integration (defaults to the type)")
+	flags.StringSliceVar(&o.clusters, "cluster", nil, "Target cluster to install on; repeat for several clusters")
+	flags.StringVar(&o.method, "method", "", "Install method, helm or manifest (defaults to manifest for flux and helm otherwise)")
+	flags.StringVar(&o.manifestURL, "manifest-url", "", "Manifest to apply when the method is manifest")
+	flags.StringVar(&o.profile, "profile", "", "Install profile, for types that have profiles such as istio")
+	flags.StringArrayVar(&o.config, "config", nil, "spec.config entry as key=value; repeat for several entries")
+	flags.BoolVar(&o.dryRun, "dry-run", false, "Print the Integration as YAML instead of creating it")
+	_ = cmd.MarkFlagRequired("cluster")
+
+	return cmd
+}
+
+func (o *installOptions) run(ctx context.Context, out io.Writer, integrationType string) error {
+	integration, err := o.buildIntegration(integrationType)
+	if err != nil {
+		return err
+	}
+
+	if o.dryRun {
+		if integration.Namespace == "" {
+			namespace, _, err := o.clientConfig().Namespace()
+			if err != nil {
+				return fmt.Errorf("failed to resolve namespace: %w", err)
+			}
+			integration.Namespace = namespace
+		}
+		data, err := yaml.Marshal(integration)
+		if err != nil {
+			return fmt.Errorf("failed to marshal integration: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	integration.Namespace = namespace
+
+	if err := c.Create(ctx, integration); err != nil {
+		return fmt.Errorf("failed to create integration %s: %w", integration.Name, err)
+	}
+
+	fmt.Fprintf(out, "integration %s/%s created; follow it with: ksit describe integration %s -n %s\n",
+		namespace, integration.Name, integration.Name, namespace)
+	return nil
+}
+
+// buildIntegration turns the flags into an Integration with autoInstall enabled
+func (o *installOptions) buildIntegration(integrationType string) (*ksitv1alpha1.Integration, error) {
+	defaults, ok := keyPods[integrationType]
+	if !ok {
+		return nil, fmt.Errorf("unknown integration type %s, must be one of: %s", integrationType, strings.Join(integrationTypes(), ", "))
+	}
+
+	config, err := parseKeyValues(o.config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --config: %w", err)
+	}
+	if config["namespace"] == "" {
+		config["namespace"] = defaults.namespace
+	}
+	for _, key := range requiredConfig[integrationType] {
+		if config[key] == "" {
+			return nil, fmt.Errorf("%s integrations require --config %s=<value>", integrationType, key)
+		}
+	}
+
+	method := o.method
+	if method == "" {
+		method = "helm"
+		if integrationType == ksitv1alpha1.IntegrationTypeFlux || o.manifestURL != "" {
+			method = "manifest"
+		}
+	}
+	install := &ksitv1alpha1.InstallConfig{
+		Enabled: true,
+		Method:  method,
+		Profile: o.profile,
+	}
+	switch method {
+	case "helm":
+		if o.manifestURL != "" {
+			return nil, fmt.Errorf("--manifest-url requires --method manifest")
+		}
+	case "manifest":
+		install.ManifestURL = o.manifestURL
+		if install.ManifestURL == "" {
+			if integrationType != ksitv1alpha1.IntegrationTypeFlux {
+				return nil, fmt.Errorf("--manifest-url is required to install %s from a manifest", integrationType)
+			}
+			install.ManifestURL = fluxManifestURL
+		}
+	default:
+		return nil, fmt.Errorf("unknown install method %s, must be helm or manifest", method)
+	}
+
+	name := o.name
+	if name == "" {
+		name = integrationType
+	}
+
+	return &ksitv1alpha1.Integration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: ksitv1alpha1.GroupVersion.String(),
+			Kind:       "Integration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: o.namespace,
+		},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           integrationType,
+			Enabled:        true,
+			TargetClusters: o.clusters,
+			Config:         config,
+			AutoInstall:    install,
+		},
+	}, nil
+}
+
+func integrationTypes() []string {
+	types := make([]string, 0, len(keyPods))
+	for integrationType := range keyPods {
+		types = append(types, integrationType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// parseKeyValues parses key=value pairs; later pairs win
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not key=value", pair)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
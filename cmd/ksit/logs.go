@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+// keyPods locates the pods of each integration type on target clusters. The namespaces
+// are the defaults the controller checks when spec.config has no namespace.
+var keyPods = map[string]struct {
+	namespace string
+	selector  string
+}{
+	ksitv1alpha1.IntegrationTypeArgoCD:      {namespace: "argocd", selector: "app.kubernetes.io/part-of=argocd"},
+	ksitv1alpha1.IntegrationTypeFlux:        {namespace: "flux-system", selector: "app.kubernetes.io/part-of=flux"},
+	ksitv1alpha1.IntegrationTypePrometheus:  {namespace: "monitoring", selector: "app.kubernetes.io/name=prometheus"},
+	ksitv1alpha1.IntegrationTypeIstio:       {namespace: "istio-system", selector: "app=istiod"},
+	ksitv1alpha1.IntegrationTypeGrafana:     {namespace: "grafana", selector: "app.kubernetes.io/name=grafana"},
+	ksitv1alpha1.IntegrationTypeCertManager: {namespace: "cert-manager", selector: "app.kubernetes.io/instance=cert-manager"},
+	ksitv1alpha1.IntegrationTypeKyverno:     {namespace: "kyverno", selector: "app.kubernetes.io/part-of=kyverno"},
+}
+
+// toolNamespace returns the namespace the integration's tool runs in on target clusters
+func toolNamespace(integration *ksitv1alpha1.Integration) string {
+	if install := integration.Spec.AutoInstall; install != nil && install.Namespace != nil && install.Namespace.Name != "" {
+		return install.Namespace.Name
+	}
+	// The controller always checks Istio in istio-system
+	if ns := integration.Spec.Config["namespace"]; ns != "" && integration.Spec.Type != ksitv1alpha1.IntegrationTypeIstio {
+		return ns
+	}
+	return keyPods[integration.Spec.Type].namespace
+}
+
+type logsOptions struct {
+	clientOptions
+	cluster   string
+	selector  string
+	container string
+	tail      int64
+	since     time.Duration
+	previous  bool
+}
+
+func newLogsCommand() *cobra.Command {
+	o := &logsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show logs of the tools KSIT integrates on target clusters",
+	}
+	o.addFlags(cmd)
+
+	integrationCmd := &cobra.Command{
+		Use:   "integration <name>",
+		Short: "Show the logs of an Integration's key pods on a target cluster",
+		Example: `  # Last 100 lines of every Argo CD pod on cluster1
+  ksit logs integration argocd -n ksit-system --cluster cluster1
+
+  # Logs of the crashed istiod container from the last hour
+  ksit logs integration istio --cluster edge-2 --previous --since 1h`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegration(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	flags := integrationCmd.Flags()
+	flags.StringVar(&o.cluster, "cluster", "", "Target cluster to read logs from; required when the Integration has several")
+	flags.StringVarP(&o.selector, "selector", "l", "", "Label selector of the pods (defaults to the key pods of the integration type)")
+	flags.StringVarP(&o.container, "container", "c", "", "Only show logs of this container")
+	flags.Int64Var(&o.tail, "tail", 100, "Lines of recent log to show per container; -1 shows all")
+	flags.DurationVar(&o.since, "since", 0, "Only show logs newer than this duration, e.g. 1h")
+	flags.BoolVarP(&o.previous, "previous", "p", false, "Show logs of the previous instance of each container")
+
+	cmd.AddCommand(integrationCmd)
+	return cmd
+}
+
+func (o *logsOptions) runIntegration(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	integration, err := getIntegration(ctx, c, types.NamespacedName{Name: name, Namespace: namespace})
+	if err != nil {
+		return err
+	}
+
+	clusterName := o.cluster
+	if clusterName == "" {
+		if len(integration.Targets()) != 1 {
+			return fmt.Errorf("--cluster is required; integration %s targets %s", name, strings.Join(integration.Targets(), ", "))
+		}
+		clusterName = integration.Targets()[0]
+	}
+
+	kubeClient, err := targetClusterClient(ctx, c, integration, clusterName)
+	if err != nil {
+		return err
+	}
+
+	selector := o.selector
+	if selector == "" {
+		selector = keyPods[integration.Spec.Type].selector
+	}
+	podNamespace := toolNamespace(integration)
+
+	pods, err := kubeClient.CoreV1().Pods(podNamespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list pods on %s: %w", clusterName, err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no pods match %q in namespace %s on %s", selector, podNamespace, clusterName)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+	failed := 0
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if o.container != "" && container.Name != o.container {
+				continue
+			}
+			fmt.Fprintf(out, "==> %s/%s/%s <==\n", clusterName, pod.Name, container.Name)
+			if err := o.copyLogs(ctx, out, kubeClient, &pod, container.Name); err != nil {
+				fmt.Fprintf(out, "error: %v\n", err)
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to read logs of %d containers", failed)
+	}
+	return nil
+}
+
+func (o *logsOptions) copyLogs(ctx context.Context, out io.Writer, kubeClient kubernetes.Interface, pod *corev1.Pod, container string) error {
+	opts := &corev1.PodLogOptions{Container: container, Previous: o.previous}
+	if o.tail >= 0 {
+		opts.TailLines = &o.tail
+	}
+	if o.since > 0 {
+		seconds := int64(o.since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+
+	stream, err := kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintln(out, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func getIntegration(ctx context.Context, c client.Client, key types.NamespacedName) (*ksitv1alpha1.Integration, error) {
+	integration := &ksitv1alpha1.Integration{}
+	if err := c.Get(ctx, key, integration); err != nil {
+		return nil, fmt.Errorf("failed to get integration %s: %w", key, err)
+	}
+	return integration, nil
+}
+
+// targetClusterClient connects to a target cluster of the Integration using the
+// kubeconfig of its IntegrationTarget on the hub
+func targetClusterClient(ctx context.Context, c client.Client, integration *ksitv1alpha1.Integration, clusterName string) (kubernetes.Interface, error) {
+	if !containsString(integration.Targets(), clusterName) {
+		return nil, fmt.Errorf("cluster %s is not a target of integration %s", clusterName, integration.Name)
+	}
+
+	namespace := integration.Namespace
+	if integration.ClusterScoped() {
+		namespace = ""
+	}
+	cm := cluster.NewClusterManager(c)
+	skipped, err := cm.LoadTargets(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := skipped[clusterName]; ok {
+		return nil, fmt.Errorf("failed to connect to cluster %s: %w", clusterName, err)
+	}
+	target, err := cm.GetIntegrationCluster(clusterName, integration)
+	if err != nil {
+		return nil, err
+	}
+	return target.Client, nil
+}
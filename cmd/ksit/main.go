@@ -1,24 +1,41 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"net"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
 	internalwebhook "github.com/kubestellar/integration-toolkit/internal/webhook"
+	"github.com/kubestellar/integration-toolkit/pkg/apiserver"
 	"github.com/kubestellar/integration-toolkit/pkg/cluster"
 	"github.com/kubestellar/integration-toolkit/pkg/config"
 	"github.com/kubestellar/integration-toolkit/pkg/controller"
 	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/factory"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+	"github.com/kubestellar/integration-toolkit/pkg/kubestellar"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/version"
 )
 
 var (
@@ -32,29 +49,91 @@ func init() {
 }
 
 func main() {
-	var configFile string
-	var metricsAddr string
-	var enableLeaderElection bool
-	var probeAddr string
-	var enableWebhook bool
-	var webhookPort int
-	var certDir string
-
-	flag.StringVar(&configFile, "config", "", "Path to configuration file")
-	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
-	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
-	flag.BoolVar(&enableWebhook, "enable-webhook", false, "Enable validating webhooks.")
-	flag.IntVar(&webhookPort, "webhook-port", 9443, "Webhook server port.")
-	flag.StringVar(&certDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Webhook certificate directory.")
-
-	opts := zap.Options{
-		Development: true,
-	}
-	opts.BindFlags(flag.CommandLine)
-	flag.Parse()
-
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// managerOptions holds the flags of the controller manager (the root command)
+type managerOptions struct {
+	configFile           string
+	metricsAddr          string
+	enableLeaderElection bool
+	probeAddr            string
+	enableWebhook        bool
+	webhookPort          int
+	certDir              string
+	featureGates         map[string]string
+	inventoryAddr        string
+	zapOpts              zap.Options
+}
+
+// newRootCommand builds the ksit command. Without a subcommand it runs the controller manager,
+// so existing deployments that start the binary with manager flags keep working.
+func newRootCommand() *cobra.Command {
+	o := &managerOptions{
+		zapOpts: zap.Options{
+			Development: true,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:           "ksit",
+		Short:         "KubeStellar Integration Toolkit",
+		Long:          "ksit runs the KSIT controller manager, or operates on Integrations when given a subcommand.",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Run: func(cmd *cobra.Command, args []string) {
+			runManager(o)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.configFile, "config", "", "Path to configuration file")
+	flags.StringVar(&o.metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flags.StringVar(&o.probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flags.BoolVar(&o.enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
+	flags.BoolVar(&o.enableWebhook, "enable-webhook", false, "Enable validating and defaulting webhooks.")
+	flags.IntVar(&o.webhookPort, "webhook-port", 9443, "Webhook server port.")
+	flags.StringVar(&o.certDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Webhook certificate directory.")
+	flags.StringVar(&o.inventoryAddr, "inventory-bind-address", "", "The address the fleet API (clusters, integrations and topology) binds to. Empty disables it.")
+	flags.StringToStringVar(&o.featureGates, "feature-gates", nil, "Feature gates to turn on or off, e.g. OnlineChartValidation=true. Overrides the config file.")
+
+	// zap and controller-runtime (--kubeconfig) register standard library flags
+	o.zapOpts.BindFlags(flag.CommandLine)
+	flags.AddGoFlagSet(flag.CommandLine)
+
+	cmd.AddCommand(newGetCommand())
+	cmd.AddCommand(newDescribeCommand())
+	cmd.AddCommand(newClustersCommand())
+	cmd.AddCommand(newOnboardCommand())
+	cmd.AddCommand(newInstallCommand())
+	cmd.AddCommand(newSyncCommand())
+	cmd.AddCommand(newVersionCommand())
+	cmd.AddCommand(newUpgradeCommand())
+	cmd.AddCommand(newUninstallCommand())
+	cmd.AddCommand(newRolloutCommand())
+	cmd.AddCommand(newEventsCommand())
+	cmd.AddCommand(newLogsCommand())
+	cmd.AddCommand(newTopologyCommand())
+	cmd.AddCommand(newReportCommand())
+
+	return cmd
+}
+
+func runManager(o *managerOptions) {
+	configFile := o.configFile
+	metricsAddr := o.metricsAddr
+	enableLeaderElection := o.enableLeaderElection
+	probeAddr := o.probeAddr
+	enableWebhook := o.enableWebhook
+	webhookPort := o.webhookPort
+	certDir := o.certDir
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&o.zapOpts)))
+
+	buildInfo := version.Get()
+	setupLog.Info("starting ksit", "version", buildInfo.Version, "commit", buildInfo.GitCommit, "buildDate", buildInfo.BuildDate)
 
 	// Load config
 	var cfg *config.Config
@@ -69,6 +148,17 @@ func main() {
 		cfg = config.NewDefaultConfig()
 	}
 
+	if err := cfg.SetFeatureGates(o.featureGates); err != nil {
+		setupLog.Error(err, "invalid --feature-gates")
+		os.Exit(1)
+	}
+
+	if err := prometheus.Configure(cfg.Metrics); err != nil {
+		setupLog.Error(err, "unable to configure metrics")
+		os.Exit(1)
+	}
+	prometheus.SetBuildInfo(buildInfo.Version, buildInfo.GitCommit, buildInfo.GoVersion)
+
 	// Use config values
 	if metricsAddr == ":8080" && cfg.MetricsAddr != "" {
 		metricsAddr = cfg.MetricsAddr
@@ -101,14 +191,38 @@ func main() {
 
 	// ✅ CREATE SHARED COMPONENTS
 	clusterManager := cluster.NewClusterManager(mgr.GetClient())
+	clusterManager.RestrictClusterScope(cfg.ClusterScopeNamespaces)
 	clusterInventory := cluster.NewClusterInventory()
+	clusterInventory.Track(clusterManager)
+	clusterWarmup := controller.NewClusterWarmup(clusterManager, ctrl.Log.WithName("Warmup"))
+	if err := mgr.Add(clusterWarmup); err != nil {
+		setupLog.Error(err, "unable to add cluster warm-up")
+		os.Exit(1)
+	}
 	installerFactory := installer.NewInstallerFactory() // ✅ INITIALIZE INSTALLER FACTORY
 
+	history, err := ledger.NewHistoryStore(context.Background(), cfg.History)
+	if err != nil {
+		setupLog.Error(err, "unable to set up install history store", "backend", cfg.History.Backend)
+		os.Exit(1)
+	}
+	installLedger := ledger.NewLedger(mgr.GetClient())
+	installLedger.History = history
+
 	setupLog.Info("initialized shared components",
 		"clusterManager", "ready",
 		"clusterInventory", "ready",
 		"installerFactory", "ready")
 
+	integrationClients := factory.New(clusterManager, mgr.GetScheme(), ctrl.Log.WithName("Integration"))
+	integrationClients.Secrets = mgr.GetClient()
+
+	wds, its, err := kubeStellarClients(mgr, cfg.KubeStellar)
+	if err != nil {
+		setupLog.Error(err, "unable to create KubeStellar clients")
+		os.Exit(1)
+	}
+
 	// Setup Integration reconciler
 	integrationReconciler := &controller.IntegrationReconciler{
 		Client:           mgr.GetClient(),
@@ -117,6 +231,39 @@ func main() {
 		ClusterManager:   clusterManager,
 		ClusterInventory: clusterInventory,
 		InstallerFactory: installerFactory, // ✅ NOW INITIALIZED
+		Ledger:           installLedger,
+		Clients:          integrationClients,
+		Recorder:         mgr.GetEventRecorderFor("ksit-integration-controller"),
+
+		MaxConcurrentClusters: cfg.Reconcile.MaxConcurrentClusters,
+		RetryBudget:           cfg.Reconcile.RetryBudget,
+		HealthSummaryInterval: cfg.Reconcile.HealthSummaryInterval,
+		Interval:              cfg.Reconcile.Interval,
+		RequeueBudget:         cfg.Reconcile.RequeueBudget,
+
+		ClusterScopeNamespaces: cfg.ClusterScopeNamespaces,
+		WDS:                    wds,
+		ITS:                    its,
+		Warmup:                 clusterWarmup,
+	}
+	if cfg.Reconcile.RetryCount > 0 {
+		integrationReconciler.Retry = &utils.RetryConfig{
+			MaxAttempts:    cfg.Reconcile.RetryCount + 1,
+			InitialDelay:   cfg.Reconcile.RetryBackoff,
+			MaxDelay:       10 * cfg.Reconcile.RetryBackoff,
+			BackoffFactor:  2,
+			Jitter:         0.2,
+			AttemptTimeout: cfg.Reconcile.RetryAttemptTimeout,
+		}
+	}
+
+	if cfg.Reconcile.FailureBackoff > 0 {
+		integrationReconciler.FailureBackoff = &utils.RetryConfig{
+			InitialDelay:  cfg.Reconcile.FailureBackoff,
+			MaxDelay:      cfg.Reconcile.MaxFailureBackoff,
+			BackoffFactor: 2,
+			Jitter:        0.2,
+		}
 	}
 
 	if err := integrationReconciler.SetupWithManager(mgr); err != nil {
@@ -130,6 +277,8 @@ func main() {
 		Scheme:         mgr.GetScheme(),
 		Log:            ctrl.Log.WithName("IntegrationTarget"),
 		ClusterManager: clusterManager,
+		Recorder:       mgr.GetEventRecorderFor("ksit-integrationtarget-controller"),
+		Interval:       cfg.Reconcile.Interval,
 	}
 
 	if err := targetReconciler.SetupWithManager(mgr); err != nil {
@@ -137,18 +286,53 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup UpgradeCampaign reconciler
+	campaignReconciler := &controller.UpgradeCampaignReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Log:              ctrl.Log.WithName("UpgradeCampaign"),
+		ClusterManager:   clusterManager,
+		InstallerFactory: installerFactory,
+		Ledger:           installLedger,
+		Recorder:         mgr.GetEventRecorderFor("ksit-upgradecampaign-controller"),
+		Warmup:           clusterWarmup,
+	}
+
+	if err := campaignReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create UpgradeCampaign controller")
+		os.Exit(1)
+	}
+
+	// Publish the metrics endpoint to the hub's Prometheus
+	if err := setupSelfMonitoring(mgr, metricsAddr, cfg.Metrics.SelfMonitoring); err != nil {
+		setupLog.Error(err, "unable to create self-monitoring controller")
+		os.Exit(1)
+	}
+	if err := setupMetricsTextfile(mgr, cfg.Metrics.Textfile); err != nil {
+		setupLog.Error(err, "unable to set up metrics textfile export")
+		os.Exit(1)
+	}
+
 	// Setup webhooks if enabled
 	if enableWebhook {
-		integrationValidator := internalwebhook.NewIntegrationValidator(mgr.GetClient())
+		integrationValidator := internalwebhook.NewIntegrationValidator(mgr.GetClient(), mgr.GetScheme())
+		integrationValidator.ClusterScopeNamespaces = cfg.ClusterScopeNamespaces
+		integrationValidator.DenyUnregisteredClusters = cfg.FeatureEnabled(config.FeatureStrictTargetValidation)
+		if cfg.FeatureEnabled(config.FeatureOnlineChartValidation) {
+			integrationValidator.Charts = internalwebhook.NewChartChecker()
+			setupLog.Info("validating Helm charts against their repositories")
+		}
+		integrationDefaulter := internalwebhook.NewIntegrationDefaulter(cfg.AutoInstallDefaults)
 		if err := ctrl.NewWebhookManagedBy(mgr).
 			For(&ksitv1alpha1.Integration{}).
+			WithDefaulter(integrationDefaulter).
 			WithValidator(integrationValidator).
 			Complete(); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Integration")
 			os.Exit(1)
 		}
 
-		targetValidator := internalwebhook.NewIntegrationTargetValidator(mgr.GetClient())
+		targetValidator := internalwebhook.NewIntegrationTargetValidator(mgr.GetClient(), mgr.GetScheme())
 		if err := ctrl.NewWebhookManagedBy(mgr).
 			For(&ksitv1alpha1.IntegrationTarget{}).
 			WithValidator(targetValidator).
@@ -158,6 +342,33 @@ func main() {
 		}
 	}
 
+	// Fleet API for dashboards
+	if o.inventoryAddr != "" {
+		apiServer, err := apiserver.NewServer(o.inventoryAddr, cfg.API, clusterInventory, mgr.GetClient(), ctrl.Log.WithName("FleetAPI"))
+		if err != nil {
+			setupLog.Error(err, "unable to set up fleet API server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(apiServer); err != nil {
+			setupLog.Error(err, "unable to add fleet API server")
+			os.Exit(1)
+		}
+	}
+
+	// Kubeconfigs written for Helm hold credentials; remove those a crash left behind
+	if removed, err := installer.SweepTempKubeconfigs(); err != nil {
+		setupLog.Error(err, "unable to sweep temp kubeconfigs")
+	} else if removed > 0 {
+		setupLog.Info("removed stale temp kubeconfigs", "count", removed)
+	}
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		installer.RunTempKubeconfigJanitor(ctx, installer.DefaultTempFileSweepInterval, ctrl.Log.WithName("Janitor"))
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to add temp kubeconfig janitor")
+		os.Exit(1)
+	}
+
 	// Health/ready checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -167,6 +378,28 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("clusters", clusterWarmup.Checker); err != nil {
+		setupLog.Error(err, "unable to set up cluster warm-up check")
+		os.Exit(1)
+	}
+	if cfg.Readiness.MinConnectedPercent > 0 {
+		connectivityGate := &controller.ConnectivityGate{
+			Client:              mgr.GetClient(),
+			ClusterManager:      clusterManager,
+			Warmup:              clusterWarmup,
+			Log:                 ctrl.Log.WithName("Readiness"),
+			MinConnectedPercent: cfg.Readiness.MinConnectedPercent,
+			StartupTimeout:      cfg.Readiness.StartupTimeout,
+		}
+		if err := mgr.Add(connectivityGate); err != nil {
+			setupLog.Error(err, "unable to add cluster connectivity gate")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("cluster-connectivity", connectivityGate.Checker); err != nil {
+			setupLog.Error(err, "unable to set up cluster connectivity check")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -174,3 +407,79 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// setupSelfMonitoring starts the controller that creates the metrics Service and
+// ServiceMonitor, unless it is disabled, metrics are off or the namespace is unknown
+func setupSelfMonitoring(mgr ctrl.Manager, metricsAddr string, cfg config.SelfMonitoringConfig) error {
+	namespace := os.Getenv("POD_NAMESPACE")
+	if cfg.Disabled || metricsAddr == "0" || namespace == "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(metricsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to parse metrics address %q: %w", metricsAddr, err)
+	}
+	metricsPort, err := strconv.ParseInt(port, 10, 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse metrics port %q: %w", port, err)
+	}
+
+	reconciler := &controller.SelfMonitorReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("SelfMonitor"),
+		Namespace:   namespace,
+		MetricsPort: int32(metricsPort),
+		Config:      cfg,
+	}
+	return reconciler.SetupWithManager(mgr)
+}
+
+// setupMetricsTextfile starts writing the metrics to a textfile collector directory
+// and/or an S3 object, when either is configured
+func setupMetricsTextfile(mgr ctrl.Manager, cfg config.TextfileConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	exporter := &prometheus.TextfileExporter{
+		Path:        cfg.Path,
+		OpenMetrics: cfg.Format == "openmetrics",
+		Interval:    cfg.Interval,
+		Log:         ctrl.Log.WithName("Textfile"),
+	}
+	if cfg.S3.Bucket != "" {
+		store, err := ledger.NewS3Store(cfg.S3)
+		if err != nil {
+			return err
+		}
+		exporter.Objects = store
+		exporter.Key = path.Join(strings.Trim(cfg.S3.Prefix, "/"), "ksit.prom")
+	}
+	return mgr.Add(exporter)
+}
+
+// kubeStellarClients returns the clients of the KubeStellar WDS and ITS that Integrations
+// read their delivery status from. The WDS defaults to the cluster the controller runs in;
+// a nil ITS means the WDS.
+func kubeStellarClients(mgr ctrl.Manager, cfg config.KubeStellarConfig) (wds, its *kubestellar.KubeStellarClient, err error) {
+	wdsConfig := mgr.GetConfig()
+	if cfg.WDSKubeconfig != "" {
+		if wdsConfig, err = clientcmd.BuildConfigFromFlags("", cfg.WDSKubeconfig); err != nil {
+			return nil, nil, fmt.Errorf("failed to load WDS kubeconfig: %w", err)
+		}
+	}
+	if wds, err = kubestellar.NewKubeStellarClient(wdsConfig, mgr.GetScheme()); err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.ITSKubeconfig == "" {
+		return wds, nil, nil
+	}
+	itsConfig, err := clientcmd.BuildConfigFromFlags("", cfg.ITSKubeconfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load ITS kubeconfig: %w", err)
+	}
+	if its, err = kubestellar.NewKubeStellarClient(itsConfig, mgr.GetScheme()); err != nil {
+		return nil, nil, err
+	}
+	return wds, its, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubestellar/integration-toolkit/pkg/sdk"
+)
+
+type onboardOptions struct {
+	clientOptions
+	fleetFile       string
+	dryRun          bool
+	continueOnError bool
+}
+
+func newOnboardCommand() *cobra.Command {
+	o := &onboardOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "onboard",
+		Short: "Register many target clusters at once from a fleet file",
+		Long: "onboard creates or updates the kubeconfig Secret and IntegrationTarget of every cluster in a fleet file.\n" +
+			"Every cluster is validated before anything is written, and when one fails the clusters onboarded before it\n" +
+			"are rolled back, unless --continue-on-error is set. Files ending in .csv are read as CSV with a header row of\n" +
+			"name, kubeconfig, context, namespace and labels (key=value;key=value); others as YAML.",
+		Example: `  # fleet.yaml
+  defaults:
+    kubeconfig: edge-fleet.kubeconfig   # relative to the fleet file
+    labels: {tier: edge}
+  clusters:
+  - name: edge-001                      # uses the edge-001 context if there is one
+  - name: edge-002
+    context: edge-002-admin
+    labels: {region: eu}
+
+  # Check the fleet against the hub without writing anything
+  ksit onboard --fleet-file fleet.yaml -n ksit-system --dry-run
+
+  # Onboard it
+  ksit onboard --fleet-file fleet.yaml -n ksit-system`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd)
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.fleetFile, "fleet-file", "", "YAML or CSV file listing the clusters to onboard")
+	flags.BoolVar(&o.dryRun, "dry-run", false, "Validate the fleet and report what would change, without writing anything")
+	flags.BoolVar(&o.continueOnError, "continue-on-error", false, "Onboard the remaining clusters when one fails instead of rolling back")
+	_ = cmd.MarkFlagRequired("fleet-file")
+
+	return cmd
+}
+
+func (o *onboardOptions) run(ctx context.Context, out io.Writer) error {
+	regs, err := sdk.LoadFleet(o.fleetFile)
+	if err != nil {
+		return fmt.Errorf("invalid fleet file, nothing was onboarded:\n%w", err)
+	}
+
+	c, namespace, err := o.newSDKClient()
+	if err != nil {
+		return err
+	}
+
+	mode := ""
+	if o.dryRun {
+		mode = " (dry run)"
+	}
+	fmt.Fprintf(out, "Onboarding %d clusters into namespace %s%s\n", len(regs), namespace, mode)
+
+	width := len(strconv.Itoa(len(regs)))
+	result, err := c.OnboardFleet(ctx, regs, sdk.OnboardOptions{
+		DryRun:          o.dryRun,
+		ContinueOnError: o.continueOnError,
+		Progress: func(done, total int, cluster sdk.ClusterResult) {
+			line := fmt.Sprintf("[%*d/%d] %s %s", width, done, total, cluster.Name, cluster.Action)
+			if cluster.Err != nil {
+				line += ": " + cluster.Err.Error()
+			}
+			fmt.Fprintln(out, line)
+		},
+	})
+	if result == nil {
+		return err
+	}
+
+	for _, cluster := range result.Clusters {
+		if cluster.Err != nil && cluster.Action != sdk.OnboardFailed {
+			fmt.Fprintf(out, "%s: %v\n", cluster.Name, cluster.Err)
+		}
+	}
+	fmt.Fprintln(out, onboardSummary(result))
+	return err
+}
+
+// onboardSummary counts the clusters by what happened to them, e.g.
+// "3 clusters: 2 created, 1 updated"
+func onboardSummary(result *sdk.OnboardResult) string {
+	var counts []string
+	for _, action := range []sdk.OnboardAction{sdk.OnboardCreated, sdk.OnboardUpdated, sdk.OnboardUnchanged,
+		sdk.OnboardFailed, sdk.OnboardRolledBack, sdk.OnboardSkipped} {
+		if count := result.Count(action); count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count, action))
+		}
+	}
+	return fmt.Sprintf("%d clusters: %s", len(result.Clusters), strings.Join(counts, ", "))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/report"
+)
+
+type reportOptions struct {
+	clientOptions
+	allNamespaces bool
+	output        string
+	since         time.Duration
+	historyFile   string
+	outputFile    string
+}
+
+func newReportCommand() *cobra.Command {
+	o := &reportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render a fleet report of clusters, integration versions, health, pending upgrades and recent changes",
+		Example: `  # Weekly report of the ksit-system namespace, as Markdown
+  ksit report -n ksit-system
+
+  # The whole fleet over the last 30 days, as HTML, with changes from the install history
+  ksit report -A --since 720h --history-file /var/lib/ksit/history.jsonl -o html --output-file fleet.html`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Report on every namespace")
+	cmd.Flags().StringVarP(&o.output, "output", "o", report.FormatMarkdown, "Output format: markdown, json or html")
+	cmd.Flags().DurationVar(&o.since, "since", 7*24*time.Hour, "How far back recent changes go")
+	cmd.Flags().StringVar(&o.historyFile, "history-file", "", "Install history written by the file history backend. Without it, only the last action per integration and cluster is known")
+	cmd.Flags().StringVar(&o.outputFile, "output-file", "", "Write the report to this file instead of stdout")
+
+	return cmd
+}
+
+func (o *reportOptions) run(ctx context.Context, out io.Writer) error {
+	switch o.output {
+	case report.FormatMarkdown, report.FormatJSON, report.FormatHTML:
+	default:
+		return fmt.Errorf("unsupported output format %q, must be markdown, json or html", o.output)
+	}
+	if o.since <= 0 {
+		return fmt.Errorf("--since must be positive")
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	if o.allNamespaces {
+		namespace = ""
+	}
+
+	opts := report.Options{Namespace: namespace, Now: time.Now(), Period: o.since}
+	if o.historyFile != "" {
+		history, err := ledger.ReadHistoryFile(o.historyFile)
+		if err != nil {
+			return err
+		}
+		// An empty history still means the changes come from it
+		opts.History = append([]ledger.HistoryRecord{}, history...)
+	}
+
+	fleetReport, err := report.Build(ctx, c, opts)
+	if err != nil {
+		return err
+	}
+
+	if o.outputFile == "" {
+		return report.Render(out, fleetReport, o.output)
+	}
+	f, err := os.Create(o.outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", o.outputFile, err)
+	}
+	if err := report.Render(f, fleetReport, o.output); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", o.outputFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/health"
+	"github.com/kubestellar/integration-toolkit/pkg/kubestellar"
+)
+
+// workloadKinds maps the kind names accepted on the command line to workload kinds
+var workloadKinds = map[string]string{
+	"deployment":  health.KindDeployment,
+	"deploy":      health.KindDeployment,
+	"statefulset": health.KindStatefulSet,
+	"sts":         health.KindStatefulSet,
+	"daemonset":   health.KindDaemonSet,
+	"ds":          health.KindDaemonSet,
+	"job":         health.KindJob,
+}
+
+type rolloutOptions struct {
+	clientOptions
+	integration       string
+	bindingPolicy     string
+	workloadNamespace string
+	wait              bool
+	timeout           time.Duration
+}
+
+func newRolloutCommand() *cobra.Command {
+	o := &rolloutOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "Inspect workloads distributed to target clusters",
+	}
+	o.addFlags(cmd)
+
+	statusCmd := &cobra.Command{
+		Use:   "status <kind>/<name>",
+		Short: "Show the readiness of a workload on every cluster of an Integration or BindingPolicy",
+		Example: `  # Wait for a Deployment to be ready on every target cluster of an Integration
+  ksit rollout status deployment/web --integration argocd -n ksit-system --workload-namespace shop
+
+  # Show a DaemonSet on the clusters selected by a BindingPolicy without waiting
+  ksit rollout status ds/node-agent --binding-policy edge-agents --workload-namespace monitoring --wait=false`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runStatus(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	statusCmd.Flags().StringVar(&o.integration, "integration", "", "Check the target clusters of this Integration")
+	statusCmd.Flags().StringVar(&o.bindingPolicy, "binding-policy", "", "Check the clusters selected by this KubeStellar BindingPolicy")
+	statusCmd.Flags().StringVar(&o.workloadNamespace, "workload-namespace", "default", "Namespace of the workload on the target clusters")
+	statusCmd.Flags().BoolVar(&o.wait, "wait", true, "Wait until the workload is ready on every cluster or the timeout expires")
+	statusCmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "How long to wait when --wait is set")
+	statusCmd.MarkFlagsMutuallyExclusive("integration", "binding-policy")
+
+	cmd.AddCommand(statusCmd)
+	return cmd
+}
+
+func parseWorkload(ref, namespace string) (health.Workload, error) {
+	kind, name, ok := strings.Cut(ref, "/")
+	if !ok || name == "" {
+		return health.Workload{}, fmt.Errorf("workload must be given as <kind>/<name>, got %q", ref)
+	}
+	workloadKind, ok := workloadKinds[strings.ToLower(kind)]
+	if !ok {
+		return health.Workload{}, fmt.Errorf("unsupported workload kind %q (use deployment, statefulset, daemonset or job)", kind)
+	}
+	return health.Workload{Kind: workloadKind, Namespace: namespace, Name: name}, nil
+}
+
+func (o *rolloutOptions) runStatus(ctx context.Context, out io.Writer, ref string) error {
+	if o.integration == "" && o.bindingPolicy == "" {
+		return fmt.Errorf("one of --integration or --binding-policy is required")
+	}
+
+	workload, err := parseWorkload(ref, o.workloadNamespace)
+	if err != nil {
+		return err
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	// A cluster-scoped Integration may target clusters registered in any namespace
+	var integration *ksitv1alpha1.Integration
+	targetNamespace := namespace
+	if o.integration != "" {
+		integration, err = getIntegration(ctx, c, types.NamespacedName{Name: o.integration, Namespace: namespace})
+		if err != nil {
+			return err
+		}
+		if integration.ClusterScoped() {
+			targetNamespace = ""
+		}
+	}
+
+	cm := cluster.NewClusterManager(c)
+	skipped, err := cm.LoadTargets(ctx, targetNamespace)
+	if err != nil {
+		return err
+	}
+
+	clusterNames, err := o.selectClusters(ctx, c, cm, namespace, integration)
+	if err != nil {
+		return err
+	}
+	if len(clusterNames) == 0 {
+		return fmt.Errorf("no clusters selected")
+	}
+
+	clients := make(map[string]client.Client, len(clusterNames))
+	for _, name := range clusterNames {
+		if _, ok := skipped[name]; ok {
+			continue
+		}
+		clusterNamespace := namespace
+		if integration != nil {
+			registered, err := cm.GetIntegrationCluster(name, integration)
+			if err != nil {
+				skipped[name] = err
+				continue
+			}
+			clusterNamespace = registered.Namespace
+		}
+		config, err := cm.GetClusterConfig(name, clusterNamespace)
+		if err != nil {
+			skipped[name] = err
+			continue
+		}
+		targetClient, err := client.New(config, client.Options{Scheme: scheme})
+		if err != nil {
+			skipped[name] = fmt.Errorf("failed to create client: %w", err)
+			continue
+		}
+		clients[name] = targetClient
+	}
+
+	var results []health.ClusterStatus
+	var waitErr error
+	if o.wait {
+		results, waitErr = health.WaitForWorkloadReadyAcrossClusters(ctx, clients, workload, o.timeout)
+	} else {
+		results = health.CheckAcrossClusters(ctx, clients, workload)
+	}
+
+	// Clusters that could not be reached are reported alongside the others
+	unreachable := 0
+	for _, name := range clusterNames {
+		if err, ok := skipped[name]; ok {
+			results = append(results, health.ClusterStatus{Cluster: name, Err: err})
+			unreachable++
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+
+	if err := printRolloutStatus(out, workload, results); err != nil {
+		return err
+	}
+	if waitErr != nil {
+		return waitErr
+	}
+	if unreachable > 0 && o.wait {
+		return fmt.Errorf("%d of %d clusters could not be checked", unreachable, len(clusterNames))
+	}
+	return nil
+}
+
+// selectClusters returns the clusters targeted by the Integration or selected by the BindingPolicy
+func (o *rolloutOptions) selectClusters(ctx context.Context, c client.Client, cm *cluster.ClusterManager, namespace string, integration *ksitv1alpha1.Integration) ([]string, error) {
+	if integration != nil {
+		return integration.Targets(), nil
+	}
+
+	// BindingPolicies are cluster-scoped
+	ks := &kubestellar.KubeStellarClient{Client: c}
+	bp, err := ks.GetBindingPolicy(ctx, o.bindingPolicy, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []*cluster.Cluster
+	for _, registered := range cm.ListClusters() {
+		if registered.Namespace == namespace {
+			clusters = append(clusters, registered)
+		}
+	}
+	return kubestellar.SelectClusters(bp, clusters)
+}
+
+func printRolloutStatus(out io.Writer, workload health.Workload, results []health.ClusterStatus) error {
+	ready := 0
+	for _, result := range results {
+		if result.Ready() {
+			ready++
+		}
+	}
+	fmt.Fprintf(out, "%s: ready on %d/%d clusters\n\n", workload, ready, len(results))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tREADY\tGENERATION\tIMAGES\tMESSAGE")
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(w, "%s\t%t\t-\t-\t%v\n", result.Cluster, false, result.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%t\t%d/%d\t%s\t%s\n",
+			result.Cluster,
+			result.Ready(),
+			result.ObservedGeneration,
+			result.Generation,
+			strings.Join(result.Images, ","),
+			result.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const syncPollInterval = 2 * time.Second
+
+type syncOptions struct {
+	clientOptions
+	cluster string
+	wait    bool
+	timeout time.Duration
+}
+
+func newSyncCommand() *cobra.Command {
+	o := &syncOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Trigger reconciliation of KSIT resources",
+	}
+	o.addFlags(cmd)
+
+	integrationCmd := &cobra.Command{
+		Use:   "integration <name>",
+		Short: "Force an Integration to reconcile and optionally wait until it is Ready",
+		Example: `  # Request a sync and return immediately
+  ksit sync integration argocd-integration -n ksit-system
+
+  # Request a sync and wait for cluster1 to report back
+  ksit sync integration argocd-integration --cluster cluster1 --wait --timeout 5m`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegration(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	integrationCmd.Flags().StringVar(&o.cluster, "cluster", "", "Only report progress for this target cluster")
+	integrationCmd.Flags().BoolVar(&o.wait, "wait", false, "Wait until the Integration reports Ready or the timeout expires")
+	integrationCmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "How long to wait when --wait is set")
+
+	cmd.AddCommand(integrationCmd)
+	return cmd
+}
+
+func (o *syncOptions) runIntegration(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	key := types.NamespacedName{Name: name, Namespace: namespace}
+
+	integration := &ksitv1alpha1.Integration{}
+	if err := c.Get(ctx, key, integration); err != nil {
+		return fmt.Errorf("failed to get integration %s: %w", key, err)
+	}
+
+	if o.cluster != "" && !containsString(integration.Targets(), o.cluster) {
+		return fmt.Errorf("cluster %s is not a target of integration %s", o.cluster, key)
+	}
+
+	requestedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	patch := client.MergeFrom(integration.DeepCopy())
+	annotations := integration.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ksitv1alpha1.ReconcileRequestAnnotation] = requestedAt
+	integration.SetAnnotations(annotations)
+	if err := c.Patch(ctx, integration, patch); err != nil {
+		return fmt.Errorf("failed to request sync of %s: %w", key, err)
+	}
+	fmt.Fprintf(out, "sync requested for integration %s at %s\n", key, requestedAt)
+
+	if !o.wait {
+		return nil
+	}
+
+	return o.waitForSync(ctx, c, out, key, requestedAt)
+}
+
+// waitForSync polls the Integration until the controller has handled the request at requestedAt
+// and the Ready condition reflects the outcome, printing cluster progress as it changes.
+func (o *syncOptions) waitForSync(ctx context.Context, c client.Client, out io.Writer, key types.NamespacedName, requestedAt string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	reported := map[string]string{}
+	for {
+		integration := &ksitv1alpha1.Integration{}
+		if err := c.Get(ctx, key, integration); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for integration %s to sync", key)
+			}
+			return fmt.Errorf("failed to get integration %s: %w", key, err)
+		}
+
+		o.printClusterProgress(out, integration, reported)
+
+		if integration.Status.LastHandledReconcileAt == requestedAt {
+			ready := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeReady)
+			if ready != nil && ready.Status == metav1.ConditionTrue {
+				fmt.Fprintf(out, "integration %s is Ready: %s\n", key, ready.Message)
+				return nil
+			}
+			if ready != nil {
+				return fmt.Errorf("integration %s is not Ready (%s): %s", key, ready.Reason, ready.Message)
+			}
+			return fmt.Errorf("integration %s is %s: %s", key, integration.Status.Phase, integration.Status.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for integration %s to sync", key)
+		case <-ticker.C:
+		}
+	}
+}
+
+// printClusterProgress prints a line for each target cluster whose reported state changed since the last poll
+func (o *syncOptions) printClusterProgress(out io.Writer, integration *ksitv1alpha1.Integration, reported map[string]string) {
+	for _, cs := range integration.Status.ClusterStatuses {
+		if o.cluster != "" && cs.Name != o.cluster {
+			continue
+		}
+
+		state := "disconnected"
+		if cs.Connected {
+			state = "connected"
+		}
+		line := fmt.Sprintf("  %s: %s", cs.Name, state)
+		if cs.Message != "" {
+			line += " - " + cs.Message
+		}
+
+		if reported[cs.Name] != line {
+			reported[cs.Name] = line
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubestellar/integration-toolkit/pkg/topology"
+)
+
+type topologyOptions struct {
+	clientOptions
+	allNamespaces bool
+	output        string
+}
+
+func newTopologyCommand() *cobra.Command {
+	o := &topologyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "topology",
+		Short: "Show which integrations run on which clusters, with their versions and health",
+		Example: `  # Clusters × integration types in the ksit-system namespace
+  ksit topology -n ksit-system
+
+  # The whole fleet, as JSON
+  ksit topology -A -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Include Integrations from every namespace")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "table", "Output format: table or json")
+
+	return cmd
+}
+
+func (o *topologyOptions) run(ctx context.Context, out io.Writer) error {
+	if o.output != "table" && o.output != "json" {
+		return fmt.Errorf("unsupported output format %q, must be table or json", o.output)
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	if o.allNamespaces {
+		namespace = ""
+	}
+
+	topo, err := topology.Build(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(topo)
+	}
+
+	if len(topo.Clusters) == 0 {
+		fmt.Fprintln(out, "No clusters found")
+		return nil
+	}
+	return printTopology(out, topo, o.allNamespaces)
+}
+
+// printTopology prints one row per cluster and one column per integration type. Each cell
+// lists the integrations of that type on the cluster as name[@version]:health.
+func printTopology(out io.Writer, topo *topology.Topology, withNamespace bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "CLUSTER")
+	for _, integrationType := range topo.Types {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(integrationType))
+	}
+	fmt.Fprintln(w)
+
+	for _, cluster := range topo.Clusters {
+		fmt.Fprint(w, cluster)
+		for _, integrationType := range topo.Types {
+			fmt.Fprintf(w, "\t%s", topologyCell(topo.Cell(cluster, integrationType), withNamespace))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func topologyCell(entries []topology.Entry, withNamespace bool) string {
+	if len(entries) == 0 {
+		return "-"
+	}
+
+	cells := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Integration
+		if withNamespace {
+			name = entry.Namespace + "/" + name
+		}
+		if entry.Version != "" {
+			name += "@" + entry.Version
+		}
+		cells = append(cells, name+":"+entry.Health)
+	}
+	return strings.Join(cells, ",")
+}
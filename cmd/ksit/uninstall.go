@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+)
+
+type uninstallOptions struct {
+	clientOptions
+	cluster string
+	wait    bool
+	timeout time.Duration
+}
+
+func newUninstallCommand() *cobra.Command {
+	o := &uninstallOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Uninstall tools from target clusters",
+	}
+	o.addFlags(cmd)
+
+	integrationCmd := &cobra.Command{
+		Use:   "integration <name>",
+		Short: "Uninstall an Integration's tool from one cluster without deleting the Integration",
+		Long: "uninstall integration sets the " + ksitv1alpha1.UninstallAnnotation + " annotation, and the controller runs the\n" +
+			"installer's uninstall against the cluster. With autoInstall enabled the tool is installed again from scratch\n" +
+			"on the next reconcile, which repairs a broken install; remove the cluster from targetClusters to keep it off.",
+		Example: `  # Reinstall Argo CD on cluster1 from scratch
+  ksit uninstall integration argocd-integration --cluster cluster1 -n ksit-system --wait`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runIntegration(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	integrationCmd.Flags().StringVar(&o.cluster, "cluster", "", "Target cluster to uninstall from")
+	integrationCmd.Flags().BoolVar(&o.wait, "wait", false, "Wait until the controller has run the uninstall")
+	integrationCmd.Flags().DurationVar(&o.timeout, "timeout", 10*time.Minute, "How long to wait when --wait is set")
+	_ = integrationCmd.MarkFlagRequired("cluster")
+
+	cmd.AddCommand(integrationCmd)
+	return cmd
+}
+
+func (o *uninstallOptions) runIntegration(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	key := types.NamespacedName{Name: name, Namespace: namespace}
+
+	integration := &ksitv1alpha1.Integration{}
+	if err := c.Get(ctx, key, integration); err != nil {
+		return fmt.Errorf("failed to get integration %s: %w", key, err)
+	}
+	if !containsString(integration.Targets(), o.cluster) {
+		return fmt.Errorf("cluster %s is not a target of integration %s", o.cluster, key)
+	}
+
+	patch := client.MergeFrom(integration.DeepCopy())
+	integration.RequestUninstall(o.cluster)
+	if err := c.Patch(ctx, integration, patch); err != nil {
+		return fmt.Errorf("failed to request uninstall of %s: %w", key, err)
+	}
+	fmt.Fprintf(out, "uninstall requested for integration %s on cluster %s\n", key, o.cluster)
+
+	if !o.wait {
+		return nil
+	}
+	return o.waitForUninstall(ctx, c, out, key)
+}
+
+// waitForUninstall polls the Integration until the controller has cleared the request,
+// then reports the outcome recorded in the install ledger
+func (o *uninstallOptions) waitForUninstall(ctx context.Context, c client.Client, out io.Writer, key types.NamespacedName) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	for {
+		integration := &ksitv1alpha1.Integration{}
+		if err := c.Get(ctx, key, integration); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for integration %s to uninstall", key)
+			}
+			return fmt.Errorf("failed to get integration %s: %w", key, err)
+		}
+
+		if !containsString(integration.UninstallRequests(), o.cluster) {
+			recorded, err := ledger.NewLedger(c).Get(ctx, integration, o.cluster)
+			if err != nil {
+				return err
+			}
+			if recorded != nil && recorded.Status.LastAction == ksitv1alpha1.InstallActionUninstall &&
+				recorded.Status.LastActionResult == ksitv1alpha1.InstallResultFailed {
+				return fmt.Errorf("uninstall of %s from cluster %s failed: %s", key, o.cluster, recorded.Status.Message)
+			}
+			fmt.Fprintf(out, "integration %s uninstalled from cluster %s\n", key, o.cluster)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for integration %s to uninstall", key)
+		case <-ticker.C:
+		}
+	}
+}
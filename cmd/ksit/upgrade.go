@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+type upgradeOptions struct {
+	clientOptions
+	integrationType string
+	version         string
+	manifestURL     string
+	selector        string
+	waveSize        int32
+	maxFailures     int32
+	waveInterval    time.Duration
+}
+
+func newUpgradeCommand() *cobra.Command {
+	o := &upgradeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Roll a new integration version out across the fleet with UpgradeCampaigns",
+	}
+	o.addFlags(cmd)
+
+	startCmd := &cobra.Command{
+		Use:   "start <campaign>",
+		Short: "Start an UpgradeCampaign",
+		Example: `  # Upgrade Argo CD on two clusters at a time, stopping at the first failure
+  ksit upgrade start argocd-6 --type argocd --version 6.0.0 --wave-size 2
+
+  # Upgrade Flux on production Integrations only, tolerating one failed cluster
+  ksit upgrade start flux-2-3 --type flux --selector env=prod --max-failures 1 \
+    --manifest-url https://github.com/fluxcd/flux2/releases/download/v2.3.0/install.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runStart(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+	startCmd.Flags().StringVar(&o.integrationType, "type", "", "Integration type to upgrade (argocd, flux, prometheus, istio)")
+	startCmd.Flags().StringVar(&o.version, "version", "", "Chart version for Helm-installed Integrations")
+	startCmd.Flags().StringVar(&o.manifestURL, "manifest-url", "", "Manifest URL for manifest-installed Integrations")
+	startCmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Only upgrade Integrations matching this label selector")
+	startCmd.Flags().Int32Var(&o.waveSize, "wave-size", 1, "Number of clusters upgraded per wave")
+	startCmd.Flags().Int32Var(&o.maxFailures, "max-failures", 0, "Number of failed clusters tolerated before the campaign fails")
+	startCmd.Flags().DurationVar(&o.waveInterval, "wave-interval", 0, "How long to wait between waves")
+	_ = startCmd.MarkFlagRequired("type")
+
+	statusCmd := &cobra.Command{
+		Use:   "status <campaign>",
+		Short: "Show the progress of an UpgradeCampaign",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.runStatus(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+
+	pauseCmd := &cobra.Command{
+		Use:   "pause <campaign>",
+		Short: "Pause an UpgradeCampaign before its next wave",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.patchCampaign(cmd.Context(), cmd.OutOrStdout(), args[0], "paused", func(spec *ksitv1alpha1.UpgradeCampaignSpec) {
+				spec.Paused = true
+			})
+		},
+	}
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume <campaign>",
+		Short: "Resume a paused UpgradeCampaign",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.patchCampaign(cmd.Context(), cmd.OutOrStdout(), args[0], "resumed", func(spec *ksitv1alpha1.UpgradeCampaignSpec) {
+				spec.Paused = false
+			})
+		},
+	}
+
+	abortCmd := &cobra.Command{
+		Use:   "abort <campaign>",
+		Short: "Abort an UpgradeCampaign; clusters already upgraded keep the new version",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.patchCampaign(cmd.Context(), cmd.OutOrStdout(), args[0], "aborted", func(spec *ksitv1alpha1.UpgradeCampaignSpec) {
+				spec.Abort = true
+			})
+		},
+	}
+
+	cmd.AddCommand(startCmd, statusCmd, pauseCmd, resumeCmd, abortCmd)
+	return cmd
+}
+
+func (o *upgradeOptions) runStart(ctx context.Context, out io.Writer, name string) error {
+	if o.version == "" && o.manifestURL == "" {
+		return fmt.Errorf("one of --version or --manifest-url is required")
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: ksitv1alpha1.UpgradeCampaignSpec{
+			IntegrationType: o.integrationType,
+			Version:         o.version,
+			ManifestURL:     o.manifestURL,
+			Strategy: ksitv1alpha1.RolloutStrategy{
+				WaveSize:    o.waveSize,
+				MaxFailures: o.maxFailures,
+			},
+		},
+	}
+	if o.selector != "" {
+		selector, err := metav1.ParseToLabelSelector(o.selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+		campaign.Spec.Selector = selector
+	}
+	if o.waveInterval > 0 {
+		campaign.Spec.Strategy.WaveInterval = &metav1.Duration{Duration: o.waveInterval}
+	}
+
+	if err := c.Create(ctx, campaign); err != nil {
+		return fmt.Errorf("failed to create upgrade campaign %s: %w", name, err)
+	}
+
+	fmt.Fprintf(out, "upgradecampaign %s/%s started; follow it with: ksit upgrade status %s -n %s\n", namespace, name, name, namespace)
+	return nil
+}
+
+func (o *upgradeOptions) runStatus(ctx context.Context, out io.Writer, name string) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	campaign := &ksitv1alpha1.UpgradeCampaign{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, campaign); err != nil {
+		return fmt.Errorf("failed to get upgrade campaign %s: %w", name, err)
+	}
+
+	status := campaign.Status
+	fmt.Fprintf(out, "Campaign: %s/%s\n", namespace, name)
+	fmt.Fprintf(out, "Phase:    %s\n", status.Phase)
+	fmt.Fprintf(out, "Wave:     %d/%d\n", status.CurrentWave, status.TotalWaves)
+	fmt.Fprintf(out, "Clusters: %d succeeded, %d failed, %d total\n", status.Succeeded, status.Failed, len(status.Clusters))
+	if status.Message != "" {
+		fmt.Fprintf(out, "Message:  %s\n", status.Message)
+	}
+
+	if len(status.Clusters) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WAVE\tINTEGRATION\tCLUSTER\tSTATE\tMESSAGE")
+	for _, entry := range status.Clusters {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", entry.Wave+1, entry.Integration, entry.Cluster, entry.State, entry.Message)
+	}
+	return w.Flush()
+}
+
+func (o *upgradeOptions) patchCampaign(ctx context.Context, out io.Writer, name, verb string, mutate func(spec *ksitv1alpha1.UpgradeCampaignSpec)) error {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	campaign := &ksitv1alpha1.UpgradeCampaign{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, campaign); err != nil {
+		return fmt.Errorf("failed to get upgrade campaign %s: %w", name, err)
+	}
+
+	switch campaign.Status.Phase {
+	case ksitv1alpha1.CampaignPhaseSucceeded, ksitv1alpha1.CampaignPhaseFailed, ksitv1alpha1.CampaignPhaseAborted:
+		return fmt.Errorf("upgrade campaign %s has already finished (%s)", name, campaign.Status.Phase)
+	}
+
+	patch := client.MergeFrom(campaign.DeepCopy())
+	mutate(&campaign.Spec)
+	if err := c.Patch(ctx, campaign, patch); err != nil {
+		return fmt.Errorf("failed to update upgrade campaign %s: %w", name, err)
+	}
+
+	fmt.Fprintf(out, "upgradecampaign %s/%s %s\n", namespace, name, verb)
+	return nil
+}
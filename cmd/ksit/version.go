@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/version"
+)
+
+type versionOptions struct {
+	clientOptions
+	clientOnly    bool
+	allNamespaces bool
+}
+
+func newVersionCommand() *cobra.Command {
+	o := &versionOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the client version and the controller versions reconciling Integrations",
+		Long: `Print the version of this binary and of the controllers that last reconciled
+Integrations on the hub (taken from status.reconciledBy). A warning is printed
+when they differ, or when several controller versions manage the same Integrations.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().BoolVar(&o.clientOnly, "client", false, "Only print the client version")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Inspect Integrations in all namespaces")
+
+	return cmd
+}
+
+func (o *versionOptions) run(ctx context.Context, out io.Writer) error {
+	info := version.Get()
+	fmt.Fprintf(out, "Client: %s (built %s, %s)\n", info.String(), info.BuildDate, info.GoVersion)
+
+	if o.clientOnly {
+		return nil
+	}
+
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return err
+	}
+
+	listOpts := []client.ListOption{}
+	if !o.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.List(ctx, integrations, listOpts...); err != nil {
+		return fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	servers := map[string]int{}
+	for _, integration := range integrations.Items {
+		if integration.Status.ReconciledBy != "" {
+			servers[integration.Status.ReconciledBy]++
+		}
+	}
+
+	if len(servers) == 0 {
+		fmt.Fprintln(out, "Server: unknown (no reconciled Integrations found)")
+		return nil
+	}
+
+	identities := make([]string, 0, len(servers))
+	for identity := range servers {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+
+	for _, identity := range identities {
+		fmt.Fprintf(out, "Server: %s (%d integrations)\n", identity, servers[identity])
+	}
+
+	clientVersion, _ := version.ParseIdentity(info.String())
+	for _, identity := range identities {
+		serverVersion, _ := version.ParseIdentity(identity)
+		if serverVersion != clientVersion {
+			fmt.Fprintf(out, "WARNING: client version %s differs from server version %s\n", clientVersion, serverVersion)
+		}
+	}
+	if len(identities) > 1 {
+		fmt.Fprintln(out, "WARNING: Integrations were reconciled by more than one controller build; check for overlapping hubs")
+	}
+
+	return nil
+}
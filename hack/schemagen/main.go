@@ -0,0 +1,32 @@
+// Command schemagen generates the JSON Schemas pkg/schema publishes from the CRDs in
+// config/crd/bases. Run it from the repository root, through "make schemas".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubestellar/integration-toolkit/pkg/schema"
+)
+
+func main() {
+	crdDir := flag.String("crd-dir", "config/crd/bases", "Directory of the generated CRDs")
+	outDir := flag.String("out-dir", "pkg/schema", "Directory to write the JSON Schemas to")
+	flag.Parse()
+
+	for _, name := range schema.Names() {
+		data, err := schema.FromCRDDir(*crdDir, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate %s schema: %v\n", name, err)
+			os.Exit(1)
+		}
+		path := filepath.Join(*outDir, schema.FileName(name))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Println("wrote", path)
+	}
+}
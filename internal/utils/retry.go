@@ -2,9 +2,15 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand"
+	"net"
+	"sync/atomic"
 	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type RetryConfig struct {
@@ -13,6 +19,21 @@ type RetryConfig struct {
 	MaxDelay        time.Duration
 	BackoffFactor   float64
 	RetryableErrors []error
+
+	// Jitter randomizes each delay by up to this fraction in either direction, e.g. 0.2
+	// waits between 80% and 120% of the delay, so clients retrying together spread out
+	Jitter float64
+	// AttemptTimeout bounds each attempt separately from the context of the whole retry
+	AttemptTimeout time.Duration
+	// Budget, when set, is drawn from by every retry. Sharing one budget between the calls
+	// of a reconcile bounds how much a bad spell of errors can slow it down.
+	Budget *RetryBudget
+	// Retryable decides which errors are retried. When nil, errors listed in RetryableErrors
+	// are retried, or all errors if none are listed.
+	Retryable func(err error) bool
+	// OnRetry is called before waiting to retry, with the number of the failed attempt,
+	// its error and the delay before the next one
+	OnRetry func(attempt int, err error, delay time.Duration)
 }
 
 func DefaultRetryConfig() *RetryConfig {
@@ -24,6 +45,36 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
+// RetryBudget is a number of retries that can be shared by concurrent callers
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// NewRetryBudget returns a budget of n retries
+func NewRetryBudget(n int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(n))
+	return b
+}
+
+// Take uses up one retry, and reports false when none are left
+func (b *RetryBudget) Take() bool {
+	for {
+		n := b.remaining.Load()
+		if n <= 0 {
+			return false
+		}
+		if b.remaining.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// Remaining returns the number of retries left
+func (b *RetryBudget) Remaining() int {
+	return int(b.remaining.Load())
+}
+
 func Retry(attempts int, sleep time.Duration, fn func() error) error {
 	var lastErr error
 	for i := 0; i < attempts; i++ {
@@ -60,40 +111,123 @@ func RetryWithContext(ctx context.Context, attempts int, sleep time.Duration, fn
 }
 
 func RetryWithBackoff(ctx context.Context, config *RetryConfig, fn func() error) error {
+	return Do(ctx, config, func(context.Context) error { return fn() })
+}
+
+// Do calls fn until it succeeds, with the delays, jitter, per-attempt timeout and budget
+// of config. It stops early on errors config does not retry, once the budget is spent,
+// and when ctx is done. When fn was called more than once, the error names the number
+// of attempts and wraps the last error.
+func Do(ctx context.Context, config *RetryConfig, fn func(ctx context.Context) error) error {
 	if config == nil {
 		config = DefaultRetryConfig()
 	}
 
-	var lastErr error
 	delay := config.InitialDelay
+	attempt := 0
+	var lastErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			if lastErr == nil {
+				return err
+			}
+			break
+		}
 
-	for attempt := 0; attempt < config.MaxAttempts; attempt++ {
+		attempt++
+		lastErr = runAttempt(ctx, config.AttemptTimeout, fn)
+		if lastErr == nil {
+			return nil
+		}
+		if attempt >= config.MaxAttempts || !config.retryable(lastErr) || ctx.Err() != nil {
+			break
+		}
+		if config.Budget != nil && !config.Budget.Take() {
+			break
+		}
+
+		wait := withJitter(delay, config.Jitter)
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, lastErr, wait)
+		}
+		timer := time.NewTimer(wait)
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := fn(); err == nil {
-				return nil
-			} else {
-				lastErr = err
-			}
-
-			if attempt < config.MaxAttempts-1 {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(delay):
-				}
+			timer.Stop()
+		case <-timer.C:
+		}
 
-				delay = time.Duration(float64(delay) * config.BackoffFactor)
-				if delay > config.MaxDelay {
-					delay = config.MaxDelay
-				}
-			}
+		if config.BackoffFactor > 0 {
+			delay = time.Duration(float64(delay) * config.BackoffFactor)
+		}
+		if config.MaxDelay > 0 && delay > config.MaxDelay {
+			delay = config.MaxDelay
 		}
 	}
 
-	return fmt.Errorf("after %d attempts, last error: %v", config.MaxAttempts, lastErr)
+	if attempt == 1 {
+		return lastErr
+	}
+	return fmt.Errorf("after %d attempts, last error: %w", attempt, lastErr)
+}
+
+func runAttempt(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(attemptCtx)
+}
+
+func (c *RetryConfig) retryable(err error) bool {
+	if c.Retryable != nil {
+		return c.Retryable(err)
+	}
+	return IsRetryable(err, c.RetryableErrors)
+}
+
+// Delay returns the jittered delay before retry number attempt, counting from 1: the
+// initial delay grown by the backoff factor once per earlier retry, capped at MaxDelay
+func (c *RetryConfig) Delay(attempt int) time.Duration {
+	delay := float64(c.InitialDelay)
+	if c.BackoffFactor > 0 && attempt > 1 {
+		delay *= math.Pow(c.BackoffFactor, float64(attempt-1))
+	}
+	if c.MaxDelay > 0 && delay > float64(c.MaxDelay) {
+		delay = float64(c.MaxDelay)
+	}
+	return withJitter(time.Duration(delay), c.Jitter)
+}
+
+// withJitter spreads delay by up to jitter times delay in either direction
+func withJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 + jitter*(2*rand.Float64()-1)))
+}
+
+// IsTransient reports whether err is worth retrying against a Kubernetes API server: a
+// network error, a timeout, throttling or a server-side failure. Errors the server meant,
+// such as NotFound or Forbidden, are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsUnexpectedServerError(err)
 }
 
 func RetryWithExponentialBackoff(ctx context.Context, maxAttempts int, fn func() error) error {
@@ -131,40 +265,16 @@ func RetryWithExponentialBackoff(ctx context.Context, maxAttempts int, fn func()
 type RetryableFunc func(ctx context.Context) (interface{}, error)
 
 func RetryWithResult(ctx context.Context, config *RetryConfig, fn RetryableFunc) (interface{}, error) {
-	if config == nil {
-		config = DefaultRetryConfig()
+	var result interface{}
+	err := Do(ctx, config, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	var lastErr error
-	delay := config.InitialDelay
-
-	for attempt := 0; attempt < config.MaxAttempts; attempt++ {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			result, err := fn(ctx)
-			if err == nil {
-				return result, nil
-			}
-			lastErr = err
-
-			if attempt < config.MaxAttempts-1 {
-				select {
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				case <-time.After(delay):
-				}
-
-				delay = time.Duration(float64(delay) * config.BackoffFactor)
-				if delay > config.MaxDelay {
-					delay = config.MaxDelay
-				}
-			}
-		}
-	}
-
-	return nil, fmt.Errorf("after %d attempts, last error: %v", config.MaxAttempts, lastErr)
+	return result, nil
 }
 
 func IsRetryable(err error, retryableErrors []error) bool {
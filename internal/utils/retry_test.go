@@ -1,11 +1,17 @@
 package utils
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestRetry(t *testing.T) {
@@ -21,3 +27,119 @@ func TestRetry(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 3, attempts)
 }
+
+func TestDo(t *testing.T) {
+	transient := apierrors.NewServiceUnavailable("etcd leader changed")
+	var retries []int
+	config := &RetryConfig{
+		MaxAttempts:  5,
+		InitialDelay: time.Millisecond,
+		Retryable:    IsTransient,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			assert.Equal(t, transient, err)
+			retries = append(retries, attempt)
+		},
+	}
+
+	attempts := 0
+	err := Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return transient
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	// Errors that are not transient are returned as they are, without retrying
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "argocd-server")
+	attempts = 0
+	err = Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		return notFound
+	})
+	assert.Equal(t, notFound, err)
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		return transient
+	})
+	assert.EqualError(t, err, "after 5 attempts, last error: etcd leader changed")
+	assert.True(t, apierrors.IsServiceUnavailable(err))
+}
+
+func TestDoAttemptTimeout(t *testing.T) {
+	config := &RetryConfig{MaxAttempts: 2, AttemptTimeout: 10 * time.Millisecond, Retryable: IsTransient}
+
+	attempts := 0
+	err := Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// The context of the whole retry ends it, however many attempts are left
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Do(ctx, config, func(ctx context.Context) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(2)
+	config := &RetryConfig{MaxAttempts: 10, InitialDelay: time.Millisecond, Budget: budget}
+
+	attempts := 0
+	err := Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection reset")
+	})
+	assert.EqualError(t, err, "after 3 attempts, last error: connection reset")
+	assert.Equal(t, 0, budget.Remaining())
+
+	// A spent budget leaves one attempt to every later call
+	attempts = 0
+	_ = Do(context.Background(), config, func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection reset")
+	})
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWithJitter(t *testing.T) {
+	assert.Equal(t, time.Second, withJitter(time.Second, 0))
+	for i := 0; i < 100; i++ {
+		delay := withJitter(time.Second, 0.2)
+		assert.GreaterOrEqual(t, delay, 800*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1200*time.Millisecond)
+	}
+}
+
+func TestRetryConfigDelay(t *testing.T) {
+	config := &RetryConfig{InitialDelay: 5 * time.Second, MaxDelay: time.Minute, BackoffFactor: 2}
+	assert.Equal(t, 5*time.Second, config.Delay(1))
+	assert.Equal(t, 20*time.Second, config.Delay(3))
+	assert.Equal(t, time.Minute, config.Delay(10))
+
+	config.Jitter = 0.2
+	delay := config.Delay(2)
+	assert.GreaterOrEqual(t, delay, 8*time.Second)
+	assert.LessOrEqual(t, delay, 12*time.Second)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.True(t, IsTransient(fmt.Errorf("failed to get deployment: %w", apierrors.NewTooManyRequests("slow down", 1))))
+	assert.True(t, IsTransient(context.DeadlineExceeded))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.False(t, IsTransient(apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("denied"))))
+	assert.False(t, IsTransient(errors.New("no Flux pods are running on edge-1")))
+}
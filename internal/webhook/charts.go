@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const (
+	// chartIndexTimeout bounds the fetch of a repository index, so admission stays fast
+	chartIndexTimeout = 5 * time.Second
+	// chartIndexTTL is how long a fetched repository index is reused
+	chartIndexTTL = 10 * time.Minute
+	// maxChartIndexSize bounds the repository indexes read; large public repositories
+	// serve indexes of tens of megabytes
+	maxChartIndexSize = 64 << 20
+)
+
+// ChartChecker looks up Helm charts in the index.yaml of their repository. Indexes are
+// cached per repository, so repeated admissions do not refetch them.
+type ChartChecker struct {
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	indexes map[string]cachedIndex
+}
+
+type cachedIndex struct {
+	index     *repo.IndexFile
+	fetchedAt time.Time
+}
+
+// NewChartChecker creates a ChartChecker with the default timeout and cache TTL
+func NewChartChecker() *ChartChecker {
+	return &ChartChecker{
+		client:  &http.Client{Timeout: chartIndexTimeout},
+		ttl:     chartIndexTTL,
+		now:     time.Now,
+		indexes: make(map[string]cachedIndex),
+	}
+}
+
+// Check returns a warning when repository does not serve chart at version, or when
+// the repository cannot be checked. An empty version only checks that the chart exists.
+func (c *ChartChecker) Check(ctx context.Context, repository, chart, version string) string {
+	if strings.HasPrefix(repository, "oci://") {
+		// OCI registries have no index to look charts up in
+		return ""
+	}
+
+	index, err := c.index(ctx, repository)
+	if err != nil {
+		return fmt.Sprintf("could not verify chart %s in %s: %v", chart, repository, err)
+	}
+
+	versions, ok := index.Entries[chart]
+	if !ok || len(versions) == 0 {
+		return fmt.Sprintf("chart %s was not found in %s", chart, repository)
+	}
+	if version == "" {
+		return ""
+	}
+	for _, v := range versions {
+		if v.Version == version || "v"+v.Version == version {
+			return ""
+		}
+	}
+	return fmt.Sprintf("version %s of chart %s was not found in %s (latest is %s)", version, chart, repository, latestVersion(index, chart))
+}
+
+func (c *ChartChecker) index(ctx context.Context, repository string) (*repo.IndexFile, error) {
+	key := strings.TrimSuffix(repository, "/")
+
+	c.mu.Lock()
+	cached, ok := c.indexes[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < c.ttl {
+		return cached.index, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, chartIndexTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key+"/index.yaml", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("index.yaml returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChartIndexSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index.yaml: %w", err)
+	}
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse index.yaml: %w", err)
+	}
+	for name, versions := range index.Entries {
+		valid := versions[:0]
+		for _, v := range versions {
+			if v != nil && v.Metadata != nil {
+				valid = append(valid, v)
+			}
+		}
+		index.Entries[name] = valid
+	}
+	index.SortEntries()
+
+	c.mu.Lock()
+	c.indexes[key] = cachedIndex{index: index, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return index, nil
+}
+
+// latestVersion returns the newest version of a chart in a sorted index
+func latestVersion(index *repo.IndexFile, chart string) string {
+	versions := index.Entries[chart]
+	if len(versions) == 0 {
+		return "unknown"
+	}
+	return versions[0].Version
+}
+
+// chartWarnings checks the Helm chart an Integration installs, and the versions its
+// cluster overrides pin, against the chart repository
+func (c *ChartChecker) chartWarnings(ctx context.Context, integration *ksitv1alpha1.Integration) []string {
+	install := integration.Spec.AutoInstall
+	if install == nil || !install.Enabled || (install.Method != "" && install.Method != "helm") || install.Profile != "" {
+		return nil
+	}
+	helmConfig := install.HelmConfig
+	if helmConfig == nil || helmConfig.Repository == "" || helmConfig.Chart == "" {
+		return nil
+	}
+
+	var warnings []string
+	if warning := c.Check(ctx, helmConfig.Repository, helmConfig.Chart, helmConfig.Version); warning != "" {
+		warnings = append(warnings, "autoInstall.helmConfig: "+warning)
+	}
+	for i, override := range install.ClusterOverrides {
+		if override.Version == "" || override.Version == helmConfig.Version {
+			continue
+		}
+		if warning := c.Check(ctx, helmConfig.Repository, helmConfig.Chart, override.Version); warning != "" {
+			warnings = append(warnings, fmt.Sprintf("autoInstall.clusterOverrides[%d]: %s", i, warning))
+		}
+	}
+	return warnings
+}
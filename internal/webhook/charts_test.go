@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const testIndex = `apiVersion: v1
+entries:
+  argo-cd:
+  - name: argo-cd
+    version: 5.51.6
+  - name: argo-cd
+    version: 6.0.0
+`
+
+func TestChartChecker(t *testing.T) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches++
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	checker := NewChartChecker()
+	ctx := context.Background()
+
+	assert.Empty(t, checker.Check(ctx, server.URL, "argo-cd", "5.51.6"))
+	assert.Empty(t, checker.Check(ctx, server.URL+"/", "argo-cd", ""))
+	assert.Equal(t, "version 5.51.7 of chart argo-cd was not found in "+server.URL+" (latest is 6.0.0)",
+		checker.Check(ctx, server.URL, "argo-cd", "5.51.7"))
+	assert.Equal(t, "chart argocd was not found in "+server.URL, checker.Check(ctx, server.URL, "argocd", ""))
+
+	// The index is fetched once and reused until it expires
+	assert.Equal(t, 1, fetches)
+	checker.now = func() time.Time { return time.Now().Add(chartIndexTTL) }
+	assert.Empty(t, checker.Check(ctx, server.URL, "argo-cd", "6.0.0"))
+	assert.Equal(t, 2, fetches)
+
+	assert.Contains(t, checker.Check(ctx, server.URL+"/missing", "argo-cd", ""), "could not verify chart argo-cd")
+	assert.Empty(t, checker.Check(ctx, "oci://registry.example.com/charts", "argo-cd", "6.0.0"))
+}
+
+func TestValidateCreateWarnsAboutMissingChartVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	validator := NewIntegrationValidator(nil, newScheme())
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"serverURL": "https://argocd.example.com"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  server.URL,
+					Chart:       "argo-cd",
+					Version:     "5.15.6",
+					ReleaseName: "argocd",
+				},
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{{Version: "6.0.0"}},
+			},
+		},
+	}
+
+	// Without the feature gate nothing is fetched
+	warnings, err := validator.ValidateCreate(context.Background(), integration)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	validator.Charts = NewChartChecker()
+	warnings, err = validator.ValidateCreate(context.Background(), integration)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"autoInstall.helmConfig: version 5.15.6 of chart argo-cd was not found in " + server.URL + " (latest is 6.0.0)",
+	}, []string(warnings))
+}
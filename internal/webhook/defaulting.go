@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+// IntegrationDefaulter merges the platform-wide autoInstall defaults from the KSIT
+// config into Integrations that do not set them explicitly
+type IntegrationDefaulter struct {
+	Defaults map[string]config.AutoInstallDefaults
+}
+
+// NewIntegrationDefaulter creates a new IntegrationDefaulter
+func NewIntegrationDefaulter(defaults map[string]config.AutoInstallDefaults) *IntegrationDefaulter {
+	return &IntegrationDefaulter{
+		Defaults: defaults,
+	}
+}
+
+// Default implements admission.CustomDefaulter
+func (d *IntegrationDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	integration, ok := obj.(*ksitv1alpha1.Integration)
+	if !ok {
+		return fmt.Errorf("expected Integration but got %T", obj)
+	}
+
+	d.applyDefaults(integration)
+	return nil
+}
+
+// applyDefaults only touches Integrations that opted into autoInstall; the defaults never enable it
+func (d *IntegrationDefaulter) applyDefaults(integration *ksitv1alpha1.Integration) {
+	if integration.Spec.AutoInstall == nil {
+		return
+	}
+
+	defaults, ok := d.Defaults[integration.Spec.Type]
+	if !ok {
+		return
+	}
+
+	defaults.ApplyTo(integration.Spec.AutoInstall)
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+func TestIntegrationDefaulter(t *testing.T) {
+	defaulter := NewIntegrationDefaulter(map[string]config.AutoInstallDefaults{
+		ksitv1alpha1.IntegrationTypeArgoCD: {
+			Method: "helm",
+			Helm: &config.HelmDefaults{
+				Repository:  "https://charts.internal.example.com/argo",
+				Chart:       "argo-cd",
+				Version:     "5.51.6",
+				ReleaseName: "argocd",
+				Values: map[string]string{
+					"server.service.type": "ClusterIP",
+					"server.insecure":     "true",
+				},
+			},
+		},
+	})
+
+	newIntegration := func(install *ksitv1alpha1.InstallConfig) *ksitv1alpha1.Integration {
+		return &ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "default"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:        ksitv1alpha1.IntegrationTypeArgoCD,
+				AutoInstall: install,
+			},
+		}
+	}
+
+	t.Run("fills empty autoInstall", func(t *testing.T) {
+		integration := newIntegration(&ksitv1alpha1.InstallConfig{Enabled: true})
+		require.NoError(t, defaulter.Default(context.Background(), integration))
+
+		install := integration.Spec.AutoInstall
+		assert.Equal(t, "helm", install.Method)
+		require.NotNil(t, install.HelmConfig)
+		assert.Equal(t, "https://charts.internal.example.com/argo", install.HelmConfig.Repository)
+		assert.Equal(t, "5.51.6", install.HelmConfig.Version)
+		assert.Equal(t, "ClusterIP", install.HelmConfig.Values["server.service.type"])
+	})
+
+	t.Run("explicit settings win", func(t *testing.T) {
+		integration := newIntegration(&ksitv1alpha1.InstallConfig{
+			Enabled: true,
+			HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+				Version: "6.0.0",
+				Values:  map[string]string{"server.insecure": "false"},
+			},
+		})
+		require.NoError(t, defaulter.Default(context.Background(), integration))
+
+		helmConfig := integration.Spec.AutoInstall.HelmConfig
+		assert.Equal(t, "6.0.0", helmConfig.Version)
+		assert.Equal(t, "false", helmConfig.Values["server.insecure"])
+		assert.Equal(t, "ClusterIP", helmConfig.Values["server.service.type"])
+	})
+
+	t.Run("different chart keeps its own version", func(t *testing.T) {
+		integration := newIntegration(&ksitv1alpha1.InstallConfig{
+			Enabled: true,
+			HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+				Repository: "https://example.com/charts",
+				Chart:      "my-argo",
+			},
+		})
+		require.NoError(t, defaulter.Default(context.Background(), integration))
+
+		helmConfig := integration.Spec.AutoInstall.HelmConfig
+		assert.Empty(t, helmConfig.Version)
+		assert.Empty(t, helmConfig.Values)
+		assert.Equal(t, "argocd", helmConfig.ReleaseName)
+	})
+
+	t.Run("autoInstall not requested", func(t *testing.T) {
+		integration := newIntegration(nil)
+		require.NoError(t, defaulter.Default(context.Background(), integration))
+		assert.Nil(t, integration.Spec.AutoInstall)
+	})
+}
@@ -2,21 +2,41 @@ package webhook
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/health"
+	"github.com/kubestellar/integration-toolkit/pkg/hooks"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/kyverno"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+	"github.com/kubestellar/integration-toolkit/pkg/template"
 )
 
+// minReconcileInterval keeps spec.reconcileInterval from driving the checks of every
+// target cluster in a tight loop
+const minReconcileInterval = 5 * time.Second
+
 var (
 	labelKeyRegex   = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*\/)?[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`)
 	labelValueRegex = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?)?$`)
@@ -37,14 +57,24 @@ type ValidationResponse struct {
 
 // IntegrationValidator validates Integration resources
 type IntegrationValidator struct {
-	Client  client.Client
-	decoder admission.Decoder
+	Client client.Client
+	// Charts, when set, looks up the Helm charts Integrations install and warns
+	// about charts or versions their repository does not serve
+	Charts *ChartChecker
+	// ClusterScopeNamespaces are the namespaces in which Integrations may use Cluster scope
+	ClusterScopeNamespaces []string
+	// DenyUnregisteredClusters rejects Integrations whose targetClusters are not
+	// registered by an IntegrationTarget, instead of warning about them
+	DenyUnregisteredClusters bool
+	decoder                  *admission.Decoder
 }
 
-// NewIntegrationValidator creates a new IntegrationValidator
-func NewIntegrationValidator(c client.Client) *IntegrationValidator {
+// NewIntegrationValidator creates a new IntegrationValidator. Its Handle decodes
+// admission requests with the types of scheme, normally the manager's.
+func NewIntegrationValidator(c client.Client, scheme *runtime.Scheme) *IntegrationValidator {
 	return &IntegrationValidator{
-		Client: c,
+		Client:  c,
+		decoder: admission.NewDecoder(scheme),
 	}
 }
 
@@ -56,13 +86,29 @@ func (v *IntegrationValidator) Handle(ctx context.Context, req admission.Request
 	if err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
+	if integration.DeletionTimestamp != nil {
+		// Removing finalizers must never be blocked
+		return admission.Allowed("")
+	}
 
 	errors := v.validateIntegration(integration)
 	if len(errors) > 0 {
 		return admission.Denied(strings.Join(errors, "; "))
 	}
+	deny := v.DenyUnregisteredClusters
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		old := &ksitv1alpha1.Integration{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		deny = deny && targetClustersChanged(old, integration)
+	}
+	warnings, err := v.warnings(ctx, integration, deny)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
 
-	return admission.Allowed("")
+	return admission.Allowed("").WithWarnings(warnings...)
 }
 
 // validateIntegration performs validation checks on Integration resource
@@ -75,6 +121,9 @@ func (v *IntegrationValidator) validateIntegration(integration *ksitv1alpha1.Int
 		ksitv1alpha1.IntegrationTypeFlux,
 		ksitv1alpha1.IntegrationTypePrometheus,
 		ksitv1alpha1.IntegrationTypeIstio,
+		ksitv1alpha1.IntegrationTypeGrafana,
+		ksitv1alpha1.IntegrationTypeCertManager,
+		ksitv1alpha1.IntegrationTypeKyverno,
 	}
 
 	isValidType := false
@@ -90,8 +139,18 @@ func (v *IntegrationValidator) validateIntegration(integration *ksitv1alpha1.Int
 	}
 
 	// Validate target clusters
-	if len(integration.Spec.TargetClusters) == 0 {
-		errors = append(errors, "targetClusters cannot be empty")
+	if len(integration.Spec.TargetClusters) == 0 && integration.Spec.TargetSelector == nil {
+		errors = append(errors, "targetClusters cannot be empty unless targetSelector is set")
+	}
+	if selector := integration.Spec.TargetSelector; selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errors = append(errors, fmt.Sprintf("invalid targetSelector: %v", err))
+		}
+	}
+
+	if integration.ClusterScoped() && !slices.Contains(v.ClusterScopeNamespaces, integration.Namespace) {
+		errors = append(errors, fmt.Sprintf("scope Cluster is not allowed in namespace %s; allowed namespaces: %v",
+			integration.Namespace, v.ClusterScopeNamespaces))
 	}
 
 	for _, cluster := range integration.Spec.TargetClusters {
@@ -103,21 +162,100 @@ func (v *IntegrationValidator) validateIntegration(integration *ksitv1alpha1.Int
 	// Type-specific validation
 	switch integration.Spec.Type {
 	case ksitv1alpha1.IntegrationTypeArgoCD:
-		if integration.Spec.Config["serverURL"] == "" {
+		if !hasConfig(integration, "serverURL") {
 			errors = append(errors, "ArgoCD integration requires serverURL in config")
 		}
 	case ksitv1alpha1.IntegrationTypeFlux:
 		if integration.Spec.Config["namespace"] == "" {
 			errors = append(errors, "Flux integration requires namespace in config")
 		}
+		errors = append(errors, validateFluxBootstrap(integration)...)
 	case ksitv1alpha1.IntegrationTypePrometheus:
-		if integration.Spec.Config["url"] == "" {
+		if !hasConfig(integration, "url") {
 			errors = append(errors, "Prometheus integration requires url in config")
 		}
 	case ksitv1alpha1.IntegrationTypeIstio:
 		if integration.Spec.Config["namespace"] == "" {
 			errors = append(errors, "Istio integration requires namespace in config")
 		}
+	case ksitv1alpha1.IntegrationTypeCertManager:
+		if window := integration.Spec.Config["expiryWindow"]; window != "" {
+			if d, err := time.ParseDuration(window); err != nil || d <= 0 {
+				errors = append(errors, fmt.Sprintf("invalid expiryWindow in config: %s", window))
+			}
+		}
+	}
+
+	if install := integration.Spec.AutoInstall; install != nil && install.Enabled {
+		errors = append(errors, validateInstallConfig(install)...)
+		if install.Profile != "" && integration.Spec.Type != ksitv1alpha1.IntegrationTypeIstio {
+			errors = append(errors, "autoInstall.profile is only supported for istio")
+		}
+		if install.Method == "operator" && (install.OperatorConfig == nil || install.OperatorConfig.Package == "") && !installer.HasDefaultOperator(integration.Spec.Type) {
+			errors = append(errors, fmt.Sprintf("autoInstall.operatorConfig.package is required to install %s with an operator", integration.Spec.Type))
+		}
+		if install.Method == "manifest" && install.ManifestURL == "" && !installer.HasDefaultManifest(integration.Spec.Type) {
+			errors = append(errors, fmt.Sprintf("autoInstall.manifestUrl is required to install %s from a manifest", integration.Spec.Type))
+		}
+		if skipsCRDs(install) && integration.Spec.Type != ksitv1alpha1.IntegrationTypePrometheus {
+			errors = append(errors, "autoInstall.skipCRDs is only supported for prometheus")
+		}
+		if integration.Spec.Type == ksitv1alpha1.IntegrationTypePrometheus && install.Method != "manifest" && install.ManifestURL != "" {
+			if err := validateURL(install.ManifestURL, "https"); err != nil {
+				errors = append(errors, fmt.Sprintf("autoInstall.manifestUrl is invalid: %v", err))
+			}
+		}
+		if hardening := install.Hardening; hardening != nil {
+			if integration.Spec.Type != ksitv1alpha1.IntegrationTypeArgoCD && integration.Spec.Type != ksitv1alpha1.IntegrationTypeFlux {
+				errors = append(errors, "autoInstall.hardening is only supported for argocd and flux")
+			}
+			if len(hardening.AllowedNamespaces) > 0 && !hardening.NetworkPolicies {
+				errors = append(errors, "autoInstall.hardening.allowedNamespaces requires autoInstall.hardening.networkPolicies")
+			}
+		}
+		if namespace := install.Namespace; namespace != nil {
+			// The Flux manifests place every object in flux-system
+			if integration.Spec.Type == ksitv1alpha1.IntegrationTypeFlux && namespace.Name != "" && namespace.Name != "flux-system" {
+				errors = append(errors, "autoInstall.namespace.name must be flux-system for flux")
+			}
+			errors = append(errors, validateInstallNamespace(namespace)...)
+		}
+	}
+
+	if integration.Spec.Flux != nil {
+		if integration.Spec.Type != ksitv1alpha1.IntegrationTypeFlux {
+			errors = append(errors, "spec.flux is only supported for flux integrations")
+		}
+		errors = append(errors, validateFluxSpec(integration.Spec.Flux)...)
+	}
+
+	if integration.Spec.Kyverno != nil {
+		if integration.Spec.Type != ksitv1alpha1.IntegrationTypeKyverno {
+			errors = append(errors, "spec.kyverno is only supported for kyverno integrations")
+		}
+		errors = append(errors, validateKyvernoSpec(integration.Spec.Kyverno)...)
+	}
+
+	if integration.Spec.Workloads != nil {
+		errors = append(errors, validateWorkloads(integration.Spec.Workloads)...)
+	}
+
+	if integration.Spec.HealthScoring != nil {
+		errors = append(errors, validateHealthWeights(integration.Spec.HealthScoring.Weights)...)
+	}
+
+	if interval := integration.Spec.ReconcileInterval; interval != nil && interval.Duration < minReconcileInterval {
+		errors = append(errors, fmt.Sprintf("reconcileInterval must be at least %s", minReconcileInterval))
+	}
+
+	if len(integration.Spec.ImpersonateGroups) > 0 && integration.Spec.ImpersonateUser == "" {
+		errors = append(errors, "impersonateGroups requires impersonateUser")
+	}
+
+	errors = append(errors, validateConfigSecretRefs(integration)...)
+	errors = append(errors, validateTemplates("config", integration.Spec.Config)...)
+	if install := integration.Spec.AutoInstall; install != nil && install.HelmConfig != nil {
+		errors = append(errors, validateTemplates("helmConfig.values", install.HelmConfig.Values)...)
 	}
 
 	// Validate name
@@ -132,9 +270,430 @@ func (v *IntegrationValidator) validateIntegration(integration *ksitv1alpha1.Int
 	return errors
 }
 
-// InjectDecoder injects the decoder
-func (v *IntegrationValidator) InjectDecoder(d admission.Decoder) error {
-	v.decoder = d
+// hasConfig reports whether a config key is set, either in spec.config or by a configSecretRef
+func hasConfig(integration *ksitv1alpha1.Integration, key string) bool {
+	if integration.Spec.Config[key] != "" {
+		return true
+	}
+	for _, ref := range integration.Spec.ConfigSecretRefs {
+		if ref.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConfigSecretRefs checks that every config key has a single source
+func validateConfigSecretRefs(integration *ksitv1alpha1.Integration) []string {
+	var errors []string
+
+	seen := make(map[string]bool, len(integration.Spec.ConfigSecretRefs))
+	for _, ref := range integration.Spec.ConfigSecretRefs {
+		switch {
+		case ref.Key == "" || ref.SecretName == "":
+			errors = append(errors, "configSecretRefs entries require key and secretName")
+		case seen[ref.Key]:
+			errors = append(errors, fmt.Sprintf("configSecretRefs sets config key %s more than once", ref.Key))
+		default:
+			if _, ok := integration.Spec.Config[ref.Key]; ok {
+				errors = append(errors, fmt.Sprintf("config key %s is set in both config and configSecretRefs", ref.Key))
+			}
+		}
+		seen[ref.Key] = true
+	}
+	return errors
+}
+
+// validateHealthWeights checks that the weights name known signals and are not negative
+func validateHealthWeights(weights map[string]int32) []string {
+	var errors []string
+
+	signals := make([]string, 0, len(weights))
+	for signal := range weights {
+		signals = append(signals, signal)
+	}
+	sort.Strings(signals)
+
+	for _, signal := range signals {
+		if _, ok := health.DefaultWeights[signal]; !ok {
+			errors = append(errors, fmt.Sprintf("healthScoring.weights has unknown signal %s", signal))
+		} else if weights[signal] < 0 {
+			errors = append(errors, fmt.Sprintf("healthScoring.weights.%s cannot be negative", signal))
+		}
+	}
+	return errors
+}
+
+// validateInstallConfig checks that the install method has what it needs, so that
+// incomplete configuration is rejected at admission instead of failing the install
+func validateInstallConfig(install *ksitv1alpha1.InstallConfig) []string {
+	var errors []string
+
+	if helmConfig := install.HelmConfig; helmConfig != nil {
+		if helmConfig.Timeout != nil && helmConfig.Timeout.Duration <= 0 {
+			errors = append(errors, "autoInstall.helmConfig.timeout must be positive")
+		}
+		if helmConfig.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(helmConfig.CABundle)) {
+			errors = append(errors, "autoInstall.helmConfig.caBundle must hold PEM-encoded certificates")
+		}
+		// Credentials are never sent in the clear
+		if helmConfig.CredentialsSecretRef != "" && strings.HasPrefix(helmConfig.Repository, "http://") {
+			errors = append(errors, "autoInstall.helmConfig.credentialsSecretRef requires an https or oci repository")
+		}
+	}
+
+	switch install.Method {
+	case "helm":
+		// Without helmConfig the installer falls back to its built-in chart
+		helmConfig := install.HelmConfig
+		if install.Profile != "" {
+			// The profile picks the charts; helmConfig may only change where they come from
+			if helmConfig != nil && helmConfig.Repository != "" {
+				if err := validateURL(helmConfig.Repository, "http", "https", "oci"); err != nil {
+					errors = append(errors, fmt.Sprintf("autoInstall.helmConfig.repository is invalid: %v", err))
+				}
+			}
+			break
+		}
+		if helmConfig == nil {
+			if hasHelmClusterOverrides(install) {
+				errors = append(errors, "autoInstall.clusterOverrides values and version require autoInstall.helmConfig")
+			}
+			break
+		}
+		if hasComponentOverrides(install) && !installer.SupportsOverrides(helmConfig.Chart) {
+			errors = append(errors, fmt.Sprintf("autoInstall.overrides are not supported for chart %s; set helmConfig.values instead", helmConfig.Chart))
+		}
+		if helmConfig.Repository == "" {
+			errors = append(errors, "autoInstall.helmConfig.repository is required when method is helm")
+		} else if err := validateURL(helmConfig.Repository, "http", "https", "oci"); err != nil {
+			errors = append(errors, fmt.Sprintf("autoInstall.helmConfig.repository is invalid: %v", err))
+		}
+		if helmConfig.Chart == "" {
+			errors = append(errors, "autoInstall.helmConfig.chart is required when method is helm")
+		}
+		if helmConfig.ValuesObject != nil && !isJSONObject(helmConfig.ValuesObject.Raw) {
+			errors = append(errors, "autoInstall.helmConfig.valuesObject must be a map of values")
+		}
+		if helmConfig.ReleaseName == "" {
+			errors = append(errors, "autoInstall.helmConfig.releaseName is required when method is helm")
+		}
+	case "operator":
+		if install.Profile != "" {
+			errors = append(errors, "autoInstall.profile is only supported when method is helm")
+		}
+		if hasHelmClusterOverrides(install) {
+			errors = append(errors, "autoInstall.clusterOverrides values and version are only supported when method is helm")
+		}
+		if hasComponentOverrides(install) {
+			errors = append(errors, "autoInstall.overrides are not supported when method is operator")
+		}
+	case "manifest":
+		if install.Profile != "" {
+			errors = append(errors, "autoInstall.profile is only supported when method is helm")
+		}
+		if hasHelmClusterOverrides(install) {
+			errors = append(errors, "autoInstall.clusterOverrides values and version are only supported when method is helm")
+		}
+		if install.ManifestURL != "" {
+			if err := validateURL(install.ManifestURL, "https"); err != nil {
+				errors = append(errors, fmt.Sprintf("autoInstall.manifestUrl is invalid: %v", err))
+			}
+		}
+	}
+
+	if install.OperatorConfig != nil && install.Method != "operator" {
+		errors = append(errors, "autoInstall.operatorConfig is only supported when method is operator")
+	}
+
+	for i, override := range install.ClusterOverrides {
+		if _, err := metav1.LabelSelectorAsSelector(&override.ClusterSelector); err != nil {
+			errors = append(errors, fmt.Sprintf("autoInstall.clusterOverrides[%d].clusterSelector is invalid: %v", i, err))
+		}
+		errors = append(errors, validateTemplates(fmt.Sprintf("autoInstall.clusterOverrides[%d].values", i), override.Values)...)
+	}
+
+	errors = append(errors, validatePostInstallHooks(install.PostInstall)...)
+
+	return errors
+}
+
+// validatePostInstallHooks checks that each hook has a unique name and exactly one
+// valid action
+func validatePostInstallHooks(hooks []ksitv1alpha1.PostInstallHook) []string {
+	var errors []string
+
+	names := make(map[string]bool, len(hooks))
+	for i, hook := range hooks {
+		field := fmt.Sprintf("autoInstall.postInstall[%d]", i)
+		if names[hook.Name] {
+			errors = append(errors, fmt.Sprintf("%s.name %s is used by another hook", field, hook.Name))
+		}
+		names[hook.Name] = true
+
+		actions := 0
+		if hook.Job != nil {
+			actions++
+		}
+		if hook.Webhook != nil {
+			actions++
+			if err := validateURL(hook.Webhook.URL, "http", "https"); err != nil {
+				errors = append(errors, fmt.Sprintf("%s.webhook.url is invalid: %v", field, err))
+			} else if err := validateWebhookHost(hook.Webhook.URL); err != nil {
+				errors = append(errors, fmt.Sprintf("%s.webhook.url is invalid: %v", field, err))
+			}
+		}
+		if len(hook.Manifests) > 0 {
+			actions++
+			for j, manifest := range hook.Manifests {
+				if _, err := manifests.Decode([]byte(manifest)); err != nil {
+					errors = append(errors, fmt.Sprintf("%s.manifests[%d] is invalid: %v", field, j, err))
+				}
+			}
+		}
+		if actions != 1 {
+			errors = append(errors, fmt.Sprintf("%s must set exactly one of job, webhook and manifests", field))
+		}
+		if hook.Timeout != nil && hook.Timeout.Duration <= 0 {
+			errors = append(errors, fmt.Sprintf("%s.timeout must be positive", field))
+		}
+	}
+
+	return errors
+}
+
+// validateInstallNamespace checks that the install namespace can be created with its labels
+func validateInstallNamespace(namespace *ksitv1alpha1.InstallNamespaceConfig) []string {
+	var errors []string
+
+	if namespace.Name != "" {
+		for _, msg := range validation.IsDNS1123Label(namespace.Name) {
+			errors = append(errors, fmt.Sprintf("autoInstall.namespace.name is invalid: %s", msg))
+		}
+	}
+
+	keys := make([]string, 0, len(namespace.Labels))
+	for key := range namespace.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !isValidLabelKey(key) {
+			errors = append(errors, fmt.Sprintf("autoInstall.namespace.labels has an invalid key: %s", key))
+		}
+		if !isValidLabelValue(namespace.Labels[key]) {
+			errors = append(errors, fmt.Sprintf("autoInstall.namespace.labels has an invalid value for %s", key))
+		}
+	}
+
+	return errors
+}
+
+// skipsCRDs reports whether autoInstall or one of its cluster overrides sets skipCRDs
+func skipsCRDs(install *ksitv1alpha1.InstallConfig) bool {
+	if install.SkipCRDs {
+		return true
+	}
+	for _, override := range install.ClusterOverrides {
+		if override.SkipCRDs != nil && *override.SkipCRDs {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHelmClusterOverrides reports whether a cluster override changes the Helm values or version
+func hasHelmClusterOverrides(install *ksitv1alpha1.InstallConfig) bool {
+	for _, override := range install.ClusterOverrides {
+		if override.Version != "" || len(override.Values) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasComponentOverrides reports whether resources or scheduling are overridden for any cluster
+func hasComponentOverrides(install *ksitv1alpha1.InstallConfig) bool {
+	if install.Overrides != nil {
+		return true
+	}
+	for _, override := range install.ClusterOverrides {
+		if override.Overrides != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFluxSpec checks that the declared Flux resources can be created on the target clusters
+func validateFluxSpec(spec *ksitv1alpha1.FluxSpec) []string {
+	var errors []string
+
+	for i, repo := range spec.GitRepositories {
+		if repo.Name == "" {
+			errors = append(errors, fmt.Sprintf("flux.gitRepositories[%d].name is required", i))
+		}
+		if repo.URL == "" {
+			errors = append(errors, fmt.Sprintf("flux.gitRepositories[%d].url is required", i))
+		} else if err := validateURL(repo.URL, "http", "https", "ssh"); err != nil {
+			errors = append(errors, fmt.Sprintf("flux.gitRepositories[%d].url is invalid: %v", i, err))
+		}
+	}
+
+	for i, ks := range spec.Kustomizations {
+		if ks.Name == "" {
+			errors = append(errors, fmt.Sprintf("flux.kustomizations[%d].name is required", i))
+		}
+		if ks.SourceRef == "" {
+			errors = append(errors, fmt.Sprintf("flux.kustomizations[%d].sourceRef is required", i))
+		}
+	}
+
+	return errors
+}
+
+// validateFluxBootstrap checks the GitRepository and root Kustomization the Flux
+// integration bootstraps from its config
+func validateFluxBootstrap(integration *ksitv1alpha1.Integration) []string {
+	var errors []string
+	config := integration.Spec.Config
+
+	gitURL := config["gitURL"]
+	if gitURL == "" {
+		for _, key := range []string{"branch", "path", "interval", "gitSecretRef", "prune"} {
+			if config[key] != "" {
+				errors = append(errors, fmt.Sprintf("%s in config requires gitURL", key))
+			}
+		}
+		return errors
+	}
+	// Templated URLs are only known once rendered for a cluster
+	if !strings.Contains(gitURL, "{{") {
+		if err := validateURL(gitURL, "http", "https", "ssh"); err != nil {
+			errors = append(errors, fmt.Sprintf("gitURL in config is invalid: %v", err))
+		}
+	}
+	if interval := config["interval"]; interval != "" && !strings.Contains(interval, "{{") {
+		if d, err := time.ParseDuration(interval); err != nil || d <= 0 {
+			errors = append(errors, fmt.Sprintf("invalid interval in config: %s", interval))
+		}
+	}
+	if prune := config["prune"]; prune != "" && prune != "true" && prune != "false" {
+		errors = append(errors, fmt.Sprintf("prune in config must be true or false, not %s", prune))
+	}
+	if spec := integration.Spec.Flux; spec != nil {
+		bootstrap := flux.BootstrapName(integration.Namespace, integration.Name)
+		for _, repo := range spec.GitRepositories {
+			if repo.Name == bootstrap {
+				errors = append(errors, fmt.Sprintf("flux.gitRepositories cannot declare %s, the GitRepository bootstrapped from gitURL", repo.Name))
+			}
+		}
+		for _, ks := range spec.Kustomizations {
+			if ks.Name == bootstrap {
+				errors = append(errors, fmt.Sprintf("flux.kustomizations cannot declare %s, the Kustomization bootstrapped from gitURL", ks.Name))
+			}
+		}
+	}
+	return errors
+}
+
+// validateKyvernoSpec checks that the declared policies decode into named ClusterPolicies
+func validateKyvernoSpec(spec *ksitv1alpha1.KyvernoSpec) []string {
+	var errors []string
+
+	for i, manifest := range spec.Policies {
+		objs, err := manifests.Decode([]byte(manifest))
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("kyverno.policies[%d] is invalid: %v", i, err))
+			continue
+		}
+		for _, obj := range objs {
+			if obj.GroupVersionKind().GroupKind() != kyverno.ClusterPolicyGVK.GroupKind() || obj.GetName() == "" {
+				errors = append(errors, fmt.Sprintf("kyverno.policies[%d] has an object that is not a named kyverno.io ClusterPolicy", i))
+				break
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateWorkloads checks that the inline workload manifests decode into named objects.
+// ConfigMap manifests are only read by the controller.
+func validateWorkloads(spec *ksitv1alpha1.WorkloadsSpec) []string {
+	var errors []string
+
+	for i, manifest := range spec.Manifests {
+		objs, err := manifests.Decode([]byte(manifest))
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("workloads.manifests[%d] is invalid: %v", i, err))
+			continue
+		}
+		for _, obj := range objs {
+			if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+				errors = append(errors, fmt.Sprintf("workloads.manifests[%d] has an object without an apiVersion, a kind or a name", i))
+				break
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateTemplates rejects values whose per-cluster templates do not parse
+func validateTemplates(field string, values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errors []string
+	for _, key := range keys {
+		if err := template.Validate(values[key]); err != nil {
+			errors = append(errors, fmt.Sprintf("%s.%s is not a valid template: %v", field, key, err))
+		}
+	}
+	return errors
+}
+
+// isJSONObject reports whether raw encodes a JSON object
+func isJSONObject(raw []byte) bool {
+	var obj map[string]interface{}
+	return json.Unmarshal(raw, &obj) == nil && obj != nil
+}
+
+// validateURL checks that raw is an absolute URL with a host and one of the given schemes
+func validateURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q must be an absolute URL with a host", raw)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q must use scheme %s", raw, strings.Join(schemes, " or "))
+}
+
+// validateWebhookHost rejects webhook URLs that name a loopback, link-local or
+// unspecified address, which the controller would refuse to call anyway
+func validateWebhookHost(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%q must not call the controller itself", raw)
+	}
+	if ip := net.ParseIP(host); ip != nil && !hooks.AllowedWebhookIP(ip) {
+		return fmt.Errorf("%q must not call a loopback, link-local or unspecified address", raw)
+	}
 	return nil
 }
 
@@ -149,7 +708,7 @@ func (v *IntegrationValidator) ValidateCreate(ctx context.Context, obj runtime.O
 	if len(errors) > 0 {
 		return nil, fmt.Errorf("%s", strings.Join(errors, "; "))
 	}
-	return nil, nil
+	return v.warnings(ctx, integration, v.DenyUnregisteredClusters)
 }
 
 // ValidateUpdate implements admission.CustomValidator
@@ -159,11 +718,83 @@ func (v *IntegrationValidator) ValidateUpdate(ctx context.Context, oldObj, newOb
 		return nil, fmt.Errorf("expected Integration but got %T", newObj)
 	}
 
+	if newIntegration.DeletionTimestamp != nil {
+		// Removing finalizers must never be blocked
+		return nil, nil
+	}
+	oldIntegration, ok := oldObj.(*ksitv1alpha1.Integration)
+	if !ok {
+		return nil, fmt.Errorf("expected Integration but got %T", oldObj)
+	}
+
 	errors := v.validateIntegration(newIntegration)
 	if len(errors) > 0 {
 		return nil, fmt.Errorf("%s", strings.Join(errors, "; "))
 	}
-	return nil, nil
+	return v.warnings(ctx, newIntegration, v.DenyUnregisteredClusters && targetClustersChanged(oldIntegration, newIntegration))
+}
+
+// targetClustersChanged reports whether an update changes the target clusters or where
+// they are looked up. Other updates are not denied for clusters that were already
+// unregistered, so unrelated edits and finalizer changes keep working.
+func targetClustersChanged(old, updated *ksitv1alpha1.Integration) bool {
+	return !slices.Equal(old.Spec.TargetClusters, updated.Spec.TargetClusters) ||
+		old.ClusterScoped() != updated.ClusterScoped()
+}
+
+// warnings returns the admission warnings for a valid Integration. Unregistered target
+// clusters are an error instead with deny.
+func (v *IntegrationValidator) warnings(ctx context.Context, integration *ksitv1alpha1.Integration, deny bool) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	unregistered, err := v.unregisteredClusters(ctx, integration)
+	switch {
+	case err != nil:
+		warnings = append(warnings, fmt.Sprintf("targetClusters were not checked: %v", err))
+	case len(unregistered) > 0:
+		message := fmt.Sprintf("targetClusters %s are not registered by an IntegrationTarget", strings.Join(unregistered, ", "))
+		if !integration.ClusterScoped() {
+			message += " in namespace " + integration.Namespace
+		}
+		if deny {
+			return nil, fmt.Errorf("%s", message)
+		}
+		warnings = append(warnings, message+"; they fail until one is created")
+	}
+
+	if v.Charts != nil {
+		warnings = append(warnings, v.Charts.chartWarnings(ctx, integration)...)
+	}
+	return warnings, nil
+}
+
+// unregisteredClusters returns the targetClusters that no IntegrationTarget registers
+// where the Integration looks for them: its own namespace, or every namespace for
+// Cluster scope
+func (v *IntegrationValidator) unregisteredClusters(ctx context.Context, integration *ksitv1alpha1.Integration) ([]string, error) {
+	if v.Client == nil || len(integration.Spec.TargetClusters) == 0 {
+		return nil, nil
+	}
+
+	var opts []client.ListOption
+	if !integration.ClusterScoped() {
+		opts = append(opts, client.InNamespace(integration.Namespace))
+	}
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := v.Client.List(ctx, targets, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+	registered := make(map[string]bool, len(targets.Items))
+	for _, target := range targets.Items {
+		registered[target.Spec.ClusterName] = true
+	}
+
+	var unregistered []string
+	for _, clusterName := range integration.Spec.TargetClusters {
+		if !registered[clusterName] {
+			unregistered = append(unregistered, clusterName)
+		}
+	}
+	return unregistered, nil
 }
 
 // ValidateDelete implements admission.CustomValidator
@@ -175,13 +806,15 @@ func (v *IntegrationValidator) ValidateDelete(ctx context.Context, obj runtime.O
 // IntegrationTargetValidator validates IntegrationTarget resources
 type IntegrationTargetValidator struct {
 	Client  client.Client
-	decoder admission.Decoder
+	decoder *admission.Decoder
 }
 
-// NewIntegrationTargetValidator creates a new IntegrationTargetValidator
-func NewIntegrationTargetValidator(c client.Client) *IntegrationTargetValidator {
+// NewIntegrationTargetValidator creates a new IntegrationTargetValidator. Its Handle
+// decodes admission requests with the types of scheme, normally the manager's.
+func NewIntegrationTargetValidator(c client.Client, scheme *runtime.Scheme) *IntegrationTargetValidator {
 	return &IntegrationTargetValidator{
-		Client: c,
+		Client:  c,
+		decoder: admission.NewDecoder(scheme),
 	}
 }
 
@@ -252,12 +885,6 @@ func isValidLabelValue(value string) bool {
 	return labelValueRegex.MatchString(value)
 }
 
-// InjectDecoder injects the decoder
-func (v *IntegrationTargetValidator) InjectDecoder(d admission.Decoder) error {
-	v.decoder = d
-	return nil
-}
-
 // ValidateCreate implements admission.CustomValidator
 func (v *IntegrationTargetValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	target, ok := obj.(*ksitv1alpha1.IntegrationTarget)
@@ -337,6 +964,9 @@ func ValidateIntegrationType(w http.ResponseWriter, r *http.Request) {
 		ksitv1alpha1.IntegrationTypeFlux,
 		ksitv1alpha1.IntegrationTypePrometheus,
 		ksitv1alpha1.IntegrationTypeIstio,
+		ksitv1alpha1.IntegrationTypeGrafana,
+		ksitv1alpha1.IntegrationTypeCertManager,
+		ksitv1alpha1.IntegrationTypeKyverno,
 	}
 
 	isValid := false
@@ -359,11 +989,11 @@ func ValidateIntegrationType(w http.ResponseWriter, r *http.Request) {
 // SetupWebhookServer sets up the webhook server with validating webhooks
 func SetupWebhookServer(mgr ctrl.Manager) error {
 	// Register Integration validator
-	integrationValidator := NewIntegrationValidator(mgr.GetClient())
+	integrationValidator := NewIntegrationValidator(mgr.GetClient(), mgr.GetScheme())
 	mgr.GetWebhookServer().Register("/validate-v1alpha1-integration", &webhook.Admission{Handler: integrationValidator})
 
 	// Register IntegrationTarget validator
-	targetValidator := NewIntegrationTargetValidator(mgr.GetClient())
+	targetValidator := NewIntegrationTargetValidator(mgr.GetClient(), mgr.GetScheme())
 	mgr.GetWebhookServer().Register("/validate-v1alpha1-integrationtarget", &webhook.Admission{Handler: targetValidator})
 
 	return nil
@@ -1,22 +1,45 @@
 package webhook
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
 )
 
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = ksitv1alpha1.AddToScheme(scheme)
+	return scheme
+}
+
+// admissionRequest wraps obj in the request the API server sends a webhook
+func admissionRequest(t *testing.T, obj runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
 func TestValidateIntegration(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = ksitv1alpha1.AddToScheme(scheme)
 	client := fake.NewClientBuilder().WithScheme(scheme).Build()
 
-	validator := NewIntegrationValidator(client)
+	validator := NewIntegrationValidator(client, scheme)
 
 	integration := &ksitv1alpha1.Integration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -36,3 +59,735 @@ func TestValidateIntegration(t *testing.T) {
 	errors := validator.validateIntegration(integration)
 	assert.Empty(t, errors)
 }
+
+func TestValidateInstallConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		install *ksitv1alpha1.InstallConfig
+		errors  int
+	}{
+		{
+			name: "complete helm config",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://argoproj.github.io/argo-helm",
+					Chart:       "argo-cd",
+					ReleaseName: "argocd",
+				},
+			},
+		},
+		{
+			name: "helm chart from an OCI registry",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:   "oci://ghcr.io/argoproj/argo-helm",
+					Chart:        "argo-cd",
+					ReleaseName:  "argocd",
+					ValuesObject: &apiextensionsv1.JSON{Raw: []byte(`{"server":{"replicas":2}}`)},
+				},
+			},
+		},
+		{
+			name: "helm with a negative timeout",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://argoproj.github.io/argo-helm",
+					Chart:       "argo-cd",
+					ReleaseName: "argocd",
+					Atomic:      true,
+					Timeout:     &metav1.Duration{Duration: -time.Minute},
+				},
+			},
+			errors: 1,
+		},
+		{
+			name: "helm chart from a private registry",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:           "oci://registry.internal:5000/charts",
+					Chart:                "argo-cd",
+					ReleaseName:          "argocd",
+					CredentialsSecretRef: "registry-credentials",
+				},
+			},
+		},
+		{
+			name: "helm credentials over http and an invalid CA bundle",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:           "http://charts.internal",
+					Chart:                "argo-cd",
+					ReleaseName:          "argocd",
+					CredentialsSecretRef: "repo-credentials",
+					CABundle:             "not a certificate",
+				},
+			},
+			errors: 2,
+		},
+		{
+			name: "helm valuesObject that is not a map",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:   "https://argoproj.github.io/argo-helm",
+					Chart:        "argo-cd",
+					ReleaseName:  "argocd",
+					ValuesObject: &apiextensionsv1.JSON{Raw: []byte(`["server"]`)},
+				},
+			},
+			errors: 1,
+		},
+		{
+			name:    "helm without helmConfig uses built-in chart",
+			install: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "helm"},
+		},
+		{
+			name: "incomplete helm config",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:    true,
+				Method:     "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{Repository: "argoproj.github.io/argo-helm"},
+			},
+			errors: 3,
+		},
+		{
+			name:    "manifest with https url",
+			install: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "manifest", ManifestURL: "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"},
+		},
+		{
+			// Whether the URL is required depends on the type
+			name:    "manifest without url",
+			install: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "manifest"},
+		},
+		{
+			name: "istio profile with version only",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:    true,
+				Method:     "helm",
+				Profile:    ksitv1alpha1.IstioProfileAmbient,
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{Version: "1.21.0"},
+			},
+		},
+		{
+			name: "profile with manifest",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:     true,
+				Method:      "manifest",
+				Profile:     ksitv1alpha1.IstioProfileDemo,
+				ManifestURL: "https://example.com/istio.yaml",
+			},
+			errors: 1,
+		},
+		{
+			name: "cluster override with invalid selector",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://argoproj.github.io/argo-helm",
+					Chart:       "argo-cd",
+					ReleaseName: "argocd",
+				},
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "edge node"}},
+				}},
+			},
+			errors: 1,
+		},
+		{
+			name: "cluster override without helmConfig",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:          true,
+				Method:           "helm",
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{{Version: "6.0.0"}},
+			},
+			errors: 1,
+		},
+		{
+			name: "per-cluster component overrides on manifest install",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:     true,
+				Method:      "manifest",
+				ManifestURL: "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml",
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{{
+					Overrides: &ksitv1alpha1.ComponentOverrides{PriorityClassName: "system-cluster-critical"},
+				}},
+			},
+		},
+		{
+			name: "component overrides for unknown chart",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://charts.example.com",
+					Chart:       "custom-argo",
+					ReleaseName: "argocd",
+				},
+				Overrides: &ksitv1alpha1.ComponentOverrides{NodeSelector: map[string]string{"tier": "edge"}},
+			},
+			errors: 1,
+		},
+		{
+			name: "post-install hooks",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				PostInstall: []ksitv1alpha1.PostInstallHook{
+					{Name: "rotate-admin", Job: &ksitv1alpha1.PostInstallJob{Image: "bitnami/kubectl"}},
+					{Name: "notify", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: "https://hooks.example.com/ksit"}},
+					{Name: "seed", Manifests: []string{"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: datasources\n"}},
+				},
+			},
+		},
+		{
+			name: "invalid post-install hooks",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				PostInstall: []ksitv1alpha1.PostInstallHook{
+					{Name: "notify", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: "hooks.example.com"}},
+					{Name: "notify", Job: &ksitv1alpha1.PostInstallJob{Image: "busybox"}, Manifests: []string{"kind: ConfigMap"}},
+					{Name: "empty"},
+					{Name: "metadata", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: "http://169.254.169.254/latest/meta-data"}},
+					{Name: "local", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: "http://localhost:8081/readyz"}},
+				},
+			},
+			errors: 6,
+		},
+		{
+			name:    "operator with defaults",
+			install: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "operator"},
+		},
+		{
+			name: "operator with component overrides",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:   true,
+				Method:    "operator",
+				Overrides: &ksitv1alpha1.ComponentOverrides{PriorityClassName: "system-cluster-critical"},
+			},
+			errors: 1,
+		},
+		{
+			name: "operatorConfig with helm",
+			install: &ksitv1alpha1.InstallConfig{
+				Enabled:        true,
+				Method:         "helm",
+				OperatorConfig: &ksitv1alpha1.OperatorInstallConfig{Package: "argocd-operator"},
+			},
+			errors: 1,
+		},
+		{
+			name:    "manifest with http url",
+			install: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "manifest", ManifestURL: "http://example.com/install.yaml"},
+			errors:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, validateInstallConfig(tt.install), tt.errors)
+		})
+	}
+}
+
+func TestValidateIntegrationSkipsDisabledAutoInstall(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+			AutoInstall:    &ksitv1alpha1.InstallConfig{Method: "manifest"},
+		},
+	}
+
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateManifestInstallWithoutURL(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	// Flux installs its release manifest by default
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+			AutoInstall:    &ksitv1alpha1.InstallConfig{Enabled: true, Method: "manifest"},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeIstio
+	integration.Spec.Config = map[string]string{"namespace": "istio-system"}
+	assert.Equal(t, []string{"autoInstall.manifestUrl is required to install istio from a manifest"}, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationImpersonation(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a-flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:              ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters:    []string{"cluster1"},
+			Config:            map[string]string{"namespace": "flux-system"},
+			ImpersonateGroups: []string{"team-a"},
+		},
+	}
+	assert.Equal(t, []string{"impersonateGroups requires impersonateUser"}, validator.validateIntegration(integration))
+
+	integration.Spec.ImpersonateUser = "ksit:team-a"
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationReconcileInterval(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:              ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters:    []string{"cluster1"},
+			Config:            map[string]string{"serverURL": "https://argocd.example.com"},
+			ReconcileInterval: &metav1.Duration{Duration: time.Second},
+		},
+	}
+	assert.Equal(t, []string{"reconcileInterval must be at least 5s"}, validator.validateIntegration(integration))
+
+	integration.Spec.ReconcileInterval.Duration = 5 * time.Minute
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationProfile(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+			AutoInstall:    &ksitv1alpha1.InstallConfig{Enabled: true, Method: "helm", Profile: ksitv1alpha1.IstioProfileMinimal},
+		},
+	}
+	assert.Equal(t, []string{"autoInstall.profile is only supported for istio"}, validator.validateIntegration(integration))
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeIstio
+	integration.Spec.Config["namespace"] = "istio-system"
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationSkipCRDs(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "istio-system"},
+			AutoInstall:    &ksitv1alpha1.InstallConfig{Enabled: true, Method: "helm", SkipCRDs: true},
+		},
+	}
+	assert.Equal(t, []string{"autoInstall.skipCRDs is only supported for prometheus"}, validator.validateIntegration(integration))
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypePrometheus
+	integration.Spec.Config = map[string]string{"url": "http://prometheus:9090"}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.AutoInstall.ManifestURL = "http://example.com/crds.yaml"
+	assert.Len(t, validator.validateIntegration(integration), 1)
+}
+
+func TestValidateIntegrationOperatorPackage(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "istio-system"},
+			AutoInstall:    &ksitv1alpha1.InstallConfig{Enabled: true, Method: "operator"},
+		},
+	}
+	assert.Equal(t, []string{"autoInstall.operatorConfig.package is required to install istio with an operator"}, validator.validateIntegration(integration))
+
+	integration.Spec.AutoInstall.OperatorConfig = &ksitv1alpha1.OperatorInstallConfig{Package: "sailoperator", Channel: "stable"}
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationHealthScoring(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+			HealthScoring:  &ksitv1alpha1.HealthScoring{Weights: map[string]int32{"controlPlane": 60, "alerts": 0}},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.HealthScoring.Weights = map[string]int32{"endpoints": -1, "latency": 10}
+	assert.Equal(t, []string{
+		"healthScoring.weights.endpoints cannot be negative",
+		"healthScoring.weights has unknown signal latency",
+	}, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationTemplates(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-prometheus", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypePrometheus,
+			TargetClusters: []string{"cluster1"},
+			Config: map[string]string{
+				"url":     "http://prometheus.{{ .Cluster.Name }}.example.com",
+				"cluster": "{{ .Cluster.Labels.region",
+			},
+		},
+	}
+
+	errors := validator.validateIntegration(integration)
+	if assert.Len(t, errors, 1) {
+		assert.Contains(t, errors[0], "config.cluster is not a valid template")
+	}
+}
+
+func TestValidateFluxSpec(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+			Flux: &ksitv1alpha1.FluxSpec{
+				GitRepositories: []ksitv1alpha1.FluxGitRepository{{Name: "fleet", URL: "ssh://git@github.com/example/fleet"}},
+				Kustomizations:  []ksitv1alpha1.FluxKustomization{{Name: "apps", SourceRef: "fleet"}},
+			},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.Flux.GitRepositories[0].URL = "ftp://example.com/fleet"
+	integration.Spec.Flux.Kustomizations[0].SourceRef = ""
+	assert.Len(t, validator.validateIntegration(integration), 2)
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeIstio
+	assert.Contains(t, validator.validateIntegration(integration), "spec.flux is only supported for flux integrations")
+}
+
+func TestValidateFluxBootstrap(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config: map[string]string{
+				"namespace": "flux-system",
+				"gitURL":    "https://github.com/example/fleet",
+				"path":      "./clusters/{{ .Cluster.Name }}",
+				"interval":  "5m",
+			},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.Config["interval"] = "often"
+	integration.Spec.Config["prune"] = "yes"
+	integration.Spec.Flux = &ksitv1alpha1.FluxSpec{
+		Kustomizations: []ksitv1alpha1.FluxKustomization{{Name: "default-fleet", SourceRef: "fleet"}},
+	}
+	assert.Equal(t, []string{
+		"invalid interval in config: often",
+		"prune in config must be true or false, not yes",
+		"flux.kustomizations cannot declare default-fleet, the Kustomization bootstrapped from gitURL",
+	}, validator.validateIntegration(integration))
+
+	integration.Spec.Flux = nil
+	integration.Spec.Config = map[string]string{"namespace": "flux-system", "branch": "main"}
+	assert.Equal(t, []string{"branch in config requires gitURL"}, validator.validateIntegration(integration))
+}
+
+func TestValidateKyvernoSpec(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "policies", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeKyverno,
+			TargetClusters: []string{"cluster1"},
+			Kyverno: &ksitv1alpha1.KyvernoSpec{
+				Policies: []string{"apiVersion: kyverno.io/v1\nkind: ClusterPolicy\nmetadata:\n  name: require-labels\n"},
+			},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.Kyverno.Policies = append(integration.Spec.Kyverno.Policies, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n")
+	assert.Equal(t, []string{"kyverno.policies[1] has an object that is not a named kyverno.io ClusterPolicy"}, validator.validateIntegration(integration))
+}
+
+func TestValidateWorkloads(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypePrometheus,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"url": "http://prometheus:9090"},
+			Workloads: &ksitv1alpha1.WorkloadsSpec{
+				Manifests: []string{"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n"},
+			},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.Workloads.Manifests = append(integration.Spec.Workloads.Manifests, "kind: ConfigMap\n", "kind: [")
+	assert.Len(t, validator.validateIntegration(integration), 2)
+}
+
+func TestValidateIntegrationHardening(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypePrometheus,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"url": "http://prometheus:9090"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled:   true,
+				Method:    "helm",
+				Hardening: &ksitv1alpha1.HardeningConfig{AllowedNamespaces: []string{"monitoring"}},
+			},
+		},
+	}
+	assert.Equal(t, []string{
+		"autoInstall.hardening is only supported for argocd and flux",
+		"autoInstall.hardening.allowedNamespaces requires autoInstall.hardening.networkPolicies",
+	}, validator.validateIntegration(integration))
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeFlux
+	integration.Spec.Config = map[string]string{"namespace": "flux-system"}
+	integration.Spec.AutoInstall.Hardening.NetworkPolicies = true
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateInstallNamespace(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "istio-system"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Namespace: &ksitv1alpha1.InstallNamespaceConfig{
+					Name:        "istio-system",
+					Labels:      map[string]string{"topology.istio.io/network": "network1"},
+					Annotations: map[string]string{"owner": "mesh team"},
+				},
+			},
+		},
+	}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.AutoInstall.Namespace.Name = "Istio_System"
+	integration.Spec.AutoInstall.Namespace.Labels["bad key!"] = "not a value!"
+	assert.Len(t, validator.validateIntegration(integration), 3)
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeFlux
+	integration.Spec.AutoInstall.Namespace = &ksitv1alpha1.InstallNamespaceConfig{Name: "gitops"}
+	assert.Equal(t, []string{"autoInstall.namespace.name must be flux-system for flux"}, validator.validateIntegration(integration))
+}
+
+func TestValidateTargetSelector(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "default"},
+		Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeFlux, Config: map[string]string{"namespace": "flux-system"}},
+	}
+	assert.Equal(t, []string{"targetClusters cannot be empty unless targetSelector is set"}, validator.validateIntegration(integration))
+
+	integration.Spec.TargetSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.TargetSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}}
+	assert.Len(t, validator.validateIntegration(integration), 1)
+}
+
+func TestValidateClusterScope(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+	validator.ClusterScopeNamespaces = []string{"ksit-system"}
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "team-a"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			Scope:          ksitv1alpha1.ScopeCluster,
+			TargetClusters: []string{"edge-1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+		},
+	}
+	errs := validator.validateIntegration(integration)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0], "scope Cluster is not allowed in namespace team-a")
+
+	integration.Namespace = "ksit-system"
+	assert.Empty(t, validator.validateIntegration(integration))
+}
+
+func TestValidateIntegrationConfigSecretRefs(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypePrometheus,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"username": "ksit"},
+			ConfigSecretRefs: []ksitv1alpha1.ConfigSecretRef{
+				{Key: "url", SecretName: "prometheus"},
+				{Key: "password", SecretName: "prometheus-auth"},
+			},
+		},
+	}
+	// A required key may come from a Secret
+	assert.Empty(t, validator.validateIntegration(integration))
+
+	integration.Spec.ConfigSecretRefs = append(integration.Spec.ConfigSecretRefs,
+		ksitv1alpha1.ConfigSecretRef{Key: "password", SecretName: "other"},
+		ksitv1alpha1.ConfigSecretRef{Key: "username", SecretName: "prometheus-auth"},
+	)
+	assert.Equal(t, []string{
+		"configSecretRefs sets config key password more than once",
+		"config key username is set in both config and configSecretRefs",
+	}, validator.validateIntegration(integration))
+}
+
+func TestIntegrationValidatorHandle(t *testing.T) {
+	validator := NewIntegrationValidator(nil, newScheme())
+
+	integration := &ksitv1alpha1.Integration{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ksitv1alpha1.GroupVersion.String(), Kind: "Integration"},
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "default"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster1"},
+			Config:         map[string]string{"namespace": "flux-system"},
+		},
+	}
+	resp := validator.Handle(context.Background(), admissionRequest(t, integration))
+	assert.True(t, resp.Allowed, resp.Result)
+
+	integration.Spec.Type = "vault"
+	resp = validator.Handle(context.Background(), admissionRequest(t, integration))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "invalid integration type: vault")
+
+	resp = validator.Handle(context.Background(), admission.Request{})
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, int32(400), resp.Result.Code)
+}
+
+func TestValidateTargetClustersRegistered(t *testing.T) {
+	ctx := context.Background()
+	target := func(namespace, clusterName string) *ksitv1alpha1.IntegrationTarget {
+		return &ksitv1alpha1.IntegrationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: namespace},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: clusterName},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(newScheme()).
+		WithObjects(target("team-a", "edge-1"), target("team-b", "edge-2")).Build()
+	validator := NewIntegrationValidator(c, newScheme())
+	validator.ClusterScopeNamespaces = []string{"team-a"}
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "team-a"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"edge-1", "edge-2"},
+			Config:         map[string]string{"namespace": "flux-system"},
+		},
+	}
+	warnings, err := validator.ValidateCreate(ctx, integration)
+	require.NoError(t, err)
+	assert.Equal(t, admission.Warnings{"targetClusters edge-2 are not registered by an IntegrationTarget in namespace team-a; they fail until one is created"}, warnings)
+
+	validator.DenyUnregisteredClusters = true
+	old := integration.DeepCopy()
+	old.Spec.TargetClusters = []string{"edge-1"}
+	_, err = validator.ValidateUpdate(ctx, old, integration)
+	assert.EqualError(t, err, "targetClusters edge-2 are not registered by an IntegrationTarget in namespace team-a")
+	resp := validator.Handle(ctx, admissionRequest(t, integration))
+	assert.False(t, resp.Allowed)
+
+	// Updates that leave the target clusters alone only warn, and deletions always pass
+	warnings, err = validator.ValidateUpdate(ctx, integration.DeepCopy(), integration)
+	require.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	request := admissionRequest(t, integration)
+	request.Operation = admissionv1.Update
+	request.OldObject = request.Object
+	assert.True(t, validator.Handle(ctx, request).Allowed)
+	deleting := integration.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	deleting.Finalizers = []string{"ksit.io/finalizer"}
+	_, err = validator.ValidateUpdate(ctx, old, deleting)
+	assert.NoError(t, err)
+	assert.True(t, validator.Handle(ctx, admissionRequest(t, deleting)).Allowed)
+
+	// Cluster scope finds the clusters registered in every namespace
+	integration.Spec.Scope = ksitv1alpha1.ScopeCluster
+	warnings, err = validator.ValidateCreate(ctx, integration)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestIntegrationTargetValidatorHandle(t *testing.T) {
+	validator := NewIntegrationTargetValidator(nil, newScheme())
+
+	target := &ksitv1alpha1.IntegrationTarget{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ksitv1alpha1.GroupVersion.String(), Kind: "IntegrationTarget"},
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "default"},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "cluster1"},
+	}
+	resp := validator.Handle(context.Background(), admissionRequest(t, target))
+	assert.True(t, resp.Allowed, resp.Result)
+
+	target.Spec.ClusterName = ""
+	resp = validator.Handle(context.Background(), admissionRequest(t, target))
+	assert.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, "clusterName is required")
+}
@@ -0,0 +1,282 @@
+// Package apiserver holds the building blocks of the KSIT fleet API server
+package apiserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+// ErrUnauthenticated is returned by authenticators that do not recognize the credentials
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// User is an authenticated API client
+type User struct {
+	Name   string
+	UID    string
+	Groups []string
+}
+
+// Credentials are what a client presented with its request. They are kept separate
+// from the transport so the same authenticators serve REST and gRPC requests.
+type Credentials struct {
+	// Token is the bearer token, if any
+	Token string
+	// PeerCertificates is the verified client certificate chain, leaf first
+	PeerCertificates []*x509.Certificate
+}
+
+// CredentialsFromRequest extracts the bearer token and verified client certificates of a request
+func CredentialsFromRequest(r *http.Request) Credentials {
+	var creds Credentials
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		creds.Token = strings.TrimSpace(token)
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		creds.PeerCertificates = r.TLS.VerifiedChains[0]
+	}
+	return creds
+}
+
+// Authenticator identifies the user behind a set of credentials. It returns
+// ErrUnauthenticated when the credentials are missing or not valid.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (*User, error)
+}
+
+// Access describes one API call being authorized
+type Access struct {
+	// Verb is the Kubernetes verb of the call: get, list, create, update, patch or delete
+	Verb string
+	// Path is the request path, e.g. /api/v1/clusters/edge-1
+	Path string
+}
+
+// ReadOnly reports whether the call only reads data
+func (a Access) ReadOnly() bool {
+	return a.Verb == "get" || a.Verb == "list" || a.Verb == "watch"
+}
+
+// AccessFromRequest maps an HTTP request to the Access being asked for
+func AccessFromRequest(r *http.Request) Access {
+	verb := "create"
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		verb = "get"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+	return Access{Verb: verb, Path: r.URL.Path}
+}
+
+// Authorizer decides whether a user may make an API call. The reason is returned
+// to the client when the call is denied.
+type Authorizer interface {
+	Authorize(ctx context.Context, user *User, access Access) (allowed bool, reason string, err error)
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the authenticated user stored in ctx by the auth middleware
+func UserFrom(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userKey{}).(*User)
+	return user, ok
+}
+
+// WithAuth wraps an HTTP handler so that every request is authenticated and
+// authorized first. Unauthenticated requests get 401, denied requests get 403.
+func WithAuth(next http.Handler, authn Authenticator, authz Authorizer, log logr.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := authn.Authenticate(r.Context(), CredentialsFromRequest(r))
+		if err != nil {
+			if !errors.Is(err, ErrUnauthenticated) {
+				log.Error(err, "failed to authenticate request", "path", r.URL.Path)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ksit"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		access := AccessFromRequest(r)
+		allowed, reason, err := authz.Authorize(r.Context(), user, access)
+		if err != nil {
+			log.Error(err, "failed to authorize request", "user", user.Name, "path", access.Path)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			log.V(1).Info("request denied", "user", user.Name, "verb", access.Verb, "path", access.Path, "reason", reason)
+			http.Error(w, fmt.Sprintf("Forbidden: %s", reason), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+	})
+}
+
+// TokenAuthenticator authenticates static bearer tokens
+type TokenAuthenticator struct {
+	tokens map[string]*User
+}
+
+// NewTokenAuthenticator reads a CSV token file with token,user,uid,"group1,group2"
+// lines, the format kube-apiserver uses for --token-auth-file
+func NewTokenAuthenticator(path string) (*TokenAuthenticator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %w", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	tokens := map[string]*User{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		if len(record) < 2 || record[0] == "" || record[1] == "" {
+			return nil, fmt.Errorf("token file line %d: token and user are required", line)
+		}
+
+		user := &User{Name: record[1]}
+		if len(record) > 2 {
+			user.UID = record[2]
+		}
+		if len(record) > 3 && record[3] != "" {
+			user.Groups = strings.Split(record[3], ",")
+		}
+		tokens[record[0]] = user
+	}
+
+	return &TokenAuthenticator{tokens: tokens}, nil
+}
+
+// Authenticate looks up the bearer token, comparing in constant time
+func (a *TokenAuthenticator) Authenticate(_ context.Context, creds Credentials) (*User, error) {
+	if creds.Token == "" {
+		return nil, ErrUnauthenticated
+	}
+	var found *User
+	for token, user := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(creds.Token)) == 1 {
+			found = user
+		}
+	}
+	if found == nil {
+		return nil, ErrUnauthenticated
+	}
+	return found, nil
+}
+
+// CertificateAuthenticator authenticates clients by their verified TLS certificate.
+// The common name is the user and the organizations are the groups, as in Kubernetes.
+type CertificateAuthenticator struct{}
+
+// Authenticate returns the user of the client certificate
+func (CertificateAuthenticator) Authenticate(_ context.Context, creds Credentials) (*User, error) {
+	if len(creds.PeerCertificates) == 0 || creds.PeerCertificates[0].Subject.CommonName == "" {
+		return nil, ErrUnauthenticated
+	}
+	subject := creds.PeerCertificates[0].Subject
+	return &User{Name: subject.CommonName, Groups: subject.Organization}, nil
+}
+
+// GroupAuthorizer allows read-only calls to ReadGroups and every call to WriteGroups.
+// An empty ReadGroups lets any authenticated user read.
+type GroupAuthorizer struct {
+	ReadGroups  []string
+	WriteGroups []string
+}
+
+// Authorize checks the user's groups against the groups allowed for the call
+func (a GroupAuthorizer) Authorize(_ context.Context, user *User, access Access) (bool, string, error) {
+	inAny := func(groups []string) bool {
+		return slices.ContainsFunc(user.Groups, func(g string) bool { return slices.Contains(groups, g) })
+	}
+
+	if inAny(a.WriteGroups) {
+		return true, "", nil
+	}
+	if !access.ReadOnly() {
+		return false, fmt.Sprintf("user %q may not %s %s", user.Name, access.Verb, access.Path), nil
+	}
+	if len(a.ReadGroups) == 0 || inAny(a.ReadGroups) {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("user %q may not read %s", user.Name, access.Path), nil
+}
+
+// NewAuth builds the authenticator and authorizer selected by cfg. The client is
+// used for TokenReviews and SubjectAccessReviews in kubernetes mode.
+func NewAuth(cfg config.APIAuthConfig, c client.Client) (Authenticator, Authorizer, error) {
+	groups := GroupAuthorizer{ReadGroups: cfg.ReadGroups, WriteGroups: cfg.WriteGroups}
+
+	switch cfg.Mode {
+	case "", "kubernetes":
+		return &TokenReviewAuthenticator{Client: c}, &SubjectAccessReviewAuthorizer{Client: c}, nil
+	case "token":
+		authn, err := NewTokenAuthenticator(cfg.TokenFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return authn, groups, nil
+	case "mtls":
+		return CertificateAuthenticator{}, groups, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported API auth mode: %s", cfg.Mode)
+	}
+}
+
+// TLSConfig returns the server TLS settings needed by cfg: in mtls mode client
+// certificates are required and verified against the client CA bundle.
+// The caller adds the serving certificate.
+func TLSConfig(cfg config.APIAuthConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.Mode != "mtls" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
@@ -0,0 +1,130 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+func serve(handler http.Handler, method, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/clusters", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWithAuthStaticTokens(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(`# token,user,uid,groups
+viewer-token,dashboard,1,"fleet-viewers"
+admin-token,alice,2,"fleet-viewers,fleet-admins"
+`), 0600))
+
+	authn, authz, err := NewAuth(config.APIAuthConfig{
+		Mode:        "token",
+		TokenFile:   tokenFile,
+		ReadGroups:  []string{"fleet-viewers"},
+		WriteGroups: []string{"fleet-admins"},
+	}, nil)
+	require.NoError(t, err)
+
+	var seen *User
+	handler := WithAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = UserFrom(r.Context())
+	}), authn, authz, logr.Discard())
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		code   int
+	}{
+		{name: "no token", method: http.MethodGet, code: http.StatusUnauthorized},
+		{name: "unknown token", method: http.MethodGet, token: "bogus", code: http.StatusUnauthorized},
+		{name: "viewer reads", method: http.MethodGet, token: "viewer-token", code: http.StatusOK},
+		{name: "viewer writes", method: http.MethodPost, token: "viewer-token", code: http.StatusForbidden},
+		{name: "admin writes", method: http.MethodDelete, token: "admin-token", code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, tt.method, tt.token)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+
+	serve(handler, http.MethodGet, "admin-token")
+	require.NotNil(t, seen)
+	assert.Equal(t, "alice", seen.Name)
+	assert.Equal(t, []string{"fleet-viewers", "fleet-admins"}, seen.Groups)
+}
+
+func TestWithAuthKubernetesDelegation(t *testing.T) {
+	var sar *authorizationv1.SubjectAccessReview
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			switch review := obj.(type) {
+			case *authenticationv1.TokenReview:
+				if review.Spec.Token == "sa-token" {
+					review.Status.Authenticated = true
+					review.Status.User = authenticationv1.UserInfo{
+						Username: "system:serviceaccount:monitoring:dashboard",
+						Groups:   []string{"system:serviceaccounts"},
+					}
+				}
+			case *authorizationv1.SubjectAccessReview:
+				sar = review
+				review.Status.Allowed = review.Spec.NonResourceAttributes.Verb == "get"
+			}
+			return nil
+		},
+	}).Build()
+
+	authn, authz, err := NewAuth(config.APIAuthConfig{Mode: "kubernetes"}, c)
+	require.NoError(t, err)
+	handler := WithAuth(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), authn, authz, logr.Discard())
+
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, http.MethodGet, "other-token").Code)
+	assert.Equal(t, http.StatusOK, serve(handler, http.MethodGet, "sa-token").Code)
+	require.NotNil(t, sar)
+	assert.Equal(t, "system:serviceaccount:monitoring:dashboard", sar.Spec.User)
+	assert.Equal(t, "/api/v1/clusters", sar.Spec.NonResourceAttributes.Path)
+
+	rec := serve(handler, http.MethodPatch, "sa-token")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "may not patch /api/v1/clusters")
+}
+
+func TestCertificateAuthenticator(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "edge-dashboard", Organization: []string{"fleet-viewers"}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clusters", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	user, err := CertificateAuthenticator{}.Authenticate(context.Background(), CredentialsFromRequest(req))
+	require.NoError(t, err)
+	assert.Equal(t, &User{Name: "edge-dashboard", Groups: []string{"fleet-viewers"}}, user)
+
+	_, err = CertificateAuthenticator{}.Authenticate(context.Background(), Credentials{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
@@ -0,0 +1,231 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+// Inventory API paths
+const (
+	ClustersPath     = "/api/v1/clusters"
+	IntegrationsPath = "/api/v1/integrations"
+)
+
+// Cluster is a cluster of the inventory as served by the API
+type Cluster struct {
+	Name         string            `json:"name"`
+	Namespace    string            `json:"namespace"`
+	Status       string            `json:"status"`
+	Version      string            `json:"version,omitempty"`
+	NodeCount    int               `json:"nodeCount"`
+	LastSeen     time.Time         `json:"lastSeen"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Capabilities []string          `json:"capabilities,omitempty"`
+}
+
+// Integration summarizes an Integration for the API. Its config is left out, since it
+// may hold credentials.
+type Integration struct {
+	Namespace      string                       `json:"namespace"`
+	Name           string                       `json:"name"`
+	Type           string                       `json:"type"`
+	Enabled        bool                         `json:"enabled"`
+	Phase          string                       `json:"phase,omitempty"`
+	Message        string                       `json:"message,omitempty"`
+	TargetClusters []string                     `json:"targetClusters,omitempty"`
+	HealthScore    *int32                       `json:"healthScore,omitempty"`
+	ClusterSummary *ksitv1alpha1.ClusterSummary `json:"clusterSummary,omitempty"`
+	Clusters       []ksitv1alpha1.ClusterStatus `json:"clusters,omitempty"`
+}
+
+// List is the envelope of collection responses
+type List[T any] struct {
+	Items []T `json:"items"`
+	// Metadata is set on paginated collections
+	Metadata *ListMeta `json:"metadata,omitempty"`
+}
+
+// ListMeta describes the page of a paginated collection
+type ListMeta struct {
+	// Total is the number of items matching the filters, across all pages
+	Total int `json:"total"`
+	// Continue is passed as the continue query parameter to get the next page. It is
+	// empty on the last page.
+	Continue string `json:"continue,omitempty"`
+}
+
+// InventoryHandler serves the clusters of the inventory on /api/v1/clusters and
+// /api/v1/clusters/{name}, and the Integrations read through c on /api/v1/integrations.
+// The clusters can be filtered, sorted and paginated with the query parameters read by
+// clusterQuery. The namespace query parameter limits the Integrations to one namespace.
+func InventoryHandler(inventory *cluster.ClusterInventory, c client.Reader, log logr.Logger) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(ClustersPath, getOnly(func(w http.ResponseWriter, r *http.Request) {
+		query, err := clusterQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := inventory.Query(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		clusters := make([]Cluster, 0, len(result.Items))
+		for _, info := range result.Items {
+			clusters = append(clusters, clusterFromInfo(info))
+		}
+		writeJSON(w, log, List[Cluster]{
+			Items:    clusters,
+			Metadata: &ListMeta{Total: result.Total, Continue: result.Continue},
+		})
+	}))
+
+	mux.HandleFunc(ClustersPath+"/", getOnly(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, ClustersPath+"/")
+		if name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := inventory.GetCluster(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, log, clusterFromInfo(info))
+	}))
+
+	mux.HandleFunc(IntegrationsPath, getOnly(func(w http.ResponseWriter, r *http.Request) {
+		var opts []client.ListOption
+		if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+			opts = append(opts, client.InNamespace(namespace))
+		}
+
+		list := &ksitv1alpha1.IntegrationList{}
+		if err := c.List(r.Context(), list, opts...); err != nil {
+			log.Error(err, "failed to list integrations")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		sort.Slice(list.Items, func(i, j int) bool {
+			if list.Items[i].Namespace != list.Items[j].Namespace {
+				return list.Items[i].Namespace < list.Items[j].Namespace
+			}
+			return list.Items[i].Name < list.Items[j].Name
+		})
+
+		integrations := make([]Integration, 0, len(list.Items))
+		for i := range list.Items {
+			integrations = append(integrations, integrationSummary(&list.Items[i]))
+		}
+		writeJSON(w, log, List[Integration]{Items: integrations})
+	}))
+
+	return mux
+}
+
+// clusterQuery reads the inventory query of a cluster list request from the status,
+// labelSelector, capability, sortBy (name or lastSeen), order (asc or desc), limit and
+// continue query parameters
+func clusterQuery(params url.Values) (cluster.InventoryQuery, error) {
+	query := cluster.InventoryQuery{
+		Status:     params.Get("status"),
+		Capability: params.Get("capability"),
+		SortBy:     cluster.SortField(params.Get("sortBy")),
+	}
+	if selector := params.Get("labelSelector"); selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return query, fmt.Errorf("invalid labelSelector: %w", err)
+		}
+		query.Selector = parsed
+	}
+	switch order := params.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("invalid order %q, must be asc or desc", order)
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return query, fmt.Errorf("invalid limit %q", limit)
+		}
+		query.Limit = n
+	}
+	if token := params.Get("continue"); token != "" {
+		offset, err := cluster.ParseContinue(token)
+		if err != nil {
+			return query, err
+		}
+		query.Offset = offset
+	}
+	return query, nil
+}
+
+func clusterFromInfo(info *cluster.ClusterInfo) Cluster {
+	return Cluster{
+		Name:         info.Name,
+		Namespace:    info.Namespace,
+		Status:       info.Status,
+		Version:      info.Version,
+		NodeCount:    info.NodeCount,
+		LastSeen:     info.LastSeen,
+		Labels:       info.Labels,
+		Capabilities: info.Capabilities,
+	}
+}
+
+func integrationSummary(integration *ksitv1alpha1.Integration) Integration {
+	summary := Integration{
+		Namespace:      integration.Namespace,
+		Name:           integration.Name,
+		Type:           integration.Spec.Type,
+		Enabled:        integration.Spec.Enabled,
+		Phase:          integration.Status.Phase,
+		Message:        integration.Status.Message,
+		TargetClusters: integration.Targets(),
+		ClusterSummary: integration.Status.ClusterSummary,
+		Clusters:       integration.Status.ClusterStatuses,
+	}
+	if health := integration.Status.Health; health != nil {
+		score := health.Score
+		summary.HealthScore = &score
+	}
+	return summary
+}
+
+// getOnly rejects requests other than GET and HEAD
+func getOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, log logr.Logger, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(err, "failed to write response")
+	}
+}
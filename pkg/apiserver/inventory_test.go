@@ -0,0 +1,164 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+func newInventoryFixture(t *testing.T) (*cluster.ClusterInventory, *ksitv1alpha1.Integration) {
+	inventory := cluster.NewClusterInventory()
+	inventory.Acquire("edge-2", "ksit-system", "test")
+	inventory.Acquire("edge-1", "ksit-system", "test")
+	require.NoError(t, inventory.SetClusterLabels("edge-1", map[string]string{"region": "eu"}))
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			Enabled:        true,
+			TargetClusters: []string{"edge-1"},
+			Config:         map[string]string{"token": "s3cr3t"},
+		},
+		Status: ksitv1alpha1.IntegrationStatus{
+			Phase:  ksitv1alpha1.PhaseRunning,
+			Health: &ksitv1alpha1.HealthScore{Score: 90},
+		},
+	}
+	return inventory, integration
+}
+
+func get(t *testing.T, handler http.Handler, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code == http.StatusOK && v != nil {
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+	return rec.Code
+}
+
+func TestInventoryHandlerQuery(t *testing.T) {
+	inventory := cluster.NewClusterInventory()
+	for _, name := range []string{"edge-1", "edge-2", "edge-3", "edge-4"} {
+		inventory.Acquire(name, "ksit-system", "test")
+	}
+	require.NoError(t, inventory.SetClusterLabels("edge-1", map[string]string{"region": "eu"}))
+	require.NoError(t, inventory.SetClusterLabels("edge-3", map[string]string{"region": "eu"}))
+	require.NoError(t, inventory.SetClusterLabels("edge-4", map[string]string{"region": "eu"}))
+	handler := InventoryHandler(inventory, fake.NewClientBuilder().Build(), logr.Discard())
+
+	var page List[Cluster]
+	require.Equal(t, http.StatusOK, get(t, handler, ClustersPath+"?labelSelector=region%3Deu&order=desc&limit=2", &page))
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "edge-4", page.Items[0].Name)
+	assert.Equal(t, "edge-3", page.Items[1].Name)
+	require.NotNil(t, page.Metadata)
+	assert.Equal(t, 3, page.Metadata.Total)
+	require.NotEmpty(t, page.Metadata.Continue)
+
+	var next List[Cluster]
+	require.Equal(t, http.StatusOK, get(t, handler, ClustersPath+"?labelSelector=region%3Deu&order=desc&limit=2&continue="+page.Metadata.Continue, &next))
+	require.Len(t, next.Items, 1)
+	assert.Equal(t, "edge-1", next.Items[0].Name)
+	assert.Empty(t, next.Metadata.Continue)
+
+	var active List[Cluster]
+	require.Equal(t, http.StatusOK, get(t, handler, ClustersPath+"?status=Error", &active))
+	assert.Empty(t, active.Items)
+	assert.Equal(t, 0, active.Metadata.Total)
+
+	for _, query := range []string{"sortBy=nodes", "order=up", "limit=-1", "continue=bogus", "labelSelector=region%3D%3D%3D"} {
+		assert.Equal(t, http.StatusBadRequest, get(t, handler, ClustersPath+"?"+query, nil), query)
+	}
+}
+
+func TestInventoryHandler(t *testing.T) {
+	inventory, integration := newInventoryFixture(t)
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(integration).Build()
+	handler := InventoryHandler(inventory, c, logr.Discard())
+
+	var clusters List[Cluster]
+	require.Equal(t, http.StatusOK, get(t, handler, ClustersPath, &clusters))
+	require.Len(t, clusters.Items, 2)
+	assert.Equal(t, "edge-1", clusters.Items[0].Name)
+	assert.Equal(t, "edge-2", clusters.Items[1].Name)
+
+	var edge1 Cluster
+	require.Equal(t, http.StatusOK, get(t, handler, ClustersPath+"/edge-1", &edge1))
+	assert.Equal(t, map[string]string{"region": "eu"}, edge1.Labels)
+	assert.Equal(t, string(cluster.ClusterStatusActive), edge1.Status)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, ClustersPath+"/edge-3", nil))
+
+	var integrations List[Integration]
+	require.Equal(t, http.StatusOK, get(t, handler, IntegrationsPath, &integrations))
+	require.Len(t, integrations.Items, 1)
+	assert.Equal(t, ksitv1alpha1.PhaseRunning, integrations.Items[0].Phase)
+	require.NotNil(t, integrations.Items[0].HealthScore)
+	assert.EqualValues(t, 90, *integrations.Items[0].HealthScore)
+
+	require.Equal(t, http.StatusOK, get(t, handler, IntegrationsPath+"?namespace=team-a", &integrations))
+	assert.Empty(t, integrations.Items)
+
+	// Config values may be credentials and are never served
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, IntegrationsPath, nil))
+	assert.NotContains(t, rec.Body.String(), "s3cr3t")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, ClustersPath+"/edge-1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestNewServer(t *testing.T) {
+	inventory, integration := newInventoryFixture(t)
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(integration).Build()
+
+	tokenFile := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("dashboard-token,dashboard,1,\"fleet-viewers\"\n"), 0600))
+	cfg := config.APIConfig{Auth: config.APIAuthConfig{Mode: "token", TokenFile: tokenFile}}
+
+	// Tokens are only accepted over plain HTTP when that is explicitly allowed
+	_, err := NewServer(":0", cfg, inventory, c, logr.Discard())
+	assert.EqualError(t, err, "api.certFile and api.keyFile are required for token authentication, unless api.insecure is set")
+	cfg.Insecure = true
+	server, err := NewServer(":0", cfg, inventory, c, logr.Discard())
+	require.NoError(t, err)
+	assert.Nil(t, server.TLSConfig)
+
+	for _, path := range []string{ClustersPath, ClustersPath + "/edge-1", IntegrationsPath, TopologyPath} {
+		assert.Equal(t, http.StatusOK, serveAt(server.Handler, path, "dashboard-token"), path)
+		assert.Equal(t, http.StatusUnauthorized, serveAt(server.Handler, path, ""), path)
+	}
+
+	_, err = NewServer(":0", config.APIConfig{Auth: config.APIAuthConfig{Mode: "mtls"}}, inventory, c, logr.Discard())
+	assert.EqualError(t, err, "api.certFile and api.keyFile are required in mtls mode")
+}
+
+func serveAt(handler http.Handler, path, token string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
@@ -0,0 +1,81 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TokenReviewAuthenticator delegates bearer token authentication to the hub's
+// API server, so service account and OIDC tokens work without extra setup
+type TokenReviewAuthenticator struct {
+	Client client.Client
+	// Audiences, if set, are the audiences the token must be issued for
+	Audiences []string
+}
+
+// Authenticate submits the token in a TokenReview
+func (a *TokenReviewAuthenticator) Authenticate(ctx context.Context, creds Credentials) (*User, error) {
+	if creds.Token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     creds.Token,
+			Audiences: a.Audiences,
+		},
+	}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return nil, fmt.Errorf("failed to create TokenReview: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, ErrUnauthenticated
+	}
+
+	return &User{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+	}, nil
+}
+
+// SubjectAccessReviewAuthorizer delegates authorization to the hub's RBAC using
+// non-resource URLs, so access is granted with ClusterRoles such as:
+//
+//	rules:
+//	- nonResourceURLs: ["/api/v1/*"]
+//	  verbs: ["get"]
+type SubjectAccessReviewAuthorizer struct {
+	Client client.Client
+}
+
+// Authorize submits a SubjectAccessReview for the request path and verb
+func (a *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, user *User, access Access) (bool, string, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Name,
+			UID:    user.UID,
+			Groups: user.Groups,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: access.Path,
+				Verb: access.Verb,
+			},
+		},
+	}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return false, "", fmt.Errorf("failed to create SubjectAccessReview: %w", err)
+	}
+
+	if review.Status.Allowed {
+		return true, "", nil
+	}
+	reason := review.Status.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("user %q may not %s %s", user.Name, access.Verb, access.Path)
+	}
+	return false, reason, nil
+}
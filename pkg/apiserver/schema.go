@@ -0,0 +1,47 @@
+package apiserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/kubestellar/integration-toolkit/pkg/schema"
+)
+
+// SchemasPath is where SchemaHandler is served
+const SchemasPath = "/schemas"
+
+// Schema is a published JSON Schema, as listed on /schemas
+type Schema struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Path is where the schema is served, e.g. /schemas/integration.json
+	Path string `json:"path"`
+}
+
+// SchemaHandler serves the JSON Schemas of the resources users write: GET /schemas
+// lists them, and GET /schemas/<name>.json returns one, e.g. /schemas/integration.json.
+func SchemaHandler(log logr.Logger) http.Handler {
+	return getOnly(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == SchemasPath || r.URL.Path == SchemasPath+"/" {
+			schemas := make([]Schema, 0, len(schema.Kinds))
+			for _, name := range schema.Names() {
+				schemas = append(schemas, Schema{Name: name, Kind: schema.Kinds[name], Path: SchemasPath + "/" + name + ".json"})
+			}
+			writeJSON(w, log, List[Schema]{Items: schemas})
+			return
+		}
+
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, SchemasPath+"/"), ".json")
+		data, found := schema.Get(name)
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		if _, err := w.Write(data); err != nil {
+			log.Error(err, "failed to write response")
+		}
+	})
+}
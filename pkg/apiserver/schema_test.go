@@ -0,0 +1,41 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/integration-toolkit/pkg/schema"
+)
+
+func TestSchemaHandler(t *testing.T) {
+	handler := SchemaHandler(logr.Discard())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, SchemasPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list List[Schema]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, []Schema{
+		{Name: "integration", Kind: "Integration", Path: "/schemas/integration.json"},
+		{Name: "integrationtarget", Kind: "IntegrationTarget", Path: "/schemas/integrationtarget.json"},
+	}, list.Items)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, list.Items[0].Path, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/schema+json", rec.Header().Get("Content-Type"))
+	published, _ := schema.Get("integration")
+	assert.Equal(t, published, rec.Body.Bytes())
+
+	for _, path := range []string{SchemasPath + "/integration", SchemasPath + "/upgradecampaign.json"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
@@ -0,0 +1,122 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take once the manager stops
+const shutdownTimeout = 10 * time.Second
+
+// Server serves an HTTP handler until its context is done. It is added to the controller
+// manager as a Runnable, and runs on every replica, not only the leader.
+type Server struct {
+	// Addr is the address to listen on, e.g. :8443
+	Addr    string
+	Handler http.Handler
+	// TLSConfig, when set, serves HTTPS. It must hold the serving certificate.
+	TLSConfig *tls.Config
+	Log       logr.Logger
+}
+
+// Start listens on Addr and serves until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Addr, err)
+	}
+	if s.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.TLSConfig)
+	}
+
+	srv := &http.Server{
+		Handler:           s.Handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(listener)
+	}()
+	s.Log.Info("serving fleet API", "address", listener.Addr().String(), "tls", s.TLSConfig != nil)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("fleet API server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down fleet API server: %w", err)
+	}
+	return nil
+}
+
+// NeedLeaderElection lets every replica serve the API
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// NewServer builds the fleet API server listening on addr. It serves the inventory,
+// topology, schema and uninstall endpoints behind the authentication and authorization
+// selected by cfg.Auth, over HTTPS when cfg has a serving certificate.
+func NewServer(addr string, cfg config.APIConfig, inventory *cluster.ClusterInventory, c client.Client, log logr.Logger) (*Server, error) {
+	authn, authz, err := NewAuth(cfg.Auth, c)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	inventoryHandler := InventoryHandler(inventory, c, log)
+	mux.Handle(ClustersPath, inventoryHandler)
+	mux.Handle(ClustersPath+"/", inventoryHandler)
+	mux.Handle(IntegrationsPath, inventoryHandler)
+	mux.Handle(IntegrationsPath+"/", UninstallHandler(c, log))
+	mux.Handle(TopologyPath, TopologyHandler(c, log))
+	schemaHandler := SchemaHandler(log)
+	mux.Handle(SchemasPath, schemaHandler)
+	mux.Handle(SchemasPath+"/", schemaHandler)
+
+	server := &Server{
+		Addr:    addr,
+		Handler: WithAuth(mux, authn, authz, log),
+		Log:     log,
+	}
+
+	if cfg.CertFile == "" {
+		// Bearer tokens sent in cleartext can be replayed by anyone on the path
+		switch {
+		case cfg.Auth.Mode == "mtls":
+			return nil, fmt.Errorf("api.certFile and api.keyFile are required in mtls mode")
+		case !cfg.Insecure:
+			return nil, fmt.Errorf("api.certFile and api.keyFile are required for token authentication, unless api.insecure is set")
+		}
+		log.Info("warning: api.insecure is set, bearer tokens are accepted over plain HTTP")
+		return server, nil
+	}
+
+	tlsConfig, err := TLSConfig(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API serving certificate: %w", err)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	server.TLSConfig = tlsConfig
+	return server, nil
+}
@@ -0,0 +1,27 @@
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/topology"
+)
+
+// TopologyPath is where TopologyHandler is served
+const TopologyPath = "/topology"
+
+// TopologyHandler serves GET /topology: the matrix of clusters × integration types with
+// versions and health. The namespace query parameter limits it to one namespace.
+func TopologyHandler(c client.Reader, log logr.Logger) http.Handler {
+	return getOnly(func(w http.ResponseWriter, r *http.Request) {
+		topo, err := topology.Build(r.Context(), c, r.URL.Query().Get("namespace"))
+		if err != nil {
+			log.Error(err, "failed to build topology")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, log, topo)
+	})
+}
@@ -0,0 +1,46 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/topology"
+)
+
+func TestTopologyHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"edge-1"},
+		},
+	}).Build()
+	handler := TopologyHandler(c, logr.Discard())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, TopologyPath+"?namespace=ksit-system", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var topo topology.Topology
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &topo))
+	assert.Equal(t, []string{"edge-1"}, topo.Clusters)
+	require.Len(t, topo.Cell("edge-1", "argocd"), 1)
+	assert.Equal(t, topology.HealthDisabled, topo.Cell("edge-1", "argocd")[0].Health)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, TopologyPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,73 @@
+package apiserver
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// UninstallRequest is the response to an accepted uninstall request
+type UninstallRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+}
+
+// UninstallHandler serves POST /api/v1/integrations/{namespace}/{name}/uninstall?cluster=,
+// which asks the controller to uninstall the Integration's tool from one target cluster
+// through the uninstall annotation. The Integration is kept.
+func UninstallHandler(c client.Client, log logr.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, IntegrationsPath+"/"), "/")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] != "uninstall" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		clusterName := r.URL.Query().Get("cluster")
+		if clusterName == "" {
+			http.Error(w, "the cluster query parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		key := types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		integration := &ksitv1alpha1.Integration{}
+		if err := c.Get(r.Context(), key, integration); err != nil {
+			if errors.IsNotFound(err) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Error(err, "failed to get integration", "integration", key)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !slices.Contains(integration.Targets(), clusterName) {
+			http.Error(w, "cluster "+clusterName+" is not a target of integration "+key.String(), http.StatusBadRequest)
+			return
+		}
+
+		patch := client.MergeFrom(integration.DeepCopy())
+		integration.RequestUninstall(clusterName)
+		if err := c.Patch(r.Context(), integration, patch); err != nil {
+			log.Error(err, "failed to request uninstall", "integration", key, "cluster", clusterName)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		log.Info("uninstall requested", "integration", key, "cluster", clusterName)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		writeJSON(w, log, UninstallRequest{Namespace: key.Namespace, Name: key.Name, Cluster: clusterName})
+	})
+}
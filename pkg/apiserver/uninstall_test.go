@@ -0,0 +1,41 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestUninstallHandler(t *testing.T) {
+	_, integration := newInventoryFixture(t)
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(integration).Build()
+	handler := UninstallHandler(c, logr.Discard())
+
+	post := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusAccepted, post(http.MethodPost, "/api/v1/integrations/ksit-system/argocd/uninstall?cluster=edge-1"))
+	assert.Equal(t, http.StatusBadRequest, post(http.MethodPost, "/api/v1/integrations/ksit-system/argocd/uninstall?cluster=edge-9"))
+	assert.Equal(t, http.StatusBadRequest, post(http.MethodPost, "/api/v1/integrations/ksit-system/argocd/uninstall"))
+	assert.Equal(t, http.StatusNotFound, post(http.MethodPost, "/api/v1/integrations/ksit-system/missing/uninstall?cluster=edge-1"))
+	assert.Equal(t, http.StatusNotFound, post(http.MethodPost, "/api/v1/integrations/ksit-system/argocd"))
+	assert.Equal(t, http.StatusMethodNotAllowed, post(http.MethodGet, "/api/v1/integrations/ksit-system/argocd/uninstall?cluster=edge-1"))
+
+	stored := &ksitv1alpha1.Integration{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(integration), stored))
+	assert.Equal(t, []string{"edge-1"}, stored.UninstallRequests())
+}
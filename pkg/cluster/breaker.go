@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold is the number of consecutive failures that opens a circuit
+	DefaultBreakerThreshold = 3
+	// DefaultBreakerCooldown is how long an open circuit skips its cluster
+	DefaultBreakerCooldown = 5 * time.Minute
+)
+
+// CircuitBreaker skips clusters that keep failing. After Threshold consecutive failures
+// the circuit of a key opens for Cooldown; the first operation after the cooldown is let
+// through, and its outcome closes the circuit again or reopens it for another cooldown.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker with the default threshold and cooldown
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: DefaultBreakerThreshold,
+		Cooldown:  DefaultBreakerCooldown,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// Allow reports whether an operation on key may run. While the circuit is open it
+// returns false and the time the circuit closes.
+func (b *CircuitBreaker) Allow(key string, now time.Time) (bool, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok || !now.Before(c.openUntil) {
+		return true, time.Time{}
+	}
+	return false, c.openUntil
+}
+
+// Success closes the circuit of key
+func (b *CircuitBreaker) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.circuits, key)
+}
+
+// Failure counts a failed operation on key and reports whether the circuit is open
+func (b *CircuitBreaker) Failure(key string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	c.failures++
+	if c.failures < b.Threshold {
+		return false
+	}
+	c.openUntil = now.Add(b.Cooldown)
+	return true
+}
+
+// Forget drops the circuits of every key with the given prefix
+func (b *CircuitBreaker) Forget(prefix string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key := range b.circuits {
+		if strings.HasPrefix(key, prefix) {
+			delete(b.circuits, key)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker()
+	now := time.Now()
+
+	assert.False(t, b.Failure("ns/argocd/edge", now))
+	assert.False(t, b.Failure("ns/argocd/edge", now))
+	allowed, _ := b.Allow("ns/argocd/edge", now)
+	assert.True(t, allowed)
+
+	assert.True(t, b.Failure("ns/argocd/edge", now))
+	allowed, until := b.Allow("ns/argocd/edge", now.Add(time.Minute))
+	assert.False(t, allowed)
+	assert.Equal(t, now.Add(DefaultBreakerCooldown), until)
+
+	// After the cooldown one attempt goes through; another failure reopens right away
+	later := now.Add(DefaultBreakerCooldown)
+	allowed, _ = b.Allow("ns/argocd/edge", later)
+	assert.True(t, allowed)
+	assert.True(t, b.Failure("ns/argocd/edge", later))
+
+	b.Success("ns/argocd/edge")
+	allowed, _ = b.Allow("ns/argocd/edge", later)
+	assert.True(t, allowed)
+
+	b.Failure("ns/argocd/a", now)
+	b.Failure("ns/flux/a", now)
+	b.Forget("ns/argocd/")
+	assert.Len(t, b.circuits, 1)
+}
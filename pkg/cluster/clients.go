@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// Clients are the clients of a target cluster for requests made on behalf of one
+// Integration. They share one rest.Config, and so one connection pool.
+type Clients struct {
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+}
+
+// DiscoveryFor returns a discovery client whose requests time out with ctx, since
+// discovery calls take no context
+func (c *Clients) DiscoveryFor(ctx context.Context) (discovery.DiscoveryInterface, error) {
+	config := c.Config
+	if deadline, ok := ctx.Deadline(); ok {
+		config = rest.CopyConfig(config)
+		config.Timeout = time.Until(deadline)
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	return dc, nil
+}
+
+// clientCache holds the Clients of a cluster per Integration. It belongs to one
+// registration of the cluster, so re-registering the cluster with another kubeconfig
+// or removing it drops the cached clients.
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[string]*cachedClients
+}
+
+type cachedClients struct {
+	// identity is what ForIntegration put in the config; a change rebuilds the clients
+	identity string
+	clients  *Clients
+}
+
+// GetIntegrationClients returns the clients of a target cluster of the Integration, as
+// resolved by GetIntegrationCluster and set up by ForIntegration. They are built on
+// first use and reused by later reconciles, so TLS handshakes and discovery are not
+// repeated every time.
+func (cm *ClusterManager) GetIntegrationClients(clusterName string, integration *ksitv1alpha1.Integration) (*Clients, error) {
+	c, err := cm.GetIntegrationCluster(clusterName, integration)
+	if err != nil {
+		return nil, err
+	}
+	config, err := cm.GetClusterConfig(c.Name, c.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	config = ForIntegration(config, integration)
+
+	key := integration.Namespace + "/" + integration.Name
+	identity := clientIdentity(config)
+
+	c.clientCache.mu.Lock()
+	defer c.clientCache.mu.Unlock()
+	if cached, ok := c.clientCache.clients[key]; ok && cached.identity == identity {
+		return cached.clients, nil
+	}
+
+	clients, err := newClients(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clients for cluster %s: %w", clusterName, err)
+	}
+	if c.clientCache.clients == nil {
+		c.clientCache.clients = make(map[string]*cachedClients)
+	}
+	c.clientCache.clients[key] = &cachedClients{identity: identity, clients: clients}
+	return clients, nil
+}
+
+// ForgetIntegration drops the clients cached for the Integration on every cluster
+func (cm *ClusterManager) ForgetIntegration(integration *ksitv1alpha1.Integration) {
+	key := integration.Namespace + "/" + integration.Name
+	for _, c := range cm.ListClusters() {
+		c.clientCache.mu.Lock()
+		delete(c.clientCache.clients, key)
+		c.clientCache.mu.Unlock()
+	}
+}
+
+func newClients(config *rest.Config) (*Clients, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Clients{Config: config, Clientset: clientset, Dynamic: dynamicClient}, nil
+}
+
+func clientIdentity(config *rest.Config) string {
+	return strings.Join([]string{
+		config.UserAgent,
+		config.Impersonate.UserName,
+		strings.Join(config.Impersonate.Groups, ","),
+	}, "|")
+}
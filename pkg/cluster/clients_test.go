@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestGetIntegrationClients(t *testing.T) {
+	cm := NewClusterManager(nil)
+	require.NoError(t, cm.AddCluster("dev", "ksit-system", mergedKubeconfig))
+	integration := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"}}
+
+	clients, err := cm.GetIntegrationClients("dev", integration)
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com", clients.Config.Host)
+	assert.Equal(t, UserAgent("argocd"), clients.Config.UserAgent)
+
+	again, err := cm.GetIntegrationClients("dev", integration)
+	require.NoError(t, err)
+	assert.Same(t, clients, again, "clients are reused across reconciles")
+
+	// Each Integration has its own clients
+	other := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "ksit-system"}}
+	otherClients, err := cm.GetIntegrationClients("dev", other)
+	require.NoError(t, err)
+	assert.NotSame(t, clients, otherClients)
+
+	// Changing the impersonated identity rebuilds them
+	integration.Spec.ImpersonateUser = "ksit:team-a"
+	impersonating, err := cm.GetIntegrationClients("dev", integration)
+	require.NoError(t, err)
+	assert.NotSame(t, clients, impersonating)
+	assert.Equal(t, "ksit:team-a", impersonating.Config.Impersonate.UserName)
+
+	// So does a new kubeconfig
+	require.NoError(t, cm.AddClusterWithContext("dev", "ksit-system", mergedKubeconfig, "prod"))
+	rotated, err := cm.GetIntegrationClients("dev", integration)
+	require.NoError(t, err)
+	assert.NotSame(t, impersonating, rotated)
+	assert.Equal(t, "https://prod.example.com", rotated.Config.Host)
+
+	cm.ForgetIntegration(integration)
+	forgotten, err := cm.GetIntegrationClients("dev", integration)
+	require.NoError(t, err)
+	assert.NotSame(t, rotated, forgotten)
+
+	require.NoError(t, cm.RemoveCluster("dev", "ksit-system"))
+	_, err = cm.GetIntegrationClients("dev", integration)
+	assert.Error(t, err)
+}
+
+func TestDiscoveryFor(t *testing.T) {
+	cm := NewClusterManager(nil)
+	require.NoError(t, cm.AddCluster("dev", "ksit-system", mergedKubeconfig))
+	clients, err := cm.GetIntegrationClients("dev", &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"}})
+	require.NoError(t, err)
+
+	// Discovery requests end with the cluster's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dc, err := clients.DiscoveryFor(ctx)
+	require.NoError(t, err)
+	timeout := dc.RESTClient().(*rest.RESTClient).Client.Timeout
+	assert.Greater(t, timeout, time.Duration(0))
+	assert.LessOrEqual(t, timeout, 5*time.Second)
+}
+
+func heapInUse() int64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.HeapInuse)
+}
+
+// BenchmarkClusterManagerCache measures the heap a ClusterManager holds per registered
+// cluster, and per set of Integration clients it caches, with five Integrations per cluster
+func BenchmarkClusterManagerCache(b *testing.B) {
+	integrations := make([]*ksitv1alpha1.Integration, 5)
+	for i := range integrations {
+		integrations[i] = &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("integration-%d", i), Namespace: "ksit-system"}}
+	}
+	for _, n := range []int{100, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			var perCluster, perClients float64
+			for i := 0; i < b.N; i++ {
+				before := heapInUse()
+				cm := NewClusterManager(nil)
+				for c := 0; c < n; c++ {
+					require.NoError(b, cm.AddCluster(fmt.Sprintf("cluster-%04d", c), "ksit-system", mergedKubeconfig))
+				}
+				registered := heapInUse()
+				for c := 0; c < n; c++ {
+					for _, integration := range integrations {
+						_, err := cm.GetIntegrationClients(fmt.Sprintf("cluster-%04d", c), integration)
+						require.NoError(b, err)
+					}
+				}
+				cached := heapInUse()
+				runtime.KeepAlive(cm)
+
+				perCluster += float64(registered-before) / float64(n)
+				perClients += float64(cached-registered) / float64(n*len(integrations))
+			}
+			b.ReportMetric(perCluster/float64(b.N), "heap-B/cluster")
+			b.ReportMetric(perClients/float64(b.N), "heap-B/clients")
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+)
+
+// DiagnosticStage is one step of establishing a connection to a cluster API server
+type DiagnosticStage string
+
+const (
+	StageDNS  DiagnosticStage = "DNS"
+	StageTCP  DiagnosticStage = "TCP"
+	StageTLS  DiagnosticStage = "TLS"
+	StageAuth DiagnosticStage = "Auth"
+)
+
+// diagnoseTimeout bounds the whole diagnostic so it never stalls a reconcile
+const diagnoseTimeout = 10 * time.Second
+
+// Diagnosis is the result of a connection diagnostic. Stage is empty when every
+// stage succeeded.
+type Diagnosis struct {
+	Stage DiagnosticStage
+	Err   error
+}
+
+// Failed reports whether a stage failed
+func (d *Diagnosis) Failed() bool {
+	return d.Stage != ""
+}
+
+func (d *Diagnosis) String() string {
+	if !d.Failed() {
+		return "all connection stages succeeded"
+	}
+	return fmt.Sprintf("%s stage failed: %v", d.Stage, d.Err)
+}
+
+// Diagnose walks through DNS resolution of the API host, a TCP dial, the TLS
+// handshake and an authenticated request, and reports the first stage that fails.
+// When the API server is reached through a proxy, from config.Proxy or the
+// HTTPS_PROXY environment, the DNS and TCP stages check the proxy instead, and the TLS
+// handshake is made through it by the request.
+func Diagnose(ctx context.Context, config *rest.Config) *Diagnosis {
+	ctx, cancel := context.WithTimeout(ctx, diagnoseTimeout)
+	defer cancel()
+
+	host := config.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return &Diagnosis{Stage: StageDNS, Err: fmt.Errorf("invalid API server address %q: %w", config.Host, err)}
+	}
+
+	proxy := config.Proxy
+	if proxy == nil {
+		// The default of client-go transports
+		proxy = utilnet.NewProxierWithNoProxyCIDR(http.ProxyFromEnvironment)
+	}
+	proxyURL, err := proxy(&http.Request{URL: u})
+	if err != nil {
+		return &Diagnosis{Stage: StageTCP, Err: fmt.Errorf("invalid proxy configuration: %w", err)}
+	}
+	if proxyURL != nil {
+		return diagnoseThroughProxy(ctx, config, u, proxyURL)
+	}
+
+	hostname := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	conn, d := dial(ctx, hostname, port, "")
+	if d != nil {
+		return d
+	}
+	defer conn.Close()
+
+	if u.Scheme == "https" {
+		tlsConfig, err := rest.TLSConfigFor(config)
+		if err != nil {
+			return &Diagnosis{Stage: StageTLS, Err: fmt.Errorf("invalid TLS configuration: %w", err)}
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = hostname
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return &Diagnosis{Stage: StageTLS, Err: fmt.Errorf("handshake with %s failed: %w", hostname, err)}
+		}
+	}
+
+	status, err := discover(ctx, config, u)
+	if err != nil {
+		return &Diagnosis{Stage: StageAuth, Err: err}
+	}
+	return authStatus(status)
+}
+
+// diagnoseThroughProxy checks that the proxy resolves and accepts connections, then
+// makes the TLS handshake and the authenticated request through it
+func diagnoseThroughProxy(ctx context.Context, config *rest.Config, u, proxyURL *url.URL) *Diagnosis {
+	port := proxyURL.Port()
+	if port == "" {
+		port = "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	conn, d := dial(ctx, proxyURL.Hostname(), port, "proxy ")
+	if d != nil {
+		return d
+	}
+	conn.Close()
+
+	status, err := discover(ctx, config, u)
+	switch {
+	case err != nil && isTLSError(err):
+		return &Diagnosis{Stage: StageTLS, Err: fmt.Errorf("handshake with %s through proxy %s failed: %w", u.Hostname(), proxyURL.Host, err)}
+	case err != nil:
+		return &Diagnosis{Stage: StageTCP, Err: fmt.Errorf("cannot reach %s through proxy %s: %w", u.Host, proxyURL.Host, err)}
+	}
+	return authStatus(status)
+}
+
+// dial resolves hostname and opens a TCP connection to it. what prefixes the host in
+// error messages, e.g. "proxy ".
+func dial(ctx context.Context, hostname, port, what string) (net.Conn, *Diagnosis) {
+	if net.ParseIP(hostname) == nil {
+		if _, err := net.DefaultResolver.LookupHost(ctx, hostname); err != nil {
+			return nil, &Diagnosis{Stage: StageDNS, Err: fmt.Errorf("cannot resolve %s%s: %w", what, hostname, err)}
+		}
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(hostname, port))
+	if err != nil {
+		return nil, &Diagnosis{Stage: StageTCP, Err: fmt.Errorf("cannot connect to %s%s:%s: %w", what, hostname, port, err)}
+	}
+	return conn, nil
+}
+
+// isTLSError reports whether err comes from verifying the server certificate or the
+// TLS handshake
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	return errors.As(err, &verifyErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &recordErr) || strings.Contains(err.Error(), "tls: ")
+}
+
+// discover makes a discovery request with the transport and credentials of config,
+// proxy included, and returns the response status
+func discover(ctx context.Context, config *rest.Config, u *url.URL) (int, error) {
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return 0, fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(u.String(), "/")+"/api", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// authStatus reports the Auth stage failed when the discovery request was rejected
+func authStatus(status int) *Diagnosis {
+	switch status {
+	case http.StatusUnauthorized:
+		return &Diagnosis{Stage: StageAuth, Err: fmt.Errorf("credentials were rejected (401 Unauthorized); check the token or client certificate in the kubeconfig")}
+	case http.StatusForbidden:
+		return &Diagnosis{Stage: StageAuth, Err: fmt.Errorf("access denied (403 Forbidden); the kubeconfig user needs API discovery permissions")}
+	}
+	return &Diagnosis{}
+}
@@ -0,0 +1,125 @@
+package cluster
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func newTestAPIServer(t *testing.T, status int) (*httptest.Server, []byte) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, caData
+}
+
+func TestDiagnose(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("healthy", func(t *testing.T) {
+		server, caData := newTestAPIServer(t, http.StatusOK)
+		d := Diagnose(ctx, &rest.Config{Host: server.URL, TLSClientConfig: rest.TLSClientConfig{CAData: caData}})
+		assert.False(t, d.Failed(), d.String())
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		d := Diagnose(ctx, &rest.Config{Host: "https://cluster.invalid:6443"})
+		assert.Equal(t, StageDNS, d.Stage)
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		listener.Close()
+
+		d := Diagnose(ctx, &rest.Config{Host: "https://" + addr})
+		assert.Equal(t, StageTCP, d.Stage)
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		server, _ := newTestAPIServer(t, http.StatusOK)
+		d := Diagnose(ctx, &rest.Config{Host: server.URL})
+		assert.Equal(t, StageTLS, d.Stage)
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		server, caData := newTestAPIServer(t, http.StatusUnauthorized)
+		d := Diagnose(ctx, &rest.Config{Host: server.URL, BearerToken: "expired", TLSClientConfig: rest.TLSClientConfig{CAData: caData}})
+		assert.Equal(t, StageAuth, d.Stage)
+		assert.Contains(t, d.String(), "401")
+	})
+}
+
+// newTestProxy returns an HTTP CONNECT proxy that tunnels every connection to target,
+// like a proxy in front of a private network would for hosts only it can resolve
+func newTestProxy(t *testing.T, target string) *url.URL {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			_, _ = io.Copy(upstream, client)
+			upstream.Close()
+		}()
+		_, _ = io.Copy(client, upstream)
+		client.Close()
+	}))
+	t.Cleanup(proxy.Close)
+
+	u, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	return u
+}
+
+func TestDiagnoseThroughProxy(t *testing.T) {
+	ctx := context.Background()
+	server, caData := newTestAPIServer(t, http.StatusOK)
+	proxyURL := newTestProxy(t, server.Listener.Addr().String())
+
+	// The API host does not resolve outside the proxy
+	config := &rest.Config{
+		Host:            "https://api.cluster.invalid:6443",
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: rest.TLSClientConfig{CAData: caData, ServerName: "example.com"},
+	}
+	d := Diagnose(ctx, config)
+	assert.False(t, d.Failed(), d.String())
+
+	config.TLSClientConfig = rest.TLSClientConfig{}
+	d = Diagnose(ctx, config)
+	assert.Equal(t, StageTLS, d.Stage, d.String())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := &url.URL{Scheme: "http", Host: listener.Addr().String()}
+	listener.Close()
+	config.Proxy = http.ProxyURL(down)
+	d = Diagnose(ctx, config)
+	assert.Equal(t, StageTCP, d.Stage)
+	assert.Contains(t, d.String(), "cannot connect to proxy")
+}
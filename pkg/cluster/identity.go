@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/version"
+)
+
+// UserAgent returns the User-Agent KSIT sends to target clusters, so their audit logs
+// attribute requests to KSIT and, when given, to the Integration they are made for
+func UserAgent(integration string) string {
+	userAgent := "ksit/" + version.Get().Version
+	if integration != "" {
+		userAgent += " integration/" + integration
+	}
+	return userAgent
+}
+
+// ForIntegration returns a copy of a target cluster's rest.Config for requests made on
+// behalf of the Integration: the User-Agent names the Integration, and the Integration's
+// impersonateUser and impersonateGroups are impersonated when set.
+func ForIntegration(config *rest.Config, integration *ksitv1alpha1.Integration) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.UserAgent = UserAgent(integration.Name)
+	if integration.Spec.ImpersonateUser != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: integration.Spec.ImpersonateUser,
+			Groups:   integration.Spec.ImpersonateGroups,
+		}
+	}
+	return config
+}
+
+// GetIntegrationCluster returns a target cluster of the Integration. The cluster
+// registered in the Integration's namespace wins; an Integration the manager treats as
+// ClusterScoped falls back to the one registered in another namespace, which must then
+// be unique.
+func (cm *ClusterManager) GetIntegrationCluster(clusterName string, integration *ksitv1alpha1.Integration) (*Cluster, error) {
+	if c, err := cm.GetCluster(clusterName, integration.Namespace); err == nil || !cm.ClusterScoped(integration) {
+		return c, err
+	}
+
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	var found []*Cluster
+	for _, c := range cm.clusters {
+		if c.Name == clusterName {
+			found = append(found, c)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("cluster %s not found in any namespace: %w", clusterName, ErrClusterNotRegistered)
+	case 1:
+		return found[0], nil
+	default:
+		namespaces := make([]string, len(found))
+		for i, c := range found {
+			namespaces[i] = c.Namespace
+		}
+		sort.Strings(namespaces)
+		return nil, fmt.Errorf("cluster %s is registered in several namespaces %v; register it in %s to pick one", clusterName, namespaces, integration.Namespace)
+	}
+}
+
+// GetIntegrationConfig returns the rest.Config of a target cluster of the Integration,
+// set up by ForIntegration
+func (cm *ClusterManager) GetIntegrationConfig(clusterName string, integration *ksitv1alpha1.Integration) (*rest.Config, error) {
+	namespace := integration.Namespace
+	if cm.ClusterScoped(integration) {
+		c, err := cm.GetIntegrationCluster(clusterName, integration)
+		if err != nil {
+			return nil, err
+		}
+		namespace = c.Namespace
+	}
+	config, err := cm.GetClusterConfig(clusterName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return ForIntegration(config, integration), nil
+}
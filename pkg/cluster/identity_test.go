@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/version"
+)
+
+func TestForIntegration(t *testing.T) {
+	base := &rest.Config{Host: "https://edge-1:6443", UserAgent: UserAgent("")}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a-argocd", Namespace: "tenants"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			ImpersonateUser:   "ksit:team-a",
+			ImpersonateGroups: []string{"team-a"},
+		},
+	}
+
+	config := ForIntegration(base, integration)
+	assert.Equal(t, "ksit/"+version.Get().Version+" integration/team-a-argocd", config.UserAgent)
+	assert.Equal(t, rest.ImpersonationConfig{UserName: "ksit:team-a", Groups: []string{"team-a"}}, config.Impersonate)
+
+	// The shared config of the cluster is left alone
+	assert.Equal(t, "ksit/"+version.Get().Version, base.UserAgent)
+	assert.Empty(t, base.Impersonate.UserName)
+
+	integration.Spec.ImpersonateUser = ""
+	assert.Empty(t, ForIntegration(base, integration).Impersonate.UserName)
+}
+
+func TestGetIntegrationCluster(t *testing.T) {
+	cm := NewClusterManager(nil)
+	require.NoError(t, cm.AddCluster("edge-1", "team-a", mergedKubeconfig))
+	require.NoError(t, cm.AddClusterWithContext("edge-2", "team-a", mergedKubeconfig, "prod"))
+	require.NoError(t, cm.AddClusterWithContext("edge-2", "team-b", mergedKubeconfig, "prod"))
+
+	integration := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"}}
+	_, err := cm.GetIntegrationCluster("edge-1", integration)
+	assert.Error(t, err, "a namespaced Integration only sees its own namespace")
+
+	integration.Spec.Scope = ksitv1alpha1.ScopeCluster
+	c, err := cm.GetIntegrationCluster("edge-1", integration)
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", c.Namespace)
+	config, err := cm.GetIntegrationConfig("edge-1", integration)
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com", config.Host)
+
+	_, err = cm.GetIntegrationCluster("edge-2", integration)
+	assert.ErrorContains(t, err, "several namespaces [team-a team-b]")
+
+	_, err = cm.GetIntegrationConfig("edge-3", integration)
+	assert.True(t, errors.Is(err, ErrClusterNotRegistered))
+
+	// The Integration's own namespace wins
+	require.NoError(t, cm.AddCluster("edge-2", "ksit-system", mergedKubeconfig))
+	c, err = cm.GetIntegrationCluster("edge-2", integration)
+	require.NoError(t, err)
+	assert.Equal(t, "ksit-system", c.Namespace)
+
+	// Outside the allowed namespaces Cluster scope is ignored, whatever the spec says
+	cm.RestrictClusterScope([]string{"platform"})
+	assert.False(t, cm.ClusterScoped(integration))
+	_, err = cm.GetIntegrationCluster("edge-1", integration)
+	assert.Error(t, err)
+	_, err = cm.GetIntegrationConfig("edge-1", integration)
+	assert.Error(t, err)
+}
@@ -11,9 +11,26 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// ClusterInventory records the clusters of the fleet. Membership is owned by cluster
+// sources such as IntegrationTargets: a cluster stays in the inventory until the last
+// source that registered it releases it. Integrations only update the clusters they use.
 type ClusterInventory struct {
 	mu       sync.RWMutex
 	clusters map[string]*ClusterInfo
+	// owners are the sources that registered each cluster
+	owners map[string]map[string]struct{}
+
+	// byStatus and byLabel index cluster names by status and by label key and value
+	byStatus map[string]map[string]struct{}
+	byLabel  map[string]map[string]map[string]struct{}
+	// indexed is what each cluster is indexed under. Callers change a ClusterInfo in
+	// place before passing it to UpdateCluster, so the old values are kept here.
+	indexed map[string]indexEntry
+}
+
+type indexEntry struct {
+	status string
+	labels map[string]string
 }
 
 type ClusterInfo struct {
@@ -30,29 +47,178 @@ type ClusterInfo struct {
 func NewClusterInventory() *ClusterInventory {
 	return &ClusterInventory{
 		clusters: make(map[string]*ClusterInfo),
+		owners:   make(map[string]map[string]struct{}),
+		byStatus: make(map[string]map[string]struct{}),
+		byLabel:  make(map[string]map[string]map[string]struct{}),
+		indexed:  make(map[string]indexEntry),
+	}
+}
+
+// put stores info under its name and indexes it. The caller holds ci.mu.
+func (ci *ClusterInventory) put(info *ClusterInfo) {
+	ci.clusters[info.Name] = info
+	ci.reindex(info.Name)
+}
+
+// drop removes a cluster and its index entries. The caller holds ci.mu.
+func (ci *ClusterInventory) drop(name string) {
+	ci.unindex(name)
+	delete(ci.clusters, name)
+}
+
+// reindex indexes a cluster under its current status and labels. The caller holds ci.mu.
+func (ci *ClusterInventory) reindex(name string) {
+	ci.unindex(name)
+	info, exists := ci.clusters[name]
+	if !exists {
+		return
 	}
+
+	entry := indexEntry{status: info.Status, labels: make(map[string]string, len(info.Labels))}
+	addToSet(ci.byStatus, info.Status, name)
+	for key, value := range info.Labels {
+		entry.labels[key] = value
+		values := ci.byLabel[key]
+		if values == nil {
+			values = make(map[string]map[string]struct{})
+			ci.byLabel[key] = values
+		}
+		addToSet(values, value, name)
+	}
+	ci.indexed[name] = entry
+}
+
+// unindex removes the index entries of a cluster. The caller holds ci.mu.
+func (ci *ClusterInventory) unindex(name string) {
+	entry, exists := ci.indexed[name]
+	if !exists {
+		return
+	}
+	removeFromSet(ci.byStatus, entry.status, name)
+	for key, value := range entry.labels {
+		removeFromSet(ci.byLabel[key], value, name)
+		if len(ci.byLabel[key]) == 0 {
+			delete(ci.byLabel, key)
+		}
+	}
+	delete(ci.indexed, name)
+}
+
+func addToSet(sets map[string]map[string]struct{}, key, name string) {
+	set := sets[key]
+	if set == nil {
+		set = make(map[string]struct{})
+		sets[key] = set
+	}
+	set[name] = struct{}{}
+}
+
+func removeFromSet(sets map[string]map[string]struct{}, key, name string) {
+	delete(sets[key], name)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+// lookup returns the clusters named in set. The caller holds ci.mu.
+func (ci *ClusterInventory) lookup(set map[string]struct{}) []*ClusterInfo {
+	var result []*ClusterInfo
+	for name := range set {
+		result = append(result, ci.clusters[name])
+	}
+	return result
+}
+
+// Acquire records owner as a source of the cluster, adding the cluster if it is new
+func (ci *ClusterInventory) Acquire(name, namespace, owner string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	if _, exists := ci.clusters[name]; !exists {
+		ci.put(&ClusterInfo{
+			Name:         name,
+			Namespace:    namespace,
+			Status:       string(ClusterStatusActive),
+			LastSeen:     time.Now(),
+			Labels:       make(map[string]string),
+			Capabilities: []string{},
+		})
+	}
+	if ci.owners[name] == nil {
+		ci.owners[name] = make(map[string]struct{})
+	}
+	ci.owners[name][owner] = struct{}{}
+}
+
+// Release drops owner as a source of the cluster and removes the cluster once no
+// source is left. It reports whether the cluster was removed.
+func (ci *ClusterInventory) Release(name, owner string) bool {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	owners, exists := ci.owners[name]
+	if !exists {
+		return false
+	}
+	delete(owners, owner)
+	if len(owners) > 0 {
+		return false
+	}
+	delete(ci.owners, name)
+	ci.drop(name)
+	return true
+}
+
+// Track keeps the inventory in sync with the clusters registered with cm, including
+// those registered before Track is called. Each registration is a separate source,
+// so a cluster registered in several namespaces stays until all of them are removed.
+func (ci *ClusterInventory) Track(cm *ClusterManager) (stop func()) {
+	stop = cm.AddListener(ClusterListenerFuncs{
+		AddFunc: func(c *Cluster) {
+			ci.Acquire(c.Name, c.Namespace, managerOwner(c))
+		},
+		RemoveFunc: func(c *Cluster) {
+			ci.Release(c.Name, managerOwner(c))
+		},
+	})
+	for _, c := range cm.ListClusters() {
+		ci.Acquire(c.Name, c.Namespace, managerOwner(c))
+	}
+	return stop
+}
+
+// managerOwner identifies a ClusterManager registration as an inventory source
+func managerOwner(c *Cluster) string {
+	return "target:" + c.Namespace + "/" + c.Name
 }
 
 func (ci *ClusterInventory) AddCluster(name, namespace, status string) {
 	ci.mu.Lock()
 	defer ci.mu.Unlock()
 
-	ci.clusters[name] = &ClusterInfo{
+	ci.put(&ClusterInfo{
 		Name:         name,
 		Namespace:    namespace,
 		Status:       status,
 		LastSeen:     time.Now(),
 		Labels:       make(map[string]string),
 		Capabilities: []string{},
-	}
+	})
 }
 
+// UpdateCluster replaces the information of a cluster in the inventory and reindexes
+// it. Clusters that have left the inventory are not added back. Changes made in place
+// to a ClusterInfo only reach GetClustersByStatus, GetClustersByLabel and Query once
+// it is passed to UpdateCluster.
 func (ci *ClusterInventory) UpdateCluster(info *ClusterInfo) {
 	ci.mu.Lock()
 	defer ci.mu.Unlock()
 
+	if _, exists := ci.clusters[info.Name]; !exists {
+		return
+	}
 	info.LastSeen = time.Now()
-	ci.clusters[info.Name] = info
+	ci.put(info)
 }
 
 func (ci *ClusterInventory) GetCluster(name string) (*ClusterInfo, error) {
@@ -67,11 +233,13 @@ func (ci *ClusterInventory) GetCluster(name string) (*ClusterInfo, error) {
 	return cluster, nil
 }
 
+// RemoveCluster removes a cluster regardless of the sources that registered it
 func (ci *ClusterInventory) RemoveCluster(name string) {
 	ci.mu.Lock()
 	defer ci.mu.Unlock()
 
-	delete(ci.clusters, name)
+	ci.drop(name)
+	delete(ci.owners, name)
 }
 
 func (ci *ClusterInventory) ListClusters() []*ClusterInfo {
@@ -86,31 +254,31 @@ func (ci *ClusterInventory) ListClusters() []*ClusterInfo {
 	return clusters
 }
 
+// GetClustersByStatus returns the clusters with the given status from the status index
 func (ci *ClusterInventory) GetClustersByStatus(status string) []*ClusterInfo {
 	ci.mu.RLock()
 	defer ci.mu.RUnlock()
 
-	var result []*ClusterInfo
-	for _, cluster := range ci.clusters {
-		if cluster.Status == status {
-			result = append(result, cluster)
-		}
-	}
-
-	return result
+	return ci.lookup(ci.byStatus[status])
 }
 
+// GetClustersByLabel returns the clusters whose label key has the given value from the
+// label index. An empty value also matches the clusters without the label, which
+// takes a scan of the inventory.
 func (ci *ClusterInventory) GetClustersByLabel(key, value string) []*ClusterInfo {
 	ci.mu.RLock()
 	defer ci.mu.RUnlock()
 
+	if value != "" {
+		return ci.lookup(ci.byLabel[key][value])
+	}
+
 	var result []*ClusterInfo
 	for _, cluster := range ci.clusters {
-		if cluster.Labels[key] == value {
+		if cluster.Labels[key] == "" {
 			result = append(result, cluster)
 		}
 	}
-
 	return result
 }
 
@@ -140,7 +308,11 @@ func (ci *ClusterInventory) LoadClusters(kubeconfig string) error {
 	defer ci.mu.Unlock()
 
 	clusterName := "default"
-	ci.clusters[clusterName] = &ClusterInfo{
+	if ci.owners[clusterName] == nil {
+		ci.owners[clusterName] = make(map[string]struct{})
+	}
+	ci.owners[clusterName]["kubeconfig:"+kubeconfig] = struct{}{}
+	ci.put(&ClusterInfo{
 		Name:         clusterName,
 		Namespace:    "default",
 		Status:       string(ClusterStatusActive),
@@ -149,7 +321,7 @@ func (ci *ClusterInventory) LoadClusters(kubeconfig string) error {
 		LastSeen:     time.Now(),
 		Labels:       make(map[string]string),
 		Capabilities: []string{},
-	}
+	})
 
 	return nil
 }
@@ -163,6 +335,8 @@ func (ci *ClusterInventory) RefreshCluster(ctx context.Context, name string, cli
 		return fmt.Errorf("cluster %s not found", name)
 	}
 
+	defer ci.reindex(name)
+
 	version, err := client.Discovery().ServerVersion()
 	if err != nil {
 		cluster.Status = string(ClusterStatusError)
@@ -183,14 +357,16 @@ func (ci *ClusterInventory) RefreshCluster(ctx context.Context, name string, cli
 	return nil
 }
 
+// CleanupStale removes clusters that have not been seen for maxAge. Clusters that a
+// source still owns are kept.
 func (ci *ClusterInventory) CleanupStale(maxAge time.Duration) {
 	ci.mu.Lock()
 	defer ci.mu.Unlock()
 
 	cutoff := time.Now().Add(-maxAge)
 	for name, cluster := range ci.clusters {
-		if cluster.LastSeen.Before(cutoff) {
-			delete(ci.clusters, name)
+		if len(ci.owners[name]) == 0 && cluster.LastSeen.Before(cutoff) {
+			ci.drop(name)
 		}
 	}
 }
@@ -212,6 +388,7 @@ func (ci *ClusterInventory) SetClusterLabels(name string, labels map[string]stri
 	}
 
 	cluster.Labels = labels
+	ci.reindex(name)
 	return nil
 }
 
@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestInventoryTracksClusterManager(t *testing.T) {
+	cm := NewClusterManager(nil)
+	require.NoError(t, cm.AddCluster("edge", "team-a", mergedKubeconfig))
+
+	inv := NewClusterInventory()
+	stop := inv.Track(cm)
+	defer stop()
+
+	// Clusters registered before tracking started are recorded too
+	_, err := inv.GetCluster("edge")
+	require.NoError(t, err)
+
+	// The same cluster registered by a second namespace is a second owner
+	require.NoError(t, cm.AddCluster("edge", "team-b", mergedKubeconfig))
+	require.NoError(t, cm.RemoveCluster("edge", "team-a"))
+	_, err = inv.GetCluster("edge")
+	require.NoError(t, err)
+
+	require.NoError(t, cm.RemoveCluster("edge", "team-b"))
+	_, err = inv.GetCluster("edge")
+	assert.Error(t, err)
+}
+
+func TestInventoryOwnership(t *testing.T) {
+	inv := NewClusterInventory()
+	inv.Acquire("edge", "ksit-system", "target:ksit-system/edge")
+	inv.Acquire("edge", "ksit-system", "discovery")
+
+	assert.False(t, inv.Release("edge", "discovery"))
+	assert.False(t, inv.Release("edge", "unknown"))
+
+	// Owned clusters are never stale
+	info, err := inv.GetCluster("edge")
+	require.NoError(t, err)
+	info.LastSeen = time.Now().Add(-48 * time.Hour)
+	inv.CleanupStale(24 * time.Hour)
+	assert.Equal(t, 1, inv.Count())
+
+	assert.True(t, inv.Release("edge", "target:ksit-system/edge"))
+	assert.Equal(t, 0, inv.Count())
+
+	// Updates do not bring a removed cluster back
+	inv.UpdateCluster(&ClusterInfo{Name: "edge"})
+	assert.Equal(t, 0, inv.Count())
+}
+
+func names(clusters []*ClusterInfo) []string {
+	out := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		out = append(out, c.Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestInventoryIndexes(t *testing.T) {
+	inv := NewClusterInventory()
+	inv.Acquire("edge-1", "ksit-system", "test")
+	inv.Acquire("edge-2", "ksit-system", "test")
+	inv.AddCluster("hub", "ksit-system", string(ClusterStatusError))
+	require.NoError(t, inv.SetClusterLabels("edge-1", map[string]string{"region": "eu"}))
+	require.NoError(t, inv.SetClusterLabels("edge-2", map[string]string{"region": "us"}))
+
+	assert.Equal(t, []string{"edge-1", "edge-2"}, names(inv.GetClustersByStatus(string(ClusterStatusActive))))
+	assert.Equal(t, []string{"edge-1"}, names(inv.GetClustersByLabel("region", "eu")))
+	assert.Equal(t, []string{"hub"}, names(inv.GetClustersByLabel("region", "")))
+
+	// Clusters changed in place are reindexed by UpdateCluster
+	info, err := inv.GetCluster("edge-1")
+	require.NoError(t, err)
+	info.Status = string(ClusterStatusError)
+	info.Labels = map[string]string{"region": "us"}
+	inv.UpdateCluster(info)
+	assert.Equal(t, []string{"edge-2"}, names(inv.GetClustersByStatus(string(ClusterStatusActive))))
+	assert.Equal(t, []string{"edge-1", "hub"}, names(inv.GetClustersByStatus(string(ClusterStatusError))))
+	assert.Empty(t, inv.GetClustersByLabel("region", "eu"))
+	assert.Equal(t, []string{"edge-1", "edge-2"}, names(inv.GetClustersByLabel("region", "us")))
+
+	result, err := inv.Query(InventoryQuery{Status: string(ClusterStatusError), Selector: labels.SelectorFromSet(labels.Set{"region": "us"})})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edge-1"}, names(result.Items))
+
+	// Removed clusters leave the indexes
+	assert.True(t, inv.Release("edge-2", "test"))
+	inv.RemoveCluster("hub")
+	assert.Empty(t, inv.GetClustersByStatus(string(ClusterStatusActive)))
+	assert.Equal(t, []string{"edge-1"}, names(inv.GetClustersByLabel("region", "us")))
+	assert.Len(t, inv.byStatus, 1)
+	assert.Len(t, inv.byLabel["region"], 1)
+}
+
+func newBenchmarkInventory(n int) *ClusterInventory {
+	inv := NewClusterInventory()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("cluster-%05d", i)
+		inv.Acquire(name, "ksit-system", "bench")
+		_ = inv.SetClusterLabels(name, map[string]string{"region": fmt.Sprintf("region-%d", i%50), "env": []string{"prod", "dev"}[i%2]})
+	}
+	return inv
+}
+
+func BenchmarkGetClustersByLabel(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		inv := newBenchmarkInventory(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				inv.GetClustersByLabel("region", "region-7")
+			}
+		})
+	}
+}
+
+func BenchmarkGetClustersByStatus(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		inv := newBenchmarkInventory(n)
+		info, _ := inv.GetCluster("cluster-00000")
+		info.Status = string(ClusterStatusError)
+		inv.UpdateCluster(info)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				inv.GetClustersByStatus(string(ClusterStatusError))
+			}
+		})
+	}
+}
+
+func BenchmarkQuerySelector(b *testing.B) {
+	selector := labels.SelectorFromSet(labels.Set{"region": "region-7", "env": "dev"})
+	for _, n := range []int{100, 1000, 10000} {
+		inv := newBenchmarkInventory(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = inv.Query(InventoryQuery{Selector: selector})
+			}
+		})
+	}
+}
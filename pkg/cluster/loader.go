@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// TargetLabels returns the labels a cluster is known by: the IntegrationTarget's own labels,
+// overridden by spec.labels
+func TargetLabels(target *ksitv1alpha1.IntegrationTarget) map[string]string {
+	labels := make(map[string]string, len(target.Labels)+len(target.Spec.Labels))
+	for k, v := range target.Labels {
+		labels[k] = v
+	}
+	for k, v := range target.Spec.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// TargetAnnotations returns the ksit.io annotations of an IntegrationTarget, with
+// which cluster owners adjust the installs on their cluster
+func TargetAnnotations(target *ksitv1alpha1.IntegrationTarget) map[string]string {
+	annotations := make(map[string]string)
+	for k, v := range target.Annotations {
+		if strings.HasPrefix(k, "ksit.io/") {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
+
+// LoadTargets registers the clusters of all IntegrationTargets in a namespace, or in all
+// namespaces when namespace is empty, from their <clusterName>-kubeconfig Secrets, the
+// same way the controller does. The CLI uses it, and the controller to restore its
+// clusters at startup. Targets that cannot be loaded are skipped and returned with the reason.
+func (cm *ClusterManager) LoadTargets(ctx context.Context, namespace string) (map[string]error, error) {
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := cm.List(ctx, targets, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+
+	skipped := make(map[string]error)
+	for i := range targets.Items {
+		target := &targets.Items[i]
+		name := target.Spec.ClusterName
+
+		secret := &corev1.Secret{}
+		if err := cm.Get(ctx, client.ObjectKey{Name: name + "-kubeconfig", Namespace: target.Namespace}, secret); err != nil {
+			skipped[name] = fmt.Errorf("failed to get kubeconfig secret: %w", err)
+			continue
+		}
+		kubeconfig, ok := secret.Data["kubeconfig"]
+		if !ok {
+			skipped[name] = fmt.Errorf("secret %s is missing the kubeconfig key", secret.Name)
+			continue
+		}
+
+		if err := cm.AddClusterWithContext(name, target.Namespace, string(kubeconfig), target.Spec.KubeconfigContext); err != nil {
+			skipped[name] = err
+			continue
+		}
+		if err := cm.SetClusterLabels(name, target.Namespace, TargetLabels(target)); err != nil {
+			skipped[name] = err
+			continue
+		}
+		if err := cm.SetClusterAnnotations(name, target.Namespace, TargetAnnotations(target)); err != nil {
+			skipped[name] = err
+		}
+	}
+
+	return skipped, nil
+}
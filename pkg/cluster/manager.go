@@ -2,20 +2,36 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
 )
 
+// ErrClusterNotRegistered is returned for clusters that no IntegrationTarget has registered
+var ErrClusterNotRegistered = errors.New("cluster is not registered")
+
 type ClusterManager struct {
 	client.Client
 	mutex    sync.RWMutex
 	clusters map[string]*Cluster
 	configs  map[string]*rest.Config
+
+	listenersMu    sync.Mutex
+	listeners      map[int]ClusterListener
+	nextListenerID int
+
+	// scopeNamespaces, once scopeRestricted is set, are the namespaces whose Integrations
+	// may use Cluster scope
+	scopeRestricted bool
+	scopeNamespaces []string
 }
 
 type Cluster struct {
@@ -23,8 +39,49 @@ type Cluster struct {
 	Namespace  string
 	Status     string
 	KubeConfig string
-	Client     kubernetes.Interface
-	Labels     map[string]string
+	// KubeConfigContext is the kubeconfig context used; empty means the current context
+	KubeConfigContext string
+	Client            kubernetes.Interface
+	Labels            map[string]string
+	// Annotations are the ksit.io annotations of the cluster's IntegrationTarget
+	Annotations map[string]string
+
+	// stop is closed when the cluster is removed from the ClusterManager
+	stop chan struct{}
+	// clientCache holds the clients handed out by GetIntegrationClients
+	clientCache clientCache
+}
+
+// Done returns a channel that is closed when the cluster is removed or re-registered
+// with different credentials. Informers and probers started for the cluster should
+// stop when it closes.
+func (c *Cluster) Done() <-chan struct{} {
+	return c.stop
+}
+
+// ClusterListener is notified when clusters join or leave a ClusterManager.
+// Notifications are delivered synchronously, outside the manager's lock.
+type ClusterListener interface {
+	OnClusterAdded(c *Cluster)
+	OnClusterRemoved(c *Cluster)
+}
+
+// ClusterListenerFuncs adapts functions to a ClusterListener; nil functions are skipped
+type ClusterListenerFuncs struct {
+	AddFunc    func(c *Cluster)
+	RemoveFunc func(c *Cluster)
+}
+
+func (f ClusterListenerFuncs) OnClusterAdded(c *Cluster) {
+	if f.AddFunc != nil {
+		f.AddFunc(c)
+	}
+}
+
+func (f ClusterListenerFuncs) OnClusterRemoved(c *Cluster) {
+	if f.RemoveFunc != nil {
+		f.RemoveFunc(c)
+	}
 }
 
 type ClusterStatus string
@@ -39,48 +96,155 @@ const (
 
 func NewClusterManager(c client.Client) *ClusterManager {
 	return &ClusterManager{
-		Client:   c,
-		clusters: make(map[string]*Cluster),
-		configs:  make(map[string]*rest.Config),
+		Client:    c,
+		clusters:  make(map[string]*Cluster),
+		configs:   make(map[string]*rest.Config),
+		listeners: make(map[int]ClusterListener),
 	}
 }
 
-func (cm *ClusterManager) AddCluster(name, namespace string, kubeConfig string) error {
+// RestrictClusterScope limits Cluster scope to the Integrations in namespaces. Lookups for
+// a Cluster-scoped Integration in any other namespace only find the clusters registered
+// in its own namespace, whatever its spec says. Without a restriction Cluster scope is
+// honoured in every namespace.
+func (cm *ClusterManager) RestrictClusterScope(namespaces []string) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
+	cm.scopeRestricted = true
+	cm.scopeNamespaces = slices.Clone(namespaces)
+}
+
+// ClusterScoped reports whether lookups for the Integration may find clusters registered
+// in other namespaces: it asks for Cluster scope, and its namespace is allowed to
+func (cm *ClusterManager) ClusterScoped(integration *ksitv1alpha1.Integration) bool {
+	if !integration.ClusterScoped() {
+		return false
+	}
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return !cm.scopeRestricted || slices.Contains(cm.scopeNamespaces, integration.Namespace)
+}
+
+// AddListener registers a listener for cluster membership changes and returns a
+// function that unregisters it. Clusters registered earlier are not replayed.
+func (cm *ClusterManager) AddListener(l ClusterListener) (remove func()) {
+	cm.listenersMu.Lock()
+	defer cm.listenersMu.Unlock()
+
+	id := cm.nextListenerID
+	cm.nextListenerID++
+	cm.listeners[id] = l
+
+	return func() {
+		cm.listenersMu.Lock()
+		defer cm.listenersMu.Unlock()
+		delete(cm.listeners, id)
+	}
+}
+
+func (cm *ClusterManager) notify(fn func(l ClusterListener)) {
+	cm.listenersMu.Lock()
+	listeners := make([]ClusterListener, 0, len(cm.listeners))
+	for _, l := range cm.listeners {
+		listeners = append(listeners, l)
+	}
+	cm.listenersMu.Unlock()
+
+	for _, l := range listeners {
+		fn(l)
+	}
+}
+
+// AddCluster registers a cluster using the current context of its kubeconfig
+func (cm *ClusterManager) AddCluster(name, namespace string, kubeConfig string) error {
+	return cm.AddClusterWithContext(name, namespace, kubeConfig, "")
+}
 
+// AddClusterWithContext registers a cluster using the named context of a kubeconfig
+// that may hold several. An empty context name selects the current context.
+// Registering a cluster again with the same kubeconfig and context is a no-op; with
+// different ones, listeners see the old cluster removed and the new one added.
+func (cm *ClusterManager) AddClusterWithContext(name, namespace, kubeConfig, contextName string) error {
 	key := fmt.Sprintf("%s/%s", namespace, name)
 
-	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeConfig))
+	cm.mutex.Lock()
+	old, exists := cm.clusters[key]
+	if exists && old.KubeConfig == kubeConfig && old.KubeConfigContext == contextName {
+		cm.mutex.Unlock()
+		return nil
+	}
+
+	config, err := restConfigForContext(kubeConfig, contextName)
 	if err != nil {
-		return fmt.Errorf("failed to parse kubeconfig: %w", err)
+		cm.mutex.Unlock()
+		return err
 	}
 
 	kubeClient, err := kubernetes.NewForConfig(config)
 	if err != nil {
+		cm.mutex.Unlock()
 		return fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	cm.clusters[key] = &Cluster{
-		Name:       name,
-		Namespace:  namespace,
-		Status:     string(ClusterStatusActive),
-		KubeConfig: kubeConfig,
-		Client:     kubeClient,
-		Labels:     make(map[string]string),
+	added := &Cluster{
+		Name:              name,
+		Namespace:         namespace,
+		Status:            string(ClusterStatusActive),
+		KubeConfig:        kubeConfig,
+		KubeConfigContext: contextName,
+		Client:            kubeClient,
+		Labels:            make(map[string]string),
+		stop:              make(chan struct{}),
 	}
+	cm.clusters[key] = added
 	cm.configs[key] = config
+	cm.mutex.Unlock()
+
+	if exists {
+		close(old.stop)
+		cm.notify(func(l ClusterListener) { l.OnClusterRemoved(old) })
+	}
+	cm.notify(func(l ClusterListener) { l.OnClusterAdded(added) })
 
 	return nil
 }
 
-func (cm *ClusterManager) RemoveCluster(name, namespace string) error {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
+func restConfigForContext(kubeConfig, contextName string) (*rest.Config, error) {
+	raw, err := clientcmd.Load([]byte(kubeConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if contextName != "" {
+		if _, ok := raw.Contexts[contextName]; !ok {
+			return nil, fmt.Errorf("context %q not found in kubeconfig", contextName)
+		}
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	config, err := clientcmd.NewDefaultClientConfig(*raw, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	config.UserAgent = UserAgent("")
+	return config, nil
+}
 
+// RemoveCluster unregisters a cluster, closes its Done channel and notifies listeners.
+// Removing a cluster that is not registered does nothing.
+func (cm *ClusterManager) RemoveCluster(name, namespace string) error {
 	key := fmt.Sprintf("%s/%s", namespace, name)
+
+	cm.mutex.Lock()
+	removed, exists := cm.clusters[key]
 	delete(cm.clusters, key)
 	delete(cm.configs, key)
+	cm.mutex.Unlock()
+
+	if !exists {
+		return nil
+	}
+	close(removed.stop)
+	cm.notify(func(l ClusterListener) { l.OnClusterRemoved(removed) })
 
 	return nil
 }
@@ -149,7 +313,7 @@ func (cm *ClusterManager) GetClusterConfig(name, namespace string) (*rest.Config
 		for k := range cm.configs {
 			availableKeys = append(availableKeys, k)
 		}
-		return nil, fmt.Errorf("config for cluster %s/%s not found (available clusters: %v)", namespace, name, availableKeys)
+		return nil, fmt.Errorf("config for cluster %s/%s not found (available clusters: %v): %w", namespace, name, availableKeys, ErrClusterNotRegistered)
 	}
 
 	return config, nil
@@ -199,6 +363,21 @@ func (cm *ClusterManager) SetClusterLabels(name, namespace string, labels map[st
 	return nil
 }
 
+// SetClusterAnnotations records the ksit.io annotations of a cluster's IntegrationTarget
+func (cm *ClusterManager) SetClusterAnnotations(name, namespace string, annotations map[string]string) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	cluster, exists := cm.clusters[key]
+	if !exists {
+		return fmt.Errorf("cluster %s/%s not found", namespace, name)
+	}
+
+	cluster.Annotations = annotations
+	return nil
+}
+
 func (cm *ClusterManager) GetClustersByLabel(key, value string) []*Cluster {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mergedKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: ksit
+  user:
+    token: abc
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: ksit
+- name: prod
+  context:
+    cluster: prod
+    user: ksit
+`
+
+func TestAddClusterWithContext(t *testing.T) {
+	cm := NewClusterManager(nil)
+
+	require.NoError(t, cm.AddCluster("dev", "ksit-system", mergedKubeconfig))
+	config, err := cm.GetClusterConfig("dev", "ksit-system")
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com", config.Host)
+
+	require.NoError(t, cm.AddClusterWithContext("prod", "ksit-system", mergedKubeconfig, "prod"))
+	config, err = cm.GetClusterConfig("prod", "ksit-system")
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", config.Host)
+	assert.Equal(t, "abc", config.BearerToken)
+
+	cluster, err := cm.GetCluster("prod", "ksit-system")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cluster.KubeConfigContext)
+
+	err = cm.AddClusterWithContext("staging", "ksit-system", mergedKubeconfig, "staging")
+	assert.EqualError(t, err, `context "staging" not found in kubeconfig`)
+}
+
+func TestClusterListeners(t *testing.T) {
+	cm := NewClusterManager(nil)
+
+	var events []string
+	remove := cm.AddListener(ClusterListenerFuncs{
+		AddFunc:    func(c *Cluster) { events = append(events, "added "+c.Name+" "+c.KubeConfigContext) },
+		RemoveFunc: func(c *Cluster) { events = append(events, "removed "+c.Name+" "+c.KubeConfigContext) },
+	})
+
+	require.NoError(t, cm.AddCluster("edge", "ksit-system", mergedKubeconfig))
+	first, err := cm.GetCluster("edge", "ksit-system")
+	require.NoError(t, err)
+
+	// Registering the same kubeconfig again changes nothing
+	require.NoError(t, cm.AddCluster("edge", "ksit-system", mergedKubeconfig))
+	assert.Equal(t, []string{"added edge "}, events)
+
+	// New credentials replace the cluster and stop whatever ran for the old one
+	require.NoError(t, cm.AddClusterWithContext("edge", "ksit-system", mergedKubeconfig, "prod"))
+	assert.Equal(t, []string{"added edge ", "removed edge ", "added edge prod"}, events)
+	select {
+	case <-first.Done():
+	default:
+		t.Fatal("Done of the replaced cluster is not closed")
+	}
+
+	second, err := cm.GetCluster("edge", "ksit-system")
+	require.NoError(t, err)
+	require.NoError(t, cm.RemoveCluster("edge", "ksit-system"))
+	require.NoError(t, cm.RemoveCluster("edge", "ksit-system"))
+	assert.Equal(t, "removed edge prod", events[len(events)-1])
+	assert.Len(t, events, 4)
+	<-second.Done()
+
+	remove()
+	require.NoError(t, cm.AddCluster("edge", "ksit-system", mergedKubeconfig))
+	assert.Len(t, events, 4)
+}
@@ -0,0 +1,220 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// SortField selects the ordering of inventory query results
+type SortField string
+
+const (
+	SortByName     SortField = "name"
+	SortByLastSeen SortField = "lastSeen"
+)
+
+// InventoryQuery filters, sorts and paginates the cluster inventory.
+// Zero values mean "no filter"; results are sorted by name by default.
+type InventoryQuery struct {
+	// Status only matches clusters with this status
+	Status string
+	// Selector only matches clusters whose labels it selects
+	Selector labels.Selector
+	// Capability only matches clusters that advertise this capability
+	Capability string
+
+	SortBy     SortField
+	Descending bool
+
+	// Offset skips this many matching clusters; Limit caps the page size (0 = no limit)
+	Offset int
+	Limit  int
+}
+
+// QueryResult is one page of an inventory query
+type QueryResult struct {
+	Items []*ClusterInfo
+	// Total is the number of clusters matching the filters, before pagination
+	Total int
+	// Continue is the token of the next page, empty on the last page
+	Continue string
+}
+
+// ParseContinue returns the offset of the page a continue token of a QueryResult
+// points at
+func ParseContinue(token string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid continue token %q", token)
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid continue token %q", token)
+	}
+	return offset, nil
+}
+
+func continueToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// Query returns the clusters matching q. The returned ClusterInfo values are
+// copies, so callers can hold on to them without racing inventory updates.
+func (ci *ClusterInventory) Query(q InventoryQuery) (*QueryResult, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	ci.mu.RLock()
+	candidates := ci.candidates(q)
+	matches := make([]*ClusterInfo, 0, len(candidates))
+	for name := range candidates {
+		if cluster := ci.clusters[name]; q.matches(cluster) {
+			matches = append(matches, cluster.copy())
+		}
+	}
+	ci.mu.RUnlock()
+
+	return q.page(matches), nil
+}
+
+// QueryClusters applies q to clusters that are not held in an inventory, such as
+// those read from IntegrationTargets
+func QueryClusters(clusters []*ClusterInfo, q InventoryQuery) (*QueryResult, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	matches := make([]*ClusterInfo, 0, len(clusters))
+	for _, cluster := range clusters {
+		if q.matches(cluster) {
+			matches = append(matches, cluster)
+		}
+	}
+	return q.page(matches), nil
+}
+
+func (q InventoryQuery) validate() error {
+	if q.Offset < 0 || q.Limit < 0 {
+		return fmt.Errorf("offset and limit must not be negative")
+	}
+	if q.SortBy != "" && q.SortBy != SortByName && q.SortBy != SortByLastSeen {
+		return fmt.Errorf("unsupported sort field %q", q.SortBy)
+	}
+	return nil
+}
+
+// page sorts the clusters matching q and returns the page of q
+func (q InventoryQuery) page(matches []*ClusterInfo) *QueryResult {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if q.Descending {
+			a, b = b, a
+		}
+		if q.SortBy == SortByLastSeen && !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.Before(b.LastSeen)
+		}
+		return a.Name < b.Name
+	})
+
+	result := &QueryResult{Total: len(matches)}
+	if q.Offset >= len(matches) {
+		result.Items = []*ClusterInfo{}
+		return result
+	}
+
+	end := len(matches)
+	if q.Limit > 0 && q.Offset+q.Limit < end {
+		end = q.Offset + q.Limit
+		result.Continue = continueToken(end)
+	}
+	result.Items = matches[q.Offset:end]
+	return result
+}
+
+// candidates returns the names of the clusters that may match q: the smallest of the
+// index entries of its status and of the equality requirements of its selector, or
+// every cluster when q has neither. The caller holds ci.mu.
+func (ci *ClusterInventory) candidates(q InventoryQuery) map[string]struct{} {
+	var smallest map[string]struct{}
+	narrowed := false
+	narrow := func(set map[string]struct{}) {
+		if !narrowed || len(set) < len(smallest) {
+			smallest, narrowed = set, true
+		}
+	}
+
+	if q.Status != "" {
+		narrow(ci.byStatus[q.Status])
+	}
+	if q.Selector != nil {
+		requirements, _ := q.Selector.Requirements()
+		for _, requirement := range requirements {
+			switch requirement.Operator() {
+			case selection.Equals, selection.DoubleEquals, selection.In:
+			default:
+				continue
+			}
+			values := requirement.Values().List()
+			if len(values) == 1 {
+				narrow(ci.byLabel[requirement.Key()][values[0]])
+				continue
+			}
+			union := make(map[string]struct{})
+			for _, value := range values {
+				for name := range ci.byLabel[requirement.Key()][value] {
+					union[name] = struct{}{}
+				}
+			}
+			narrow(union)
+		}
+	}
+
+	if narrowed {
+		return smallest
+	}
+	all := make(map[string]struct{}, len(ci.clusters))
+	for name := range ci.clusters {
+		all[name] = struct{}{}
+	}
+	return all
+}
+
+func (q InventoryQuery) matches(cluster *ClusterInfo) bool {
+	if q.Status != "" && cluster.Status != q.Status {
+		return false
+	}
+	if q.Selector != nil && !q.Selector.Matches(labels.Set(cluster.Labels)) {
+		return false
+	}
+	if q.Capability != "" {
+		found := false
+		for _, capability := range cluster.Capabilities {
+			if capability == q.Capability {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ClusterInfo) copy() *ClusterInfo {
+	out := *c
+	if c.Labels != nil {
+		out.Labels = make(map[string]string, len(c.Labels))
+		for k, v := range c.Labels {
+			out.Labels[k] = v
+		}
+	}
+	out.Capabilities = append([]string(nil), c.Capabilities...)
+	return &out
+}
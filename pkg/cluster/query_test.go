@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func newTestInventory(n int) *ClusterInventory {
+	inv := NewClusterInventory()
+	base := time.Now()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("cluster-%02d", i)
+		status := string(ClusterStatusActive)
+		if i%4 == 0 {
+			status = string(ClusterStatusError)
+		}
+		info := &ClusterInfo{
+			Name:     name,
+			Status:   status,
+			LastSeen: base.Add(-time.Duration(i) * time.Minute),
+			Labels:   map[string]string{"env": []string{"prod", "dev"}[i%2]},
+		}
+		if i%3 == 0 {
+			info.Capabilities = []string{"gpu"}
+		}
+		inv.put(info)
+	}
+	return inv
+}
+
+func TestInventoryQuery(t *testing.T) {
+	inv := newTestInventory(12)
+
+	t.Run("default sorts by name", func(t *testing.T) {
+		result, err := inv.Query(InventoryQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, 12, result.Total)
+		assert.Equal(t, "cluster-00", result.Items[0].Name)
+		assert.Equal(t, "cluster-11", result.Items[11].Name)
+	})
+
+	t.Run("filters combine", func(t *testing.T) {
+		selector, err := labels.Parse("env=prod")
+		require.NoError(t, err)
+
+		result, err := inv.Query(InventoryQuery{
+			Status:     string(ClusterStatusError),
+			Selector:   selector,
+			Capability: "gpu",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Total)
+		assert.Equal(t, "cluster-00", result.Items[0].Name)
+	})
+
+	t.Run("sort by lastSeen and paginate", func(t *testing.T) {
+		result, err := inv.Query(InventoryQuery{SortBy: SortByLastSeen, Descending: true, Offset: 2, Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, 12, result.Total)
+		require.Len(t, result.Items, 3)
+		assert.Equal(t, "cluster-02", result.Items[0].Name)
+		assert.Equal(t, "cluster-04", result.Items[2].Name)
+
+		// The continue token points at the next page
+		offset, err := ParseContinue(result.Continue)
+		require.NoError(t, err)
+		assert.Equal(t, 5, offset)
+		last, err := inv.Query(InventoryQuery{Offset: 9, Limit: 3})
+		require.NoError(t, err)
+		assert.Empty(t, last.Continue)
+
+		_, err = ParseContinue("not-a-token")
+		assert.Error(t, err)
+	})
+
+	t.Run("offset past the end", func(t *testing.T) {
+		result, err := inv.Query(InventoryQuery{Offset: 50})
+		require.NoError(t, err)
+		assert.Equal(t, 12, result.Total)
+		assert.Empty(t, result.Items)
+	})
+
+	t.Run("results are copies", func(t *testing.T) {
+		result, err := inv.Query(InventoryQuery{Limit: 1})
+		require.NoError(t, err)
+		result.Items[0].Labels["env"] = "changed"
+		assert.Equal(t, "prod", inv.clusters["cluster-00"].Labels["env"])
+	})
+
+	t.Run("invalid sort field", func(t *testing.T) {
+		_, err := inv.Query(InventoryQuery{SortBy: "nodes"})
+		assert.Error(t, err)
+	})
+}
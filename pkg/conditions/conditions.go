@@ -0,0 +1,106 @@
+// Package conditions maps the errors of reconciles and installs to condition reasons and
+// to whether retrying soon can help, so that conditions read the same for every
+// integration type and tooling can act on the reason alone
+package conditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+// Class is what kind of failure an error is
+type Class struct {
+	// Reason is the condition reason of the failure
+	Reason string
+	// Retryable is true when the failure may clear without anyone changing anything, so
+	// retrying soon can help. Missing CRDs, rejected credentials and objects that do not
+	// exist need a fix first.
+	Retryable bool
+}
+
+// HelmError is an error Helm returned for a release
+type HelmError struct {
+	Release string
+	Err     error
+}
+
+// Helm marks err as returned by Helm for release; nil stays nil
+func Helm(release string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &HelmError{Release: release, Err: err}
+}
+
+func (e *HelmError) Error() string {
+	return fmt.Sprintf("helm release %s: %v", e.Release, e.Err)
+}
+
+func (e *HelmError) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the class of err. fallback is the reason of errors that fit no class;
+// it defaults to ReasonReconcileFailed. The most specific class wins: a Helm failure
+// caused by a timeout is HelmFailed, and retryable.
+func Classify(err error, fallback string) Class {
+	if fallback == "" {
+		fallback = ksitv1alpha1.ReasonReconcileFailed
+	}
+	var helmErr *HelmError
+	switch {
+	case crds.IsMissingCRDs(err):
+		return Class{Reason: ksitv1alpha1.ReasonMissingCRDs}
+	case errors.As(err, &helmErr):
+		return Class{Reason: ksitv1alpha1.ReasonHelmFailed, Retryable: isTimeout(err) || utils.IsTransient(err)}
+	case apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err):
+		return Class{Reason: ksitv1alpha1.ReasonUnauthorized}
+	case apierrors.IsNotFound(err) || errors.Is(err, cluster.ErrClusterNotRegistered):
+		return Class{Reason: ksitv1alpha1.ReasonNotFound}
+	case isTimeout(err):
+		return Class{Reason: ksitv1alpha1.ReasonTimeout, Retryable: true}
+	}
+	return Class{Reason: fallback, Retryable: utils.IsTransient(err)}
+}
+
+// Reason returns the condition reason of err, or fallback when it fits no class
+func Reason(err error, fallback string) string {
+	return Classify(err, fallback).Reason
+}
+
+// Retryable reports whether retrying soon can help with err
+func Retryable(err error) bool {
+	return Classify(err, "").Retryable
+}
+
+// Failed returns a False condition of conditionType for err
+func Failed(conditionType string, err error, fallback string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  Reason(err, fallback),
+		Message: err.Error(),
+	}
+}
+
+// isTimeout reports whether err is a deadline or a timeout of the network or API server
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err)
+}
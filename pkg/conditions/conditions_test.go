@@ -0,0 +1,100 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+func TestClassify(t *testing.T) {
+	secrets := schema.GroupResource{Resource: "secrets"}
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{
+			name: "missing CRDs",
+			err:  fmt.Errorf("cluster1: %w", &crds.MissingCRDsError{Missing: []schema.GroupVersionKind{{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}}}),
+			want: Class{Reason: ksitv1alpha1.ReasonMissingCRDs},
+		},
+		{
+			name: "helm failure",
+			err:  Helm("argocd", errors.New("chart requires kubeVersion >=1.25")),
+			want: Class{Reason: ksitv1alpha1.ReasonHelmFailed},
+		},
+		{
+			name: "helm timeout",
+			err:  Helm("argocd", context.DeadlineExceeded),
+			want: Class{Reason: ksitv1alpha1.ReasonHelmFailed, Retryable: true},
+		},
+		{
+			name: "forbidden",
+			err:  apierrors.NewForbidden(secrets, "argocd-secret", errors.New("no RBAC")),
+			want: Class{Reason: ksitv1alpha1.ReasonUnauthorized},
+		},
+		{
+			name: "unauthorized",
+			err:  apierrors.NewUnauthorized("token expired"),
+			want: Class{Reason: ksitv1alpha1.ReasonUnauthorized},
+		},
+		{
+			name: "object not found",
+			err:  fmt.Errorf("failed to get secret: %w", apierrors.NewNotFound(secrets, "argocd-secret")),
+			want: Class{Reason: ksitv1alpha1.ReasonNotFound},
+		},
+		{
+			name: "cluster not registered",
+			err:  fmt.Errorf("cluster2: %w", cluster.ErrClusterNotRegistered),
+			want: Class{Reason: ksitv1alpha1.ReasonNotFound},
+		},
+		{
+			name: "deadline",
+			err:  fmt.Errorf("cluster1: %w", context.DeadlineExceeded),
+			want: Class{Reason: ksitv1alpha1.ReasonTimeout, Retryable: true},
+		},
+		{
+			name: "throttled",
+			err:  apierrors.NewTooManyRequests("slow down", 1),
+			want: Class{Reason: "ConnectionFailed", Retryable: true},
+		},
+		{
+			name: "other",
+			err:  errors.New("argocd-server has 0 ready replicas"),
+			want: Class{Reason: "ConnectionFailed"},
+		},
+		{
+			name: "several clusters",
+			err:  errors.Join(errors.New("cluster1: unhealthy"), fmt.Errorf("cluster2: %w", context.DeadlineExceeded)),
+			want: Class{Reason: ksitv1alpha1.ReasonTimeout, Retryable: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err, "ConnectionFailed"))
+		})
+	}
+
+	assert.Equal(t, ksitv1alpha1.ReasonReconcileFailed, Reason(errors.New("boom"), ""))
+	assert.Nil(t, Helm("argocd", nil))
+}
+
+func TestFailed(t *testing.T) {
+	err := fmt.Errorf("failed to get cluster: %w", cluster.ErrClusterNotRegistered)
+	assert.Equal(t, metav1.Condition{
+		Type:    ksitv1alpha1.ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  ksitv1alpha1.ReasonNotFound,
+		Message: err.Error(),
+	}, Failed(ksitv1alpha1.ConditionTypeReady, err, ""))
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
 )
 
 type Config struct {
@@ -19,6 +23,22 @@ type Config struct {
 	Integrations   []IntegrationConfig `json:"integrations" yaml:"integrations"`
 	Webhook        WebhookConfig       `json:"webhook" yaml:"webhook"`
 	Reconcile      ReconcileConfig     `json:"reconcile" yaml:"reconcile"`
+	API            APIConfig           `json:"api" yaml:"api"`
+	History        HistoryConfig       `json:"history" yaml:"history"`
+	Metrics        MetricsConfig       `json:"metrics" yaml:"metrics"`
+	KubeStellar    KubeStellarConfig   `json:"kubeStellar" yaml:"kubeStellar"`
+	Readiness      ReadinessConfig     `json:"readiness" yaml:"readiness"`
+
+	// FeatureGates turns optional features on or off by name
+	FeatureGates map[string]bool `json:"featureGates" yaml:"featureGates"`
+
+	// AutoInstallDefaults holds organization-wide autoInstall settings keyed by integration type.
+	// The defaulting webhook merges them into Integrations that leave those settings empty.
+	AutoInstallDefaults map[string]AutoInstallDefaults `json:"autoInstallDefaults" yaml:"autoInstallDefaults"`
+
+	// ClusterScopeNamespaces are the namespaces whose Integrations may set scope: Cluster
+	// and target clusters registered in any namespace
+	ClusterScopeNamespaces []string `json:"clusterScopeNamespaces" yaml:"clusterScopeNamespaces"`
 }
 
 type IntegrationConfig struct {
@@ -36,10 +56,262 @@ type WebhookConfig struct {
 	KeyName  string `json:"keyName" yaml:"keyName"`
 }
 
+// APIConfig configures the fleet API served by the controller manager
+type APIConfig struct {
+	Auth APIAuthConfig `json:"auth" yaml:"auth"`
+	// CertFile and KeyFile are the serving certificate and key. Without them the API is
+	// served over plain HTTP, which mtls mode does not allow and the token modes only
+	// allow when Insecure is set.
+	CertFile string `json:"certFile" yaml:"certFile"`
+	KeyFile  string `json:"keyFile" yaml:"keyFile"`
+	// Insecure allows bearer tokens over plain HTTP, e.g. behind a proxy that terminates
+	// TLS. Without it the API does not start with token authentication and no certificate.
+	Insecure bool `json:"insecure" yaml:"insecure"`
+}
+
+// APIAuthConfig selects how fleet API clients are authenticated and authorized
+type APIAuthConfig struct {
+	// Mode is "kubernetes" (TokenReview and SubjectAccessReview against the hub),
+	// "token" (static bearer tokens) or "mtls" (client certificates)
+	Mode string `json:"mode" yaml:"mode"`
+	// TokenFile is a CSV file of token,user,uid,"group1,group2" lines, used in token mode
+	TokenFile string `json:"tokenFile" yaml:"tokenFile"`
+	// ClientCAFile holds the CA bundle client certificates are verified against, used in mtls mode
+	ClientCAFile string `json:"clientCAFile" yaml:"clientCAFile"`
+	// ReadGroups may call read-only endpoints; empty allows every authenticated user.
+	// WriteGroups may also call mutating endpoints. Both are ignored in kubernetes mode.
+	ReadGroups  []string `json:"readGroups" yaml:"readGroups"`
+	WriteGroups []string `json:"writeGroups" yaml:"writeGroups"`
+}
+
+// MetricsConfig aligns the controller's Prometheus metrics with an organization's
+// naming conventions and SLO boundaries
+type MetricsConfig struct {
+	// Namespace prefixes every metric name, e.g. ksit_integration_status; defaults to ksit
+	Namespace string `json:"namespace" yaml:"namespace"`
+	// ReconcileDurationBuckets are the upper bounds, in seconds, of the
+	// integration_reconcile_duration_seconds histogram
+	ReconcileDurationBuckets []float64 `json:"reconcileDurationBuckets" yaml:"reconcileDurationBuckets"`
+	// SyncLatencyBuckets are the upper bounds, in seconds, of the sync_latency_seconds histogram
+	SyncLatencyBuckets []float64 `json:"syncLatencyBuckets" yaml:"syncLatencyBuckets"`
+	// SelfMonitoring configures how the controller's own metrics endpoint is published
+	SelfMonitoring SelfMonitoringConfig `json:"selfMonitoring" yaml:"selfMonitoring"`
+	// Textfile periodically writes the metrics to a file or an S3 object, for hubs
+	// Prometheus cannot scrape
+	Textfile TextfileConfig `json:"textfile" yaml:"textfile"`
+}
+
+// TextfileConfig writes the KSIT metrics to a node-exporter textfile collector
+// directory and/or an S3 object. Neither is written when Path and S3.Bucket are empty.
+type TextfileConfig struct {
+	// Path of the file, e.g. /var/lib/node_exporter/textfile_collector/ksit.prom
+	Path string `json:"path" yaml:"path"`
+	// S3 uploads the metrics as <prefix>/ksit.prom; credentials come from the same
+	// environment variables as the s3 history backend
+	S3 S3HistoryConfig `json:"s3" yaml:"s3"`
+	// Interval between writes; defaults to 1m
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Format is "text", the Prometheus text format the textfile collector reads, or
+	// "openmetrics"; defaults to text
+	Format string `json:"format" yaml:"format"`
+}
+
+// Enabled reports whether the metrics are written anywhere
+func (t TextfileConfig) Enabled() bool {
+	return t.Path != "" || t.S3.Bucket != ""
+}
+
+// SelfMonitoringConfig configures the Service and ServiceMonitor the controller creates on
+// the hub for its own metrics endpoint while a Prometheus Integration is enabled there
+type SelfMonitoringConfig struct {
+	// Disabled leaves scraping the controller to the operator
+	Disabled bool `json:"disabled" yaml:"disabled"`
+	// ServiceName names the Service and the ServiceMonitor; defaults to ksit-controller-metrics
+	ServiceName string `json:"serviceName" yaml:"serviceName"`
+	// PodSelector selects the controller pods; defaults to control-plane: controller-manager
+	PodSelector map[string]string `json:"podSelector" yaml:"podSelector"`
+	// Labels are added to the ServiceMonitor, such as the release label the Prometheus
+	// of kube-prometheus-stack selects ServiceMonitors by
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	// Interval and ScrapeTimeout of the ServiceMonitor; empty keeps Prometheus' defaults
+	Interval      string `json:"interval" yaml:"interval"`
+	ScrapeTimeout string `json:"scrapeTimeout" yaml:"scrapeTimeout"`
+}
+
+// ReadinessConfig decides when the controller reports ready on /readyz
+type ReadinessConfig struct {
+	// MinConnectedPercent is the share of the registered clusters targeted by enabled
+	// Integrations the controller must have reached before it is ready; 0, the default,
+	// does not wait for clusters
+	MinConnectedPercent int `json:"minConnectedPercent" yaml:"minConnectedPercent"`
+	// StartupTimeout is how long the controller waits for the clusters before it reports
+	// ready anyway
+	StartupTimeout time.Duration `json:"startupTimeout" yaml:"startupTimeout"`
+}
+
+// metricNamespacePattern matches valid Prometheus metric name prefixes
+var metricNamespacePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Validate checks the metric namespace and that the buckets are increasing
+func (m MetricsConfig) Validate() error {
+	if m.Namespace != "" && !metricNamespacePattern.MatchString(m.Namespace) {
+		return fmt.Errorf("invalid metrics.namespace %q", m.Namespace)
+	}
+	if err := validateBuckets("metrics.reconcileDurationBuckets", m.ReconcileDurationBuckets); err != nil {
+		return err
+	}
+	if err := validateBuckets("metrics.syncLatencyBuckets", m.SyncLatencyBuckets); err != nil {
+		return err
+	}
+	switch m.Textfile.Format {
+	case "", "text", "openmetrics":
+	default:
+		return fmt.Errorf("invalid metrics.textfile.format %q, must be text or openmetrics", m.Textfile.Format)
+	}
+	if m.Textfile.Interval < 0 {
+		return fmt.Errorf("metrics.textfile.interval must not be negative")
+	}
+	if m.Textfile.S3.Bucket != "" && m.Textfile.S3.Region == "" {
+		return fmt.Errorf("metrics.textfile.s3.region is required with metrics.textfile.s3.bucket")
+	}
+	for field, value := range map[string]string{
+		"metrics.selfMonitoring.interval":      m.SelfMonitoring.Interval,
+		"metrics.selfMonitoring.scrapeTimeout": m.SelfMonitoring.ScrapeTimeout,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s %q", field, value)
+		}
+	}
+	return nil
+}
+
+func validateBuckets(field string, buckets []float64) error {
+	for i, bound := range buckets {
+		if bound <= 0 {
+			return fmt.Errorf("%s must be positive", field)
+		}
+		if i > 0 && bound <= buckets[i-1] {
+			return fmt.Errorf("%s must be in increasing order", field)
+		}
+	}
+	return nil
+}
+
+// Feature gates
+const (
+	// FeatureOnlineChartValidation makes the validating webhook look up the Helm chart and
+	// version of an Integration in its repository and warn when they do not exist
+	FeatureOnlineChartValidation = "OnlineChartValidation"
+
+	// FeatureStrictTargetValidation makes the validating webhook reject Integrations that
+	// list clusters no IntegrationTarget registers, instead of warning about them
+	FeatureStrictTargetValidation = "StrictTargetValidation"
+)
+
+// knownFeatures are the feature gates and whether they are on by default
+var knownFeatures = map[string]bool{
+	FeatureOnlineChartValidation:  false,
+	FeatureStrictTargetValidation: false,
+}
+
+// History backends
+const (
+	HistoryBackendFile     = "file"
+	HistoryBackendS3       = "s3"
+	HistoryBackendPostgres = "postgres"
+)
+
+// KubeStellarConfig locates the KubeStellar spaces Integrations with spec.kubeStellar
+// read their delivery status from
+type KubeStellarConfig struct {
+	// WDSKubeconfig is the kubeconfig file of the WDS holding the Bindings; empty uses
+	// the cluster the controller runs in
+	WDSKubeconfig string `json:"wdsKubeconfig" yaml:"wdsKubeconfig"`
+	// ITSKubeconfig is the kubeconfig file of the ITS holding the WorkStatuses; empty uses the WDS
+	ITSKubeconfig string `json:"itsKubeconfig" yaml:"itsKubeconfig"`
+}
+
+// HistoryConfig selects an external store that keeps every install action, for change
+// history that must outlive the InstalledComponent objects on the hub
+type HistoryConfig struct {
+	// Backend is "file", "s3" or "postgres"; empty keeps history on the hub only
+	Backend  string                `json:"backend" yaml:"backend"`
+	File     FileHistoryConfig     `json:"file" yaml:"file"`
+	S3       S3HistoryConfig       `json:"s3" yaml:"s3"`
+	Postgres PostgresHistoryConfig `json:"postgres" yaml:"postgres"`
+}
+
+// FileHistoryConfig appends history as JSON lines to a file
+type FileHistoryConfig struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+// S3HistoryConfig writes one object per history record to a bucket. Credentials come
+// from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+type S3HistoryConfig struct {
+	// Endpoint of an S3-compatible store; defaults to AWS S3 in Region
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	Region   string `json:"region" yaml:"region"`
+	Bucket   string `json:"bucket" yaml:"bucket"`
+	Prefix   string `json:"prefix" yaml:"prefix"`
+}
+
+// PostgresHistoryConfig inserts history into a table. The password is best left out
+// of DSN and set with the PGPASSWORD environment variable.
+type PostgresHistoryConfig struct {
+	DSN string `json:"dsn" yaml:"dsn"`
+	// Table defaults to ksit_install_history
+	Table string `json:"table" yaml:"table"`
+}
+
+// AutoInstallDefaults are the default autoInstall settings for one integration type
+type AutoInstallDefaults struct {
+	Method      string        `json:"method" yaml:"method"`
+	ManifestURL string        `json:"manifestUrl" yaml:"manifestUrl"`
+	Helm        *HelmDefaults `json:"helm" yaml:"helm"`
+}
+
+// HelmDefaults are the default Helm settings for one integration type, typically
+// pointing at an internal chart mirror with a pinned version
+type HelmDefaults struct {
+	Repository  string            `json:"repository" yaml:"repository"`
+	Chart       string            `json:"chart" yaml:"chart"`
+	Version     string            `json:"version" yaml:"version"`
+	ReleaseName string            `json:"releaseName" yaml:"releaseName"`
+	Values      map[string]string `json:"values" yaml:"values"`
+}
+
 type ReconcileConfig struct {
-	Interval     time.Duration `json:"interval" yaml:"interval"`
-	RetryCount   int           `json:"retryCount" yaml:"retryCount"`
+	// Interval is how often an Integration is reconciled when nothing changes, unless its
+	// spec.reconcileInterval is set
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// RetryCount is how many times a check on a target cluster is retried after a
+	// transient error, such as a timeout or throttling; 0 does not retry
+	RetryCount int `json:"retryCount" yaml:"retryCount"`
+	// RetryBackoff is the delay before the first retry; it doubles with every retry
 	RetryBackoff time.Duration `json:"retryBackoff" yaml:"retryBackoff"`
+	// RetryAttemptTimeout bounds each attempt of a check; 0 leaves only the cluster timeout
+	RetryAttemptTimeout time.Duration `json:"retryAttemptTimeout" yaml:"retryAttemptTimeout"`
+	// RetryBudget bounds the retries of all target clusters of one reconcile of an
+	// Integration, so a fleet-wide outage fails fast; 0 does not bound them
+	RetryBudget int `json:"retryBudget" yaml:"retryBudget"`
+	// MaxConcurrentClusters bounds how many target clusters of one Integration are checked at once
+	MaxConcurrentClusters int `json:"maxConcurrentClusters" yaml:"maxConcurrentClusters"`
+	// HealthSummaryInterval is how often the health of each Integration is summed up in
+	// one log line; between summaries only health transitions are logged at info level
+	HealthSummaryInterval time.Duration `json:"healthSummaryInterval" yaml:"healthSummaryInterval"`
+	// RequeueBudget bounds how many target cluster checks the periodic reconciles of all
+	// Integrations make per minute together. As Integrations times clusters grows past it,
+	// Integrations are requeued less often than Interval. 0 keeps the fixed interval.
+	RequeueBudget int `json:"requeueBudget" yaml:"requeueBudget"`
+	// FailureBackoff is the delay before an Integration whose reconcile failed is
+	// reconciled again; it doubles, with jitter, on every further failure up to
+	// MaxFailureBackoff. 0 retries failed Integrations at the fixed interval.
+	FailureBackoff    time.Duration `json:"failureBackoff" yaml:"failureBackoff"`
+	MaxFailureBackoff time.Duration `json:"maxFailureBackoff" yaml:"maxFailureBackoff"`
 }
 
 func NewDefaultConfig() *Config {
@@ -55,11 +327,26 @@ func NewDefaultConfig() *Config {
 			CertDir: "/tmp/k8s-webhook-server/serving-certs",
 		},
 		Reconcile: ReconcileConfig{
-			Interval:     30 * time.Second,
-			RetryCount:   3,
-			RetryBackoff: 5 * time.Second,
+			Interval:            30 * time.Second,
+			RetryCount:          3,
+			RetryBackoff:        500 * time.Millisecond,
+			RetryAttemptTimeout: 10 * time.Second,
+			RetryBudget:         20,
+
+			MaxConcurrentClusters: 10,
+			HealthSummaryInterval: 10 * time.Minute,
+			RequeueBudget:         600,
+			FailureBackoff:        5 * time.Second,
+			MaxFailureBackoff:     5 * time.Minute,
+		},
+		API: APIConfig{
+			Auth: APIAuthConfig{Mode: "kubernetes"},
 		},
-		Integrations: []IntegrationConfig{},
+		Readiness: ReadinessConfig{
+			StartupTimeout: 5 * time.Minute,
+		},
+		Integrations:           []IntegrationConfig{},
+		ClusterScopeNamespaces: []string{"ksit-system"},
 	}
 }
 
@@ -98,6 +385,67 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("clusterName is required")
 	}
 
+	switch auth := c.API.Auth; auth.Mode {
+	case "", "kubernetes":
+	case "token":
+		if auth.TokenFile == "" {
+			return fmt.Errorf("api.auth.tokenFile is required in token mode")
+		}
+	case "mtls":
+		if auth.ClientCAFile == "" {
+			return fmt.Errorf("api.auth.clientCAFile is required in mtls mode")
+		}
+	default:
+		return fmt.Errorf("invalid api.auth mode %q", auth.Mode)
+	}
+	if (c.API.CertFile == "") != (c.API.KeyFile == "") {
+		return fmt.Errorf("api.certFile and api.keyFile must be set together")
+	}
+
+	if c.Reconcile.Interval < 0 {
+		return fmt.Errorf("reconcile.interval must not be negative")
+	}
+	if c.Reconcile.MaxConcurrentClusters < 0 {
+		return fmt.Errorf("reconcile.maxConcurrentClusters must not be negative")
+	}
+	if c.Reconcile.HealthSummaryInterval < 0 {
+		return fmt.Errorf("reconcile.healthSummaryInterval must not be negative")
+	}
+	if c.Reconcile.RetryCount < 0 || c.Reconcile.RetryBackoff < 0 || c.Reconcile.RetryAttemptTimeout < 0 || c.Reconcile.RetryBudget < 0 {
+		return fmt.Errorf("reconcile.retryCount, retryBackoff, retryAttemptTimeout and retryBudget must not be negative")
+	}
+	if c.Reconcile.FailureBackoff < 0 || c.Reconcile.MaxFailureBackoff < 0 {
+		return fmt.Errorf("reconcile.failureBackoff and maxFailureBackoff must not be negative")
+	}
+	if c.Readiness.MinConnectedPercent < 0 || c.Readiness.MinConnectedPercent > 100 {
+		return fmt.Errorf("readiness.minConnectedPercent must be between 0 and 100")
+	}
+	if c.Readiness.StartupTimeout < 0 {
+		return fmt.Errorf("readiness.startupTimeout must not be negative")
+	}
+
+	for name := range c.FeatureGates {
+		if _, ok := knownFeatures[name]; !ok {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+	}
+
+	if err := c.History.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Metrics.Validate(); err != nil {
+		return err
+	}
+
+	for integrationType, defaults := range c.AutoInstallDefaults {
+		switch defaults.Method {
+		case "", "helm", "manifest", "operator":
+		default:
+			return fmt.Errorf("invalid autoInstallDefaults method %q for %s", defaults.Method, integrationType)
+		}
+	}
+
 	for _, integration := range c.Integrations {
 		if integration.Name == "" {
 			return fmt.Errorf("integration name is required")
@@ -110,6 +458,58 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// FeatureEnabled reports whether a feature gate is on
+func (c *Config) FeatureEnabled(name string) bool {
+	if enabled, ok := c.FeatureGates[name]; ok {
+		return enabled
+	}
+	return knownFeatures[name]
+}
+
+// SetFeatureGates parses name=bool pairs, such as those of the --feature-gates flag, over
+// the feature gates of the config file
+func (c *Config) SetFeatureGates(gates map[string]string) error {
+	for name, value := range gates {
+		if _, ok := knownFeatures[name]; !ok {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for feature gate %s", value, name)
+		}
+		if c.FeatureGates == nil {
+			c.FeatureGates = make(map[string]bool)
+		}
+		c.FeatureGates[name] = enabled
+	}
+	return nil
+}
+
+// Validate checks that the selected history backend has the settings it needs
+func (h HistoryConfig) Validate() error {
+	switch h.Backend {
+	case "":
+	case HistoryBackendFile:
+		if h.File.Path == "" {
+			return fmt.Errorf("history.file.path is required for the file backend")
+		}
+	case HistoryBackendS3:
+		if h.S3.Bucket == "" {
+			return fmt.Errorf("history.s3.bucket is required for the s3 backend")
+		}
+		if h.S3.Region == "" {
+			return fmt.Errorf("history.s3.region is required for the s3 backend")
+		}
+	case HistoryBackendPostgres:
+		if h.Postgres.DSN == "" {
+			return fmt.Errorf("history.postgres.dsn is required for the postgres backend")
+		}
+	default:
+		return fmt.Errorf("invalid history backend %q", h.Backend)
+	}
+	return nil
+}
+
 func (c *Config) GetIntegration(name string) (*IntegrationConfig, bool) {
 	for _, integration := range c.Integrations {
 		if integration.Name == name {
@@ -128,3 +528,50 @@ func (c *Config) GetIntegrationsByType(integrationType string) []IntegrationConf
 	}
 	return result
 }
+
+// ApplyTo fills the unset fields of install with the defaults. Fields the Integration
+// sets explicitly always win; default values are only added for keys it does not set.
+func (d AutoInstallDefaults) ApplyTo(install *ksitv1alpha1.InstallConfig) {
+	if install.Method == "" {
+		install.Method = d.Method
+	}
+	if install.ManifestURL == "" {
+		install.ManifestURL = d.ManifestURL
+	}
+
+	if d.Helm == nil || (install.Method != "" && install.Method != "helm") {
+		return
+	}
+
+	if install.HelmConfig == nil {
+		install.HelmConfig = &ksitv1alpha1.HelmInstallConfig{}
+	}
+	helmConfig := install.HelmConfig
+
+	// A pinned version only makes sense for the chart it was pinned for
+	sameChart := helmConfig.Chart == "" || helmConfig.Chart == d.Helm.Chart
+
+	if helmConfig.Repository == "" && sameChart {
+		helmConfig.Repository = d.Helm.Repository
+	}
+	if helmConfig.Chart == "" {
+		helmConfig.Chart = d.Helm.Chart
+	}
+	if helmConfig.Version == "" && sameChart {
+		helmConfig.Version = d.Helm.Version
+	}
+	if helmConfig.ReleaseName == "" {
+		helmConfig.ReleaseName = d.Helm.ReleaseName
+	}
+
+	if sameChart && len(d.Helm.Values) > 0 {
+		if helmConfig.Values == nil {
+			helmConfig.Values = map[string]string{}
+		}
+		for key, value := range d.Helm.Values {
+			if _, exists := helmConfig.Values[key]; !exists {
+				helmConfig.Values[key] = value
+			}
+		}
+	}
+}
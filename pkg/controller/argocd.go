@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/argocd"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+// syncArgoCDApps syncs the Argo CD Applications of a target cluster when config["syncApps"]
+// is true. Applications that fail to sync fail the cluster check, after the others were synced.
+func (r *IntegrationReconciler) syncArgoCDApps(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	if integration.Spec.Config["syncApps"] != "true" {
+		return nil
+	}
+
+	argoClient, err := r.clients().ArgoCD(ctx, integration, clusterName)
+	if err != nil {
+		return err
+	}
+	report, err := argoClient.SyncClusterReport(ctx, clusterName)
+	r.recordSyncReport(integration, report)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+// recordSyncReport counts the failed applications of a sync in the sync operations metric
+// and records a warning event naming them
+func (r *IntegrationReconciler) recordSyncReport(integration *ksitv1alpha1.Integration, report *argocd.SyncReport) {
+	r.Log.Info("synced ArgoCD applications", "integration", integration.Name, "cluster", report.Cluster,
+		"synced", len(report.Synced), "failed", len(report.Failed), "skipped", len(report.Skipped))
+
+	for range report.Failed {
+		prometheus.RecordSyncOperation(integration.Name, report.Cluster, "failed")
+	}
+	if err := report.Err(); err != nil {
+		r.event(integration, corev1.EventTypeWarning, "ArgoCDSyncFailed", err.Error())
+	}
+}
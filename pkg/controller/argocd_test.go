@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/argocd"
+)
+
+func TestRecordSyncReport(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &IntegrationReconciler{Log: logr.Discard(), Recorder: recorder}
+	integration := campaignIntegration()
+
+	r.recordSyncReport(integration, &argocd.SyncReport{
+		Cluster: "edge-1",
+		Synced:  []argocd.AppSyncResult{{Namespace: "argocd", Name: "guestbook"}},
+		Skipped: []argocd.AppSyncResult{{Namespace: "argocd", Name: "billing", Reason: "destination is cluster edge-2"}},
+	})
+	assert.Empty(t, recorder.Events)
+
+	r.recordSyncReport(integration, &argocd.SyncReport{
+		Cluster: "edge-1",
+		Synced:  []argocd.AppSyncResult{{Namespace: "argocd", Name: "guestbook"}},
+		Failed:  []argocd.AppSyncResult{{Namespace: "argocd", Name: "payments", Reason: "permission denied"}},
+	})
+	event := <-recorder.Events
+	assert.Contains(t, event, "ArgoCDSyncFailed")
+	assert.Contains(t, event, "failed to sync 1 of 2 applications on edge-1: argocd/payments: permission denied")
+}
@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/slo"
+)
+
+// recordingInstaller tracks what is installed on each API server host. Installs fail
+// for the hosts in failHosts.
+type recordingInstaller struct {
+	mu         sync.Mutex
+	installed  map[string]bool
+	failHosts  map[string]bool
+	installs   []string
+	uninstalls []string
+}
+
+func newRecordingInstaller() *recordingInstaller {
+	return &recordingInstaller{installed: map[string]bool{}, failHosts: map[string]bool{}}
+}
+
+func (f *recordingInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.installs = append(f.installs, config.Host)
+	if f.failHosts[config.Host] {
+		return fmt.Errorf("helm install failed")
+	}
+	f.installed[config.Host] = true
+	return nil
+}
+
+func (f *recordingInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uninstalls = append(f.uninstalls, config.Host)
+	delete(f.installed, config.Host)
+	return nil
+}
+
+func (f *recordingInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.installed[config.Host], nil
+}
+
+// newArgoCDAPIServer serves the discovery documents of a cluster with the Argo CD CRDs,
+// which is all the controller reads from a target cluster when the installer is faked
+func newArgoCDAPIServer(t *testing.T) *httptest.Server {
+	documents := map[string]string{
+		"/api":    `{"kind":"APIVersions","versions":["v1"]}`,
+		"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[]}`,
+		"/apis": `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"argoproj.io",` +
+			`"versions":[{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}],` +
+			`"preferredVersion":{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}}]}`,
+		"/apis/argoproj.io/v1alpha1": `{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"argoproj.io/v1alpha1","resources":[` +
+			`{"name":"applications","singularName":"application","namespaced":true,"kind":"Application","verbs":["get","list"]},` +
+			`{"name":"appprojects","singularName":"appproject","namespaced":true,"kind":"AppProject","verbs":["get","list"]}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		document, ok := documents[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, document)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newAutoInstallReconciler returns a reconciler whose installer factory serves inst for
+// Argo CD, with one fake API server per cluster. It also returns the API server host of
+// each cluster.
+func newAutoInstallReconciler(t *testing.T, inst installer.Installer, integration *ksitv1alpha1.Integration, clusterNames ...string) (*IntegrationReconciler, map[string]string) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(integration).
+		WithStatusSubresource(&ksitv1alpha1.Integration{}, &ksitv1alpha1.InstalledComponent{}).
+		Build()
+
+	cm := cluster.NewClusterManager(c)
+	hosts := make(map[string]string)
+	for _, name := range clusterNames {
+		server := newArgoCDAPIServer(t)
+		require.NoError(t, cm.AddCluster(name, "ksit-system", testKubeconfig(server.URL)))
+		hosts[name] = server.URL
+	}
+
+	factory := installer.NewInstallerFactory()
+	factory.Register(ksitv1alpha1.IntegrationTypeArgoCD, inst)
+
+	return &IntegrationReconciler{
+		Client:           c,
+		Scheme:           scheme,
+		Log:              logr.Discard(),
+		ClusterManager:   cm,
+		ClusterInventory: cluster.NewClusterInventory(),
+		InstallerFactory: factory,
+		Ledger:           ledger.NewLedger(c),
+		statusBatcher:    newStatusBatcher(minStatusInterval, statusHeartbeatInterval),
+		sloTracker:       slo.NewTracker(),
+	}, hosts
+}
+
+func autoInstallIntegration() *ksitv1alpha1.Integration {
+	integration := campaignIntegration()
+	integration.Spec.Enabled = true
+	integration.Spec.TargetClusters = []string{"cluster-a", "cluster-b"}
+	return integration
+}
+
+// versionedInstaller is a recordingInstaller that installs the chart version of
+// helmConfig and reports it as the release's
+type versionedInstaller struct {
+	*recordingInstaller
+	versions map[string]string
+}
+
+func (f *versionedInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	if err := f.recordingInstaller.Install(ctx, config, integration); err != nil {
+		return err
+	}
+	f.versions[config.Host] = integration.Spec.AutoInstall.HelmConfig.Version
+	return nil
+}
+
+func (f *versionedInstaller) Releases(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) ([]ksitv1alpha1.HelmReleaseStatus, error) {
+	if !f.installed[config.Host] {
+		return nil, nil
+	}
+	return []ksitv1alpha1.HelmReleaseStatus{{Name: "argocd", Status: "deployed", ChartVersion: f.versions[config.Host]}}, nil
+}
+
+func TestHandleAutoInstallUpgradesChartVersion(t *testing.T) {
+	ctx := context.Background()
+	inst := &versionedInstaller{recordingInstaller: newRecordingInstaller(), versions: map[string]string{}}
+	integration := autoInstallIntegration()
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+	// cluster-b already runs Argo CD, installed by someone else
+	inst.installed[hosts["cluster-b"]] = true
+	inst.versions[hosts["cluster-b"]] = "5.40.0"
+
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Equal(t, []string{hosts["cluster-a"]}, inst.installs, "adopted installs keep their version")
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 1)
+
+	// Without selfHeal, a new version upgrades every cluster, adopted ones included
+	integration.Spec.AutoInstall.HelmConfig.Version = "5.52.0"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Equal(t, []string{hosts["cluster-a"], hosts["cluster-a"], hosts["cluster-b"]}, inst.installs)
+	recorded, err := r.Ledger.Get(ctx, integration, "cluster-b")
+	require.NoError(t, err)
+	assert.Equal(t, ksitv1alpha1.InstallActionUpgrade, recorded.Status.LastAction)
+	assert.Equal(t, "5.52.0", recorded.Spec.Version)
+	for _, release := range integration.Status.HelmReleases {
+		assert.Equal(t, "5.52.0", release.ChartVersion, release.Cluster)
+	}
+
+	// A constraint the installed version meets changes nothing
+	integration.Spec.AutoInstall.HelmConfig.Version = "~5.52"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 3)
+
+	// Releases ahead of the pinned version are not downgraded, even with selfHeal
+	integration.Spec.AutoInstall.HelmConfig.Version = "5.51.0"
+	integration.Spec.AutoInstall.SelfHeal = true
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 3)
+}
+
+func TestCompareChartVersion(t *testing.T) {
+	assert.Equal(t, 0, compareChartVersion("5.51.6", "5.51.6"))
+	assert.Equal(t, 0, compareChartVersion("5.51.6", ">=5.50.0 <6.0.0"))
+	assert.Equal(t, -1, compareChartVersion("5.51.6", "5.52.0"))
+	assert.Equal(t, -1, compareChartVersion("6.5.0", "~7.0"))
+	assert.Equal(t, -1, compareChartVersion("5.40.0", ">=5.50.0 <6.0.0"))
+	assert.Equal(t, 1, compareChartVersion("5.53.0", "5.52.0"))
+	assert.Equal(t, 1, compareChartVersion("8.1.0", "~7.0"))
+	assert.Equal(t, 1, compareChartVersion("6.1.0", ">=5.50.0 <6.0.0"))
+	assert.Equal(t, 1, compareChartVersion("nightly", "5.52.0"))
+}
+
+func TestVersionMatches(t *testing.T) {
+	assert.True(t, versionMatches("5.51.6", "5.51.6"))
+	assert.True(t, versionMatches("v1.20.0", "1.20.0"))
+	assert.True(t, versionMatches("5.51.6", ">=5.50.0 <6.0.0"))
+	assert.False(t, versionMatches("5.51.6", "5.52.0"))
+	assert.False(t, versionMatches("nightly", "5.52.0"))
+}
+
+func TestReconcileInstallsOnSelectedClusters(t *testing.T) {
+	ctx := context.Background()
+	inst := newRecordingInstaller()
+	integration := autoInstallIntegration()
+	integration.Spec.TargetClusters = []string{"cluster-a"}
+	integration.Spec.TargetSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	integration.Spec.PausedClusters = []string{"cluster-c"}
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b", "cluster-c")
+	for _, name := range []string{"cluster-b", "cluster-c"} {
+		require.NoError(t, r.Create(ctx, selectorTarget(name, map[string]string{"env": "prod"})))
+	}
+	key := types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace}
+
+	// The first reconcile reloads the object when it adds the finalizer and sets the
+	// phase, which must not drop the selected clusters or bring the paused one back
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{hosts["cluster-a"], hosts["cluster-b"]}, inst.installs)
+
+	current := &ksitv1alpha1.Integration{}
+	require.NoError(t, r.Get(ctx, key, current))
+	assert.Equal(t, []string{"cluster-a"}, current.Spec.TargetClusters)
+	assert.Equal(t, []string{"cluster-a", "cluster-b", "cluster-c"}, current.Status.SelectedClusters)
+	assert.Equal(t, []string{"cluster-c"}, current.Status.PausedClusters)
+}
+
+func TestDeleteWithUninstallOnDelete(t *testing.T) {
+	ctx := context.Background()
+	inst := newRecordingInstaller()
+	integration := autoInstallIntegration()
+	uninstall := true
+	integration.Spec.AutoInstall.UninstallOnDelete = &uninstall
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	done, _, err := r.finalizeIntegration(ctx, integration)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.ElementsMatch(t, []string{hosts["cluster-a"], hosts["cluster-b"]}, inst.uninstalls)
+}
+
+func TestUninstallOnDeleteFallsBackToOnDisable(t *testing.T) {
+	integration := autoInstallIntegration()
+	assert.False(t, uninstallOnDelete(integration))
+
+	integration.Spec.OnDisable = ksitv1alpha1.DisablePolicyUninstall
+	assert.True(t, uninstallOnDelete(integration))
+
+	// An explicit setting wins over onDisable
+	keep := false
+	integration.Spec.AutoInstall.UninstallOnDelete = &keep
+	assert.False(t, uninstallOnDelete(integration))
+
+	uninstall := true
+	integration.Spec.OnDisable = ksitv1alpha1.DisablePolicyRetain
+	integration.Spec.AutoInstall.UninstallOnDelete = &uninstall
+	assert.True(t, uninstallOnDelete(integration))
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+)
+
+// failureBackoff counts the consecutive failed reconciles of each Integration and spaces
+// out their retries exponentially, with jitter, so a broken Integration is not retried
+// at full rate while healthy ones keep their interval. A nil failureBackoff retries at
+// the interval it is given.
+type failureBackoff struct {
+	mu       sync.Mutex
+	config   *utils.RetryConfig
+	failures map[types.NamespacedName]int
+}
+
+func newFailureBackoff(config *utils.RetryConfig) *failureBackoff {
+	if config == nil {
+		return nil
+	}
+	return &failureBackoff{config: config, failures: make(map[types.NamespacedName]int)}
+}
+
+// failed records a failed reconcile of key and returns when to retry it; a nil
+// failureBackoff returns interval
+func (b *failureBackoff) failed(key types.NamespacedName, interval time.Duration) time.Duration {
+	if b == nil {
+		return interval
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures[key]++
+	return b.config.Delay(b.failures[key])
+}
+
+// reset forgets the failures of key after it reconciled, or was disabled or deleted
+func (b *failureBackoff) reset(key types.NamespacedName) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, key)
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+)
+
+func TestFailureBackoff(t *testing.T) {
+	argocd := types.NamespacedName{Namespace: "ksit-system", Name: "argocd"}
+	flux := types.NamespacedName{Namespace: "ksit-system", Name: "flux"}
+	b := newFailureBackoff(&utils.RetryConfig{InitialDelay: 5 * time.Second, MaxDelay: 30 * time.Second, BackoffFactor: 2})
+
+	assert.Equal(t, 5*time.Second, b.failed(argocd, time.Minute))
+	assert.Equal(t, 10*time.Second, b.failed(argocd, time.Minute))
+	assert.Equal(t, 5*time.Second, b.failed(flux, time.Minute), "Integrations back off separately")
+	assert.Equal(t, 20*time.Second, b.failed(argocd, time.Minute))
+	assert.Equal(t, 30*time.Second, b.failed(argocd, time.Minute))
+
+	b.reset(argocd)
+	assert.Equal(t, 5*time.Second, b.failed(argocd, time.Minute))
+
+	assert.Nil(t, newFailureBackoff(nil))
+	var fixed *failureBackoff
+	assert.Equal(t, time.Minute, fixed.failed(argocd, time.Minute))
+}
@@ -0,0 +1,420 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/rollout"
+)
+
+// UpgradeCampaignReconciler rolls UpgradeCampaigns out wave by wave. Each cluster is
+// upgraded by running the integration's installer with the campaign version; once
+// every wave is done the new version is written back to the Integrations.
+type UpgradeCampaignReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Log              logr.Logger
+	ClusterManager   *cluster.ClusterManager
+	InstallerFactory *installer.InstallerFactory
+	Ledger           *ledger.Ledger
+	Recorder         record.EventRecorder
+	// Warmup is waited for before the first reconcile; nil does not wait
+	Warmup *ClusterWarmup
+}
+
+func (r *UpgradeCampaignReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("campaign", req.NamespacedName)
+
+	if err := r.Warmup.Wait(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	campaign := &ksitv1alpha1.UpgradeCampaign{}
+	if err := r.Get(ctx, req.NamespacedName, campaign); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if campaignFinished(campaign) {
+		return ctrl.Result{}, nil
+	}
+
+	before := campaign.DeepCopy()
+	result, err := r.reconcileCampaign(ctx, campaign)
+	if err != nil {
+		log.Error(err, "failed to reconcile upgrade campaign")
+	}
+
+	campaign.Status.ObservedGeneration = campaign.Generation
+	if !equality.Semantic.DeepEqual(before.Status, campaign.Status) {
+		if patchErr := r.Status().Patch(ctx, campaign, client.MergeFrom(before)); patchErr != nil {
+			return ctrl.Result{}, patchErr
+		}
+	}
+
+	return result, err
+}
+
+func (r *UpgradeCampaignReconciler) reconcileCampaign(ctx context.Context, campaign *ksitv1alpha1.UpgradeCampaign) (ctrl.Result, error) {
+	status := &campaign.Status
+
+	if campaign.Spec.Abort {
+		message := fmt.Sprintf("Aborted before wave %d of %d", status.CurrentWave+1, status.TotalWaves)
+		if status.Phase == ksitv1alpha1.CampaignPhasePromoting {
+			message = "Aborted before the Integrations were updated"
+		}
+		r.finish(campaign, ksitv1alpha1.CampaignPhaseAborted, message)
+		return ctrl.Result{}, nil
+	}
+
+	// Every wave has completed; only the Integrations are left to update
+	if status.Phase == ksitv1alpha1.CampaignPhasePromoting {
+		return r.promoteCampaign(ctx, campaign)
+	}
+
+	if status.Phase == "" {
+		if err := r.planCampaign(ctx, campaign); err != nil {
+			return ctrl.Result{}, err
+		}
+		if status.TotalWaves == 0 {
+			r.finish(campaign, ksitv1alpha1.CampaignPhaseSucceeded, "No Integrations matched the campaign")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if campaign.Spec.Paused {
+		status.Phase = ksitv1alpha1.CampaignPhasePaused
+		status.Message = fmt.Sprintf("Paused before wave %d of %d", status.CurrentWave+1, status.TotalWaves)
+		return ctrl.Result{}, nil
+	}
+
+	if wait := waveWait(campaign, time.Now()); wait > 0 {
+		status.Phase = ksitv1alpha1.CampaignPhaseProgressing
+		status.Message = fmt.Sprintf("Waiting %s before wave %d of %d", wait.Round(time.Second), status.CurrentWave+1, status.TotalWaves)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	status.Phase = ksitv1alpha1.CampaignPhaseProgressing
+	integrations := map[string]*ksitv1alpha1.Integration{}
+	for i := range status.Clusters {
+		entry := &status.Clusters[i]
+		if entry.Wave != status.CurrentWave || entry.State != ksitv1alpha1.CampaignClusterPending {
+			continue
+		}
+		r.upgradeCluster(ctx, campaign, entry, integrations)
+	}
+
+	countCampaignResults(status)
+	strategy := campaignStrategy(campaign)
+	if strategy.Halted(int(status.Failed)) {
+		r.finish(campaign, ksitv1alpha1.CampaignPhaseFailed,
+			fmt.Sprintf("%d clusters failed to upgrade, more than the %d allowed", status.Failed, strategy.MaxFailures))
+		return ctrl.Result{}, nil
+	}
+
+	now := metav1.Now()
+	status.LastWaveTime = &now
+	status.CurrentWave++
+	r.event(campaign, corev1.EventTypeNormal, "WaveCompleted",
+		fmt.Sprintf("Wave %d of %d completed", status.CurrentWave, status.TotalWaves))
+
+	if status.CurrentWave < status.TotalWaves {
+		status.Message = fmt.Sprintf("Completed wave %d of %d", status.CurrentWave, status.TotalWaves)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	status.Phase = ksitv1alpha1.CampaignPhasePromoting
+	return r.promoteCampaign(ctx, campaign)
+}
+
+// promoteCampaign updates the Integrations once every wave has completed. A failure
+// leaves the campaign Promoting, so the retry only updates the Integrations again.
+func (r *UpgradeCampaignReconciler) promoteCampaign(ctx context.Context, campaign *ksitv1alpha1.UpgradeCampaign) (ctrl.Result, error) {
+	status := &campaign.Status
+	if err := r.promote(ctx, campaign); err != nil {
+		status.Message = fmt.Sprintf("All waves completed but updating the Integrations failed: %v", err)
+		return ctrl.Result{}, err
+	}
+	r.finish(campaign, ksitv1alpha1.CampaignPhaseSucceeded,
+		fmt.Sprintf("Upgraded %d clusters (%d failed)", status.Succeeded, status.Failed))
+	return ctrl.Result{}, nil
+}
+
+// planCampaign selects the Integrations of the campaign's type and assigns their
+// target clusters to waves
+func (r *UpgradeCampaignReconciler) planCampaign(ctx context.Context, campaign *ksitv1alpha1.UpgradeCampaign) error {
+	selector := labels.Everything()
+	if campaign.Spec.Selector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(campaign.Spec.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := r.List(ctx, integrations,
+		client.InNamespace(campaign.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	var targets []rollout.Target
+	for _, integration := range integrations.Items {
+		if integration.Spec.Type != campaign.Spec.IntegrationType {
+			continue
+		}
+		// Only installs KSIT manages can be upgraded by it
+		if integration.Spec.AutoInstall == nil || !integration.Spec.AutoInstall.Enabled {
+			continue
+		}
+		for _, clusterName := range integration.Targets() {
+			targets = append(targets, rollout.Target{Integration: integration.Name, Cluster: clusterName})
+		}
+	}
+
+	waves := campaignStrategy(campaign).Plan(targets)
+	campaign.Status.Clusters = nil
+	for w, wave := range waves {
+		for _, target := range wave {
+			campaign.Status.Clusters = append(campaign.Status.Clusters, ksitv1alpha1.CampaignClusterStatus{
+				Integration: target.Integration,
+				Cluster:     target.Cluster,
+				Wave:        int32(w),
+				State:       ksitv1alpha1.CampaignClusterPending,
+			})
+		}
+	}
+	campaign.Status.TotalWaves = int32(len(waves))
+	campaign.Status.CurrentWave = 0
+	campaign.Status.Phase = ksitv1alpha1.CampaignPhasePending
+	campaign.Status.Message = fmt.Sprintf("Planned %d upgrades in %d waves", len(targets), len(waves))
+
+	return nil
+}
+
+// upgradeCluster runs the installer for one Integration on one cluster with the
+// campaign version and records the result in the campaign and the install ledger
+func (r *UpgradeCampaignReconciler) upgradeCluster(ctx context.Context, campaign *ksitv1alpha1.UpgradeCampaign, entry *ksitv1alpha1.CampaignClusterStatus, integrations map[string]*ksitv1alpha1.Integration) {
+	log := r.Log.WithValues("campaign", campaign.Name, "integration", entry.Integration, "cluster", entry.Cluster)
+
+	integration, ok := integrations[entry.Integration]
+	if !ok {
+		integration = &ksitv1alpha1.Integration{}
+		key := types.NamespacedName{Name: entry.Integration, Namespace: campaign.Namespace}
+		if err := r.Get(ctx, key, integration); err != nil {
+			if errors.IsNotFound(err) {
+				setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterSkipped, "Integration no longer exists")
+			} else {
+				setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, fmt.Sprintf("Failed to get Integration: %v", err))
+			}
+			return
+		}
+		integrations[entry.Integration] = integration
+	}
+
+	if !slices.Contains(integration.Targets(), entry.Cluster) {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterSkipped, "Cluster is no longer a target of the Integration")
+		return
+	}
+	if slices.Contains(integration.Spec.PausedClusters, entry.Cluster) {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterSkipped, "Cluster is paused in the Integration")
+		return
+	}
+
+	// The campaign version wins over any version pinned by a cluster override
+	resolved, err := resolveForCluster(ctx, r.Client, r.ClusterManager, integration, entry.Cluster)
+	if err != nil {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, err.Error())
+		return
+	}
+
+	upgraded, err := campaignUpgrade(resolved, campaign)
+	if err != nil {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, err.Error())
+		return
+	}
+
+	inst, err := r.InstallerFactory.InstallerFor(upgraded)
+	if err != nil || inst == nil {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, fmt.Sprintf("No installer for integration type %s", integration.Spec.Type))
+		return
+	}
+
+	config, err := r.ClusterManager.GetIntegrationConfig(entry.Cluster, integration)
+	if err != nil {
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, fmt.Sprintf("Cluster is not registered: %v", err))
+		return
+	}
+
+	log.Info("upgrading integration", "version", installVersion(upgraded))
+	installErr := inst.Install(ctx, config, upgraded)
+
+	if r.Ledger != nil {
+		ledgerEntry := ledger.Entry{
+			Action:     ksitv1alpha1.InstallActionUpgrade,
+			Method:     installMethod(upgraded),
+			Version:    installVersion(upgraded),
+			ValuesHash: ledger.ValuesHash(upgraded),
+			Err:        installErr,
+		}
+		if err := r.Ledger.Record(ctx, upgraded, entry.Cluster, ledgerEntry); err != nil {
+			log.Error(err, "failed to record upgrade in ledger")
+		}
+	}
+
+	if installErr != nil {
+		log.Error(installErr, "upgrade failed")
+		setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterFailed, fmt.Sprintf("Upgrade failed: %v", installErr))
+		return
+	}
+	setCampaignClusterState(entry, ksitv1alpha1.CampaignClusterSucceeded, "")
+}
+
+// promote writes the campaign version to every Integration upgraded on at least one
+// cluster, so clusters added later are installed with the new version
+func (r *UpgradeCampaignReconciler) promote(ctx context.Context, campaign *ksitv1alpha1.UpgradeCampaign) error {
+	promoted := map[string]bool{}
+	for _, entry := range campaign.Status.Clusters {
+		if entry.State != ksitv1alpha1.CampaignClusterSucceeded || promoted[entry.Integration] {
+			continue
+		}
+		promoted[entry.Integration] = true
+
+		integration := &ksitv1alpha1.Integration{}
+		key := types.NamespacedName{Name: entry.Integration, Namespace: campaign.Namespace}
+		if err := r.Get(ctx, key, integration); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		upgraded, err := campaignUpgrade(integration, campaign)
+		if err != nil {
+			return err
+		}
+		if err := r.Patch(ctx, upgraded, client.MergeFrom(integration)); err != nil {
+			return fmt.Errorf("failed to update integration %s: %w", integration.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *UpgradeCampaignReconciler) finish(campaign *ksitv1alpha1.UpgradeCampaign, phase, message string) {
+	campaign.Status.Phase = phase
+	campaign.Status.Message = message
+
+	eventType := corev1.EventTypeNormal
+	if phase != ksitv1alpha1.CampaignPhaseSucceeded {
+		eventType = corev1.EventTypeWarning
+	}
+	r.event(campaign, eventType, "Campaign"+phase, message)
+}
+
+func (r *UpgradeCampaignReconciler) event(campaign *ksitv1alpha1.UpgradeCampaign, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(campaign, eventType, reason, message)
+	}
+}
+
+func (r *UpgradeCampaignReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Status writes do not need another pass; waves are driven by explicit requeues
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ksitv1alpha1.UpgradeCampaign{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// campaignUpgrade returns a copy of the Integration with the campaign version applied
+func campaignUpgrade(integration *ksitv1alpha1.Integration, campaign *ksitv1alpha1.UpgradeCampaign) (*ksitv1alpha1.Integration, error) {
+	upgraded := integration.DeepCopy()
+	install := upgraded.Spec.AutoInstall
+	if install == nil {
+		return nil, fmt.Errorf("integration %s has no autoInstall configuration", integration.Name)
+	}
+
+	switch method := installMethod(upgraded); method {
+	case "helm":
+		if campaign.Spec.Version == "" {
+			return nil, fmt.Errorf("campaign sets no version for Helm-installed integration %s", integration.Name)
+		}
+		if install.HelmConfig == nil {
+			return nil, fmt.Errorf("integration %s has no autoInstall.helmConfig to set the version on", integration.Name)
+		}
+		install.HelmConfig.Version = campaign.Spec.Version
+	case "manifest":
+		if campaign.Spec.ManifestURL == "" {
+			return nil, fmt.Errorf("campaign sets no manifestUrl for manifest-installed integration %s", integration.Name)
+		}
+		install.ManifestURL = campaign.Spec.ManifestURL
+	default:
+		return nil, fmt.Errorf("install method %s of integration %s cannot be upgraded by a campaign", method, integration.Name)
+	}
+
+	return upgraded, nil
+}
+
+func campaignStrategy(campaign *ksitv1alpha1.UpgradeCampaign) rollout.Strategy {
+	return rollout.Strategy{
+		WaveSize:    int(campaign.Spec.Strategy.WaveSize),
+		MaxFailures: int(campaign.Spec.Strategy.MaxFailures),
+	}
+}
+
+// waveWait returns how long to wait before the next wave may start
+func waveWait(campaign *ksitv1alpha1.UpgradeCampaign, now time.Time) time.Duration {
+	interval := campaign.Spec.Strategy.WaveInterval
+	if interval == nil || campaign.Status.LastWaveTime == nil {
+		return 0
+	}
+	return campaign.Status.LastWaveTime.Add(interval.Duration).Sub(now)
+}
+
+func campaignFinished(campaign *ksitv1alpha1.UpgradeCampaign) bool {
+	switch campaign.Status.Phase {
+	case ksitv1alpha1.CampaignPhaseSucceeded, ksitv1alpha1.CampaignPhaseFailed, ksitv1alpha1.CampaignPhaseAborted:
+		return true
+	}
+	return false
+}
+
+func countCampaignResults(status *ksitv1alpha1.UpgradeCampaignStatus) {
+	status.Succeeded, status.Failed = 0, 0
+	for _, entry := range status.Clusters {
+		switch entry.State {
+		case ksitv1alpha1.CampaignClusterSucceeded:
+			status.Succeeded++
+		case ksitv1alpha1.CampaignClusterFailed:
+			status.Failed++
+		}
+	}
+}
+
+func setCampaignClusterState(entry *ksitv1alpha1.CampaignClusterStatus, state, message string) {
+	now := metav1.Now()
+	entry.State = state
+	entry.Message = message
+	entry.LastTransitionTime = &now
+}
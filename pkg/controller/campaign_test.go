@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+)
+
+// fakeInstaller records installs by API server host and fails for the hosts in failHosts
+type fakeInstaller struct {
+	installed map[string]string
+	failHosts map[string]bool
+}
+
+func (f *fakeInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	if f.failHosts[config.Host] {
+		return fmt.Errorf("helm upgrade failed")
+	}
+	f.installed[config.Host] = integration.Spec.AutoInstall.HelmConfig.Version
+	return nil
+}
+
+func (f *fakeInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	return nil
+}
+
+func (f *fakeInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	return true, nil
+}
+
+func testKubeconfig(server string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: %s
+contexts:
+- name: c
+  context:
+    cluster: c
+    user: u
+current-context: c
+users:
+- name: u
+  user:
+    token: t
+`, server)
+}
+
+func newCampaignReconciler(t *testing.T, inst installer.Installer, objs ...client.Object) *UpgradeCampaignReconciler {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&ksitv1alpha1.UpgradeCampaign{}).
+		Build()
+
+	clusterManager := cluster.NewClusterManager(c)
+	for _, name := range []string{"cluster-a", "cluster-b", "cluster-c"} {
+		require.NoError(t, clusterManager.AddCluster(name, "ksit-system", testKubeconfig("https://"+name+".example.com")))
+	}
+
+	factory := installer.NewInstallerFactory()
+	factory.Register(ksitv1alpha1.IntegrationTypeArgoCD, inst)
+
+	return &UpgradeCampaignReconciler{
+		Client:           c,
+		Scheme:           scheme,
+		Log:              logr.Discard(),
+		ClusterManager:   clusterManager,
+		InstallerFactory: factory,
+	}
+}
+
+func campaignIntegration() *ksitv1alpha1.Integration {
+	return &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster-a", "cluster-b", "cluster-c"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://argoproj.github.io/argo-helm",
+					Chart:       "argo-cd",
+					Version:     "5.51.6",
+					ReleaseName: "argocd",
+				},
+			},
+		},
+	}
+}
+
+func runCampaign(t *testing.T, r *UpgradeCampaignReconciler, key types.NamespacedName) *ksitv1alpha1.UpgradeCampaign {
+	for i := 0; i < 10; i++ {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		if !result.Requeue && result.RequeueAfter == 0 {
+			break
+		}
+	}
+
+	campaign := &ksitv1alpha1.UpgradeCampaign{}
+	require.NoError(t, r.Get(context.Background(), key, campaign))
+	return campaign
+}
+
+func TestUpgradeCampaignRollsOutInWaves(t *testing.T) {
+	inst := &fakeInstaller{installed: map[string]string{}}
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-6", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.UpgradeCampaignSpec{
+			IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD,
+			Version:         "6.0.0",
+			Strategy:        ksitv1alpha1.RolloutStrategy{WaveSize: 2},
+		},
+	}
+	r := newCampaignReconciler(t, inst, campaignIntegration(), campaign)
+	key := types.NamespacedName{Name: "argocd-6", Namespace: "ksit-system"}
+
+	campaign = runCampaign(t, r, key)
+	assert.Equal(t, ksitv1alpha1.CampaignPhaseSucceeded, campaign.Status.Phase)
+	assert.Equal(t, int32(2), campaign.Status.TotalWaves)
+	assert.Equal(t, int32(3), campaign.Status.Succeeded)
+	assert.Len(t, inst.installed, 3)
+	assert.Equal(t, "6.0.0", inst.installed["https://cluster-c.example.com"])
+
+	integration := &ksitv1alpha1.Integration{}
+	require.NoError(t, r.Get(context.Background(), types.NamespacedName{Name: "argocd", Namespace: "ksit-system"}, integration))
+	assert.Equal(t, "6.0.0", integration.Spec.AutoInstall.HelmConfig.Version)
+}
+
+func TestUpgradeCampaignHaltsOnFailures(t *testing.T) {
+	inst := &fakeInstaller{
+		installed: map[string]string{},
+		failHosts: map[string]bool{"https://cluster-a.example.com": true},
+	}
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-6", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.UpgradeCampaignSpec{
+			IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD,
+			Version:         "6.0.0",
+		},
+	}
+	r := newCampaignReconciler(t, inst, campaignIntegration(), campaign)
+
+	campaign = runCampaign(t, r, types.NamespacedName{Name: "argocd-6", Namespace: "ksit-system"})
+	assert.Equal(t, ksitv1alpha1.CampaignPhaseFailed, campaign.Status.Phase)
+	assert.Equal(t, int32(1), campaign.Status.Failed)
+	assert.Empty(t, inst.installed, "later waves must not run after the campaign failed")
+	assert.Equal(t, ksitv1alpha1.CampaignClusterFailed, campaign.Status.Clusters[0].State)
+	assert.Equal(t, ksitv1alpha1.CampaignClusterPending, campaign.Status.Clusters[1].State)
+}
+
+func TestUpgradeCampaignPauseAndAbort(t *testing.T) {
+	inst := &fakeInstaller{installed: map[string]string{}}
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-6", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.UpgradeCampaignSpec{
+			IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD,
+			Version:         "6.0.0",
+			Paused:          true,
+		},
+	}
+	r := newCampaignReconciler(t, inst, campaignIntegration(), campaign)
+	key := types.NamespacedName{Name: "argocd-6", Namespace: "ksit-system"}
+
+	campaign = runCampaign(t, r, key)
+	assert.Equal(t, ksitv1alpha1.CampaignPhasePaused, campaign.Status.Phase)
+	assert.Equal(t, int32(3), campaign.Status.TotalWaves)
+	assert.Empty(t, inst.installed)
+
+	campaign.Spec.Paused = false
+	campaign.Spec.Abort = true
+	require.NoError(t, r.Update(context.Background(), campaign))
+
+	campaign = runCampaign(t, r, key)
+	assert.Equal(t, ksitv1alpha1.CampaignPhaseAborted, campaign.Status.Phase)
+	assert.Empty(t, inst.installed)
+}
+
+func TestUpgradeCampaignRetriesOnlyPromotion(t *testing.T) {
+	ctx := context.Background()
+	inst := &fakeInstaller{installed: map[string]string{}}
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-6", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.UpgradeCampaignSpec{
+			IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD,
+			Version:         "6.0.0",
+			Strategy:        ksitv1alpha1.RolloutStrategy{WaveSize: 3},
+		},
+	}
+	r := newCampaignReconciler(t, inst, campaignIntegration(), campaign)
+	failPromotion := true
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*ksitv1alpha1.Integration); ok && failPromotion {
+				return fmt.Errorf("conflict")
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+	key := types.NamespacedName{Name: "argocd-6", Namespace: "ksit-system"}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.Error(t, err)
+	current := &ksitv1alpha1.UpgradeCampaign{}
+	require.NoError(t, r.Get(ctx, key, current))
+	assert.Equal(t, ksitv1alpha1.CampaignPhasePromoting, current.Status.Phase)
+	assert.Equal(t, int32(1), current.Status.CurrentWave)
+	assert.Equal(t, "All waves completed but updating the Integrations failed: failed to update integration argocd: conflict", current.Status.Message)
+
+	// The retry updates the Integration without running another wave
+	inst.installed = map[string]string{}
+	failPromotion = false
+	current = runCampaign(t, r, key)
+	assert.Equal(t, ksitv1alpha1.CampaignPhaseSucceeded, current.Status.Phase)
+	assert.Equal(t, int32(1), current.Status.CurrentWave)
+	assert.Empty(t, inst.installed)
+
+	integration := &ksitv1alpha1.Integration{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Name: "argocd", Namespace: "ksit-system"}, integration))
+	assert.Equal(t, "6.0.0", integration.Spec.AutoInstall.HelmConfig.Version)
+}
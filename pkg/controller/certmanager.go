@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/certmanager"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+const (
+	// defaultExpiryWindow is how close to expiry a Certificate is reported when
+	// config["expiryWindow"] is not set. cert-manager renews certificates a third of
+	// their lifetime before expiry, so healthy 90 day certificates never get this close.
+	defaultExpiryWindow = 14 * 24 * time.Hour
+
+	// maxExpiringCertificates bounds status.expiringCertificates
+	maxExpiringCertificates = 20
+)
+
+// certManagerDeployments are the cert-manager components the chart installs
+var certManagerDeployments = []string{
+	"cert-manager",
+	"cert-manager-webhook",
+	"cert-manager-cainjector",
+}
+
+// certManagerHandler also records the Certificates that are about to expire in status
+type certManagerHandler struct {
+	r *IntegrationReconciler
+}
+
+func (h *certManagerHandler) HealthCheck(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	_, err := h.r.checkCertManager(ctx, integration, clusterName)
+	return err
+}
+
+func (h *certManagerHandler) Reconcile(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	return h.r.reconcileCertManager(ctx, integration)
+}
+
+func (h *certManagerHandler) Cleanup(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	return nil
+}
+
+func (r *IntegrationReconciler) reconcileCertManager(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	expiringByCluster, err := collectClusters(ctx, r, integration, func(ctx context.Context, clusterName string) ([]ksitv1alpha1.ExpiringCertificate, error) {
+		return r.checkCertManager(ctx, integration, clusterName)
+	})
+
+	var expiring []ksitv1alpha1.ExpiringCertificate
+	for _, clusterExpiring := range expiringByCluster {
+		expiring = append(expiring, clusterExpiring...)
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].NotAfter.Before(&expiring[j].NotAfter)
+	})
+	total := len(expiring)
+	if total > maxExpiringCertificates {
+		expiring = expiring[:maxExpiringCertificates]
+	}
+
+	integration.Status.ExpiringCertificates = expiring
+	if err != nil {
+		return err
+	}
+
+	if total > 0 {
+		names := make([]string, 0, 3)
+		for _, cert := range expiring[:min(3, len(expiring))] {
+			names = append(names, fmt.Sprintf("%s/%s/%s", cert.Cluster, cert.Namespace, cert.Name))
+		}
+		message := fmt.Sprintf("%d Certificates expire within %s: %s", total, expiryWindow(integration), strings.Join(names, ", "))
+		if total > len(names) {
+			message += ", ..."
+		}
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypeCertificatesExpiring,
+			Status:  metav1.ConditionTrue,
+			Reason:  "CertificatesExpiring",
+			Message: message,
+		})
+	} else {
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypeCertificatesExpiring,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoCertificatesExpiring",
+			Message: fmt.Sprintf("No Certificate expires within %s", expiryWindow(integration)),
+		})
+	}
+
+	return nil
+}
+
+// checkCertManager is the cert-manager health check on one target cluster. It also
+// returns the Certificates on the cluster that expire within the expiry window.
+func (r *IntegrationReconciler) checkCertManager(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) ([]ksitv1alpha1.ExpiringCertificate, error) {
+	namespace := integration.Spec.Config["namespace"]
+	if namespace == "" {
+		namespace = "cert-manager"
+	}
+
+	r.Log.V(1).Info("checking cert-manager health on cluster", "cluster", clusterName)
+
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
+
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return nil, err
+	}
+
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return nil, err
+	}
+
+	// ✅ Health Check 2: controller, webhook and cainjector are available. Without the
+	// webhook the API server rejects every cert-manager resource, and without the
+	// cainjector the webhook's CA bundle goes stale, so all three are required.
+	err = runCheck(ctx, "deployments", func(ctx context.Context) error {
+		for _, deploymentName := range certManagerDeployments {
+			deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+			if err != nil {
+				r.logComponent(integration, clusterName, deploymentName, false, "reason", "not found")
+				return fmt.Errorf("cert-manager deployment %s not found on %s: %w", deploymentName, clusterName, err)
+			}
+			if deployment.Status.AvailableReplicas == 0 {
+				r.logComponent(integration, clusterName, deploymentName, false, "replicas", 0)
+				return fmt.Errorf("cert-manager deployment %s has 0 available replicas on %s", deploymentName, clusterName)
+			}
+			r.logComponent(integration, clusterName, deploymentName, true, "replicas", deployment.Status.AvailableReplicas)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Health Check 3: Certificates close to expiry
+	expiring := r.expiringCertificates(ctx, integration, clusterName)
+
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ cert-manager integration is healthy", "cluster", clusterName, "expiringCertificates", len(expiring))
+	return expiring, nil
+}
+
+// expiringCertificates lists the Certificates of a cluster that expire within the
+// expiry window. Listing errors are logged and skipped so a missing permission does
+// not fail the cert-manager health check itself.
+func (r *IntegrationReconciler) expiringCertificates(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) []ksitv1alpha1.ExpiringCertificate {
+	certManagerClient, err := r.clients().CertManager(ctx, integration, clusterName)
+	if err != nil {
+		r.Log.Error(err, "failed to create client for Certificates", "cluster", clusterName)
+		return nil
+	}
+
+	certs, err := certManagerClient.ListCertificates(ctx, "")
+	if err != nil {
+		r.Log.Error(err, "failed to list Certificates", "cluster", clusterName)
+		return nil
+	}
+
+	var expiring []ksitv1alpha1.ExpiringCertificate
+	for _, cert := range certmanager.Expiring(certs, time.Now(), expiryWindow(integration)) {
+		expiring = append(expiring, ksitv1alpha1.ExpiringCertificate{
+			Cluster:   clusterName,
+			Namespace: cert.Namespace,
+			Name:      cert.Name,
+			NotAfter:  metav1.NewTime(*cert.NotAfter),
+			Message:   cert.Message,
+		})
+	}
+	return expiring
+}
+
+// expiryWindow returns config["expiryWindow"], or defaultExpiryWindow when it is not a
+// positive duration
+func expiryWindow(integration *ksitv1alpha1.Integration) time.Duration {
+	if window, err := time.ParseDuration(integration.Spec.Config["expiryWindow"]); err == nil && window > 0 {
+		return window
+	}
+	return defaultExpiryWindow
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+type checkRecorderKey struct{}
+
+// checkRecorder collects the results of the health checks run on one cluster
+type checkRecorder struct {
+	results []ksitv1alpha1.HealthCheckResult
+}
+
+// withCheckRecorder returns a context whose runCheck calls are recorded by the returned recorder
+func withCheckRecorder(ctx context.Context) (context.Context, *checkRecorder) {
+	recorder := &checkRecorder{}
+	return context.WithValue(ctx, checkRecorderKey{}, recorder), recorder
+}
+
+type checkRetryKey struct{}
+
+// checkRetry is how runCheck retries the checks on one cluster
+type checkRetry struct {
+	config utils.RetryConfig
+	// onRetry is told about every retry of the named check
+	onRetry func(check string, attempt int, err error, delay time.Duration)
+}
+
+// withCheckRetry returns a context whose runCheck calls retry transient errors with config
+func withCheckRetry(ctx context.Context, config utils.RetryConfig, onRetry func(check string, attempt int, err error, delay time.Duration)) context.Context {
+	return context.WithValue(ctx, checkRetryKey{}, &checkRetry{config: config, onRetry: onRetry})
+}
+
+// runCheck runs a named health check and records its result in the cluster's
+// status.clusterStatuses entry. Calls to the cluster go through runCheck so that,
+// with a retry policy in ctx, transient errors are retried before the check fails.
+// The check's last error is returned.
+func runCheck(ctx context.Context, name string, check func(ctx context.Context) error) error {
+	start := time.Now()
+	var err error
+	if retry, ok := ctx.Value(checkRetryKey{}).(*checkRetry); ok {
+		config := retry.config
+		config.Retryable = utils.IsTransient
+		if retry.onRetry != nil {
+			config.OnRetry = func(attempt int, err error, delay time.Duration) {
+				retry.onRetry(name, attempt, err, delay)
+			}
+		}
+		err = utils.Do(ctx, &config, check)
+	} else {
+		err = check(ctx)
+	}
+
+	if recorder, ok := ctx.Value(checkRecorderKey{}).(*checkRecorder); ok {
+		result := ksitv1alpha1.HealthCheckResult{
+			Name:     name,
+			Passed:   err == nil,
+			Duration: metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)},
+		}
+		if err != nil {
+			result.Message = err.Error()
+		}
+		recorder.results = append(recorder.results, result)
+	}
+	return err
+}
+
+// toolNames are the names check messages use for the tool of each integration type
+var toolNames = map[string]string{
+	ksitv1alpha1.IntegrationTypeArgoCD:      "ArgoCD",
+	ksitv1alpha1.IntegrationTypeFlux:        "Flux",
+	ksitv1alpha1.IntegrationTypePrometheus:  "Prometheus",
+	ksitv1alpha1.IntegrationTypeIstio:       "Istio",
+	ksitv1alpha1.IntegrationTypeGrafana:     "Grafana",
+	ksitv1alpha1.IntegrationTypeCertManager: "cert-manager",
+	ksitv1alpha1.IntegrationTypeKyverno:     "Kyverno",
+}
+
+// toolName returns the name of the tool integration connects, or its type when it has none
+func toolName(integration *ksitv1alpha1.Integration) string {
+	if name, ok := toolNames[integration.Spec.Type]; ok {
+		return name
+	}
+	return integration.Spec.Type
+}
+
+// checkCRDs is the check, shared by all integration types, that the tool's CRDs are served
+func checkCRDs(ctx context.Context, clients *cluster.Clients, integration *ksitv1alpha1.Integration, clusterName string) error {
+	return runCheck(ctx, "crds", func(ctx context.Context) error {
+		dc, err := clients.DiscoveryFor(ctx)
+		if err != nil {
+			return err
+		}
+		if err := crds.EnsureForIntegration(dc, integration.Spec.Type); err != nil {
+			return fmt.Errorf("%s CRD check failed on %s: %w", toolName(integration), clusterName, err)
+		}
+		return nil
+	})
+}
+
+// checkNamespace is the check, shared by all integration types, that the tool's namespace exists
+func checkNamespace(ctx context.Context, clientset kubernetes.Interface, integration *ksitv1alpha1.Integration, namespace, clusterName string) error {
+	return runCheck(ctx, "namespace", func(ctx context.Context) error {
+		if _, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("%s namespace %s not found on %s: %w", toolName(integration), namespace, clusterName, err)
+		}
+		return nil
+	})
+}
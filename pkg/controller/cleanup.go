@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const (
+	// cleanupRetryInterval is the time between two cleanup attempts of a deletion
+	cleanupRetryInterval = 30 * time.Second
+
+	// defaultCleanupTimeout is how long cleanup is retried when spec.cleanup.timeout is not set
+	defaultCleanupTimeout = time.Hour
+)
+
+// forceCleanup reports whether a deletion should stop retrying cleanup, and why.
+// attempts is the number of failed attempts including the current one.
+func forceCleanup(integration *ksitv1alpha1.Integration, attempts int32, now time.Time) (string, bool) {
+	if integration.Annotations[ksitv1alpha1.ForceDeleteAnnotation] == "true" {
+		return fmt.Sprintf("%s annotation is set", ksitv1alpha1.ForceDeleteAnnotation), true
+	}
+
+	timeout := defaultCleanupTimeout
+	if policy := integration.Spec.Cleanup; policy != nil {
+		if policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts {
+			return fmt.Sprintf("cleanup failed %d times", attempts), true
+		}
+		if policy.Timeout != nil {
+			timeout = policy.Timeout.Duration
+		}
+	}
+
+	if deletion := integration.DeletionTimestamp; deletion != nil && now.Sub(deletion.Time) >= timeout {
+		return fmt.Sprintf("cleanup did not succeed within %s", timeout), true
+	}
+	return "", false
+}
+
+// cleanupRetryAfter returns how long to wait before the next cleanup attempt. A set
+// force-delete annotation skips the wait so it takes effect right away.
+func cleanupRetryAfter(integration *ksitv1alpha1.Integration, now time.Time) time.Duration {
+	status := integration.Status.Cleanup
+	if status == nil || status.LastAttemptTime == nil ||
+		integration.Annotations[ksitv1alpha1.ForceDeleteAnnotation] == "true" {
+		return 0
+	}
+	if wait := cleanupRetryInterval - now.Sub(status.LastAttemptTime.Time); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// finalizeIntegration cleans up a deleted Integration. It reports whether the finalizer
+// can be released; when it cannot, the result says when to try again.
+func (r *IntegrationReconciler) finalizeIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) (bool, ctrl.Result, error) {
+	now := time.Now()
+	if wait := cleanupRetryAfter(integration, now); wait > 0 {
+		return false, ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	var failures map[string]error
+	if uninstallOnDelete(integration) {
+		failures = r.uninstallIntegration(ctx, integration)
+	} else {
+		failures = r.cleanupIntegration(ctx, integration)
+	}
+	if len(failures) == 0 {
+		return true, ctrl.Result{}, nil
+	}
+
+	clusters := make([]string, 0, len(failures))
+	for clusterName := range failures {
+		clusters = append(clusters, clusterName)
+	}
+	sort.Strings(clusters)
+
+	attempts := int32(1)
+	if integration.Status.Cleanup != nil {
+		attempts += integration.Status.Cleanup.Attempts
+	}
+
+	if reason, force := forceCleanup(integration, attempts, now); force {
+		// The Integration is about to disappear, so the events are the record of what was left behind
+		for _, clusterName := range clusters {
+			r.Log.Info("releasing finalizer with resources left behind", "cluster", clusterName, "reason", reason, "error", failures[clusterName].Error())
+			r.event(integration, corev1.EventTypeWarning, "CleanupSkipped",
+				fmt.Sprintf("Left resources behind on cluster %s (%s): %v", clusterName, reason, failures[clusterName]))
+		}
+		return true, ctrl.Result{}, nil
+	}
+
+	integration.Status.Cleanup = &ksitv1alpha1.CleanupStatus{
+		Attempts:        attempts,
+		LastAttemptTime: &metav1.Time{Time: now},
+		FailedClusters:  clusters,
+	}
+	integration.Status.Message = fmt.Sprintf("Cleanup failed on %s; retrying", strings.Join(clusters, ", "))
+	if err := r.Status().Update(ctx, integration); err != nil {
+		return false, ctrl.Result{}, err
+	}
+	r.event(integration, corev1.EventTypeWarning, "CleanupFailed", integration.Status.Message)
+
+	return false, ctrl.Result{RequeueAfter: cleanupRetryInterval}, nil
+}
+
+// uninstallOnDelete reports whether deleting the Integration uninstalls the tool, as set by
+// autoInstall.uninstallOnDelete or, when that is unset, by onDisable: Uninstall
+func uninstallOnDelete(integration *ksitv1alpha1.Integration) bool {
+	if install := integration.Spec.AutoInstall; install != nil && install.UninstallOnDelete != nil {
+		return *install.UninstallOnDelete
+	}
+	return integration.Spec.OnDisable == ksitv1alpha1.DisablePolicyUninstall
+}
+
+// event records an event on the Integration when a recorder is configured
+func (r *IntegrationReconciler) event(integration *ksitv1alpha1.Integration, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(integration, eventType, reason, message)
+	}
+}
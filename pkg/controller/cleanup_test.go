@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestForceCleanup(t *testing.T) {
+	now := time.Now()
+	deleting := func(age time.Duration, policy *ksitv1alpha1.CleanupPolicy, annotations map[string]string) *ksitv1alpha1.Integration {
+		return &ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{
+				DeletionTimestamp: &metav1.Time{Time: now.Add(-age)},
+				Annotations:       annotations,
+			},
+			Spec: ksitv1alpha1.IntegrationSpec{Cleanup: policy},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		integration *ksitv1alpha1.Integration
+		attempts    int32
+		force       bool
+	}{
+		{name: "retries by default", integration: deleting(time.Minute, nil, nil), attempts: 100},
+		{name: "default timeout", integration: deleting(2*time.Hour, nil, nil), attempts: 1, force: true},
+		{name: "annotation", integration: deleting(0, nil, map[string]string{ksitv1alpha1.ForceDeleteAnnotation: "true"}), attempts: 1, force: true},
+		{name: "annotation not true", integration: deleting(0, nil, map[string]string{ksitv1alpha1.ForceDeleteAnnotation: "false"}), attempts: 1},
+		{name: "max attempts not reached", integration: deleting(0, &ksitv1alpha1.CleanupPolicy{MaxAttempts: 3}, nil), attempts: 2},
+		{name: "max attempts reached", integration: deleting(0, &ksitv1alpha1.CleanupPolicy{MaxAttempts: 3}, nil), attempts: 3, force: true},
+		{name: "custom timeout", integration: deleting(10*time.Minute, &ksitv1alpha1.CleanupPolicy{Timeout: &metav1.Duration{Duration: 5 * time.Minute}}, nil), attempts: 1, force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, force := forceCleanup(tt.integration, tt.attempts, now)
+			assert.Equal(t, tt.force, force)
+			assert.Equal(t, tt.force, reason != "")
+		})
+	}
+}
+
+func TestFinalizeIntegrationWithUnreachableCluster(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+
+	// cluster-b is not registered, so its Flux resources cannot be deleted
+	r, _ := newFluxTargets(t, "cluster-a")
+	integration := fluxIntegration()
+	integration.Finalizers = []string{integrationFinalizer}
+	integration.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(integration).WithStatusSubresource(integration).Build()
+
+	get := func() *ksitv1alpha1.Integration {
+		current := &ksitv1alpha1.Integration{}
+		require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(integration), current))
+		return current
+	}
+
+	done, result, err := r.finalizeIntegration(ctx, get())
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, cleanupRetryInterval, result.RequeueAfter)
+	current := get()
+	require.NotNil(t, current.Status.Cleanup)
+	assert.Equal(t, int32(1), current.Status.Cleanup.Attempts)
+	assert.Equal(t, []string{"cluster-b"}, current.Status.Cleanup.FailedClusters)
+	assert.Contains(t, <-recorder.Events, "CleanupFailed")
+
+	// A reconcile triggered by the status update does not count as another attempt
+	done, result, err = r.finalizeIntegration(ctx, current)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.Greater(t, result.RequeueAfter, time.Duration(0))
+	assert.Equal(t, int32(1), get().Status.Cleanup.Attempts)
+
+	current.Annotations = map[string]string{ksitv1alpha1.ForceDeleteAnnotation: "true"}
+	done, _, err = r.finalizeIntegration(ctx, current)
+	require.NoError(t, err)
+	assert.True(t, done)
+	event := <-recorder.Events
+	assert.Contains(t, event, "CleanupSkipped")
+	assert.Contains(t, event, "cluster cluster-b")
+}
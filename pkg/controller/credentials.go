@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// applyRepositoryCredentials reads the Secret named by the Integration's
+// helmConfig.credentialsSecretRef into the credentials of a copy of the Integration, so
+// that the installer can authenticate to the chart repository without access to the hub
+func applyRepositoryCredentials(ctx context.Context, c client.Reader, integration *ksitv1alpha1.Integration) (*ksitv1alpha1.Integration, error) {
+	install := integration.Spec.AutoInstall
+	if install == nil || install.HelmConfig == nil || install.HelmConfig.CredentialsSecretRef == "" {
+		return integration, nil
+	}
+	name := install.HelmConfig.CredentialsSecretRef
+	if c == nil {
+		return nil, fmt.Errorf("integration %s has a credentialsSecretRef but no client to read Secrets", integration.Name)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: integration.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get helm credentials secret %s: %w", name, err)
+	}
+	credentials, err := repositoryCredentials(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := integration.DeepCopy()
+	resolved.Spec.AutoInstall.HelmConfig.Credentials = credentials
+	return resolved, nil
+}
+
+// repositoryCredentials returns the username and password of a credentials Secret. A
+// token is sent as the password, with the username if the Secret has one.
+func repositoryCredentials(secret *corev1.Secret) (*ksitv1alpha1.RepositoryCredentials, error) {
+	username := string(secret.Data["username"])
+	if token := string(secret.Data["token"]); token != "" {
+		return &ksitv1alpha1.RepositoryCredentials{Username: username, Password: token}, nil
+	}
+	if password := string(secret.Data["password"]); username != "" && password != "" {
+		return &ksitv1alpha1.RepositoryCredentials{Username: username, Password: password}, nil
+	}
+	return nil, fmt.Errorf("helm credentials secret %s must hold username and password, or token", secret.Name)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestApplyRepositoryCredentials(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-token", Namespace: "team-a"},
+			Data:       map[string][]byte{"token": []byte("glpat-123")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo-basic", Namespace: "team-a"},
+			Data:       map[string][]byte{"username": []byte("ksit"), "password": []byte("s3cret")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo-incomplete", Namespace: "team-a"},
+			Data:       map[string][]byte{"username": []byte("ksit")},
+		},
+	).Build()
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "team-a"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled:    true,
+				Method:     "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{Chart: "argo-cd", CredentialsSecretRef: "registry-token"},
+			},
+		},
+	}
+
+	resolved, err := applyRepositoryCredentials(ctx, c, integration)
+	require.NoError(t, err)
+	assert.Equal(t, &ksitv1alpha1.RepositoryCredentials{Password: "glpat-123"}, resolved.Spec.AutoInstall.HelmConfig.Credentials)
+	assert.Nil(t, integration.Spec.AutoInstall.HelmConfig.Credentials, "the Integration itself is not changed")
+
+	integration.Spec.AutoInstall.HelmConfig.CredentialsSecretRef = "repo-basic"
+	resolved, err = applyRepositoryCredentials(ctx, c, integration)
+	require.NoError(t, err)
+	assert.Equal(t, &ksitv1alpha1.RepositoryCredentials{Username: "ksit", Password: "s3cret"}, resolved.Spec.AutoInstall.HelmConfig.Credentials)
+
+	integration.Spec.AutoInstall.HelmConfig.CredentialsSecretRef = "repo-incomplete"
+	_, err = applyRepositoryCredentials(ctx, c, integration)
+	assert.ErrorContains(t, err, "must hold username and password, or token")
+
+	integration.Spec.AutoInstall.HelmConfig.CredentialsSecretRef = "missing"
+	_, err = applyRepositoryCredentials(ctx, c, integration)
+	assert.ErrorContains(t, err, "failed to get helm credentials secret missing")
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/kubestellar"
+)
+
+// maxMissingObjects bounds the objects listed per WEC in status.delivery
+const maxMissingObjects = 10
+
+// reconcileDelivery reads the Binding of spec.kubeStellar.bindingPolicy from the WDS
+// and the WorkStatuses of its WECs from the ITS, and reports in status.delivery and the
+// Delivered condition which downsynced objects landed where. Like the WorkloadsApplied
+// condition, an incomplete delivery does not fail the Integration.
+func (r *IntegrationReconciler) reconcileDelivery(ctx context.Context, integration *ksitv1alpha1.Integration) {
+	if integration.Spec.KubeStellar == nil {
+		integration.Status.Delivery = nil
+		meta.RemoveStatusCondition(&integration.Status.Conditions, ksitv1alpha1.ConditionTypeDelivered)
+		return
+	}
+	if r.WDS == nil {
+		setDeliveredCondition(integration, metav1.ConditionUnknown, "KubeStellarNotConfigured",
+			"the controller has no KubeStellar WDS configured")
+		return
+	}
+	its := r.ITS
+	if its == nil {
+		its = r.WDS
+	}
+
+	binding, err := r.WDS.GetBinding(ctx, integration.Spec.KubeStellar.BindingPolicy)
+	if err != nil {
+		setDeliveredCondition(integration, metav1.ConditionUnknown, "BindingUnavailable", err.Error())
+		return
+	}
+
+	statuses := make(map[string][]kubestellar.WorkStatus, len(binding.Destinations))
+	failures := map[string]error{}
+	for _, cluster := range binding.Destinations {
+		if statuses[cluster], err = its.ListWorkStatuses(ctx, cluster); err != nil {
+			failures[cluster] = err
+		}
+	}
+
+	var incomplete []string
+	delivery := make([]ksitv1alpha1.DeliveryStatus, 0, len(binding.Destinations))
+	for _, d := range kubestellar.Delivery(binding, statuses) {
+		status := ksitv1alpha1.DeliveryStatus{
+			Cluster:   d.Cluster,
+			Expected:  int32(len(binding.Workload)),
+			Delivered: int32(len(d.Delivered)),
+		}
+		for i, ref := range d.Missing {
+			if i == maxMissingObjects {
+				break
+			}
+			status.Missing = append(status.Missing, ref.String())
+		}
+		if _, failed := failures[d.Cluster]; !failed && len(d.Missing) > 0 {
+			incomplete = append(incomplete, fmt.Sprintf("%s: %d/%d objects", d.Cluster, status.Delivered, status.Expected))
+		}
+		delivery = append(delivery, status)
+	}
+	integration.Status.Delivery = delivery
+
+	switch {
+	case len(failures) > 0:
+		setDeliveredCondition(integration, metav1.ConditionUnknown, "WorkStatusUnavailable", clusterFailures(failures))
+	case len(incomplete) > 0:
+		setDeliveredCondition(integration, metav1.ConditionFalse, "Pending", "not delivered yet to "+strings.Join(incomplete, "; "))
+	default:
+		setDeliveredCondition(integration, metav1.ConditionTrue, "Delivered",
+			fmt.Sprintf("Delivered %d objects to %d WECs", len(binding.Workload), len(binding.Destinations)))
+	}
+}
+
+func setDeliveredCondition(integration *ksitv1alpha1.Integration, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+		Type:    ksitv1alpha1.ConditionTypeDelivered,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
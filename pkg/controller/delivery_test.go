@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/kubestellar"
+)
+
+func TestReconcileDelivery(t *testing.T) {
+	ctx := context.Background()
+	agent := map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "monitoring", "name": "agent"}
+
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "Binding",
+		"metadata":   map[string]interface{}{"name": "edge-agents"},
+		"spec": map[string]interface{}{
+			"workload":     map[string]interface{}{"namespaceScope": []interface{}{agent}},
+			"destinations": []interface{}{map[string]interface{}{"clusterId": "edge-1"}, map[string]interface{}{"clusterId": "edge-2"}},
+		},
+	}}
+	workStatus := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "WorkStatus",
+		"metadata":   map[string]interface{}{"name": "appsv1-deployment-monitoring-agent", "namespace": "edge-1"},
+		"spec":       map[string]interface{}{"sourceRef": agent},
+	}}
+	wds := &kubestellar.KubeStellarClient{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(binding).Build()}
+	its := &kubestellar.KubeStellarClient{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(workStatus).Build()}
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:        ksitv1alpha1.IntegrationTypePrometheus,
+			KubeStellar: &ksitv1alpha1.KubeStellarSpec{BindingPolicy: "edge-agents"},
+		},
+	}
+
+	// Without a WDS the delivery is unknown
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	r.reconcileDelivery(ctx, integration)
+	condition := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeDelivered)
+	require.NotNil(t, condition)
+	assert.Equal(t, "KubeStellarNotConfigured", condition.Reason)
+
+	r.WDS, r.ITS = wds, its
+	r.reconcileDelivery(ctx, integration)
+	assert.Equal(t, []ksitv1alpha1.DeliveryStatus{
+		{Cluster: "edge-1", Expected: 1, Delivered: 1},
+		{Cluster: "edge-2", Expected: 1, Delivered: 0, Missing: []string{"deployments.apps/monitoring/agent"}},
+	}, integration.Status.Delivery)
+	condition = meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeDelivered)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "not delivered yet to edge-2: 0/1 objects", condition.Message)
+
+	edge2 := workStatus.DeepCopy()
+	edge2.SetNamespace("edge-2")
+	edge2.SetResourceVersion("")
+	require.NoError(t, its.Create(ctx, edge2))
+	r.reconcileDelivery(ctx, integration)
+	condition = meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeDelivered)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "Delivered 1 objects to 2 WECs", condition.Message)
+
+	// Unlinking the BindingPolicy clears the delivery status
+	integration.Spec.KubeStellar = nil
+	r.reconcileDelivery(ctx, integration)
+	assert.Nil(t, integration.Status.Delivery)
+	assert.Nil(t, meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeDelivered))
+}
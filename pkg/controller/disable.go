@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+// disableIntegration applies spec.onDisable to a disabled Integration and marks it
+// Disabled. Its status and health metrics are dropped, so a disabled Integration does
+// not look like one that is down. spec.workloads are always deleted. It returns the
+// clusters where uninstalling or deleting the workloads failed.
+func (r *IntegrationReconciler) disableIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) []string {
+	var failed []string
+	message := "Integration is disabled; installed components are left in place"
+	if integration.Spec.OnDisable == ksitv1alpha1.DisablePolicyUninstall {
+		failures := r.uninstallIntegration(ctx, integration)
+		for clusterName, err := range failures {
+			r.Log.Error(err, "failed to uninstall disabled integration", "integration", integration.Name, "cluster", clusterName)
+			failed = append(failed, clusterName)
+		}
+		sort.Strings(failed)
+
+		message = "Integration is disabled and uninstalled"
+		if len(failed) > 0 {
+			message = fmt.Sprintf("Integration is disabled; uninstall failed on %s, retrying", strings.Join(failed, ", "))
+		}
+	} else if failures := r.cleanupWorkloads(ctx, integration); len(failures) > 0 {
+		// spec.workloads are removed whatever onDisable says, since they are not the tool
+		for clusterName, err := range failures {
+			r.Log.Error(err, "failed to delete workloads of disabled integration", "integration", integration.Name, "cluster", clusterName)
+			failed = append(failed, clusterName)
+		}
+		sort.Strings(failed)
+		message = fmt.Sprintf("Integration is disabled; deleting workloads failed on %s, retrying", strings.Join(failed, ", "))
+	}
+
+	integration.Status.Phase = ksitv1alpha1.PhaseDisabled
+	integration.Status.Message = message
+	integration.Status.Health = nil
+	integration.Status.ClusterStatuses = nil
+	integration.Status.ClusterSummary = nil
+	meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+		Type:    ksitv1alpha1.ConditionTypeReady,
+		Status:  metav1.ConditionUnknown,
+		Reason:  ksitv1alpha1.ReasonDisabled,
+		Message: message,
+	})
+
+	prometheus.DeleteIntegrationStatus(integration.Name, integration.Spec.Type)
+	prometheus.DeleteHealthScore(integration.Name, integration.Spec.Type)
+	return failed
+}
+
+// uninstallIntegration removes the resources KSIT created for the Integration and the tool
+// from every cluster where KSIT installed it. Tools KSIT adopted are left alone.
+func (r *IntegrationReconciler) uninstallIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	failures := r.cleanupIntegration(ctx, integration)
+	if failures == nil {
+		failures = make(map[string]error)
+	}
+
+	install := integration.Spec.AutoInstall
+	if install == nil || !install.Enabled || r.InstallerFactory == nil {
+		return failures
+	}
+	inst, err := r.InstallerFactory.InstallerFor(integration)
+	if err != nil {
+		for _, clusterName := range targetClusters(ctx, integration) {
+			failures[clusterName] = fmt.Errorf("failed to get installer: %w", err)
+		}
+		return failures
+	}
+
+	for _, clusterName := range targetClusters(ctx, integration) {
+		if failures[clusterName] != nil {
+			continue
+		}
+
+		recorded, err := r.ledgerEntry(ctx, integration, clusterName)
+		if err != nil {
+			failures[clusterName] = err
+			continue
+		}
+		if recorded == nil || recorded.Status.Adopted ||
+			(recorded.Status.LastAction == ksitv1alpha1.InstallActionUninstall &&
+				recorded.Status.LastActionResult == ksitv1alpha1.InstallResultSucceeded) {
+			continue
+		}
+
+		config, err := r.ClusterManager.GetIntegrationConfig(clusterName, integration)
+		if err != nil {
+			failures[clusterName] = fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+			continue
+		}
+		rendered, err := resolveForCluster(ctx, r.Client, r.ClusterManager, integration, clusterName)
+		if err != nil {
+			failures[clusterName] = err
+			continue
+		}
+
+		uninstallErr := inst.Uninstall(ctx, config, rendered)
+		r.recordInstall(ctx, rendered, clusterName, ksitv1alpha1.InstallActionUninstall, uninstallErr)
+		if uninstallErr != nil {
+			failures[clusterName] = fmt.Errorf("failed to uninstall from cluster %s: %w", clusterName, uninstallErr)
+			continue
+		}
+		r.Log.Info("uninstalled integration", "integration", integration.Name, "cluster", clusterName)
+	}
+	return failures
+}
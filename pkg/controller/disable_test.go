@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestDisableIntegration(t *testing.T) {
+	r, targets := newFluxTargets(t, "cluster-a")
+	integration := fluxIntegration()
+	integration.Spec.TargetClusters = []string{"cluster-a"}
+	ctx := context.Background()
+
+	kustomizations := func() []unstructured.Unstructured {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "KustomizationList"})
+		require.NoError(t, targets["https://cluster-a.example.com"].List(ctx, list))
+		return list.Items
+	}
+	r.applyFluxResources(ctx, integration, "cluster-a", "flux-system")
+	require.Len(t, kustomizations(), 2)
+
+	// By default everything is left in place
+	assert.Empty(t, r.disableIntegration(ctx, integration))
+	assert.Equal(t, ksitv1alpha1.PhaseDisabled, integration.Status.Phase)
+	ready := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionUnknown, ready.Status)
+	assert.Equal(t, ksitv1alpha1.ReasonDisabled, ready.Reason)
+	assert.Len(t, kustomizations(), 2)
+
+	integration.Spec.OnDisable = ksitv1alpha1.DisablePolicyUninstall
+	assert.Empty(t, r.disableIntegration(ctx, integration))
+	assert.Equal(t, "Integration is disabled and uninstalled", integration.Status.Message)
+	assert.Empty(t, kustomizations())
+
+	// Clusters that cannot be cleaned up are retried
+	integration.Spec.TargetClusters = []string{"cluster-a", "cluster-b"}
+	assert.Equal(t, []string{"cluster-b"}, r.disableIntegration(ctx, integration))
+	assert.Contains(t, integration.Status.Message, "uninstall failed on cluster-b")
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+)
+
+// selfHealEnabled reports whether drifted installs of the integration are reinstalled
+func selfHealEnabled(integration *ksitv1alpha1.Integration) bool {
+	install := integration.Spec.AutoInstall
+	return install != nil && install.Enabled && install.SelfHeal
+}
+
+// installDrift describes how the install KSIT made on a cluster drifted from autoInstall:
+// first against the install recorded in the ledger, then against the cluster itself when
+// the installer can tell. It returns "" when nothing drifted or self-healing does not
+// apply: it is off, the install was adopted or is not recorded, or an upgrade campaign
+// is rolling out a new version of the integration.
+func (r *IntegrationReconciler) installDrift(ctx context.Context, inst installer.Installer, config *rest.Config, integration *ksitv1alpha1.Integration, recorded *ksitv1alpha1.InstalledComponent) (string, error) {
+	if !selfHealEnabled(integration) || recorded == nil || recorded.Status.Adopted {
+		return "", nil
+	}
+	campaign, err := r.runningCampaign(ctx, integration)
+	if err != nil || campaign != "" {
+		return "", err
+	}
+
+	if method := installMethod(integration); recorded.Spec.Method != "" && recorded.Spec.Method != method {
+		return fmt.Sprintf("installed with %s instead of %s", recorded.Spec.Method, method), nil
+	}
+	if version := installVersion(integration); version != "" && recorded.Spec.Version != version {
+		return fmt.Sprintf("version %q is installed instead of %q", recorded.Spec.Version, version), nil
+	}
+	if recorded.Spec.ValuesHash != ledger.ValuesHash(integration) {
+		return "install values changed", nil
+	}
+
+	detector, ok := inst.(installer.DriftDetector)
+	if !ok {
+		return "", nil
+	}
+	drift, err := detector.Drift(ctx, config, integration)
+	if err != nil {
+		return "", fmt.Errorf("failed to check install drift: %w", err)
+	}
+	return drift, nil
+}
+
+// runningCampaign returns the name of an unfinished UpgradeCampaign that plans to upgrade
+// the integration, or "" if there is none
+func (r *IntegrationReconciler) runningCampaign(ctx context.Context, integration *ksitv1alpha1.Integration) (string, error) {
+	campaigns := &ksitv1alpha1.UpgradeCampaignList{}
+	if err := r.List(ctx, campaigns, client.InNamespace(integration.Namespace)); err != nil {
+		return "", fmt.Errorf("failed to list upgrade campaigns: %w", err)
+	}
+	for i := range campaigns.Items {
+		campaign := &campaigns.Items[i]
+		if campaign.Spec.IntegrationType != integration.Spec.Type || campaignFinished(campaign) {
+			continue
+		}
+		for _, entry := range campaign.Status.Clusters {
+			if entry.Integration == integration.Name {
+				return campaign.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
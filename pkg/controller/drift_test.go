@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// driftingInstaller is a recordingInstaller that reports drift on the hosts in drifts
+// until they are installed again
+type driftingInstaller struct {
+	*recordingInstaller
+	drifts map[string]string
+}
+
+func (f *driftingInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	delete(f.drifts, config.Host)
+	return f.recordingInstaller.Install(ctx, config, integration)
+}
+
+func (f *driftingInstaller) Drift(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (string, error) {
+	return f.drifts[config.Host], nil
+}
+
+func TestHandleAutoInstallSelfHeals(t *testing.T) {
+	ctx := context.Background()
+	inst := &driftingInstaller{recordingInstaller: newRecordingInstaller(), drifts: map[string]string{}}
+	integration := autoInstallIntegration()
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+
+	// Drift is ignored until selfHeal is set
+	inst.drifts[hosts["cluster-a"]] = "Deployment argocd/argocd-server is missing"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 2)
+
+	integration.Spec.AutoInstall.SelfHeal = true
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Equal(t, []string{hosts["cluster-a"], hosts["cluster-b"], hosts["cluster-a"]}, inst.installs)
+	recorded, err := r.Ledger.Get(ctx, integration, "cluster-a")
+	require.NoError(t, err)
+	assert.Equal(t, ksitv1alpha1.InstallActionUpgrade, recorded.Status.LastAction)
+
+	// A version that differs from the recorded install upgrades every cluster
+	integration.Spec.AutoInstall.HelmConfig.Version = "5.52.0"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 5)
+	recorded, err = r.Ledger.Get(ctx, integration, "cluster-b")
+	require.NoError(t, err)
+	assert.Equal(t, "5.52.0", recorded.Spec.Version)
+
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 5, "healed installs are not reinstalled again")
+}
+
+func TestHandleAutoInstallSelfHealSkipsAdoptedAndCampaigns(t *testing.T) {
+	ctx := context.Background()
+	inst := &driftingInstaller{recordingInstaller: newRecordingInstaller(), drifts: map[string]string{}}
+	integration := autoInstallIntegration()
+	integration.Spec.AutoInstall.SelfHeal = true
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+	inst.installed[hosts["cluster-b"]] = true
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Equal(t, []string{hosts["cluster-a"]}, inst.installs)
+
+	// cluster-b was adopted, so its drift is left alone
+	inst.drifts[hosts["cluster-b"]] = "release argocd is failed"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 1)
+
+	// A campaign rolling out a new version owns the install until it finishes
+	campaign := &ksitv1alpha1.UpgradeCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-5-52", Namespace: integration.Namespace},
+		Spec:       ksitv1alpha1.UpgradeCampaignSpec{IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD, Version: "5.52.0"},
+		Status: ksitv1alpha1.UpgradeCampaignStatus{
+			Phase:    ksitv1alpha1.CampaignPhaseProgressing,
+			Clusters: []ksitv1alpha1.CampaignClusterStatus{{Integration: integration.Name, Cluster: "cluster-a"}},
+		},
+	}
+	require.NoError(t, r.Create(ctx, campaign))
+	inst.drifts[hosts["cluster-a"]] = "Deployment argocd/argocd-server is missing"
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Len(t, inst.installs, 1)
+
+	campaign.Status.Phase = ksitv1alpha1.CampaignPhaseSucceeded
+	require.NoError(t, r.Update(ctx, campaign))
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+	assert.Equal(t, []string{hosts["cluster-a"], hosts["cluster-a"]}, inst.installs)
+}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+const (
+	// defaultClusterTimeout bounds the checks on one cluster when spec.clusterTimeout is not set
+	defaultClusterTimeout = 30 * time.Second
+	// defaultFluxClusterTimeout is longer because Flux checks also apply spec.flux resources
+	defaultFluxClusterTimeout = time.Minute
+	// defaultMaxConcurrentClusters bounds the clusters checked at once when
+	// MaxConcurrentClusters is not set
+	defaultMaxConcurrentClusters = 10
+)
+
+// clusterTimeout returns how long the checks of an integration may take on one cluster
+func clusterTimeout(integration *ksitv1alpha1.Integration) time.Duration {
+	if timeout := integration.Spec.ClusterTimeout; timeout != nil && timeout.Duration > 0 {
+		return timeout.Duration
+	}
+	if integration.Spec.Type == ksitv1alpha1.IntegrationTypeFlux {
+		return defaultFluxClusterTimeout
+	}
+	return defaultClusterTimeout
+}
+
+// clusterErrors are the failures of a fan-out, in target cluster order
+type clusterErrors []error
+
+func (e clusterErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e clusterErrors) Unwrap() []error {
+	return e
+}
+
+// forEachCluster runs check on the target clusters of the integration, at most
+// MaxConcurrentClusters at a time, so check must be safe for concurrent use. Each check
+// gets a context bounded by the cluster timeout. Clusters whose circuit is open are
+// skipped and count as failed; a failing cluster does not stop the others. The outcome
+// on each cluster is recorded in status.clusterStatuses, in target cluster order,
+// followed by the last known status of the paused clusters.
+func (r *IntegrationReconciler) forEachCluster(ctx context.Context, integration *ksitv1alpha1.Integration, check func(ctx context.Context, clusterName string) error) error {
+	previous := make(map[string]ksitv1alpha1.ClusterStatus, len(integration.Status.ClusterStatuses))
+	for _, cs := range integration.Status.ClusterStatuses {
+		previous[cs.Name] = cs
+	}
+
+	clusters := targetClusters(ctx, integration)
+	statuses := make([]ksitv1alpha1.ClusterStatus, len(clusters))
+	results := make([]error, len(clusters))
+
+	retry := r.retryConfig()
+	r.runClusters(clusters, func(i int, clusterName string) {
+		statuses[i], results[i] = r.checkCluster(ctx, integration, clusterName, previous[clusterName], retry, check)
+	})
+	for _, clusterName := range integration.Status.PausedClusters {
+		status := previous[clusterName]
+		status.Name = clusterName
+		status.Paused = true
+		statuses = append(statuses, status)
+	}
+	integration.Status.ClusterStatuses = statuses
+	r.logHealthSummary(integration, statuses)
+
+	var errs clusterErrors
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// collectClusters runs check on the target clusters of the integration with
+// forEachCluster, and returns what it returned on each cluster in target cluster order.
+// Clusters that failed or were skipped get the zero value.
+func collectClusters[T any](ctx context.Context, r *IntegrationReconciler, integration *ksitv1alpha1.Integration, check func(ctx context.Context, clusterName string) (T, error)) ([]T, error) {
+	clusters := targetClusters(ctx, integration)
+	index := make(map[string]int, len(clusters))
+	for i, clusterName := range clusters {
+		index[clusterName] = i
+	}
+
+	var mu sync.Mutex
+	results := make([]T, len(clusters))
+	err := r.forEachCluster(ctx, integration, func(ctx context.Context, clusterName string) error {
+		result, err := check(ctx, clusterName)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		results[index[clusterName]] = result
+		mu.Unlock()
+		return nil
+	})
+	return results, err
+}
+
+// visitClusters runs fn on the target clusters of the integration, at most
+// MaxConcurrentClusters at a time and each bounded by the cluster timeout, and returns
+// its errors in target cluster order. Clusters whose circuit is open are skipped with an
+// error. Unlike forEachCluster it leaves status.clusterStatuses and the circuits alone,
+// for work done besides the checks, such as applying workloads or scoring health.
+func (r *IntegrationReconciler) visitClusters(ctx context.Context, integration *ksitv1alpha1.Integration, fn func(ctx context.Context, clusterName string) error) []error {
+	clusters := targetClusters(ctx, integration)
+	errs := make([]error, len(clusters))
+	timeout := clusterTimeout(integration)
+	r.runClusters(clusters, func(i int, clusterName string) {
+		if r.breaker != nil {
+			if allowed, until := r.breaker.Allow(circuitKey(integration, clusterName), time.Now()); !allowed {
+				errs[i] = fmt.Errorf("skipped %s after repeated failures until %s", clusterName, until.UTC().Format(time.RFC3339))
+				return
+			}
+		}
+		clusterCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		errs[i] = fn(clusterCtx, clusterName)
+	})
+	return errs
+}
+
+// observeClusters runs observe on the target clusters of the integration with
+// visitClusters, and returns what it returned on each cluster in target cluster order.
+// Clusters that failed or were skipped get the zero value and false.
+func observeClusters[T any](ctx context.Context, r *IntegrationReconciler, integration *ksitv1alpha1.Integration, observe func(ctx context.Context, clusterName string) (T, error)) ([]T, []bool) {
+	clusters := targetClusters(ctx, integration)
+	results := make([]T, len(clusters))
+	observed := make([]bool, len(clusters))
+	index := make(map[string]int, len(clusters))
+	for i, clusterName := range clusters {
+		index[clusterName] = i
+	}
+	errs := r.visitClusters(ctx, integration, func(ctx context.Context, clusterName string) error {
+		result, err := observe(ctx, clusterName)
+		if err == nil {
+			// Each cluster writes its own element
+			results[index[clusterName]] = result
+		}
+		return err
+	})
+	for i, err := range errs {
+		if err != nil {
+			r.Log.V(1).Info("failed to observe cluster", "integration", integration.Name, "cluster", clusters[i], "error", err.Error())
+			continue
+		}
+		observed[i] = true
+	}
+	return results, observed
+}
+
+// runClusters calls fn with the index and name of each cluster, at most
+// MaxConcurrentClusters at a time, and returns when every call has
+func (r *IntegrationReconciler) runClusters(clusters []string, fn func(i int, clusterName string)) {
+	workers := r.MaxConcurrentClusters
+	if workers <= 0 {
+		workers = defaultMaxConcurrentClusters
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, clusterName := range clusters {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, clusterName string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i, clusterName)
+		}(i, clusterName)
+	}
+	wg.Wait()
+}
+
+// retryConfig returns the retry policy of one fan-out, with a fresh budget shared by its
+// clusters, or nil when checks are not retried
+func (r *IntegrationReconciler) retryConfig() *utils.RetryConfig {
+	if r.Retry == nil {
+		return nil
+	}
+	config := *r.Retry
+	if r.RetryBudget > 0 {
+		config.Budget = utils.NewRetryBudget(r.RetryBudget)
+	}
+	return &config
+}
+
+// checkCluster runs check on one cluster, unless its circuit is open, and returns the
+// cluster's status along with the check's error. With a retry policy, transient errors
+// of the checks are retried within the cluster timeout.
+func (r *IntegrationReconciler) checkCluster(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, previous ksitv1alpha1.ClusterStatus, retry *utils.RetryConfig, check func(ctx context.Context, clusterName string) error) (ksitv1alpha1.ClusterStatus, error) {
+	key := circuitKey(integration, clusterName)
+	if r.breaker != nil {
+		if allowed, until := r.breaker.Allow(key, time.Now()); !allowed {
+			err := fmt.Errorf("skipped %s after repeated failures until %s", clusterName, until.UTC().Format(time.RFC3339))
+			return clusterStatus(previous, clusterName, err, false, nil), err
+		}
+	}
+
+	timeout := clusterTimeout(integration)
+	clusterCtx, cancel := context.WithTimeout(ctx, timeout)
+	clusterCtx, checks := withCheckRecorder(clusterCtx)
+	if retry != nil {
+		clusterCtx = withCheckRetry(clusterCtx, *retry, func(check string, attempt int, err error, delay time.Duration) {
+			prometheus.RecordCheckRetry(integration.Name, clusterName, check)
+			r.Log.V(1).Info("retrying check", "integration", integration.Name, "cluster", clusterName, "check", check, "attempt", attempt, "delay", delay, "error", err.Error())
+		})
+	}
+	err := check(clusterCtx, clusterName)
+	timedOut := errors.Is(clusterCtx.Err(), context.DeadlineExceeded)
+	cancel()
+
+	if timedOut {
+		prometheus.RecordClusterTimeout(integration.Name, clusterName)
+		if err == nil {
+			err = fmt.Errorf("checks on %s did not finish within %s", clusterName, timeout)
+		}
+	}
+	status := clusterStatus(previous, clusterName, err, !timedOut && clusterAnswered(err), checks.results)
+	r.logClusterHealth(integration, clusterName, err)
+
+	if r.breaker != nil {
+		if err == nil {
+			r.breaker.Success(key)
+			prometheus.SetCircuitOpen(integration.Name, clusterName, false)
+		} else if r.breaker.Failure(key, time.Now()) {
+			prometheus.SetCircuitOpen(integration.Name, clusterName, true)
+			r.Log.Info("opened circuit for cluster", "integration", integration.Name, "cluster", clusterName, "cooldown", r.breaker.Cooldown)
+		}
+	}
+	return status, err
+}
+
+// clusterStatus records the outcome of the checks on a cluster. LastSeen only moves when
+// the cluster answered, so for an unreachable cluster it tells how long it has been gone.
+func clusterStatus(previous ksitv1alpha1.ClusterStatus, clusterName string, err error, answered bool, checks []ksitv1alpha1.HealthCheckResult) ksitv1alpha1.ClusterStatus {
+	status := ksitv1alpha1.ClusterStatus{
+		Name:      clusterName,
+		Connected: err == nil,
+		LastSeen:  previous.LastSeen,
+		Checks:    checks,
+	}
+	if err != nil {
+		status.Message = err.Error()
+	}
+	if answered {
+		status.LastSeen = metav1.Now()
+	}
+	return status
+}
+
+// clusterAnswered reports whether a check error came from the cluster, such as a missing
+// deployment, rather than from failing to reach it
+func clusterAnswered(err error) bool {
+	if err == nil {
+		return true
+	}
+	var netErr net.Error
+	return !errors.Is(err, cluster.ErrClusterNotRegistered) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.As(err, &netErr)
+}
+
+// circuitKey identifies the circuit of an integration on a cluster
+func circuitKey(integration *ksitv1alpha1.Integration, clusterName string) string {
+	return integration.Namespace + "/" + integration.Name + "/" + clusterName
+}
@@ -0,0 +1,360 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func TestForEachCluster(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard(), breaker: cluster.NewCircuitBreaker()}
+	r.breaker.Threshold = 2
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"hung", "healthy"},
+			ClusterTimeout: &metav1.Duration{Duration: 10 * time.Millisecond},
+		},
+	}
+
+	var mu sync.Mutex
+	var checked []string
+	check := func(ctx context.Context, clusterName string) error {
+		mu.Lock()
+		checked = append(checked, clusterName)
+		mu.Unlock()
+		if clusterName == "hung" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	// The hung cluster times out without holding up the healthy one
+	err := r.forEachCluster(context.Background(), integration, check)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.ElementsMatch(t, []string{"hung", "healthy"}, checked)
+
+	// After the second failure the hung cluster is skipped
+	assert.Error(t, r.forEachCluster(context.Background(), integration, check))
+	checked = nil
+	err = r.forEachCluster(context.Background(), integration, check)
+	assert.ErrorContains(t, err, "skipped hung after repeated failures")
+	assert.Equal(t, []string{"healthy"}, checked)
+}
+
+func TestObserveClusters(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard(), breaker: cluster.NewCircuitBreaker()}
+	r.breaker.Threshold = 1
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"hung", "healthy", "broken"},
+			ClusterTimeout: &metav1.Duration{Duration: 10 * time.Millisecond},
+		},
+		Status: ksitv1alpha1.IntegrationStatus{ClusterStatuses: []ksitv1alpha1.ClusterStatus{{Name: "hung", Connected: true}}},
+	}
+	r.breaker.Failure(circuitKey(integration, "broken"), time.Now())
+
+	var mu sync.Mutex
+	var observed []string
+	results, ok := observeClusters(context.Background(), r, integration, func(ctx context.Context, clusterName string) (string, error) {
+		mu.Lock()
+		observed = append(observed, clusterName)
+		mu.Unlock()
+		if clusterName == "hung" {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "ok", nil
+	})
+
+	// The hung cluster times out and the open circuit is skipped, without touching the
+	// status or the circuits
+	assert.Equal(t, []string{"", "ok", ""}, results)
+	assert.Equal(t, []bool{false, true, false}, ok)
+	assert.ElementsMatch(t, []string{"hung", "healthy"}, observed)
+	assert.Equal(t, []ksitv1alpha1.ClusterStatus{{Name: "hung", Connected: true}}, integration.Status.ClusterStatuses)
+	allowed, _ := r.breaker.Allow(circuitKey(integration, "hung"), time.Now())
+	assert.True(t, allowed)
+}
+
+func TestForEachClusterBoundsConcurrency(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard(), MaxConcurrentClusters: 2}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"},
+		},
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	err := r.forEachCluster(context.Background(), integration, func(ctx context.Context, clusterName string) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, maxInFlight)
+
+	// Statuses keep the order of spec.targetClusters
+	statuses := integration.Status.ClusterStatuses
+	assert.Len(t, statuses, 5)
+	for i, name := range integration.Spec.TargetClusters {
+		assert.Equal(t, name, statuses[i].Name)
+	}
+}
+
+func TestCollectClusters(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard(), breaker: cluster.NewCircuitBreaker()}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mesh", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeIstio,
+			TargetClusters: []string{"slow", "broken", "fast"},
+		},
+	}
+
+	results, err := collectClusters(context.Background(), r, integration, func(ctx context.Context, clusterName string) (string, error) {
+		switch clusterName {
+		case "slow":
+			time.Sleep(20 * time.Millisecond)
+		case "broken":
+			return "ignored", errors.New("unreachable")
+		}
+		return "checked " + clusterName, nil
+	})
+	assert.ErrorContains(t, err, "unreachable")
+	// Results follow the target clusters, whatever order the checks finish in
+	assert.Equal(t, []string{"checked slow", "", "checked fast"}, results)
+}
+
+func TestClusterTimeout(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{Spec: ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD}}
+	assert.Equal(t, defaultClusterTimeout, clusterTimeout(integration))
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeFlux
+	assert.Equal(t, defaultFluxClusterTimeout, clusterTimeout(integration))
+
+	integration.Spec.ClusterTimeout = &metav1.Duration{Duration: 5 * time.Second}
+	assert.Equal(t, 5*time.Second, clusterTimeout(integration))
+}
+
+func TestReconcileInterval(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{}
+	r := &IntegrationReconciler{}
+	assert.Equal(t, requeueInterval, r.reconcileInterval(integration))
+
+	r.Interval = 2 * time.Minute
+	assert.Equal(t, 2*time.Minute, r.reconcileInterval(integration))
+
+	integration.Spec.ReconcileInterval = &metav1.Duration{Duration: 15 * time.Second}
+	assert.Equal(t, 15*time.Second, r.reconcileInterval(integration))
+}
+
+func TestForEachClusterRecordsClusterStatuses(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	lastSeen := metav1.NewTime(time.Now().Add(-time.Hour))
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"unregistered", "unhealthy", "healthy"},
+		},
+		Status: ksitv1alpha1.IntegrationStatus{
+			ClusterStatuses: []ksitv1alpha1.ClusterStatus{
+				{Name: "unregistered", Connected: true, LastSeen: lastSeen},
+				{Name: "removed", Connected: true, LastSeen: lastSeen},
+			},
+		},
+	}
+
+	err := r.forEachCluster(context.Background(), integration, func(ctx context.Context, clusterName string) error {
+		switch clusterName {
+		case "unregistered":
+			return fmt.Errorf("failed to get cluster config for %s: %w", clusterName, cluster.ErrClusterNotRegistered)
+		case "unhealthy":
+			return fmt.Errorf("ArgoCD server has 0 available replicas on %s", clusterName)
+		}
+		return nil
+	})
+	assert.Error(t, err)
+
+	statuses := integration.Status.ClusterStatuses
+	assert.Len(t, statuses, 3)
+
+	// An unreachable cluster keeps the time it was last seen
+	assert.Equal(t, "unregistered", statuses[0].Name)
+	assert.False(t, statuses[0].Connected)
+	assert.Contains(t, statuses[0].Message, "cluster is not registered")
+	assert.True(t, statuses[0].LastSeen.Equal(&lastSeen))
+
+	// A cluster that answers but fails its checks was seen now
+	assert.Equal(t, "unhealthy", statuses[1].Name)
+	assert.False(t, statuses[1].Connected)
+	assert.Equal(t, "ArgoCD server has 0 available replicas on unhealthy", statuses[1].Message)
+	assert.True(t, statuses[1].LastSeen.After(lastSeen.Time))
+
+	assert.Equal(t, "healthy", statuses[2].Name)
+	assert.True(t, statuses[2].Connected)
+	assert.Empty(t, statuses[2].Message)
+	assert.False(t, statuses[2].LastSeen.IsZero())
+}
+
+func TestForEachClusterRecordsChecks(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster1", "cluster2"},
+		},
+	}
+
+	err := r.forEachCluster(context.Background(), integration, func(ctx context.Context, clusterName string) error {
+		if err := runCheck(ctx, "namespace", func(ctx context.Context) error { return nil }); err != nil {
+			return err
+		}
+		return runCheck(ctx, "server-deployment", func(ctx context.Context) error {
+			if clusterName == "cluster2" {
+				return fmt.Errorf("ArgoCD server has 0 available replicas on %s", clusterName)
+			}
+			return nil
+		})
+	})
+	assert.Error(t, err)
+
+	statuses := integration.Status.ClusterStatuses
+	assert.Len(t, statuses, 2)
+	assert.Len(t, statuses[0].Checks, 2)
+	assert.True(t, statuses[0].Checks[1].Passed)
+
+	// Each cluster gets its own checks, and the failed one carries its error
+	checks := statuses[1].Checks
+	assert.Len(t, checks, 2)
+	assert.Equal(t, "namespace", checks[0].Name)
+	assert.True(t, checks[0].Passed)
+	assert.Equal(t, "server-deployment", checks[1].Name)
+	assert.False(t, checks[1].Passed)
+	assert.Equal(t, "ArgoCD server has 0 available replicas on cluster2", checks[1].Message)
+}
+
+func TestForEachClusterRetriesTransientErrors(t *testing.T) {
+	r := &IntegrationReconciler{
+		Log:         logr.Discard(),
+		Retry:       &utils.RetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond, BackoffFactor: 1},
+		RetryBudget: 2,
+	}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"flaky", "missing", "down"},
+		},
+	}
+	// Checked one at a time, flaky and down share the budget of 2 retries in order
+	r.MaxConcurrentClusters = 1
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	err := r.forEachCluster(context.Background(), integration, func(ctx context.Context, clusterName string) error {
+		return runCheck(ctx, "server-deployment", func(ctx context.Context) error {
+			mu.Lock()
+			attempts[clusterName]++
+			n := attempts[clusterName]
+			mu.Unlock()
+			switch {
+			case clusterName == "flaky" && n == 1:
+				return fmt.Errorf("failed to get deployment: %w", apierrors.NewServiceUnavailable("etcd leader changed"))
+			case clusterName == "missing":
+				return fmt.Errorf("failed to get deployment: %w", apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "argocd-server"))
+			case clusterName == "down":
+				return fmt.Errorf("failed to get deployment: %w", apierrors.NewTooManyRequests("slow down", 1))
+			}
+			return nil
+		})
+	})
+
+	// A transient error is retried and the check passes
+	assert.Equal(t, 2, attempts["flaky"])
+	statuses := integration.Status.ClusterStatuses
+	assert.True(t, statuses[0].Connected)
+	assert.True(t, statuses[0].Checks[0].Passed)
+
+	// An error from the cluster is not retried
+	assert.Equal(t, 1, attempts["missing"])
+	assert.False(t, statuses[1].Connected)
+
+	// Once the shared budget is spent, transient errors fail too
+	assert.Equal(t, 2, attempts["down"])
+	assert.False(t, statuses[2].Connected)
+	assert.ErrorContains(t, err, "slow down")
+}
+
+func TestForEachClusterSkipsPausedClusters(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster1", "cluster2", "cluster3"},
+			PausedClusters: []string{"cluster2", "not-a-target"},
+		},
+		Status: ksitv1alpha1.IntegrationStatus{
+			ClusterStatuses: []ksitv1alpha1.ClusterStatus{{Name: "cluster2", Message: "maintenance started"}},
+		},
+	}
+
+	active, paused := pauseClusters(integration, integration.Spec.TargetClusters)
+	integration.Status.PausedClusters = paused
+	assert.Equal(t, []string{"cluster2"}, paused)
+	assert.Equal(t, []string{"cluster1", "cluster3"}, active)
+	assert.Equal(t, []string{"cluster1", "cluster2", "cluster3"}, integration.Spec.TargetClusters, "the spec is left alone")
+
+	// A reload of the object from the API server does not bring the paused cluster back
+	ctx := withTargets(context.Background(), integration, active)
+	integration = integration.DeepCopy()
+
+	var mu sync.Mutex
+	var checked []string
+	err := r.forEachCluster(ctx, integration, func(ctx context.Context, clusterName string) error {
+		mu.Lock()
+		checked = append(checked, clusterName)
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cluster1", "cluster3"}, checked)
+
+	statuses := integration.Status.ClusterStatuses
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, ksitv1alpha1.ClusterStatus{Name: "cluster2", Message: "maintenance started", Paused: true}, statuses[2])
+	assert.False(t, statuses[0].Paused)
+}
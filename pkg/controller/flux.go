@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
+	"github.com/kubestellar/integration-toolkit/pkg/template"
+)
+
+// Config keys that bootstrap Flux on each target cluster from a Git repository
+const (
+	fluxGitURLKey       = "gitURL"
+	fluxBranchKey       = "branch"
+	fluxPathKey         = "path"
+	fluxIntervalKey     = "interval"
+	fluxGitSecretRefKey = "gitSecretRef"
+	fluxPruneKey        = "prune"
+)
+
+// fluxBootstrap returns the GitRepository of config.gitURL and the root Kustomization
+// applying config.path from it, as flux bootstrap creates them. Both are named by
+// flux.BootstrapName. The Kustomization only prunes when config.prune is "true". It
+// returns nil when gitURL is not set.
+func fluxBootstrap(integration *ksitv1alpha1.Integration, namespace string, labels map[string]string) (*flux.GitRepository, *flux.Kustomization) {
+	config := integration.Spec.Config
+	if config[fluxGitURLKey] == "" {
+		return nil, nil
+	}
+
+	repo := &flux.GitRepository{
+		Name:      flux.BootstrapName(integration.Namespace, integration.Name),
+		Namespace: namespace,
+		URL:       config[fluxGitURLKey],
+		Branch:    config[fluxBranchKey],
+		Interval:  config[fluxIntervalKey],
+		SecretRef: config[fluxGitSecretRefKey],
+		Labels:    labels,
+	}
+	if repo.Branch == "" {
+		repo.Branch = "main"
+	}
+	if repo.Interval == "" {
+		repo.Interval = "1m"
+	}
+
+	ks := &flux.Kustomization{
+		Name:      repo.Name,
+		Namespace: namespace,
+		SourceRef: repo.Name,
+		Path:      config[fluxPathKey],
+		Interval:  config[fluxIntervalKey],
+		Prune:     config[fluxPruneKey] == "true",
+		Labels:    labels,
+	}
+	if ks.Path == "" {
+		ks.Path = "./"
+	}
+	if ks.Interval == "" {
+		ks.Interval = "10m"
+	}
+	return repo, ks
+}
+
+// fluxResources converts the bootstrap config and spec.flux into the resources created
+// on each target cluster, defaulting namespaces to the Integration's Flux namespace
+func fluxResources(integration *ksitv1alpha1.Integration, namespace string) ([]*flux.GitRepository, []*flux.Kustomization) {
+	labels := map[string]string{ksitv1alpha1.LabelIntegration: integration.Name}
+
+	var repos []*flux.GitRepository
+	var kustomizations []*flux.Kustomization
+	if repo, ks := fluxBootstrap(integration, namespace, labels); repo != nil {
+		repos = append(repos, repo)
+		kustomizations = append(kustomizations, ks)
+	}
+
+	spec := integration.Spec.Flux
+	if spec == nil {
+		return repos, kustomizations
+	}
+
+	for _, repo := range spec.GitRepositories {
+		r := &flux.GitRepository{
+			Name:      repo.Name,
+			Namespace: repo.Namespace,
+			URL:       repo.URL,
+			Branch:    repo.Branch,
+			Interval:  repo.Interval,
+			SecretRef: repo.SecretRef,
+			Labels:    labels,
+		}
+		if r.Namespace == "" {
+			r.Namespace = namespace
+		}
+		if r.Branch == "" {
+			r.Branch = "main"
+		}
+		if r.Interval == "" {
+			r.Interval = "1m"
+		}
+		repos = append(repos, r)
+	}
+
+	for _, ks := range spec.Kustomizations {
+		k := &flux.Kustomization{
+			Name:            ks.Name,
+			Namespace:       ks.Namespace,
+			SourceRef:       ks.SourceRef,
+			Path:            ks.Path,
+			Interval:        ks.Interval,
+			Prune:           ks.Prune,
+			TargetNamespace: ks.TargetNamespace,
+			DependsOn:       ks.DependsOn,
+			Labels:          labels,
+		}
+		if k.Namespace == "" {
+			k.Namespace = namespace
+		}
+		if k.Interval == "" {
+			k.Interval = "10m"
+		}
+		kustomizations = append(kustomizations, k)
+	}
+
+	return repos, kustomizations
+}
+
+// applyFluxResources creates or updates the bootstrap resources and those declared in
+// spec.flux on a target cluster and reports the readiness of each. The bootstrap config
+// is rendered for the cluster first, so that e.g. path can be ./clusters/{{ .Cluster.Name }}.
+// A resource that cannot be applied is reported as not ready rather than failing the
+// Flux health check.
+func (r *IntegrationReconciler) applyFluxResources(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName, namespace string) []ksitv1alpha1.FluxResourceStatus {
+	if integration.Spec.Config[fluxGitURLKey] != "" {
+		var labels map[string]string
+		if registered, err := r.ClusterManager.GetIntegrationCluster(clusterName, integration); err == nil {
+			labels = registered.Labels
+		}
+		rendered, err := template.RenderIntegration(integration, template.NewData(integration, clusterName, labels))
+		if err != nil {
+			r.Log.Error(err, "failed to render Flux bootstrap config", "cluster", clusterName)
+			return []ksitv1alpha1.FluxResourceStatus{fluxResourceStatus(clusterName, "GitRepository", flux.BootstrapName(integration.Namespace, integration.Name), namespace, nil, err)}
+		}
+		integration = rendered
+	}
+
+	repos, kustomizations := fluxResources(integration, namespace)
+	if len(repos) == 0 && len(kustomizations) == 0 {
+		return nil
+	}
+
+	statuses := make([]ksitv1alpha1.FluxResourceStatus, 0, len(repos)+len(kustomizations))
+	fluxClient, err := r.clients().Flux(ctx, integration, clusterName)
+	if err != nil {
+		r.Log.Error(err, "failed to create Flux client", "cluster", clusterName)
+		for _, repo := range repos {
+			statuses = append(statuses, fluxResourceStatus(clusterName, "GitRepository", repo.Name, repo.Namespace, nil, err))
+		}
+		for _, ks := range kustomizations {
+			statuses = append(statuses, fluxResourceStatus(clusterName, "Kustomization", ks.Name, ks.Namespace, nil, err))
+		}
+		return statuses
+	}
+
+	for _, repo := range repos {
+		if err := fluxClient.ApplyGitRepository(ctx, repo); err != nil {
+			r.Log.Error(err, "failed to apply GitRepository", "cluster", clusterName, "name", repo.Name)
+			statuses = append(statuses, fluxResourceStatus(clusterName, "GitRepository", repo.Name, repo.Namespace, nil, err))
+			continue
+		}
+		status, err := fluxClient.GetGitRepositoryStatus(ctx, repo.Name, repo.Namespace)
+		statuses = append(statuses, fluxResourceStatus(clusterName, "GitRepository", repo.Name, repo.Namespace, status, err))
+	}
+
+	for _, ks := range kustomizations {
+		if err := fluxClient.ApplyKustomization(ctx, ks); err != nil {
+			r.Log.Error(err, "failed to apply Kustomization", "cluster", clusterName, "name", ks.Name)
+			statuses = append(statuses, fluxResourceStatus(clusterName, "Kustomization", ks.Name, ks.Namespace, nil, err))
+			continue
+		}
+		status, err := fluxClient.GetKustomizationStatus(ctx, ks.Name, ks.Namespace)
+		statuses = append(statuses, fluxResourceStatus(clusterName, "Kustomization", ks.Name, ks.Namespace, status, err))
+	}
+
+	return statuses
+}
+
+func fluxResourceStatus(clusterName, kind, name, namespace string, status *flux.SyncStatus, err error) ksitv1alpha1.FluxResourceStatus {
+	out := ksitv1alpha1.FluxResourceStatus{
+		Cluster:   clusterName,
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+	}
+
+	switch {
+	case err != nil:
+		out.Message = err.Error()
+	case status != nil:
+		out.Ready = status.Ready
+		out.Message = "Waiting for Flux to reconcile"
+		for _, cond := range status.Conditions {
+			if cond.Type == "Ready" {
+				out.Message = cond.Message
+			}
+		}
+	}
+
+	return out
+}
+
+// cleanupFluxResources deletes the bootstrap resources and those declared in spec.flux
+// from every target cluster. When the Integration is deleted without uninstalling, the
+// root Kustomization and its GitRepository stay behind, suspended and no longer pruning,
+// so Flux does not remove the workloads it applied. It returns the clusters where cleanup
+// failed, with the reason.
+func (r *IntegrationReconciler) cleanupFluxResources(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	namespace := integration.Spec.Config["namespace"]
+	if namespace == "" {
+		namespace = "flux-system"
+	}
+	repos, kustomizations := fluxResources(integration, namespace)
+	if len(repos) == 0 && len(kustomizations) == 0 {
+		return nil
+	}
+	var orphan string
+	if integration.Spec.Config[fluxGitURLKey] != "" && integration.DeletionTimestamp != nil && !uninstallOnDelete(integration) {
+		orphan = flux.BootstrapName(integration.Namespace, integration.Name)
+	}
+
+	failures := map[string]error{}
+	for _, clusterName := range targetClusters(ctx, integration) {
+		fluxClient, err := r.clients().Flux(ctx, integration, clusterName)
+		if err != nil {
+			r.Log.Error(err, "failed to clean up Flux resources", "cluster", clusterName)
+			failures[clusterName] = err
+			continue
+		}
+
+		var errs []error
+		// Kustomizations go first so Flux can still prune what they applied
+		for _, ks := range kustomizations {
+			if ks.Name == orphan && ks.Namespace == namespace {
+				if err := fluxClient.OrphanKustomization(ctx, ks.Name, ks.Namespace); err != nil && !errors.IsNotFound(err) {
+					r.Log.Error(err, "failed to orphan Kustomization", "cluster", clusterName, "name", ks.Name)
+					errs = append(errs, fmt.Errorf("Kustomization %s/%s: %w", ks.Namespace, ks.Name, err))
+				}
+				continue
+			}
+			if err := fluxClient.DeleteKustomization(ctx, ks.Name, ks.Namespace); err != nil && !errors.IsNotFound(err) {
+				r.Log.Error(err, "failed to delete Kustomization", "cluster", clusterName, "name", ks.Name)
+				errs = append(errs, fmt.Errorf("Kustomization %s/%s: %w", ks.Namespace, ks.Name, err))
+			}
+		}
+		for _, repo := range repos {
+			if repo.Name == orphan && repo.Namespace == namespace {
+				continue
+			}
+			if err := fluxClient.DeleteGitRepository(ctx, repo.Name, repo.Namespace); err != nil && !errors.IsNotFound(err) {
+				r.Log.Error(err, "failed to delete GitRepository", "cluster", clusterName, "name", repo.Name)
+				errs = append(errs, fmt.Errorf("GitRepository %s/%s: %w", repo.Namespace, repo.Name, err))
+			}
+		}
+		if len(errs) > 0 {
+			failures[clusterName] = goerrors.Join(errs...)
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/factory"
+)
+
+// newFluxTargets returns a reconciler whose target clusters are fake clients serving the Flux CRDs
+func newFluxTargets(t *testing.T, clusterNames ...string) (*IntegrationReconciler, map[string]client.Client) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor(ksitv1alpha1.IntegrationTypeFlux) {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	cm := cluster.NewClusterManager(nil)
+	targets := make(map[string]client.Client)
+	for _, name := range clusterNames {
+		require.NoError(t, cm.AddCluster(name, "ksit-system", testKubeconfig("https://"+name+".example.com")))
+		targets["https://"+name+".example.com"] = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+	}
+
+	clients := factory.New(cm, scheme.Scheme, logr.Discard())
+	clients.NewClient = func(config *rest.Config) (client.Client, error) {
+		return targets[config.Host], nil
+	}
+
+	return &IntegrationReconciler{Log: logr.Discard(), ClusterManager: cm, Clients: clients}, targets
+}
+
+func fluxIntegration() *ksitv1alpha1.Integration {
+	return &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster-a", "cluster-b"},
+			Flux: &ksitv1alpha1.FluxSpec{
+				GitRepositories: []ksitv1alpha1.FluxGitRepository{{Name: "fleet", URL: "https://github.com/example/fleet"}},
+				Kustomizations: []ksitv1alpha1.FluxKustomization{
+					{Name: "infra", SourceRef: "fleet", Path: "./infra"},
+					{Name: "apps", SourceRef: "fleet", Path: "./apps", DependsOn: []string{"infra"}},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyFluxResourcesOnTargetClusters(t *testing.T) {
+	r, targets := newFluxTargets(t, "cluster-a", "cluster-b")
+	integration := fluxIntegration()
+	ctx := context.Background()
+
+	statuses := r.applyFluxResources(ctx, integration, "cluster-a", "flux-system")
+	require.Len(t, statuses, 3)
+	for _, status := range statuses {
+		assert.Equal(t, "cluster-a", status.Cluster)
+		assert.False(t, status.Ready)
+	}
+
+	target := targets["https://cluster-a.example.com"]
+	ks := &unstructured.Unstructured{}
+	ks.SetGroupVersionKind(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"})
+	require.NoError(t, target.Get(ctx, client.ObjectKey{Name: "apps", Namespace: "flux-system"}, ks))
+	assert.Equal(t, "apps", ks.GetLabels()[ksitv1alpha1.LabelIntegration])
+	deps, _, _ := unstructured.NestedSlice(ks.Object, "spec", "dependsOn")
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "infra"}}, deps)
+
+	// Flux marks the Kustomization ready and the path changes in the Integration
+	require.NoError(t, unstructured.SetNestedSlice(ks.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True", "message": "Applied revision: main@sha1:abc"},
+	}, "status", "conditions"))
+	require.NoError(t, target.Update(ctx, ks))
+	integration.Spec.Flux.Kustomizations[1].Path = "./apps/prod"
+
+	statuses = r.applyFluxResources(ctx, integration, "cluster-a", "flux-system")
+	assert.True(t, statuses[2].Ready)
+	assert.Equal(t, "Applied revision: main@sha1:abc", statuses[2].Message)
+	require.NoError(t, target.Get(ctx, client.ObjectKey{Name: "apps", Namespace: "flux-system"}, ks))
+	path, _, _ := unstructured.NestedString(ks.Object, "spec", "path")
+	assert.Equal(t, "./apps/prod", path)
+
+	// The other cluster is untouched until it is reconciled
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "KustomizationList"})
+	require.NoError(t, targets["https://cluster-b.example.com"].List(ctx, list))
+	assert.Empty(t, list.Items)
+
+	assert.Empty(t, r.cleanupFluxResources(ctx, integration))
+	require.NoError(t, target.List(ctx, list))
+	assert.Empty(t, list.Items)
+}
+
+func TestApplyFluxResourcesReportsUnreachableCluster(t *testing.T) {
+	r, _ := newFluxTargets(t, "cluster-a")
+
+	statuses := r.applyFluxResources(context.Background(), fluxIntegration(), "cluster-b", "flux-system")
+	require.Len(t, statuses, 3)
+	assert.False(t, statuses[0].Ready)
+	assert.Contains(t, statuses[0].Message, "failed to get cluster config for cluster-b")
+}
+
+func TestApplyFluxBootstrap(t *testing.T) {
+	r, targets := newFluxTargets(t, "cluster-a")
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"cluster-a"},
+			Config: map[string]string{
+				"namespace": "flux-system",
+				"gitURL":    "https://github.com/example/fleet",
+				"path":      "./clusters/{{ .Cluster.Name }}",
+				"interval":  "5m",
+			},
+		},
+	}
+	ctx := context.Background()
+
+	statuses := r.applyFluxResources(ctx, integration, "cluster-a", "flux-system")
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "GitRepository", statuses[0].Kind)
+	assert.Equal(t, "Kustomization", statuses[1].Kind)
+
+	target := targets["https://cluster-a.example.com"]
+	repo := &unstructured.Unstructured{}
+	repo.SetGroupVersionKind(schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"})
+	key := client.ObjectKey{Name: "ksit-system-fleet", Namespace: "flux-system"}
+	require.NoError(t, target.Get(ctx, key, repo), "the name is qualified with the Integration's namespace")
+	branch, _, _ := unstructured.NestedString(repo.Object, "spec", "ref", "branch")
+	assert.Equal(t, "main", branch)
+
+	ks := &unstructured.Unstructured{}
+	ks.SetGroupVersionKind(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"})
+	require.NoError(t, target.Get(ctx, key, ks))
+	spec, _, _ := unstructured.NestedMap(ks.Object, "spec")
+	assert.Equal(t, "./clusters/cluster-a", spec["path"], "the path is rendered for the cluster")
+	assert.Equal(t, "5m", spec["interval"])
+	assert.Equal(t, false, spec["prune"], "pruning is opt-in")
+	assert.Equal(t, map[string]interface{}{"kind": "GitRepository", "name": "ksit-system-fleet"}, spec["sourceRef"])
+
+	integration.Spec.Config["prune"] = "true"
+	r.applyFluxResources(ctx, integration, "cluster-a", "flux-system")
+	require.NoError(t, target.Get(ctx, key, ks))
+	prune, _, _ := unstructured.NestedBool(ks.Object, "spec", "prune")
+	assert.True(t, prune)
+
+	// Deleting the Integration without uninstalling leaves the bootstrap behind, suspended
+	// and no longer pruning, so Flux keeps the workloads it applied
+	deleted := integration.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	assert.Empty(t, r.cleanupFluxResources(ctx, deleted))
+	require.NoError(t, target.Get(ctx, key, ks))
+	suspend, _, _ := unstructured.NestedBool(ks.Object, "spec", "suspend")
+	prune, _, _ = unstructured.NestedBool(ks.Object, "spec", "prune")
+	assert.True(t, suspend)
+	assert.False(t, prune)
+	require.NoError(t, target.Get(ctx, key, repo))
+
+	// Uninstalling removes it
+	assert.Empty(t, r.cleanupFluxResources(ctx, integration))
+	err := target.Get(ctx, key, ks)
+	assert.True(t, apierrors.IsNotFound(err))
+	err = target.Get(ctx, key, repo)
+	assert.True(t, apierrors.IsNotFound(err))
+}
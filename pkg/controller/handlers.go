@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// IntegrationHandler checks and cleans up the Integrations of one type
+type IntegrationHandler interface {
+	// HealthCheck checks the integration on one target cluster
+	HealthCheck(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error
+	// Reconcile checks the integration on all its target clusters and records the outcome in its status
+	Reconcile(ctx context.Context, integration *ksitv1alpha1.Integration) error
+	// Cleanup removes what KSIT created for the integration and returns the clusters where it failed
+	Cleanup(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error
+}
+
+// HandlerRegistry holds the IntegrationHandler of each integration type
+type HandlerRegistry struct {
+	handlers map[string]IntegrationHandler
+}
+
+// NewHandlerRegistry creates a registry with the built-in integration types, checked by r
+func NewHandlerRegistry(r *IntegrationReconciler) *HandlerRegistry {
+	return &HandlerRegistry{
+		handlers: map[string]IntegrationHandler{
+			ksitv1alpha1.IntegrationTypeArgoCD:      NewClusterHandler(r, r.checkArgoCD, nil),
+			ksitv1alpha1.IntegrationTypeFlux:        &fluxHandler{r: r},
+			ksitv1alpha1.IntegrationTypePrometheus:  NewClusterHandler(r, r.checkPrometheus, nil),
+			ksitv1alpha1.IntegrationTypeIstio:       NewClusterHandler(r, r.checkIstio, nil),
+			ksitv1alpha1.IntegrationTypeGrafana:     NewClusterHandler(r, r.checkGrafana, nil),
+			ksitv1alpha1.IntegrationTypeCertManager: &certManagerHandler{r: r},
+			ksitv1alpha1.IntegrationTypeKyverno:     &kyvernoHandler{r: r},
+		},
+	}
+}
+
+// GetHandler returns the handler for the given integration type
+func (h *HandlerRegistry) GetHandler(integrationType string) (IntegrationHandler, error) {
+	handler, ok := h.handlers[integrationType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported integration type: %s", integrationType)
+	}
+	return handler, nil
+}
+
+// Register sets the handler used for an integration type, replacing any existing one
+func (h *HandlerRegistry) Register(integrationType string, handler IntegrationHandler) {
+	h.handlers[integrationType] = handler
+}
+
+// HealthCheckFunc checks an integration on one target cluster
+type HealthCheckFunc func(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error
+
+// CleanupFunc removes what KSIT created for an integration and returns the clusters where it failed
+type CleanupFunc func(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error
+
+// NewClusterHandler returns a handler that reconciles an integration by running healthCheck
+// on its target clusters, with the timeouts, circuit breaking and status.clusterStatuses
+// bookkeeping of r. A nil cleanup leaves nothing to clean up.
+func NewClusterHandler(r *IntegrationReconciler, healthCheck HealthCheckFunc, cleanup CleanupFunc) IntegrationHandler {
+	return &clusterHandler{r: r, healthCheck: healthCheck, cleanup: cleanup}
+}
+
+type clusterHandler struct {
+	r           *IntegrationReconciler
+	healthCheck HealthCheckFunc
+	cleanup     CleanupFunc
+}
+
+func (h *clusterHandler) HealthCheck(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	return h.healthCheck(ctx, integration, clusterName)
+}
+
+func (h *clusterHandler) Reconcile(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	return h.r.forEachCluster(ctx, integration, func(ctx context.Context, clusterName string) error {
+		return h.healthCheck(ctx, integration, clusterName)
+	})
+}
+
+func (h *clusterHandler) Cleanup(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	if h.cleanup == nil {
+		return nil
+	}
+	return h.cleanup(ctx, integration)
+}
+
+// fluxHandler also records spec.flux resources and Kustomization dependency chains in status
+type fluxHandler struct {
+	r *IntegrationReconciler
+}
+
+func (h *fluxHandler) HealthCheck(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	_, _, err := h.r.checkFlux(ctx, integration, clusterName)
+	return err
+}
+
+func (h *fluxHandler) Reconcile(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	return h.r.reconcileFlux(ctx, integration)
+}
+
+func (h *fluxHandler) Cleanup(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	return h.r.cleanupFluxResources(ctx, integration)
+}
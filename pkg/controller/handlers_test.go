@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestHandlerRegistry(t *testing.T) {
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	registry := NewHandlerRegistry(r)
+
+	for _, integrationType := range []string{
+		ksitv1alpha1.IntegrationTypeArgoCD,
+		ksitv1alpha1.IntegrationTypeFlux,
+		ksitv1alpha1.IntegrationTypePrometheus,
+		ksitv1alpha1.IntegrationTypeIstio,
+		ksitv1alpha1.IntegrationTypeGrafana,
+		ksitv1alpha1.IntegrationTypeCertManager,
+		ksitv1alpha1.IntegrationTypeKyverno,
+	} {
+		handler, err := registry.GetHandler(integrationType)
+		require.NoError(t, err, integrationType)
+		assert.NotNil(t, handler, integrationType)
+	}
+
+	_, err := registry.GetHandler("vault")
+	assert.EqualError(t, err, "unsupported integration type: vault")
+}
+
+func TestRegisteredHandler(t *testing.T) {
+	ctx := context.Background()
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	r.Handlers = NewHandlerRegistry(r)
+
+	var mu sync.Mutex
+	var checked []string
+	cleaned := false
+	r.Handlers.Register("vault", NewClusterHandler(r,
+		func(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			checked = append(checked, clusterName)
+			if clusterName == "cluster2" {
+				return errors.New("vault is sealed on cluster2")
+			}
+			return nil
+		},
+		func(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+			cleaned = true
+			return nil
+		},
+	))
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           "vault",
+			TargetClusters: []string{"cluster1", "cluster2"},
+		},
+	}
+
+	handler, err := r.handlers().GetHandler("vault")
+	require.NoError(t, err)
+	assert.EqualError(t, handler.Reconcile(ctx, integration), "vault is sealed on cluster2")
+	assert.ElementsMatch(t, []string{"cluster1", "cluster2"}, checked)
+
+	// The handler's checks get the usual per-cluster status bookkeeping
+	statuses := integration.Status.ClusterStatuses
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Connected)
+	assert.False(t, statuses[1].Connected)
+	assert.Equal(t, "vault is sealed on cluster2", statuses[1].Message)
+
+	assert.Empty(t, r.cleanupIntegration(ctx, integration))
+	assert.True(t, cleaned)
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// defaultHealthSummaryInterval is how often the health of an integration is summed up
+// in one line when HealthSummaryInterval is not set
+const defaultHealthSummaryInterval = 10 * time.Minute
+
+// healthLog remembers the last logged health of each component and cluster, so health
+// checks log transitions at info level and repeat unchanged results only at V(1). A nil
+// healthLog treats every result as a transition.
+type healthLog struct {
+	mu        sync.Mutex
+	healthy   map[string]bool
+	summaries map[string]time.Time
+	interval  time.Duration
+}
+
+func newHealthLog(interval time.Duration) *healthLog {
+	if interval <= 0 {
+		interval = defaultHealthSummaryInterval
+	}
+	return &healthLog{
+		healthy:   make(map[string]bool),
+		summaries: make(map[string]time.Time),
+		interval:  interval,
+	}
+}
+
+// changed records the health of key and reports whether it differs from the last
+// recorded one; the first result for a key counts as a change
+func (h *healthLog) changed(key string, healthy bool) bool {
+	if h == nil {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last, seen := h.healthy[key]
+	h.healthy[key] = healthy
+	return !seen || last != healthy
+}
+
+// summaryDue reports whether the summary line of key is due at now, and if so starts
+// the next interval
+func (h *healthLog) summaryDue(key string, now time.Time) bool {
+	if h == nil {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if last, ok := h.summaries[key]; ok && now.Sub(last) < h.interval {
+		return false
+	}
+	h.summaries[key] = now
+	return true
+}
+
+// forget drops the bookkeeping of every key starting with prefix
+func (h *healthLog) forget(prefix string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key := range h.healthy {
+		if strings.HasPrefix(key, prefix) {
+			delete(h.healthy, key)
+		}
+	}
+	for key := range h.summaries {
+		if strings.HasPrefix(key, prefix) {
+			delete(h.summaries, key)
+		}
+	}
+}
+
+// logComponent logs the health of one component of an integration on a cluster: at
+// info level when it changed since the last check, otherwise at V(1)
+func (r *IntegrationReconciler) logComponent(integration *ksitv1alpha1.Integration, clusterName, component string, healthy bool, keysAndValues ...interface{}) {
+	keysAndValues = append([]interface{}{"integration", integration.Name, "cluster", clusterName, "component", component}, keysAndValues...)
+	if !r.healthLog.changed(circuitKey(integration, clusterName)+"/"+component, healthy) {
+		if healthy {
+			r.Log.V(1).Info("component is healthy", keysAndValues...)
+		} else {
+			r.Log.V(1).Info("component is unhealthy", keysAndValues...)
+		}
+		return
+	}
+	if healthy {
+		r.Log.Info("component became healthy", keysAndValues...)
+	} else {
+		r.Log.Info("component became unhealthy", keysAndValues...)
+	}
+}
+
+// logClusterHealth logs the outcome of the checks of an integration on a cluster when it
+// changed since the last check
+func (r *IntegrationReconciler) logClusterHealth(integration *ksitv1alpha1.Integration, clusterName string, err error) {
+	if !r.healthLog.changed(circuitKey(integration, clusterName), err == nil) {
+		return
+	}
+	if err == nil {
+		r.Log.Info("integration became healthy on cluster", "integration", integration.Name, "cluster", clusterName)
+	} else {
+		r.Log.Info("integration became unhealthy on cluster", "integration", integration.Name, "cluster", clusterName, "error", err.Error())
+	}
+}
+
+// logHealthSummary sums up the health of an integration across its target clusters in
+// one info line, at most once per summary interval
+func (r *IntegrationReconciler) logHealthSummary(integration *ksitv1alpha1.Integration, statuses []ksitv1alpha1.ClusterStatus) {
+	if !r.healthLog.summaryDue(circuitKey(integration, ""), time.Now()) {
+		return
+	}
+	var unhealthy []string
+	for _, status := range statuses {
+		if !status.Connected {
+			unhealthy = append(unhealthy, status.Name)
+		}
+	}
+	r.Log.Info("health summary", "integration", integration.Name, "type", integration.Spec.Type,
+		"clusters", len(statuses), "healthy", len(statuses)-len(unhealthy), "unhealthyClusters", unhealthy)
+}
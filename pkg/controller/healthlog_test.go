@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestHealthLogOnlyLogsTransitions(t *testing.T) {
+	var lines []string
+	log := funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{})
+
+	r := &IntegrationReconciler{Log: log, healthLog: newHealthLog(time.Minute)}
+	integration := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"}}
+
+	for i := 0; i < 3; i++ {
+		r.logComponent(integration, "cluster1", "argocd-server", true)
+		r.logClusterHealth(integration, "cluster1", nil)
+	}
+	assert.Len(t, lines, 2, "repeated healthy results are not logged at info level")
+	assert.Contains(t, lines[0], "component became healthy")
+	assert.Contains(t, lines[1], "integration became healthy on cluster")
+
+	lines = nil
+	r.logComponent(integration, "cluster1", "argocd-server", false)
+	r.logComponent(integration, "cluster1", "argocd-server", false)
+	r.logComponent(integration, "cluster2", "argocd-server", true)
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "component became unhealthy")
+	assert.Contains(t, lines[1], `"cluster"="cluster2"`)
+
+	// A recreated Integration starts over
+	lines = nil
+	r.healthLog.forget(circuitKey(integration, ""))
+	r.logComponent(integration, "cluster1", "argocd-server", false)
+	assert.Len(t, lines, 1)
+}
+
+func TestHealthLogSummaryInterval(t *testing.T) {
+	h := newHealthLog(10 * time.Minute)
+	now := time.Now()
+
+	assert.True(t, h.summaryDue("ksit-system/argocd/", now))
+	assert.False(t, h.summaryDue("ksit-system/argocd/", now.Add(5*time.Minute)))
+	assert.True(t, h.summaryDue("ksit-system/flux/", now.Add(5*time.Minute)))
+	assert.True(t, h.summaryDue("ksit-system/argocd/", now.Add(10*time.Minute)))
+
+	// Without bookkeeping every result is logged
+	var none *healthLog
+	assert.True(t, none.changed("key", true))
+	assert.True(t, none.changed("key", true))
+	assert.True(t, none.summaryDue("key", now))
+}
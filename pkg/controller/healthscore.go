@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/health"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+// scoreHealth scores the integration on every target cluster, in parallel and bounded by
+// the cluster timeout, and publishes the scores in the status and as metrics. Clusters
+// whose circuit is open are not observed and score 0.
+func (r *IntegrationReconciler) scoreHealth(ctx context.Context, integration *ksitv1alpha1.Integration) {
+	weights := healthWeights(integration)
+	namespace := healthNamespace(integration)
+
+	type clusterHealth struct {
+		signals health.Signals
+		version string
+	}
+	clusters := targetClusters(ctx, integration)
+	results, ok := observeClusters(ctx, r, integration, func(ctx context.Context, clusterName string) (clusterHealth, error) {
+		clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+		if err != nil {
+			return clusterHealth{}, err
+		}
+		signals, version, err := clusterSignals(ctx, clients.Clientset, namespace)
+		if err != nil {
+			return clusterHealth{}, fmt.Errorf("failed to observe health signals: %w", err)
+		}
+		r.probeAPI(ctx, integration, clusterName, &signals)
+		return clusterHealth{signals: signals, version: version}, nil
+	})
+	observed := make(map[string]*health.Signals, len(clusters))
+	versions := make(map[string]string, len(clusters))
+	for i, clusterName := range clusters {
+		if !ok[i] {
+			continue
+		}
+		observed[clusterName] = &results[i].signals
+		if results[i].version != "" {
+			versions[clusterName] = results[i].version
+		}
+	}
+
+	skewed := versionSkew(versions)
+	scores := make([]ksitv1alpha1.ClusterHealthScore, 0, len(clusters))
+	values := make([]int32, 0, len(clusters))
+	for _, clusterName := range clusters {
+		score := ksitv1alpha1.ClusterHealthScore{Name: clusterName}
+		// Unreachable clusters score 0
+		if signals := observed[clusterName]; signals != nil {
+			if skew, ok := skewed[clusterName]; ok {
+				signals.VersionSkew = &skew
+			}
+			score.Score, score.Signals = health.Score(*signals, weights)
+		}
+		scores = append(scores, score)
+		values = append(values, score.Score)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].Name < scores[j].Name
+	})
+
+	fleet := health.Aggregate(values)
+	prometheus.SetHealthScore(integration.Name, integration.Spec.Type, scores, fleet)
+
+	if len(scores) > maxClusterStatuses {
+		scores = scores[:maxClusterStatuses]
+	}
+	integration.Status.Health = &ksitv1alpha1.HealthScore{Score: fleet, Clusters: scores}
+}
+
+// clusterSignals observes the control plane workloads and Services in namespace. It also
+// returns the images of the control plane, which identify the version running there.
+func clusterSignals(ctx context.Context, clientset kubernetes.Interface, namespace string) (health.Signals, string, error) {
+	var signals health.Signals
+	var images []string
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return signals, "", err
+	}
+	for i := range deployments.Items {
+		status := health.DeploymentStatus(&deployments.Items[i])
+		signals.ControlPlaneTotal++
+		if status.Ready {
+			signals.ControlPlaneReady++
+		}
+		images = append(images, templateImages(&deployments.Items[i].Spec.Template)...)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return signals, "", err
+	}
+	for i := range statefulSets.Items {
+		status := health.StatefulSetStatus(&statefulSets.Items[i])
+		signals.ControlPlaneTotal++
+		if status.Ready {
+			signals.ControlPlaneReady++
+		}
+		images = append(images, templateImages(&statefulSets.Items[i].Spec.Template)...)
+	}
+
+	endpoints, err := clientset.CoreV1().Endpoints(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return signals, "", err
+	}
+	for _, ep := range endpoints.Items {
+		signals.EndpointsTotal++
+		for _, subset := range ep.Subsets {
+			if len(subset.Addresses) > 0 {
+				signals.EndpointsReady++
+				break
+			}
+		}
+	}
+
+	sort.Strings(images)
+	return signals, strings.Join(images, ","), nil
+}
+
+func templateImages(template *corev1.PodTemplateSpec) []string {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+// probeAPI asks the tool's own API whether it is up and, for Prometheus, how many alerts
+// are firing. Tools whose client cannot be built, e.g. without a configured URL, are not probed.
+func (r *IntegrationReconciler) probeAPI(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, signals *health.Signals) {
+	var probeErr error
+	switch integration.Spec.Type {
+	case ksitv1alpha1.IntegrationTypeArgoCD:
+		argoClient, err := r.clients().ArgoCD(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		probeErr = argoClient.HealthCheck(ctx)
+	case ksitv1alpha1.IntegrationTypePrometheus:
+		promClient, err := r.clients().Prometheus(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		probeErr = promClient.ValidateConnection(ctx)
+		if probeErr == nil {
+			if alerts, err := promClient.GetAlerts(ctx); err == nil {
+				firing := 0
+				for _, alert := range alerts.Alerts {
+					if alert.State == promv1.AlertStateFiring {
+						firing++
+					}
+				}
+				signals.FiringAlerts = &firing
+			}
+		}
+	case ksitv1alpha1.IntegrationTypeIstio:
+		istioClient, err := r.clients().Istio(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		probeErr = istioClient.HealthCheck()
+	case ksitv1alpha1.IntegrationTypeGrafana:
+		grafanaClient, err := r.clients().Grafana(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		_, probeErr = grafanaClient.HealthCheck(ctx)
+	case ksitv1alpha1.IntegrationTypeCertManager:
+		certManagerClient, err := r.clients().CertManager(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		probeErr = certManagerClient.HealthCheck(ctx)
+	case ksitv1alpha1.IntegrationTypeKyverno:
+		kyvernoClient, err := r.clients().Kyverno(ctx, integration, clusterName)
+		if err != nil {
+			return
+		}
+		probeErr = kyvernoClient.HealthCheck(ctx)
+	default:
+		return
+	}
+	ok := probeErr == nil
+	signals.APIProbe = &ok
+}
+
+// versionSkew reports for every cluster whether it runs other versions than most of the
+// fleet. Nothing is reported for fleets of one cluster.
+func versionSkew(versions map[string]string) map[string]bool {
+	if len(versions) < 2 {
+		return nil
+	}
+
+	counts := map[string]int{}
+	for _, version := range versions {
+		counts[version]++
+	}
+	var common string
+	for version, count := range counts {
+		// Ties go to the lowest version string, so the result does not depend on map order
+		if count > counts[common] || (count == counts[common] && version < common) {
+			common = version
+		}
+	}
+
+	skewed := make(map[string]bool, len(versions))
+	for cluster, version := range versions {
+		skewed[cluster] = version != common
+	}
+	return skewed
+}
+
+// healthWeights returns the default weights with spec.healthScoring.weights applied
+func healthWeights(integration *ksitv1alpha1.Integration) health.Weights {
+	weights := health.Weights{}
+	for signal, weight := range health.DefaultWeights {
+		weights[signal] = weight
+	}
+	if scoring := integration.Spec.HealthScoring; scoring != nil {
+		for signal, weight := range scoring.Weights {
+			weights[signal] = weight
+		}
+	}
+	return weights
+}
+
+// healthNamespace returns the namespace the integration's tool runs in
+func healthNamespace(integration *ksitv1alpha1.Integration) string {
+	// Istio is always checked in istio-system
+	if integration.Spec.Type == ksitv1alpha1.IntegrationTypeIstio {
+		return "istio-system"
+	}
+	if namespace := integration.Spec.Config["namespace"]; namespace != "" {
+		return namespace
+	}
+	switch integration.Spec.Type {
+	case ksitv1alpha1.IntegrationTypeArgoCD:
+		return "argocd"
+	case ksitv1alpha1.IntegrationTypeFlux:
+		return "flux-system"
+	case ksitv1alpha1.IntegrationTypeGrafana:
+		return "grafana"
+	case ksitv1alpha1.IntegrationTypeCertManager:
+		return "cert-manager"
+	case ksitv1alpha1.IntegrationTypeKyverno:
+		return "kyverno"
+	default:
+		return "monitoring"
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClusterSignals(t *testing.T) {
+	template := func(image string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}}}
+	}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-controller", Namespace: "flux-system"},
+			Spec:       appsv1.DeploymentSpec{Template: template("ghcr.io/fluxcd/source-controller:v1.2.0")},
+			Status:     appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "kustomize-controller", Namespace: "flux-system"},
+			Spec:       appsv1.DeploymentSpec{Template: template("ghcr.io/fluxcd/kustomize-controller:v1.2.0")},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "source-controller", Namespace: "flux-system"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "webhook-receiver", Namespace: "flux-system"}},
+	)
+
+	signals, version, err := clusterSignals(context.Background(), clientset, "flux-system")
+	require.NoError(t, err)
+	assert.Equal(t, 1, signals.ControlPlaneReady)
+	assert.Equal(t, 2, signals.ControlPlaneTotal)
+	assert.Equal(t, 1, signals.EndpointsReady)
+	assert.Equal(t, 2, signals.EndpointsTotal)
+	assert.Equal(t, "ghcr.io/fluxcd/kustomize-controller:v1.2.0,ghcr.io/fluxcd/source-controller:v1.2.0", version)
+}
+
+func TestVersionSkew(t *testing.T) {
+	assert.Nil(t, versionSkew(map[string]string{"cluster1": "v1"}))
+	assert.Equal(t, map[string]bool{"cluster1": false, "cluster2": false, "cluster3": true},
+		versionSkew(map[string]string{"cluster1": "v1", "cluster2": "v1", "cluster3": "v2"}))
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+// kubeconfigSecretSuffix names the Secret holding a target's kubeconfig: <clusterName>-kubeconfig
+const kubeconfigSecretSuffix = "-kubeconfig"
+
+// targetsForKubeconfigSecret maps a kubeconfig Secret to the IntegrationTargets that
+// read it, so rotated credentials are picked up without waiting for the next resync
+func (r *IntegrationTargetReconciler) targetsForKubeconfigSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusterName, ok := strings.CutSuffix(obj.GetName(), kubeconfigSecretSuffix)
+	if !ok || clusterName == "" {
+		return nil
+	}
+
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := r.List(ctx, targets, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list integration targets for kubeconfig secret", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, target := range targets.Items {
+		if target.Spec.ClusterName == clusterName {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&target)})
+		}
+	}
+	return requests
+}
+
+// kubeconfigRotated reports whether a registered cluster is about to be re-registered with
+// a different kubeconfig or context. Clusters registered for the first time are not rotations.
+func kubeconfigRotated(cm *cluster.ClusterManager, target *ksitv1alpha1.IntegrationTarget, kubeconfig string) bool {
+	registered, err := cm.GetCluster(target.Spec.ClusterName, target.Namespace)
+	if err != nil {
+		return false
+	}
+	return registered.KubeConfig != kubeconfig || registered.KubeConfigContext != target.Spec.KubeconfigContext
+}
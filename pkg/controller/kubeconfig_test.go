@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func TestTargetsForKubeconfigSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	target := func(name, namespace, clusterName string) *ksitv1alpha1.IntegrationTarget {
+		return &ksitv1alpha1.IntegrationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: clusterName},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		target("prod-1", "ksit-system", "cluster-1"),
+		target("cluster-2", "ksit-system", "cluster-2"),
+		target("prod-1", "other", "cluster-1"),
+	).Build()
+	r := &IntegrationTargetReconciler{Client: c, Log: logr.Discard()}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cluster-1-kubeconfig", Namespace: "ksit-system"}}
+	requests := r.targetsForKubeconfigSecret(context.Background(), secret)
+	require.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Name: "prod-1", Namespace: "ksit-system"}, requests[0].NamespacedName)
+
+	// Secrets that are not kubeconfigs are ignored
+	secret.Name = "cluster-1-token"
+	assert.Empty(t, r.targetsForKubeconfigSecret(context.Background(), secret))
+}
+
+func TestKubeconfigRotated(t *testing.T) {
+	cm := cluster.NewClusterManager(nil)
+	target := &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1", Namespace: "ksit-system"},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "cluster-1"},
+	}
+	kubeconfig := testKubeconfig("https://cluster-1.example.com")
+
+	// A first registration is not a rotation
+	assert.False(t, kubeconfigRotated(cm, target, kubeconfig))
+	require.NoError(t, cm.AddCluster("cluster-1", "ksit-system", kubeconfig))
+
+	assert.False(t, kubeconfigRotated(cm, target, kubeconfig))
+	assert.True(t, kubeconfigRotated(cm, target, testKubeconfig("https://cluster-1.example.net")))
+
+	target.Spec.KubeconfigContext = "other"
+	assert.True(t, kubeconfigRotated(cm, target, kubeconfig))
+}
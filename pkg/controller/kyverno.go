@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/kyverno"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+const (
+	// maxPolicyReportClusters bounds status.policyReports.clusters
+	maxPolicyReportClusters = 20
+
+	// maxFailingPolicies bounds the failing policies listed per cluster
+	maxFailingPolicies = 5
+)
+
+// kyvernoHandler also applies spec.kyverno policies and sums the policy reports in status
+type kyvernoHandler struct {
+	r *IntegrationReconciler
+}
+
+func (h *kyvernoHandler) HealthCheck(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	_, err := h.r.checkKyverno(ctx, integration, clusterName)
+	return err
+}
+
+func (h *kyvernoHandler) Reconcile(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	return h.r.reconcileKyverno(ctx, integration)
+}
+
+func (h *kyvernoHandler) Cleanup(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	return h.r.cleanupKyvernoPolicies(ctx, integration)
+}
+
+// kyvernoPolicies decodes spec.kyverno.policies
+func kyvernoPolicies(integration *ksitv1alpha1.Integration) ([]*unstructured.Unstructured, error) {
+	spec := integration.Spec.Kyverno
+	if spec == nil {
+		return nil, nil
+	}
+
+	var policies []*unstructured.Unstructured
+	for i, manifest := range spec.Policies {
+		objs, err := manifests.Decode([]byte(manifest))
+		if err != nil {
+			return nil, fmt.Errorf("kyverno.policies[%d]: %w", i, err)
+		}
+		policies = append(policies, objs...)
+	}
+	return policies, nil
+}
+
+// kyvernoOwnerLabels are set on every ClusterPolicy KSIT applies for the Integration, so
+// that the ones no longer declared can be found and deleted. ClusterPolicies are not
+// namespaced, so the Integration's namespace is part of them.
+func kyvernoOwnerLabels(integration *ksitv1alpha1.Integration) map[string]string {
+	return map[string]string{
+		ksitv1alpha1.LabelIntegration:          integration.Name,
+		ksitv1alpha1.LabelIntegrationNamespace: integration.Namespace,
+	}
+}
+
+func (r *IntegrationReconciler) reconcileKyverno(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	summaries, err := collectClusters(ctx, r, integration, func(ctx context.Context, clusterName string) (*kyverno.ReportSummary, error) {
+		summary, err := r.checkKyverno(ctx, integration, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		// Policies are applied once Kyverno is healthy, never by the health check itself
+		kyvernoClient, err := r.clients().Kyverno(ctx, integration, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.applyKyvernoPolicies(ctx, kyvernoClient, integration, clusterName); err != nil {
+			return nil, err
+		}
+		return summary, nil
+	})
+
+	reports := policyReportSummary(targetClusters(ctx, integration), summaries)
+	integration.Status.PolicyReports = reports
+	if err != nil {
+		return err
+	}
+
+	if reports.Fail > 0 {
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypePolicyViolations,
+			Status:  metav1.ConditionTrue,
+			Reason:  "PolicyViolations",
+			Message: fmt.Sprintf("%d policy results fail on %d clusters", reports.Fail, len(reports.Clusters)),
+		})
+	} else {
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypePolicyViolations,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoPolicyViolations",
+			Message: "No policy result fails",
+		})
+	}
+
+	return nil
+}
+
+// policyReportSummary sums the report summaries of the clusters, where summaries[i] is
+// that of clusterNames[i]. Clusters without a summary, because they failed or their
+// reports could not be read, are left out.
+func policyReportSummary(clusterNames []string, summaries []*kyverno.ReportSummary) *ksitv1alpha1.PolicyReportSummary {
+	out := &ksitv1alpha1.PolicyReportSummary{}
+	for i, clusterName := range clusterNames {
+		summary := summaries[i]
+		if summary == nil {
+			continue
+		}
+		out.Pass += int32(summary.Pass)
+		out.Fail += int32(summary.Fail)
+		out.Warn += int32(summary.Warn)
+		out.Error += int32(summary.Error)
+		out.Skip += int32(summary.Skip)
+		if summary.Fail == 0 {
+			continue
+		}
+
+		policies := make([]string, 0, len(summary.FailuresByPolicy))
+		for policy := range summary.FailuresByPolicy {
+			policies = append(policies, policy)
+		}
+		sort.Slice(policies, func(i, j int) bool {
+			if summary.FailuresByPolicy[policies[i]] != summary.FailuresByPolicy[policies[j]] {
+				return summary.FailuresByPolicy[policies[i]] > summary.FailuresByPolicy[policies[j]]
+			}
+			return policies[i] < policies[j]
+		})
+		if len(policies) > maxFailingPolicies {
+			policies = policies[:maxFailingPolicies]
+		}
+
+		out.Clusters = append(out.Clusters, ksitv1alpha1.ClusterPolicyReportSummary{
+			Cluster:         clusterName,
+			Fail:            int32(summary.Fail),
+			Warn:            int32(summary.Warn),
+			Error:           int32(summary.Error),
+			FailingPolicies: policies,
+		})
+	}
+
+	sort.SliceStable(out.Clusters, func(i, j int) bool {
+		return out.Clusters[i].Fail > out.Clusters[j].Fail
+	})
+	if len(out.Clusters) > maxPolicyReportClusters {
+		out.Clusters = out.Clusters[:maxPolicyReportClusters]
+	}
+	return out
+}
+
+// checkKyverno is the Kyverno health check on one target cluster. It also returns the
+// summary of the cluster's policy reports.
+func (r *IntegrationReconciler) checkKyverno(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*kyverno.ReportSummary, error) {
+	namespace := integration.Spec.Config["namespace"]
+	if namespace == "" {
+		namespace = "kyverno"
+	}
+
+	r.Log.V(1).Info("checking Kyverno health on cluster", "cluster", clusterName)
+
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
+
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return nil, err
+	}
+
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return nil, err
+	}
+
+	// ✅ Health Check 2: the admission controller is available. The background, cleanup
+	// and reports controllers can be turned off in the chart, so they are only logged.
+	err = runCheck(ctx, "deployments", func(ctx context.Context) error {
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, "kyverno-admission-controller", metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("Kyverno admission controller not found on %s: %w", clusterName, err)
+		}
+		if deployment.Status.AvailableReplicas == 0 {
+			return fmt.Errorf("Kyverno admission controller has 0 available replicas on %s", clusterName)
+		}
+		r.logComponent(integration, clusterName, "kyverno-admission-controller", true, "replicas", deployment.Status.AvailableReplicas)
+
+		for _, controllerName := range []string{"kyverno-background-controller", "kyverno-cleanup-controller", "kyverno-reports-controller"} {
+			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, controllerName, metav1.GetOptions{})
+			if err != nil {
+				r.logComponent(integration, clusterName, controllerName, false, "reason", "not found")
+				continue
+			}
+			r.logComponent(integration, clusterName, controllerName, deploy.Status.AvailableReplicas > 0,
+				"replicas", deploy.Status.AvailableReplicas)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kyvernoClient, err := r.clients().Kyverno(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ Policy reports. Listing errors are logged so that a missing permission does not
+	// fail the Kyverno health check itself.
+	summary, err := kyvernoClient.Summarize(ctx)
+	if err != nil {
+		r.Log.Error(err, "failed to read policy reports", "cluster", clusterName)
+	}
+
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ Kyverno integration is healthy", "cluster", clusterName)
+	return summary, nil
+}
+
+// applyKyvernoPolicies applies the policies of spec.kyverno on a cluster and deletes the
+// ClusterPolicies KSIT applied earlier that are no longer declared
+func (r *IntegrationReconciler) applyKyvernoPolicies(ctx context.Context, kyvernoClient *kyverno.Client, integration *ksitv1alpha1.Integration, clusterName string) error {
+	policies, err := kyvernoPolicies(integration)
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		if err := kyvernoClient.ApplyClusterPolicy(ctx, policy, kyvernoOwnerLabels(integration)); err != nil {
+			return fmt.Errorf("failed to apply Kyverno policies on %s: %w", clusterName, err)
+		}
+		declared[policy.GetName()] = true
+	}
+
+	applied, err := kyvernoClient.ListClusterPolicies(ctx, kyvernoOwnerLabels(integration))
+	if err != nil {
+		return fmt.Errorf("failed to list Kyverno policies on %s: %w", clusterName, err)
+	}
+	for _, name := range applied {
+		if declared[name] {
+			continue
+		}
+		if err := kyvernoClient.DeleteClusterPolicy(ctx, name); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ClusterPolicy %s on %s: %w", name, clusterName, err)
+		}
+		r.Log.Info("deleted ClusterPolicy no longer declared", "cluster", clusterName, "policy", name)
+	}
+	return nil
+}
+
+// cleanupKyvernoPolicies deletes the ClusterPolicies KSIT applied for the Integration
+// from every target cluster. It returns the clusters where cleanup failed, with the reason.
+func (r *IntegrationReconciler) cleanupKyvernoPolicies(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	if integration.Spec.Kyverno == nil || len(integration.Spec.Kyverno.Policies) == 0 {
+		return nil
+	}
+
+	failures := map[string]error{}
+	for _, clusterName := range targetClusters(ctx, integration) {
+		kyvernoClient, err := r.clients().Kyverno(ctx, integration, clusterName)
+		if err != nil {
+			r.Log.Error(err, "failed to clean up Kyverno policies", "cluster", clusterName)
+			failures[clusterName] = err
+			continue
+		}
+
+		applied, err := kyvernoClient.ListClusterPolicies(ctx, kyvernoOwnerLabels(integration))
+		if err != nil {
+			r.Log.Error(err, "failed to clean up Kyverno policies", "cluster", clusterName)
+			failures[clusterName] = err
+			continue
+		}
+
+		var errs []error
+		for _, name := range applied {
+			if err := kyvernoClient.DeleteClusterPolicy(ctx, name); err != nil && !errors.IsNotFound(err) {
+				r.Log.Error(err, "failed to delete ClusterPolicy", "cluster", clusterName, "name", name)
+				errs = append(errs, fmt.Errorf("ClusterPolicy %s: %w", name, err))
+			}
+		}
+		if len(errs) > 0 {
+			failures[clusterName] = goerrors.Join(errs...)
+		}
+	}
+
+	return failures
+}
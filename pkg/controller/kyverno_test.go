@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/kyverno"
+)
+
+const requireLabelsPolicy = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  validationFailureAction: Audit
+`
+
+const disallowLatestPolicy = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: disallow-latest-tag
+spec:
+  validationFailureAction: Enforce
+`
+
+func TestApplyKyvernoPolicies(t *testing.T) {
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor(ksitv1alpha1.IntegrationTypeKyverno) {
+		scope := meta.RESTScopeNamespace
+		if gvk.Kind == "ClusterPolicy" || gvk.Kind == "ClusterPolicyReport" {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	kyvernoClient := kyverno.NewClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build())
+
+	r := &IntegrationReconciler{Log: logr.Discard()}
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "policies", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeKyverno,
+			TargetClusters: []string{"cluster-a"},
+			Kyverno:        &ksitv1alpha1.KyvernoSpec{Policies: []string{requireLabelsPolicy + "---\n" + disallowLatestPolicy}},
+		},
+	}
+
+	require.NoError(t, r.applyKyvernoPolicies(ctx, kyvernoClient, integration, "cluster-a"))
+	selector := map[string]string{ksitv1alpha1.LabelIntegration: "policies", ksitv1alpha1.LabelIntegrationNamespace: "ksit-system"}
+	applied, err := kyvernoClient.ListClusterPolicies(ctx, selector)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"disallow-latest-tag", "require-labels"}, applied)
+
+	// A policy that is no longer declared is deleted
+	integration.Spec.Kyverno.Policies = []string{requireLabelsPolicy}
+	require.NoError(t, r.applyKyvernoPolicies(ctx, kyvernoClient, integration, "cluster-a"))
+	applied, err = kyvernoClient.ListClusterPolicies(ctx, selector)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"require-labels"}, applied)
+
+	// An Integration of the same name in another namespace neither takes over nor prunes
+	// the policies of this one
+	other := integration.DeepCopy()
+	other.Namespace = "team-b"
+	other.Spec.Kyverno.Policies = []string{requireLabelsPolicy + "---\n" + disallowLatestPolicy}
+	assert.ErrorContains(t, r.applyKyvernoPolicies(ctx, kyvernoClient, other, "cluster-a"), "ClusterPolicy require-labels already exists")
+	other.Spec.Kyverno.Policies = []string{disallowLatestPolicy}
+	require.NoError(t, r.applyKyvernoPolicies(ctx, kyvernoClient, other, "cluster-a"))
+	applied, err = kyvernoClient.ListClusterPolicies(ctx, selector)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"require-labels"}, applied)
+}
+
+func TestPolicyReportSummary(t *testing.T) {
+	summaries := []*kyverno.ReportSummary{
+		{Pass: 10, Fail: 1, FailuresByPolicy: map[string]int{"require-labels": 1}},
+		{Pass: 4, Fail: 5, Warn: 2, FailuresByPolicy: map[string]int{"require-limits": 2, "disallow-latest-tag": 3}},
+		{Pass: 7},
+		nil,
+	}
+
+	out := policyReportSummary([]string{"cluster-a", "cluster-b", "cluster-c", "cluster-d"}, summaries)
+	assert.Equal(t, int32(21), out.Pass)
+	assert.Equal(t, int32(6), out.Fail)
+	assert.Equal(t, int32(2), out.Warn)
+	require.Len(t, out.Clusters, 2, "only clusters with failures are listed")
+	assert.Equal(t, "cluster-b", out.Clusters[0].Cluster)
+	assert.Equal(t, []string{"disallow-latest-tag", "require-limits"}, out.Clusters[0].FailingPolicies)
+	assert.Equal(t, "cluster-a", out.Clusters[1].Cluster)
+}
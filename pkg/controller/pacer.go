@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// maxRequeueInterval caps the interval the pacer stretches requeues to, so even a
+	// large fleet is checked every few minutes
+	maxRequeueInterval = 10 * time.Minute
+	// slowReconcileFactor keeps an Integration from being requeued sooner than this many
+	// times its recent reconcile duration
+	slowReconcileFactor = 10
+	// durationWeight is the weight of the latest reconcile in the moving average of the
+	// reconcile durations of an Integration
+	durationWeight = 0.3
+)
+
+// requeuePacer computes the requeue interval of Integrations from the size of the
+// fleet and their recent reconcile durations. Every reconcile checks each target
+// cluster of the Integration, so the interval grows with the number of Integrations
+// times clusters to keep all checks within the budget per minute. A nil requeuePacer
+// always requeues at the base interval.
+type requeuePacer struct {
+	mu sync.Mutex
+	// budget is how many cluster checks all Integrations may make per minute together;
+	// zero or less keeps the interval at base for any fleet size
+	budget    int
+	clusters  map[types.NamespacedName]int
+	durations map[types.NamespacedName]time.Duration
+}
+
+func newRequeuePacer(budget int) *requeuePacer {
+	return &requeuePacer{
+		budget:    budget,
+		clusters:  make(map[types.NamespacedName]int),
+		durations: make(map[types.NamespacedName]time.Duration),
+	}
+}
+
+// observe records that a reconcile of key checked clusters target clusters in duration,
+// and returns when the Integration should be reconciled next; base is its shortest interval
+func (p *requeuePacer) observe(key types.NamespacedName, base time.Duration, clusters int, duration time.Duration) time.Duration {
+	if p == nil {
+		return base
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// An Integration without target clusters still costs a reconcile
+	if clusters < 1 {
+		clusters = 1
+	}
+	p.clusters[key] = clusters
+	if average, ok := p.durations[key]; ok {
+		duration = time.Duration(durationWeight*float64(duration) + (1-durationWeight)*float64(average))
+	}
+	p.durations[key] = duration
+
+	interval := base
+	if p.budget > 0 {
+		checks := 0
+		for _, n := range p.clusters {
+			checks += n
+		}
+		if fleet := time.Duration(checks) * time.Minute / time.Duration(p.budget); fleet > interval {
+			interval = fleet
+		}
+	}
+	if slow := slowReconcileFactor * duration; slow > interval {
+		interval = slow
+	}
+	if ceiling := max(maxRequeueInterval, base); interval > ceiling {
+		interval = ceiling
+	}
+	return interval
+}
+
+// forget drops a deleted Integration from the fleet
+func (p *requeuePacer) forget(key types.NamespacedName) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.clusters, key)
+	delete(p.durations, key)
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRequeuePacer(t *testing.T) {
+	argocd := types.NamespacedName{Namespace: "ksit-system", Name: "argocd"}
+	flux := types.NamespacedName{Namespace: "ksit-system", Name: "flux"}
+	p := newRequeuePacer(600)
+
+	// A small fleet stays at the base interval
+	assert.Equal(t, 30*time.Second, p.observe(argocd, 30*time.Second, 100, time.Second))
+
+	// 900 cluster checks at 600 per minute take 90s
+	assert.Equal(t, 90*time.Second, p.observe(flux, 30*time.Second, 800, time.Second))
+	assert.Equal(t, 90*time.Second, p.observe(argocd, 30*time.Second, 100, time.Second))
+
+	// A slow Integration is requeued no sooner than 10 times its average reconcile duration
+	assert.Equal(t, 100*time.Second, p.observe(argocd, 30*time.Second, 100, 31*time.Second))
+	assert.Equal(t, maxRequeueInterval, p.observe(argocd, 30*time.Second, 100, 10*time.Minute))
+
+	// Deleted Integrations leave the fleet
+	p.forget(flux)
+	p.forget(argocd)
+	assert.Equal(t, 30*time.Second, p.observe(argocd, 30*time.Second, 100, time.Second))
+
+	// Without a budget, only slow reconciles stretch the interval
+	unbounded := newRequeuePacer(0)
+	assert.Equal(t, 30*time.Second, unbounded.observe(argocd, 30*time.Second, 100000, time.Second))
+
+	// A longer spec.reconcileInterval also raises the ceiling
+	assert.Equal(t, time.Hour, unbounded.observe(flux, time.Hour, 1, time.Hour))
+
+	var disabled *requeuePacer
+	assert.Equal(t, 45*time.Second, disabled.observe(argocd, 45*time.Second, 100000, time.Hour))
+}
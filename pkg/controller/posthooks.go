@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/hooks"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+// hookFieldManager owns the fields of the objects applied by manifests hooks
+const hookFieldManager = "ksit-hooks"
+
+// postInstallHooksBudget bounds the post-install hooks of one cluster in one reconcile,
+// so slow hooks do not hold up the other clusters. Hooks still running then, such as
+// long Jobs, carry on at the next reconcile.
+var postInstallHooksBudget = time.Minute
+
+// postInstallHooks returns the post-install hooks of the Integration
+func postInstallHooks(integration *ksitv1alpha1.Integration) []ksitv1alpha1.PostInstallHook {
+	if install := integration.Spec.AutoInstall; install != nil {
+		return install.PostInstall
+	}
+	return nil
+}
+
+// postInstallHooksFailed reports whether the last post-install hooks on a cluster did
+// not all succeed. Such clusters resume their hooks on every reconcile until they do.
+func postInstallHooksFailed(integration *ksitv1alpha1.Integration, clusterName string) bool {
+	if len(postInstallHooks(integration)) == 0 {
+		return false
+	}
+	for _, result := range integration.Status.PostInstallHooks {
+		if result.Cluster == clusterName {
+			return !result.Succeeded
+		}
+	}
+	return false
+}
+
+// runPostInstallHooks runs the post-install hooks in order on a cluster, retrying each
+// one within postInstallHooksBudget, and records the outcome in the Integration's status.
+// After an install or upgrade every hook runs; when resuming, the hooks that already
+// succeeded on the cluster are skipped, and the Job of a job hook is waited for rather
+// than restarted. It stops at the first hook that still fails and returns its error.
+func (r *IntegrationReconciler) runPostInstallHooks(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName, action string, resume bool) error {
+	succeeded := map[string]ksitv1alpha1.PostInstallHookRun{}
+	if resume {
+		for _, result := range integration.Status.PostInstallHooks {
+			if result.Cluster != clusterName {
+				continue
+			}
+			for _, run := range result.Hooks {
+				if run.Succeeded {
+					succeeded[run.Name] = run
+				}
+			}
+		}
+	}
+
+	budgetCtx, cancel := context.WithTimeout(ctx, postInstallHooksBudget)
+	defer cancel()
+
+	var runs []ksitv1alpha1.PostInstallHookRun
+	var hookErr error
+	for _, hook := range postInstallHooks(integration) {
+		if run, ok := succeeded[hook.Name]; ok {
+			runs = append(runs, run)
+			continue
+		}
+
+		retries := hooks.DefaultRetries
+		if hook.Retries != nil {
+			retries = int(*hook.Retries)
+		}
+		var timeout time.Duration
+		if hook.Timeout != nil {
+			timeout = hook.Timeout.Duration
+		}
+		adopt := resume
+		attempts, message, err := hooks.Retry(budgetCtx, retries, timeout, func(ctx context.Context) (string, error) {
+			// Only the first attempt picks up the Job of an earlier reconcile
+			defer func() { adopt = false }()
+			return r.runPostInstallHook(ctx, integration, clusterName, action, hook, adopt)
+		})
+
+		run := ksitv1alpha1.PostInstallHookRun{Name: hook.Name, Succeeded: err == nil, Attempts: int32(attempts), Message: message}
+		if err != nil && budgetCtx.Err() != nil && ctx.Err() == nil {
+			// Out of time for this reconcile rather than failed
+			run.Message = fmt.Sprintf("still running after %s, resumed at the next reconcile: %v", postInstallHooksBudget, err)
+			runs = append(runs, run)
+			hookErr = fmt.Errorf("hook %s is still running", hook.Name)
+			break
+		}
+		if err != nil {
+			run.Message = err.Error()
+		}
+		runs = append(runs, run)
+		if err != nil {
+			hookErr = fmt.Errorf("hook %s: %w", hook.Name, err)
+			r.event(integration, corev1.EventTypeWarning, "PostInstallHookFailed",
+				fmt.Sprintf("Post-install hook %s failed on cluster %s after %d attempts: %v", hook.Name, clusterName, attempts, err))
+			break
+		}
+		r.Log.Info("post-install hook succeeded", "integration", integration.Name, "cluster", clusterName, "hook", hook.Name, "attempts", attempts)
+	}
+
+	setPostInstallHooksResult(integration, clusterName, runs, hookErr == nil)
+	return hookErr
+}
+
+// runPostInstallHook runs one attempt of a hook. With adopt, a job hook waits for the
+// Job an earlier attempt left running.
+func (r *IntegrationReconciler) runPostInstallHook(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName, action string, hook ksitv1alpha1.PostInstallHook, adopt bool) (string, error) {
+	switch {
+	case hook.Job != nil:
+		c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+		if err != nil {
+			return "", err
+		}
+		return hooks.RunJob(ctx, c, postInstallJob(integration, hook), adopt)
+	case hook.Webhook != nil:
+		var token string
+		if ref := hook.Webhook.TokenSecretRef; ref != "" {
+			secret := &corev1.Secret{}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: integration.Namespace, Name: ref}, secret); err != nil {
+				return "", fmt.Errorf("failed to get webhook token Secret %s: %w", ref, err)
+			}
+			token = string(secret.Data["token"])
+		}
+		event := hooks.Event{
+			Integration: integration.Name,
+			Namespace:   integration.Namespace,
+			Type:        integration.Spec.Type,
+			Cluster:     clusterName,
+			Action:      action,
+			Hook:        hook.Name,
+		}
+		return hooks.CallWebhook(ctx, r.HookClient, hook.Webhook.URL, token, event)
+	case len(hook.Manifests) > 0:
+		c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+		if err != nil {
+			return "", err
+		}
+		return applyHookManifests(ctx, c, integration, hook)
+	default:
+		return "", fmt.Errorf("sets none of job, webhook and manifests")
+	}
+}
+
+// postInstallJob builds the Job of a job hook. KSIT retries failed hooks itself, so the
+// Job does not retry its pod.
+func postInstallJob(integration *ksitv1alpha1.Integration, hook ksitv1alpha1.PostInstallHook) *batchv1.Job {
+	name := "ksit-hook-" + integration.Name + "-" + hook.Name
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	labels := map[string]string{ksitv1alpha1.LabelIntegration: integration.Name}
+	backoffLimit := int32(0)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: installer.InstallNamespace(integration),
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hook.Job.ServiceAccountName,
+					Containers: []corev1.Container{{
+						Name:    hook.Name,
+						Image:   hook.Job.Image,
+						Command: hook.Job.Command,
+						Args:    hook.Job.Args,
+						Env:     hook.Job.Env,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// applyHookManifests server-side applies the manifests of a hook to one cluster.
+// Namespaced objects without a namespace go into the install namespace.
+func applyHookManifests(ctx context.Context, c client.Client, integration *ksitv1alpha1.Integration, hook ksitv1alpha1.PostInstallHook) (string, error) {
+	namespace := installer.InstallNamespace(integration)
+	applied := 0
+	for i, manifest := range hook.Manifests {
+		objs, err := manifests.Decode([]byte(manifest))
+		if err != nil {
+			return "", fmt.Errorf("manifests[%d]: %w", i, err)
+		}
+		for _, obj := range objs {
+			labels := obj.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[ksitv1alpha1.LabelIntegration] = integration.Name
+			obj.SetLabels(labels)
+			if err := setWorkloadNamespace(c, obj, namespace); err != nil {
+				return "", err
+			}
+			if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(hookFieldManager), client.ForceOwnership); err != nil {
+				return "", fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			applied++
+		}
+	}
+	return fmt.Sprintf("applied %d objects", applied), nil
+}
+
+// setPostInstallHooksResult replaces the post-install hook results of a cluster in the
+// Integration's status
+func setPostInstallHooksResult(integration *ksitv1alpha1.Integration, clusterName string, runs []ksitv1alpha1.PostInstallHookRun, succeeded bool) {
+	now := metav1.NewTime(time.Now())
+	result := ksitv1alpha1.PostInstallHooksResult{
+		Cluster:     clusterName,
+		Succeeded:   succeeded,
+		Hooks:       runs,
+		LastRunTime: &now,
+	}
+
+	for i := range integration.Status.PostInstallHooks {
+		if integration.Status.PostInstallHooks[i].Cluster == clusterName {
+			integration.Status.PostInstallHooks[i] = result
+			return
+		}
+	}
+	integration.Status.PostInstallHooks = append(integration.Status.PostInstallHooks, result)
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/hooks"
+)
+
+func TestRunPostInstallHooks(t *testing.T) {
+	healthy := false
+	var events []hooks.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy || r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		event := hooks.Event{}
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hook-token", Namespace: "ksit-system"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	applied := map[string][]string{}
+	r, _ := newWorkloadTargets(t, applied, token)
+	r.HookClient = server.Client()
+
+	noRetries := int32(0)
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafana", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeGrafana,
+			TargetClusters: []string{"cluster-a"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				PostInstall: []ksitv1alpha1.PostInstallHook{
+					{Name: "seed-datasources", Manifests: []string{"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: datasources\n"}},
+					{Name: "notify", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: server.URL, TokenSecretRef: "hook-token"}, Retries: &noRetries},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+
+	err := r.runPostInstallHooks(ctx, integration, "cluster-a", ksitv1alpha1.InstallActionInstall, false)
+	assert.ErrorContains(t, err, "hook notify: webhook answered 503 Service Unavailable")
+	assert.True(t, postInstallHooksFailed(integration, "cluster-a"))
+	require.Len(t, integration.Status.PostInstallHooks, 1)
+	result := integration.Status.PostInstallHooks[0]
+	assert.False(t, result.Succeeded)
+	require.Len(t, result.Hooks, 2)
+	assert.Equal(t, ksitv1alpha1.PostInstallHookRun{Name: "seed-datasources", Succeeded: true, Attempts: 1, Message: "applied 1 objects"}, result.Hooks[0])
+	assert.False(t, result.Hooks[1].Succeeded)
+	assert.Equal(t, []string{"ConfigMap grafana/datasources"}, applied["cluster-a"])
+
+	// Resuming skips the hooks that already succeeded
+	healthy = true
+	require.NoError(t, r.runPostInstallHooks(ctx, integration, "cluster-a", ksitv1alpha1.InstallActionInstall, true))
+	assert.False(t, postInstallHooksFailed(integration, "cluster-a"))
+	assert.Len(t, applied["cluster-a"], 1)
+	assert.Equal(t, []hooks.Event{{
+		Integration: "grafana", Namespace: "ksit-system", Type: ksitv1alpha1.IntegrationTypeGrafana,
+		Cluster: "cluster-a", Action: ksitv1alpha1.InstallActionInstall, Hook: "notify",
+	}}, events)
+
+	// An upgrade runs every hook again
+	require.NoError(t, r.runPostInstallHooks(ctx, integration, "cluster-a", ksitv1alpha1.InstallActionUpgrade, false))
+	assert.Len(t, applied["cluster-a"], 2)
+	require.Len(t, events, 2)
+	assert.Equal(t, ksitv1alpha1.InstallActionUpgrade, events[1].Action)
+}
+
+func TestPostInstallJob(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("argocd", 10), Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:        ksitv1alpha1.IntegrationTypeArgoCD,
+			AutoInstall: &ksitv1alpha1.InstallConfig{Namespace: &ksitv1alpha1.InstallNamespaceConfig{Name: "gitops"}},
+		},
+	}
+	hook := ksitv1alpha1.PostInstallHook{Name: "rotate-admin", Job: &ksitv1alpha1.PostInstallJob{Image: "bitnami/kubectl", Args: []string{"rollout"}}}
+
+	job := postInstallJob(integration, hook)
+	assert.Len(t, job.Name, 63)
+	assert.True(t, strings.HasPrefix(job.Name, "ksit-hook-argocd"))
+	assert.Equal(t, "gitops", job.Namespace)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	require.Len(t, job.Spec.Template.Spec.Containers, 1)
+	assert.Equal(t, "bitnami/kubectl", job.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+}
+
+func TestHandleAutoInstallContinuesAfterFailedHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	inst := newRecordingInstaller()
+	integration := autoInstallIntegration()
+	noRetries := int32(0)
+	integration.Spec.AutoInstall.PostInstall = []ksitv1alpha1.PostInstallHook{
+		{Name: "notify", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: server.URL}, Retries: &noRetries},
+	}
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+	r.HookClient = server.Client()
+
+	// The failed hook on cluster-a does not keep cluster-b from being installed
+	err := r.handleAutoInstall(ctx, integration)
+	assert.ErrorContains(t, err, "post-install hook failed on cluster cluster-a")
+	assert.ErrorContains(t, err, "post-install hook failed on cluster cluster-b")
+	assert.Equal(t, []string{hosts["cluster-a"], hosts["cluster-b"]}, inst.installs)
+	assert.True(t, postInstallHooksFailed(integration, "cluster-a"))
+	assert.True(t, postInstallHooksFailed(integration, "cluster-b"))
+}
+
+func TestRunPostInstallHooksBudget(t *testing.T) {
+	defer func(budget time.Duration) { postInstallHooksBudget = budget }(postInstallHooksBudget)
+	postInstallHooksBudget = 20 * time.Millisecond
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	r, _ := newWorkloadTargets(t, map[string][]string{})
+	r.HookClient = server.Client()
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafana", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeGrafana,
+			TargetClusters: []string{"cluster-a"},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled:     true,
+				PostInstall: []ksitv1alpha1.PostInstallHook{{Name: "slow", Webhook: &ksitv1alpha1.PostInstallWebhook{URL: server.URL}}},
+			},
+		},
+	}
+
+	// A hook that outlasts the budget is left for the next reconcile, not reported as failed
+	err := r.runPostInstallHooks(context.Background(), integration, "cluster-a", ksitv1alpha1.InstallActionInstall, false)
+	assert.EqualError(t, err, "hook slow is still running")
+	require.Len(t, integration.Status.PostInstallHooks, 1)
+	run := integration.Status.PostInstallHooks[0].Hooks[0]
+	assert.False(t, run.Succeeded)
+	assert.Contains(t, run.Message, "resumed at the next reconcile")
+	assert.True(t, postInstallHooksFailed(integration, "cluster-a"))
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+const (
+	// defaultConnectivityProbeInterval is how often the clusters that are not connected
+	// yet are probed again
+	defaultConnectivityProbeInterval = 10 * time.Second
+	// connectivityProbeTimeout bounds the probe of one cluster
+	connectivityProbeTimeout = 10 * time.Second
+	// defaultConnectivityStartupTimeout is how long the gate waits for the clusters
+	// before it opens anyway
+	defaultConnectivityStartupTimeout = 5 * time.Minute
+	// maxListedClusters bounds how many missing clusters the readyz check names
+	maxListedClusters = 5
+)
+
+// ConnectivityGate keeps the controller not ready until it has connected to enough of
+// the clusters that enabled Integrations target, so rollout tooling does not send
+// traffic to, or finish a rollout on, a replica that cannot see the fleet. Once the
+// threshold is met the gate stays open: losing clusters later is reported on the
+// Integrations rather than by taking the replica out of service.
+//
+// Like ClusterWarmup it runs on every replica, so standby replicas become ready too.
+type ConnectivityGate struct {
+	Client         client.Reader
+	ClusterManager *cluster.ClusterManager
+	Warmup         *ClusterWarmup
+	Log            logr.Logger
+	// MinConnectedPercent is the share of the targeted clusters that must be connected,
+	// from 1 to 100
+	MinConnectedPercent int
+	// ProbeInterval defaults to defaultConnectivityProbeInterval
+	ProbeInterval time.Duration
+	// StartupTimeout is how long after the warm-up the gate opens even if the threshold
+	// is not met, so unreachable clusters cannot keep the webhooks out of service;
+	// defaults to defaultConnectivityStartupTimeout
+	StartupTimeout time.Duration
+
+	// connected are the clusters that answered, by namespace/name; only probe uses it
+	connected map[string]bool
+
+	mu      sync.Mutex
+	open    bool
+	probed  bool
+	missing []string
+	total   int
+}
+
+// Start probes the targeted clusters after the warm-up until the threshold is met or
+// the startup timeout passes
+func (g *ConnectivityGate) Start(ctx context.Context) error {
+	if err := g.Warmup.Wait(ctx); err != nil {
+		return nil
+	}
+	interval := g.ProbeInterval
+	if interval <= 0 {
+		interval = defaultConnectivityProbeInterval
+	}
+	timeout := g.StartupTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectivityStartupTimeout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		open, err := g.probe(ctx)
+		if err != nil {
+			g.Log.Error(err, "failed to probe targeted clusters")
+		}
+		if open {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-deadline.C:
+			g.mu.Lock()
+			g.open = true
+			missing := g.missing
+			g.mu.Unlock()
+			g.Log.Info("opening the connectivity gate after the startup timeout", "timeout", timeout.String(), "notConnected", missing)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection is false so that standby replicas become ready
+func (g *ConnectivityGate) NeedLeaderElection() bool {
+	return false
+}
+
+// probe connects to the targeted clusters that are not connected yet, in parallel, and
+// opens the gate once enough of them are. Clusters no IntegrationTarget has registered do
+// not count, as no replica can connect to them.
+func (g *ConnectivityGate) probe(ctx context.Context) (bool, error) {
+	targeted, err := g.targetedClusters(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	seen := map[string]bool{}
+	var registered []*cluster.Cluster
+	for _, ref := range targeted {
+		c, err := g.ClusterManager.GetIntegrationCluster(ref.name, ref.integration)
+		if err != nil {
+			g.Log.V(1).Info("targeted cluster not registered", "cluster", ref.name, "namespace", ref.integration.Namespace, "error", err.Error())
+			continue
+		}
+		if key := c.Namespace + "/" + c.Name; !seen[key] {
+			seen[key] = true
+			registered = append(registered, c)
+		}
+	}
+
+	errs := make([]error, len(registered))
+	sem := make(chan struct{}, defaultMaxConcurrentClusters)
+	var wg sync.WaitGroup
+	for i, c := range registered {
+		if g.connected[c.Namespace+"/"+c.Name] {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c *cluster.Cluster) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = g.connect(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var missing []string
+	for i, c := range registered {
+		if errs[i] != nil {
+			g.Log.V(1).Info("targeted cluster not connected", "cluster", c.Name, "namespace", c.Namespace, "error", errs[i].Error())
+			missing = append(missing, c.Name)
+			continue
+		}
+		if g.connected == nil {
+			g.connected = map[string]bool{}
+		}
+		g.connected[c.Namespace+"/"+c.Name] = true
+	}
+	sort.Strings(missing)
+
+	connected := len(registered) - len(missing)
+	open := connected*100 >= g.MinConnectedPercent*len(registered)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.probed, g.open, g.missing, g.total = true, open, missing, len(registered)
+	if open {
+		g.Log.Info("connected to targeted clusters", "connected", connected, "targeted", len(registered))
+	}
+	return open, nil
+}
+
+// clusterRef is a cluster targeted by an Integration
+type clusterRef struct {
+	name        string
+	integration *ksitv1alpha1.Integration
+}
+
+// targetedClusters returns the clusters targeted by enabled Integrations, once each,
+// leaving out paused ones
+func (g *ConnectivityGate) targetedClusters(ctx context.Context) ([]clusterRef, error) {
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := g.Client.List(ctx, integrations); err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var targeted []clusterRef
+	for i := range integrations.Items {
+		integration := &integrations.Items[i]
+		if !integration.Spec.Enabled {
+			continue
+		}
+		for _, name := range integration.Targets() {
+			key := integration.Namespace + "/" + name
+			if g.ClusterManager.ClusterScoped(integration) {
+				key = "*/" + name
+			}
+			if seen[key] || slices.Contains(integration.Spec.PausedClusters, name) {
+				continue
+			}
+			seen[key] = true
+			targeted = append(targeted, clusterRef{name: name, integration: integration})
+		}
+	}
+	return targeted, nil
+}
+
+// connect reports whether the API server of a registered cluster answers
+func (g *ConnectivityGate) connect(c *cluster.Cluster) error {
+	config, err := g.ClusterManager.GetClusterConfig(c.Name, c.Namespace)
+	if err != nil {
+		return err
+	}
+	// Discovery calls take no context, so the probe is bounded by the client timeout
+	config = rest.CopyConfig(config)
+	config.Timeout = connectivityProbeTimeout
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	if _, err := dc.ServerVersion(); err != nil {
+		return fmt.Errorf("failed to reach API server: %w", err)
+	}
+	return nil
+}
+
+// Checker is a readyz check that fails until the gate is open
+func (g *ConnectivityGate) Checker(_ *http.Request) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case g.open:
+		return nil
+	case !g.probed:
+		return fmt.Errorf("targeted clusters are not probed yet")
+	}
+	missing := g.missing
+	more := ""
+	if len(missing) > maxListedClusters {
+		more = fmt.Sprintf(" and %d more", len(missing)-maxListedClusters)
+		missing = missing[:maxListedClusters]
+	}
+	return fmt.Errorf("connected to %d of %d targeted clusters, %d%% required; not connected: %s%s",
+		g.total-len(g.missing), g.total, g.MinConnectedPercent, strings.Join(missing, ", "), more)
+}
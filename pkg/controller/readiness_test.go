@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func TestConnectivityGate(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"28","gitVersion":"v1.28.0"}`))
+	}))
+	defer apiServer.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type: ksitv1alpha1.IntegrationTypeArgoCD, Enabled: true,
+				TargetClusters: []string{"hub", "edge", "maintenance"},
+				PausedClusters: []string{"maintenance"},
+			},
+		},
+		&ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "ksit-system"},
+			Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeFlux, Enabled: true, TargetClusters: []string{"unregistered"}},
+		},
+	).Build()
+	cm := cluster.NewClusterManager(c)
+	require.NoError(t, cm.AddCluster("hub", "ksit-system", testKubeconfig(apiServer.URL)))
+	require.NoError(t, cm.AddCluster("edge", "ksit-system", testKubeconfig(down.URL)))
+
+	gate := &ConnectivityGate{Client: c, ClusterManager: cm, Log: logr.Discard(), MinConnectedPercent: 100}
+	assert.EqualError(t, gate.Checker(nil), "targeted clusters are not probed yet")
+
+	// No replica can reach a cluster no IntegrationTarget registered, so it does not count
+	open, err := gate.probe(context.Background())
+	require.NoError(t, err)
+	assert.False(t, open)
+	assert.EqualError(t, gate.Checker(nil), "connected to 1 of 2 targeted clusters, 100% required; not connected: edge")
+
+	// The gate opens after the startup timeout even though edge is still down
+	timedOut := &ConnectivityGate{Client: c, ClusterManager: cm, Log: logr.Discard(), MinConnectedPercent: 100,
+		ProbeInterval: time.Hour, StartupTimeout: 10 * time.Millisecond}
+	require.NoError(t, timedOut.Start(context.Background()))
+	assert.NoError(t, timedOut.Checker(nil))
+
+	// Paused clusters do not count, so half is enough here
+	gate.MinConnectedPercent = 50
+	open, err = gate.probe(context.Background())
+	require.NoError(t, err)
+	assert.True(t, open)
+	assert.NoError(t, gate.Checker(nil))
+	require.NoError(t, gate.Start(context.Background()))
+}
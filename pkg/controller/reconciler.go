@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -12,15 +14,27 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/internal/utils"
 	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/conditions"
+	"github.com/kubestellar/integration-toolkit/pkg/health"
 	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/factory"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
 	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+	"github.com/kubestellar/integration-toolkit/pkg/kubestellar"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/slo"
+	"github.com/kubestellar/integration-toolkit/pkg/template"
+	"github.com/kubestellar/integration-toolkit/pkg/version"
 )
 
 const (
@@ -35,12 +49,71 @@ type IntegrationReconciler struct {
 	ClusterManager   *cluster.ClusterManager
 	ClusterInventory *cluster.ClusterInventory
 	InstallerFactory *installer.InstallerFactory
+	// Ledger records installs as InstalledComponent objects; optional
+	Ledger *ledger.Ledger
+	// Clients builds integration clients for target clusters; defaults to one backed by ClusterManager
+	Clients *factory.Factory
+	// Recorder records events on Integrations; optional
+	Recorder record.EventRecorder
+	// Handlers checks and cleans up each integration type; defaults to NewHandlerRegistry
+	Handlers *HandlerRegistry
+	// MaxConcurrentClusters bounds how many target clusters of an Integration are checked
+	// at once. Defaults to defaultMaxConcurrentClusters.
+	MaxConcurrentClusters int
+	// Retry retries transient errors of the checks on target clusters; nil does not retry
+	Retry *utils.RetryConfig
+	// RetryBudget bounds the retries shared by all target clusters of one reconcile of an
+	// Integration. Zero or less does not bound them.
+	RetryBudget int
+	// WDS reads the KubeStellar Bindings named by spec.kubeStellar; nil leaves delivery unreported
+	WDS *kubestellar.KubeStellarClient
+	// ITS reads the WorkStatuses reported by KubeStellar WECs; defaults to WDS
+	ITS *kubestellar.KubeStellarClient
+	// ClusterScopeNamespaces are the namespaces whose Integrations may use Cluster scope
+	// and target clusters registered in any namespace
+	ClusterScopeNamespaces []string
+	// HealthSummaryInterval is how often the health of each Integration is summed up in
+	// one log line. Defaults to defaultHealthSummaryInterval.
+	HealthSummaryInterval time.Duration
+	// Warmup is waited for before the first reconcile so that target clusters are
+	// registered; nil does not wait
+	Warmup *ClusterWarmup
+	// HookClient calls the webhooks of post-install hooks; defaults to a client that
+	// refuses loopback and link-local addresses
+	HookClient *http.Client
+	// FailureBackoff spaces out the retries of an Integration whose reconciles keep
+	// failing; only its delays and jitter are used. Nil retries at the fixed interval.
+	FailureBackoff *utils.RetryConfig
+	// Interval is how often an Integration is reconciled when nothing changes, unless its
+	// spec.reconcileInterval is set. Defaults to requeueInterval.
+	Interval time.Duration
+	// RequeueBudget bounds how many target cluster checks the periodic reconciles of all
+	// Integrations make per minute together; larger fleets are requeued less often. Zero
+	// or less requeues every Integration at the fixed interval.
+	RequeueBudget int
+
+	statusBatcher *statusBatcher
+	sloTracker    *slo.Tracker
+	// breaker skips target clusters that keep failing; nil checks every cluster every time
+	breaker *cluster.CircuitBreaker
+	// healthLog keeps unchanged health check results out of the info log; nil logs them all
+	healthLog *healthLog
+	// pacer stretches the requeue interval as the fleet grows; nil requeues at the interval
+	// of the Integration
+	pacer *requeuePacer
+	// backoff spaces out the retries of failing Integrations; nil retries at the interval
+	// of the Integration
+	backoff *failureBackoff
 }
 
 func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("integration", req.NamespacedName)
 	log.Info("reconciling integration")
 
+	if err := r.Warmup.Wait(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	startTime := time.Now()
 
 	integration := &ksitv1alpha1.Integration{}
@@ -51,16 +124,16 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	// ✅ USE CLUSTER INVENTORY: Track clusters
-	for _, clusterName := range integration.Spec.TargetClusters {
-		clusterInfo, err := r.ClusterInventory.GetCluster(clusterName)
-		if err != nil {
-			// Cluster not in inventory, add it
-			r.ClusterInventory.AddCluster(clusterName, integration.Namespace, string(cluster.ClusterStatusActive))
-			log.Info("added cluster to inventory", "cluster", clusterName)
-		} else {
-			// Update last seen time
-			clusterInfo.LastSeen = time.Now()
+	// Clusters picked by spec.targetSelector count as listed ones for the rest of the reconcile
+	targets, selected, err := r.resolveTargets(ctx, integration)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	ctx = withTargets(ctx, integration, targets)
+
+	// Inventory membership belongs to IntegrationTargets; only mark the clusters as seen
+	for _, clusterName := range targets {
+		if clusterInfo, err := r.ClusterInventory.GetCluster(clusterName); err == nil {
 			r.ClusterInventory.UpdateCluster(clusterInfo)
 		}
 	}
@@ -68,40 +141,66 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// Handle deletion
 	if !integration.ObjectMeta.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(integration, integrationFinalizer) {
-			if err := r.cleanupIntegration(ctx, integration); err != nil {
-				return ctrl.Result{}, err
-			}
-
-			// ✅ REMOVE CLUSTERS FROM INVENTORY
-			for _, clusterName := range integration.Spec.TargetClusters {
-				r.ClusterInventory.RemoveCluster(clusterName)
-				log.Info("removed cluster from inventory", "cluster", clusterName)
+			done, result, err := r.finalizeIntegration(ctx, integration)
+			if err != nil || !done {
+				return result, err
 			}
 
+			patch := client.MergeFrom(integration.DeepCopy())
 			controllerutil.RemoveFinalizer(integration, integrationFinalizer)
-			if err := r.Update(ctx, integration); err != nil {
+			if err := r.Patch(ctx, integration, patch); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
+		r.statusBatcher.forget(req.NamespacedName)
+		r.sloTracker.Forget(req.NamespacedName)
+		prometheus.DeleteSLO(integration.Name, integration.Spec.Type)
+		prometheus.DeleteHealthScore(integration.Name, integration.Spec.Type)
+		if r.breaker != nil {
+			r.breaker.Forget(circuitKey(integration, ""))
+		}
+		prometheus.DeleteCircuits(integration.Name)
+		r.healthLog.forget(circuitKey(integration, ""))
+		r.pacer.forget(req.NamespacedName)
+		r.backoff.reset(req.NamespacedName)
+		prometheus.DeleteRequeueInterval(integration.Name, integration.Spec.Type)
+		r.ClusterManager.ForgetIntegration(integration)
 		return ctrl.Result{}, nil
 	}
 
 	// Add finalizer if not present
 	if !controllerutil.ContainsFinalizer(integration, integrationFinalizer) {
+		patch := client.MergeFrom(integration.DeepCopy())
 		controllerutil.AddFinalizer(integration, integrationFinalizer)
-		if err := r.Update(ctx, integration); err != nil {
+		if err := r.Patch(ctx, integration, patch); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	// Skip if disabled
+	// Uninstall from the clusters an operator asked for before anything else touches them
+	if err := r.handleUninstallRequests(ctx, integration); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// From here on, paused clusters are left alone
+	active, paused := pauseClusters(integration, targets)
+	ctx = withTargets(ctx, integration, active)
+
+	// Skip if disabled. Disabled Integrations are not requeued and leave the fleet the
+	// requeue interval is paced for.
 	if !integration.Spec.Enabled {
-		integration.Status.Phase = ksitv1alpha1.PhaseFailed
-		integration.Status.Message = "Integration is disabled"
+		r.pacer.forget(req.NamespacedName)
+		r.backoff.reset(req.NamespacedName)
+		failed := r.disableIntegration(ctx, integration)
+		markReconcileHandled(integration)
+		r.recordSLO(integration, slo.PhaseDisabled)
 		if err := r.Status().Update(ctx, integration); err != nil {
 			r.Log.Error(err, "failed to update status for disabled integration")
 			return ctrl.Result{}, err
 		}
+		if len(failed) > 0 {
+			return ctrl.Result{RequeueAfter: cleanupRetryInterval}, nil
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -114,6 +213,11 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Status changes below are written in a single batched patch
+	before := integration.DeepCopy()
+	integration.Status.SelectedClusters = selected
+	integration.Status.PausedClusters = paused
+
 	// Handle auto-installation if enabled
 	if integration.Spec.AutoInstall != nil && integration.Spec.AutoInstall.Enabled {
 		log.Info("auto-install enabled, checking installation status")
@@ -123,27 +227,29 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			log.Error(installErr, "auto-install failed")
 			integration.Status.Phase = ksitv1alpha1.PhaseFailed
 			integration.Status.Message = fmt.Sprintf("Auto-install failed: %v", installErr)
-			if err := r.Status().Update(ctx, integration); err != nil {
+			meta.SetStatusCondition(&integration.Status.Conditions, conditions.Failed(ksitv1alpha1.ConditionTypeReady, installErr, "InstallFailed"))
+			markReconcileHandled(integration)
+			r.recordSLO(integration, integration.Status.Phase)
+			if _, err := r.writeStatus(ctx, before, integration); err != nil {
 				log.Error(err, "failed to update status after auto-install failure")
 			}
-			return ctrl.Result{RequeueAfter: requeueInterval}, installErr
+			// The failure is in the status; returning it too would requeue a second time
+			return ctrl.Result{RequeueAfter: r.retryAfter(req.NamespacedName, integration, installErr)}, nil
 		}
 		log.Info("auto-install completed successfully")
 	}
 
+	// Push spec.workloads; failures are reported in the WorkloadsApplied condition
+	r.reconcileWorkloads(ctx, integration)
+
+	// Read back what KubeStellar delivered; reported in the Delivered condition
+	r.reconcileDelivery(ctx, integration)
+
 	// Reconcile based on type
-	var reconcileErr error
-	switch integration.Spec.Type {
-	case ksitv1alpha1.IntegrationTypeArgoCD:
-		reconcileErr = r.reconcileArgoCD(ctx, integration)
-	case ksitv1alpha1.IntegrationTypeFlux:
-		reconcileErr = r.reconcileFlux(ctx, integration)
-	case ksitv1alpha1.IntegrationTypePrometheus:
-		reconcileErr = r.reconcilePrometheus(ctx, integration)
-	case ksitv1alpha1.IntegrationTypeIstio:
-		reconcileErr = r.reconcileIstio(ctx, integration)
-	default:
-		reconcileErr = fmt.Errorf("unsupported integration type: %s", integration.Spec.Type)
+	handler, reconcileErr := r.handlers().GetHandler(integration.Spec.Type)
+	if reconcileErr == nil {
+		log.Info("reconciling integration", "type", integration.Spec.Type)
+		reconcileErr = handler.Reconcile(ctx, integration)
 	}
 
 	// Record reconcile duration
@@ -154,6 +260,7 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	now := metav1.Now()
 	integration.Status.LastReconcileTime = &now
 	integration.Status.ObservedGeneration = integration.Generation
+	markReconcileHandled(integration)
 
 	if reconcileErr != nil {
 		integration.Status.Phase = ksitv1alpha1.PhaseFailed
@@ -161,7 +268,7 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		prometheus.RecordReconcile(integration.Name, integration.Spec.Type, "failed")
 
 		// ✅ UPDATE INVENTORY: Mark clusters as error
-		for _, clusterName := range integration.Spec.TargetClusters {
+		for _, clusterName := range active {
 			clusterInfo, _ := r.ClusterInventory.GetCluster(clusterName)
 			if clusterInfo != nil {
 				clusterInfo.Status = string(cluster.ClusterStatusError)
@@ -169,19 +276,14 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
-		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
-			Type:    ksitv1alpha1.ConditionTypeReady,
-			Status:  metav1.ConditionFalse,
-			Reason:  "ReconcileFailed",
-			Message: reconcileErr.Error(),
-		})
+		meta.SetStatusCondition(&integration.Status.Conditions, conditions.Failed(ksitv1alpha1.ConditionTypeReady, reconcileErr, ksitv1alpha1.ReasonReconcileFailed))
 	} else {
 		integration.Status.Phase = ksitv1alpha1.PhaseRunning
 		integration.Status.Message = "Integration is running"
 		prometheus.RecordReconcile(integration.Name, integration.Spec.Type, "success")
 
 		// ✅ UPDATE INVENTORY: Mark clusters as active
-		for _, clusterName := range integration.Spec.TargetClusters {
+		for _, clusterName := range active {
 			clusterInfo, _ := r.ClusterInventory.GetCluster(clusterName)
 			if clusterInfo != nil {
 				clusterInfo.Status = string(cluster.ClusterStatusActive)
@@ -197,24 +299,72 @@ func (r *IntegrationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			Message: "Integration is healthy",
 		})
 	}
+	r.recordSLO(integration, integration.Status.Phase)
+	r.scoreHealth(ctx, integration)
 
-	if err := r.Status().Update(ctx, integration); err != nil {
+	flushAfter, err := r.writeStatus(ctx, before, integration)
+	if err != nil {
 		r.Log.Error(err, "failed to update integration status")
 		return ctrl.Result{}, err
 	}
 
-	// ✅ CLEANUP STALE CLUSTERS FROM INVENTORY (every hour)
-	go func() {
-		time.Sleep(1 * time.Hour)
-		r.ClusterInventory.CleanupStale(24 * time.Hour)
-		log.Info("cleaned up stale clusters from inventory")
-	}()
+	// Back off as the fleet grows, so hub and target API servers are not overwhelmed
+	base := r.reconcileInterval(integration)
+	interval := r.pacer.observe(req.NamespacedName, base, len(active), time.Since(startTime))
+	if reconcileErr != nil {
+		interval = r.retryAfter(req.NamespacedName, integration, reconcileErr)
+	} else {
+		r.backoff.reset(req.NamespacedName)
+	}
+	prometheus.SetRequeueInterval(integration.Name, integration.Spec.Type, interval.Seconds())
+
+	if flushAfter > 0 && flushAfter < interval {
+		// A deferred status change is flushed on the next reconcile
+		return ctrl.Result{RequeueAfter: flushAfter}, nil
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
 
-	return ctrl.Result{RequeueAfter: requeueInterval}, nil
+// reconcileInterval returns how often an integration is reconciled when nothing changes
+func (r *IntegrationReconciler) reconcileInterval(integration *ksitv1alpha1.Integration) time.Duration {
+	if interval := integration.Spec.ReconcileInterval; interval != nil && interval.Duration > 0 {
+		return interval.Duration
+	}
+	if r.Interval > 0 {
+		return r.Interval
+	}
+	return requeueInterval
+}
+
+// retryAfter returns when to reconcile an integration that failed with err. Failures that
+// may clear on their own are retried with backoff; the others, such as missing CRDs or
+// rejected credentials, wait for the integration's interval, as retrying sooner cannot help.
+func (r *IntegrationReconciler) retryAfter(key types.NamespacedName, integration *ksitv1alpha1.Integration, err error) time.Duration {
+	if !conditions.Retryable(err) {
+		return r.reconcileInterval(integration)
+	}
+	return r.backoff.failed(key, r.reconcileInterval(integration))
+}
+
+// recordSLO records the phase a reconcile ended in and publishes the updated
+// service level indicators in the status and as metrics
+func (r *IntegrationReconciler) recordSLO(integration *ksitv1alpha1.Integration, phase string) {
+	key := types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace}
+	integration.Status.SLO = r.sloTracker.Observe(key, integration.Status.SLO, phase, time.Now())
+	prometheus.SetSLO(integration.Name, integration.Spec.Type, &integration.Status.SLO.Current)
+}
+
+// markReconcileHandled records that a pending force-reconcile request has been processed
+// and which controller build processed it
+func markReconcileHandled(integration *ksitv1alpha1.Integration) {
+	integration.Status.ReconciledBy = version.Get().String()
+	if requestedAt, ok := integration.Annotations[ksitv1alpha1.ReconcileRequestAnnotation]; ok {
+		integration.Status.LastHandledReconcileAt = requestedAt
+	}
 }
 
-func (r *IntegrationReconciler) reconcileArgoCD(ctx context.Context, integration *ksitv1alpha1.Integration) error {
-	r.Log.Info("reconciling ArgoCD integration", "name", integration.Name)
+// checkArgoCD is the ArgoCD health check on one target cluster
+func (r *IntegrationReconciler) checkArgoCD(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
 	startTime := time.Now()
 
 	// Get namespace from config or use default
@@ -223,39 +373,41 @@ func (r *IntegrationReconciler) reconcileArgoCD(ctx context.Context, integration
 		namespace = "argocd"
 	}
 
-	// Health check for each target cluster using Kubernetes API
-	for _, clusterName := range integration.Spec.TargetClusters {
-		r.Log.Info("checking ArgoCD health on cluster", "cluster", clusterName)
+	r.Log.V(1).Info("checking ArgoCD health on cluster", "cluster", clusterName)
 
-		// Get cluster configuration
-		clusterConfig, err := r.ClusterManager.GetClusterConfig(clusterName, integration.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get cluster config for %s: %w", clusterName, err)
-		}
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
 
-		// Create clientset for target cluster
-		clientset, err := kubernetes.NewForConfig(clusterConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create clientset for %s: %w", clusterName, err)
-		}
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return err
+	}
 
-		// ✅ Health Check 1: Namespace exists
-		_, err = clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("ArgoCD namespace %s not found on %s: %w", namespace, clusterName, err)
-		}
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return err
+	}
 
-		// ✅ Health Check 2: ArgoCD server deployment is healthy
+	// ✅ Health Check 2: ArgoCD server deployment is healthy
+	err = runCheck(ctx, "server-deployment", func(ctx context.Context) error {
 		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, "argocd-server", metav1.GetOptions{})
 		if err != nil {
 			return fmt.Errorf("ArgoCD server deployment not found on %s: %w", clusterName, err)
 		}
-
 		if deployment.Status.AvailableReplicas == 0 {
 			return fmt.Errorf("ArgoCD server has 0 available replicas on %s", clusterName)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// ✅ Health Check 3: ArgoCD server service has endpoints
+	// ✅ Health Check 3: ArgoCD server service has endpoints
+	err = runCheck(ctx, "server-endpoints", func(ctx context.Context) error {
 		endpoints, err := clientset.CoreV1().Endpoints(namespace).Get(ctx, "argocd-server", metav1.GetOptions{})
 		if err != nil {
 			return fmt.Errorf("ArgoCD server endpoints not found on %s: %w", clusterName, err)
@@ -265,45 +417,45 @@ func (r *IntegrationReconciler) reconcileArgoCD(ctx context.Context, integration
 		for _, subset := range endpoints.Subsets {
 			totalEndpoints += len(subset.Addresses)
 		}
-
 		if totalEndpoints == 0 {
 			return fmt.Errorf("ArgoCD server service has no endpoints on %s", clusterName)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// ✅ Health Check 4: Check critical ArgoCD components
-		criticalComponents := []string{
-			"argocd-server",
-			"argocd-repo-server",
-			"argocd-application-controller",
-		}
-
-		for _, componentName := range criticalComponents {
-			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, componentName, metav1.GetOptions{})
-			if err != nil {
-				r.Log.Info("ArgoCD component not found", "component", componentName, "cluster", clusterName)
-				continue
-			}
+	// ✅ Health Check 4: Check critical ArgoCD components
+	criticalComponents := []string{
+		"argocd-server",
+		"argocd-repo-server",
+		"argocd-application-controller",
+	}
 
-			if deploy.Status.AvailableReplicas > 0 {
-				r.Log.Info("ArgoCD component is healthy",
-					"component", componentName,
-					"cluster", clusterName,
-					"replicas", deploy.Status.AvailableReplicas)
-			}
+	for _, componentName := range criticalComponents {
+		deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, componentName, metav1.GetOptions{})
+		if err != nil {
+			r.logComponent(integration, clusterName, componentName, false, "reason", "not found")
+			continue
 		}
+		r.logComponent(integration, clusterName, componentName, deploy.Status.AvailableReplicas > 0,
+			"replicas", deploy.Status.AvailableReplicas)
+	}
 
-		// ✅ Health Check 5: Verify ArgoCD pods are running
-		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: "app.kubernetes.io/name",
-		})
-		if err == nil {
+	// ✅ Health Check 5: Verify ArgoCD pods are running
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name",
+	})
+	if err == nil {
+		err = runCheck(ctx, "pods", func(ctx context.Context) error {
 			runningPods := 0
 			for _, pod := range pods.Items {
 				if pod.Status.Phase == corev1.PodRunning {
 					runningPods++
 				}
 			}
-			r.Log.Info("ArgoCD pods status",
+			r.Log.V(1).Info("ArgoCD pods status",
 				"cluster", clusterName,
 				"total", len(pods.Items),
 				"running", runningPods)
@@ -311,77 +463,127 @@ func (r *IntegrationReconciler) reconcileArgoCD(ctx context.Context, integration
 			if runningPods == 0 {
 				return fmt.Errorf("no ArgoCD pods are running on %s", clusterName)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
+	}
 
-		latency := time.Since(startTime).Seconds()
-		prometheus.RecordSyncLatency(integration.Name, clusterName, latency)
-		prometheus.RecordSyncOperation(integration.Name, clusterName, "success")
-		r.Log.Info("✅ ArgoCD integration is healthy", "cluster", clusterName)
+	// ✅ Applications sync, when enabled
+	if err := r.syncArgoCDApps(ctx, integration, clusterName); err != nil {
+		return err
 	}
 
+	latency := time.Since(startTime).Seconds()
+	prometheus.RecordSyncLatency(integration.Name, clusterName, latency)
+	prometheus.RecordSyncOperation(integration.Name, clusterName, "success")
+	r.Log.V(1).Info("✅ ArgoCD integration is healthy", "cluster", clusterName)
 	return nil
 }
 
 func (r *IntegrationReconciler) reconcileFlux(ctx context.Context, integration *ksitv1alpha1.Integration) error {
-	r.Log.Info("reconciling Flux integration", "name", integration.Name)
+	type fluxCheck struct {
+		statuses   []ksitv1alpha1.FluxResourceStatus
+		rootCauses []string
+	}
+	checks, err := collectClusters(ctx, r, integration, func(ctx context.Context, clusterName string) (fluxCheck, error) {
+		statuses, rootCauses, err := r.checkFlux(ctx, integration, clusterName)
+		return fluxCheck{statuses: statuses, rootCauses: rootCauses}, err
+	})
+
+	var rootCauses []string
+	var fluxStatuses []ksitv1alpha1.FluxResourceStatus
+	for _, check := range checks {
+		fluxStatuses = append(fluxStatuses, check.statuses...)
+		rootCauses = append(rootCauses, check.rootCauses...)
+	}
+
+	integration.Status.FluxResources = fluxStatuses
+	if err != nil {
+		return err
+	}
+
+	if len(rootCauses) > 0 {
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypeDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  "KustomizationNotReady",
+			Message: strings.Join(rootCauses, "; "),
+		})
+	} else {
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:    ksitv1alpha1.ConditionTypeDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  "KustomizationsReady",
+			Message: "All Kustomizations are ready",
+		})
+	}
+
+	return nil
+}
 
+// checkFlux is the Flux health check on one target cluster. It also returns the status of
+// the resources declared in spec.flux and the broken Kustomization dependency chains.
+func (r *IntegrationReconciler) checkFlux(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) ([]ksitv1alpha1.FluxResourceStatus, []string, error) {
 	namespace := integration.Spec.Config["namespace"]
 	if namespace == "" {
 		namespace = "flux-system"
 	}
 
-	// Health check for each target cluster using Kubernetes API
-	for _, clusterName := range integration.Spec.TargetClusters {
-		r.Log.Info("checking Flux health on cluster", "cluster", clusterName)
+	r.Log.V(1).Info("checking Flux health on cluster", "cluster", clusterName)
 
-		// Get cluster configuration
-		clusterConfig, err := r.ClusterManager.GetClusterConfig(clusterName, integration.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get cluster config for %s: %w", clusterName, err)
-		}
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
 
-		// Create clientset for target cluster
-		clientset, err := kubernetes.NewForConfig(clusterConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create clientset for %s: %w", clusterName, err)
-		}
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return nil, nil, err
+	}
 
-		// ✅ Health Check 1: Namespace exists
-		_, err = clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("Flux namespace %s not found on %s: %w", namespace, clusterName, err)
-		}
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return nil, nil, err
+	}
 
-		// ✅ Health Check 2: Flux controllers are running
-		fluxControllers := []string{
-			"source-controller",
-			"kustomize-controller",
-			"helm-controller",
-			"notification-controller",
-		}
+	// ✅ Health Check 2: Flux controllers are running
+	fluxControllers := []string{
+		"source-controller",
+		"kustomize-controller",
+		"helm-controller",
+		"notification-controller",
+	}
 
-		healthyControllers := 0
-		for _, controllerName := range fluxControllers {
-			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, controllerName, metav1.GetOptions{})
-			if err != nil {
-				r.Log.Info("Flux controller not found", "controller", controllerName, "cluster", clusterName)
-				continue
-			}
+	healthyControllers := 0
+	for _, controllerName := range fluxControllers {
+		deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, controllerName, metav1.GetOptions{})
+		if err != nil {
+			r.logComponent(integration, clusterName, controllerName, false, "reason", "not found")
+			continue
+		}
 
-			if deploy.Status.AvailableReplicas > 0 {
-				healthyControllers++
-				r.Log.Info("Flux controller is healthy",
-					"controller", controllerName,
-					"cluster", clusterName,
-					"replicas", deploy.Status.AvailableReplicas)
-			}
+		if deploy.Status.AvailableReplicas > 0 {
+			healthyControllers++
 		}
+		r.logComponent(integration, clusterName, controllerName, deploy.Status.AvailableReplicas > 0,
+			"replicas", deploy.Status.AvailableReplicas)
+	}
 
+	err = runCheck(ctx, "controllers", func(ctx context.Context) error {
 		if healthyControllers == 0 {
 			return fmt.Errorf("no Flux controllers are running on %s", clusterName)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// ✅ Health Check 3: Check Flux pods
+	// ✅ Health Check 3: Check Flux pods
+	err = runCheck(ctx, "pods", func(ctx context.Context) error {
 		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return fmt.Errorf("failed to list Flux pods on %s: %w", clusterName, err)
@@ -394,7 +596,7 @@ func (r *IntegrationReconciler) reconcileFlux(ctx context.Context, integration *
 			}
 		}
 
-		r.Log.Info("Flux pods status",
+		r.Log.V(1).Info("Flux pods status",
 			"cluster", clusterName,
 			"total", len(pods.Items),
 			"running", runningPods)
@@ -402,90 +604,127 @@ func (r *IntegrationReconciler) reconcileFlux(ctx context.Context, integration *
 		if runningPods == 0 {
 			return fmt.Errorf("no Flux pods are running on %s", clusterName)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
-		r.Log.Info("✅ Flux integration is healthy", "cluster", clusterName, "controllers", healthyControllers)
+	// ✅ Resources declared in spec.flux exist on the cluster
+	fluxStatuses := r.applyFluxResources(ctx, integration, clusterName, namespace)
+
+	// ✅ Health Check 4: Kustomization dependency chains
+	var rootCauses []string
+	for _, failure := range r.fluxRootCauses(ctx, integration, clusterName) {
+		rootCauses = append(rootCauses, fmt.Sprintf("%s: %s", clusterName, failure))
 	}
 
-	return nil
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ Flux integration is healthy", "cluster", clusterName, "controllers", healthyControllers)
+	return fluxStatuses, rootCauses, nil
 }
 
-func (r *IntegrationReconciler) reconcilePrometheus(ctx context.Context, integration *ksitv1alpha1.Integration) error {
-	r.Log.Info("reconciling Prometheus integration", "name", integration.Name)
+// fluxRootCauses reports the first failed Kustomization of every broken
+// dependsOn chain on a cluster. Listing errors are logged and skipped so a
+// missing permission does not fail the Flux health check itself.
+func (r *IntegrationReconciler) fluxRootCauses(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) []flux.DependencyFailure {
+	fluxClient, err := r.clients().Flux(ctx, integration, clusterName)
+	if err != nil {
+		r.Log.Error(err, "failed to create client for Kustomizations", "cluster", clusterName)
+		return nil
+	}
 
+	items, err := fluxClient.ListKustomizations(ctx, "")
+	if err != nil {
+		r.Log.Error(err, "failed to list Kustomizations", "cluster", clusterName)
+		return nil
+	}
+
+	failures := flux.NewDependencyGraph(items).RootCauses()
+	for _, failure := range failures {
+		r.Log.Info("Kustomization dependency chain is broken", "cluster", clusterName, "kustomization", failure.Kustomization, "blocked", len(failure.Blocked))
+	}
+	return failures
+}
+
+// checkPrometheus is the Prometheus health check on one target cluster
+func (r *IntegrationReconciler) checkPrometheus(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
 	namespace := integration.Spec.Config["namespace"]
 	if namespace == "" {
 		namespace = "monitoring"
 	}
 
-	// Health check for each target cluster using Kubernetes API
-	for _, clusterName := range integration.Spec.TargetClusters {
-		r.Log.Info("checking Prometheus health on cluster", "cluster", clusterName)
+	r.Log.V(1).Info("checking Prometheus health on cluster", "cluster", clusterName)
 
-		// Get cluster configuration
-		clusterConfig, err := r.ClusterManager.GetClusterConfig(clusterName, integration.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get cluster config for %s: %w", clusterName, err)
-		}
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
 
-		// Create clientset for target cluster
-		clientset, err := kubernetes.NewForConfig(clusterConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create clientset for %s: %w", clusterName, err)
-		}
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return err
+	}
+
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return err
+	}
+
+	// ✅ Health Check 2: Check Prometheus operator deployment
+	deployments := []string{
+		"prometheus-kube-prometheus-operator",
+		"prometheus-grafana",
+	}
 
-		// ✅ Health Check 1: Namespace exists
-		_, err = clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	healthyComponents := 0
+	for _, deployName := range deployments {
+		deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deployName, metav1.GetOptions{})
 		if err != nil {
-			return fmt.Errorf("Prometheus namespace %s not found on %s: %w", namespace, clusterName, err)
+			r.logComponent(integration, clusterName, deployName, false, "reason", "not found")
+			continue
 		}
 
-		// ✅ Health Check 2: Check Prometheus operator deployment
-		deployments := []string{
-			"prometheus-kube-prometheus-operator",
-			"prometheus-grafana",
+		if deploy.Status.AvailableReplicas > 0 {
+			healthyComponents++
 		}
+		r.logComponent(integration, clusterName, deployName, deploy.Status.AvailableReplicas > 0,
+			"replicas", deploy.Status.AvailableReplicas)
+	}
 
-		healthyComponents := 0
-		for _, deployName := range deployments {
-			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deployName, metav1.GetOptions{})
-			if err != nil {
-				r.Log.Info("Prometheus component not found", "component", deployName, "cluster", clusterName)
-				continue
-			}
+	// ✅ Health Check 3: Check StatefulSets (Prometheus, Alertmanager)
+	statefulsets := []string{
+		"prometheus-prometheus-kube-prometheus-prometheus",
+		"alertmanager-prometheus-kube-prometheus-alertmanager",
+	}
 
-			if deploy.Status.AvailableReplicas > 0 {
-				healthyComponents++
-				r.Log.Info("Prometheus component is healthy",
-					"component", deployName,
-					"cluster", clusterName,
-					"replicas", deploy.Status.AvailableReplicas)
-			}
+	for _, stsName := range statefulsets {
+		sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, stsName, metav1.GetOptions{})
+		if err != nil {
+			r.logComponent(integration, clusterName, stsName, false, "reason", "not found")
+			continue
 		}
 
-		// ✅ Health Check 3: Check StatefulSets (Prometheus, Alertmanager)
-		statefulsets := []string{
-			"prometheus-prometheus-kube-prometheus-prometheus",
-			"alertmanager-prometheus-kube-prometheus-alertmanager",
+		if sts.Status.ReadyReplicas > 0 {
+			healthyComponents++
 		}
+		r.logComponent(integration, clusterName, stsName, sts.Status.ReadyReplicas > 0,
+			"replicas", sts.Status.ReadyReplicas)
+	}
 
-		for _, stsName := range statefulsets {
-			sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, stsName, metav1.GetOptions{})
-			if err != nil {
-				r.Log.Info("StatefulSet not found", "statefulset", stsName, "cluster", clusterName)
-				continue
-			}
-
-			if sts.Status.ReadyReplicas > 0 {
-				healthyComponents++
-				r.Log.Info("StatefulSet is healthy",
-					"statefulset", stsName,
-					"cluster", clusterName,
-					"replicas", sts.Status.ReadyReplicas)
-			}
-		}
+	// ✅ Health Check 4: node-exporter runs on every node
+	ds, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, "prometheus-prometheus-node-exporter", metav1.GetOptions{})
+	if err != nil {
+		r.logComponent(integration, clusterName, "node-exporter", false, "reason", "not found")
+	} else {
+		// Missing node metrics degrade dashboards but do not make Prometheus unusable
+		status := health.DaemonSetStatus(ds)
+		r.logComponent(integration, clusterName, "node-exporter", status.Ready, "status", status.Message)
+	}
 
-		// ✅ Health Check 4: Count running Prometheus pods
+	// ✅ Health Check 5: Count running Prometheus pods
+	err = runCheck(ctx, "pods", func(ctx context.Context) error {
 		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return fmt.Errorf("failed to list Prometheus pods on %s: %w", clusterName, err)
@@ -498,7 +737,7 @@ func (r *IntegrationReconciler) reconcilePrometheus(ctx context.Context, integra
 			}
 		}
 
-		r.Log.Info("Prometheus pods status",
+		r.Log.V(1).Info("Prometheus pods status",
 			"cluster", clusterName,
 			"total", len(pods.Items),
 			"running", runningPods)
@@ -506,43 +745,42 @@ func (r *IntegrationReconciler) reconcilePrometheus(ctx context.Context, integra
 		if runningPods == 0 {
 			return fmt.Errorf("no Prometheus pods are running on %s", clusterName)
 		}
-
-		prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
-		r.Log.Info("✅ Prometheus integration is healthy", "cluster", clusterName)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ Prometheus integration is healthy", "cluster", clusterName)
 	return nil
 }
 
-func (r *IntegrationReconciler) reconcileIstio(ctx context.Context, integration *ksitv1alpha1.Integration) error {
-	r.Log.Info("reconciling Istio integration", "name", integration.Name)
-
+// checkIstio is the Istio health check on one target cluster
+func (r *IntegrationReconciler) checkIstio(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
 	// Istio typically runs in istio-system namespace
 	namespace := "istio-system"
 
-	// Health check for each target cluster using Kubernetes API
-	for _, clusterName := range integration.Spec.TargetClusters {
-		r.Log.Info("checking Istio health on cluster", "cluster", clusterName)
+	r.Log.V(1).Info("checking Istio health on cluster", "cluster", clusterName)
 
-		// Get cluster configuration
-		clusterConfig, err := r.ClusterManager.GetClusterConfig(clusterName, integration.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get cluster config for %s: %w", clusterName, err)
-		}
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
 
-		// Create clientset for target cluster
-		clientset, err := kubernetes.NewForConfig(clusterConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create clientset for %s: %w", clusterName, err)
-		}
+	// ✅ Required CRDs are served
+	if err := checkCRDs(ctx, clients, integration, clusterName); err != nil {
+		return err
+	}
 
-		// ✅ Health Check 1: Namespace exists
-		_, err = clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("Istio namespace %s not found on %s: %w", namespace, clusterName, err)
-		}
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return err
+	}
 
-		// ✅ Health Check 2: Istiod (control plane) is running
+	// ✅ Health Check 2: Istiod (control plane) is running
+	err = runCheck(ctx, "istiod", func(ctx context.Context) error {
 		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, "istiod", metav1.GetOptions{})
 		if err != nil {
 			return fmt.Errorf("Istiod deployment not found on %s: %w", clusterName, err)
@@ -552,21 +790,41 @@ func (r *IntegrationReconciler) reconcileIstio(ctx context.Context, integration
 			return fmt.Errorf("Istiod has 0 available replicas on %s", clusterName)
 		}
 
-		r.Log.Info("Istiod is healthy",
-			"cluster", clusterName,
-			"replicas", deployment.Status.AvailableReplicas)
+		r.logComponent(integration, clusterName, "istiod", true, "replicas", deployment.Status.AvailableReplicas)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// ✅ Health Check 3: Ingress gateway (if exists)
-		ingressDeploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, "istio-ingressgateway", metav1.GetOptions{})
-		if err == nil {
-			r.Log.Info("Istio ingress gateway found",
-				"cluster", clusterName,
-				"replicas", ingressDeploy.Status.AvailableReplicas)
-		} else {
-			r.Log.Info("Istio ingress gateway not found (optional)", "cluster", clusterName)
+	// ✅ Health Check 3: Ingress gateway (if exists)
+	ingressDeploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, "istio-ingressgateway", metav1.GetOptions{})
+	if err == nil {
+		r.logComponent(integration, clusterName, "istio-ingressgateway", ingressDeploy.Status.AvailableReplicas > 0,
+			"replicas", ingressDeploy.Status.AvailableReplicas)
+	} else {
+		r.logComponent(integration, clusterName, "istio-ingressgateway", false, "reason", "not found (optional)")
+	}
+
+	// ✅ Health Check 4: Istio CNI (if installed) runs on every node; pods cannot
+	// start on nodes without it
+	cni, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, "istio-cni-node", metav1.GetOptions{})
+	if err == nil {
+		err = runCheck(ctx, "cni", func(ctx context.Context) error {
+			status := health.DaemonSetStatus(cni)
+			if !status.Ready {
+				return fmt.Errorf("Istio CNI DaemonSet is not ready on %s: %s", clusterName, status.Message)
+			}
+			r.logComponent(integration, clusterName, "istio-cni-node", true, "status", status.Message)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
+	}
 
-		// ✅ Health Check 4: Check Istio pods
+	// ✅ Health Check 5: Check Istio pods
+	err = runCheck(ctx, "pods", func(ctx context.Context) error {
 		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return fmt.Errorf("failed to list Istio pods on %s: %w", clusterName, err)
@@ -579,7 +837,7 @@ func (r *IntegrationReconciler) reconcileIstio(ctx context.Context, integration
 			}
 		}
 
-		r.Log.Info("Istio pods status",
+		r.Log.V(1).Info("Istio pods status",
 			"cluster", clusterName,
 			"total", len(pods.Items),
 			"running", runningPods)
@@ -587,43 +845,135 @@ func (r *IntegrationReconciler) reconcileIstio(ctx context.Context, integration
 		if runningPods == 0 {
 			return fmt.Errorf("no Istio pods are running on %s", clusterName)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ Istio integration is healthy", "cluster", clusterName)
+	return nil
+}
 
-		prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
-		r.Log.Info("✅ Istio integration is healthy", "cluster", clusterName)
+// checkGrafana is the Grafana health check on one target cluster
+func (r *IntegrationReconciler) checkGrafana(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	namespace := integration.Spec.Config["namespace"]
+	if namespace == "" {
+		namespace = "grafana"
+	}
+	deploymentName := integration.Spec.Config["deployment"]
+	if deploymentName == "" {
+		deploymentName = "grafana"
 	}
 
+	r.Log.V(1).Info("checking Grafana health on cluster", "cluster", clusterName)
+
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return fmt.Errorf("failed to get clients for %s: %w", clusterName, err)
+	}
+	clientset := clients.Clientset
+
+	// ✅ Health Check 1: Namespace exists
+	if err := checkNamespace(ctx, clientset, integration, namespace, clusterName); err != nil {
+		return err
+	}
+
+	// ✅ Health Check 2: Grafana deployment is available
+	err = runCheck(ctx, "deployment", func(ctx context.Context) error {
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("Grafana deployment %s not found on %s: %w", deploymentName, clusterName, err)
+		}
+		if deployment.Status.AvailableReplicas == 0 {
+			return fmt.Errorf("Grafana has 0 available replicas on %s", clusterName)
+		}
+
+		r.logComponent(integration, clusterName, deploymentName, true, "replicas", deployment.Status.AvailableReplicas)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// ✅ Health Check 3: Grafana API and database (if a url is configured)
+	if integration.Spec.Config["url"] != "" {
+		grafanaClient, err := r.clients().Grafana(ctx, integration, clusterName)
+		if err != nil {
+			return err
+		}
+		err = runCheck(ctx, "api", func(ctx context.Context) error {
+			apiHealth, err := grafanaClient.HealthCheck(ctx)
+			if err != nil {
+				return fmt.Errorf("Grafana API health check failed on %s: %w", clusterName, err)
+			}
+			r.logComponent(integration, clusterName, "api", true, "version", apiHealth.Version)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, clusterName, true)
+	r.Log.V(1).Info("✅ Grafana integration is healthy", "cluster", clusterName)
 	return nil
 }
 
-func (r *IntegrationReconciler) cleanupIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+// cleanupIntegration removes what KSIT created for the Integration on its target clusters.
+// It returns the clusters where cleanup failed.
+func (r *IntegrationReconciler) cleanupIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
 	r.Log.Info("cleaning up integration", "name", integration.Name)
 
 	// Update metrics to show integration is down
-	for _, cluster := range integration.Spec.TargetClusters {
+	for _, cluster := range targetClusters(ctx, integration) {
 		prometheus.SetIntegrationStatus(integration.Name, integration.Spec.Type, cluster, false)
 	}
 
+	failures := r.cleanupWorkloads(ctx, integration)
+
 	// Type-specific cleanup
-	switch integration.Spec.Type {
-	case ksitv1alpha1.IntegrationTypeArgoCD:
-		// ArgoCD cleanup if needed
-	case ksitv1alpha1.IntegrationTypeFlux:
-		// Flux cleanup if needed
-	case ksitv1alpha1.IntegrationTypePrometheus:
-		// Prometheus cleanup if needed
-	case ksitv1alpha1.IntegrationTypeIstio:
-		// Istio cleanup if needed
+	handler, err := r.handlers().GetHandler(integration.Spec.Type)
+	if err != nil {
+		return failures
 	}
-
-	return nil
+	for clusterName, err := range handler.Cleanup(ctx, integration) {
+		if failures[clusterName] == nil {
+			failures[clusterName] = err
+		}
+	}
+	return failures
 }
 
 func (r *IntegrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// ClusterManager and ClusterInventory should be set before calling SetupWithManager
 	// They are passed from main.go to ensure both reconcilers share the same instances
+	if r.statusBatcher == nil {
+		r.statusBatcher = newStatusBatcher(minStatusInterval, statusHeartbeatInterval)
+	}
+	if r.sloTracker == nil {
+		r.sloTracker = slo.NewTracker()
+	}
+	if r.breaker == nil {
+		r.breaker = cluster.NewCircuitBreaker()
+	}
+	if r.healthLog == nil {
+		r.healthLog = newHealthLog(r.HealthSummaryInterval)
+	}
+	if r.pacer == nil {
+		r.pacer = newRequeuePacer(r.RequeueBudget)
+	}
+	if r.backoff == nil {
+		r.backoff = newFailureBackoff(r.FailureBackoff)
+	}
+	if r.Handlers == nil {
+		r.Handlers = NewHandlerRegistry(r)
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ksitv1alpha1.Integration{}).
+		Watches(&ksitv1alpha1.IntegrationTarget{}, handler.EnqueueRequestsFromMapFunc(r.integrationsSelectingTarget)).
 		Complete(r)
 }
 
@@ -633,6 +983,18 @@ type IntegrationTargetReconciler struct {
 	Scheme         *runtime.Scheme
 	Log            logr.Logger
 	ClusterManager *cluster.ClusterManager
+	Recorder       record.EventRecorder
+	// Interval is how soon a target that could not be connected is retried; connected
+	// targets are checked again at twice the interval. Defaults to requeueInterval.
+	Interval time.Duration
+}
+
+// retryInterval returns how soon a target that could not be connected is retried
+func (r *IntegrationTargetReconciler) retryInterval() time.Duration {
+	if r.Interval > 0 {
+		return r.Interval
+	}
+	return requeueInterval
 }
 
 func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -653,7 +1015,7 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}
 
 	// Get kubeconfig from secret
-	secretName := target.Spec.ClusterName + "-kubeconfig"
+	secretName := target.Spec.ClusterName + kubeconfigSecretSuffix
 	secret := &corev1.Secret{}
 	secretKey := types.NamespacedName{
 		Name:      secretName,
@@ -673,7 +1035,7 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		})
 
 		_ = r.Status().Update(ctx, target)
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.retryInterval()}, nil
 	}
 
 	// Extract kubeconfig from secret
@@ -696,10 +1058,12 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 
 	// Register cluster with ClusterManager
 	if r.ClusterManager != nil {
-		if err := r.ClusterManager.AddCluster(
+		rotated := kubeconfigRotated(r.ClusterManager, target, string(kubeconfigData))
+		if err := r.ClusterManager.AddClusterWithContext(
 			target.Spec.ClusterName,
 			target.Namespace,
 			string(kubeconfigData),
+			target.Spec.KubeconfigContext,
 		); err != nil {
 			r.Log.Error(err, "failed to register cluster", "cluster", target.Spec.ClusterName)
 			target.Status.Ready = false
@@ -708,12 +1072,39 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			meta.SetStatusCondition(&target.Status.Conditions, metav1.Condition{
 				Type:    "Ready",
 				Status:  metav1.ConditionFalse,
-				Reason:  "RegistrationFailed",
+				Reason:  conditions.Reason(err, "RegistrationFailed"),
 				Message: fmt.Sprintf("Failed to register cluster: %v", err),
 			})
 
 			_ = r.Status().Update(ctx, target)
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: r.retryInterval()}, nil
+		}
+
+		if rotated {
+			// Re-registering closed the old cluster's Done channel, so its informers and
+			// probers restart with the new credentials
+			message := fmt.Sprintf("Kubeconfig secret %s changed; cluster re-registered with the new credentials", secretName)
+			r.Log.Info("kubeconfig rotated", "cluster", target.Spec.ClusterName, "secret", secretName)
+			meta.SetStatusCondition(&target.Status.Conditions, metav1.Condition{
+				Type:               ksitv1alpha1.ConditionTypeKubeconfigRotated,
+				Status:             metav1.ConditionTrue,
+				Reason:             "SecretUpdated",
+				Message:            message,
+				ObservedGeneration: target.Generation,
+			})
+			// SetStatusCondition keeps the transition time of a condition whose status does
+			// not change, so set it to the time of this rotation
+			meta.FindStatusCondition(target.Status.Conditions, ksitv1alpha1.ConditionTypeKubeconfigRotated).LastTransitionTime = metav1.Now()
+			if r.Recorder != nil {
+				r.Recorder.Event(target, corev1.EventTypeNormal, "KubeconfigRotated", message)
+			}
+		}
+
+		if err := r.ClusterManager.SetClusterLabels(target.Spec.ClusterName, target.Namespace, cluster.TargetLabels(target)); err != nil {
+			r.Log.Error(err, "failed to set cluster labels", "cluster", target.Spec.ClusterName)
+		}
+		if err := r.ClusterManager.SetClusterAnnotations(target.Spec.ClusterName, target.Namespace, cluster.TargetAnnotations(target)); err != nil {
+			r.Log.Error(err, "failed to set cluster annotations", "cluster", target.Spec.ClusterName)
 		}
 
 		r.Log.Info("successfully registered cluster",
@@ -723,19 +1114,23 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		// Test connection
 		if err := r.ClusterManager.SyncCluster(ctx, target.Spec.ClusterName, target.Namespace); err != nil {
 			r.Log.Error(err, "cluster connection test failed", "cluster", target.Spec.ClusterName)
+			message := r.diagnoseConnection(ctx, target, err)
 			target.Status.Ready = false
-			target.Status.Message = fmt.Sprintf("Connection test failed: %v", err)
+			target.Status.Message = message
 
 			meta.SetStatusCondition(&target.Status.Conditions, metav1.Condition{
 				Type:    "Ready",
 				Status:  metav1.ConditionFalse,
-				Reason:  "ConnectionFailed",
-				Message: fmt.Sprintf("Connection test failed: %v", err),
+				Reason:  conditions.Reason(err, "ConnectionFailed"),
+				Message: message,
 			})
+			if r.Recorder != nil {
+				r.Recorder.Event(target, corev1.EventTypeWarning, "ConnectionFailed", message)
+			}
 
 			_ = r.Status().Update(ctx, target)
 			prometheus.SetClusterConnectionStatus(target.Spec.ClusterName, false)
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: r.retryInterval()}, nil
 		}
 
 		r.Log.Info("cluster connection verified", "cluster", target.Spec.ClusterName)
@@ -763,12 +1158,47 @@ func (r *IntegrationTargetReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	prometheus.SetClusterConnectionStatus(target.Spec.ClusterName, true)
 
 	r.Log.Info("successfully reconciled integration target", "name", req.NamespacedName)
-	return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
+	return ctrl.Result{RequeueAfter: 2 * r.retryInterval()}, nil
+}
+
+// diagnoseConnection explains a failed connection test by naming the stage (DNS, TCP,
+// TLS or Auth) that failed, falling back to the original error
+func (r *IntegrationTargetReconciler) diagnoseConnection(ctx context.Context, target *ksitv1alpha1.IntegrationTarget, connErr error) string {
+	config, err := r.ClusterManager.GetClusterConfig(target.Spec.ClusterName, target.Namespace)
+	if err != nil {
+		return fmt.Sprintf("Connection test failed: %v", connErr)
+	}
+
+	diagnosis := cluster.Diagnose(ctx, config)
+	if !diagnosis.Failed() {
+		return fmt.Sprintf("Connection test failed: %v", connErr)
+	}
+
+	r.Log.Info("connection diagnostic", "cluster", target.Spec.ClusterName, "stage", diagnosis.Stage, "error", diagnosis.Err.Error())
+	return fmt.Sprintf("Connection test failed at %s stage: %v", diagnosis.Stage, diagnosis.Err)
+}
+
+// clusterRemoved drops the metrics of a cluster that left the fleet. Metrics are
+// labeled by cluster name only, so they are kept while another namespace still
+// registers a cluster with the same name.
+func (r *IntegrationTargetReconciler) clusterRemoved(removed *cluster.Cluster) {
+	for _, c := range r.ClusterManager.ListClusters() {
+		if c.Name == removed.Name {
+			return
+		}
+	}
+	prometheus.DeleteClusterMetrics(removed.Name)
+	r.Log.Info("deleted metrics of removed cluster", "cluster", removed.Name)
 }
 
 func (r *IntegrationTargetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.ClusterManager != nil {
+		r.ClusterManager.AddListener(cluster.ClusterListenerFuncs{RemoveFunc: r.clusterRemoved})
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ksitv1alpha1.IntegrationTarget{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.targetsForKubeconfigSecret)).
 		Complete(r)
 }
 
@@ -777,43 +1207,276 @@ func (r *IntegrationReconciler) handleAutoInstall(ctx context.Context, integrati
 	log := r.Log.WithValues("integration", integration.Name, "type", integration.Spec.Type)
 
 	// Get the installer for this integration type
-	inst, err := r.InstallerFactory.GetInstaller(integration.Spec.Type)
+	inst, err := r.InstallerFactory.InstallerFor(integration)
 	if err != nil {
 		return fmt.Errorf("failed to get installer: %w", err)
 	}
 
+	// Failed post-install hooks are recorded in the status and do not stop the other
+	// clusters; they are returned once every cluster had its turn
+	var hookErrs clusterErrors
+
+	clusters := targetClusters(ctx, integration)
+	pruneHelmReleases(integration, clusters)
+
 	// Install on each target cluster
-	for _, clusterName := range integration.Spec.TargetClusters {
+	for _, clusterName := range clusters {
 		clusterLog := log.WithValues("cluster", clusterName)
 
 		// Get cluster config from manager
-		config, err := r.ClusterManager.GetClusterConfig(clusterName, integration.Namespace)
+		config, err := r.ClusterManager.GetIntegrationConfig(clusterName, integration)
 		if err != nil {
 			clusterLog.Error(err, "failed to get cluster config")
 			return fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
 		}
 
+		// Resolve cluster overrides and templates for this cluster
+		rendered, err := resolveForCluster(ctx, r.Client, r.ClusterManager, integration, clusterName)
+		if err != nil {
+			clusterLog.Error(err, "failed to resolve install config")
+			return err
+		}
+
 		// Check if already installed
-		installed, err := inst.IsInstalled(ctx, config, integration)
+		installed, err := inst.IsInstalled(ctx, config, rendered)
 		if err != nil {
 			clusterLog.Error(err, "failed to check installation status")
 			return fmt.Errorf("failed to check installation on cluster %s: %w", clusterName, err)
 		}
 
+		recorded, err := r.ledgerEntry(ctx, integration, clusterName)
+		if err != nil {
+			clusterLog.Error(err, "failed to read install ledger")
+		}
+
 		if installed {
-			clusterLog.Info("integration already installed, skipping")
-			continue
+			// An install whose CRDs were removed is repaired by running the installer again
+			missingCRDs, err := r.hasMissingCRDs(ctx, clusterName, integration)
+			if err != nil {
+				return fmt.Errorf("failed to check CRDs on cluster %s: %w", clusterName, err)
+			}
+			upgrade, ahead := "", ""
+			if !missingCRDs {
+				if upgrade, ahead, err = r.versionUpgrade(ctx, inst, config, rendered, recorded); err != nil {
+					return fmt.Errorf("failed to check chart version on cluster %s: %w", clusterName, err)
+				}
+			}
+			switch {
+			case missingCRDs:
+				clusterLog.Info("integration installed but required CRDs are missing, reinstalling")
+			case upgrade != "":
+				clusterLog.Info("chart version changed, upgrading", "reason", upgrade)
+				r.event(integration, corev1.EventTypeNormal, "VersionChanged", fmt.Sprintf("Upgrading on cluster %s: %s", clusterName, upgrade))
+			default:
+				drift := ""
+				if ahead != "" {
+					// A newer chart, such as one a failed campaign left behind, is reported and kept
+					clusterLog.Info("chart version is ahead of the pinned version, not downgrading", "reason", ahead)
+					r.event(integration, corev1.EventTypeWarning, "VersionAhead", fmt.Sprintf("Not downgrading on cluster %s: %s", clusterName, ahead))
+				} else if drift, err = r.installDrift(ctx, inst, config, rendered, recorded); err != nil {
+					return fmt.Errorf("failed to check drift on cluster %s: %w", clusterName, err)
+				}
+				if drift == "" {
+					if recorded == nil {
+						// Installed by someone else before KSIT managed it: adopt without touching it
+						r.recordInstall(ctx, rendered, clusterName, ksitv1alpha1.InstallActionAdopt, nil)
+						clusterLog.Info("adopted existing installation")
+					} else {
+						clusterLog.Info("integration already installed, skipping")
+					}
+					r.recordReleases(ctx, integration, inst, config, rendered, clusterName)
+					// Hooks that failed after the install are resumed before anything else
+					if postInstallHooksFailed(integration, clusterName) {
+						action := ksitv1alpha1.InstallActionInstall
+						if recorded != nil && recorded.Status.LastAction != "" {
+							action = recorded.Status.LastAction
+						}
+						if err := r.runPostInstallHooks(ctx, integration, clusterName, action, true); err != nil {
+							hookErrs = append(hookErrs, fmt.Errorf("post-install hook failed on cluster %s: %w", clusterName, err))
+							continue
+						}
+					}
+					// The Integration stays out of Running until a failed smoke test passes
+					if smokeTestEnabled(integration) && smokeTestFailed(integration, clusterName) {
+						if err := r.runSmokeTest(ctx, integration, clusterName); err != nil {
+							return fmt.Errorf("smoke test failed on cluster %s: %w", clusterName, err)
+						}
+					}
+					continue
+				}
+				clusterLog.Info("integration drifted from its spec, reinstalling", "drift", drift)
+				r.event(integration, corev1.EventTypeWarning, "InstallDrifted", fmt.Sprintf("Reinstalling on cluster %s: %s", clusterName, drift))
+				prometheus.RecordInstallDrift(integration.Name, clusterName)
+			}
+		}
+
+		action := ksitv1alpha1.InstallActionInstall
+		if recorded != nil {
+			action = ksitv1alpha1.InstallActionUpgrade
 		}
 
 		// Install the integration
 		clusterLog.Info("installing integration")
-		if err := inst.Install(ctx, config, integration); err != nil {
-			clusterLog.Error(err, "installation failed")
-			return fmt.Errorf("failed to install on cluster %s: %w", clusterName, err)
+		installCtx := installer.WithProgress(ctx, func(message string) {
+			r.event(integration, corev1.EventTypeNormal, "InstallProgress", fmt.Sprintf("cluster %s: %s", clusterName, message))
+		})
+		installErr := inst.Install(installCtx, config, rendered)
+		r.recordInstall(ctx, rendered, clusterName, action, installErr)
+		r.recordReleases(ctx, integration, inst, config, rendered, clusterName)
+		if installErr != nil {
+			clusterLog.Error(installErr, "installation failed")
+			return fmt.Errorf("failed to install on cluster %s: %w", clusterName, installErr)
 		}
 
 		clusterLog.Info("installation completed successfully")
+
+		if len(postInstallHooks(integration)) > 0 {
+			if err := r.runPostInstallHooks(ctx, integration, clusterName, action, false); err != nil {
+				clusterLog.Error(err, "post-install hook failed")
+				hookErrs = append(hookErrs, fmt.Errorf("post-install hook failed on cluster %s: %w", clusterName, err))
+				continue
+			}
+		}
+
+		if smokeTestEnabled(integration) {
+			if err := r.runSmokeTest(ctx, integration, clusterName); err != nil {
+				clusterLog.Error(err, "smoke test failed")
+				return fmt.Errorf("smoke test failed on cluster %s: %w", clusterName, err)
+			}
+		}
 	}
 
+	if len(hookErrs) > 0 {
+		return hookErrs
+	}
 	return nil
 }
+
+// clients returns the integration client factory
+func (r *IntegrationReconciler) clients() *factory.Factory {
+	if r.Clients == nil {
+		clients := factory.New(r.ClusterManager, r.Scheme, r.Log)
+		clients.Secrets = r.Client
+		return clients
+	}
+	return r.Clients
+}
+
+// handlers returns the integration handler registry
+func (r *IntegrationReconciler) handlers() *HandlerRegistry {
+	if r.Handlers == nil {
+		return NewHandlerRegistry(r)
+	}
+	return r.Handlers
+}
+
+// resolveForCluster applies the Integration's cluster overrides, resolves its config and
+// Helm value templates against a target cluster's name and the labels of its IntegrationTarget,
+// merges in the values of the Secrets named by the IntegrationTarget's annotations and the
+// values files of helmConfig.valuesFrom, reads the repository credentials of
+// helmConfig.credentialsSecretRef, and sets the config keys of its configSecretRefs from
+// Secrets read through c
+func resolveForCluster(ctx context.Context, c client.Reader, cm *cluster.ClusterManager, integration *ksitv1alpha1.Integration, clusterName string) (*ksitv1alpha1.Integration, error) {
+	var labels map[string]string
+	var target *cluster.Cluster
+	if registered, err := cm.GetIntegrationCluster(clusterName, integration); err == nil {
+		labels, target = registered.Labels, registered
+	}
+
+	overridden, err := installer.ApplyClusterOverrides(integration, labels)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := template.RenderIntegration(overridden, template.NewData(integration, clusterName, labels))
+	if err != nil {
+		return nil, err
+	}
+	// Values from Secrets are taken literally, so they are merged after the templates
+	if target != nil {
+		if rendered, err = applyTargetValues(ctx, c, rendered, target); err != nil {
+			return nil, err
+		}
+	}
+	if rendered, err = applyValuesFrom(ctx, c, rendered); err != nil {
+		return nil, err
+	}
+	if rendered, err = applyRepositoryCredentials(ctx, c, rendered); err != nil {
+		return nil, err
+	}
+	rendered.Spec.Config, err = factory.ResolveConfigSecrets(ctx, c, integration, rendered.Spec.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config secrets for cluster %s: %w", clusterName, err)
+	}
+	return rendered, nil
+}
+
+// hasMissingCRDs reports whether a target cluster lacks any CRD required by the integration type
+func (r *IntegrationReconciler) hasMissingCRDs(ctx context.Context, clusterName string, integration *ksitv1alpha1.Integration) (bool, error) {
+	clients, err := r.ClusterManager.GetIntegrationClients(clusterName, integration)
+	if err != nil {
+		return false, err
+	}
+	dc, err := clients.DiscoveryFor(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	err = crds.EnsureForIntegration(dc, integration.Spec.Type)
+	if crds.IsMissingCRDs(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// ledgerEntry returns the recorded install for a cluster, or nil if there is none or no ledger is configured
+func (r *IntegrationReconciler) ledgerEntry(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*ksitv1alpha1.InstalledComponent, error) {
+	if r.Ledger == nil {
+		return nil, nil
+	}
+	return r.Ledger.Get(ctx, integration, clusterName)
+}
+
+// recordInstall writes an install action to the ledger. Ledger failures are logged but never fail the install.
+func (r *IntegrationReconciler) recordInstall(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName, action string, installErr error) {
+	if r.Ledger == nil {
+		return
+	}
+
+	entry := ledger.Entry{
+		Action:     action,
+		Method:     installMethod(integration),
+		Version:    installVersion(integration),
+		ValuesHash: ledger.ValuesHash(integration),
+		Err:        installErr,
+	}
+	if err := r.Ledger.Record(ctx, integration, clusterName, entry); err != nil {
+		r.Log.Error(err, "failed to record install in ledger", "integration", integration.Name, "cluster", clusterName)
+	}
+}
+
+// installMethod returns the effective install method of an integration
+func installMethod(integration *ksitv1alpha1.Integration) string {
+	if install := integration.Spec.AutoInstall; install != nil && install.Method != "" {
+		return install.Method
+	}
+	if integration.Spec.Type == ksitv1alpha1.IntegrationTypeFlux {
+		return "manifest"
+	}
+	return "helm"
+}
+
+// installVersion returns the chart version, starting CSV or manifest URL requested by an
+// integration, if any
+func installVersion(integration *ksitv1alpha1.Integration) string {
+	install := integration.Spec.AutoInstall
+	if install == nil {
+		return ""
+	}
+	if install.HelmConfig != nil && install.HelmConfig.Version != "" {
+		return install.HelmConfig.Version
+	}
+	if install.OperatorConfig != nil && install.OperatorConfig.StartingCSV != "" {
+		return install.OperatorConfig.StartingCSV
+	}
+	return install.ManifestURL
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+)
+
+// recordReleases reads the Helm releases of the integration on a cluster into
+// status.helmReleases. Installers without Helm releases leave the status alone, and so
+// does a failure to read them, which only keeps the last known state.
+func (r *IntegrationReconciler) recordReleases(ctx context.Context, integration *ksitv1alpha1.Integration, inst installer.Installer, config *rest.Config, rendered *ksitv1alpha1.Integration, clusterName string) {
+	reporter, ok := inst.(installer.ReleaseReporter)
+	if !ok {
+		return
+	}
+	releases, err := reporter.Releases(ctx, config, rendered)
+	if err != nil {
+		r.Log.V(1).Info("failed to read helm releases", "integration", integration.Name, "cluster", clusterName, "error", err.Error())
+		return
+	}
+	setHelmReleases(integration, clusterName, releases)
+}
+
+// setHelmReleases replaces the releases of a cluster in the Integration's status,
+// keeping the position of the cluster's entries
+func setHelmReleases(integration *ksitv1alpha1.Integration, clusterName string, releases []ksitv1alpha1.HelmReleaseStatus) {
+	for i := range releases {
+		releases[i].Cluster = clusterName
+	}
+
+	var statuses []ksitv1alpha1.HelmReleaseStatus
+	replaced := false
+	for _, status := range integration.Status.HelmReleases {
+		if status.Cluster != clusterName {
+			statuses = append(statuses, status)
+			continue
+		}
+		if !replaced {
+			statuses = append(statuses, releases...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		statuses = append(statuses, releases...)
+	}
+	integration.Status.HelmReleases = statuses
+}
+
+// pruneHelmReleases drops the releases of clusters the Integration no longer targets.
+// Paused clusters keep their last known releases, like their cluster status.
+func pruneHelmReleases(integration *ksitv1alpha1.Integration, clusters []string) {
+	keep := make(map[string]bool, len(clusters)+len(integration.Status.PausedClusters))
+	for _, clusterName := range clusters {
+		keep[clusterName] = true
+	}
+	for _, clusterName := range integration.Status.PausedClusters {
+		keep[clusterName] = true
+	}
+	var statuses []ksitv1alpha1.HelmReleaseStatus
+	for _, status := range integration.Status.HelmReleases {
+		if keep[status.Cluster] {
+			statuses = append(statuses, status)
+		}
+	}
+	integration.Status.HelmReleases = statuses
+}
+
+// versionUpgrade describes why the integration's Helm releases on a cluster must be
+// upgraded to helmConfig.version, or returns "" when they already run it. A version
+// constraint such as ~7.0 is met by any chart version it allows. Releases are only
+// upgraded, never downgraded: when one runs a newer version, or one that cannot be
+// compared, that is described in ahead instead. Unpinned versions, installs KSIT has not
+// recorded or has adopted at the pinned version, and integrations an upgrade campaign is
+// rolling out are left alone.
+func (r *IntegrationReconciler) versionUpgrade(ctx context.Context, inst installer.Installer, config *rest.Config, integration *ksitv1alpha1.Integration, recorded *ksitv1alpha1.InstalledComponent) (upgrade, ahead string, err error) {
+	install := integration.Spec.AutoInstall
+	if installMethod(integration) != "helm" || install == nil || install.HelmConfig == nil || install.HelmConfig.Version == "" {
+		return "", "", nil
+	}
+	desired := install.HelmConfig.Version
+	reporter, ok := inst.(installer.ReleaseReporter)
+	if !ok {
+		return "", "", nil
+	}
+	// Installs KSIT did not make are adopted as they are before versions are enforced
+	if recorded == nil || (recorded.Status.Adopted && recorded.Spec.Version == desired) {
+		return "", "", nil
+	}
+	campaign, err := r.runningCampaign(ctx, integration)
+	if err != nil || campaign != "" {
+		return "", "", err
+	}
+
+	releases, err := reporter.Releases(ctx, config, integration)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read helm releases: %w", err)
+	}
+	for _, release := range releases {
+		if release.ChartVersion == "" {
+			continue
+		}
+		switch compareChartVersion(release.ChartVersion, desired) {
+		case 1:
+			return "", fmt.Sprintf("release %s runs chart version %s, which is not below %s", release.Name, release.ChartVersion, desired), nil
+		case -1:
+			if upgrade == "" {
+				upgrade = fmt.Sprintf("release %s runs chart version %s instead of %s", release.Name, release.ChartVersion, desired)
+			}
+		}
+	}
+	return upgrade, "", nil
+}
+
+// constraintVersions finds the versions a semver constraint names
+var constraintVersions = regexp.MustCompile(`v?\d+(\.\d+){0,2}(-[0-9A-Za-z.-]+)?`)
+
+// compareChartVersion returns 0 when a chart version meets the desired version, -1 when
+// it is lower, and 1 when it is higher or cannot be compared. A version outside a
+// constraint is lower when it is below every version the constraint names.
+func compareChartVersion(version, desired string) int {
+	if versionMatches(version, desired) {
+		return 0
+	}
+	running, err := semver.NewVersion(version)
+	if err != nil {
+		return 1
+	}
+	named := constraintVersions.FindAllString(desired, -1)
+	if len(named) == 0 {
+		return 1
+	}
+	for _, bound := range named {
+		parsed, err := semver.NewVersion(bound)
+		if err != nil || !running.LessThan(parsed) {
+			return 1
+		}
+	}
+	return -1
+}
+
+// versionMatches reports whether a chart version is the desired version, or allowed by
+// it when it is a semver constraint
+func versionMatches(version, desired string) bool {
+	if strings.TrimPrefix(version, "v") == strings.TrimPrefix(desired, "v") {
+		return true
+	}
+	constraint, err := semver.NewConstraint(desired)
+	if err != nil {
+		return false
+	}
+	parsed, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	return constraint.Check(parsed)
+}
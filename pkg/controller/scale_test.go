@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/slo"
+)
+
+// scaleClustersPerIntegration is how many target clusters each Integration of a
+// simulated fleet spans
+const scaleClustersPerIntegration = 25
+
+// healthyArgoCDDocuments are the objects the Argo CD health check reads from a cluster
+// where Argo CD is running, by path
+var healthyArgoCDDocuments = map[string]string{
+	"/api":    `{"kind":"APIVersions","versions":["v1"]}`,
+	"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[]}`,
+	"/apis": `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"argoproj.io",` +
+		`"versions":[{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}],` +
+		`"preferredVersion":{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}}]}`,
+	"/apis/argoproj.io/v1alpha1": `{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"argoproj.io/v1alpha1","resources":[` +
+		`{"name":"applications","singularName":"application","namespaced":true,"kind":"Application","verbs":["get","list"]},` +
+		`{"name":"appprojects","singularName":"appproject","namespaced":true,"kind":"AppProject","verbs":["get","list"]}]}`,
+	"/api/v1/namespaces/argocd": `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"argocd"}}`,
+	"/apis/apps/v1/namespaces/argocd/deployments/argocd-server": `{"kind":"Deployment","apiVersion":"apps/v1",` +
+		`"metadata":{"name":"argocd-server","namespace":"argocd"},"status":{"replicas":1,"availableReplicas":1}}`,
+	"/apis/apps/v1/namespaces/argocd/deployments/argocd-repo-server": `{"kind":"Deployment","apiVersion":"apps/v1",` +
+		`"metadata":{"name":"argocd-repo-server","namespace":"argocd"},"status":{"replicas":1,"availableReplicas":1}}`,
+	"/apis/apps/v1/namespaces/argocd/deployments/argocd-application-controller": `{"kind":"Deployment","apiVersion":"apps/v1",` +
+		`"metadata":{"name":"argocd-application-controller","namespace":"argocd"},"status":{"replicas":1,"availableReplicas":1}}`,
+	"/api/v1/namespaces/argocd/endpoints/argocd-server": `{"kind":"Endpoints","apiVersion":"v1",` +
+		`"metadata":{"name":"argocd-server","namespace":"argocd"},"subsets":[{"addresses":[{"ip":"10.0.0.1"}]}]}`,
+	"/api/v1/namespaces/argocd/pods": `{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[` +
+		`{"metadata":{"name":"argocd-server-0","namespace":"argocd"},"status":{"phase":"Running"}}]}`,
+}
+
+// scaleFleet is a simulated fleet: clusters served under /clusters/<name> by one fake
+// API server, and Argo CD Integrations spread over them. It counts the requests made to
+// the hub and to the clusters.
+type scaleFleet struct {
+	reconciler   *IntegrationReconciler
+	integrations []types.NamespacedName
+	hubCalls     atomic.Int64
+	clusterCalls atomic.Int64
+}
+
+func newScaleFleet(b *testing.B, clusters, integrations int) *scaleFleet {
+	fleet := &scaleFleet{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fleet.clusterCalls.Add(1)
+		// /clusters/<name>/<path>
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/clusters/"), "/", 2)
+		document, ok := healthyArgoCDDocuments["/"+parts[len(parts)-1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, document)
+	}))
+	b.Cleanup(server.Close)
+
+	scheme := k8sruntime.NewScheme()
+	require.NoError(b, ksitv1alpha1.AddToScheme(scheme))
+
+	clusterNames := make([]string, clusters)
+	for i := range clusterNames {
+		clusterNames[i] = fmt.Sprintf("cluster-%04d", i)
+	}
+	var objs []client.Object
+	for i := 0; i < integrations; i++ {
+		targets := make([]string, 0, scaleClustersPerIntegration)
+		for j := 0; j < scaleClustersPerIntegration && j < clusters; j++ {
+			targets = append(targets, clusterNames[(i*scaleClustersPerIntegration/5+j)%clusters])
+		}
+		integration := &ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("argocd-%03d", i), Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+				Enabled:        true,
+				TargetClusters: targets,
+			},
+		}
+		objs = append(objs, integration)
+		fleet.integrations = append(fleet.integrations, types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace})
+	}
+
+	count := func() { fleet.hubCalls.Add(1) }
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&ksitv1alpha1.Integration{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				count()
+				return c.Get(ctx, key, obj, opts...)
+			},
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				count()
+				return c.List(ctx, list, opts...)
+			},
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				count()
+				return c.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				count()
+				return c.Update(ctx, obj, opts...)
+			},
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				count()
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				count()
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				count()
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+
+	cm := cluster.NewClusterManager(c)
+	for _, name := range clusterNames {
+		require.NoError(b, cm.AddCluster(name, "ksit-system", testKubeconfig(server.URL+"/clusters/"+name)))
+	}
+
+	fleet.reconciler = &IntegrationReconciler{
+		Client:                c,
+		Scheme:                scheme,
+		Log:                   logr.Discard(),
+		ClusterManager:        cm,
+		ClusterInventory:      cluster.NewClusterInventory(),
+		Ledger:                ledger.NewLedger(c),
+		MaxConcurrentClusters: 10,
+		statusBatcher:         newStatusBatcher(minStatusInterval, statusHeartbeatInterval),
+		sloTracker:            slo.NewTracker(),
+	}
+	return fleet
+}
+
+// reconcile reconciles the i-th Integration of the fleet, round robin
+func (f *scaleFleet) reconcile(b *testing.B, i int) {
+	key := f.integrations[i%len(f.integrations)]
+	if _, err := f.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		b.Fatalf("failed to reconcile %s: %v", key, err)
+	}
+}
+
+// requireRunning fails the benchmark unless every Integration is Running, so that it
+// measures healthy reconciles rather than failing ones
+func (f *scaleFleet) requireRunning(b *testing.B) {
+	for _, key := range f.integrations {
+		integration := &ksitv1alpha1.Integration{}
+		require.NoError(b, f.reconciler.Get(context.Background(), key, integration))
+		require.Equal(b, ksitv1alpha1.PhaseRunning, integration.Status.Phase, "%s: %s", key, integration.Status.Message)
+	}
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+// BenchmarkReconcile measures steady-state reconciles of a simulated fleet of Argo CD
+// Integrations. Besides time per reconcile it reports the reconcile throughput, the
+// requests each reconcile makes to the hub and to target clusters, and the heap the
+// fleet holds per cluster once every Integration was reconciled, cached clients
+// included. Compare runs with benchstat before merging changes to the reconcile path;
+// see "make bench".
+func BenchmarkReconcile(b *testing.B) {
+	for _, size := range []struct{ clusters, integrations int }{
+		{clusters: 50, integrations: 10},
+		{clusters: 500, integrations: 100},
+	} {
+		b.Run(fmt.Sprintf("clusters=%d/integrations=%d", size.clusters, size.integrations), func(b *testing.B) {
+			before := heapInUse()
+			fleet := newScaleFleet(b, size.clusters, size.integrations)
+
+			// Warm up: the first reconcile adds finalizers and builds the cached clients
+			for i := range fleet.integrations {
+				fleet.reconcile(b, i)
+			}
+			heap := float64(int64(heapInUse())-int64(before)) / float64(size.clusters)
+			fleet.requireRunning(b)
+
+			fleet.hubCalls.Store(0)
+			fleet.clusterCalls.Store(0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				fleet.reconcile(b, i)
+			}
+			b.StopTimer()
+
+			b.ReportMetric(heap, "heap-B/cluster")
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "reconciles/s")
+			b.ReportMetric(float64(fleet.hubCalls.Load())/float64(b.N), "hub-calls/op")
+			b.ReportMetric(float64(fleet.clusterCalls.Load())/float64(b.N), "cluster-calls/op")
+		})
+	}
+}
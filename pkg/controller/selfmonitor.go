@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+const (
+	// selfMonitorFieldManager owns the fields of the metrics Service and ServiceMonitor
+	selfMonitorFieldManager = "ksit-self-monitoring"
+
+	defaultSelfMonitorService = "ksit-controller-metrics"
+
+	// selfMonitorRecheck is how often a missing ServiceMonitor CRD is looked for again
+	selfMonitorRecheck = 5 * time.Minute
+)
+
+// SelfMonitorReconciler publishes the controller's own metrics endpoint on the hub while
+// a Prometheus Integration is enabled there: a Service with prometheus.io scrape
+// annotations, and a ServiceMonitor when the Prometheus Operator CRDs are installed.
+// Both are deleted when the last Prometheus Integration is disabled or deleted.
+type SelfMonitorReconciler struct {
+	client.Client
+	Log logr.Logger
+	// Namespace is the namespace the controller runs in
+	Namespace string
+	// MetricsPort is the port the controller serves metrics on
+	MetricsPort int32
+	Config      config.SelfMonitoringConfig
+}
+
+func (r *SelfMonitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	enabled, err := r.prometheusEnabled(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !enabled {
+		return ctrl.Result{}, r.cleanup(ctx)
+	}
+
+	if err := r.Patch(ctx, r.service(), client.Apply, client.FieldOwner(selfMonitorFieldManager), client.ForceOwnership); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to apply metrics service: %w", err)
+	}
+	err = r.Patch(ctx, r.serviceMonitor(), client.Apply, client.FieldOwner(selfMonitorFieldManager), client.ForceOwnership)
+	if meta.IsNoMatchError(err) {
+		r.Log.V(1).Info("ServiceMonitor CRD is not installed, relying on scrape annotations")
+		return ctrl.Result{RequeueAfter: selfMonitorRecheck}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to apply metrics ServiceMonitor: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// prometheusEnabled reports whether any enabled Prometheus Integration exists on the hub
+func (r *SelfMonitorReconciler) prometheusEnabled(ctx context.Context) (bool, error) {
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := r.List(ctx, integrations); err != nil {
+		return false, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	for _, integration := range integrations.Items {
+		if integration.Spec.Type == ksitv1alpha1.IntegrationTypePrometheus && integration.Spec.Enabled && integration.DeletionTimestamp == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *SelfMonitorReconciler) cleanup(ctx context.Context) error {
+	for _, obj := range []client.Object{r.service(), r.serviceMonitor()} {
+		err := r.Delete(ctx, obj)
+		if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete metrics %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+		}
+	}
+	return nil
+}
+
+func (r *SelfMonitorReconciler) serviceName() string {
+	if r.Config.ServiceName != "" {
+		return r.Config.ServiceName
+	}
+	return defaultSelfMonitorService
+}
+
+func (r *SelfMonitorReconciler) labels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       r.serviceName(),
+		"app.kubernetes.io/managed-by": "ksit",
+	}
+}
+
+func (r *SelfMonitorReconciler) service() *corev1.Service {
+	selector := r.Config.PodSelector
+	if len(selector) == 0 {
+		selector = map[string]string{"control-plane": "controller-manager"}
+	}
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.serviceName(),
+			Namespace: r.Namespace,
+			Labels:    r.labels(),
+			// Annotation-based scrape configs find the endpoint without a ServiceMonitor
+			Annotations: map[string]string{
+				"prometheus.io/scrape": "true",
+				"prometheus.io/port":   strconv.Itoa(int(r.MetricsPort)),
+				"prometheus.io/path":   "/metrics",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{{
+				Name:       "metrics",
+				Port:       r.MetricsPort,
+				TargetPort: intstr.FromInt(int(r.MetricsPort)),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+func (r *SelfMonitorReconciler) serviceMonitor() *unstructured.Unstructured {
+	endpoint := map[string]interface{}{"port": "metrics", "path": "/metrics"}
+	if r.Config.Interval != "" {
+		endpoint["interval"] = r.Config.Interval
+	}
+	if r.Config.ScrapeTimeout != "" {
+		endpoint["scrapeTimeout"] = r.Config.ScrapeTimeout
+	}
+	labels := r.labels()
+	for key, value := range r.Config.Labels {
+		labels[key] = value
+	}
+	selector := map[string]interface{}{}
+	for key, value := range r.labels() {
+		selector[key] = value
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector":  map[string]interface{}{"matchLabels": selector},
+			"endpoints": []interface{}{endpoint},
+		},
+	}}
+	obj.SetAPIVersion("monitoring.coreos.com/v1")
+	obj.SetKind("ServiceMonitor")
+	obj.SetName(r.serviceName())
+	obj.SetNamespace(r.Namespace)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func (r *SelfMonitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Every Prometheus Integration change reconciles the same pair of objects
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.serviceName()}
+	isPrometheus := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		integration, ok := obj.(*ksitv1alpha1.Integration)
+		return ok && integration.Spec.Type == ksitv1alpha1.IntegrationTypePrometheus
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("selfmonitor").
+		Watches(&ksitv1alpha1.Integration{}, handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: key}}
+		}), builder.WithPredicates(isPrometheus)).
+		Complete(r)
+}
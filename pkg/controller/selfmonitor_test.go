@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// newSelfMonitorReconciler returns a reconciler on a fake hub that serves the
+// ServiceMonitor CRD only when withCRD is set
+func newSelfMonitorReconciler(t *testing.T, withCRD bool, objs ...client.Object) *SelfMonitorReconciler {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	mapper.Add(ksitv1alpha1.GroupVersion.WithKind("Integration"), meta.RESTScopeNamespace)
+	if withCRD {
+		mapper.Add(serviceMonitorGVK, meta.RESTScopeNamespace)
+	}
+
+	// The fake client does not support apply patches, so applies create the object of
+	// a kind the mapper knows
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			gvk := obj.GetObjectKind().GroupVersionKind()
+			if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+				return err
+			}
+			if err := c.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			return nil
+		}}).Build()
+	return &SelfMonitorReconciler{
+		Client:      c,
+		Log:         logr.Discard(),
+		Namespace:   "ksit-system",
+		MetricsPort: 8080,
+		Config:      config.SelfMonitoringConfig{Labels: map[string]string{"release": "kube-prometheus-stack"}, Interval: "30s"},
+	}
+}
+
+func prometheusIntegration() *ksitv1alpha1.Integration {
+	return &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "default"},
+		Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypePrometheus, Enabled: true},
+	}
+}
+
+func TestSelfMonitorReconcile(t *testing.T) {
+	ctx := context.Background()
+	integration := prometheusIntegration()
+	r := newSelfMonitorReconciler(t, true, integration)
+
+	_, err := r.Reconcile(ctx, ctrl.Request{})
+	require.NoError(t, err)
+
+	svc := &corev1.Service{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, svc))
+	assert.Equal(t, "true", svc.Annotations["prometheus.io/scrape"])
+	assert.Equal(t, "8080", svc.Annotations["prometheus.io/port"])
+	assert.Equal(t, map[string]string{"control-plane": "controller-manager"}, svc.Spec.Selector)
+	assert.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(serviceMonitorGVK)
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, monitor))
+	assert.Equal(t, "kube-prometheus-stack", monitor.GetLabels()["release"])
+	selector, _, _ := unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, svc.Labels, selector)
+	endpoints, _, _ := unstructured.NestedSlice(monitor.Object, "spec", "endpoints")
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "30s", endpoints[0].(map[string]interface{})["interval"])
+
+	// Disabling the last Prometheus Integration removes both
+	integration.Spec.Enabled = false
+	require.NoError(t, r.Update(ctx, integration))
+	_, err = r.Reconcile(ctx, ctrl.Request{})
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(r.Get(ctx, client.ObjectKeyFromObject(svc), &corev1.Service{})))
+	assert.True(t, apierrors.IsNotFound(r.Get(ctx, client.ObjectKeyFromObject(monitor), monitor)))
+}
+
+func TestSelfMonitorReconcileWithoutServiceMonitorCRD(t *testing.T) {
+	ctx := context.Background()
+	r := newSelfMonitorReconciler(t, false, prometheusIntegration())
+
+	result, err := r.Reconcile(ctx, ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, selfMonitorRecheck, result.RequeueAfter)
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, &corev1.Service{}))
+}
+
+func TestSelfMonitorReconcileWithoutPrometheus(t *testing.T) {
+	ctx := context.Background()
+	r := newSelfMonitorReconciler(t, true)
+
+	_, err := r.Reconcile(ctx, ctrl.Request{})
+	require.NoError(t, err)
+	err = r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, &corev1.Service{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestSelfMonitorServiceScrapesControllerMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The metrics server the manager runs
+	server, err := metricsserver.NewServer(metricsserver.Options{BindAddress: "127.0.0.1:0"}, nil, nil)
+	require.NoError(t, err)
+	go func() { _ = server.Start(ctx) }()
+	bound := server.(interface{ GetBindAddr() string })
+	require.Eventually(t, func() bool { return bound.GetBindAddr() != "" }, 5*time.Second, 10*time.Millisecond)
+	_, port, err := net.SplitHostPort(bound.GetBindAddr())
+	require.NoError(t, err)
+	metricsPort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	r := newSelfMonitorReconciler(t, true, prometheusIntegration())
+	r.MetricsPort = int32(metricsPort)
+	_, err = r.Reconcile(ctx, ctrl.Request{})
+	require.NoError(t, err)
+	prometheus.RecordReconcile("prometheus", "prometheus", "success")
+
+	// Scrape the endpoint the Service annotations and the ServiceMonitor point at
+	svc := &corev1.Service{}
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, svc))
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(serviceMonitorGVK)
+	require.NoError(t, r.Get(ctx, types.NamespacedName{Namespace: "ksit-system", Name: "ksit-controller-metrics"}, monitor))
+	endpoints, _, _ := unstructured.NestedSlice(monitor.Object, "spec", "endpoints")
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, svc.Annotations["prometheus.io/path"], endpoints[0].(map[string]interface{})["path"])
+
+	resp, err := http.Get("http://" + net.JoinHostPort("127.0.0.1", svc.Annotations["prometheus.io/port"]) + svc.Annotations["prometheus.io/path"])
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `ksit_integration_reconcile_total{integration="prometheus",status="success",type="prometheus"}`)
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/smoketest"
+)
+
+// smokeTestEnabled reports whether the Integration asks for a smoke test after install
+func smokeTestEnabled(integration *ksitv1alpha1.Integration) bool {
+	install := integration.Spec.AutoInstall
+	return install != nil && install.SmokeTest != nil && install.SmokeTest.Enabled
+}
+
+// smokeTestFailed reports whether the last smoke test on a cluster failed. Such
+// clusters are tested again on every reconcile until the smoke test passes.
+func smokeTestFailed(integration *ksitv1alpha1.Integration, clusterName string) bool {
+	for _, result := range integration.Status.SmokeTests {
+		if result.Cluster == clusterName {
+			return !result.Passed
+		}
+	}
+	return false
+}
+
+// runSmokeTest runs the smoke test of the Integration's type on a cluster and records
+// the result in the Integration's status. It returns the first failed check.
+func (r *IntegrationReconciler) runSmokeTest(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	cfg := integration.Spec.AutoInstall.SmokeTest
+	opts := smoketest.Options{
+		Name:      "ksit-smoketest-" + integration.Name,
+		Namespace: integration.Spec.Config["namespace"],
+		RepoURL:   cfg.RepoURL,
+		Branch:    cfg.Branch,
+		Path:      cfg.Path,
+	}
+	if cfg.Timeout != nil {
+		opts.Timeout = cfg.Timeout.Duration
+	}
+
+	result, err := r.smokeTest(ctx, integration, clusterName, opts)
+	if err != nil {
+		result = smoketest.Result{Checks: []smoketest.Check{{Name: "Connect", Message: err.Error()}}}
+	}
+	setSmokeTestResult(integration, clusterName, result)
+
+	r.Log.Info("smoke test finished", "integration", integration.Name, "cluster", clusterName, "passed", result.Passed())
+	return result.Err()
+}
+
+func (r *IntegrationReconciler) smokeTest(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, opts smoketest.Options) (smoketest.Result, error) {
+	switch integration.Spec.Type {
+	case ksitv1alpha1.IntegrationTypeArgoCD:
+		if opts.Namespace == "" {
+			opts.Namespace = "argocd"
+		}
+		c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+		if err != nil {
+			return smoketest.Result{}, err
+		}
+		return smoketest.ArgoCD(ctx, c, opts), nil
+	case ksitv1alpha1.IntegrationTypeFlux:
+		if opts.Namespace == "" {
+			opts.Namespace = "flux-system"
+		}
+		fluxClient, err := r.clients().Flux(ctx, integration, clusterName)
+		if err != nil {
+			return smoketest.Result{}, err
+		}
+		return smoketest.Flux(ctx, fluxClient, opts), nil
+	case ksitv1alpha1.IntegrationTypePrometheus:
+		promClient, err := r.clients().Prometheus(ctx, integration, clusterName)
+		if err != nil {
+			return smoketest.Result{}, err
+		}
+		return smoketest.Prometheus(ctx, promClient, opts), nil
+	case ksitv1alpha1.IntegrationTypeIstio:
+		// The injection check runs in an ordinary application namespace, not istio-system
+		opts.Namespace = "default"
+		c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+		if err != nil {
+			return smoketest.Result{}, err
+		}
+		return smoketest.Istio(ctx, c, opts), nil
+	default:
+		return smoketest.Result{}, fmt.Errorf("no smoke test for integration type %s", integration.Spec.Type)
+	}
+}
+
+// setSmokeTestResult replaces the smoke test result of a cluster in the Integration's status
+func setSmokeTestResult(integration *ksitv1alpha1.Integration, clusterName string, result smoketest.Result) {
+	now := metav1.NewTime(time.Now())
+	status := ksitv1alpha1.SmokeTestResult{
+		Cluster:     clusterName,
+		Passed:      result.Passed(),
+		LastRunTime: &now,
+	}
+	for _, check := range result.Checks {
+		status.Checks = append(status.Checks, ksitv1alpha1.SmokeTestCheck{
+			Name:    check.Name,
+			Passed:  check.Passed,
+			Message: check.Message,
+		})
+	}
+
+	for i := range integration.Status.SmokeTests {
+		if integration.Status.SmokeTests[i].Cluster == clusterName {
+			integration.Status.SmokeTests[i] = status
+			return
+		}
+	}
+	integration.Status.SmokeTests = append(integration.Status.SmokeTests, status)
+}
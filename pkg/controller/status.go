@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const (
+	// maxClusterStatuses bounds status.clusterStatuses so Integrations targeting
+	// very large fleets stay well below the etcd object size limit
+	maxClusterStatuses = 50
+
+	// minStatusInterval is the minimum time between two status writes for one
+	// Integration when only per-cluster details changed
+	minStatusInterval = 10 * time.Second
+
+	// statusHeartbeatInterval is how often an otherwise unchanged status is
+	// rewritten to refresh lastReconcileTime
+	statusHeartbeatInterval = 5 * time.Minute
+)
+
+// statusBatcher coalesces status writes per Integration. Transitions that users
+// and tooling wait on (phase, Ready, observed generation, handled sync requests)
+// are written immediately; per-cluster churn is written at most once per
+// minStatusInterval, and unchanged statuses only on the heartbeat.
+type statusBatcher struct {
+	mu          sync.Mutex
+	lastWrite   map[types.NamespacedName]time.Time
+	minInterval time.Duration
+	heartbeat   time.Duration
+}
+
+func newStatusBatcher(minInterval, heartbeat time.Duration) *statusBatcher {
+	return &statusBatcher{
+		lastWrite:   make(map[types.NamespacedName]time.Time),
+		minInterval: minInterval,
+		heartbeat:   heartbeat,
+	}
+}
+
+// decide reports whether the new status should be written now. When the write is
+// deferred, retryAfter is how long to wait before the change can be flushed.
+func (b *statusBatcher) decide(key types.NamespacedName, oldStatus, newStatus *ksitv1alpha1.IntegrationStatus, now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	last, written := b.lastWrite[key]
+	since := now.Sub(last)
+
+	switch {
+	case !written || significantStatusChange(oldStatus, newStatus):
+	case !statusEqualIgnoringTimestamps(oldStatus, newStatus):
+		if since < b.minInterval {
+			return false, b.minInterval - since
+		}
+	default:
+		if since < b.heartbeat {
+			return false, 0
+		}
+	}
+
+	b.lastWrite[key] = now
+	return true, 0
+}
+
+// forget drops the bookkeeping for a deleted Integration
+func (b *statusBatcher) forget(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.lastWrite, key)
+}
+
+func significantStatusChange(oldStatus, newStatus *ksitv1alpha1.IntegrationStatus) bool {
+	if oldStatus.Phase != newStatus.Phase ||
+		oldStatus.ObservedGeneration != newStatus.ObservedGeneration ||
+		oldStatus.LastHandledReconcileAt != newStatus.LastHandledReconcileAt ||
+		oldStatus.ReconciledBy != newStatus.ReconciledBy {
+		return true
+	}
+
+	oldReady := meta.FindStatusCondition(oldStatus.Conditions, ksitv1alpha1.ConditionTypeReady)
+	newReady := meta.FindStatusCondition(newStatus.Conditions, ksitv1alpha1.ConditionTypeReady)
+	if (oldReady == nil) != (newReady == nil) {
+		return true
+	}
+	return oldReady != nil && (oldReady.Status != newReady.Status || oldReady.Reason != newReady.Reason)
+}
+
+func statusEqualIgnoringTimestamps(oldStatus, newStatus *ksitv1alpha1.IntegrationStatus) bool {
+	a := oldStatus.DeepCopy()
+	b := newStatus.DeepCopy()
+	a.LastReconcileTime, b.LastReconcileTime = nil, nil
+	// The SLO tracker keeps its totals in memory, so they can ride along with the next write
+	a.SLO, b.SLO = nil, nil
+	for _, statuses := range [][]ksitv1alpha1.ClusterStatus{a.ClusterStatuses, b.ClusterStatuses} {
+		for i := range statuses {
+			statuses[i].LastSeen = metav1.Time{}
+			// Check durations differ on every run
+			for j := range statuses[i].Checks {
+				statuses[i].Checks[j].Duration = metav1.Duration{}
+			}
+		}
+	}
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// boundClusterStatuses keeps at most max entries, worst offenders first, and
+// returns a summary computed over the full list
+func boundClusterStatuses(statuses []ksitv1alpha1.ClusterStatus, max int) ([]ksitv1alpha1.ClusterStatus, *ksitv1alpha1.ClusterSummary) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	summary := &ksitv1alpha1.ClusterSummary{Total: int32(len(statuses))}
+	for _, cs := range statuses {
+		if cs.Connected {
+			summary.Connected++
+		} else {
+			summary.Failing++
+		}
+	}
+
+	sorted := make([]ksitv1alpha1.ClusterStatus, len(statuses))
+	copy(sorted, statuses)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Connected != sorted[j].Connected {
+			return !sorted[i].Connected
+		}
+		if !sorted[i].Connected && !sorted[i].LastSeen.Equal(&sorted[j].LastSeen) {
+			// Clusters that have been unreachable the longest come first
+			return sorted[i].LastSeen.Before(&sorted[j].LastSeen)
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	if len(sorted) > max {
+		summary.Omitted = int32(len(sorted) - max)
+		sorted = sorted[:max]
+	}
+
+	return sorted, summary
+}
+
+// writeStatus bounds the cluster statuses and patches the status subresource in a
+// single request, subject to the batcher. It returns how long to wait before a
+// deferred write can be flushed (zero if nothing is pending).
+func (r *IntegrationReconciler) writeStatus(ctx context.Context, before, integration *ksitv1alpha1.Integration) (time.Duration, error) {
+	integration.Status.ClusterStatuses, integration.Status.ClusterSummary =
+		boundClusterStatuses(integration.Status.ClusterStatuses, maxClusterStatuses)
+
+	key := types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace}
+	write, retryAfter := r.statusBatcher.decide(key, &before.Status, &integration.Status, time.Now())
+	if !write {
+		return retryAfter, nil
+	}
+
+	if err := r.Status().Patch(ctx, integration, client.MergeFrom(before)); err != nil {
+		r.statusBatcher.forget(key)
+		return 0, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestBoundClusterStatuses(t *testing.T) {
+	now := time.Now()
+	var statuses []ksitv1alpha1.ClusterStatus
+	for i := 0; i < 10; i++ {
+		statuses = append(statuses, ksitv1alpha1.ClusterStatus{
+			Name:      fmt.Sprintf("cluster-%02d", i),
+			Connected: i%3 != 0,
+			LastSeen:  metav1.NewTime(now.Add(-time.Duration(i) * time.Minute)),
+		})
+	}
+
+	bounded, summary := boundClusterStatuses(statuses, 3)
+	require.Len(t, bounded, 3)
+	require.NotNil(t, summary)
+	assert.Equal(t, int32(10), summary.Total)
+	assert.Equal(t, int32(6), summary.Connected)
+	assert.Equal(t, int32(4), summary.Failing)
+	assert.Equal(t, int32(7), summary.Omitted)
+
+	// Failing clusters first, longest unreachable first
+	assert.Equal(t, "cluster-09", bounded[0].Name)
+	assert.Equal(t, "cluster-06", bounded[1].Name)
+	assert.Equal(t, "cluster-03", bounded[2].Name)
+
+	bounded, summary = boundClusterStatuses(nil, 3)
+	assert.Nil(t, bounded)
+	assert.Nil(t, summary)
+}
+
+func TestStatusBatcher(t *testing.T) {
+	batcher := newStatusBatcher(10*time.Second, time.Minute)
+	key := types.NamespacedName{Name: "argocd", Namespace: "default"}
+	now := time.Now()
+
+	running := ksitv1alpha1.IntegrationStatus{
+		Phase:           ksitv1alpha1.PhaseRunning,
+		ClusterStatuses: []ksitv1alpha1.ClusterStatus{{Name: "cluster1", Connected: true}},
+	}
+
+	write, _ := batcher.decide(key, &ksitv1alpha1.IntegrationStatus{}, &running, now)
+	assert.True(t, write, "first write always goes through")
+
+	// Only the heartbeat timestamp changed
+	refreshed := running.DeepCopy()
+	ts := metav1.NewTime(now)
+	refreshed.LastReconcileTime = &ts
+	write, _ = batcher.decide(key, &running, refreshed, now.Add(5*time.Second))
+	assert.False(t, write)
+	write, _ = batcher.decide(key, &running, refreshed, now.Add(2*time.Minute))
+	assert.True(t, write, "heartbeat is due")
+	now = now.Add(2 * time.Minute)
+
+	// Per-cluster detail changed: deferred until minInterval has passed
+	detail := running.DeepCopy()
+	detail.ClusterStatuses[0].Message = "3 pods running"
+	write, retryAfter := batcher.decide(key, &running, detail, now.Add(4*time.Second))
+	assert.False(t, write)
+	assert.Equal(t, 6*time.Second, retryAfter)
+
+	// Phase transitions are never deferred
+	failed := running.DeepCopy()
+	failed.Phase = ksitv1alpha1.PhaseFailed
+	write, _ = batcher.decide(key, &running, failed, now.Add(time.Second))
+	assert.True(t, write)
+}
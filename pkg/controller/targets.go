@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+)
+
+// resolveTargets returns the target clusters of the Integration: spec.targetClusters,
+// plus the clusters matched by spec.targetSelector, sorted. The second list is the same
+// clusters when a selector is set, for status.selectedClusters, or nil without one. The
+// spec is left alone; the reconcile carries the targets with withTargets. A cluster is
+// selected once its IntegrationTarget has registered it.
+//
+// Cluster scope outside ClusterScopeNamespaces is reported and ignored, so an Integration
+// admitted without the webhook cannot reach other namespaces' clusters.
+func (r *IntegrationReconciler) resolveTargets(ctx context.Context, integration *ksitv1alpha1.Integration) ([]string, []string, error) {
+	clusterScoped := r.clusterScoped(integration)
+	if integration.ClusterScoped() && !clusterScoped {
+		r.event(integration, corev1.EventTypeWarning, "ClusterScopeDenied",
+			fmt.Sprintf("cluster scope is not allowed in namespace %s; only clusters registered there are targeted", integration.Namespace))
+	}
+	if integration.Spec.TargetSelector == nil {
+		return integration.Spec.TargetClusters, nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(integration.Spec.TargetSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid targetSelector: %w", err)
+	}
+
+	var opts []client.ListOption
+	if !clusterScoped {
+		opts = append(opts, client.InNamespace(integration.Namespace))
+	}
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := r.List(ctx, targets, opts...); err != nil {
+		return nil, nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+	clusters := slices.Clone(integration.Spec.TargetClusters)
+	for i := range targets.Items {
+		target := &targets.Items[i]
+		name := target.Spec.ClusterName
+		if !selector.Matches(labels.Set(cluster.TargetLabels(target))) || slices.Contains(clusters, name) {
+			continue
+		}
+		if _, err := r.ClusterManager.GetCluster(name, target.Namespace); err != nil {
+			continue
+		}
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+	return clusters, clusters, nil
+}
+
+// clusterScoped reports whether the Integration may target clusters registered in other
+// namespaces: it asks for Cluster scope, and its namespace is in ClusterScopeNamespaces.
+// The ClusterManager applies the same rule to its lookups.
+func (r *IntegrationReconciler) clusterScoped(integration *ksitv1alpha1.Integration) bool {
+	return integration.ClusterScoped() && slices.Contains(r.ClusterScopeNamespaces, integration.Namespace)
+}
+
+// pauseClusters splits clusters into those the reconcile acts on and those in
+// spec.pausedClusters
+func pauseClusters(integration *ksitv1alpha1.Integration, clusters []string) (active, paused []string) {
+	if len(integration.Spec.PausedClusters) == 0 {
+		return clusters, nil
+	}
+	for _, clusterName := range clusters {
+		if slices.Contains(integration.Spec.PausedClusters, clusterName) {
+			paused = append(paused, clusterName)
+		} else {
+			active = append(active, clusterName)
+		}
+	}
+	return active, paused
+}
+
+type targetsKey struct{}
+
+// reconcileTargets are the clusters a reconcile of one Integration acts on
+type reconcileTargets struct {
+	integration types.NamespacedName
+	clusters    []string
+}
+
+// withTargets returns a context in which the reconcile of the Integration acts on clusters
+// instead of spec.targetClusters. The spec is left alone, so the clusters survive the
+// reloads of the object from the API server during the reconcile.
+func withTargets(ctx context.Context, integration *ksitv1alpha1.Integration, clusters []string) context.Context {
+	return context.WithValue(ctx, targetsKey{}, reconcileTargets{integration: client.ObjectKeyFromObject(integration), clusters: clusters})
+}
+
+// targetClusters returns the clusters the reconcile of the Integration acts on: those
+// set by withTargets, or spec.targetClusters outside a reconcile
+func targetClusters(ctx context.Context, integration *ksitv1alpha1.Integration) []string {
+	if targets, ok := ctx.Value(targetsKey{}).(reconcileTargets); ok && targets.integration == client.ObjectKeyFromObject(integration) {
+		return targets.clusters
+	}
+	return integration.Spec.TargetClusters
+}
+
+// integrationsSelectingTarget maps an IntegrationTarget to the Integrations with a
+// targetSelector that can see it: those in its namespace and the cluster-scoped ones, so
+// a cluster that registers is picked up right away
+func (r *IntegrationReconciler) integrationsSelectingTarget(ctx context.Context, obj client.Object) []reconcile.Request {
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := r.List(ctx, integrations); err != nil {
+		r.Log.Error(err, "failed to list integrations for integration target", "target", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, integration := range integrations.Items {
+		if integration.Namespace != obj.GetNamespace() && !r.clusterScoped(&integration) {
+			continue
+		}
+		if integration.Spec.TargetSelector != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&integration)})
+		}
+	}
+	return requests
+}
+
+// applyTargetValues merges into the Integration's Helm values the Secrets named by the
+// annotations of the cluster's IntegrationTarget for the Integration's type, so cluster
+// owners can adjust the install on their cluster. Integrations without helmConfig are
+// returned unchanged.
+func applyTargetValues(ctx context.Context, c client.Reader, integration *ksitv1alpha1.Integration, target *cluster.Cluster) (*ksitv1alpha1.Integration, error) {
+	if install := integration.Spec.AutoInstall; install == nil || install.HelmConfig == nil {
+		return integration, nil
+	}
+	defaults, err := targetValues(ctx, c, integration.Spec.Type, target, ksitv1alpha1.DefaultsSecretAnnotationSuffix)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := targetValues(ctx, c, integration.Spec.Type, target, ksitv1alpha1.ValuesSecretAnnotationSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return installer.ApplyClusterValues(integration, defaults, overrides), nil
+}
+
+// targetValues reads the Helm values of the Secret that an IntegrationTarget annotation
+// names, or returns nil when the annotation is not set
+func targetValues(ctx context.Context, c client.Reader, integrationType string, target *cluster.Cluster, suffix string) (map[string]string, error) {
+	annotation := ksitv1alpha1.TargetAnnotation(integrationType, suffix)
+	name := target.Annotations[annotation]
+	if name == "" {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: target.Namespace, Name: name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s/%s named by annotation %s of cluster %s: %w", target.Namespace, name, annotation, target.Name, err)
+	}
+	values := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		values[key] = string(value)
+	}
+	return values, nil
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func selectorTarget(clusterName string, labels map[string]string) *ksitv1alpha1.IntegrationTarget {
+	return &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: "ksit-system", Labels: labels},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: clusterName},
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeFlux,
+			TargetClusters: []string{"hub"},
+			TargetSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		},
+	}
+	other := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		integration, other,
+		selectorTarget("prod-b", map[string]string{"env": "prod"}),
+		selectorTarget("prod-a", map[string]string{"env": "prod"}),
+		selectorTarget("staging", map[string]string{"env": "staging"}),
+		// Not registered yet, so not selected
+		selectorTarget("prod-new", map[string]string{"env": "prod"}),
+	).Build()
+
+	cm := cluster.NewClusterManager(c)
+	for _, name := range []string{"hub", "prod-a", "prod-b", "staging"} {
+		require.NoError(t, cm.AddCluster(name, "ksit-system", testKubeconfig("https://"+name+".example.com")))
+	}
+	r := &IntegrationReconciler{Client: c, Log: logr.Discard(), ClusterManager: cm}
+
+	targets, selected, err := r.resolveTargets(ctx, integration)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hub", "prod-a", "prod-b"}, selected)
+	assert.Equal(t, selected, targets)
+	assert.Equal(t, []string{"hub"}, integration.Spec.TargetClusters, "the spec is left alone")
+
+	// A cluster that registers is selected on the next reconcile
+	require.NoError(t, cm.AddCluster("prod-new", "ksit-system", testKubeconfig("https://prod-new.example.com")))
+	_, selected, err = r.resolveTargets(ctx, integration)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hub", "prod-a", "prod-b", "prod-new"}, selected)
+
+	requests := r.integrationsSelectingTarget(ctx, selectorTarget("prod-new", nil))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "flux", requests[0].Name)
+
+	other.Spec.TargetClusters = []string{"hub"}
+	targets, selected, err = r.resolveTargets(ctx, other)
+	require.NoError(t, err)
+	assert.Nil(t, selected)
+	assert.Equal(t, []string{"hub"}, targets)
+}
+
+func TestResolveTargetsClusterScope(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+
+	teamTarget := selectorTarget("edge-1", map[string]string{"env": "prod"})
+	teamTarget.Namespace = "team-a"
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		teamTarget,
+		selectorTarget("hub-prod", map[string]string{"env": "prod"}),
+	).Build()
+	cm := cluster.NewClusterManager(c)
+	require.NoError(t, cm.AddCluster("edge-1", "team-a", testKubeconfig("https://edge-1.example.com")))
+	require.NoError(t, cm.AddCluster("hub-prod", "ksit-system", testKubeconfig("https://hub-prod.example.com")))
+
+	newIntegration := func() *ksitv1alpha1.Integration {
+		return &ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypePrometheus,
+				Scope:          ksitv1alpha1.ScopeCluster,
+				TargetSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			},
+		}
+	}
+
+	r := &IntegrationReconciler{Client: c, Log: logr.Discard(), ClusterManager: cm, ClusterScopeNamespaces: []string{"ksit-system"}}
+	integration := newIntegration()
+	_, selected, err := r.resolveTargets(ctx, integration)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edge-1", "hub-prod"}, selected)
+	assert.True(t, integration.ClusterScoped())
+
+	// The IntegrationTarget of another namespace reconciles cluster-scoped Integrations
+	require.NoError(t, c.Create(ctx, integration))
+	requests := r.integrationsSelectingTarget(ctx, teamTarget)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "prometheus", requests[0].Name)
+
+	// Outside the allowed namespaces the Integration only sees its own namespace, and the
+	// spec is left as it is
+	r.ClusterScopeNamespaces = nil
+	integration = newIntegration()
+	_, selected, err = r.resolveTargets(ctx, integration)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hub-prod"}, selected)
+	assert.Equal(t, ksitv1alpha1.ScopeCluster, integration.Spec.Scope)
+	assert.Empty(t, r.integrationsSelectingTarget(ctx, teamTarget))
+}
+
+func TestResolveForClusterTargetValues(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-argocd", Namespace: "team-a"},
+			Data: map[string][]byte{
+				"server.service.type":              []byte("LoadBalancer"),
+				"configs.secret.argocdServerAdmin": []byte("{{ not a template }}"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-defaults", Namespace: "team-a"},
+			Data: map[string][]byte{
+				"server.replicas":     []byte("2"),
+				"global.image.tag":    []byte("v2.9.0"),
+				"server.service.type": []byte("NodePort"),
+			},
+		},
+	).Build()
+	cm := cluster.NewClusterManager(c)
+	require.NoError(t, cm.AddCluster("edge", "team-a", testKubeconfig("https://edge.example.com")))
+	require.NoError(t, cm.SetClusterAnnotations("edge", "team-a", cluster.TargetAnnotations(&ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			"ksit.io/argocd-values-secret":   "edge-argocd",
+			"ksit.io/argocd-defaults-secret": "edge-defaults",
+			"ksit.io/flux-values-secret":     "edge-flux",
+			"example.com/ignored":            "true",
+		}},
+	})))
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "team-a"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type: ksitv1alpha1.IntegrationTypeArgoCD,
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository: "https://argoproj.github.io/argo-helm", Chart: "argo-cd", ReleaseName: "argocd",
+					Values: map[string]string{"server.service.type": "ClusterIP", "server.replicas": "3"},
+				},
+			},
+		},
+	}
+
+	rendered, err := resolveForCluster(ctx, c, cm, integration, "edge")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"server.service.type":              "LoadBalancer",
+		"server.replicas":                  "3",
+		"global.image.tag":                 "v2.9.0",
+		"configs.secret.argocdServerAdmin": "{{ not a template }}",
+	}, rendered.Spec.AutoInstall.HelmConfig.Values)
+	assert.Len(t, integration.Spec.AutoInstall.HelmConfig.Values, 2, "the Integration is left untouched")
+
+	// A missing Secret fails the install on that cluster
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeFlux
+	_, err = resolveForCluster(ctx, c, cm, integration, "edge")
+	assert.ErrorContains(t, err, "failed to get Secret team-a/edge-flux named by annotation ksit.io/flux-values-secret of cluster edge")
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// handleUninstallRequests uninstalls the tool from each cluster listed in the uninstall
+// annotation, then removes the annotation. The Integration is kept, so with autoInstall
+// enabled the next reconcile installs the tool again from scratch. Adopted installs are
+// uninstalled too, since the request is explicit. Results are reported as events.
+func (r *IntegrationReconciler) handleUninstallRequests(ctx context.Context, integration *ksitv1alpha1.Integration) error {
+	clusters := integration.UninstallRequests()
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	for _, clusterName := range clusters {
+		if err := r.forceUninstall(ctx, integration, clusterName); err != nil {
+			r.Log.Error(err, "requested uninstall failed", "integration", integration.Name, "cluster", clusterName)
+			r.event(integration, corev1.EventTypeWarning, "UninstallFailed", err.Error())
+			continue
+		}
+		r.Log.Info("uninstalled integration on request", "integration", integration.Name, "cluster", clusterName)
+		r.event(integration, corev1.EventTypeNormal, "Uninstalled", fmt.Sprintf("Uninstalled from cluster %s on request", clusterName))
+	}
+
+	patch := client.MergeFrom(integration.DeepCopy())
+	delete(integration.Annotations, ksitv1alpha1.UninstallAnnotation)
+	if err := r.Patch(ctx, integration, patch); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", ksitv1alpha1.UninstallAnnotation, err)
+	}
+	return nil
+}
+
+// forceUninstall runs the installer's Uninstall against one cluster and records it
+func (r *IntegrationReconciler) forceUninstall(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) error {
+	if !slices.Contains(targetClusters(ctx, integration), clusterName) {
+		return fmt.Errorf("cluster %s is not a target of the integration", clusterName)
+	}
+	if r.InstallerFactory == nil {
+		return fmt.Errorf("no installer factory is configured")
+	}
+	inst, err := r.InstallerFactory.InstallerFor(integration)
+	if err != nil {
+		return fmt.Errorf("failed to get installer: %w", err)
+	}
+	config, err := r.ClusterManager.GetIntegrationConfig(clusterName, integration)
+	if err != nil {
+		return fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+	}
+	rendered, err := resolveForCluster(ctx, r.Client, r.ClusterManager, integration, clusterName)
+	if err != nil {
+		return err
+	}
+
+	uninstallErr := inst.Uninstall(ctx, config, rendered)
+	r.recordInstall(ctx, rendered, clusterName, ksitv1alpha1.InstallActionUninstall, uninstallErr)
+	if uninstallErr != nil {
+		return fmt.Errorf("failed to uninstall from cluster %s: %w", clusterName, uninstallErr)
+	}
+	return nil
+}
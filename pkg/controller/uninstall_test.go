@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestRequestUninstall(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{}
+	assert.Empty(t, integration.UninstallRequests())
+
+	integration.RequestUninstall("cluster-a")
+	integration.RequestUninstall("cluster-b")
+	integration.RequestUninstall("cluster-a")
+	assert.Equal(t, "cluster-a,cluster-b", integration.Annotations[ksitv1alpha1.UninstallAnnotation])
+
+	integration.Annotations[ksitv1alpha1.UninstallAnnotation] = " cluster-a, ,cluster-c"
+	assert.Equal(t, []string{"cluster-a", "cluster-c"}, integration.UninstallRequests())
+}
+
+func TestHandleUninstallRequests(t *testing.T) {
+	ctx := context.Background()
+	inst := newRecordingInstaller()
+	integration := autoInstallIntegration()
+	r, hosts := newAutoInstallReconciler(t, inst, integration, "cluster-a", "cluster-b")
+	require.NoError(t, r.handleAutoInstall(ctx, integration))
+
+	integration.RequestUninstall("cluster-a")
+	integration.RequestUninstall("cluster-x")
+	require.NoError(t, r.Update(ctx, integration))
+	require.NoError(t, r.handleUninstallRequests(ctx, integration))
+
+	// Only the target cluster is uninstalled, and the request is cleared either way
+	assert.Equal(t, []string{hosts["cluster-a"]}, inst.uninstalls)
+	stored := &ksitv1alpha1.Integration{}
+	require.NoError(t, r.Get(ctx, client.ObjectKeyFromObject(integration), stored))
+	assert.NotContains(t, stored.Annotations, ksitv1alpha1.UninstallAnnotation)
+	recorded, err := r.Ledger.Get(ctx, integration, "cluster-a")
+	require.NoError(t, err)
+	assert.Equal(t, ksitv1alpha1.InstallActionUninstall, recorded.Status.LastAction)
+
+	// The Integration is kept, so auto-install puts the tool back
+	require.NoError(t, r.handleAutoInstall(ctx, stored))
+	assert.Equal(t, []string{hosts["cluster-a"], hosts["cluster-b"], hosts["cluster-a"]}, inst.installs)
+}
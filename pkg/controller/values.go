@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/installer"
+)
+
+// defaultValuesKey is the entry of a valuesFrom ConfigMap or Secret read when valuesKey is not set
+const defaultValuesKey = "values.yaml"
+
+// applyValuesFrom reads the values files of the Integration's helmConfig.valuesFrom from
+// its namespace and merges them into its valuesObject. Like Secret values, the files are
+// not rendered as templates.
+func applyValuesFrom(ctx context.Context, c client.Reader, integration *ksitv1alpha1.Integration) (*ksitv1alpha1.Integration, error) {
+	install := integration.Spec.AutoInstall
+	if install == nil || install.HelmConfig == nil || len(install.HelmConfig.ValuesFrom) == 0 {
+		return integration, nil
+	}
+
+	var files [][]byte
+	for _, ref := range install.HelmConfig.ValuesFrom {
+		file, err := valuesFile(ctx, c, integration.Namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	return installer.ApplyValuesFrom(integration, files)
+}
+
+// valuesFile returns the values file a valuesFrom entry names, or nil when an optional
+// entry does not exist
+func valuesFile(ctx context.Context, c client.Reader, namespace string, ref ksitv1alpha1.ValuesReference) ([]byte, error) {
+	key := ref.ValuesKey
+	if key == "" {
+		key = defaultValuesKey
+	}
+	name := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+
+	var data map[string][]byte
+	switch ref.Kind {
+	case "ConfigMap":
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, name, cm); err != nil {
+			if errors.IsNotFound(err) && ref.Optional {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get ConfigMap %s for helm values: %w", ref.Name, err)
+		}
+		data = make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+	case "Secret":
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, name, secret); err != nil {
+			if errors.IsNotFound(err) && ref.Optional {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get Secret %s for helm values: %w", ref.Name, err)
+		}
+		data = secret.Data
+	default:
+		return nil, fmt.Errorf("unsupported valuesFrom kind %q", ref.Kind)
+	}
+
+	file, ok := data[key]
+	if !ok {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("key %s not found in %s %s for helm values", key, ref.Kind, ref.Name)
+	}
+	return file, nil
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestApplyValuesFrom(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-base", Namespace: "team-a"},
+			Data:       map[string]string{"values.yaml": "server:\n  replicas: 2\n  insecure: true\n"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-sso", Namespace: "team-a"},
+			Data:       map[string][]byte{"sso.yaml": []byte("dex:\n  enabled: false\n")},
+		},
+	).Build()
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "team-a"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Chart: "argo-cd",
+					ValuesFrom: []ksitv1alpha1.ValuesReference{
+						{Kind: "ConfigMap", Name: "argocd-base"},
+						{Kind: "Secret", Name: "argocd-sso", ValuesKey: "sso.yaml"},
+						{Kind: "ConfigMap", Name: "argocd-extra", Optional: true},
+					},
+				},
+			},
+		},
+	}
+
+	resolved, err := applyValuesFrom(ctx, c, integration)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"server":{"replicas":2,"insecure":true},"dex":{"enabled":false}}`,
+		string(resolved.Spec.AutoInstall.HelmConfig.ValuesObject.Raw))
+
+	integration.Spec.AutoInstall.HelmConfig.ValuesFrom[2].Optional = false
+	_, err = applyValuesFrom(ctx, c, integration)
+	assert.ErrorContains(t, err, "argocd-extra")
+}
+
+func TestSetHelmReleases(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{}
+	setHelmReleases(integration, "cluster1", []ksitv1alpha1.HelmReleaseStatus{{Name: "istio-base", Status: "deployed"}, {Name: "istiod", Status: "deployed"}})
+	setHelmReleases(integration, "cluster2", []ksitv1alpha1.HelmReleaseStatus{{Name: "istio-base", Status: "deployed"}})
+	setHelmReleases(integration, "cluster1", []ksitv1alpha1.HelmReleaseStatus{{Name: "istio-base", Status: "deployed"}, {Name: "istiod", Status: "failed"}})
+
+	assert.Equal(t, []ksitv1alpha1.HelmReleaseStatus{
+		{Cluster: "cluster1", Name: "istio-base", Status: "deployed"},
+		{Cluster: "cluster1", Name: "istiod", Status: "failed"},
+		{Cluster: "cluster2", Name: "istio-base", Status: "deployed"},
+	}, integration.Status.HelmReleases)
+}
+
+func TestPruneHelmReleases(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{}
+	integration.Status.PausedClusters = []string{"cluster3"}
+	integration.Status.HelmReleases = []ksitv1alpha1.HelmReleaseStatus{
+		{Cluster: "cluster1", Name: "argocd", Status: "deployed"},
+		{Cluster: "cluster2", Name: "argocd", Status: "deployed"},
+		{Cluster: "cluster3", Name: "argocd", Status: "deployed"},
+	}
+	pruneHelmReleases(integration, []string{"cluster1"})
+
+	assert.Equal(t, []ksitv1alpha1.HelmReleaseStatus{
+		{Cluster: "cluster1", Name: "argocd", Status: "deployed"},
+		{Cluster: "cluster3", Name: "argocd", Status: "deployed"},
+	}, integration.Status.HelmReleases)
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+// ClusterWarmup restores the clusters of all IntegrationTargets into the ClusterManager,
+// and through it the ClusterInventory, when the controller starts. Both are in memory
+// only, so without it a restarted controller knows no cluster until each
+// IntegrationTarget happens to be reconciled.
+//
+// It runs on every replica once the caches are synced. Controllers start at the same
+// time, so reconcilers that need the clusters wait for it.
+type ClusterWarmup struct {
+	ClusterManager *cluster.ClusterManager
+	Log            logr.Logger
+
+	done chan struct{}
+}
+
+func NewClusterWarmup(cm *cluster.ClusterManager, log logr.Logger) *ClusterWarmup {
+	return &ClusterWarmup{ClusterManager: cm, Log: log, done: make(chan struct{})}
+}
+
+// Start loads the clusters once. A failure is logged rather than returned: the
+// IntegrationTarget controller registers the clusters on its own, only later.
+func (w *ClusterWarmup) Start(ctx context.Context) error {
+	defer close(w.done)
+
+	skipped, err := w.ClusterManager.LoadTargets(ctx, "")
+	if err != nil {
+		w.Log.Error(err, "failed to restore clusters from integration targets")
+		return nil
+	}
+	for name, reason := range skipped {
+		w.Log.Info("cluster not restored", "cluster", name, "reason", reason.Error())
+	}
+	w.Log.Info("restored clusters from integration targets",
+		"clusters", len(w.ClusterManager.ListClusters()), "skipped", len(skipped))
+	return nil
+}
+
+// NeedLeaderElection is false so that standby replicas are warm when they take over
+func (w *ClusterWarmup) NeedLeaderElection() bool {
+	return false
+}
+
+// Wait blocks until the warm-up has finished or ctx is done. A nil warm-up does not wait.
+func (w *ClusterWarmup) Wait(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Checker is a readyz check that fails until the warm-up has finished
+func (w *ClusterWarmup) Checker(_ *http.Request) error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+		return fmt.Errorf("clusters are not restored yet")
+	}
+}
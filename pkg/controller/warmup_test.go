@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func TestClusterWarmup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ksitv1alpha1.IntegrationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-1", Namespace: "ksit-system", Labels: map[string]string{"region": "eu"}},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-1"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-1-kubeconfig", Namespace: "ksit-system"},
+			Data:       map[string][]byte{"kubeconfig": []byte(testKubeconfig("https://edge-1.example.com"))},
+		},
+		// No kubeconfig Secret yet
+		&ksitv1alpha1.IntegrationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-2", Namespace: "fleet"},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-2"},
+		},
+	).Build()
+
+	cm := cluster.NewClusterManager(c)
+	inventory := cluster.NewClusterInventory()
+	inventory.Track(cm)
+	warmup := NewClusterWarmup(cm, logr.Discard())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, warmup.Wait(ctx), "waits until the warm-up has run")
+	assert.Error(t, warmup.Checker(nil))
+
+	require.NoError(t, warmup.Start(context.Background()))
+	require.NoError(t, warmup.Wait(context.Background()))
+	assert.NoError(t, warmup.Checker(nil))
+
+	registered, err := cm.GetCluster("edge-1", "ksit-system")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"region": "eu"}, registered.Labels)
+	_, err = cm.GetCluster("edge-2", "fleet")
+	assert.Error(t, err)
+
+	info, err := inventory.GetCluster("edge-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ksit-system", info.Namespace)
+	assert.Equal(t, 1, inventory.Count())
+
+	var none *ClusterWarmup
+	assert.NoError(t, none.Wait(context.Background()))
+}
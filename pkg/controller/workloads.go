@@ -0,0 +1,332 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/conditions"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+// workloadsFieldManager owns the fields of the objects applied from spec.workloads
+const workloadsFieldManager = "ksit-workloads"
+
+// workloadObjects decodes spec.workloads: the inline manifests, then the manifests of
+// each ConfigMap in key order. Every object is labeled with the Integration's name.
+func (r *IntegrationReconciler) workloadObjects(ctx context.Context, integration *ksitv1alpha1.Integration) ([]*unstructured.Unstructured, error) {
+	spec := integration.Spec.Workloads
+	if spec == nil {
+		return nil, nil
+	}
+
+	var objs []*unstructured.Unstructured
+	for i, manifest := range spec.Manifests {
+		decoded, err := manifests.Decode([]byte(manifest))
+		if err != nil {
+			return nil, fmt.Errorf("workloads.manifests[%d]: %w", i, err)
+		}
+		objs = append(objs, decoded...)
+	}
+	for _, name := range spec.ConfigMaps {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: integration.Namespace, Name: name}, cm); err != nil {
+			return nil, fmt.Errorf("failed to get workloads ConfigMap %s: %w", name, err)
+		}
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			decoded, err := manifests.Decode([]byte(cm.Data[key]))
+			if err != nil {
+				return nil, fmt.Errorf("workloads ConfigMap %s key %s: %w", name, key, err)
+			}
+			objs = append(objs, decoded...)
+		}
+	}
+
+	for _, obj := range objs {
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("workload %q needs an apiVersion, a kind and a name", obj.GetName())
+		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ksitv1alpha1.LabelIntegration] = integration.Name
+		obj.SetLabels(labels)
+	}
+	// CRDs and namespaces go first so the objects that need them can be applied
+	sort.SliceStable(objs, func(i, j int) bool {
+		return workloadRank(objs[i]) < workloadRank(objs[j])
+	})
+	return objs, nil
+}
+
+func workloadRank(obj *unstructured.Unstructured) int {
+	switch obj.GetKind() {
+	case "CustomResourceDefinition":
+		return 0
+	case "Namespace":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// workloadsNamespace is where namespaced workloads without a namespace are applied
+func workloadsNamespace(integration *ksitv1alpha1.Integration) string {
+	if spec := integration.Spec.Workloads; spec != nil && spec.Namespace != "" {
+		return spec.Namespace
+	}
+	return corev1.NamespaceDefault
+}
+
+// reconcileWorkloads server-side applies spec.workloads to every target cluster, then
+// deletes the objects of status.appliedWorkloads that are no longer declared. Objects
+// that could not be deleted everywhere stay in status.appliedWorkloads and are retried
+// on the next reconcile. The outcome is reported in the WorkloadsApplied condition.
+func (r *IntegrationReconciler) reconcileWorkloads(ctx context.Context, integration *ksitv1alpha1.Integration) {
+	if integration.Spec.Workloads == nil && len(integration.Status.AppliedWorkloads) == 0 {
+		meta.RemoveStatusCondition(&integration.Status.Conditions, ksitv1alpha1.ConditionTypeWorkloadsApplied)
+		return
+	}
+
+	objs, err := r.workloadObjects(ctx, integration)
+	if err != nil {
+		// Nothing is pruned while the declared workloads cannot be read
+		r.setWorkloadsCondition(integration, metav1.ConditionFalse, conditions.Reason(err, "InvalidWorkloads"), err.Error())
+		return
+	}
+
+	namespace := workloadsNamespace(integration)
+	clusters := targetClusters(ctx, integration)
+	failures := map[string]error{}
+	errs := r.visitClusters(ctx, integration, func(ctx context.Context, clusterName string) error {
+		return r.applyWorkloads(ctx, integration, clusterName, objs, namespace)
+	})
+	for i, err := range errs {
+		if err != nil {
+			failures[clusters[i]] = err
+		}
+	}
+
+	// Refs are taken from the declared objects, not from the copies each cluster applied,
+	// so they do not depend on which clusters were reached
+	desired := make(map[ksitv1alpha1.WorkloadRef]bool, len(objs))
+	applied := make([]ksitv1alpha1.WorkloadRef, 0, len(objs))
+	for _, obj := range objs {
+		ref := workloadRef(obj)
+		if ref.Namespace == "" && r.workloadNamespaced(obj) {
+			// Refs recorded before namespaces were defaulted here have none
+			desired[ref] = true
+			ref.Namespace = namespace
+		}
+		if !desired[ref] {
+			desired[ref] = true
+			applied = append(applied, ref)
+		}
+	}
+	var stale []ksitv1alpha1.WorkloadRef
+	for _, ref := range integration.Status.AppliedWorkloads {
+		if !desired[ref] {
+			stale = append(stale, ref)
+		}
+	}
+	integration.Status.AppliedWorkloads = append(applied, r.pruneWorkloads(ctx, integration, stale, namespace, failures)...)
+
+	if len(failures) > 0 {
+		r.setWorkloadsCondition(integration, metav1.ConditionFalse, "ApplyFailed", clusterFailures(failures))
+		return
+	}
+	r.setWorkloadsCondition(integration, metav1.ConditionTrue, "Applied",
+		fmt.Sprintf("Applied %d objects to %d clusters", len(objs), len(clusters)))
+}
+
+// workloadNamespaced reports whether obj may be namespaced, going by the hub's API.
+// Kinds the hub does not serve, such as those of CRDs in the workloads, are taken as
+// namespaced: deleting them clears the namespace again if they are not.
+func (r *IntegrationReconciler) workloadNamespaced(obj *unstructured.Unstructured) bool {
+	namespaced, err := r.IsObjectNamespaced(obj)
+	return err != nil || namespaced
+}
+
+// applyWorkloads server-side applies objs to one cluster, stopping at the first failure
+func (r *IntegrationReconciler) applyWorkloads(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, objs []*unstructured.Unstructured, namespace string) error {
+	if len(objs) == 0 {
+		return nil
+	}
+	c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		// Clusters are applied concurrently, and Apply changes the object to the
+		// server's response, so each cluster gets a copy
+		applied := obj.DeepCopy()
+		if err := setWorkloadNamespace(c, applied, namespace); err != nil {
+			return err
+		}
+		if err := c.Patch(ctx, applied, client.Apply, client.FieldOwner(workloadsFieldManager), client.ForceOwnership); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// pruneWorkloads deletes refs from every target cluster, in reverse order. Clusters
+// where a deletion fails are added to failures. It returns the refs that could not be
+// deleted everywhere.
+func (r *IntegrationReconciler) pruneWorkloads(ctx context.Context, integration *ksitv1alpha1.Integration, refs []ksitv1alpha1.WorkloadRef, namespace string, failures map[string]error) []ksitv1alpha1.WorkloadRef {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	var mu sync.Mutex
+	failed := make(map[ksitv1alpha1.WorkloadRef]bool, len(refs))
+	visited := make(map[string]bool)
+	clusters := targetClusters(ctx, integration)
+	errs := r.visitClusters(ctx, integration, func(ctx context.Context, clusterName string) error {
+		mu.Lock()
+		visited[clusterName] = true
+		mu.Unlock()
+		var firstErr error
+		for i := len(refs) - 1; i >= 0; i-- {
+			if err := r.deleteWorkloadFrom(ctx, integration, clusterName, refs[i], namespace); err != nil {
+				mu.Lock()
+				failed[refs[i]] = true
+				mu.Unlock()
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		return firstErr
+	})
+
+	skipped := false
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if failures[clusters[i]] == nil {
+			failures[clusters[i]] = err
+		}
+		// Nothing was deleted from a skipped cluster
+		skipped = skipped || !visited[clusters[i]]
+	}
+	if skipped {
+		return refs
+	}
+	var remaining []ksitv1alpha1.WorkloadRef
+	for _, ref := range refs {
+		if failed[ref] {
+			remaining = append(remaining, ref)
+		}
+	}
+	return remaining
+}
+
+func (r *IntegrationReconciler) deleteWorkloadFrom(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, ref ksitv1alpha1.WorkloadRef, namespace string) error {
+	c, err := r.clients().Kubernetes(ctx, integration, clusterName)
+	if err != nil {
+		return err
+	}
+	obj := refObject(ref)
+	if err := setWorkloadNamespace(c, obj, namespace); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	err = c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to delete %s %s: %w", ref.Kind, ref.Name, err)
+	}
+	return nil
+}
+
+// cleanupWorkloads deletes every applied workload from every target cluster, for
+// Integrations that are being deleted or uninstalled on disable. Only the workloads
+// that could not be deleted are left in status.appliedWorkloads. It returns the clusters
+// where it failed.
+func (r *IntegrationReconciler) cleanupWorkloads(ctx context.Context, integration *ksitv1alpha1.Integration) map[string]error {
+	failures := map[string]error{}
+	integration.Status.AppliedWorkloads = r.pruneWorkloads(ctx, integration, integration.Status.AppliedWorkloads, workloadsNamespace(integration), failures)
+	return failures
+}
+
+// setWorkloadNamespace sets namespace on a namespaced obj that has none, and clears it
+// on a cluster-scoped one
+func setWorkloadNamespace(c client.Client, obj *unstructured.Unstructured, namespace string) error {
+	namespaced, err := c.IsObjectNamespaced(obj)
+	if err != nil {
+		return fmt.Errorf("failed to find resource of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	switch {
+	case !namespaced:
+		obj.SetNamespace("")
+	case obj.GetNamespace() == "":
+		obj.SetNamespace(namespace)
+	}
+	return nil
+}
+
+func workloadRef(obj *unstructured.Unstructured) ksitv1alpha1.WorkloadRef {
+	return ksitv1alpha1.WorkloadRef{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+func refObject(ref ksitv1alpha1.WorkloadRef) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(ref.APIVersion)
+	obj.SetKind(ref.Kind)
+	obj.SetNamespace(ref.Namespace)
+	obj.SetName(ref.Name)
+	return obj
+}
+
+func (r *IntegrationReconciler) setWorkloadsCondition(integration *ksitv1alpha1.Integration, status metav1.ConditionStatus, reason, message string) {
+	previous := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeWorkloadsApplied)
+	changed := previous == nil || previous.Status != status || previous.Message != message
+	meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+		Type:    ksitv1alpha1.ConditionTypeWorkloadsApplied,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	if changed && status == metav1.ConditionFalse {
+		r.event(integration, corev1.EventTypeWarning, "WorkloadsFailed", message)
+	}
+}
+
+// clusterFailures describes per-cluster errors in cluster order
+func clusterFailures(failures map[string]error) string {
+	clusters := make([]string, 0, len(failures))
+	for clusterName := range failures {
+		clusters = append(clusters, clusterName)
+	}
+	sort.Strings(clusters)
+	messages := make([]string, len(clusters))
+	for i, clusterName := range clusters {
+		messages[i] = fmt.Sprintf("%s: %v", clusterName, failures[clusterName])
+	}
+	return strings.Join(messages, "; ")
+}
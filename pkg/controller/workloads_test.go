@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/factory"
+)
+
+// newWorkloadTargets returns a reconciler whose hub serves hubObjects and whose target
+// clusters are fake clients that record server-side applies
+func newWorkloadTargets(t *testing.T, applied map[string][]string, hubObjects ...client.Object) (*IntegrationReconciler, map[string]client.Client) {
+	// Namespaced lookups carry no version, so the mapper needs a default one
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+
+	cm := cluster.NewClusterManager(nil)
+	targets := make(map[string]client.Client)
+	// Clusters are applied concurrently
+	var mu sync.Mutex
+	for _, name := range []string{"cluster-a", "cluster-b"} {
+		name := name
+		require.NoError(t, cm.AddCluster(name, "ksit-system", testKubeconfig("https://"+name+".example.com")))
+		// The fake client does not support apply patches, so applies create the object
+		targets["https://"+name+".example.com"] = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).
+			WithInterceptorFuncs(interceptor.Funcs{Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() != types.ApplyPatchType {
+					return c.Patch(ctx, obj, patch, opts...)
+				}
+				mu.Lock()
+				applied[name] = append(applied[name], obj.GetObjectKind().GroupVersionKind().Kind+" "+obj.GetNamespace()+"/"+obj.GetName())
+				mu.Unlock()
+				if err := c.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+					return err
+				}
+				return nil
+			}}).Build()
+	}
+
+	clients := factory.New(cm, scheme.Scheme, logr.Discard())
+	clients.NewClient = func(config *rest.Config) (client.Client, error) {
+		return targets[config.Host], nil
+	}
+	hub := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(hubObjects...).Build()
+	return &IntegrationReconciler{Client: hub, Log: logr.Discard(), ClusterManager: cm, Clients: clients}, targets
+}
+
+func workloadsIntegration() *ksitv1alpha1.Integration {
+	return &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			TargetClusters: []string{"cluster-a", "cluster-b"},
+			Workloads: &ksitv1alpha1.WorkloadsSpec{
+				Namespace: "apps",
+				Manifests: []string{`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: apps
+`},
+				ConfigMaps: []string{"extra"},
+			},
+		},
+	}
+}
+
+func TestReconcileWorkloads(t *testing.T) {
+	ctx := context.Background()
+	extra := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "extra", Namespace: "ksit-system"},
+		Data: map[string]string{
+			"b.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: frontend\n",
+			"a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: first\n",
+		},
+	}
+	applied := map[string][]string{}
+	r, targets := newWorkloadTargets(t, applied, extra)
+	integration := workloadsIntegration()
+
+	r.reconcileWorkloads(ctx, integration)
+
+	// Namespaces first, then inline manifests before ConfigMap keys in key order
+	want := []string{"Namespace /apps", "ConfigMap apps/settings", "ConfigMap apps/first", "Service frontend/web"}
+	assert.Equal(t, map[string][]string{"cluster-a": want, "cluster-b": want}, applied)
+	assert.Equal(t, []ksitv1alpha1.WorkloadRef{
+		{APIVersion: "v1", Kind: "Namespace", Name: "apps"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "apps", Name: "settings"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "apps", Name: "first"},
+		{APIVersion: "v1", Kind: "Service", Namespace: "frontend", Name: "web"},
+	}, integration.Status.AppliedWorkloads)
+	condition := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeWorkloadsApplied)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status, condition.Message)
+
+	settings := &corev1.ConfigMap{}
+	require.NoError(t, targets["https://cluster-a.example.com"].Get(ctx, types.NamespacedName{Namespace: "apps", Name: "settings"}, settings))
+	assert.Equal(t, "apps", settings.Labels[ksitv1alpha1.LabelIntegration])
+
+	// Objects that are no longer declared are pruned from every cluster
+	integration.Spec.Workloads.ConfigMaps = nil
+	r.reconcileWorkloads(ctx, integration)
+	assert.Len(t, integration.Status.AppliedWorkloads, 2)
+	for _, target := range targets {
+		err := target.Get(ctx, types.NamespacedName{Namespace: "frontend", Name: "web"}, &corev1.Service{})
+		assert.True(t, apierrors.IsNotFound(err), "service should be pruned")
+		assert.NoError(t, target.Get(ctx, types.NamespacedName{Namespace: "apps", Name: "settings"}, &corev1.ConfigMap{}))
+	}
+}
+
+func TestReconcileWorkloadsInvalidManifest(t *testing.T) {
+	r, _ := newWorkloadTargets(t, map[string][]string{})
+	integration := workloadsIntegration()
+	integration.Spec.Workloads.ConfigMaps = nil
+	integration.Spec.Workloads.Manifests = []string{"kind: ConfigMap\nmetadata:\n  name: settings\n"}
+	previous := []ksitv1alpha1.WorkloadRef{{APIVersion: "v1", Kind: "ConfigMap", Namespace: "apps", Name: "old"}}
+	integration.Status.AppliedWorkloads = previous
+
+	r.reconcileWorkloads(context.Background(), integration)
+
+	condition := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeWorkloadsApplied)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "InvalidWorkloads", condition.Reason)
+	// Nothing is pruned while the workloads cannot be decoded
+	assert.Equal(t, previous, integration.Status.AppliedWorkloads)
+}
+
+func TestReconcileWorkloadsUnreachableCluster(t *testing.T) {
+	ctx := context.Background()
+	r, targets := newWorkloadTargets(t, map[string][]string{}, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "extra", Namespace: "ksit-system"}})
+	integration := workloadsIntegration()
+	integration.Spec.TargetClusters = []string{"missing", "cluster-a"}
+
+	// Refs do not depend on which clusters were reached
+	r.reconcileWorkloads(ctx, integration)
+	assert.Equal(t, []ksitv1alpha1.WorkloadRef{
+		{APIVersion: "v1", Kind: "Namespace", Name: "apps"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "apps", Name: "settings"},
+	}, integration.Status.AppliedWorkloads)
+	condition := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeWorkloadsApplied)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Contains(t, condition.Message, "missing")
+
+	// Workloads that could not be deleted everywhere stay recorded
+	assert.Contains(t, r.cleanupWorkloads(ctx, integration), "missing")
+	assert.Len(t, integration.Status.AppliedWorkloads, 2)
+	err := targets["https://cluster-a.example.com"].Get(ctx, types.NamespacedName{Namespace: "apps", Name: "settings"}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Once they are, the status no longer lists them
+	integration.Spec.TargetClusters = []string{"cluster-a"}
+	assert.Empty(t, r.cleanupWorkloads(ctx, integration))
+	assert.Empty(t, integration.Status.AppliedWorkloads)
+}
+
+func TestDisableIntegrationDeletesWorkloads(t *testing.T) {
+	ctx := context.Background()
+	r, targets := newWorkloadTargets(t, map[string][]string{}, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "extra", Namespace: "ksit-system"}})
+	integration := workloadsIntegration()
+	r.reconcileWorkloads(ctx, integration)
+	require.Len(t, integration.Status.AppliedWorkloads, 2)
+
+	// Workloads go even when the tool is left in place
+	assert.Empty(t, r.disableIntegration(ctx, integration))
+	assert.Empty(t, integration.Status.AppliedWorkloads)
+	for _, target := range targets {
+		err := target.Get(ctx, types.NamespacedName{Namespace: "apps", Name: "settings"}, &corev1.ConfigMap{})
+		assert.True(t, apierrors.IsNotFound(err))
+	}
+}
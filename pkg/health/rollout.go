@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterStatus is the status of a workload on one cluster
+type ClusterStatus struct {
+	Cluster string
+	Status
+	// Err is set when the workload could not be read or, for Jobs, has failed
+	Err error
+}
+
+// Ready reports whether the workload is ready on the cluster
+func (s ClusterStatus) Ready() bool {
+	return s.Err == nil && s.Status.Ready
+}
+
+// CheckAcrossClusters checks a workload on every cluster in parallel. Results are sorted by cluster name.
+func CheckAcrossClusters(ctx context.Context, clients map[string]client.Client, w Workload) []ClusterStatus {
+	results := make([]ClusterStatus, 0, len(clients))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, c := range clients {
+		wg.Add(1)
+		go func(name string, c client.Client) {
+			defer wg.Done()
+			status, err := Check(ctx, c, w)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, ClusterStatus{Cluster: name, Status: status, Err: err})
+		}(name, c)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+	return results
+}
+
+// WaitForWorkloadReadyAcrossClusters waits until a workload is ready on every cluster. It
+// always returns the last status of each cluster, and an error naming the clusters that
+// were not ready when the timeout expired. A failed Job stops the wait right away.
+func WaitForWorkloadReadyAcrossClusters(ctx context.Context, clients map[string]client.Client, w Workload, timeout time.Duration) ([]ClusterStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		results := CheckAcrossClusters(ctx, clients, w)
+
+		var pending, failed []string
+		for _, result := range results {
+			switch {
+			case errors.Is(result.Err, ErrJobFailed):
+				failed = append(failed, result.Cluster)
+			case !result.Ready():
+				pending = append(pending, result.Cluster)
+			}
+		}
+		if len(failed) > 0 {
+			return results, fmt.Errorf("%s failed on %s: %w", w, strings.Join(failed, ", "), ErrJobFailed)
+		}
+		if len(pending) == 0 {
+			return results, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return results, fmt.Errorf("timeout waiting for %s to be ready on %s", w, strings.Join(pending, ", "))
+		case <-ticker.C:
+		}
+	}
+}
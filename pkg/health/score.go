@@ -0,0 +1,110 @@
+package health
+
+import (
+	"math"
+)
+
+// Health score signals, used as keys of the per-signal sub-scores
+const (
+	SignalControlPlane = "controlPlane"
+	SignalEndpoints    = "endpoints"
+	SignalAPIProbe     = "apiProbe"
+	SignalVersionSkew  = "versionSkew"
+	SignalAlerts       = "alerts"
+)
+
+// alertsForZero is the number of firing alerts at which the alert signal scores 0
+const alertsForZero = 5
+
+// Signals are the observations a health score is computed from. Signals that could
+// not be observed are nil and are left out of the score.
+type Signals struct {
+	// ControlPlaneReady of ControlPlaneTotal control plane workloads are ready
+	ControlPlaneReady int
+	ControlPlaneTotal int
+
+	// EndpointsReady of EndpointsTotal Services have at least one ready endpoint
+	EndpointsReady int
+	EndpointsTotal int
+
+	// APIProbe is whether the tool's own API answered
+	APIProbe *bool
+
+	// VersionSkew is whether the cluster runs other versions than most of the fleet
+	VersionSkew *bool
+
+	// FiringAlerts is the number of alerts firing
+	FiringAlerts *int
+}
+
+// Weights set how much each signal counts towards the score
+type Weights map[string]int32
+
+// DefaultWeights favor the control plane, which decides whether the tool works at all
+var DefaultWeights = Weights{
+	SignalControlPlane: 40,
+	SignalEndpoints:    20,
+	SignalAPIProbe:     20,
+	SignalVersionSkew:  10,
+	SignalAlerts:       10,
+}
+
+// Score returns the 0-100 health score of the signals and the sub-score of every
+// observed signal. The weights of the observed signals are scaled to add up to 100.
+func Score(signals Signals, weights Weights) (int32, map[string]int32) {
+	components := map[string]int32{}
+	if signals.ControlPlaneTotal > 0 {
+		components[SignalControlPlane] = ratio(signals.ControlPlaneReady, signals.ControlPlaneTotal)
+	}
+	if signals.EndpointsTotal > 0 {
+		components[SignalEndpoints] = ratio(signals.EndpointsReady, signals.EndpointsTotal)
+	}
+	if signals.APIProbe != nil {
+		components[SignalAPIProbe] = boolScore(*signals.APIProbe)
+	}
+	if signals.VersionSkew != nil {
+		components[SignalVersionSkew] = boolScore(!*signals.VersionSkew)
+	}
+	if signals.FiringAlerts != nil {
+		components[SignalAlerts] = ratio(max(alertsForZero-*signals.FiringAlerts, 0), alertsForZero)
+	}
+
+	var total, weighted float64
+	for signal, score := range components {
+		weight := float64(weights[signal])
+		total += weight
+		weighted += weight * float64(score)
+	}
+	if total == 0 {
+		return 0, components
+	}
+	return int32(math.Round(weighted / total)), components
+}
+
+// Aggregate returns the fleet score of per-cluster scores: their mean, lowered to the
+// worst cluster plus 50 so that a single broken cluster stays visible in a large fleet
+func Aggregate(scores []int32) int32 {
+	if len(scores) == 0 {
+		return 0
+	}
+
+	worst := scores[0]
+	var sum float64
+	for _, score := range scores {
+		sum += float64(score)
+		worst = min(worst, score)
+	}
+	mean := int32(math.Round(sum / float64(len(scores))))
+	return min(mean, worst+50)
+}
+
+func ratio(n, total int) int32 {
+	return int32(math.Round(100 * float64(n) / float64(total)))
+}
+
+func boolScore(ok bool) int32 {
+	if ok {
+		return 100
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	probe, skew, alerts := true, false, 0
+	healthy := Signals{
+		ControlPlaneReady: 3, ControlPlaneTotal: 3,
+		EndpointsReady: 2, EndpointsTotal: 2,
+		APIProbe: &probe, VersionSkew: &skew, FiringAlerts: &alerts,
+	}
+	score, signals := Score(healthy, DefaultWeights)
+	assert.Equal(t, int32(100), score)
+	assert.Len(t, signals, 5)
+
+	// One of four controllers down and two alerts firing
+	degraded := healthy
+	degraded.ControlPlaneReady, degraded.ControlPlaneTotal = 3, 4
+	firing := 2
+	degraded.FiringAlerts = &firing
+	score, signals = Score(degraded, DefaultWeights)
+	assert.Equal(t, int32(75), signals[SignalControlPlane])
+	assert.Equal(t, int32(60), signals[SignalAlerts])
+	assert.Equal(t, int32(86), score)
+
+	// Signals that were not observed are left out
+	score, signals = Score(Signals{ControlPlaneReady: 1, ControlPlaneTotal: 2}, DefaultWeights)
+	assert.Equal(t, int32(50), score)
+	assert.Equal(t, map[string]int32{SignalControlPlane: 50}, signals)
+
+	// Signals without weight do not count
+	down := false
+	score, _ = Score(Signals{ControlPlaneReady: 1, ControlPlaneTotal: 1, APIProbe: &down}, Weights{SignalControlPlane: 40})
+	assert.Equal(t, int32(100), score)
+
+	score, _ = Score(Signals{}, DefaultWeights)
+	assert.Equal(t, int32(0), score)
+}
+
+func TestAggregate(t *testing.T) {
+	assert.Equal(t, int32(0), Aggregate(nil))
+	assert.Equal(t, int32(90), Aggregate([]int32{100, 80}))
+	// A broken cluster caps the fleet score
+	assert.Equal(t, int32(50), Aggregate([]int32{100, 100, 100, 100, 100, 100, 100, 100, 100, 0}))
+}
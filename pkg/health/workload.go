@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Workload kinds understood by Check and WaitForWorkloadReady
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindJob         = "Job"
+)
+
+// ErrJobFailed is returned when a Job has failed; waiting longer will not make it complete
+var ErrJobFailed = errors.New("job failed")
+
+// pollInterval is how often WaitForWorkloadReady checks the workload
+var pollInterval = 5 * time.Second
+
+// Workload identifies a workload object
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (w Workload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.Kind, w.Namespace, w.Name)
+}
+
+// Status is the readiness of a workload
+type Status struct {
+	Ready   bool
+	Message string
+	// Generation and ObservedGeneration show whether the controller has seen the latest spec
+	Generation         int64
+	ObservedGeneration int64
+	// Images are the container images of the pod template
+	Images []string
+}
+
+func podImages(template *corev1.PodTemplateSpec) []string {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, c := range template.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// DeploymentStatus reports whether all replicas of the current rollout are available
+func DeploymentStatus(d *appsv1.Deployment) Status {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return Status{Message: "rollout has not been observed yet"}
+	}
+	if d.Status.UpdatedReplicas < desired || d.Status.AvailableReplicas < desired {
+		return Status{Message: fmt.Sprintf("%d/%d replicas available", d.Status.AvailableReplicas, desired)}
+	}
+	return Status{Ready: true, Message: fmt.Sprintf("%d/%d replicas available", d.Status.AvailableReplicas, desired)}
+}
+
+// StatefulSetStatus reports whether all replicas are ready
+func StatefulSetStatus(s *appsv1.StatefulSet) Status {
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+	if s.Status.ObservedGeneration < s.Generation {
+		return Status{Message: "rollout has not been observed yet"}
+	}
+	if s.Status.ReadyReplicas < desired {
+		return Status{Message: fmt.Sprintf("%d/%d replicas ready", s.Status.ReadyReplicas, desired)}
+	}
+	return Status{Ready: true, Message: fmt.Sprintf("%d/%d replicas ready", s.Status.ReadyReplicas, desired)}
+}
+
+// DaemonSetStatus reports whether a ready, up-to-date pod runs on every node the DaemonSet is scheduled to
+func DaemonSetStatus(ds *appsv1.DaemonSet) Status {
+	desired := ds.Status.DesiredNumberScheduled
+	if ds.Status.ObservedGeneration < ds.Generation {
+		return Status{Message: "rollout has not been observed yet"}
+	}
+	if ds.Status.UpdatedNumberScheduled < desired || ds.Status.NumberReady < desired {
+		return Status{Message: fmt.Sprintf("%d/%d pods ready, %d up to date", ds.Status.NumberReady, desired, ds.Status.UpdatedNumberScheduled)}
+	}
+	return Status{Ready: true, Message: fmt.Sprintf("%d/%d pods ready", ds.Status.NumberReady, desired)}
+}
+
+// JobStatus reports whether a Job has completed. A failed Job returns ErrJobFailed.
+func JobStatus(j *batchv1.Job) (Status, error) {
+	for _, cond := range j.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return Status{Ready: true, Message: fmt.Sprintf("completed with %d succeeded pods", j.Status.Succeeded)}, nil
+		case batchv1.JobFailed:
+			return Status{Message: cond.Message}, fmt.Errorf("%w: %s: %s", ErrJobFailed, cond.Reason, cond.Message)
+		}
+	}
+	return Status{Message: fmt.Sprintf("%d active, %d succeeded, %d failed pods", j.Status.Active, j.Status.Succeeded, j.Status.Failed)}, nil
+}
+
+// Check fetches a workload and reports its readiness, generations and images
+func Check(ctx context.Context, c client.Client, w Workload) (Status, error) {
+	key := client.ObjectKey{Namespace: w.Namespace, Name: w.Name}
+
+	var status Status
+	switch w.Kind {
+	case KindDeployment:
+		d := &appsv1.Deployment{}
+		if err := c.Get(ctx, key, d); err != nil {
+			return Status{}, fmt.Errorf("failed to get %s: %w", w, err)
+		}
+		status = DeploymentStatus(d)
+		status.Generation, status.ObservedGeneration = d.Generation, d.Status.ObservedGeneration
+		status.Images = podImages(&d.Spec.Template)
+	case KindStatefulSet:
+		s := &appsv1.StatefulSet{}
+		if err := c.Get(ctx, key, s); err != nil {
+			return Status{}, fmt.Errorf("failed to get %s: %w", w, err)
+		}
+		status = StatefulSetStatus(s)
+		status.Generation, status.ObservedGeneration = s.Generation, s.Status.ObservedGeneration
+		status.Images = podImages(&s.Spec.Template)
+	case KindDaemonSet:
+		ds := &appsv1.DaemonSet{}
+		if err := c.Get(ctx, key, ds); err != nil {
+			return Status{}, fmt.Errorf("failed to get %s: %w", w, err)
+		}
+		status = DaemonSetStatus(ds)
+		status.Generation, status.ObservedGeneration = ds.Generation, ds.Status.ObservedGeneration
+		status.Images = podImages(&ds.Spec.Template)
+	case KindJob:
+		j := &batchv1.Job{}
+		if err := c.Get(ctx, key, j); err != nil {
+			return Status{}, fmt.Errorf("failed to get %s: %w", w, err)
+		}
+		var err error
+		status, err = JobStatus(j)
+		// Jobs have no observedGeneration; their spec is immutable
+		status.Generation, status.ObservedGeneration = j.Generation, j.Generation
+		status.Images = podImages(&j.Spec.Template)
+		return status, err
+	default:
+		return Status{}, fmt.Errorf("unsupported workload kind: %s", w.Kind)
+	}
+
+	return status, nil
+}
+
+// WaitForWorkloadReady waits until a workload is ready, or for Jobs, complete. It returns
+// early when a Job fails.
+func WaitForWorkloadReady(ctx context.Context, c client.Client, w Workload, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last Status
+	for {
+		status, err := Check(ctx, c, w)
+		if errors.Is(err, ErrJobFailed) {
+			return fmt.Errorf("%s: %w", w, err)
+		}
+		if err == nil {
+			if status.Ready {
+				return nil
+			}
+			last = status
+		}
+
+		select {
+		case <-ctx.Done():
+			if last.Message != "" {
+				return fmt.Errorf("timeout waiting for %s to be ready: %s", w, last.Message)
+			}
+			return fmt.Errorf("timeout waiting for %s to be ready: %w", w, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDaemonSetStatus(t *testing.T) {
+	ds := &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{
+		DesiredNumberScheduled: 3,
+		NumberReady:            2,
+		UpdatedNumberScheduled: 3,
+	}}
+	status := DaemonSetStatus(ds)
+	assert.False(t, status.Ready)
+	assert.Equal(t, "2/3 pods ready, 3 up to date", status.Message)
+
+	ds.Status.NumberReady = 3
+	assert.True(t, DaemonSetStatus(ds).Ready)
+
+	// An update that has not reached every node is not ready yet
+	ds.Status.UpdatedNumberScheduled = 1
+	assert.False(t, DaemonSetStatus(ds).Ready)
+}
+
+func TestJobStatus(t *testing.T) {
+	job := &batchv1.Job{Status: batchv1.JobStatus{Active: 1}}
+	status, err := JobStatus(job)
+	require.NoError(t, err)
+	assert.False(t, status.Ready)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	status, err = JobStatus(job)
+	require.NoError(t, err)
+	assert.True(t, status.Ready)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}}
+	_, err = JobStatus(job)
+	assert.ErrorIs(t, err, ErrJobFailed)
+}
+
+func TestWaitForWorkloadReady(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	defer func() { pollInterval = 5 * time.Second }()
+
+	failed := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+		}},
+	}
+	pending := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberReady: 1, UpdatedNumberScheduled: 2},
+	}
+	ready := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(failed, pending, ready).Build()
+	ctx := context.Background()
+
+	// A failed Job is reported without waiting for the timeout
+	start := time.Now()
+	err := WaitForWorkloadReady(ctx, c, Workload{Kind: KindJob, Namespace: "default", Name: "migrate"}, time.Minute)
+	assert.ErrorIs(t, err, ErrJobFailed)
+	assert.Less(t, time.Since(start), time.Second)
+
+	err = WaitForWorkloadReady(ctx, c, Workload{Kind: KindDaemonSet, Namespace: "default", Name: "agent"}, 50*time.Millisecond)
+	assert.ErrorContains(t, err, "1/2 pods ready")
+
+	assert.NoError(t, WaitForWorkloadReady(ctx, c, Workload{Kind: KindDeployment, Namespace: "default", Name: "web"}, time.Second))
+
+	_, err = Check(ctx, c, Workload{Kind: "CronJob", Namespace: "default", Name: "web"})
+	assert.ErrorContains(t, err, "unsupported workload kind")
+}
+
+func TestWaitForWorkloadReadyAcrossClusters(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	defer func() { pollInterval = 5 * time.Second }()
+
+	deployment := func(available int32, image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Generation: 2},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "web", Image: image}},
+			}}},
+			Status: appsv1.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: available, AvailableReplicas: available},
+		}
+	}
+	clients := map[string]client.Client{
+		"edge-2": fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment(0, "web:1.1")).Build(),
+		"edge-1": fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment(1, "web:1.1")).Build(),
+		"edge-3": fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+	}
+	w := Workload{Kind: KindDeployment, Namespace: "shop", Name: "web"}
+
+	results, err := WaitForWorkloadReadyAcrossClusters(context.Background(), clients, w, 50*time.Millisecond)
+	assert.EqualError(t, err, "timeout waiting for Deployment shop/web to be ready on edge-2, edge-3")
+	require.Len(t, results, 3)
+	assert.Equal(t, "edge-1", results[0].Cluster)
+	assert.True(t, results[0].Ready())
+	assert.Equal(t, []string{"web:1.1"}, results[0].Images)
+	assert.Equal(t, int64(2), results[0].ObservedGeneration)
+	assert.False(t, results[1].Ready())
+	assert.Error(t, results[2].Err)
+
+	delete(clients, "edge-2")
+	delete(clients, "edge-3")
+	_, err = WaitForWorkloadReadyAcrossClusters(context.Background(), clients, w, time.Second)
+	assert.NoError(t, err)
+}
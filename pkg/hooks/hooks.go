@@ -0,0 +1,221 @@
+// Package hooks runs the post-install hooks of an Integration: Jobs on a target
+// cluster and webhooks called from the hub
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Defaults for hooks that leave them unset
+const (
+	DefaultRetries = 2
+	DefaultTimeout = 5 * time.Minute
+)
+
+var (
+	// pollInterval is how often a Job is checked for completion
+	pollInterval = 2 * time.Second
+	// retryDelay is the wait before a failed hook runs again
+	retryDelay = 10 * time.Second
+)
+
+// Event is the JSON body posted to a webhook hook
+type Event struct {
+	Integration string `json:"integration"`
+	Namespace   string `json:"namespace"`
+	Type        string `json:"type"`
+	Cluster     string `json:"cluster"`
+	// Action is the install action the hook follows, Install or Upgrade
+	Action string `json:"action"`
+	Hook   string `json:"hook"`
+}
+
+// Retry runs hook until it succeeds, at most retries+1 times, giving each attempt
+// timeout. It returns the number of attempts with the message or error of the last one.
+func Retry(ctx context.Context, retries int, timeout time.Duration, hook func(context.Context) (string, error)) (int, string, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	attempts := 0
+	for {
+		attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		message, err := hook(attemptCtx)
+		cancel()
+		if err == nil || attempts > retries {
+			return attempts, message, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempts, "", err
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// RunJob creates job and waits until it completes or fails. A Job with the same name
+// left by an earlier run is deleted first. With adopt, one that has not failed is waited
+// for instead, so a hook that outlasted the reconcile that started it is not restarted.
+func RunJob(ctx context.Context, c client.Client, job *batchv1.Job, adopt bool) (string, error) {
+	key := client.ObjectKeyFromObject(job)
+	existing := &batchv1.Job{}
+	if err := c.Get(ctx, key, existing); err == nil && adopt && !jobFailed(existing) {
+		return waitForJob(ctx, c, key)
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get Job %s: %w", key, err)
+	}
+	if err := deleteJob(ctx, c, key); err != nil {
+		return "", err
+	}
+	if err := c.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create Job %s: %w", key, err)
+	}
+	return waitForJob(ctx, c, key)
+}
+
+// jobFailed reports whether a Job has the Failed condition
+func jobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForJob waits until the Job completes or fails
+func waitForJob(ctx context.Context, c client.Client, key client.ObjectKey) (string, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		current := &batchv1.Job{}
+		if err := c.Get(ctx, key, current); err != nil {
+			return "", fmt.Errorf("failed to get Job %s: %w", key, err)
+		}
+		for _, condition := range current.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return fmt.Sprintf("Job %s completed", key), nil
+			case batchv1.JobFailed:
+				return "", fmt.Errorf("job %s failed: %s", key, condition.Message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for Job %s: %d active, %d failed pods", key, current.Status.Active, current.Status.Failed)
+		case <-ticker.C:
+		}
+	}
+}
+
+// deleteJob deletes a Job and its pods, and waits until the Job is gone
+func deleteJob(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	err := c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete previous Job %s: %w", key, err)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.Get(ctx, key, job); apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get previous Job %s: %w", key, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for previous Job %s to be deleted", key)
+		case <-ticker.C:
+		}
+	}
+}
+
+// webhookClient calls webhooks when the caller passes no client of its own. It refuses
+// to connect to addresses AllowedWebhookIP rejects, also after DNS resolution and
+// redirects, so a webhook URL cannot reach the controller's own endpoints or the cloud
+// metadata service.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 30 * time.Second, Control: checkWebhookAddress}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// checkWebhookAddress is the dialer control of webhookClient
+func checkWebhookAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !AllowedWebhookIP(ip) {
+		return fmt.Errorf("webhooks may not call %s", host)
+	}
+	return nil
+}
+
+// AllowedWebhookIP reports whether webhooks may be called at ip. Loopback, link-local,
+// unspecified and multicast addresses are refused; private ones are allowed, since
+// webhooks often run in the hub cluster.
+func AllowedWebhookIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// CallWebhook posts event as JSON to url, with token as bearer token when it is set,
+// and fails unless the answer has a 2xx status. Without httpClient, webhookClient is used.
+func CallWebhook(ctx context.Context, httpClient *http.Client, url, token string, event Event) (string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if httpClient == nil {
+		httpClient = webhookClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("webhook answered %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return fmt.Sprintf("webhook answered %s", resp.Status), nil
+}
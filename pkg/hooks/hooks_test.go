@@ -0,0 +1,141 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	pollInterval = 10 * time.Millisecond
+	retryDelay = time.Millisecond
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	attempts, message, err := Retry(context.Background(), 2, time.Second, func(context.Context) (string, error) {
+		calls++
+		if calls < 2 {
+			return "", errors.New("not yet")
+		}
+		return "done", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "done", message)
+
+	attempts, _, err = Retry(context.Background(), 1, time.Second, func(context.Context) (string, error) {
+		return "", errors.New("always fails")
+	})
+	assert.EqualError(t, err, "always fails")
+	assert.Equal(t, 2, attempts)
+}
+
+// finishJob waits for the Job to be created and sets its condition
+func finishJob(c client.Client, key client.ObjectKey, run string, condition batchv1.JobConditionType) {
+	for {
+		job := &batchv1.Job{}
+		if err := c.Get(context.Background(), key, job); err == nil && job.Labels["run"] == run {
+			job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+			_ = c.Status().Update(context.Background(), job)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key := client.ObjectKey{Namespace: "argocd", Name: "ksit-hook-argocd-rotate"}
+	previous := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Labels: map[string]string{"run": "old"}}}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(previous).Build()
+
+	job := func(run string) *batchv1.Job {
+		return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Labels: map[string]string{"run": run}}}
+	}
+
+	go finishJob(c, key, "first", batchv1.JobComplete)
+	message, err := RunJob(ctx, c, job("first"), false)
+	require.NoError(t, err)
+	assert.Equal(t, "Job argocd/ksit-hook-argocd-rotate completed", message)
+
+	go finishJob(c, key, "second", batchv1.JobFailed)
+	_, err = RunJob(ctx, c, job("second"), false)
+	assert.ErrorContains(t, err, "BackoffLimitExceeded")
+
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelTimeout()
+	_, err = RunJob(timeoutCtx, c, job("third"), false)
+	assert.ErrorContains(t, err, "timed out waiting for Job")
+
+	// An adopted Job that is still running is waited for rather than replaced
+	go finishJob(c, key, "third", batchv1.JobComplete)
+	_, err = RunJob(ctx, c, job("fourth"), true)
+	require.NoError(t, err)
+	current := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, key, current))
+	assert.Equal(t, "third", current.Labels["run"])
+
+	// A failed one is replaced
+	go finishJob(c, key, "fifth", batchv1.JobComplete)
+	require.NoError(t, c.Get(ctx, key, current))
+	current.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	require.NoError(t, c.Status().Update(ctx, current))
+	_, err = RunJob(ctx, c, job("fifth"), true)
+	require.NoError(t, err)
+}
+
+func TestCallWebhook(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := Event{Integration: "grafana", Namespace: "ksit-system", Type: "grafana", Cluster: "edge-1", Action: "Install", Hook: "seed-datasources"}
+	message, err := CallWebhook(context.Background(), server.Client(), server.URL, "s3cr3t", event)
+	require.NoError(t, err)
+	assert.Equal(t, "webhook answered 202 Accepted", message)
+	assert.Equal(t, event, received)
+
+	_, err = CallWebhook(context.Background(), server.Client(), server.URL, "wrong", event)
+	assert.EqualError(t, err, "webhook answered 401 Unauthorized: bad token")
+
+	// Without a client of its own, a webhook cannot reach loopback addresses
+	_, err = CallWebhook(context.Background(), nil, server.URL, "s3cr3t", event)
+	assert.ErrorContains(t, err, "webhooks may not call 127.0.0.1")
+}
+
+func TestAllowedWebhookIP(t *testing.T) {
+	for ip, allowed := range map[string]bool{
+		"10.96.0.10":      true,
+		"203.0.113.7":     true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+	} {
+		assert.Equal(t, allowed, AllowedWebhookIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -0,0 +1,22 @@
+package installer
+
+import (
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// NewCertManagerInstaller creates a new cert-manager installer with default configuration.
+// The chart installs its own CRDs, which Helm does not upgrade from the crds/ directory.
+func NewCertManagerInstaller() *HelmInstaller {
+	return &HelmInstaller{
+		integrationType: ksitv1alpha1.IntegrationTypeCertManager,
+		defaultConfig: &ksitv1alpha1.HelmInstallConfig{
+			Repository:  "https://charts.jetstack.io",
+			Chart:       "cert-manager",
+			Version:     "v1.14.4",
+			ReleaseName: "cert-manager",
+			Values: map[string]string{
+				"installCRDs": "true",
+			},
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// chartComponents lists, per chart, the values paths of the components that
+// autoInstall.overrides apply to. Each path takes resources, nodeSelector,
+// tolerations and priorityClassName keys.
+var chartComponents = map[string][]string{
+	"argo-cd": {
+		"controller",
+		"server",
+		"repoServer",
+		"applicationSet",
+		"notifications",
+		"redis",
+		"dex",
+	},
+	"kube-prometheus-stack": {
+		"prometheus.prometheusSpec",
+		"alertmanager.alertmanagerSpec",
+		"prometheusOperator",
+		"grafana",
+		"kube-state-metrics",
+	},
+	"istiod": {
+		"pilot",
+	},
+}
+
+// SupportsOverrides reports whether autoInstall.overrides can be translated into values of the chart
+func SupportsOverrides(chart string) bool {
+	_, ok := chartComponents[chart]
+	return ok
+}
+
+// helmOverrideValues translates component overrides into values of the chart
+func helmOverrideValues(chart string, overrides *ksitv1alpha1.ComponentOverrides) (map[string]interface{}, error) {
+	components, ok := chartComponents[chart]
+	if !ok {
+		return nil, fmt.Errorf("autoInstall.overrides are not supported for chart %s; set helmConfig.values instead", chart)
+	}
+
+	values := map[string]interface{}{}
+	for _, path := range components {
+		// Every component gets its own copy, so Helm never sees shared maps
+		settings, err := overrideSettings(overrides)
+		if err != nil {
+			return nil, err
+		}
+		component := nestedMap(values, strings.Split(path, "."))
+		for key, value := range settings {
+			component[key] = value
+		}
+	}
+	return values, nil
+}
+
+// overrideSettings returns the pod settings of the overrides in their JSON form
+func overrideSettings(overrides *ksitv1alpha1.ComponentOverrides) (map[string]interface{}, error) {
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode overrides: %w", err)
+	}
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode overrides: %w", err)
+	}
+	return settings, nil
+}
+
+// nestedMap returns the map at path in values, creating missing levels
+func nestedMap(values map[string]interface{}, path []string) map[string]interface{} {
+	current := values
+	for _, key := range path {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[key] = next
+		}
+		current = next
+	}
+	return current
+}
+
+// mergeValues merges src into dst recursively; values from src win
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// applyWorkloadOverrides sets the component overrides on the pod template of a
+// Deployment, StatefulSet or DaemonSet from a manifest. Other kinds are left alone.
+func applyWorkloadOverrides(obj *unstructured.Unstructured, overrides *ksitv1alpha1.ComponentOverrides) error {
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil
+	}
+
+	settings, err := overrideSettings(overrides)
+	if err != nil {
+		return err
+	}
+	podSpec := []string{"spec", "template", "spec"}
+
+	for _, key := range []string{"nodeSelector", "tolerations", "priorityClassName"} {
+		if value, ok := settings[key]; ok {
+			if err := unstructured.SetNestedField(obj.Object, value, append(podSpec, key)...); err != nil {
+				return fmt.Errorf("failed to set %s on %s %s: %w", key, obj.GetKind(), obj.GetName(), err)
+			}
+		}
+	}
+
+	resources, ok := settings["resources"]
+	if !ok {
+		return nil
+	}
+	containers, _, err := unstructured.NestedSlice(obj.Object, append(podSpec, "containers")...)
+	if err != nil {
+		return fmt.Errorf("failed to read containers of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	for i := range containers {
+		container, ok := containers[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		container["resources"] = resources
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, containers, append(podSpec, "containers")...); err != nil {
+		return fmt.Errorf("failed to set resources on %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package installer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+var edgeOverrides = &ksitv1alpha1.ComponentOverrides{
+	Resources: &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+	},
+	NodeSelector: map[string]string{"node-role.kubernetes.io/edge": ""},
+	Tolerations:  []corev1.Toleration{{Key: "edge", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+}
+
+func TestChartValuesWithOverrides(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{Enabled: true, Overrides: edgeOverrides},
+		},
+	}
+	helmConfig := NewArgoCDInstaller().defaultConfig
+
+	values, err := chartValues(integration, helmConfig)
+	require.NoError(t, err)
+
+	// Flat values are passed through untouched
+	assert.Equal(t, "true", values["server.insecure"])
+	for _, component := range chartComponents["argo-cd"] {
+		settings, ok := values[component].(map[string]interface{})
+		require.True(t, ok, component)
+		assert.Equal(t, map[string]interface{}{"memory": "64Mi"}, settings["resources"].(map[string]interface{})["requests"], component)
+		assert.Equal(t, map[string]interface{}{"node-role.kubernetes.io/edge": ""}, settings["nodeSelector"], component)
+		assert.Len(t, settings["tolerations"], 1, component)
+		assert.NotContains(t, settings, "priorityClassName", component)
+	}
+
+	prometheus := NewPrometheusInstaller().defaultConfig
+	values, err = chartValues(integration, prometheus)
+	require.NoError(t, err)
+	spec := values["prometheus"].(map[string]interface{})["prometheusSpec"].(map[string]interface{})
+	assert.Contains(t, spec, "nodeSelector")
+
+	_, err = chartValues(integration, &ksitv1alpha1.HelmInstallConfig{Chart: "custom"})
+	assert.Error(t, err)
+}
+
+func TestChartValuesObject(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{Spec: ksitv1alpha1.IntegrationSpec{AutoInstall: &ksitv1alpha1.InstallConfig{Enabled: true}}}
+	helmConfig := &ksitv1alpha1.HelmInstallConfig{
+		Chart:        "argo-cd",
+		ValuesObject: &apiextensionsv1.JSON{Raw: []byte(`{"server":{"replicas":2,"insecure":true},"crds":{"install":false}}`)},
+		Values:       map[string]string{"crds": "keep"},
+	}
+
+	values, err := chartValues(integration, helmConfig)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": float64(2), "insecure": true}, values["server"])
+	assert.Equal(t, "keep", values["crds"], "values are set over valuesObject")
+
+	helmConfig.ValuesObject.Raw = []byte(`not json`)
+	_, err = chartValues(integration, helmConfig)
+	assert.Error(t, err)
+}
+
+func TestApplyWorkloadOverrides(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "source-controller", "namespace": "flux-system"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"priorityClassName": "system-cluster-critical",
+					"containers": []interface{}{
+						map[string]interface{}{"name": "manager", "image": "ghcr.io/fluxcd/source-controller"},
+					},
+				},
+			},
+		},
+	}}
+
+	require.NoError(t, applyWorkloadOverrides(deployment, edgeOverrides))
+
+	podSpec, _, _ := unstructured.NestedMap(deployment.Object, "spec", "template", "spec")
+	assert.Equal(t, map[string]interface{}{"node-role.kubernetes.io/edge": ""}, podSpec["nodeSelector"])
+	assert.Equal(t, "system-cluster-critical", podSpec["priorityClassName"], "unset overrides keep the manifest's value")
+	container := podSpec["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "manager", container["name"])
+	memory, _, _ := unstructured.NestedString(container, "resources", "requests", "memory")
+	assert.Equal(t, "64Mi", memory)
+
+	service := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}}
+	require.NoError(t, applyWorkloadOverrides(service, edgeOverrides))
+	assert.NotContains(t, service.Object, "spec")
+}
+
+func TestApplyClusterOverridesComponentOverrides(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled:   true,
+				Method:    "manifest",
+				Overrides: &ksitv1alpha1.ComponentOverrides{PriorityClassName: "fleet-critical"},
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{{
+					ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "edge"}},
+					Overrides:       edgeOverrides,
+				}},
+			},
+		},
+	}
+
+	out, err := ApplyClusterOverrides(integration, map[string]string{"tier": "edge"})
+	require.NoError(t, err)
+	overrides := out.Spec.AutoInstall.Overrides
+	assert.Equal(t, "fleet-critical", overrides.PriorityClassName)
+	assert.Equal(t, edgeOverrides.NodeSelector, overrides.NodeSelector)
+	assert.Equal(t, edgeOverrides.Resources, overrides.Resources)
+
+	out, err = ApplyClusterOverrides(integration, map[string]string{"tier": "core"})
+	require.NoError(t, err)
+	assert.Nil(t, out.Spec.AutoInstall.Overrides.NodeSelector)
+	assert.Nil(t, integration.Spec.AutoInstall.Overrides.Resources, "the base config is never modified")
+}
@@ -3,31 +3,42 @@ package installer
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
-	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
 )
 
+// fluxControllers are the Deployments that must be ready for Flux to be installed
+var fluxControllers = []string{
+	"source-controller",
+	"kustomize-controller",
+	"helm-controller",
+	"notification-controller",
+}
+
+// fluxManifestURL is installed when autoInstall.manifestUrl is not set
+const fluxManifestURL = "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"
+
+// HasDefaultManifest reports whether the manifest method works for integrationType
+// without autoInstall.manifestUrl
+func HasDefaultManifest(integrationType string) bool {
+	return integrationType == ksitv1alpha1.IntegrationTypeFlux
+}
+
 // FluxInstaller handles Flux installation using manifests
-type FluxInstaller struct{}
+type FluxInstaller struct {
+	manifests *ManifestCache
+}
 
-// NewFluxInstaller creates a new Flux installer
+// NewFluxInstaller creates a new Flux installer that shares downloaded manifests with
+// the other manifest-based installers
 func NewFluxInstaller() *FluxInstaller {
-	return &FluxInstaller{}
+	return &FluxInstaller{manifests: sharedManifests}
 }
 
 // Install installs Flux using official manifests
@@ -38,182 +49,41 @@ func (f *FluxInstaller) Install(ctx context.Context, config *rest.Config, integr
 
 	manifestURL := integration.Spec.AutoInstall.ManifestURL
 	if manifestURL == "" {
-		manifestURL = "https://github.com/fluxcd/flux2/releases/latest/download/install.yaml"
+		manifestURL = fluxManifestURL
 	}
 
-	// log.Info("downloading Flux manifests", "url", manifestURL)
-
-	// Download manifests
-	resp, err := http.Get(manifestURL)
+	manifestBytes, err := f.manifests.Get(ctx, manifestURL, integration.Spec.AutoInstall.ManifestDigest)
 	if err != nil {
-		return fmt.Errorf("failed to download Flux manifests: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download manifests: HTTP %d", resp.StatusCode)
+		return fmt.Errorf("failed to get Flux manifests: %w", err)
 	}
 
-	manifestBytes, err := io.ReadAll(resp.Body)
+	objs, err := manifests.Decode(manifestBytes)
 	if err != nil {
-		return fmt.Errorf("failed to read manifest content: %w", err)
+		return fmt.Errorf("failed to decode Flux manifests: %w", err)
 	}
-
-	// log.Info("downloaded Flux manifests", "size", len(manifestBytes))
-
-	// Create clients
-	dynClient, err := dynamic.NewForConfig(config)
+	// The mapper discovers the kinds the target cluster serves, including those of the
+	// Flux CRDs once they are applied
+	dynClient, mapper, err := manifests.NewClients(config)
 	if err != nil {
-		return fmt.Errorf("failed to create dynamic client: %w", err)
+		return err
 	}
-
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	// Ensure flux-system namespace exists
-	ns := &corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "flux-system",
-		},
+	// Set up flux-system before any Flux pod exists
+	if err := prepareNamespace(ctx, config, integration, "flux-system"); err != nil {
+		return err
 	}
-	_, err = clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return fmt.Errorf("failed to create flux-system namespace: %w", err)
-	}
-
-	// log.Info("flux-system namespace ready")
-
-	// Parse and apply manifests
-	manifestsStr := string(manifestBytes)
-	docs := strings.Split(manifestsStr, "---")
-
-	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
-	applied := 0
-	skipped := 0
 
-	// PHASE 1: Apply CRDs first
-	for _, doc := range docs {
-		doc = strings.TrimSpace(doc)
-		if doc == "" || strings.HasPrefix(doc, "#") {
-			continue
-		}
-
-		// Only process CRDs in this pass
-		if !strings.Contains(doc, "kind: CustomResourceDefinition") {
-			continue
-		}
-
-		// Decode YAML to unstructured
-		obj := &unstructured.Unstructured{}
-		_, gvk, err := decoder.Decode([]byte(doc), nil, obj)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		// Get resource mapping
-		gvr, err := getGVR(gvk)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		// Apply CRD (CRDs are always cluster-scoped)
-		_, applyErr := dynClient.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
-		if errors.IsAlreadyExists(applyErr) {
-			_, applyErr = dynClient.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{})
-		}
-
-		if applyErr != nil {
-			// Log but continue - some CRDs may be partially applied
-			continue
-		}
-
-		applied++
+	if _, err := applyManifest(ctx, dynClient, mapper, objs, "flux-system", integration.Spec.AutoInstall.Overrides); err != nil {
+		return fmt.Errorf("failed to apply Flux manifests: %w", err)
 	}
-
-	// Wait briefly for CRDs to be established
-	time.Sleep(3 * time.Second)
-
-	// PHASE 2: Apply all other resources
-	for _, doc := range docs {
-		doc = strings.TrimSpace(doc)
-		if doc == "" || strings.HasPrefix(doc, "#") {
-			continue
-		}
-
-		// Skip CRDs - already applied
-		if strings.Contains(doc, "kind: CustomResourceDefinition") {
-			continue
-		}
-
-		// Decode YAML to unstructured
-		obj := &unstructured.Unstructured{}
-		_, gvk, err := decoder.Decode([]byte(doc), nil, obj)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		// Get resource mapping
-		gvr, err := getGVR(gvk)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		namespace := obj.GetNamespace()
-		_ = obj.GetName() // name used for logging only
-
-		// Apply resource
-		var applyErr error
-		if namespace != "" {
-			_, applyErr = dynClient.Resource(gvr).Namespace(namespace).Create(ctx, obj, metav1.CreateOptions{})
-			if errors.IsAlreadyExists(applyErr) {
-				_, applyErr = dynClient.Resource(gvr).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{})
-			}
-		} else {
-			_, applyErr = dynClient.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
-			if errors.IsAlreadyExists(applyErr) {
-				_, applyErr = dynClient.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{})
-			}
-		}
-
-		if applyErr != nil {
-			// Continue with other resources
-			continue
-		}
-
-		applied++
-	}
-
-	// log.Info("applied Flux manifests", "applied", applied, "skipped", skipped)
+	reportProgress(ctx, fmt.Sprintf("applied %d objects", len(objs)))
 
 	// Wait for Flux controllers to be ready
-	// log.Info("waiting for Flux controllers to be ready")
-	err = wait.PollImmediate(5*time.Second, 3*time.Minute, func() (bool, error) {
-		deployments := []string{
-			"source-controller",
-			"kustomize-controller",
-			"helm-controller",
-			"notification-controller",
-		}
-
-		for _, name := range deployments {
-			deploy, err := clientset.AppsV1().Deployments("flux-system").Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				return false, nil
-			}
-			if deploy.Status.ReadyReplicas < 1 {
-				return false, nil
-			}
-		}
-		return true, nil
-	})
-
-	if err != nil {
+	if err := waitForDeployments(ctx, clientset, "flux-system", fluxControllers, readinessTimeout(integration)); err != nil {
 		return fmt.Errorf("timeout waiting for Flux controllers: %w", err)
 	}
 
@@ -266,38 +136,21 @@ func (f *FluxInstaller) IsInstalled(ctx context.Context, config *rest.Config, in
 	return true, nil
 }
 
-// getGVR converts GroupVersionKind to GroupVersionResource
-func getGVR(gvk *schema.GroupVersionKind) (schema.GroupVersionResource, error) {
-	// Map common Kubernetes resources to their plural forms
-	resourceMap := map[string]string{
-		"Namespace":                "namespaces",
-		"ServiceAccount":           "serviceaccounts",
-		"ClusterRole":              "clusterroles",
-		"ClusterRoleBinding":       "clusterrolebindings",
-		"Role":                     "roles",
-		"RoleBinding":              "rolebindings",
-		"ConfigMap":                "configmaps",
-		"Secret":                   "secrets",
-		"Service":                  "services",
-		"Deployment":               "deployments",
-		"StatefulSet":              "statefulsets",
-		"DaemonSet":                "daemonsets",
-		"CustomResourceDefinition": "customresourcedefinitions",
-		"NetworkPolicy":            "networkpolicies",
-		"PriorityClass":            "priorityclasses",
-		"ResourceQuota":            "resourcequotas",
-		"LimitRange":               "limitranges",
+// Drift reports the first Flux controller that was deleted from flux-system
+func (f *FluxInstaller) Drift(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (string, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	resource, ok := resourceMap[gvk.Kind]
-	if !ok {
-		// Default: lowercase + s
-		resource = strings.ToLower(gvk.Kind) + "s"
+	for _, name := range fluxControllers {
+		_, err := clientset.AppsV1().Deployments("flux-system").Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("Deployment flux-system/%s is missing", name), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get Deployment %s: %w", name, err)
+		}
 	}
-
-	return schema.GroupVersionResource{
-		Group:    gvk.Group,
-		Version:  gvk.Version,
-		Resource: resource,
-	}, nil
+	return "", nil
 }
@@ -0,0 +1,18 @@
+package installer
+
+import (
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// NewGrafanaInstaller creates a new Grafana installer with default configuration
+func NewGrafanaInstaller() *HelmInstaller {
+	return &HelmInstaller{
+		integrationType: ksitv1alpha1.IntegrationTypeGrafana,
+		defaultConfig: &ksitv1alpha1.HelmInstallConfig{
+			Repository:  "https://grafana.github.io/helm-charts",
+			Chart:       "grafana",
+			Version:     "7.0.19",
+			ReleaseName: "grafana",
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// podSecurityModes are the Pod Security admission modes set on a hardened namespace
+var podSecurityModes = []string{"enforce", "warn", "audit"}
+
+// toolIngress lists, per integration type, the pods of the tool that must stay reachable
+// from outside its namespace and their ports
+var toolIngress = map[string]struct {
+	selector map[string]string
+	ports    []int32
+}{
+	// The Argo CD UI, API and gRPC all go through argocd-server
+	ksitv1alpha1.IntegrationTypeArgoCD: {
+		selector: map[string]string{"app.kubernetes.io/name": "argocd-server"},
+		ports:    []int32{8080},
+	},
+	// Git providers call the webhook receiver of the notification-controller
+	ksitv1alpha1.IntegrationTypeFlux: {
+		selector: map[string]string{"app": "notification-controller"},
+		ports:    []int32{9292},
+	},
+}
+
+// applyHardening reconciles the NetworkPolicies of the tool's namespace, deleting those
+// KSIT created when hardening or its network policies were turned off. The namespace is
+// labeled for Pod Security admission by prepareNamespace.
+func applyHardening(ctx context.Context, clientset kubernetes.Interface, integration *ksitv1alpha1.Integration, namespace string) error {
+	var policies []networkingv1.NetworkPolicy
+	if install := integration.Spec.AutoInstall; install != nil && install.Hardening != nil && install.Hardening.NetworkPolicies {
+		policies = networkPolicies(integration, namespace)
+	}
+	return syncNetworkPolicies(ctx, clientset, integration, namespace, policies)
+}
+
+// removeHardening deletes the NetworkPolicies KSIT created for the integration, even
+// when hardening was turned off since. Namespace labels are left in place, since the
+// namespace may outlive the tool.
+func removeHardening(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration, namespace string) error {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return syncNetworkPolicies(ctx, clientset, integration, namespace, nil)
+}
+
+// podSecurityLabels returns the Pod Security admission labels for a standard
+func podSecurityLabels(standard string) map[string]string {
+	if standard == "" {
+		return nil
+	}
+	podLabels := make(map[string]string, len(podSecurityModes))
+	for _, mode := range podSecurityModes {
+		podLabels["pod-security.kubernetes.io/"+mode] = standard
+	}
+	return podLabels
+}
+
+// networkPolicies returns the NetworkPolicies of a hardened namespace: deny everything,
+// then allow traffic within the namespace, DNS, HTTPS, SSH and Kubernetes API egress, and
+// ingress to the tool's endpoints and from the allowed namespaces
+func networkPolicies(integration *ksitv1alpha1.Integration, namespace string) []networkingv1.NetworkPolicy {
+	hardening := integration.Spec.AutoInstall.Hardening
+	policy := func(name string, spec networkingv1.NetworkPolicySpec) networkingv1.NetworkPolicy {
+		return networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{ksitv1alpha1.LabelIntegration: integration.Name},
+			},
+			Spec: spec,
+		}
+	}
+	bothDirections := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	samePods := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+
+	policies := []networkingv1.NetworkPolicy{
+		policy("ksit-default-deny", networkingv1.NetworkPolicySpec{
+			PolicyTypes: bothDirections,
+		}),
+		policy("ksit-allow-same-namespace", networkingv1.NetworkPolicySpec{
+			PolicyTypes: bothDirections,
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: samePods}},
+			Egress:      []networkingv1.NetworkPolicyEgressRule{{To: samePods}},
+		}),
+		policy("ksit-allow-egress", networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress: []networkingv1.NetworkPolicyEgressRule{{
+				Ports: append(ports(corev1.ProtocolUDP, 53), ports(corev1.ProtocolTCP, 53, 443, 6443, 22)...),
+			}},
+		}),
+	}
+
+	if ingress, ok := toolIngress[integration.Spec.Type]; ok {
+		policies = append(policies, policy("ksit-allow-ingress", networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: ingress.selector},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{Ports: ports(corev1.ProtocolTCP, ingress.ports...)}},
+		}))
+	}
+
+	if len(hardening.AllowedNamespaces) > 0 {
+		peers := make([]networkingv1.NetworkPolicyPeer, 0, len(hardening.AllowedNamespaces))
+		for _, allowed := range hardening.AllowedNamespaces {
+			peers = append(peers, networkingv1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: allowed}},
+			})
+		}
+		policies = append(policies, policy("ksit-allow-namespaces", networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+		}))
+	}
+	return policies
+}
+
+func ports(protocol corev1.Protocol, numbers ...int32) []networkingv1.NetworkPolicyPort {
+	policyPorts := make([]networkingv1.NetworkPolicyPort, 0, len(numbers))
+	for _, number := range numbers {
+		protocol, port := protocol, intstr.FromInt32(number)
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+	return policyPorts
+}
+
+// syncNetworkPolicies creates or updates policies and deletes the integration's other
+// NetworkPolicies in namespace
+func syncNetworkPolicies(ctx context.Context, clientset kubernetes.Interface, integration *ksitv1alpha1.Integration, namespace string, policies []networkingv1.NetworkPolicy) error {
+	client := clientset.NetworkingV1().NetworkPolicies(namespace)
+
+	wanted := make(map[string]bool, len(policies))
+	for i := range policies {
+		desired := &policies[i]
+		wanted[desired.Name] = true
+
+		existing, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			_, err = client.Create(ctx, desired, metav1.CreateOptions{})
+		case err == nil:
+			desired.ResourceVersion = existing.ResourceVersion
+			_, err = client.Update(ctx, desired, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply NetworkPolicy %s/%s: %w", namespace, desired.Name, err)
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{ksitv1alpha1.LabelIntegration: integration.Name}).String()
+	existing, err := client.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list NetworkPolicies in %s: %w", namespace, err)
+	}
+	for _, stale := range existing.Items {
+		if wanted[stale.Name] {
+			continue
+		}
+		if err := client.Delete(ctx, stale.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NetworkPolicy %s/%s: %w", namespace, stale.Name, err)
+		}
+	}
+	return nil
+}
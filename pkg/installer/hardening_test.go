@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestSyncNetworkPolicies(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-policy", Namespace: "argocd"},
+	})
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type: ksitv1alpha1.IntegrationTypeArgoCD,
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled:   true,
+				Hardening: &ksitv1alpha1.HardeningConfig{NetworkPolicies: true, AllowedNamespaces: []string{"monitoring"}},
+			},
+		},
+	}
+
+	names := func() []string {
+		list, err := clientset.NetworkingV1().NetworkPolicies("argocd").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		var names []string
+		for _, policy := range list.Items {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+
+	require.NoError(t, syncNetworkPolicies(ctx, clientset, integration, "argocd", networkPolicies(integration, "argocd")))
+	assert.ElementsMatch(t, []string{
+		"admin-policy",
+		"ksit-default-deny",
+		"ksit-allow-same-namespace",
+		"ksit-allow-egress",
+		"ksit-allow-ingress",
+		"ksit-allow-namespaces",
+	}, names())
+
+	// Policies no longer wanted are deleted; policies KSIT did not create are left alone
+	integration.Spec.AutoInstall.Hardening.AllowedNamespaces = nil
+	require.NoError(t, syncNetworkPolicies(ctx, clientset, integration, "argocd", networkPolicies(integration, "argocd")))
+	assert.NotContains(t, names(), "ksit-allow-namespaces")
+
+	require.NoError(t, syncNetworkPolicies(ctx, clientset, integration, "argocd", nil))
+	assert.Equal(t, []string{"admin-policy"}, names())
+
+	// Turning hardening off deletes the policies KSIT created
+	require.NoError(t, applyHardening(ctx, clientset, integration, "argocd"))
+	assert.Len(t, names(), 5)
+	integration.Spec.AutoInstall.Hardening = nil
+	require.NoError(t, applyHardening(ctx, clientset, integration, "argocd"))
+	assert.Equal(t, []string{"admin-policy"}, names())
+}
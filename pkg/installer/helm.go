@@ -2,25 +2,36 @@ package installer
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	helmrelease "helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/yaml"
 
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/conditions"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
 )
 
+// defaultHelmTimeout bounds a Helm operation when helmConfig.timeout is not set, as in the Helm CLI
+const defaultHelmTimeout = 5 * time.Minute
+
 // HelmInstaller handles Helm-based installation of integrations
 type HelmInstaller struct {
 	integrationType string
@@ -29,35 +40,40 @@ type HelmInstaller struct {
 
 // Install installs the integration using Helm
 func (h *HelmInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	return h.install(ctx, config, integration, false)
+}
+
+// install installs the chart, leaving out the CRDs it ships when skipCRDs is set
+func (h *HelmInstaller) install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration, skipCRDs bool) error {
 	helmConfig := integration.Spec.AutoInstall.HelmConfig
 	if helmConfig == nil {
 		helmConfig = h.defaultConfig
 	}
 
-	namespace := integration.Spec.Config["namespace"]
-	if namespace == "" {
-		namespace = h.getDefaultNamespace()
-	}
-
-	settings := cli.New()
+	namespace := InstallNamespace(integration)
 
-	// ✅ FIX: Write kubeconfig and keep it until Helm finishes
-	kubeconfigFile, cleanup, err := writeKubeconfigToTempFile(config)
+	values, err := chartValues(integration, helmConfig)
 	if err != nil {
-		return fmt.Errorf("failed to write kubeconfig: %w", err)
+		return err
 	}
-	// Cleanup AFTER all operations complete
-	defer cleanup()
 
-	settings.KubeConfig = kubeconfigFile
+	// Set up the namespace before the chart creates any pod
+	if err := prepareNamespace(ctx, config, integration, namespace); err != nil {
+		return err
+	}
 
-	// ✅ FIX: Extract repo name from URL, not chart name
-	repoName := extractRepoNameFromURL(helmConfig.Repository)
+	return installRelease(ctx, config, helmConfig, namespace, values, skipCRDs)
+}
 
-	// Add Helm repository
-	if err := h.addHelmRepo(ctx, helmConfig.Repository, repoName, settings); err != nil {
-		return fmt.Errorf("failed to add helm repo: %w", err)
+// installRelease installs the chart of helmConfig as a release in namespace, or
+// upgrades the release if it already exists. skipCRDs leaves out the chart's crds directories.
+func installRelease(ctx context.Context, config *rest.Config, helmConfig *ksitv1alpha1.HelmInstallConfig, namespace string, values map[string]interface{}, skipCRDs bool) error {
+	settings, release, err := newHelmSettings(config)
+	if err != nil {
+		return err
 	}
+	// Keep the kubeconfig until Helm finishes
+	defer release()
 
 	// Initialize action configuration
 	actionConfig := new(action.Configuration)
@@ -65,6 +81,13 @@ func (h *HelmInstaller) Install(ctx context.Context, config *rest.Config, integr
 		return fmt.Errorf("failed to initialize helm action config: %w", err)
 	}
 
+	// The settings are locked while the repository is recorded and the chart
+	// fetched, but not while Helm installs and waits for the release
+	loadedChart, caFile, err := loadChart(ctx, settings, actionConfig, helmConfig)
+	if err != nil {
+		return err
+	}
+
 	// Check if release exists
 	listClient := action.NewList(actionConfig)
 	releases, err := listClient.Run()
@@ -74,20 +97,18 @@ func (h *HelmInstaller) Install(ctx context.Context, config *rest.Config, integr
 				// Upgrade existing release
 				upgradeClient := action.NewUpgrade(actionConfig)
 				upgradeClient.Namespace = namespace
-
-				chartPath := fmt.Sprintf("%s/%s", repoName, helmConfig.Chart)
-				chartRequested, err := upgradeClient.ChartPathOptions.LocateChart(chartPath, settings)
-				if err != nil {
-					return fmt.Errorf("failed to locate chart: %w", err)
-				}
-
-				loadedChart, err := loader.Load(chartRequested)
-				if err != nil {
-					return fmt.Errorf("failed to load chart: %w", err)
+				upgradeClient.Version = helmConfig.Version
+				upgradeClient.Wait = helmConfig.Wait
+				upgradeClient.Atomic = helmConfig.Atomic
+				upgradeClient.Timeout = helmTimeout(helmConfig)
+				upgradeClient.CaFile = caFile
+
+				_, err = upgradeClient.Run(helmConfig.ReleaseName, loadedChart, values)
+				if err != nil && !helmConfig.Atomic {
+					// Atomic upgrades roll back by themselves
+					err = rollbackFailedUpgrade(ctx, actionConfig, helmConfig, err)
 				}
-
-				_, err = upgradeClient.Run(helmConfig.ReleaseName, loadedChart, convertValuesToMap(helmConfig.Values))
-				return err
+				return conditions.Helm(helmConfig.ReleaseName, err)
 			}
 		}
 	}
@@ -97,20 +118,117 @@ func (h *HelmInstaller) Install(ctx context.Context, config *rest.Config, integr
 	installClient.Namespace = namespace
 	installClient.CreateNamespace = true
 	installClient.ReleaseName = helmConfig.ReleaseName
+	installClient.Version = helmConfig.Version
+	installClient.SkipCRDs = skipCRDs
+	installClient.Wait = helmConfig.Wait
+	installClient.Atomic = helmConfig.Atomic
+	installClient.Timeout = helmTimeout(helmConfig)
+	installClient.CaFile = caFile
+
+	_, err = installClient.Run(loadedChart, values)
+	return conditions.Helm(helmConfig.ReleaseName, err)
+}
 
-	chartPath := fmt.Sprintf("%s/%s", repoName, helmConfig.Chart)
-	chartRequested, err := installClient.ChartPathOptions.LocateChart(chartPath, settings)
+// loadChart records the repository of helmConfig in the settings, or sets up the
+// registry client of actionConfig for an OCI chart, then fetches and loads the chart.
+// It holds the settings lock throughout and returns the CA file to trust for the chart.
+// Credentials are passed to this fetch alone and are not left in the settings.
+func loadChart(ctx context.Context, settings *cli.EnvSettings, actionConfig *action.Configuration, helmConfig *ksitv1alpha1.HelmInstallConfig) (*chart.Chart, string, error) {
+	unlock := lockHelmSettings(settings)
+	defer unlock()
+
+	if err := removeStoredCredentials(settings); err != nil {
+		return nil, "", err
+	}
+	caFile, err := writeCABundle(settings, helmConfig.CABundle)
 	if err != nil {
-		return fmt.Errorf("failed to locate chart: %w", err)
+		return nil, "", err
+	}
+	name := chartRef(helmConfig)
+	switch {
+	case registry.IsOCI(helmConfig.Repository):
+		// OCI registries have no index to add; the chart is pulled by reference
+		registryClient, cleanup, err := newRegistryClient(settings, helmConfig)
+		if err != nil {
+			return nil, "", err
+		}
+		defer cleanup()
+		actionConfig.RegistryClient = registryClient
+	case helmConfig.Credentials != nil:
+		// A private repo is not added to the shared repo file; its chart is fetched by URL
+		if name, err = findChartURL(settings, helmConfig, caFile); err != nil {
+			return nil, "", conditions.Helm(helmConfig.ReleaseName, fmt.Errorf("failed to locate chart: %w", err))
+		}
+	default:
+		if err := addHelmRepo(ctx, repoEntry(helmConfig, caFile), settings); err != nil {
+			return nil, "", fmt.Errorf("failed to add helm repo: %w", err)
+		}
+	}
+
+	// An install action carries the registry client of actionConfig into the chart lookup
+	locate := action.NewInstall(actionConfig)
+	locate.Version = helmConfig.Version
+	locate.CaFile = caFile
+	if creds := helmConfig.Credentials; creds != nil {
+		locate.Username, locate.Password = creds.Username, creds.Password
+	}
+	chartRequested, err := locate.ChartPathOptions.LocateChart(name, settings)
+	if err != nil {
+		return nil, "", conditions.Helm(helmConfig.ReleaseName, fmt.Errorf("failed to locate chart: %w", err))
 	}
 
 	loadedChart, err := loader.Load(chartRequested)
+	if helmConfig.Credentials != nil {
+		// A private chart is not kept in the cache shared with other Integrations
+		os.Remove(chartRequested)
+	}
+	if err != nil {
+		return nil, "", conditions.Helm(helmConfig.ReleaseName, fmt.Errorf("failed to load chart: %w", err))
+	}
+	return loadedChart, caFile, nil
+}
+
+// rollbackFailedUpgrade rolls a release back to its last deployed revision after an
+// upgrade failed, so the cluster does not keep a half-deployed release, and returns the
+// upgrade error annotated with the outcome of the rollback
+func rollbackFailedUpgrade(ctx context.Context, actionConfig *action.Configuration, helmConfig *ksitv1alpha1.HelmInstallConfig, upgradeErr error) error {
+	deployed, err := actionConfig.Releases.Deployed(helmConfig.ReleaseName)
 	if err != nil {
-		return fmt.Errorf("failed to load chart: %w", err)
+		// Nothing was deployed before, or the upgrade failed before replacing it
+		return upgradeErr
+	}
+	last, err := actionConfig.Releases.Last(helmConfig.ReleaseName)
+	if err != nil || last.Version == deployed.Version {
+		return upgradeErr
 	}
 
-	_, err = installClient.Run(loadedChart, convertValuesToMap(helmConfig.Values))
-	return err
+	rollback := action.NewRollback(actionConfig)
+	rollback.Version = deployed.Version
+	rollback.Wait = helmConfig.Wait
+	rollback.Timeout = helmTimeout(helmConfig)
+	rollback.CleanupOnFail = true
+	if err := rollback.Run(helmConfig.ReleaseName); err != nil {
+		return fmt.Errorf("upgrade failed: %w; rollback to revision %d failed: %v", upgradeErr, deployed.Version, err)
+	}
+	reportProgress(ctx, fmt.Sprintf("upgrade of %s failed, rolled back to revision %d", helmConfig.ReleaseName, deployed.Version))
+	return fmt.Errorf("upgrade failed and was rolled back to revision %d: %w", deployed.Version, upgradeErr)
+}
+
+// helmTimeout returns how long each Helm operation of helmConfig may take
+func helmTimeout(helmConfig *ksitv1alpha1.HelmInstallConfig) time.Duration {
+	if helmConfig.Timeout != nil && helmConfig.Timeout.Duration > 0 {
+		return helmConfig.Timeout.Duration
+	}
+	return defaultHelmTimeout
+}
+
+// chartRef returns the chart reference to locate: <registry>/<chart> for an OCI
+// registry, or <repo name>/<chart> for a chart repository added under its name
+func chartRef(helmConfig *ksitv1alpha1.HelmInstallConfig) string {
+	if registry.IsOCI(helmConfig.Repository) {
+		return strings.TrimSuffix(helmConfig.Repository, "/") + "/" + helmConfig.Chart
+	}
+	return fmt.Sprintf("%s/%s", extractRepoNameFromURL(helmConfig.Repository), helmConfig.Chart)
 }
 
 // ✅ ADD THIS NEW HELPER FUNCTION
@@ -131,19 +249,21 @@ func (h *HelmInstaller) Uninstall(ctx context.Context, config *rest.Config, inte
 		helmConfig = h.defaultConfig
 	}
 
-	namespace := integration.Spec.Config["namespace"]
-	if namespace == "" {
-		namespace = h.getDefaultNamespace()
+	namespace := InstallNamespace(integration)
+
+	if err := uninstallRelease(config, helmConfig.ReleaseName, namespace); err != nil {
+		return err
 	}
+	return removeHardening(ctx, config, integration, namespace)
+}
 
-	settings := cli.New()
-	kubeconfigFile, cleanup, err := writeKubeconfigToTempFile(config)
+// uninstallRelease removes a release from namespace
+func uninstallRelease(config *rest.Config, releaseName, namespace string) error {
+	settings, release, err := newHelmSettings(config)
 	if err != nil {
-		return fmt.Errorf("failed to write kubeconfig: %w", err)
+		return err
 	}
-	defer cleanup()
-
-	settings.KubeConfig = kubeconfigFile
+	defer release()
 
 	actionConfig := new(action.Configuration)
 	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
@@ -151,8 +271,8 @@ func (h *HelmInstaller) Uninstall(ctx context.Context, config *rest.Config, inte
 	}
 
 	uninstallClient := action.NewUninstall(actionConfig)
-	_, err = uninstallClient.Run(helmConfig.ReleaseName)
-	return err
+	_, err = uninstallClient.Run(releaseName)
+	return conditions.Helm(releaseName, err)
 }
 
 // IsInstalled checks if the Helm release exists
@@ -162,19 +282,122 @@ func (h *HelmInstaller) IsInstalled(ctx context.Context, config *rest.Config, in
 		helmConfig = h.defaultConfig
 	}
 
-	namespace := integration.Spec.Config["namespace"]
-	if namespace == "" {
-		namespace = h.getDefaultNamespace()
+	namespace := InstallNamespace(integration)
+
+	return releaseExists(config, helmConfig.ReleaseName, namespace)
+}
+
+// Releases reports the release of the integration, unless it is not installed
+func (h *HelmInstaller) Releases(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) ([]ksitv1alpha1.HelmReleaseStatus, error) {
+	helmConfig := integration.Spec.AutoInstall.HelmConfig
+	if helmConfig == nil {
+		helmConfig = h.defaultConfig
+	}
+	return releaseStatuses(config, InstallNamespace(integration), helmConfig.ReleaseName)
+}
+
+// releaseStatuses returns the state of the named releases in namespace, leaving out
+// those that are not installed
+func releaseStatuses(config *rest.Config, namespace string, releaseNames ...string) ([]ksitv1alpha1.HelmReleaseStatus, error) {
+	settings, release, err := newHelmSettings(config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
+		return nil, fmt.Errorf("failed to initialize helm action config: %w", err)
+	}
+
+	var statuses []ksitv1alpha1.HelmReleaseStatus
+	for _, name := range releaseNames {
+		rel, err := action.NewGet(actionConfig).Run(name)
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+		}
+		statuses = append(statuses, releaseStatus(rel))
+	}
+	return statuses, nil
+}
+
+// releaseStatus describes a release for the Integration status
+func releaseStatus(rel *helmrelease.Release) ksitv1alpha1.HelmReleaseStatus {
+	status := ksitv1alpha1.HelmReleaseStatus{
+		Name:     rel.Name,
+		Revision: int32(rel.Version),
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		status.Chart = rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version
+		status.ChartVersion = rel.Chart.Metadata.Version
+	}
+	if rel.Info != nil {
+		status.Status = rel.Info.Status.String()
+		status.Description = rel.Info.Description
+		if !rel.Info.LastDeployed.IsZero() {
+			lastDeployed := metav1.NewTime(rel.Info.LastDeployed.Time)
+			status.LastDeployed = &lastDeployed
+		}
+	}
+	return status
+}
+
+// Drift reports a release that is missing or not deployed, or a workload of the release
+// that was deleted from the cluster
+func (h *HelmInstaller) Drift(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (string, error) {
+	helmConfig := integration.Spec.AutoInstall.HelmConfig
+	if helmConfig == nil {
+		helmConfig = h.defaultConfig
+	}
+	return releaseDrift(ctx, config, helmConfig.ReleaseName, InstallNamespace(integration))
+}
+
+// releaseDrift describes how a release in namespace differs from its last deployed
+// manifest, or returns "" when its workloads all exist
+func releaseDrift(ctx context.Context, config *rest.Config, releaseName, namespace string) (string, error) {
+	settings, release, err := newHelmSettings(config)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
+		return "", fmt.Errorf("failed to initialize helm action config: %w", err)
 	}
 
-	settings := cli.New()
-	kubeconfigFile, cleanup, err := writeKubeconfigToTempFile(config)
+	rel, err := action.NewGet(actionConfig).Run(releaseName)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return fmt.Sprintf("release %s is missing", releaseName), nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("failed to write kubeconfig: %w", err)
+		return "", fmt.Errorf("failed to get release %s: %w", releaseName, err)
+	}
+	if rel.Info.Status != helmrelease.StatusDeployed {
+		return fmt.Sprintf("release %s is %s", releaseName, rel.Info.Status), nil
 	}
-	defer cleanup()
 
-	settings.KubeConfig = kubeconfigFile
+	objs, err := manifests.Decode([]byte(rel.Manifest))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode manifest of release %s: %w", releaseName, err)
+	}
+	dynClient, mapper, err := manifests.NewClients(config)
+	if err != nil {
+		return "", err
+	}
+	return missingObject(ctx, dynClient, mapper, workloadsOf(objs), namespace)
+}
+
+// releaseExists reports whether a release is installed in namespace
+func releaseExists(config *rest.Config, releaseName, namespace string) (bool, error) {
+	settings, release, err := newHelmSettings(config)
+	if err != nil {
+		return false, err
+	}
+	defer release()
 
 	actionConfig := new(action.Configuration)
 	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
@@ -188,7 +411,7 @@ func (h *HelmInstaller) IsInstalled(ctx context.Context, config *rest.Config, in
 	}
 
 	for _, rel := range releases {
-		if rel.Name == helmConfig.ReleaseName {
+		if rel.Name == releaseName {
 			return true, nil
 		}
 	}
@@ -196,29 +419,13 @@ func (h *HelmInstaller) IsInstalled(ctx context.Context, config *rest.Config, in
 	return false, nil
 }
 
-// addHelmRepo adds a Helm repository
-func (h *HelmInstaller) addHelmRepo(ctx context.Context, repoURL, repoName string, settings *cli.EnvSettings) error {
-	// ✅ FIX: Ensure writable paths under /tmp for container environments
-	if settings.RepositoryConfig == "" {
-		settings.RepositoryConfig = filepath.Join("/tmp", "helm", "repositories.yaml")
-	}
-	if settings.RepositoryCache == "" {
-		settings.RepositoryCache = filepath.Join("/tmp", "helm", "cache")
-	}
-
+// addHelmRepo adds a Helm repository, or updates it when its URL, credentials or CA
+// bundle changed, and downloads its index
+func addHelmRepo(ctx context.Context, entry *repo.Entry, settings *cli.EnvSettings) error {
+	// The settings come from newHelmSettings, so the files below belong to this
+	// cluster; callers hold lockHelmSettings while they are read and rewritten
 	repoFile := settings.RepositoryConfig
-
-	// Ensure directory exists
-	repoDir := filepath.Dir(repoFile)
-	if err := os.MkdirAll(repoDir, 0755); err != nil {
-		return fmt.Errorf("failed to create repo dir: %w", err)
-	}
-
-	// Also create cache directory
 	cacheDir := settings.RepositoryCache
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return fmt.Errorf("failed to create cache dir: %w", err)
-	}
 
 	// Load existing repos
 	b, err := os.ReadFile(repoFile)
@@ -240,41 +447,33 @@ func (h *HelmInstaller) addHelmRepo(ctx context.Context, repoURL, repoName strin
 	// Check if repo already exists
 	var repoEntry *repo.Entry
 	for _, r := range repoFileContent.Repositories {
-		if r.Name == repoName {
+		if r.Name == entry.Name {
 			repoEntry = r
 			break
 		}
 	}
+	if repoEntry != nil && *repoEntry == *entry {
+		return nil
+	}
 
-	// Add new repo if not exists
-	if repoEntry == nil {
-		repoEntry = &repo.Entry{
-			Name: repoName,
-			URL:  repoURL,
-		}
-		repoFileContent.Repositories = append(repoFileContent.Repositories, repoEntry)
-
-		// Write updated file
-		data, err := yaml.Marshal(&repoFileContent)
-		if err != nil {
-			return fmt.Errorf("failed to marshal repo file: %w", err)
-		}
-		if err := os.WriteFile(repoFile, data, 0644); err != nil {
-			return fmt.Errorf("failed to write repo file: %w", err)
-		}
+	// Download the index before recording the repo, so a failed download is retried
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		return fmt.Errorf("failed to create chart repository: %w", err)
+	}
+	chartRepo.CachePath = cacheDir
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		return fmt.Errorf("failed to download repo index: %w", err)
+	}
 
-		// ✅ FIX: Download repo index after adding new repository
-		chartRepo, err := repo.NewChartRepository(repoEntry, getter.All(settings))
-		if err != nil {
-			return fmt.Errorf("failed to create chart repository: %w", err)
-		}
-		chartRepo.CachePath = cacheDir
-		if _, err := chartRepo.DownloadIndexFile(); err != nil {
-			return fmt.Errorf("failed to download repo index: %w", err)
-		}
+	// Add the repo, or replace the changed one
+	if repoEntry == nil {
+		repoFileContent.Repositories = append(repoFileContent.Repositories, entry)
+	} else {
+		*repoEntry = *entry
 	}
 
-	return nil
+	return writeRepoFile(repoFile, &repoFileContent)
 }
 
 // writeKubeconfigToTempFile writes kubeconfig to temp file and returns path + cleanup func
@@ -300,6 +499,8 @@ func writeKubeconfigToTempFile(config *rest.Config) (string, func(), error) {
 				ClientCertificateData: config.CertData,
 				ClientKeyData:         config.KeyData,
 				Token:                 config.BearerToken,
+				Impersonate:           config.Impersonate.UserName,
+				ImpersonateGroups:     config.Impersonate.Groups,
 			},
 		},
 	}
@@ -309,29 +510,7 @@ func writeKubeconfigToTempFile(config *rest.Config) (string, func(), error) {
 		return "", nil, fmt.Errorf("failed to marshal kubeconfig: %w", err)
 	}
 
-	// Write to temp file under /tmp for container compatibility
-	tmpFile, err := os.CreateTemp("/tmp", "kubeconfig-*.yaml")
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
-	}
-
-	if _, err := tmpFile.Write(kubeconfigBytes); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return "", nil, fmt.Errorf("failed to write kubeconfig: %w", err)
-	}
-
-	if err := tmpFile.Close(); err != nil {
-		os.Remove(tmpFile.Name())
-		return "", nil, fmt.Errorf("failed to close temp file: %w", err)
-	}
-
-	// Return path and cleanup function
-	cleanup := func() {
-		os.Remove(tmpFile.Name())
-	}
-
-	return tmpFile.Name(), cleanup, nil
+	return createTempKubeconfig(kubeconfigBytes)
 }
 
 // convertValuesToMap converts string map to interface map
@@ -343,9 +522,50 @@ func convertValuesToMap(values map[string]string) map[string]interface{} {
 	return result
 }
 
+// chartValues returns helmConfig.valuesObject with helmConfig.values and then
+// autoInstall.overrides merged over it
+func chartValues(integration *ksitv1alpha1.Integration, helmConfig *ksitv1alpha1.HelmInstallConfig) (map[string]interface{}, error) {
+	values, err := objectValues(helmConfig)
+	if err != nil {
+		return nil, err
+	}
+	mergeValues(values, convertValuesToMap(helmConfig.Values))
+	overrides := integration.Spec.AutoInstall.Overrides
+	if overrides == nil {
+		return values, nil
+	}
+
+	overrideValues, err := helmOverrideValues(helmConfig.Chart, overrides)
+	if err != nil {
+		return nil, err
+	}
+	mergeValues(values, overrideValues)
+	return values, nil
+}
+
+// objectValues decodes helmConfig.valuesObject, or returns empty values when it is not set
+func objectValues(helmConfig *ksitv1alpha1.HelmInstallConfig) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if helmConfig.ValuesObject == nil || len(helmConfig.ValuesObject.Raw) == 0 {
+		return values, nil
+	}
+	if err := json.Unmarshal(helmConfig.ValuesObject.Raw, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode helmConfig.valuesObject: %w", err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
 // getDefaultNamespace returns the default namespace for the integration type
 func (h *HelmInstaller) getDefaultNamespace() string {
-	switch h.integrationType {
+	return defaultNamespace(h.integrationType)
+}
+
+// defaultNamespace is where an integration type is installed when config.namespace is not set
+func defaultNamespace(integrationType string) string {
+	switch integrationType {
 	case ksitv1alpha1.IntegrationTypeArgoCD:
 		return "argocd"
 	case ksitv1alpha1.IntegrationTypeFlux:
@@ -354,6 +574,12 @@ func (h *HelmInstaller) getDefaultNamespace() string {
 		return "monitoring"
 	case ksitv1alpha1.IntegrationTypeIstio:
 		return "istio-system"
+	case ksitv1alpha1.IntegrationTypeGrafana:
+		return "grafana"
+	case ksitv1alpha1.IntegrationTypeCertManager:
+		return "cert-manager"
+	case ksitv1alpha1.IntegrationTypeKyverno:
+		return "kyverno"
 	default:
 		return "default"
 	}
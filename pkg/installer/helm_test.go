@@ -0,0 +1,88 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func testRelease(version int, status helmrelease.Status) *helmrelease.Release {
+	return &helmrelease.Release{
+		Name:      "argocd",
+		Namespace: "argocd",
+		Version:   version,
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "argo-cd", Version: "5.51.0", APIVersion: chart.APIVersionV2}},
+		Info: &helmrelease.Info{
+			Status:       status,
+			Description:  "Upgrade complete",
+			LastDeployed: helmtime.Unix(1700000000, 0),
+		},
+	}
+}
+
+func TestRollbackFailedUpgrade(t *testing.T) {
+	ctx := context.Background()
+	helmConfig := &ksitv1alpha1.HelmInstallConfig{ReleaseName: "argocd"}
+	upgradeErr := errors.New("timed out waiting for the condition")
+
+	cfg := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(string, ...interface{}) {},
+	}
+	require.NoError(t, cfg.Releases.Create(testRelease(1, helmrelease.StatusDeployed)))
+
+	// The upgrade failed before creating a revision: there is nothing to roll back
+	err := rollbackFailedUpgrade(ctx, cfg, helmConfig, upgradeErr)
+	assert.Equal(t, upgradeErr, err)
+
+	require.NoError(t, cfg.Releases.Create(testRelease(2, helmrelease.StatusFailed)))
+	err = rollbackFailedUpgrade(ctx, cfg, helmConfig, upgradeErr)
+	assert.ErrorIs(t, err, upgradeErr)
+	assert.ErrorContains(t, err, "rolled back to revision 1")
+
+	last, err := cfg.Releases.Last("argocd")
+	require.NoError(t, err)
+	assert.Equal(t, 3, last.Version)
+	assert.Equal(t, helmrelease.StatusDeployed, last.Info.Status)
+}
+
+func TestReleaseStatus(t *testing.T) {
+	status := releaseStatus(testRelease(4, helmrelease.StatusPendingUpgrade))
+	assert.Equal(t, ksitv1alpha1.HelmReleaseStatus{
+		Name:         "argocd",
+		Revision:     4,
+		Status:       "pending-upgrade",
+		Chart:        "argo-cd-5.51.0",
+		ChartVersion: "5.51.0",
+		Description:  "Upgrade complete",
+		LastDeployed: &metav1.Time{Time: time.Unix(1700000000, 0)},
+	}, status)
+}
+
+func TestHelmTimeout(t *testing.T) {
+	assert.Equal(t, defaultHelmTimeout, helmTimeout(&ksitv1alpha1.HelmInstallConfig{}))
+	assert.Equal(t, 10*time.Minute, helmTimeout(&ksitv1alpha1.HelmInstallConfig{Timeout: &metav1.Duration{Duration: 10 * time.Minute}}))
+}
+
+func TestChartRef(t *testing.T) {
+	assert.Equal(t, "argo-helm/argo-cd", chartRef(&ksitv1alpha1.HelmInstallConfig{Repository: "https://argoproj.github.io/argo-helm/", Chart: "argo-cd"}))
+	assert.Equal(t, "oci://ghcr.io/argoproj/argo-helm/argo-cd", chartRef(&ksitv1alpha1.HelmInstallConfig{Repository: "oci://ghcr.io/argoproj/argo-helm/", Chart: "argo-cd"}))
+}
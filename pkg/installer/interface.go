@@ -2,6 +2,7 @@ package installer
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/client-go/rest"
 
@@ -18,20 +19,42 @@ type Installer interface {
 	IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error)
 }
 
+// DriftDetector is implemented by installers that can tell whether an installed tool
+// still looks the way they installed it
+type DriftDetector interface {
+	// Drift describes the first difference found between the cluster and what the
+	// installer would install, or returns "" when there is none
+	Drift(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (string, error)
+}
+
+// ReleaseReporter is implemented by installers that install Helm releases
+type ReleaseReporter interface {
+	// Releases returns the state of the integration's releases on the cluster, leaving
+	// out releases that are not installed. Cluster is not set.
+	Releases(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) ([]ksitv1alpha1.HelmReleaseStatus, error)
+}
+
 // InstallerFactory creates appropriate installer based on integration type
 type InstallerFactory struct {
 	installers map[string]Installer
+	manifest   Installer
+	operator   Installer
 }
 
 // NewInstallerFactory creates a new installer factory
 func NewInstallerFactory() *InstallerFactory {
 	return &InstallerFactory{
 		installers: map[string]Installer{
-			ksitv1alpha1.IntegrationTypeArgoCD:     NewArgoCDInstaller(),
-			ksitv1alpha1.IntegrationTypeFlux:       NewFluxInstaller(),
-			ksitv1alpha1.IntegrationTypePrometheus: NewPrometheusInstaller(),
-			ksitv1alpha1.IntegrationTypeIstio:      NewIstioInstaller(),
+			ksitv1alpha1.IntegrationTypeArgoCD:      NewArgoCDInstaller(),
+			ksitv1alpha1.IntegrationTypeFlux:        NewFluxInstaller(),
+			ksitv1alpha1.IntegrationTypePrometheus:  NewPrometheusInstaller(),
+			ksitv1alpha1.IntegrationTypeIstio:       NewIstioInstaller(),
+			ksitv1alpha1.IntegrationTypeGrafana:     NewGrafanaInstaller(),
+			ksitv1alpha1.IntegrationTypeCertManager: NewCertManagerInstaller(),
+			ksitv1alpha1.IntegrationTypeKyverno:     NewKyvernoInstaller(),
 		},
+		manifest: NewManifestInstaller(),
+		operator: NewOperatorInstaller(),
 	}
 }
 
@@ -43,3 +66,27 @@ func (f *InstallerFactory) GetInstaller(integrationType string) (Installer, erro
 	}
 	return installer, nil
 }
+
+// InstallerFor returns the installer of an integration: the OperatorInstaller when
+// autoInstall.method is operator, the ManifestInstaller when it is manifest, except for
+// Flux whose installer applies manifests itself, and otherwise the installer of its type
+func (f *InstallerFactory) InstallerFor(integration *ksitv1alpha1.Integration) (Installer, error) {
+	if install := integration.Spec.AutoInstall; install != nil {
+		switch {
+		case install.Method == "operator":
+			return f.operator, nil
+		case install.Method == "manifest" && integration.Spec.Type != ksitv1alpha1.IntegrationTypeFlux:
+			return f.manifest, nil
+		}
+	}
+	installer, ok := f.installers[integration.Spec.Type]
+	if !ok {
+		return nil, fmt.Errorf("no installer for integration type %s", integration.Spec.Type)
+	}
+	return installer, nil
+}
+
+// Register sets the installer used for an integration type, replacing any existing one
+func (f *InstallerFactory) Register(integrationType string, installer Installer) {
+	f.installers[integrationType] = installer
+}
@@ -1,22 +1,216 @@
 package installer
 
 import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
 )
 
+const (
+	istioRepository = "https://istio-release.storage.googleapis.com/charts"
+	istioVersion    = "1.20.2"
+)
+
+// IstioInstaller installs Istio from the charts of an install profile. Integrations
+// without autoInstall.profile keep the single istiod chart they were installed with.
+type IstioInstaller struct {
+	*HelmInstaller
+}
+
+// istioComponent is one chart of an Istio profile
+type istioComponent struct {
+	chart       string
+	releaseName string
+	values      map[string]interface{}
+}
+
 // NewIstioInstaller creates a new Istio installer with default configuration
-func NewIstioInstaller() *HelmInstaller {
-	return &HelmInstaller{
-		integrationType: ksitv1alpha1.IntegrationTypeIstio,
-		defaultConfig: &ksitv1alpha1.HelmInstallConfig{
-			Repository:  "https://istio-release.storage.googleapis.com/charts",
-			Chart:       "istiod",
-			Version:     "1.20.2",
-			ReleaseName: "istio",
-			Values: map[string]string{
-				"global.proxy.resources.requests.cpu":    "10m",
-				"global.proxy.resources.requests.memory": "128Mi",
+func NewIstioInstaller() *IstioInstaller {
+	return &IstioInstaller{
+		HelmInstaller: &HelmInstaller{
+			integrationType: ksitv1alpha1.IntegrationTypeIstio,
+			defaultConfig: &ksitv1alpha1.HelmInstallConfig{
+				Repository:  istioRepository,
+				Chart:       "istiod",
+				Version:     istioVersion,
+				ReleaseName: "istio",
+				Values: map[string]string{
+					"global.proxy.resources.requests.cpu":    "10m",
+					"global.proxy.resources.requests.memory": "128Mi",
+				},
 			},
 		},
 	}
 }
+
+// istioComponents returns the charts of a profile in install order: base carries the
+// CRDs every other chart needs, and gateways need istiod to inject them
+func istioComponents(profile string) []istioComponent {
+	// The charts read the same profile names for their profile-specific defaults
+	chartProfile := map[string]interface{}{}
+	if profile == ksitv1alpha1.IstioProfileDemo || profile == ksitv1alpha1.IstioProfileAmbient {
+		chartProfile["profile"] = profile
+	}
+
+	components := []istioComponent{
+		{chart: "base", releaseName: "istio-base", values: chartProfile},
+		{chart: "istiod", releaseName: "istiod", values: chartProfile},
+	}
+
+	switch profile {
+	case ksitv1alpha1.IstioProfileDefault:
+		components = append(components,
+			istioComponent{chart: "gateway", releaseName: "istio-ingressgateway", values: map[string]interface{}{}})
+	case ksitv1alpha1.IstioProfileDemo:
+		components = append(components,
+			istioComponent{chart: "gateway", releaseName: "istio-ingressgateway", values: map[string]interface{}{}},
+			istioComponent{chart: "gateway", releaseName: "istio-egressgateway", values: map[string]interface{}{
+				"service": map[string]interface{}{"type": "ClusterIP"},
+			}})
+	case ksitv1alpha1.IstioProfileAmbient:
+		components = append(components,
+			istioComponent{chart: "cni", releaseName: "istio-cni", values: chartProfile},
+			istioComponent{chart: "ztunnel", releaseName: "ztunnel", values: map[string]interface{}{}})
+	}
+	return components
+}
+
+// componentConfig returns the chart settings of a profile component
+func componentConfig(integration *ksitv1alpha1.Integration, component istioComponent) *ksitv1alpha1.HelmInstallConfig {
+	helmConfig := &ksitv1alpha1.HelmInstallConfig{
+		Repository:  istioRepository,
+		Chart:       component.chart,
+		Version:     istioVersion,
+		ReleaseName: component.releaseName,
+	}
+	if user := integration.Spec.AutoInstall.HelmConfig; user != nil {
+		if user.Repository != "" {
+			helmConfig.Repository = user.Repository
+		}
+		if user.Version != "" {
+			helmConfig.Version = user.Version
+		}
+		helmConfig.Wait, helmConfig.Atomic, helmConfig.Timeout = user.Wait, user.Atomic, user.Timeout
+		helmConfig.CABundle, helmConfig.Credentials = user.CABundle, user.Credentials
+		// helmConfig.values configure istiod, as they do without a profile
+		if component.chart == "istiod" {
+			helmConfig.Values = user.Values
+			helmConfig.ValuesObject = user.ValuesObject
+		}
+	}
+	return helmConfig
+}
+
+// Install installs every chart of the profile, or the single chart without one
+func (i *IstioInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	profile := integration.Spec.AutoInstall.Profile
+	if profile == "" {
+		return i.HelmInstaller.Install(ctx, config, integration)
+	}
+
+	namespace := InstallNamespace(integration)
+	if err := prepareNamespace(ctx, config, integration, namespace); err != nil {
+		return err
+	}
+	components := istioComponents(profile)
+	for n, component := range components {
+		helmConfig := componentConfig(integration, component)
+
+		var values map[string]interface{}
+		if component.chart == "istiod" {
+			// Overrides are only supported on istiod
+			var err error
+			if values, err = chartValues(integration, helmConfig); err != nil {
+				return err
+			}
+		} else {
+			values = map[string]interface{}{}
+		}
+		mergeValues(values, component.values)
+
+		if err := installRelease(ctx, config, helmConfig, namespace, values, false); err != nil {
+			return fmt.Errorf("failed to install %s: %w", component.releaseName, err)
+		}
+		reportProgress(ctx, fmt.Sprintf("installed %s (%d/%d Istio components)", component.releaseName, n+1, len(components)))
+	}
+	return nil
+}
+
+// Uninstall removes the charts of the profile in reverse install order, then the
+// NetworkPolicies of hardening
+func (i *IstioInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	profile := integration.Spec.AutoInstall.Profile
+	if profile == "" {
+		return i.HelmInstaller.Uninstall(ctx, config, integration)
+	}
+
+	namespace := InstallNamespace(integration)
+	components := istioComponents(profile)
+	for n := len(components) - 1; n >= 0; n-- {
+		releaseName := components[n].releaseName
+		installed, err := releaseExists(config, releaseName, namespace)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			continue
+		}
+		if err := uninstallRelease(config, releaseName, namespace); err != nil {
+			return fmt.Errorf("failed to uninstall %s: %w", releaseName, err)
+		}
+	}
+	return removeHardening(ctx, config, integration, namespace)
+}
+
+// IsInstalled reports whether every chart of the profile is installed, so a profile
+// that gained a component is completed on the next reconcile
+func (i *IstioInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	profile := integration.Spec.AutoInstall.Profile
+	if profile == "" {
+		return i.HelmInstaller.IsInstalled(ctx, config, integration)
+	}
+
+	namespace := InstallNamespace(integration)
+	for _, component := range istioComponents(profile) {
+		installed, err := releaseExists(config, component.releaseName, namespace)
+		if err != nil || !installed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Drift reports the first chart of the profile whose release drifted
+func (i *IstioInstaller) Drift(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (string, error) {
+	profile := integration.Spec.AutoInstall.Profile
+	if profile == "" {
+		return i.HelmInstaller.Drift(ctx, config, integration)
+	}
+
+	namespace := InstallNamespace(integration)
+	for _, component := range istioComponents(profile) {
+		drift, err := releaseDrift(ctx, config, component.releaseName, namespace)
+		if drift != "" || err != nil {
+			return drift, err
+		}
+	}
+	return "", nil
+}
+
+// Releases reports the releases of the charts of the profile that are installed
+func (i *IstioInstaller) Releases(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) ([]ksitv1alpha1.HelmReleaseStatus, error) {
+	profile := integration.Spec.AutoInstall.Profile
+	if profile == "" {
+		return i.HelmInstaller.Releases(ctx, config, integration)
+	}
+
+	components := istioComponents(profile)
+	names := make([]string, len(components))
+	for n, component := range components {
+		names[n] = component.releaseName
+	}
+	return releaseStatuses(config, InstallNamespace(integration), names...)
+}
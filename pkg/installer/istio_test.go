@@ -0,0 +1,56 @@
+package installer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func releaseNames(components []istioComponent) []string {
+	var names []string
+	for _, component := range components {
+		names = append(names, component.releaseName)
+	}
+	return names
+}
+
+func TestIstioComponents(t *testing.T) {
+	assert.Equal(t, []string{"istio-base", "istiod"}, releaseNames(istioComponents(ksitv1alpha1.IstioProfileMinimal)))
+	assert.Equal(t, []string{"istio-base", "istiod", "istio-ingressgateway"}, releaseNames(istioComponents(ksitv1alpha1.IstioProfileDefault)))
+	assert.Equal(t, []string{"istio-base", "istiod", "istio-ingressgateway", "istio-egressgateway"}, releaseNames(istioComponents(ksitv1alpha1.IstioProfileDemo)))
+
+	ambient := istioComponents(ksitv1alpha1.IstioProfileAmbient)
+	assert.Equal(t, []string{"istio-base", "istiod", "istio-cni", "ztunnel"}, releaseNames(ambient))
+	assert.Equal(t, "ambient", ambient[1].values["profile"])
+	assert.Empty(t, istioComponents(ksitv1alpha1.IstioProfileMinimal)[1].values)
+}
+
+func TestIstioComponentConfig(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:        ksitv1alpha1.IntegrationTypeIstio,
+			AutoInstall: &ksitv1alpha1.InstallConfig{Profile: ksitv1alpha1.IstioProfileDefault},
+		},
+	}
+	components := istioComponents(ksitv1alpha1.IstioProfileDefault)
+
+	base := componentConfig(integration, components[0])
+	assert.Equal(t, istioRepository, base.Repository)
+	assert.Equal(t, istioVersion, base.Version)
+	assert.Equal(t, "base", base.Chart)
+
+	integration.Spec.AutoInstall.HelmConfig = &ksitv1alpha1.HelmInstallConfig{
+		Version: "1.21.0",
+		Values:  map[string]string{"pilot.autoscaleEnabled": "false"},
+	}
+	gateway := componentConfig(integration, components[2])
+	assert.Equal(t, "1.21.0", gateway.Version)
+	assert.Equal(t, "istio-ingressgateway", gateway.ReleaseName)
+	assert.Empty(t, gateway.Values, "helmConfig.values only configure istiod")
+
+	istiod := componentConfig(integration, components[1])
+	assert.Equal(t, "1.21.0", istiod.Version)
+	assert.Equal(t, "false", istiod.Values["pilot.autoscaleEnabled"])
+}
@@ -0,0 +1,18 @@
+package installer
+
+import (
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// NewKyvernoInstaller creates a new Kyverno installer with default configuration
+func NewKyvernoInstaller() *HelmInstaller {
+	return &HelmInstaller{
+		integrationType: ksitv1alpha1.IntegrationTypeKyverno,
+		defaultConfig: &ksitv1alpha1.HelmInstallConfig{
+			Repository:  "https://kyverno.github.io/kyverno/",
+			Chart:       "kyverno",
+			Version:     "3.1.4",
+			ReleaseName: "kyverno",
+		},
+	}
+}
@@ -0,0 +1,285 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+// ManifestInstaller installs any integration type from the manifest at
+// spec.autoInstall.manifestUrl. CRDs are applied first, then namespaces, then everything
+// else, all with server-side apply. Namespaced objects without a namespace go to the
+// integration's namespace. The install is ready when its workloads are.
+type ManifestInstaller struct {
+	manifests *ManifestCache
+}
+
+// NewManifestInstaller creates a manifest installer that shares downloaded manifests with
+// the other manifest-based installers
+func NewManifestInstaller() *ManifestInstaller {
+	return &ManifestInstaller{manifests: sharedManifests}
+}
+
+// Install applies the manifest and waits for its Deployments, StatefulSets and DaemonSets to be ready
+func (m *ManifestInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	install := integration.Spec.AutoInstall
+	if install == nil || !install.Enabled {
+		return nil
+	}
+
+	objs, err := m.objects(ctx, integration)
+	if err != nil {
+		return err
+	}
+	dynClient, mapper, err := manifests.NewClients(config)
+	if err != nil {
+		return err
+	}
+
+	namespace := InstallNamespace(integration)
+	// Set up the namespace before any pod of the manifest exists
+	if err := prepareNamespace(ctx, config, integration, namespace); err != nil {
+		return err
+	}
+
+	workloads, err := applyManifest(ctx, dynClient, mapper, objs, namespace, install.Overrides)
+	if err != nil {
+		return err
+	}
+	reportProgress(ctx, fmt.Sprintf("applied %d objects", len(objs)))
+
+	return waitForWorkloads(ctx, dynClient, mapper, workloads, readinessTimeout(integration))
+}
+
+// Uninstall deletes the objects of the manifest in reverse order, then the
+// NetworkPolicies of hardening. CRDs are kept, like Helm keeps them, so that custom
+// resources of other owners survive.
+func (m *ManifestInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	objs, err := m.objects(ctx, integration)
+	if err != nil {
+		return err
+	}
+	dynClient, mapper, err := manifests.NewClients(config)
+	if err != nil {
+		return err
+	}
+	namespace := InstallNamespace(integration)
+	if err := deleteManifest(ctx, dynClient, mapper, objs, namespace); err != nil {
+		return err
+	}
+	return removeHardening(ctx, config, integration, namespace)
+}
+
+// IsInstalled reports whether every workload of the manifest exists, or every object if
+// the manifest has no workloads
+func (m *ManifestInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	objs, err := m.objects(ctx, integration)
+	if err != nil {
+		return false, err
+	}
+	dynClient, mapper, err := manifests.NewClients(config)
+	if err != nil {
+		return false, err
+	}
+
+	check := workloadsOf(objs)
+	if len(check) == 0 {
+		check = objs
+	}
+	missing, err := missingObject(ctx, dynClient, mapper, check, InstallNamespace(integration))
+	return missing == "" && err == nil, err
+}
+
+// missingObject names the first of objs that does not exist on the cluster, or returns ""
+// when they all exist. Objects without a namespace are looked up in namespace.
+func missingObject(ctx context.Context, dynClient dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured, namespace string) (string, error) {
+	for _, obj := range objs {
+		resource, err := manifests.ResourceFor(dynClient, mapper, obj, namespace)
+		if meta.IsNoMatchError(err) {
+			return fmt.Sprintf("%s is no longer served", obj.GetKind()), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Sprintf("%s %s/%s is missing", obj.GetKind(), obj.GetNamespace(), obj.GetName()), nil
+			}
+			return "", fmt.Errorf("failed to get %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return "", nil
+}
+
+// objects downloads and decodes the manifest of the integration
+func (m *ManifestInstaller) objects(ctx context.Context, integration *ksitv1alpha1.Integration) ([]*unstructured.Unstructured, error) {
+	install := integration.Spec.AutoInstall
+	if install == nil || install.ManifestURL == "" {
+		return nil, fmt.Errorf("autoInstall.manifestUrl is required to install %s from a manifest", integration.Spec.Type)
+	}
+	data, err := m.manifests.Get(ctx, install.ManifestURL, install.ManifestDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	objs, err := manifests.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("manifest %s has no objects", install.ManifestURL)
+	}
+	return objs, nil
+}
+
+// applyManifest server-side applies the CRDs of objs and waits for them to be
+// established, then applies namespaces and the remaining objects in manifest order.
+// It returns the workloads it applied.
+func applyManifest(ctx context.Context, dynClient dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured, namespace string, overrides *ksitv1alpha1.ComponentOverrides) ([]*unstructured.Unstructured, error) {
+	var crds []string
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		if err := manifests.Apply(ctx, dynClient, mapper, obj, namespace, fieldManager); err != nil {
+			return nil, err
+		}
+		crds = append(crds, obj.GetName())
+	}
+	if len(crds) > 0 {
+		if err := waitForCRDs(ctx, dynClient, crds, crdEstablishTimeout); err != nil {
+			return nil, err
+		}
+		// Discover the kinds the CRDs just added
+		if resettable, ok := mapper.(meta.ResettableRESTMapper); ok {
+			resettable.Reset()
+		}
+	}
+
+	others := make([]*unstructured.Unstructured, 0, len(objs)-len(crds))
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			others = append(others, obj)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].GetKind() == "Namespace" && others[j].GetKind() != "Namespace"
+	})
+
+	var workloads []*unstructured.Unstructured
+	for _, obj := range others {
+		if overrides != nil {
+			obj = obj.DeepCopy()
+			if err := applyWorkloadOverrides(obj, overrides); err != nil {
+				return nil, err
+			}
+		}
+		if err := manifests.Apply(ctx, dynClient, mapper, obj, namespace, fieldManager); err != nil {
+			return nil, err
+		}
+		if isWorkload(obj) {
+			workloads = append(workloads, obj)
+		}
+	}
+	return workloads, nil
+}
+
+// deleteManifest deletes the objects of a manifest except CRDs, last applied first.
+// Objects that are already gone, or whose kind is no longer served, are skipped.
+func deleteManifest(ctx context.Context, dynClient dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured, namespace string) error {
+	var errs []error
+	for i := len(objs) - 1; i >= 0; i-- {
+		if objs[i].GetKind() == "CustomResourceDefinition" {
+			continue
+		}
+		if err := manifests.Delete(ctx, dynClient, mapper, objs[i], namespace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isWorkload(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+func workloadsOf(objs []*unstructured.Unstructured) []*unstructured.Unstructured {
+	var workloads []*unstructured.Unstructured
+	for _, obj := range objs {
+		if isWorkload(obj) {
+			workloads = append(workloads, obj)
+		}
+	}
+	return workloads
+}
+
+// waitForWorkloads waits until every workload is ready, reporting progress each time the
+// number of ready workloads changes
+func waitForWorkloads(ctx context.Context, dynClient dynamic.Interface, mapper meta.RESTMapper, workloads []*unstructured.Unstructured, timeout time.Duration) error {
+	if len(workloads) == 0 {
+		return nil
+	}
+	lastReady := -1
+	var notReady string
+	err := wait.PollUntilContextTimeout(ctx, readinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		ready := 0
+		notReady = ""
+		for _, workload := range workloads {
+			resource, err := manifests.ResourceFor(dynClient, mapper, workload, workload.GetNamespace())
+			if err != nil {
+				return false, err
+			}
+			current, err := resource.Get(ctx, workload.GetName(), metav1.GetOptions{})
+			if err == nil && workloadReady(current) {
+				ready++
+			} else if notReady == "" {
+				notReady = workload.GetKind() + " " + workload.GetNamespace() + "/" + workload.GetName()
+			}
+		}
+		if ready != lastReady {
+			lastReady = ready
+			reportProgress(ctx, fmt.Sprintf("%d/%d workloads ready", ready, len(workloads)))
+		}
+		return ready == len(workloads), nil
+	})
+	if err != nil && notReady != "" {
+		return fmt.Errorf("timeout waiting for %s to be ready: %w", notReady, err)
+	}
+	return err
+}
+
+// workloadReady reports whether a Deployment, StatefulSet or DaemonSet has observed its
+// latest spec and all of its pods are ready
+func workloadReady(obj *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observed < obj.GetGeneration() {
+		return false
+	}
+	if obj.GetKind() == "DaemonSet" {
+		desired, found, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		return found && ready >= desired
+	}
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	return ready >= replicas
+}
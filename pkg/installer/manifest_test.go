@@ -0,0 +1,241 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+const vaultManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: vault
+spec:
+  template:
+    spec:
+      containers:
+      - name: vault
+        image: hashicorp/vault:1.15
+---
+# the namespace is applied before the objects in it
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: vault
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: vault
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: secrets.vault.example.com
+spec:
+  group: vault.example.com
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: vault
+  namespace: other
+`
+
+func newManifestMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+func TestApplyManifest(t *testing.T) {
+	objs, err := manifests.Decode([]byte(vaultManifest))
+	require.NoError(t, err)
+	require.Len(t, objs, 5)
+
+	established := &unstructured.Unstructured{}
+	established.SetAPIVersion("apiextensions.k8s.io/v1")
+	established.SetKind("CustomResourceDefinition")
+	established.SetName("secrets.vault.example.com")
+	require.NoError(t, unstructured.SetNestedSlice(established.Object, []interface{}{
+		map[string]interface{}{"type": "Established", "status": "True"},
+	}, "status", "conditions"))
+	dynClient := newCRDClient(established)
+
+	var applied []string
+	dynClient.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		applied = append(applied, patch.GetResource().Resource+" "+patch.GetNamespace()+"/"+patch.GetName())
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	overrides := &ksitv1alpha1.ComponentOverrides{PriorityClassName: "system-cluster-critical"}
+	workloads, err := applyManifest(context.Background(), dynClient, newManifestMapper(), objs, "vault", overrides)
+	require.NoError(t, err)
+
+	// CRDs first, then namespaces; namespaced objects without a namespace get the integration's
+	assert.Equal(t, []string{
+		"customresourcedefinitions /secrets.vault.example.com",
+		"namespaces /vault",
+		"deployments vault/vault",
+		"clusterroles /vault",
+		"services other/vault",
+	}, applied)
+
+	require.Len(t, workloads, 1)
+	assert.Equal(t, "vault", workloads[0].GetNamespace())
+	priorityClass, _, _ := unstructured.NestedString(workloads[0].Object, "spec", "template", "spec", "priorityClassName")
+	assert.Equal(t, "system-cluster-critical", priorityClass)
+}
+
+func TestApplyManifestUnknownKind(t *testing.T) {
+	objs, err := manifests.Decode([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"))
+	require.NoError(t, err)
+
+	_, err = applyManifest(context.Background(), newCRDClient(), newManifestMapper(), objs, "default", nil)
+	assert.ErrorContains(t, err, "failed to find resource of Widget w")
+}
+
+func TestApplyManifestRejectsObjectsWithoutKind(t *testing.T) {
+	objs, err := manifests.Decode([]byte("metadata:\n  name: orphan\n"))
+	require.NoError(t, err)
+
+	_, err = applyManifest(context.Background(), newCRDClient(), newManifestMapper(), objs, "default", nil)
+	assert.EqualError(t, err, `manifest object "orphan" has no apiVersion or kind`)
+}
+
+func TestDeleteManifest(t *testing.T) {
+	objs, err := manifests.Decode([]byte(vaultManifest))
+	require.NoError(t, err)
+
+	dynClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	var deleted []string
+	dynClient.PrependReactor("delete", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.GetResource().Resource+" "+action.GetNamespace()+"/"+action.(clienttesting.DeleteAction).GetName())
+		return true, nil, nil
+	})
+
+	require.NoError(t, deleteManifest(context.Background(), dynClient, newManifestMapper(), objs, "vault"))
+	// Reverse order, and CRDs are kept
+	assert.Equal(t, []string{
+		"services other/vault",
+		"clusterroles /vault",
+		"namespaces /vault",
+		"deployments vault/vault",
+	}, deleted)
+}
+
+func TestMissingObject(t *testing.T) {
+	ctx := context.Background()
+	objs, err := manifests.Decode([]byte(vaultManifest))
+	require.NoError(t, err)
+	workloads := workloadsOf(objs)
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("vault")
+	deployment.SetName("vault")
+	dynClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), deployment)
+
+	missing, err := missingObject(ctx, dynClient, newManifestMapper(), workloads, "vault")
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	missing, err = missingObject(ctx, dynClient, newManifestMapper(), objs, "vault")
+	require.NoError(t, err)
+	assert.Equal(t, "Namespace /vault is missing", missing)
+
+	widgets, err := manifests.Decode([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n"))
+	require.NoError(t, err)
+	missing, err = missingObject(ctx, dynClient, newManifestMapper(), widgets, "vault")
+	require.NoError(t, err)
+	assert.Equal(t, "Widget is no longer served", missing)
+}
+
+func TestWorkloadReady(t *testing.T) {
+	workload := func(kind string, generation int64, fields map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: fields}
+		obj.SetKind(kind)
+		obj.SetGeneration(generation)
+		return obj
+	}
+
+	tests := []struct {
+		name     string
+		workload *unstructured.Unstructured
+		ready    bool
+	}{
+		{"deployment without status", workload("Deployment", 1, map[string]interface{}{}), false},
+		{"deployment with one replica ready", workload("Deployment", 1, map[string]interface{}{
+			"status": map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1)},
+		}), true},
+		{"statefulset waiting for replicas", workload("StatefulSet", 1, map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": int64(3)},
+			"status": map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(2)},
+		}), false},
+		{"stale generation", workload("Deployment", 2, map[string]interface{}{
+			"status": map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1)},
+		}), false},
+		{"daemonset ready on every node", workload("DaemonSet", 1, map[string]interface{}{
+			"status": map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "numberReady": int64(3)},
+		}), true},
+		{"daemonset not scheduled yet", workload("DaemonSet", 1, map[string]interface{}{
+			"status": map[string]interface{}{"observedGeneration": int64(1)},
+		}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.ready, workloadReady(tt.workload))
+		})
+	}
+}
+
+func TestInstallerFor(t *testing.T) {
+	factory := NewInstallerFactory()
+	integration := func(integrationType, method string) *ksitv1alpha1.Integration {
+		return &ksitv1alpha1.Integration{Spec: ksitv1alpha1.IntegrationSpec{
+			Type:        integrationType,
+			AutoInstall: &ksitv1alpha1.InstallConfig{Enabled: true, Method: method},
+		}}
+	}
+
+	inst, err := factory.InstallerFor(integration(ksitv1alpha1.IntegrationTypeArgoCD, "manifest"))
+	require.NoError(t, err)
+	assert.IsType(t, &ManifestInstaller{}, inst)
+
+	// Types KSIT has no installer for can still be installed from a manifest
+	inst, err = factory.InstallerFor(integration("vault", "manifest"))
+	require.NoError(t, err)
+	assert.IsType(t, &ManifestInstaller{}, inst)
+
+	inst, err = factory.InstallerFor(integration(ksitv1alpha1.IntegrationTypePrometheus, "operator"))
+	require.NoError(t, err)
+	assert.IsType(t, &OperatorInstaller{}, inst)
+
+	inst, err = factory.InstallerFor(integration(ksitv1alpha1.IntegrationTypeFlux, "manifest"))
+	require.NoError(t, err)
+	assert.IsType(t, &FluxInstaller{}, inst)
+
+	inst, err = factory.InstallerFor(integration(ksitv1alpha1.IntegrationTypeArgoCD, "helm"))
+	require.NoError(t, err)
+	assert.IsType(t, &HelmInstaller{}, inst)
+
+	_, err = factory.InstallerFor(integration("vault", ""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxManifestSize bounds a downloaded manifest; Flux's install.yaml is well below 1MiB
+	defaultMaxManifestSize = 16 << 20
+
+	// defaultManifestRevalidateAfter is how long a downloaded manifest is used without
+	// asking the server whether it changed
+	defaultManifestRevalidateAfter = 5 * time.Minute
+)
+
+// sharedManifests is the cache used by every manifest-based installer, so installing on
+// a whole fleet downloads each manifest once
+var sharedManifests = NewManifestCache(&http.Client{Timeout: time.Minute}, defaultMaxManifestSize, defaultManifestRevalidateAfter)
+
+// ManifestCache downloads install manifests and shares them between installs. A cached
+// manifest is revalidated with If-None-Match and If-Modified-Since once it is older
+// than the revalidation interval; concurrent requests for one URL share a download.
+// Manifests pinned by digest are immutable and never downloaded again.
+type ManifestCache struct {
+	client          *http.Client
+	maxSize         int64
+	revalidateAfter time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*manifestEntry
+	byDigest map[string][]byte
+}
+
+type manifestEntry struct {
+	mu           sync.Mutex
+	data         []byte
+	etag         string
+	lastModified string
+	checked      time.Time
+}
+
+// NewManifestCache creates a cache that refuses manifests larger than maxSize bytes
+func NewManifestCache(client *http.Client, maxSize int64, revalidateAfter time.Duration) *ManifestCache {
+	return &ManifestCache{
+		client:          client,
+		maxSize:         maxSize,
+		revalidateAfter: revalidateAfter,
+		entries:         make(map[string]*manifestEntry),
+		byDigest:        make(map[string][]byte),
+	}
+}
+
+// Get returns the manifest at url. digest, if set, pins the expected content as
+// "sha256:<hex>"; content that does not match is rejected. When the server cannot
+// be reached, a previously downloaded copy is returned.
+func (c *ManifestCache) Get(ctx context.Context, url, digest string) ([]byte, error) {
+	digest = strings.ToLower(digest)
+
+	c.mu.Lock()
+	if data, ok := c.byDigest[digest]; ok && digest != "" {
+		c.mu.Unlock()
+		return data, nil
+	}
+	entry, ok := c.entries[url]
+	if !ok {
+		entry = &manifestEntry{}
+		c.entries[url] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.data == nil || time.Since(entry.checked) >= c.revalidateAfter {
+		if err := c.fetch(ctx, url, entry); err != nil {
+			if entry.data == nil {
+				return nil, err
+			}
+			// Serve the stale copy, and retry on the next install attempt
+		}
+	}
+
+	if digest != "" {
+		if actual := manifestDigest(entry.data); actual != digest {
+			return nil, fmt.Errorf("manifest %s has digest %s, expected %s", url, actual, digest)
+		}
+		c.mu.Lock()
+		c.byDigest[digest] = entry.data
+		c.mu.Unlock()
+	}
+	return entry.data, nil
+}
+
+// fetch downloads the manifest into entry, or only marks it checked if it is unchanged
+func (c *ManifestCache) fetch(ctx context.Context, url string, entry *manifestEntry) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	if entry.data != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download manifest %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry.data != nil:
+		entry.checked = time.Now()
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to download manifest %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	if resp.ContentLength > c.maxSize {
+		return fmt.Errorf("manifest %s is %d bytes, more than the limit of %d", url, resp.ContentLength, c.maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest %s: %w", url, err)
+	}
+	if int64(len(data)) > c.maxSize {
+		return fmt.Errorf("manifest %s is more than the limit of %d bytes", url, c.maxSize)
+	}
+
+	entry.data = data
+	entry.etag = resp.Header.Get("ETag")
+	entry.lastModified = resp.Header.Get("Last-Modified")
+	entry.checked = time.Now()
+	return nil
+}
+
+func manifestDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
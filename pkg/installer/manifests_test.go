@@ -0,0 +1,82 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestCacheRevalidates(t *testing.T) {
+	var downloads, notModified atomic.Int32
+	content := "kind: Namespace\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	cache := NewManifestCache(server.Client(), 1024, time.Hour)
+	for i := 0; i < 3; i++ {
+		data, err := cache.Get(context.Background(), server.URL, "")
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+	assert.Equal(t, int32(1), downloads.Load(), "fresh copies are not revalidated")
+	assert.Equal(t, int32(0), notModified.Load())
+
+	cache.revalidateAfter = 0
+	data, err := cache.Get(context.Background(), server.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, int32(1), downloads.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+
+	// A server that goes away leaves the stale copy usable
+	server.Close()
+	data, err = cache.Get(context.Background(), server.URL, "")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestManifestCacheDigestAndSize(t *testing.T) {
+	var downloads atomic.Int32
+	content := "kind: Deployment\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		if r.URL.Path == "/large.yaml" {
+			_, _ = w.Write([]byte(strings.Repeat("#", 2048)))
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	cache := NewManifestCache(server.Client(), 1024, 0)
+	digest := manifestDigest([]byte(content))
+
+	_, err := cache.Get(context.Background(), server.URL+"/install.yaml", "sha256:"+strings.Repeat("0", 64))
+	assert.ErrorContains(t, err, "expected sha256:000")
+
+	for i := 0; i < 2; i++ {
+		data, err := cache.Get(context.Background(), server.URL+"/install.yaml", strings.ToUpper(digest[:7])+digest[7:])
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+	assert.Equal(t, int32(2), downloads.Load(), "pinned manifests are reused without revalidation")
+
+	_, err = cache.Get(context.Background(), server.URL+"/large.yaml", "")
+	assert.ErrorContains(t, err, "limit of 1024")
+}
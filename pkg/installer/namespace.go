@@ -0,0 +1,93 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// InstallNamespace returns the namespace the tool is installed into: autoInstall.namespace.name,
+// then config["namespace"], then the default namespace of the integration type
+func InstallNamespace(integration *ksitv1alpha1.Integration) string {
+	if install := integration.Spec.AutoInstall; install != nil && install.Namespace != nil && install.Namespace.Name != "" {
+		return install.Namespace.Name
+	}
+	if namespace := integration.Spec.Config["namespace"]; namespace != "" {
+		return namespace
+	}
+	return defaultNamespace(integration.Spec.Type)
+}
+
+// prepareNamespace is the first step of every install. It creates namespace with the
+// labels and annotations of autoInstall.namespace and hardening, or adds them to the
+// existing namespace, then restricts its traffic, so the tool's pods start in a
+// namespace that is already set up.
+func prepareNamespace(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration, namespace string) error {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	nsLabels, annotations := namespaceMetadata(integration.Spec.AutoInstall)
+	if err := ensureNamespace(ctx, clientset, namespace, nsLabels, annotations); err != nil {
+		return err
+	}
+	return applyHardening(ctx, clientset, integration, namespace)
+}
+
+// namespaceMetadata returns the labels and annotations of the install namespace. The Pod
+// Security labels of hardening win over the same labels of autoInstall.namespace.
+func namespaceMetadata(install *ksitv1alpha1.InstallConfig) (map[string]string, map[string]string) {
+	nsLabels := map[string]string{}
+	var annotations map[string]string
+	if install.Namespace != nil {
+		for key, value := range install.Namespace.Labels {
+			nsLabels[key] = value
+		}
+		annotations = install.Namespace.Annotations
+	}
+	if install.Hardening != nil {
+		for key, value := range podSecurityLabels(install.Hardening.PodSecurity) {
+			nsLabels[key] = value
+		}
+	}
+	return nsLabels, annotations
+}
+
+// ensureNamespace creates namespace with nsLabels and annotations, or adds them to the
+// existing namespace. Labels and annotations set by others are kept.
+func ensureNamespace(ctx context.Context, clientset kubernetes.Interface, namespace string, nsLabels, annotations map[string]string) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: nsLabels, Annotations: annotations}}
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	if err == nil || (len(nsLabels) == 0 && len(annotations) == 0) {
+		return nil
+	}
+
+	metadata := map[string]interface{}{}
+	if len(nsLabels) > 0 {
+		metadata["labels"] = nsLabels
+	}
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return fmt.Errorf("failed to encode namespace metadata: %w", err)
+	}
+	if _, err := clientset.CoreV1().Namespaces().Patch(ctx, namespace, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to label namespace %s: %w", namespace, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestInstallNamespace(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{Spec: ksitv1alpha1.IntegrationSpec{
+		Type:        ksitv1alpha1.IntegrationTypePrometheus,
+		AutoInstall: &ksitv1alpha1.InstallConfig{Enabled: true},
+	}}
+	assert.Equal(t, "monitoring", InstallNamespace(integration))
+
+	integration.Spec.Config = map[string]string{"namespace": "observability"}
+	assert.Equal(t, "observability", InstallNamespace(integration))
+
+	// The install namespace can differ from the one health checks look in
+	integration.Spec.AutoInstall.Namespace = &ksitv1alpha1.InstallNamespaceConfig{Name: "prometheus-operator"}
+	assert.Equal(t, "prometheus-operator", InstallNamespace(integration))
+}
+
+func TestNamespaceMetadata(t *testing.T) {
+	install := &ksitv1alpha1.InstallConfig{
+		Namespace: &ksitv1alpha1.InstallNamespaceConfig{
+			Labels: map[string]string{
+				"istio-injection":                    "enabled",
+				"pod-security.kubernetes.io/enforce": "privileged",
+			},
+			Annotations: map[string]string{"owner": "platform"},
+		},
+		Hardening: &ksitv1alpha1.HardeningConfig{PodSecurity: ksitv1alpha1.PodSecurityBaseline},
+	}
+
+	nsLabels, annotations := namespaceMetadata(install)
+	// Hardening wins over the labels of autoInstall.namespace
+	assert.Equal(t, map[string]string{
+		"istio-injection":                    "enabled",
+		"pod-security.kubernetes.io/enforce": "baseline",
+		"pod-security.kubernetes.io/warn":    "baseline",
+		"pod-security.kubernetes.io/audit":   "baseline",
+	}, nsLabels)
+	assert.Equal(t, map[string]string{"owner": "platform"}, annotations)
+	assert.Equal(t, "privileged", install.Namespace.Labels["pod-security.kubernetes.io/enforce"], "spec is not modified")
+}
+
+func TestEnsureNamespace(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Labels: map[string]string{"team": "platform"}},
+	})
+
+	require.NoError(t, ensureNamespace(ctx, clientset, "argocd", podSecurityLabels(ksitv1alpha1.PodSecurityRestricted), map[string]string{"owner": "gitops"}))
+	require.NoError(t, ensureNamespace(ctx, clientset, "flux-system", podSecurityLabels(ksitv1alpha1.PodSecurityBaseline), nil))
+	require.NoError(t, ensureNamespace(ctx, clientset, "monitoring", nil, nil))
+
+	// Existing labels are kept
+	ns, err := clientset.CoreV1().Namespaces().Get(ctx, "argocd", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"team":                               "platform",
+		"pod-security.kubernetes.io/enforce": "restricted",
+		"pod-security.kubernetes.io/warn":    "restricted",
+		"pod-security.kubernetes.io/audit":   "restricted",
+	}, ns.Labels)
+	assert.Equal(t, map[string]string{"owner": "gitops"}, ns.Annotations)
+
+	ns, err = clientset.CoreV1().Namespaces().Get(ctx, "flux-system", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "baseline", ns.Labels["pod-security.kubernetes.io/enforce"])
+
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "monitoring", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,339 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const (
+	defaultCatalogSource          = "operatorhubio-catalog"
+	defaultCatalogSourceNamespace = "olm"
+
+	// operatorGroupName is the OperatorGroup KSIT creates in namespaces that have none
+	operatorGroupName = "ksit"
+	// managedByLabel marks objects KSIT created, so uninstall only deletes those
+	managedByLabel = "app.kubernetes.io/managed-by"
+)
+
+var (
+	subscriptionGVR  = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	csvGVR           = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+	operatorGroupGVR = schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1", Resource: "operatorgroups"}
+)
+
+// defaultOperators are the OLM packages, and their tested channels, of the types that
+// can be installed without autoInstall.operatorConfig.package
+var defaultOperators = map[string]ksitv1alpha1.OperatorInstallConfig{
+	ksitv1alpha1.IntegrationTypeArgoCD:     {Package: "argocd-operator", Channel: "alpha"},
+	ksitv1alpha1.IntegrationTypePrometheus: {Package: "prometheus", Channel: "beta"},
+	ksitv1alpha1.IntegrationTypeGrafana:    {Package: "grafana-operator", Channel: "v5"},
+}
+
+// HasDefaultOperator reports whether the operator method works for integrationType
+// without naming a package
+func HasDefaultOperator(integrationType string) bool {
+	_, ok := defaultOperators[integrationType]
+	return ok
+}
+
+// OperatorInstaller installs an integration's operator through OLM, which must already
+// run on the target clusters. It subscribes to the operator's package in the integration's
+// namespace, creating an OperatorGroup there if the namespace has none, and waits for
+// the subscribed ClusterServiceVersion to succeed.
+type OperatorInstaller struct{}
+
+// NewOperatorInstaller creates a new OLM-based installer
+func NewOperatorInstaller() *OperatorInstaller {
+	return &OperatorInstaller{}
+}
+
+// Install subscribes to the operator and waits for its ClusterServiceVersion to succeed
+func (o *OperatorInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	install := integration.Spec.AutoInstall
+	if install == nil || !install.Enabled {
+		return nil
+	}
+	operator, err := operatorConfig(integration)
+	if err != nil {
+		return err
+	}
+
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	namespace := InstallNamespace(integration)
+	// Set up the namespace before the operator's pod exists
+	if err := prepareNamespace(ctx, config, integration, namespace); err != nil {
+		return err
+	}
+
+	if err := subscribe(ctx, dynClient, namespace, operator); err != nil {
+		return err
+	}
+	reportProgress(ctx, fmt.Sprintf("subscribed to %s from %s", operator.Package, operator.CatalogSource))
+
+	return waitForOperator(ctx, dynClient, namespace, operator, readinessTimeout(integration))
+}
+
+// Uninstall deletes the Subscription and its ClusterServiceVersion, which removes the
+// operator, then the NetworkPolicies of hardening. The operator's CRDs are kept, and so
+// is the OperatorGroup unless KSIT created it.
+func (o *OperatorInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	operator, err := operatorConfig(integration)
+	if err != nil {
+		return err
+	}
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	namespace := InstallNamespace(integration)
+	if err := unsubscribe(ctx, dynClient, namespace, operator.Package); err != nil {
+		return err
+	}
+	return removeHardening(ctx, config, integration, namespace)
+}
+
+// IsInstalled reports whether the subscribed ClusterServiceVersion has succeeded. It is
+// false on clusters without OLM.
+func (o *OperatorInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	operator, err := operatorConfig(integration)
+	if err != nil {
+		return false, err
+	}
+	dynClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	phase, err := csvPhase(ctx, dynClient, InstallNamespace(integration), operator.Package)
+	if err != nil {
+		return false, err
+	}
+	return phase == "Succeeded", nil
+}
+
+// operatorConfig returns autoInstall.operatorConfig with defaults filled in
+func operatorConfig(integration *ksitv1alpha1.Integration) (ksitv1alpha1.OperatorInstallConfig, error) {
+	var operator ksitv1alpha1.OperatorInstallConfig
+	if install := integration.Spec.AutoInstall; install != nil && install.OperatorConfig != nil {
+		operator = *install.OperatorConfig
+	}
+	if operator.Package == "" {
+		defaults, ok := defaultOperators[integration.Spec.Type]
+		if !ok {
+			return operator, fmt.Errorf("autoInstall.operatorConfig.package is required to install %s with an operator", integration.Spec.Type)
+		}
+		operator.Package = defaults.Package
+		if operator.Channel == "" {
+			operator.Channel = defaults.Channel
+		}
+	}
+	if operator.CatalogSource == "" {
+		operator.CatalogSource = defaultCatalogSource
+	}
+	if operator.CatalogSourceNamespace == "" {
+		operator.CatalogSourceNamespace = defaultCatalogSourceNamespace
+	}
+	if operator.InstallPlanApproval == "" {
+		operator.InstallPlanApproval = "Automatic"
+	}
+	return operator, nil
+}
+
+// subscribe makes sure namespace has an OperatorGroup, then server-side applies the
+// Subscription to the operator, named after its package
+func subscribe(ctx context.Context, dynClient dynamic.Interface, namespace string, operator ksitv1alpha1.OperatorInstallConfig) error {
+	groups, err := dynClient.Resource(operatorGroupGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			return fmt.Errorf("OLM is not installed on the cluster: %w", err)
+		}
+		return fmt.Errorf("failed to list OperatorGroups in %s: %w", namespace, err)
+	}
+	// OLM allows a single OperatorGroup per namespace, so an existing one is used as is
+	if len(groups.Items) == 0 {
+		group := &unstructured.Unstructured{}
+		group.SetAPIVersion(operatorGroupGVR.GroupVersion().String())
+		group.SetKind("OperatorGroup")
+		group.SetName(operatorGroupName)
+		group.SetNamespace(namespace)
+		group.SetLabels(map[string]string{managedByLabel: "ksit"})
+		if !operator.AllNamespaces {
+			if err := unstructured.SetNestedStringSlice(group.Object, []string{namespace}, "spec", "targetNamespaces"); err != nil {
+				return fmt.Errorf("failed to build OperatorGroup: %w", err)
+			}
+		}
+		_, err := dynClient.Resource(operatorGroupGVR).Namespace(namespace).Create(ctx, group, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create OperatorGroup in %s: %w", namespace, err)
+		}
+	}
+
+	spec := map[string]interface{}{
+		"name":                operator.Package,
+		"source":              operator.CatalogSource,
+		"sourceNamespace":     operator.CatalogSourceNamespace,
+		"installPlanApproval": operator.InstallPlanApproval,
+	}
+	if operator.Channel != "" {
+		spec["channel"] = operator.Channel
+	}
+	if operator.StartingCSV != "" {
+		spec["startingCSV"] = operator.StartingCSV
+	}
+	subscription := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": subscriptionGVR.GroupVersion().String(),
+		"kind":       "Subscription",
+		"metadata": map[string]interface{}{
+			"name":      operator.Package,
+			"namespace": namespace,
+			"labels":    map[string]interface{}{managedByLabel: "ksit"},
+		},
+		"spec": spec,
+	}}
+	data, err := json.Marshal(subscription.Object)
+	if err != nil {
+		return fmt.Errorf("failed to encode Subscription %s: %w", operator.Package, err)
+	}
+	force := true
+	_, err = dynClient.Resource(subscriptionGVR).Namespace(namespace).Patch(ctx, operator.Package, types.ApplyPatchType, data,
+		metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+	if err != nil {
+		return fmt.Errorf("failed to apply Subscription %s: %w", operator.Package, err)
+	}
+	return nil
+}
+
+// csvPhase returns the phase of the ClusterServiceVersion installed by the Subscription
+// to pkg, or "" if the Subscription, its CSV or OLM itself is missing
+func csvPhase(ctx context.Context, dynClient dynamic.Interface, namespace, pkg string) (string, error) {
+	subscription, err := dynClient.Resource(subscriptionGVR).Namespace(namespace).Get(ctx, pkg, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get Subscription %s: %w", pkg, err)
+	}
+	csvName, _, _ := unstructured.NestedString(subscription.Object, "status", "installedCSV")
+	if csvName == "" {
+		return "", nil
+	}
+
+	csv, err := dynClient.Resource(csvGVR).Namespace(namespace).Get(ctx, csvName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get ClusterServiceVersion %s: %w", csvName, err)
+	}
+	phase, _, _ := unstructured.NestedString(csv.Object, "status", "phase")
+	return phase, nil
+}
+
+// waitForOperator waits until the subscribed ClusterServiceVersion succeeds, reporting
+// each phase it goes through. A CSV that fails, or an InstallPlan waiting for manual
+// approval, ends the wait early.
+func waitForOperator(ctx context.Context, dynClient dynamic.Interface, namespace string, operator ksitv1alpha1.OperatorInstallConfig, timeout time.Duration) error {
+	lastPhase := "-"
+	var stopErr error
+	err := wait.PollUntilContextTimeout(ctx, readinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		phase, err := csvPhase(ctx, dynClient, namespace, operator.Package)
+		if err != nil {
+			return false, nil
+		}
+		if phase != lastPhase {
+			lastPhase = phase
+			if phase == "" {
+				reportProgress(ctx, fmt.Sprintf("waiting for %s to be installed", operator.Package))
+			} else {
+				reportProgress(ctx, fmt.Sprintf("%s is %s", operator.Package, phase))
+			}
+		}
+		switch phase {
+		case "Succeeded":
+			return true, nil
+		case "Failed":
+			stopErr = fmt.Errorf("ClusterServiceVersion of %s failed", operator.Package)
+			return false, stopErr
+		case "":
+			if operator.InstallPlanApproval == "Manual" && subscriptionState(ctx, dynClient, namespace, operator.Package) == "UpgradePending" {
+				stopErr = fmt.Errorf("InstallPlan of %s is waiting for manual approval", operator.Package)
+				return false, stopErr
+			}
+		}
+		return false, nil
+	})
+	if stopErr != nil {
+		return stopErr
+	}
+	if err != nil {
+		return fmt.Errorf("timeout waiting for operator %s: %w", operator.Package, err)
+	}
+	return nil
+}
+
+// subscriptionState returns status.state of the Subscription, e.g. AtLatestKnown or UpgradePending
+func subscriptionState(ctx context.Context, dynClient dynamic.Interface, namespace, pkg string) string {
+	subscription, err := dynClient.Resource(subscriptionGVR).Namespace(namespace).Get(ctx, pkg, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	state, _, _ := unstructured.NestedString(subscription.Object, "status", "state")
+	return state
+}
+
+// unsubscribe deletes the Subscription to pkg, the ClusterServiceVersion it installed,
+// and the OperatorGroup if KSIT created it and no other Subscription uses it
+func unsubscribe(ctx context.Context, dynClient dynamic.Interface, namespace, pkg string) error {
+	subscriptions := dynClient.Resource(subscriptionGVR).Namespace(namespace)
+	subscription, err := subscriptions.Get(ctx, pkg, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Subscription %s: %w", pkg, err)
+	}
+
+	var errs []error
+	if err := subscriptions.Delete(ctx, pkg, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete Subscription %s: %w", pkg, err))
+	}
+	// OLM leaves the CSV, and with it the operator, in place when the Subscription goes
+	if csvName, _, _ := unstructured.NestedString(subscription.Object, "status", "installedCSV"); csvName != "" {
+		err := dynClient.Resource(csvGVR).Namespace(namespace).Delete(ctx, csvName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete ClusterServiceVersion %s: %w", csvName, err))
+		}
+	}
+
+	remaining, err := subscriptions.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list Subscriptions in %s: %w", namespace, err))
+	} else if len(remaining.Items) == 0 {
+		groups := dynClient.Resource(operatorGroupGVR).Namespace(namespace)
+		group, err := groups.Get(ctx, operatorGroupName, metav1.GetOptions{})
+		if err == nil && group.GetLabels()[managedByLabel] == "ksit" {
+			if err := groups.Delete(ctx, operatorGroupName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete OperatorGroup %s: %w", operatorGroupName, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
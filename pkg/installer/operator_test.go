@@ -0,0 +1,212 @@
+package installer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func newOLMClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		subscriptionGVR:  "SubscriptionList",
+		csvGVR:           "ClusterServiceVersionList",
+		operatorGroupGVR: "OperatorGroupList",
+	}, objects...)
+}
+
+func olmObject(t *testing.T, manifest string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &obj.Object))
+	return obj
+}
+
+func TestOperatorConfig(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{Spec: ksitv1alpha1.IntegrationSpec{
+		Type:        ksitv1alpha1.IntegrationTypeArgoCD,
+		AutoInstall: &ksitv1alpha1.InstallConfig{Enabled: true, Method: "operator"},
+	}}
+	operator, err := operatorConfig(integration)
+	require.NoError(t, err)
+	assert.Equal(t, ksitv1alpha1.OperatorInstallConfig{
+		Package:                "argocd-operator",
+		Channel:                "alpha",
+		CatalogSource:          "operatorhubio-catalog",
+		CatalogSourceNamespace: "olm",
+		InstallPlanApproval:    "Automatic",
+	}, operator)
+
+	// A package of its own does not get the default channel
+	integration.Spec.AutoInstall.OperatorConfig = &ksitv1alpha1.OperatorInstallConfig{Package: "openshift-gitops-operator", CatalogSource: "redhat-operators"}
+	operator, err = operatorConfig(integration)
+	require.NoError(t, err)
+	assert.Equal(t, "openshift-gitops-operator", operator.Package)
+	assert.Empty(t, operator.Channel)
+	assert.Equal(t, "redhat-operators", operator.CatalogSource)
+
+	integration.Spec.Type = ksitv1alpha1.IntegrationTypeIstio
+	integration.Spec.AutoInstall.OperatorConfig = nil
+	_, err = operatorConfig(integration)
+	assert.EqualError(t, err, "autoInstall.operatorConfig.package is required to install istio with an operator")
+}
+
+func TestSubscribe(t *testing.T) {
+	dynClient := newOLMClient()
+	var applied map[string]interface{}
+	dynClient.PrependReactor("patch", "subscriptions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+		require.NoError(t, yaml.Unmarshal(patch.GetPatch(), &applied))
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	operator := ksitv1alpha1.OperatorInstallConfig{
+		Package:                "prometheus",
+		Channel:                "beta",
+		CatalogSource:          "operatorhubio-catalog",
+		CatalogSourceNamespace: "olm",
+		InstallPlanApproval:    "Automatic",
+		StartingCSV:            "prometheusoperator.0.70.0",
+	}
+	require.NoError(t, subscribe(context.Background(), dynClient, "monitoring", operator))
+
+	group, err := dynClient.Resource(operatorGroupGVR).Namespace("monitoring").Get(context.Background(), operatorGroupName, metav1.GetOptions{})
+	require.NoError(t, err)
+	targets, _, _ := unstructured.NestedStringSlice(group.Object, "spec", "targetNamespaces")
+	assert.Equal(t, []string{"monitoring"}, targets)
+
+	assert.Equal(t, map[string]interface{}{
+		"name":                "prometheus",
+		"channel":             "beta",
+		"source":              "operatorhubio-catalog",
+		"sourceNamespace":     "olm",
+		"installPlanApproval": "Automatic",
+		"startingCSV":         "prometheusoperator.0.70.0",
+	}, applied["spec"])
+}
+
+func TestSubscribeUsesExistingOperatorGroup(t *testing.T) {
+	existing := olmObject(t, `apiVersion: operators.coreos.com/v1
+kind: OperatorGroup
+metadata:
+  name: global
+  namespace: argocd
+`)
+	dynClient := newOLMClient(existing)
+	dynClient.PrependReactor("patch", "subscriptions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	require.NoError(t, subscribe(context.Background(), dynClient, "argocd", ksitv1alpha1.OperatorInstallConfig{Package: "argocd-operator"}))
+	groups, err := dynClient.Resource(operatorGroupGVR).Namespace("argocd").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, groups.Items, 1)
+	assert.Equal(t, "global", groups.Items[0].GetName())
+}
+
+func TestSubscribeWithoutOLM(t *testing.T) {
+	dynClient := newOLMClient()
+	dynClient.PrependReactor("list", "operatorgroups", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "operators.coreos.com", Resource: "operatorgroups"}, "")
+	})
+
+	err := subscribe(context.Background(), dynClient, "argocd", ksitv1alpha1.OperatorInstallConfig{Package: "argocd-operator"})
+	assert.ErrorContains(t, err, "OLM is not installed on the cluster")
+}
+
+const prometheusSubscription = `apiVersion: operators.coreos.com/v1alpha1
+kind: Subscription
+metadata:
+  name: prometheus
+  namespace: monitoring
+status:
+  installedCSV: prometheusoperator.0.70.0
+`
+
+func prometheusCSV(t *testing.T, phase string) *unstructured.Unstructured {
+	csv := olmObject(t, `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: prometheusoperator.0.70.0
+  namespace: monitoring
+`)
+	require.NoError(t, unstructured.SetNestedField(csv.Object, phase, "status", "phase"))
+	return csv
+}
+
+func TestCSVPhase(t *testing.T) {
+	ctx := context.Background()
+
+	phase, err := csvPhase(ctx, newOLMClient(), "monitoring", "prometheus")
+	require.NoError(t, err)
+	assert.Empty(t, phase, "no Subscription")
+
+	dynClient := newOLMClient(olmObject(t, prometheusSubscription))
+	phase, err = csvPhase(ctx, dynClient, "monitoring", "prometheus")
+	require.NoError(t, err)
+	assert.Empty(t, phase, "CSV not created yet")
+
+	dynClient = newOLMClient(olmObject(t, prometheusSubscription), prometheusCSV(t, "Installing"))
+	phase, err = csvPhase(ctx, dynClient, "monitoring", "prometheus")
+	require.NoError(t, err)
+	assert.Equal(t, "Installing", phase)
+}
+
+func TestWaitForOperator(t *testing.T) {
+	ctx := context.Background()
+	operator := ksitv1alpha1.OperatorInstallConfig{Package: "prometheus", InstallPlanApproval: "Automatic"}
+
+	dynClient := newOLMClient(olmObject(t, prometheusSubscription), prometheusCSV(t, "Succeeded"))
+	assert.NoError(t, waitForOperator(ctx, dynClient, "monitoring", operator, time.Second))
+
+	dynClient = newOLMClient(olmObject(t, prometheusSubscription), prometheusCSV(t, "Failed"))
+	assert.EqualError(t, waitForOperator(ctx, dynClient, "monitoring", operator, time.Second), "ClusterServiceVersion of prometheus failed")
+
+	pending := olmObject(t, `apiVersion: operators.coreos.com/v1alpha1
+kind: Subscription
+metadata:
+  name: prometheus
+  namespace: monitoring
+status:
+  state: UpgradePending
+`)
+	operator.InstallPlanApproval = "Manual"
+	dynClient = newOLMClient(pending)
+	assert.EqualError(t, waitForOperator(ctx, dynClient, "monitoring", operator, time.Second), "InstallPlan of prometheus is waiting for manual approval")
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ctx := context.Background()
+	group := olmObject(t, `apiVersion: operators.coreos.com/v1
+kind: OperatorGroup
+metadata:
+  name: ksit
+  namespace: monitoring
+  labels:
+    app.kubernetes.io/managed-by: ksit
+`)
+	dynClient := newOLMClient(olmObject(t, prometheusSubscription), prometheusCSV(t, "Succeeded"), group)
+
+	require.NoError(t, unsubscribe(ctx, dynClient, "monitoring", "prometheus"))
+	for _, gvr := range []schema.GroupVersionResource{subscriptionGVR, csvGVR, operatorGroupGVR} {
+		list, err := dynClient.Resource(gvr).Namespace("monitoring").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, list.Items, gvr.Resource)
+	}
+
+	// Nothing to do once the Subscription is gone
+	assert.NoError(t, unsubscribe(ctx, dynClient, "monitoring", "prometheus"))
+}
@@ -0,0 +1,137 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// ApplyClusterOverrides returns a copy of the Integration with the autoInstall.clusterOverrides
+// that match the cluster's labels merged into its Helm config, component overrides and skipCRDs.
+// The original is left untouched.
+func ApplyClusterOverrides(integration *ksitv1alpha1.Integration, clusterLabels map[string]string) (*ksitv1alpha1.Integration, error) {
+	install := integration.Spec.AutoInstall
+	if install == nil || len(install.ClusterOverrides) == 0 {
+		return integration, nil
+	}
+
+	out := integration.DeepCopy()
+	helmConfig := out.Spec.AutoInstall.HelmConfig
+	for i, override := range out.Spec.AutoInstall.ClusterOverrides {
+		selector, err := metav1.LabelSelectorAsSelector(&override.ClusterSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clusterSelector in clusterOverrides[%d]: %w", i, err)
+		}
+		if !selector.Matches(labels.Set(clusterLabels)) {
+			continue
+		}
+
+		if override.Overrides != nil {
+			out.Spec.AutoInstall.Overrides = mergeComponentOverrides(out.Spec.AutoInstall.Overrides, override.Overrides)
+		}
+		if override.SkipCRDs != nil {
+			out.Spec.AutoInstall.SkipCRDs = *override.SkipCRDs
+		}
+		if helmConfig == nil {
+			continue
+		}
+
+		if override.Version != "" {
+			helmConfig.Version = override.Version
+		}
+		if len(override.Values) > 0 && helmConfig.Values == nil {
+			helmConfig.Values = make(map[string]string, len(override.Values))
+		}
+		for key, value := range override.Values {
+			helmConfig.Values[key] = value
+		}
+	}
+
+	return out, nil
+}
+
+// ApplyClusterValues returns a copy of the Integration with defaults added to the
+// helmConfig values it does not set and overrides replacing its values. Integrations
+// without helmConfig are returned unchanged.
+func ApplyClusterValues(integration *ksitv1alpha1.Integration, defaults, overrides map[string]string) *ksitv1alpha1.Integration {
+	install := integration.Spec.AutoInstall
+	if install == nil || install.HelmConfig == nil || len(defaults)+len(overrides) == 0 {
+		return integration
+	}
+
+	out := integration.DeepCopy()
+	helmConfig := out.Spec.AutoInstall.HelmConfig
+	if helmConfig.Values == nil {
+		helmConfig.Values = make(map[string]string, len(defaults)+len(overrides))
+	}
+	for key, value := range defaults {
+		if _, set := helmConfig.Values[key]; !set {
+			helmConfig.Values[key] = value
+		}
+	}
+	for key, value := range overrides {
+		helmConfig.Values[key] = value
+	}
+	return out
+}
+
+// ApplyValuesFrom returns a copy of the Integration with the values files read for its
+// helmConfig.valuesFrom merged in order under its valuesObject, and valuesFrom cleared,
+// so that the installer does not read them again. Integrations without valuesFrom are
+// returned unchanged.
+func ApplyValuesFrom(integration *ksitv1alpha1.Integration, files [][]byte) (*ksitv1alpha1.Integration, error) {
+	install := integration.Spec.AutoInstall
+	if install == nil || install.HelmConfig == nil || len(install.HelmConfig.ValuesFrom) == 0 {
+		return integration, nil
+	}
+
+	values := map[string]interface{}{}
+	for i, file := range files {
+		var fileValues map[string]interface{}
+		if err := yaml.Unmarshal(file, &fileValues); err != nil {
+			return nil, fmt.Errorf("failed to decode values file of helmConfig.valuesFrom[%d]: %w", i, err)
+		}
+		mergeValues(values, fileValues)
+	}
+	objValues, err := objectValues(install.HelmConfig)
+	if err != nil {
+		return nil, err
+	}
+	mergeValues(values, objValues)
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode helm values: %w", err)
+	}
+	out := integration.DeepCopy()
+	out.Spec.AutoInstall.HelmConfig.ValuesObject = &apiextensionsv1.JSON{Raw: raw}
+	out.Spec.AutoInstall.HelmConfig.ValuesFrom = nil
+	return out, nil
+}
+
+// mergeComponentOverrides returns base with the fields set in override replaced
+func mergeComponentOverrides(base, override *ksitv1alpha1.ComponentOverrides) *ksitv1alpha1.ComponentOverrides {
+	out := &ksitv1alpha1.ComponentOverrides{}
+	if base != nil {
+		out = base.DeepCopy()
+	}
+	if override.Resources != nil {
+		out.Resources = override.Resources.DeepCopy()
+	}
+	if override.NodeSelector != nil {
+		out.NodeSelector = override.NodeSelector
+	}
+	if override.Tolerations != nil {
+		out.Tolerations = override.Tolerations
+	}
+	if override.PriorityClassName != "" {
+		out.PriorityClassName = override.PriorityClassName
+	}
+	return out
+}
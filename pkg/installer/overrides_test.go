@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestApplyClusterOverrides(t *testing.T) {
+	skipCRDs := true
+	integration := &ksitv1alpha1.Integration{
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Version: "55.5.0",
+					Values: map[string]string{
+						"storageClass":               "standard",
+						"prometheus.resources.limit": "2Gi",
+					},
+				},
+				ClusterOverrides: []ksitv1alpha1.ClusterOverride{
+					{
+						ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"provider": "aws"}},
+						Values:          map[string]string{"storageClass": "gp3"},
+						SkipCRDs:        &skipCRDs,
+					},
+					{
+						ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "edge"}},
+						Values:          map[string]string{"prometheus.resources.limit": "512Mi"},
+						Version:         "55.0.0",
+					},
+				},
+			},
+		},
+	}
+
+	out, err := ApplyClusterOverrides(integration, map[string]string{"provider": "aws", "tier": "edge"})
+	require.NoError(t, err)
+	assert.Equal(t, "55.0.0", out.Spec.AutoInstall.HelmConfig.Version)
+	assert.Equal(t, map[string]string{
+		"storageClass":               "gp3",
+		"prometheus.resources.limit": "512Mi",
+	}, out.Spec.AutoInstall.HelmConfig.Values)
+	assert.True(t, out.Spec.AutoInstall.SkipCRDs)
+
+	out, err = ApplyClusterOverrides(integration, map[string]string{"provider": "gcp"})
+	require.NoError(t, err)
+	assert.Equal(t, "55.5.0", out.Spec.AutoInstall.HelmConfig.Version)
+	assert.Equal(t, "standard", out.Spec.AutoInstall.HelmConfig.Values["storageClass"])
+	assert.False(t, out.Spec.AutoInstall.SkipCRDs)
+
+	// The base config is never modified
+	assert.Equal(t, "standard", integration.Spec.AutoInstall.HelmConfig.Values["storageClass"])
+	assert.Equal(t, "55.5.0", integration.Spec.AutoInstall.HelmConfig.Version)
+}
+
+func TestApplyValuesFrom(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		Spec: ksitv1alpha1.IntegrationSpec{
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Chart:        "argo-cd",
+					ValuesObject: &apiextensionsv1.JSON{Raw: []byte(`{"server":{"replicas":3}}`)},
+					ValuesFrom: []ksitv1alpha1.ValuesReference{
+						{Kind: "ConfigMap", Name: "argocd-base"},
+						{Kind: "Secret", Name: "argocd-prod"},
+					},
+				},
+			},
+		},
+	}
+
+	out, err := ApplyValuesFrom(integration, [][]byte{
+		[]byte("server:\n  replicas: 1\n  insecure: true\nredis:\n  enabled: true\n"),
+		[]byte("redis:\n  enabled: false\n"),
+	})
+	require.NoError(t, err)
+	helmConfig := out.Spec.AutoInstall.HelmConfig
+	assert.Empty(t, helmConfig.ValuesFrom)
+	assert.JSONEq(t, `{"server":{"replicas":3,"insecure":true},"redis":{"enabled":false}}`, string(helmConfig.ValuesObject.Raw))
+	assert.Len(t, integration.Spec.AutoInstall.HelmConfig.ValuesFrom, 2, "the original is left untouched")
+
+	_, err = ApplyValuesFrom(integration, [][]byte{[]byte("- not a map")})
+	assert.Error(t, err)
+}
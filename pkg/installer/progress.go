@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const (
+	// defaultReadinessTimeout is how long an install waits for its controllers when
+	// autoInstall.readinessTimeout is not set
+	defaultReadinessTimeout = 3 * time.Minute
+
+	readinessPollInterval = 5 * time.Second
+)
+
+// ProgressFunc receives progress messages while an install is running
+type ProgressFunc func(message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose installs report their progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress sends a message to the ProgressFunc of ctx, if there is one
+func reportProgress(ctx context.Context, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(message)
+	}
+}
+
+// readinessTimeout returns how long the Integration's install waits for readiness
+func readinessTimeout(integration *ksitv1alpha1.Integration) time.Duration {
+	if cfg := integration.Spec.AutoInstall; cfg != nil && cfg.ReadinessTimeout != nil && cfg.ReadinessTimeout.Duration > 0 {
+		return cfg.ReadinessTimeout.Duration
+	}
+	return defaultReadinessTimeout
+}
+
+// waitForDeployments waits until every named Deployment has a ready replica, reporting
+// progress each time the number of ready Deployments changes
+func waitForDeployments(ctx context.Context, clientset kubernetes.Interface, namespace string, names []string, timeout time.Duration) error {
+	lastReady := -1
+	err := wait.PollUntilContextTimeout(ctx, readinessPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		ready := 0
+		for _, name := range names {
+			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err == nil && deploy.Status.ReadyReplicas >= 1 {
+				ready++
+			}
+		}
+		if ready != lastReady {
+			lastReady = ready
+			reportProgress(ctx, fmt.Sprintf("%d/%d controllers ready", ready, len(names)))
+		}
+		return ready == len(names), nil
+	})
+	if err != nil {
+		return fmt.Errorf("%d/%d controllers ready after %s: %w", max(lastReady, 0), len(names), timeout, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package installer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func deployment(name string, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "flux-system"},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+	}
+}
+
+func TestWaitForDeployments(t *testing.T) {
+	var messages []string
+	ctx := WithProgress(context.Background(), func(message string) {
+		messages = append(messages, message)
+	})
+
+	clientset := fake.NewSimpleClientset(deployment("source-controller", 1), deployment("kustomize-controller", 0))
+	err := waitForDeployments(ctx, clientset, "flux-system", []string{"source-controller", "kustomize-controller"}, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "1/2 controllers ready after 10ms")
+	assert.Equal(t, []string{"1/2 controllers ready"}, messages)
+
+	messages = nil
+	clientset = fake.NewSimpleClientset(deployment("source-controller", 1), deployment("kustomize-controller", 2))
+	require.NoError(t, waitForDeployments(ctx, clientset, "flux-system", []string{"source-controller", "kustomize-controller"}, time.Second))
+	assert.Equal(t, []string{"2/2 controllers ready"}, messages)
+
+	// Without a ProgressFunc progress is dropped
+	require.NoError(t, waitForDeployments(context.Background(), clientset, "flux-system", []string{"source-controller"}, time.Second))
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{}
+	assert.Equal(t, defaultReadinessTimeout, readinessTimeout(integration))
+
+	integration.Spec.AutoInstall = &ksitv1alpha1.InstallConfig{ReadinessTimeout: &metav1.Duration{Duration: 10 * time.Minute}}
+	assert.Equal(t, 10*time.Minute, readinessTimeout(integration))
+}
@@ -1,22 +1,155 @@
 package installer
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
 	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/manifests"
+)
+
+const (
+	// prometheusCRDsURL holds the CRDs of prometheus-operator v0.70.0, the operator of the
+	// default kube-prometheus-stack chart
+	prometheusCRDsURL = "https://github.com/prometheus-operator/prometheus-operator/releases/download/v0.70.0/stripped-down-crds.yaml"
+
+	// prometheusCRDGroup is the only API group applied from the CRD manifest
+	prometheusCRDGroup = "monitoring.coreos.com"
+
+	// fieldManager owns the fields KSIT server-side applies
+	fieldManager = "ksit"
+
+	// crdEstablishTimeout bounds how long the chart install waits for applied CRDs to be served
+	crdEstablishTimeout = time.Minute
 )
 
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// PrometheusInstaller installs kube-prometheus-stack. Helm only installs CRDs with a new
+// release and never upgrades them, and the largest do not fit in the annotation that
+// client-side apply needs, so the monitoring.coreos.com CRDs are server-side applied
+// before every install or upgrade and the chart's own are skipped.
+type PrometheusInstaller struct {
+	*HelmInstaller
+	manifests *ManifestCache
+}
+
 // NewPrometheusInstaller creates a new Prometheus installer with default configuration
-func NewPrometheusInstaller() *HelmInstaller {
-	return &HelmInstaller{
-		integrationType: ksitv1alpha1.IntegrationTypePrometheus,
-		defaultConfig: &ksitv1alpha1.HelmInstallConfig{
-			Repository:  "https://prometheus-community.github.io/helm-charts",
-			Chart:       "kube-prometheus-stack",
-			Version:     "55.5.0",
-			ReleaseName: "prometheus",
-			Values: map[string]string{
-				"prometheus.prometheusSpec.retention": "7d",
-				"grafana.enabled":                     "true",
+func NewPrometheusInstaller() *PrometheusInstaller {
+	return &PrometheusInstaller{
+		HelmInstaller: &HelmInstaller{
+			integrationType: ksitv1alpha1.IntegrationTypePrometheus,
+			defaultConfig: &ksitv1alpha1.HelmInstallConfig{
+				Repository:  "https://prometheus-community.github.io/helm-charts",
+				Chart:       "kube-prometheus-stack",
+				Version:     "55.5.0",
+				ReleaseName: "prometheus",
+				Values: map[string]string{
+					"prometheus.prometheusSpec.retention": "7d",
+					"grafana.enabled":                     "true",
+				},
 			},
 		},
+		manifests: sharedManifests,
+	}
+}
+
+// Install applies the CRDs unless autoInstall.skipCRDs is set, then installs or upgrades the chart
+func (p *PrometheusInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	install := integration.Spec.AutoInstall
+	if !install.SkipCRDs {
+		url := install.ManifestURL
+		if url == "" {
+			url = prometheusCRDsURL
+		}
+		manifest, err := p.manifests.Get(ctx, url, install.ManifestDigest)
+		if err != nil {
+			return fmt.Errorf("failed to get Prometheus CRDs: %w", err)
+		}
+
+		dynClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		names, err := applyCRDs(ctx, dynClient, manifest, prometheusCRDGroup)
+		if err != nil {
+			return err
+		}
+		if err := waitForCRDs(ctx, dynClient, names, crdEstablishTimeout); err != nil {
+			return err
+		}
+		reportProgress(ctx, fmt.Sprintf("applied %d Prometheus CRDs", len(names)))
+	}
+
+	return p.install(ctx, config, integration, true)
+}
+
+// applyCRDs server-side applies the CustomResourceDefinitions of group found in manifest
+// and returns their names. Other documents are ignored.
+func applyCRDs(ctx context.Context, dynClient dynamic.Interface, manifest []byte, group string) ([]string, error) {
+	objs, err := manifests.Decode(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRD manifest: %w", err)
+	}
+
+	var names []string
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		if crdGroup, _, _ := unstructured.NestedString(obj.Object, "spec", "group"); crdGroup != group {
+			continue
+		}
+
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return names, fmt.Errorf("failed to encode CRD %s: %w", obj.GetName(), err)
+		}
+		force := true
+		_, err = dynClient.Resource(crdGVR).Patch(ctx, obj.GetName(), types.ApplyPatchType, data,
+			metav1.PatchOptions{FieldManager: fieldManager, Force: &force})
+		if err != nil {
+			return names, fmt.Errorf("failed to apply CRD %s: %w", obj.GetName(), err)
+		}
+		names = append(names, obj.GetName())
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("CRD manifest has no %s CRDs", group)
+	}
+	return names, nil
+}
+
+// waitForCRDs waits until every named CRD is Established, so the chart's custom resources
+// are accepted
+func waitForCRDs(ctx context.Context, dynClient dynamic.Interface, names []string, timeout time.Duration) error {
+	for _, name := range names {
+		err := wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+			crd, err := dynClient.Resource(crdGVR).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+			for _, c := range conditions {
+				condition, ok := c.(map[string]interface{})
+				if ok && condition["type"] == "Established" && condition["status"] == "True" {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("CRD %s was not established: %w", name, err)
+		}
 	}
+	return nil
 }
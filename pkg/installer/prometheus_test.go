@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+const crdManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: prometheuses.monitoring.coreos.com
+spec:
+  group: monitoring.coreos.com
+---
+# comments and other documents are ignored
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: monitoring
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: servicemonitors.monitoring.coreos.com
+spec:
+  group: monitoring.coreos.com
+`
+
+func newCRDClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdGVR: "CustomResourceDefinitionList"}, objects...)
+}
+
+func TestApplyCRDs(t *testing.T) {
+	dynClient := newCRDClient()
+	var applied []string
+	dynClient.PrependReactor("patch", "customresourcedefinitions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+		applied = append(applied, patch.GetName())
+		return true, &unstructured.Unstructured{}, nil
+	})
+
+	names, err := applyCRDs(context.Background(), dynClient, []byte(crdManifest), prometheusCRDGroup)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prometheuses.monitoring.coreos.com", "servicemonitors.monitoring.coreos.com"}, names)
+	assert.Equal(t, names, applied)
+
+	_, err = applyCRDs(context.Background(), dynClient, []byte("kind: Namespace\n"), prometheusCRDGroup)
+	assert.EqualError(t, err, "CRD manifest has no monitoring.coreos.com CRDs")
+}
+
+func TestWaitForCRDs(t *testing.T) {
+	crd := func(name, established string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apiextensions.k8s.io/v1")
+		obj.SetKind("CustomResourceDefinition")
+		obj.SetName(name)
+		require.NoError(t, unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"type": "Established", "status": established},
+		}, "status", "conditions"))
+		return obj
+	}
+	dynClient := newCRDClient(crd("prometheuses.monitoring.coreos.com", "True"), crd("alertmanagers.monitoring.coreos.com", "False"))
+
+	require.NoError(t, waitForCRDs(context.Background(), dynClient, []string{"prometheuses.monitoring.coreos.com"}, time.Second))
+	err := waitForCRDs(context.Background(), dynClient, []string{"alertmanagers.monitoring.coreos.com"}, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "CRD alertmanagers.monitoring.coreos.com was not established")
+}
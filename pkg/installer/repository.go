@@ -0,0 +1,198 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// repoEntry returns the chart repository of helmConfig as Helm stores it, with the CA
+// bundle written to caFile. It never holds credentials: the repo file is shared by the
+// installs of every Integration on the cluster.
+func repoEntry(helmConfig *ksitv1alpha1.HelmInstallConfig, caFile string) *repo.Entry {
+	return &repo.Entry{
+		Name:   extractRepoNameFromURL(helmConfig.Repository),
+		URL:    helmConfig.Repository,
+		CAFile: caFile,
+	}
+}
+
+// findChartURL looks the chart of helmConfig up in the index of its repository,
+// authenticating with its credentials, and returns the URL to download it from. The
+// index is downloaded to a directory of its own and removed afterwards, so neither it
+// nor the credentials are left for other installs.
+func findChartURL(settings *cli.EnvSettings, helmConfig *ksitv1alpha1.HelmInstallConfig, caFile string) (string, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(settings.RepositoryConfig), "index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create index directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	entry := repoEntry(helmConfig, caFile)
+	entry.Username, entry.Password = helmConfig.Credentials.Username, helmConfig.Credentials.Password
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		return "", fmt.Errorf("failed to create chart repository: %w", err)
+	}
+	chartRepo.CachePath = dir
+	indexFile, err := chartRepo.DownloadIndexFile()
+	if err != nil {
+		return "", fmt.Errorf("failed to download repo index: %w", err)
+	}
+	index, err := repo.LoadIndexFile(indexFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load repo index: %w", err)
+	}
+	version, err := index.Get(helmConfig.Chart, helmConfig.Version)
+	if err != nil {
+		return "", fmt.Errorf("failed to find chart %s %s: %w", helmConfig.Chart, helmConfig.Version, err)
+	}
+	if len(version.URLs) == 0 {
+		return "", fmt.Errorf("chart %s %s has no download URL", helmConfig.Chart, version.Version)
+	}
+	chartURL, err := repo.ResolveReferenceURL(helmConfig.Repository, version.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve chart URL: %w", err)
+	}
+	return chartURL, nil
+}
+
+// writeRepoFile writes the repo file of a cluster, readable only by the controller.
+// WriteFile keeps the mode of an existing file, so it is tightened as well.
+func writeRepoFile(path string, content *repo.File) error {
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repo file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write repo file: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict repo file: %w", err)
+	}
+	return nil
+}
+
+// removeStoredCredentials removes the credentials earlier releases left in the settings
+// of a cluster: the registry config file, and those of the entries in the repo file
+func removeStoredCredentials(settings *cli.EnvSettings) error {
+	if err := os.Remove(settings.RegistryConfig); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove registry credentials: %w", err)
+	}
+
+	repoFile, err := repo.LoadFile(settings.RepositoryConfig)
+	if err != nil {
+		// A missing repo file holds no credentials
+		return nil
+	}
+	found := false
+	for _, entry := range repoFile.Repositories {
+		if entry.Username != "" || entry.Password != "" {
+			entry.Username, entry.Password = "", ""
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return writeRepoFile(settings.RepositoryConfig, repoFile)
+}
+
+// writeCABundle writes a CA bundle to the settings directory of the cluster and returns
+// its path, or "" without a bundle. Bundles are named by their content, so releases
+// using different CAs do not overwrite each other's.
+func writeCABundle(settings *cli.EnvSettings, bundle string) (string, error) {
+	if bundle == "" {
+		return "", nil
+	}
+	sum := sha256.Sum256([]byte(bundle))
+	path := filepath.Join(filepath.Dir(settings.RepositoryConfig), "ca-"+hex.EncodeToString(sum[:])[:16]+".crt")
+	if err := os.WriteFile(path, []byte(bundle), 0644); err != nil {
+		return "", fmt.Errorf("failed to write CA bundle: %w", err)
+	}
+	return path, nil
+}
+
+// newRegistryClient returns a client of the OCI registry of helmConfig that trusts its
+// CA bundle and authenticates with its credentials, and a func that removes the
+// credentials file the client reads. The file belongs to this install alone, so the
+// credentials of one Integration are never offered to the registries of another.
+func newRegistryClient(settings *cli.EnvSettings, helmConfig *ksitv1alpha1.HelmInstallConfig) (*registry.Client, func(), error) {
+	credentialsFile, err := writeRegistryCredentials(filepath.Dir(settings.RegistryConfig), registryHost(helmConfig.Repository), helmConfig.Credentials)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.Remove(credentialsFile) }
+
+	opts := []registry.ClientOption{registry.ClientOptCredentialsFile(credentialsFile), registry.ClientOptWriter(io.Discard)}
+	if helmConfig.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(helmConfig.CABundle)) {
+			cleanup()
+			return nil, nil, fmt.Errorf("caBundle holds no PEM-encoded certificate")
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		opts = append(opts, registry.ClientOptHTTPClient(&http.Client{Transport: transport}))
+	}
+	registryClient, err := registry.NewClient(opts...)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to create helm registry client: %w", err)
+	}
+	return registryClient, cleanup, nil
+}
+
+// writeRegistryCredentials writes a new Docker config file in dir, readable only by
+// the controller, holding the credentials of a registry host, or none without creds.
+// It returns the path of the file.
+func writeRegistryCredentials(dir, host string, creds *ksitv1alpha1.RepositoryCredentials) (string, error) {
+	auths := map[string]interface{}{}
+	if creds != nil {
+		auths[host] = map[string]interface{}{
+			"auth": base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password)),
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"auths": auths})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal registry credentials: %w", err)
+	}
+
+	// CreateTemp creates the file with mode 0600
+	file, err := os.CreateTemp(dir, "registry-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create registry credentials file: %w", err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write registry credentials: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// registryHost returns the host, and port, of an oci:// repository
+func registryHost(repository string) string {
+	host, _, _ := strings.Cut(strings.TrimPrefix(repository, "oci://"), "/")
+	return host
+}
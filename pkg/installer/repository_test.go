@@ -0,0 +1,148 @@
+package installer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/repo"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestLoadChartWithCredentials(t *testing.T) {
+	chartDir := t.TempDir()
+	chartFile, err := chartutil.Save(&chart.Chart{Metadata: &chart.Metadata{Name: "argo-cd", Version: "5.51.0", APIVersion: chart.APIVersionV2}}, chartDir)
+	require.NoError(t, err)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "ksit" || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			fmt.Fprint(w, "apiVersion: v1\nentries:\n  argo-cd:\n  - name: argo-cd\n    version: 5.51.0\n    urls: [argo-cd-5.51.0.tgz]\n")
+		case "/charts/argo-cd-5.51.0.tgz":
+			http.ServeFile(w, r, chartFile)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(dir, "cache")
+	settings.RegistryConfig = filepath.Join(dir, "registry.json")
+	require.NoError(t, os.MkdirAll(settings.RepositoryCache, 0755))
+
+	// Earlier releases stored credentials in the settings
+	require.NoError(t, os.WriteFile(settings.RegistryConfig, []byte(`{"auths":{"ghcr.io":{"auth":"b3RoZXI="}}}`), 0600))
+	require.NoError(t, os.WriteFile(settings.RepositoryConfig, []byte("apiVersion: v1\nrepositories:\n- name: other\n  url: https://example.com/other\n  username: other\n  password: secret\n"), 0644))
+	otherIndex := filepath.Join(settings.RepositoryCache, "other-index.yaml")
+	require.NoError(t, os.WriteFile(otherIndex, []byte("apiVersion: v1\nentries: {}\n"), 0644))
+
+	helmConfig := &ksitv1alpha1.HelmInstallConfig{Repository: server.URL + "/charts", Chart: "argo-cd", ReleaseName: "argocd"}
+	_, _, err = loadChart(context.Background(), settings, &action.Configuration{}, helmConfig)
+	assert.ErrorContains(t, err, "certificate")
+
+	helmConfig.CABundle = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	_, _, err = loadChart(context.Background(), settings, &action.Configuration{}, helmConfig)
+	assert.ErrorContains(t, err, "401")
+
+	helmConfig.Credentials = &ksitv1alpha1.RepositoryCredentials{Username: "ksit", Password: "s3cret"}
+	loadedChart, caFile, err := loadChart(context.Background(), settings, &action.Configuration{}, helmConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "5.51.0", loadedChart.Metadata.Version)
+	assert.NotEmpty(t, caFile)
+
+	// Neither these credentials nor the earlier ones are left for other installs
+	repoFile, err := repo.LoadFile(settings.RepositoryConfig)
+	require.NoError(t, err)
+	for _, entry := range repoFile.Repositories {
+		assert.Empty(t, entry.Username, entry.Name)
+		assert.Empty(t, entry.Password, entry.Name)
+	}
+	info, err := os.Stat(settings.RepositoryConfig)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.NoFileExists(t, settings.RegistryConfig)
+	cached, err := filepath.Glob(filepath.Join(settings.RepositoryCache, "*.tgz"))
+	require.NoError(t, err)
+	assert.Empty(t, cached)
+	files, err := filepath.Glob(filepath.Join(dir, "index-*"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestAddHelmRepo(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "apiVersion: v1\nentries: {}\n")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(dir, "cache")
+
+	helmConfig := &ksitv1alpha1.HelmInstallConfig{Repository: server.URL + "/charts", Chart: "argo-cd"}
+	require.NoError(t, addHelmRepo(context.Background(), repoEntry(helmConfig, ""), settings))
+	repoFile, err := repo.LoadFile(settings.RepositoryConfig)
+	require.NoError(t, err)
+	assert.NotNil(t, repoFile.Get("charts"))
+
+	// An unchanged repo is not downloaded again
+	downloads := requests
+	require.NoError(t, addHelmRepo(context.Background(), repoEntry(helmConfig, ""), settings))
+	assert.Equal(t, downloads, requests)
+}
+
+func TestWriteRegistryCredentials(t *testing.T) {
+	dir := t.TempDir()
+	host := registryHost("oci://registry.internal:5000/charts/argo")
+	assert.Equal(t, "registry.internal:5000", host)
+	path, err := writeRegistryCredentials(dir, host, &ksitv1alpha1.RepositoryCredentials{Username: "ksit", Password: "token"})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	require.NoError(t, json.Unmarshal(data, &config))
+	assert.Len(t, config.Auths, 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("ksit:token")), config.Auths[host].Auth)
+
+	// Each install gets a file of its own, empty without credentials
+	other, err := writeRegistryCredentials(dir, host, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, path, other)
+	data, err = os.ReadFile(other)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"auths":{}}`, string(data))
+}
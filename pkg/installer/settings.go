@@ -0,0 +1,75 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// helmSettingsRoot holds one Helm settings directory per target cluster
+var helmSettingsRoot = filepath.Join("/tmp", "helm")
+
+// helmLocks serializes changes to each settings directory
+var helmLocks sync.Map
+
+// newHelmSettings returns Helm settings for one operation against a target cluster.
+// The repository config, repository cache and registry config live in a directory
+// private to that cluster, so operations on different clusters can run in parallel
+// without sharing repositories.yaml or index files. Code that changes those files
+// holds lockHelmSettings while it does. The returned release func removes the
+// temporary kubeconfig.
+func newHelmSettings(config *rest.Config) (*cli.EnvSettings, func(), error) {
+	dir := helmSettingsDir(config.Host)
+
+	if err := os.MkdirAll(filepath.Join(dir, "cache"), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create helm settings dir: %w", err)
+	}
+
+	kubeconfigFile, cleanup, err := writeKubeconfigToTempFile(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+
+	settings := cli.New()
+	settings.KubeConfig = kubeconfigFile
+	settings.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(dir, "cache")
+	settings.RegistryConfig = filepath.Join(dir, "registry.json")
+
+	// The kubeconfig cannot carry the User-Agent, so Helm's own is replaced on every client it builds
+	if flags, ok := settings.RESTClientGetter().(*genericclioptions.ConfigFlags); ok && config.UserAgent != "" {
+		wrap := flags.WrapConfigFn
+		flags.WrapConfigFn = func(c *rest.Config) *rest.Config {
+			if wrap != nil {
+				c = wrap(c)
+			}
+			c.UserAgent = config.UserAgent
+			return c
+		}
+	}
+
+	return settings, cleanup, nil
+}
+
+// lockHelmSettings locks the settings directory of settings until the returned func
+// is called, so concurrent operations on the same cluster do not interleave their
+// reads and writes of repositories.yaml, the registry config and the cache
+func lockHelmSettings(settings *cli.EnvSettings) func() {
+	lock, _ := helmLocks.LoadOrStore(filepath.Dir(settings.RepositoryConfig), &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// helmSettingsDir returns the settings directory for the cluster served at host
+func helmSettingsDir(host string) string {
+	sum := sha256.Sum256([]byte(host))
+	return filepath.Join(helmSettingsRoot, hex.EncodeToString(sum[:])[:16])
+}
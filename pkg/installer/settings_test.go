@@ -0,0 +1,67 @@
+package installer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewHelmSettingsIsolatesClusters(t *testing.T) {
+	helmSettingsRoot = t.TempDir()
+
+	first, releaseFirst, err := newHelmSettings(&rest.Config{Host: "https://cluster1.example.com"})
+	require.NoError(t, err)
+	defer releaseFirst()
+
+	// A different cluster gets its own files
+	second, releaseSecond, err := newHelmSettings(&rest.Config{Host: "https://cluster2.example.com"})
+	require.NoError(t, err)
+	releaseSecond()
+
+	assert.NotEqual(t, first.RepositoryConfig, second.RepositoryConfig)
+	assert.NotEqual(t, first.RepositoryCache, second.RepositoryCache)
+	assert.DirExists(t, first.RepositoryCache)
+	assert.FileExists(t, first.KubeConfig)
+}
+
+func TestLockHelmSettingsSerializesCluster(t *testing.T) {
+	helmSettingsRoot = t.TempDir()
+	config := &rest.Config{Host: "https://cluster1.example.com"}
+
+	first, releaseFirst, err := newHelmSettings(config)
+	require.NoError(t, err)
+	defer releaseFirst()
+	second, releaseSecond, err := newHelmSettings(config)
+	require.NoError(t, err)
+	defer releaseSecond()
+
+	// Other clusters are not blocked by the lock
+	other, releaseOther, err := newHelmSettings(&rest.Config{Host: "https://cluster2.example.com"})
+	require.NoError(t, err)
+	defer releaseOther()
+
+	unlock := lockHelmSettings(first)
+	lockHelmSettings(other)()
+
+	acquired := make(chan struct{})
+	go func() {
+		lockHelmSettings(second)()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second operation on the same cluster did not wait for the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second operation did not proceed after unlock")
+	}
+}
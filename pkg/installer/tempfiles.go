@@ -0,0 +1,128 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// tempKubeconfigPattern names the kubeconfig files written for Helm
+const tempKubeconfigPattern = "kubeconfig-*.yaml"
+
+// DefaultTempFileSweepInterval is how often the janitor removes orphaned kubeconfig files
+const DefaultTempFileSweepInterval = 10 * time.Minute
+
+// tempKubeconfigDir holds the kubeconfig files. Only KSIT writes to it, so sweeps never
+// touch files of other tools sharing the temp directory.
+var tempKubeconfigDir = filepath.Join(os.TempDir(), "ksit")
+
+// liveKubeconfigs are the kubeconfig files of operations still running. Sweeps skip
+// them; every other file matching tempKubeconfigPattern was left behind by a crash or
+// a missed cleanup, and holds credentials.
+var liveKubeconfigs = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: map[string]bool{}}
+
+// createTempKubeconfig writes data to a new file readable only by the owner and returns
+// its path and a func that removes it
+func createTempKubeconfig(data []byte) (string, func(), error) {
+	liveKubeconfigs.Lock()
+	defer liveKubeconfigs.Unlock()
+
+	if err := ensureTempKubeconfigDir(); err != nil {
+		return "", nil, err
+	}
+	// CreateTemp already uses 0600; Chmod keeps that explicit whatever the umask
+	tmpFile, err := os.CreateTemp(tempKubeconfigDir, tempKubeconfigPattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := tmpFile.Name()
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		os.Remove(path)
+		return "", nil, fmt.Errorf("failed to restrict temp file permissions: %w", err)
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(path)
+		return "", nil, fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(path)
+		return "", nil, fmt.Errorf("failed to close temp file: %w", err)
+	}
+	liveKubeconfigs.paths[path] = true
+
+	cleanup := func() {
+		liveKubeconfigs.Lock()
+		defer liveKubeconfigs.Unlock()
+		os.Remove(path)
+		delete(liveKubeconfigs.paths, path)
+	}
+	return path, cleanup, nil
+}
+
+// ensureTempKubeconfigDir creates tempKubeconfigDir readable only by the owner. A
+// symlink or a directory others can open is refused, since the files hold credentials.
+func ensureTempKubeconfigDir() error {
+	if err := os.MkdirAll(tempKubeconfigDir, 0700); err != nil {
+		return fmt.Errorf("failed to create temp kubeconfig directory: %w", err)
+	}
+	info, err := os.Lstat(tempKubeconfigDir)
+	if err != nil {
+		return fmt.Errorf("failed to stat temp kubeconfig directory: %w", err)
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("temp kubeconfig directory %s must be a directory only its owner can access", tempKubeconfigDir)
+	}
+	return nil
+}
+
+// SweepTempKubeconfigs removes the kubeconfig files in tempKubeconfigDir that no running
+// operation uses, and returns how many it removed. At startup that is every one of them.
+func SweepTempKubeconfigs() (int, error) {
+	liveKubeconfigs.Lock()
+	defer liveKubeconfigs.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(tempKubeconfigDir, tempKubeconfigPattern))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list temp kubeconfigs: %w", err)
+	}
+	removed := 0
+	for _, path := range paths {
+		if liveKubeconfigs.paths[path] {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove temp kubeconfig %s: %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RunTempKubeconfigJanitor sweeps orphaned kubeconfig files every interval until ctx is done
+func RunTempKubeconfigJanitor(ctx context.Context, interval time.Duration, log logr.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		removed, err := SweepTempKubeconfigs()
+		if err != nil {
+			log.Error(err, "failed to sweep temp kubeconfigs")
+		} else if removed > 0 {
+			log.Info("removed orphaned temp kubeconfigs", "count", removed)
+		}
+	}
+}
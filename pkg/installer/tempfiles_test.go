@@ -0,0 +1,54 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestSweepTempKubeconfigs(t *testing.T) {
+	shared := t.TempDir()
+	tempKubeconfigDir = filepath.Join(shared, "ksit")
+	defer func() { tempKubeconfigDir = filepath.Join(os.TempDir(), "ksit") }()
+
+	// Left behind by a crash, and a file of another tool in the shared temp directory
+	require.NoError(t, ensureTempKubeconfigDir())
+	orphan := filepath.Join(tempKubeconfigDir, "kubeconfig-123.yaml")
+	other := filepath.Join(shared, "kubeconfig-other.yaml")
+	require.NoError(t, os.WriteFile(orphan, []byte("token"), 0600))
+	require.NoError(t, os.WriteFile(other, []byte("token"), 0600))
+
+	path, cleanup, err := writeKubeconfigToTempFile(&rest.Config{Host: "https://cluster1.example.com", BearerToken: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, tempKubeconfigDir, filepath.Dir(path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	removed, err := SweepTempKubeconfigs()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, other, "files outside the KSIT directory are kept")
+	assert.FileExists(t, path, "the kubeconfig of a running operation is kept")
+
+	cleanup()
+	assert.NoFileExists(t, path)
+	removed, err = SweepTempKubeconfigs()
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestTempKubeconfigDirMustBePrivate(t *testing.T) {
+	tempKubeconfigDir = filepath.Join(t.TempDir(), "ksit")
+	defer func() { tempKubeconfigDir = filepath.Join(os.TempDir(), "ksit") }()
+	require.NoError(t, os.Mkdir(tempKubeconfigDir, 0755))
+	require.NoError(t, os.Chmod(tempKubeconfigDir, 0755))
+
+	_, _, err := createTempKubeconfig([]byte("token"))
+	assert.ErrorContains(t, err, "must be a directory only its owner can access")
+}
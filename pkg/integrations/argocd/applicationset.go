@@ -0,0 +1,217 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+// applicationSetGVK identifies Argo CD ApplicationSets
+var applicationSetGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "ApplicationSet"}
+
+// ApplicationSet generates one Application per parameter set produced by its generators
+type ApplicationSet struct {
+	Metadata ApplicationMetadata `json:"metadata"`
+	Spec     ApplicationSetSpec  `json:"spec"`
+}
+
+// ApplicationSetSpec defines the generators and the Application template
+type ApplicationSetSpec struct {
+	Generators []ApplicationSetGenerator `json:"generators"`
+	Template   ApplicationSetTemplate    `json:"template"`
+	// GoTemplate renders the template with Go templates ({{ .name }}) instead of {{name}}
+	GoTemplate bool `json:"goTemplate,omitempty"`
+}
+
+// ApplicationSetGenerator produces parameter sets. Exactly one field is set.
+type ApplicationSetGenerator struct {
+	Clusters *ClusterGenerator `json:"clusters,omitempty"`
+	List     *ListGenerator    `json:"list,omitempty"`
+}
+
+// ClusterGenerator produces one parameter set, with name and server, per cluster
+// registered in Argo CD that matches Selector
+type ClusterGenerator struct {
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Values are added to every parameter set as values.<key>
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// ListGenerator produces one parameter set per element
+type ListGenerator struct {
+	Elements []map[string]string `json:"elements"`
+}
+
+// ApplicationSetTemplate is the Application created for each parameter set. Its
+// fields may refer to the parameters.
+type ApplicationSetTemplate struct {
+	Metadata ApplicationSetTemplateMeta `json:"metadata"`
+	Spec     ApplicationSpec            `json:"spec"`
+}
+
+// ApplicationSetTemplateMeta is the metadata of the generated Applications
+type ApplicationSetTemplateMeta struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// InventoryListGenerator returns a list generator with one element per cluster of the
+// inventory, so an ApplicationSet fans out to the whole fleet. Each element sets
+// cluster to the cluster name, which matches its Argo CD cluster name when the
+// clusters were registered under the same names.
+func InventoryListGenerator(clusters []*cluster.ClusterInfo) ApplicationSetGenerator {
+	names := make([]string, 0, len(clusters))
+	for _, info := range clusters {
+		names = append(names, info.Name)
+	}
+	sort.Strings(names)
+
+	elements := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		elements = append(elements, map[string]string{"cluster": name})
+	}
+	return ApplicationSetGenerator{List: &ListGenerator{Elements: elements}}
+}
+
+// CreateApplicationSet creates an ApplicationSet. An empty namespace means the Argo CD
+// control plane namespace.
+func (c *Client) CreateApplicationSet(ctx context.Context, appSet *ApplicationSet) error {
+	if err := c.ensureApplicationSets(); err != nil {
+		return err
+	}
+
+	obj, err := c.applicationSetObject(appSet)
+	if err != nil {
+		return err
+	}
+	if err := c.Create(ctx, obj); err != nil {
+		return fmt.Errorf("failed to create ApplicationSet %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// GetApplicationSet returns the ApplicationSet name in namespace
+func (c *Client) GetApplicationSet(ctx context.Context, namespace, name string) (*ApplicationSet, error) {
+	if err := c.ensureApplicationSets(); err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(applicationSetGVK)
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: c.appSetNamespace(namespace)}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get ApplicationSet %s: %w", name, err)
+	}
+	return fromApplicationSetObject(obj)
+}
+
+// ListApplicationSets lists the ApplicationSets in namespace matching the label selector,
+// which may be empty
+func (c *Client) ListApplicationSets(ctx context.Context, namespace, selector string) ([]ApplicationSet, error) {
+	if err := c.ensureApplicationSets(); err != nil {
+		return nil, err
+	}
+
+	opts := []client.ListOption{client.InNamespace(c.appSetNamespace(namespace))}
+	if selector != "" {
+		sel, err := metav1.ParseToLabelSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		labelSelector, err := metav1.LabelSelectorAsSelector(sel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(applicationSetGVK.GroupVersion().WithKind(applicationSetGVK.Kind + "List"))
+	if err := c.List(ctx, list, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list ApplicationSets: %w", err)
+	}
+
+	appSets := make([]ApplicationSet, 0, len(list.Items))
+	for i := range list.Items {
+		appSet, err := fromApplicationSetObject(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		appSets = append(appSets, *appSet)
+	}
+	return appSets, nil
+}
+
+// DeleteApplicationSet deletes the ApplicationSet name in namespace. Argo CD deletes the
+// Applications it generated unless its preserveResourcesOnDeletion policy is set.
+func (c *Client) DeleteApplicationSet(ctx context.Context, namespace, name string) error {
+	if err := c.ensureApplicationSets(); err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(applicationSetGVK)
+	obj.SetName(name)
+	obj.SetNamespace(c.appSetNamespace(namespace))
+	if err := c.Delete(ctx, obj); err != nil {
+		return fmt.Errorf("failed to delete ApplicationSet %s: %w", name, err)
+	}
+	return nil
+}
+
+// ensureApplicationSets checks that ApplicationSets can be managed: they are Kubernetes
+// resources rather than Argo CD API objects, so the client needs a Kubernetes client
+// and the ApplicationSet CRD must be served
+func (c *Client) ensureApplicationSets() error {
+	if c.Client == nil {
+		return fmt.Errorf("ApplicationSets need a Kubernetes client")
+	}
+	return crds.EnsureCRDs(c.RESTMapper(), applicationSetGVK)
+}
+
+func (c *Client) appSetNamespace(namespace string) string {
+	if namespace == "" {
+		return c.namespace
+	}
+	return namespace
+}
+
+// applicationSetObject converts an ApplicationSet to the unstructured object sent to the cluster
+func (c *Client) applicationSetObject(appSet *ApplicationSet) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(appSet.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ApplicationSet %s: %w", appSet.Metadata.Name, err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to decode ApplicationSet %s: %w", appSet.Metadata.Name, err)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(applicationSetGVK)
+	obj.SetName(appSet.Metadata.Name)
+	obj.SetNamespace(c.appSetNamespace(appSet.Metadata.Namespace))
+	obj.SetLabels(appSet.Metadata.Labels)
+	return obj, nil
+}
+
+func fromApplicationSetObject(obj *unstructured.Unstructured) (*ApplicationSet, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ApplicationSet %s: %w", obj.GetName(), err)
+	}
+	appSet := &ApplicationSet{}
+	if err := json.Unmarshal(data, appSet); err != nil {
+		return nil, fmt.Errorf("failed to decode ApplicationSet %s: %w", obj.GetName(), err)
+	}
+	return appSet, nil
+}
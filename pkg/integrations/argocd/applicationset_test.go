@@ -0,0 +1,95 @@
+package argocd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+func TestApplicationSets(t *testing.T) {
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(applicationSetGVK, meta.RESTScopeNamespace)
+	k8sClient := fake.NewClientBuilder().WithRESTMapper(mapper).Build()
+
+	c, err := NewClient(k8sClient, map[string]string{"serverURL": "https://argocd.example.com"})
+	require.NoError(t, err)
+
+	inventory := cluster.NewClusterInventory()
+	inventory.Acquire("edge-2", "ksit-system", "test")
+	inventory.Acquire("edge-1", "ksit-system", "test")
+
+	require.NoError(t, c.CreateApplicationSet(ctx, &ApplicationSet{
+		Metadata: ApplicationMetadata{Name: "guestbook", Labels: map[string]string{"team": "web"}},
+		Spec: ApplicationSetSpec{
+			Generators: []ApplicationSetGenerator{
+				InventoryListGenerator(inventory.ListClusters()),
+				{Clusters: &ClusterGenerator{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}}},
+			},
+			Template: ApplicationSetTemplate{
+				Metadata: ApplicationSetTemplateMeta{Name: "{{cluster}}-guestbook"},
+				Spec: ApplicationSpec{
+					Project:     "default",
+					Source:      ApplicationSource{RepoURL: "https://github.com/argoproj/argocd-example-apps", Path: "guestbook", TargetRevision: "HEAD"},
+					Destination: ApplicationDestination{Name: "{{cluster}}", Namespace: "guestbook"},
+				},
+			},
+		},
+	}))
+
+	// The ApplicationSet lands in the Argo CD namespace with the generators Argo CD expects
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(applicationSetGVK)
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Name: "guestbook", Namespace: "argocd"}, obj))
+	generators, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	require.Len(t, generators, 2)
+	elements, _, _ := unstructured.NestedSlice(generators[0].(map[string]interface{}), "list", "elements")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"cluster": "edge-1"},
+		map[string]interface{}{"cluster": "edge-2"},
+	}, elements)
+	env, _, _ := unstructured.NestedString(generators[1].(map[string]interface{}), "clusters", "selector", "matchLabels", "env")
+	assert.Equal(t, "prod", env)
+
+	appSet, err := c.GetApplicationSet(ctx, "", "guestbook")
+	require.NoError(t, err)
+	assert.Equal(t, "argocd", appSet.Metadata.Namespace)
+	assert.Equal(t, "{{cluster}}-guestbook", appSet.Spec.Template.Metadata.Name)
+	assert.Equal(t, "{{cluster}}", appSet.Spec.Template.Spec.Destination.Name)
+
+	appSets, err := c.ListApplicationSets(ctx, "", "team=web")
+	require.NoError(t, err)
+	require.Len(t, appSets, 1)
+	assert.Equal(t, "guestbook", appSets[0].Metadata.Name)
+
+	appSets, err = c.ListApplicationSets(ctx, "", "team=api")
+	require.NoError(t, err)
+	assert.Empty(t, appSets)
+
+	require.NoError(t, c.DeleteApplicationSet(ctx, "", "guestbook"))
+	_, err = c.GetApplicationSet(ctx, "", "guestbook")
+	assert.Error(t, err)
+}
+
+func TestApplicationSetsRequireCRD(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().WithRESTMapper(meta.NewDefaultRESTMapper(nil)).Build()
+	c, err := NewClient(k8sClient, map[string]string{"serverURL": "https://argocd.example.com"})
+	require.NoError(t, err)
+
+	_, err = c.ListApplicationSets(context.Background(), "", "")
+	assert.True(t, crds.IsMissingCRDs(err))
+
+	c, err = NewClient(nil, map[string]string{"serverURL": "https://argocd.example.com"})
+	require.NoError(t, err)
+	assert.EqualError(t, c.DeleteApplicationSet(context.Background(), "", "guestbook"), "ApplicationSets need a Kubernetes client")
+}
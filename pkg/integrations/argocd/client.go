@@ -3,16 +3,24 @@ package argocd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
 )
 
 // Client represents an ArgoCD client
@@ -24,8 +32,29 @@ type Client struct {
 	namespace  string
 	secretName string
 	secretKey  string
+	// adminSecretName holds admin credentials used once to create a least-privilege
+	// token into secretName; the Secret is deleted afterwards
+	adminSecretName string
+	// tokenProject makes the created token belong to a role of this AppProject, and
+	// limits syncs to its Applications. Empty creates a token of tokenAccount.
+	tokenProject string
+	tokenRole    string
+	tokenAccount string
+	// appSelector restricts SyncCluster to Applications matching this label selector
+	appSelector string
+	// appNamespaces are the namespaces outside the control plane namespace that may
+	// hold Applications (Argo CD "applications in any namespace")
+	appNamespaces []string
+	// appProject is the AppProject that must allow appNamespaces as source namespaces
+	appProject string
+	// grpc is set when config["transport"] is grpc. Calls fall back to HTTP when the
+	// server cannot be reached over gRPC.
+	grpc *grpcTransport
 }
 
+// appProjectGVK identifies Argo CD AppProjects
+var appProjectGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "AppProject"}
+
 // NewClient creates a new ArgoCD client with secret-based token support
 func NewClient(c client.Client, config map[string]string) (*Client, error) {
 	serverURL := config["serverURL"]
@@ -38,17 +67,35 @@ func NewClient(c client.Client, config map[string]string) (*Client, error) {
 		namespace = "argocd"
 	}
 
-	insecure := config["insecure"] == "true"
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: insecure,
-			},
+			TLSClientConfig: tlsConfig,
 		},
 	}
 
+	appProject := config["appProject"]
+	if appProject == "" {
+		appProject = "default"
+	}
+
+	if config["adminSecretName"] != "" && config["secretName"] == "" {
+		return nil, fmt.Errorf("adminSecretName requires secretName to store the created token in")
+	}
+	tokenRole := config["tokenRole"]
+	if tokenRole == "" {
+		tokenRole = DefaultTokenSubject
+	}
+	tokenAccount := config["tokenAccount"]
+	if tokenAccount == "" {
+		tokenAccount = DefaultTokenSubject
+	}
+
 	client := &Client{
 		Client:     c,
 		serverURL:  serverURL,
@@ -57,12 +104,49 @@ func NewClient(c client.Client, config map[string]string) (*Client, error) {
 		secretName: config["secretName"],
 		secretKey:  config["secretKey"],
 		authToken:  config["token"],
+
+		adminSecretName: config["adminSecretName"],
+		tokenProject:    config["tokenProject"],
+		tokenRole:       tokenRole,
+		tokenAccount:    tokenAccount,
+
+		appSelector:   config["appSelector"],
+		appNamespaces: splitList(config["appNamespaces"]),
+		appProject:    appProject,
+	}
+
+	switch config["transport"] {
+	case "", TransportHTTP:
+	case TransportGRPC:
+		client.grpc, err = newGRPCTransport(serverURL, tlsConfig, config["caCert"])
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transport %q, must be %s or %s", config["transport"], TransportHTTP, TransportGRPC)
 	}
 
 	return client, nil
 }
 
-// GetToken retrieves the auth token, either from config or from a Kubernetes Secret
+// newTLSConfig builds the TLS settings shared by the HTTP and gRPC transports from
+// config["insecure"] and the PEM bundle in config["caCert"]
+func newTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config["insecure"] == "true",
+	}
+	if caCert := config["caCert"]; caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("caCert does not contain a PEM certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// GetToken retrieves the auth token, either from config or from a Kubernetes Secret.
+// When the Secret holds no token yet and admin credentials are configured, it creates one.
 func (c *Client) GetToken(ctx context.Context) (string, error) {
 	// If token is directly provided, use it
 	if c.authToken != "" {
@@ -76,6 +160,9 @@ func (c *Client) GetToken(ctx context.Context) (string, error) {
 			Name:      c.secretName,
 			Namespace: c.namespace,
 		}, secret)
+		if apierrors.IsNotFound(err) && c.bootstrapsToken() {
+			return c.bootstrapToken(ctx)
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to get secret %s: %w", c.secretName, err)
 		}
@@ -86,6 +173,9 @@ func (c *Client) GetToken(ctx context.Context) (string, error) {
 		}
 
 		token, ok := secret.Data[key]
+		if !ok && c.bootstrapsToken() {
+			return c.bootstrapToken(ctx)
+		}
 		if !ok {
 			return "", fmt.Errorf("key %s not found in secret %s", key, c.secretName)
 		}
@@ -162,14 +252,22 @@ type SyncStatus struct {
 	Revision string `json:"revision,omitempty"`
 }
 
-// GetApplication retrieves an application
+// GetApplication retrieves an application. namespace is the namespace the Application
+// resource lives in; it may be empty for Applications in the control plane namespace.
 func (c *Client) GetApplication(ctx context.Context, namespace, name string) (*Application, error) {
 	token, err := c.GetToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/api/v1/applications/%s", c.serverURL, name)
+	if c.grpc != nil {
+		app, err := c.grpc.getApplication(ctx, token, c.appNamespaceParam(namespace), name)
+		if err == nil || !fallbackToHTTP(err) {
+			return app, err
+		}
+	}
+
+	url := c.applicationURL(namespace, name, "")
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -196,50 +294,180 @@ func (c *Client) GetApplication(ctx context.Context, namespace, name string) (*A
 	return &app, nil
 }
 
-// ListApplications lists all applications
-func (c *Client) ListApplications(ctx context.Context) ([]Application, error) {
-	token, err := c.GetToken(ctx)
+// ListOptions filters and projects the Applications returned by the Argo CD API
+type ListOptions struct {
+	// Selector is a label selector, e.g. "team=payments,env!=dev"
+	Selector string
+	// Name returns only the Application with this name
+	Name string
+	// AppNamespace returns only Applications in this namespace
+	AppNamespace string
+	// Projects returns only Applications in these projects
+	Projects []string
+	// Fields limits the returned fields, e.g. "items.metadata.name". Projecting
+	// the response keeps large lists small on the wire and in memory.
+	Fields []string
+}
+
+// query encodes the options as Argo CD API query parameters
+func (o ListOptions) query() url.Values {
+	q := url.Values{}
+	if o.Selector != "" {
+		q.Set("selector", o.Selector)
+	}
+	if o.Name != "" {
+		q.Set("name", o.Name)
+	}
+	if o.AppNamespace != "" {
+		q.Set("appNamespace", o.AppNamespace)
+	}
+	for _, project := range o.Projects {
+		q.Add("projects", project)
+	}
+	if len(o.Fields) > 0 {
+		q.Set("fields", strings.Join(o.Fields, ","))
+	}
+	return q
+}
+
+// syncFields are the only fields needed to sync Applications
+var syncFields = []string{
+	"items.metadata.name",
+	"items.metadata.namespace",
+	"items.spec.destination",
+}
+
+// ListApplications lists the applications matching opts
+func (c *Client) ListApplications(ctx context.Context, opts ListOptions) ([]Application, error) {
+	var apps []Application
+	err := c.ForEachApplication(ctx, opts, func(app *Application) error {
+		apps = append(apps, *app)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return apps, nil
+}
+
+// ForEachApplication calls fn for every application matching opts. The Argo CD API
+// does not paginate, so the response is decoded one item at a time instead of being
+// buffered, keeping memory flat for instances with thousands of Applications.
+// Iteration stops at the first error returned by fn. The response is read under the
+// timeout of the HTTP client, so fn should not make requests of its own.
+func (c *Client) ForEachApplication(ctx context.Context, opts ListOptions, fn func(app *Application) error) error {
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return err
+	}
 
 	url := fmt.Sprintf("%s/api/v1/applications", c.serverURL)
+	if q := opts.query(); len(q) > 0 {
+		url += "?" + q.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list applications: %w", err)
+		return fmt.Errorf("failed to list applications: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to list applications, status: %d", resp.StatusCode)
+		return fmt.Errorf("failed to list applications, status: %d", resp.StatusCode)
 	}
 
-	var result struct {
-		Items []Application `json:"items"`
+	return decodeItems(json.NewDecoder(resp.Body), fn)
+}
+
+// decodeItems walks a {"items": [...]} document and decodes the items one by one
+func decodeItems(dec *json.Decoder, fn func(app *Application) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode applications: %w", err)
+		}
+
+		if key, _ := tok.(string); key != "items" {
+			// Skip metadata and any other top-level fields
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to decode applications: %w", err)
+			}
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode applications: %w", err)
+		}
+		if tok == nil {
+			// Argo CD returns "items": null when nothing matches
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("failed to decode applications: items is not an array")
+		}
+
+		for dec.More() {
+			var app Application
+			if err := dec.Decode(&app); err != nil {
+				return fmt.Errorf("failed to decode application: %w", err)
+			}
+			if err := fn(&app); err != nil {
+				return err
+			}
+		}
+
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
 	}
 
-	return result.Items, nil
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to decode applications: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("failed to decode applications: expected %q, got %v", want, tok)
+	}
+	return nil
 }
 
-// SyncApplication syncs an application
-func (c *Client) SyncApplication(ctx context.Context, name string) error {
+// SyncApplication syncs the application name in namespace
+func (c *Client) SyncApplication(ctx context.Context, namespace, name string) error {
 	token, err := c.GetToken(ctx)
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("%s/api/v1/applications/%s/sync", c.serverURL, name)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader("{}"))
+	if c.grpc != nil {
+		err := c.grpc.syncApplication(ctx, token, c.appNamespaceParam(namespace), name)
+		if err == nil || !fallbackToHTTP(err) {
+			return err
+		}
+	}
+
+	body := "{}"
+	if c.isAppNamespace(namespace) {
+		body = fmt.Sprintf(`{"appNamespace":%q}`, namespace)
+	}
+
+	url := c.applicationURL(namespace, name, "/sync")
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -282,29 +510,167 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-// SyncCluster syncs all applications for a given cluster
+// SyncCluster syncs all applications for a given cluster, limited to the
+// configured appSelector. Every application is attempted; the error lists those
+// that failed to sync. Use SyncClusterReport for the per-application results.
 func (c *Client) SyncCluster(ctx context.Context, clusterName string) error {
-	apps, err := c.ListApplications(ctx)
+	report, err := c.SyncClusterReport(ctx, clusterName)
 	if err != nil {
 		return err
 	}
+	return report.Err()
+}
+
+// SyncClusterReport syncs the applications of SyncCluster and reports which were
+// synced, which failed and which were skipped because their destination is another
+// cluster. The error is only set when the applications could not be listed; nothing is
+// synced then.
+func (c *Client) SyncClusterReport(ctx context.Context, clusterName string) (*SyncReport, error) {
+	opts := ListOptions{
+		Selector: c.appSelector,
+		Fields:   syncFields,
+	}
+	if c.tokenProject != "" {
+		// A project token may only sync the Applications of its project
+		opts.Projects = []string{c.tokenProject}
+	}
 
-	for _, app := range apps {
-		if err := c.SyncApplication(ctx, app.Metadata.Name); err != nil {
-			return fmt.Errorf("failed to sync app %s: %w", app.Metadata.Name, err)
+	report := &SyncReport{Cluster: clusterName}
+	var apps []AppSyncResult
+	err := c.ForEachApplication(ctx, opts, func(app *Application) error {
+		result := AppSyncResult{Namespace: app.Metadata.Namespace, Name: app.Metadata.Name}
+		if dest := app.Spec.Destination.Name; dest != "" && dest != clusterName {
+			result.Reason = fmt.Sprintf("destination is cluster %s", dest)
+			report.Skipped = append(report.Skipped, result)
+			return nil
 		}
+		apps = append(apps, result)
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to list applications for %s: %w", clusterName, err)
 	}
 
-	return nil
+	for _, result := range apps {
+		if err := c.SyncApplication(ctx, result.Namespace, result.Name); err != nil {
+			result.Reason = err.Error()
+			report.Failed = append(report.Failed, result)
+			continue
+		}
+		report.Synced = append(report.Synced, result)
+	}
+	return report, nil
+}
+
+// EnsureCRDs verifies that the ArgoCD Application and AppProject CRDs are served by the
+// cluster backing the Kubernetes client. It is a no-op when the client was built without one.
+func (c *Client) EnsureCRDs(ctx context.Context) error {
+	if c.Client == nil {
+		return nil
+	}
+	return crds.EnsureCRDs(c.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeArgoCD)...)
 }
 
 // ReconcileCluster reconciles ArgoCD for a cluster
 func (c *Client) ReconcileCluster(ctx context.Context, clusterName string) error {
+	if err := c.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	// Health check first
 	if err := c.HealthCheck(ctx); err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
+	if err := c.EnsureAppNamespaces(ctx); err != nil {
+		return err
+	}
+
 	// Sync all applications for this cluster
 	return c.SyncCluster(ctx, clusterName)
 }
+
+// EnsureAppNamespaces adds the configured application namespaces to the
+// sourceNamespaces of the AppProject, so Applications created there are accepted.
+// Argo CD itself must also list them in application.namespaces (argocd-cmd-params-cm).
+func (c *Client) EnsureAppNamespaces(ctx context.Context) error {
+	if c.Client == nil || len(c.appNamespaces) == 0 {
+		return nil
+	}
+
+	project := &unstructured.Unstructured{}
+	project.SetGroupVersionKind(appProjectGVK)
+	if err := c.Get(ctx, types.NamespacedName{Name: c.appProject, Namespace: c.namespace}, project); err != nil {
+		return fmt.Errorf("failed to get AppProject %s: %w", c.appProject, err)
+	}
+
+	sourceNamespaces, _, err := unstructured.NestedStringSlice(project.Object, "spec", "sourceNamespaces")
+	if err != nil {
+		return fmt.Errorf("failed to read sourceNamespaces of AppProject %s: %w", c.appProject, err)
+	}
+
+	patch := client.MergeFrom(project.DeepCopy())
+	changed := false
+	for _, ns := range c.appNamespaces {
+		if !containsString(sourceNamespaces, ns) {
+			sourceNamespaces = append(sourceNamespaces, ns)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := unstructured.SetNestedStringSlice(project.Object, sourceNamespaces, "spec", "sourceNamespaces"); err != nil {
+		return err
+	}
+	if err := c.Patch(ctx, project, patch); err != nil {
+		return fmt.Errorf("failed to update sourceNamespaces of AppProject %s: %w", c.appProject, err)
+	}
+	return nil
+}
+
+// isAppNamespace reports whether namespace is an application namespace other than
+// the control plane namespace, which Argo CD addresses with the appNamespace parameter
+func (c *Client) isAppNamespace(namespace string) bool {
+	return namespace != "" && namespace != c.namespace
+}
+
+// appNamespaceParam returns the appNamespace to send for an Application in namespace,
+// which is empty for the control plane namespace
+func (c *Client) appNamespaceParam(namespace string) string {
+	if c.isAppNamespace(namespace) {
+		return namespace
+	}
+	return ""
+}
+
+// applicationURL returns the API URL of an application. Applications outside the
+// control plane namespace are addressed with the appNamespace query parameter.
+func (c *Client) applicationURL(namespace, name, suffix string) string {
+	u := fmt.Sprintf("%s/api/v1/applications/%s%s", c.serverURL, url.PathEscape(name), suffix)
+	if c.isAppNamespace(namespace) {
+		u += "?" + url.Values{"appNamespace": {namespace}}.Encode()
+	}
+	return u
+}
+
+// splitList splits a comma-separated config value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestListApplications(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, `{"metadata":{"resourceVersion":"42"},"items":[
+			{"metadata":{"name":"guestbook","namespace":"argocd"},"spec":{"destination":{"name":"cluster1"}}},
+			{"metadata":{"name":"payments","namespace":"argocd"},"spec":{"destination":{"name":"cluster2"}}}
+		]}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t"})
+	require.NoError(t, err)
+
+	apps, err := c.ListApplications(context.Background(), ListOptions{
+		Selector: "team=payments",
+		Fields:   []string{"items.metadata.name"},
+	})
+	require.NoError(t, err)
+	require.Len(t, apps, 2)
+	assert.Equal(t, "payments", apps[1].Metadata.Name)
+	assert.Equal(t, "cluster2", apps[1].Spec.Destination.Name)
+	assert.Equal(t, "fields=items.metadata.name&selector=team%3Dpayments", query)
+}
+
+func TestForEachApplicationStopsOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}},{"metadata":{"name":"c"}}]}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t"})
+	require.NoError(t, err)
+
+	var seen []string
+	err = c.ForEachApplication(context.Background(), ListOptions{}, func(app *Application) error {
+		seen = append(seen, app.Metadata.Name)
+		if app.Metadata.Name == "b" {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, []string{"a", "b"}, seen)
+}
+
+func TestListApplicationsNullItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"metadata":{},"items":null}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t"})
+	require.NoError(t, err)
+
+	apps, err := c.ListApplications(context.Background(), ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, apps)
+}
+
+func TestApplicationsInAnyNamespace(t *testing.T) {
+	var requests []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		fmt.Fprint(w, `{"metadata":{"name":"guestbook","namespace":"team-a"}}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t", "appNamespaces": "team-a, team-b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a", "team-b"}, c.appNamespaces)
+
+	app, err := c.GetApplication(context.Background(), "team-a", "guestbook")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", app.Metadata.Namespace)
+
+	require.NoError(t, c.SyncApplication(context.Background(), "team-a", "guestbook"))
+	require.NoError(t, c.SyncApplication(context.Background(), "argocd", "guestbook"))
+
+	assert.Equal(t, []string{
+		"GET /api/v1/applications/guestbook?appNamespace=team-a",
+		"POST /api/v1/applications/guestbook/sync?appNamespace=team-a",
+		"POST /api/v1/applications/guestbook/sync",
+	}, requests)
+	assert.Equal(t, `{"appNamespace":"team-a"}`, bodies[1])
+	assert.Equal(t, "{}", bodies[2])
+}
+
+func TestSyncClusterReportListsBeforeSyncing(t *testing.T) {
+	var listing, syncedWhileListing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			// Stream the first item, then hold the response open for a while
+			listing.Store(true)
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"guestbook","namespace":"argocd"}},`)
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `{"metadata":{"name":"payments","namespace":"argocd"}}]}`)
+			listing.Store(false)
+			return
+		}
+		if listing.Load() {
+			syncedWhileListing.Store(true)
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t"})
+	require.NoError(t, err)
+
+	report, err := c.SyncClusterReport(context.Background(), "cluster1")
+	require.NoError(t, err)
+	assert.Len(t, report.Synced, 2)
+	assert.False(t, syncedWhileListing.Load(), "applications were synced while the list response was open")
+}
+
+func TestEnsureAppNamespaces(t *testing.T) {
+	project := &unstructured.Unstructured{}
+	project.SetGroupVersionKind(appProjectGVK)
+	project.SetName("default")
+	project.SetNamespace("argocd")
+	require.NoError(t, unstructured.SetNestedStringSlice(project.Object, []string{"team-a"}, "spec", "sourceNamespaces"))
+
+	k8sClient := fake.NewClientBuilder().WithObjects(project).Build()
+	c, err := NewClient(k8sClient, map[string]string{"serverURL": "https://argocd.example.com", "appNamespaces": "team-a,team-b"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.EnsureAppNamespaces(context.Background()))
+
+	updated := &unstructured.Unstructured{}
+	updated.SetGroupVersionKind(appProjectGVK)
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "default", Namespace: "argocd"}, updated))
+	sourceNamespaces, _, _ := unstructured.NestedStringSlice(updated.Object, "spec", "sourceNamespaces")
+	assert.Equal(t, []string{"team-a", "team-b"}, sourceNamespaces)
+}
+
+func TestSyncClusterReport(t *testing.T) {
+	var synced []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"items":[
+				{"metadata":{"name":"guestbook","namespace":"argocd"},"spec":{"destination":{"name":"cluster1"}}},
+				{"metadata":{"name":"payments","namespace":"argocd"},"spec":{"destination":{"name":"cluster1"}}},
+				{"metadata":{"name":"billing","namespace":"argocd"},"spec":{"destination":{"name":"cluster2"}}},
+				{"metadata":{"name":"ledger","namespace":"argocd"},"spec":{"destination":{"server":"https://kubernetes.default.svc"}}}
+			]}`)
+			return
+		}
+		synced = append(synced, r.URL.Path)
+		if r.URL.Path == "/api/v1/applications/payments/sync" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t"})
+	require.NoError(t, err)
+
+	report, err := c.SyncClusterReport(context.Background(), "cluster1")
+	require.NoError(t, err)
+	// A failed sync does not stop the others
+	assert.Equal(t, []string{
+		"/api/v1/applications/guestbook/sync",
+		"/api/v1/applications/payments/sync",
+		"/api/v1/applications/ledger/sync",
+	}, synced)
+	assert.Equal(t, []AppSyncResult{{Namespace: "argocd", Name: "guestbook"}, {Namespace: "argocd", Name: "ledger"}}, report.Synced)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, "payments", report.Failed[0].Name)
+	assert.Contains(t, report.Failed[0].Reason, "status: 403")
+	assert.Equal(t, []AppSyncResult{{Namespace: "argocd", Name: "billing", Reason: "destination is cluster cluster2"}}, report.Skipped)
+
+	err = c.SyncCluster(context.Background(), "cluster1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to sync 1 of 3 applications on cluster1: argocd/payments: ")
+}
@@ -0,0 +1,471 @@
+package argocd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Transports selectable with config["transport"]
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
+// Argo CD ApplicationService methods
+const (
+	methodGet                = "/application.ApplicationService/Get"
+	methodSync               = "/application.ApplicationService/Sync"
+	methodTerminateOperation = "/application.ApplicationService/TerminateOperation"
+	methodResourceTree       = "/application.ApplicationService/ResourceTree"
+	methodWatch              = "/application.ApplicationService/Watch"
+)
+
+// grpcConns holds one connection per Argo CD server and TLS setting. Clients are created
+// for every reconcile, so connections are kept here to be reused across them.
+var grpcConns = struct {
+	sync.Mutex
+	conns map[string]*grpc.ClientConn
+}{conns: make(map[string]*grpc.ClientConn)}
+
+// grpcTransport calls the Argo CD API over gRPC. Requests and responses are encoded
+// by hand with the field numbers of Argo CD's protobuf API, so only the fields KSIT
+// uses are read.
+type grpcTransport struct {
+	conn *grpc.ClientConn
+}
+
+// newGRPCTransport returns a transport for serverURL, reusing an existing connection
+func newGRPCTransport(serverURL string, tlsConfig *tls.Config, caCert string) (*grpcTransport, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse serverURL: %w", err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	target := net.JoinHostPort(u.Hostname(), port)
+
+	// Servers behind http:// URLs are reached in plaintext, like the HTTP transport does
+	creds := credentials.NewTLS(tlsConfig)
+	if u.Scheme == "http" {
+		creds = insecure.NewCredentials()
+	}
+
+	sum := sha256.Sum256([]byte(caCert))
+	key := fmt.Sprintf("%s|%s|%t|%s", u.Scheme, target, tlsConfig.InsecureSkipVerify, hex.EncodeToString(sum[:8]))
+
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	if conn, ok := grpcConns.conns[key]; ok {
+		return &grpcTransport{conn: conn}, nil
+	}
+
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds), grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", target, err)
+	}
+	grpcConns.conns[key] = conn
+	return &grpcTransport{conn: conn}, nil
+}
+
+// invoke makes a unary call with a bearer token
+func (t *grpcTransport) invoke(ctx context.Context, method, token string, req []byte) ([]byte, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	var resp []byte
+	if err := t.conn.Invoke(ctx, method, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *grpcTransport) getApplication(ctx context.Context, token, namespace, name string) (*Application, error) {
+	resp, err := t.invoke(ctx, methodGet, token, applicationQuery(namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	return decodeApplication(resp)
+}
+
+func (t *grpcTransport) syncApplication(ctx context.Context, token, namespace, name string) error {
+	// ApplicationSyncRequest: name = 1, appNamespace = 12
+	req := appendString(nil, 1, name)
+	req = appendString(req, 12, namespace)
+	_, err := t.invoke(ctx, methodSync, token, req)
+	return err
+}
+
+func (t *grpcTransport) terminateOperation(ctx context.Context, token, namespace, name string) error {
+	// OperationTerminateRequest: name = 1, appNamespace = 2
+	req := appendString(nil, 1, name)
+	req = appendString(req, 2, namespace)
+	_, err := t.invoke(ctx, methodTerminateOperation, token, req)
+	return err
+}
+
+func (t *grpcTransport) resourceTree(ctx context.Context, token, namespace, name string) (*ApplicationTree, error) {
+	// ResourcesQuery: applicationName = 1, appNamespace = 7
+	req := appendString(nil, 1, name)
+	req = appendString(req, 7, namespace)
+	resp, err := t.invoke(ctx, methodResourceTree, token, req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeApplicationTree(resp)
+}
+
+func (t *grpcTransport) watch(ctx context.Context, token, namespace, name string, fn func(event *ApplicationWatchEvent) error) error {
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token))
+	defer cancel()
+
+	desc := &grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+	stream, err := t.conn.NewStream(ctx, desc, methodWatch)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(applicationQuery(namespace, name)); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		event, err := decodeWatchEvent(msg)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
+
+// applicationQuery encodes an ApplicationQuery: name = 1, appNamespace = 7
+func applicationQuery(namespace, name string) []byte {
+	req := appendString(nil, 1, name)
+	return appendString(req, 7, namespace)
+}
+
+// fallbackToHTTP reports whether a gRPC error means the server cannot be reached over
+// gRPC at all, e.g. because an ingress in front of Argo CD only passes HTTP/1.1
+func fallbackToHTTP(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.Unimplemented, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// rawCodec passes already encoded protobuf messages through gRPC unchanged
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	out, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*out = append([]byte(nil), data...)
+	return nil
+}
+
+// Name is "proto" so the server decodes the messages with its protobuf codec
+func (rawCodec) Name() string { return "proto" }
+
+// appendString appends a string field, leaving out empty values like protobuf does
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// walkFields calls fn for every field of an encoded message. data is the content of
+// length-delimited fields and v the value of varint fields.
+func walkFields(b []byte, fn func(num protowire.Number, data []byte, v uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("failed to decode message: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var data []byte
+		var v uint64
+		switch typ {
+		case protowire.BytesType:
+			data, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("failed to decode field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(num, data, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeApplication decodes a v1alpha1.Application
+func decodeApplication(b []byte) (*Application, error) {
+	app := &Application{}
+	err := walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			return decodeObjectMeta(data, &app.Metadata)
+		case 2:
+			return decodeApplicationSpec(data, &app.Spec)
+		case 3:
+			return decodeApplicationStatus(data, &app.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode application: %w", err)
+	}
+	return app, nil
+}
+
+func decodeObjectMeta(b []byte, meta *ApplicationMetadata) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			meta.Name = string(data)
+		case 3:
+			meta.Namespace = string(data)
+		case 11:
+			var key, value string
+			err := walkFields(data, func(num protowire.Number, data []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(data)
+				case 2:
+					value = string(data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if meta.Labels == nil {
+				meta.Labels = make(map[string]string)
+			}
+			meta.Labels[key] = value
+		}
+		return nil
+	})
+}
+
+func decodeApplicationSpec(b []byte, spec *ApplicationSpec) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			return walkFields(data, func(num protowire.Number, data []byte, _ uint64) error {
+				switch num {
+				case 1:
+					spec.Source.RepoURL = string(data)
+				case 2:
+					spec.Source.Path = string(data)
+				case 4:
+					spec.Source.TargetRevision = string(data)
+				}
+				return nil
+			})
+		case 2:
+			return walkFields(data, func(num protowire.Number, data []byte, _ uint64) error {
+				switch num {
+				case 1:
+					spec.Destination.Server = string(data)
+				case 2:
+					spec.Destination.Namespace = string(data)
+				case 3:
+					spec.Destination.Name = string(data)
+				}
+				return nil
+			})
+		case 3:
+			spec.Project = string(data)
+		case 4:
+			spec.SyncPolicy = &SyncPolicy{}
+			return decodeSyncPolicy(data, spec.SyncPolicy)
+		}
+		return nil
+	})
+}
+
+func decodeSyncPolicy(b []byte, policy *SyncPolicy) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			policy.Automated = &AutomatedSyncPolicy{}
+			return walkFields(data, func(num protowire.Number, _ []byte, v uint64) error {
+				switch num {
+				case 1:
+					policy.Automated.Prune = v != 0
+				case 2:
+					policy.Automated.SelfHeal = v != 0
+				}
+				return nil
+			})
+		case 2:
+			policy.SyncOptions = append(policy.SyncOptions, string(data))
+		}
+		return nil
+	})
+}
+
+func decodeApplicationStatus(b []byte, appStatus *ApplicationStatus) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 2:
+			return walkFields(data, func(num protowire.Number, data []byte, _ uint64) error {
+				switch num {
+				case 1:
+					appStatus.Sync.Status = string(data)
+				case 3:
+					appStatus.Sync.Revision = string(data)
+				}
+				return nil
+			})
+		case 3:
+			return decodeHealthStatus(data, &appStatus.Health)
+		}
+		return nil
+	})
+}
+
+func decodeHealthStatus(b []byte, health *HealthStatus) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			health.Status = string(data)
+		case 2:
+			health.Message = string(data)
+		}
+		return nil
+	})
+}
+
+// decodeApplicationTree decodes a v1alpha1.ApplicationTree
+func decodeApplicationTree(b []byte) (*ApplicationTree, error) {
+	tree := &ApplicationTree{}
+	err := walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		var node ResourceNode
+		err := walkFields(data, func(num protowire.Number, data []byte, _ uint64) error {
+			switch num {
+			case 1:
+				return decodeResourceRef(data, &node.ResourceRef)
+			case 2:
+				var ref ResourceRef
+				if err := decodeResourceRef(data, &ref); err != nil {
+					return err
+				}
+				node.ParentRefs = append(node.ParentRefs, ref)
+			case 7:
+				node.Health = &HealthStatus{}
+				return decodeHealthStatus(data, node.Health)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		tree.Nodes = append(tree.Nodes, node)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode resource tree: %w", err)
+	}
+	return tree, nil
+}
+
+func decodeResourceRef(b []byte, ref *ResourceRef) error {
+	return walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			ref.Group = string(data)
+		case 2:
+			ref.Version = string(data)
+		case 3:
+			ref.Kind = string(data)
+		case 4:
+			ref.Namespace = string(data)
+		case 5:
+			ref.Name = string(data)
+		case 6:
+			ref.UID = string(data)
+		}
+		return nil
+	})
+}
+
+// decodeWatchEvent decodes a v1alpha1.ApplicationWatchEvent
+func decodeWatchEvent(b []byte) (*ApplicationWatchEvent, error) {
+	event := &ApplicationWatchEvent{}
+	err := walkFields(b, func(num protowire.Number, data []byte, _ uint64) error {
+		switch num {
+		case 1:
+			event.Type = string(data)
+		case 2:
+			app, err := decodeApplication(data)
+			if err != nil {
+				return err
+			}
+			event.Application = *app
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode watch event: %w", err)
+	}
+	return event, nil
+}
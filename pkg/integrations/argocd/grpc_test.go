@@ -0,0 +1,199 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// appendMessage appends an embedded message field
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func encodedApplication(name, namespace, health string) []byte {
+	meta := appendString(nil, 1, name)
+	meta = appendString(meta, 3, namespace)
+	meta = appendMessage(meta, 11, appendString(appendString(nil, 1, "team"), 2, "payments"))
+
+	destination := appendString(nil, 3, "cluster1")
+	spec := appendMessage(nil, 2, destination)
+	spec = appendString(spec, 3, "default")
+	automated := protowire.AppendVarint(protowire.AppendTag(nil, 2, protowire.VarintType), 1)
+	spec = appendMessage(spec, 4, appendMessage(nil, 1, automated))
+
+	status := appendMessage(nil, 2, appendString(appendString(nil, 1, "Synced"), 3, "abc123"))
+	status = appendMessage(status, 3, appendString(nil, 1, health))
+
+	app := appendMessage(nil, 1, meta)
+	app = appendMessage(app, 2, spec)
+	return appendMessage(app, 3, status)
+}
+
+// startGRPCServer serves every method with handle, passing the raw request message
+func startGRPCServer(t *testing.T, handle func(method string, req []byte, stream grpc.ServerStream) error) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		return handle(method, req, stream)
+	}))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return "http://" + listener.Addr().String()
+}
+
+func TestGRPCTransport(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	serverURL := startGRPCServer(t, func(method string, req []byte, stream grpc.ServerStream) error {
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s %x %s", method, req, md.Get("authorization")))
+		mu.Unlock()
+
+		switch method {
+		case methodGet, methodSync:
+			return stream.SendMsg(encodedApplication("guestbook", "team-a", "Healthy"))
+		case methodResourceTree:
+			ref := appendString(appendString(appendString(nil, 3, "Deployment"), 4, "guestbook"), 5, "web")
+			node := appendMessage(nil, 1, ref)
+			node = appendMessage(node, 7, appendString(nil, 1, "Progressing"))
+			return stream.SendMsg(appendMessage(nil, 1, node))
+		case methodWatch:
+			for _, health := range []string{"Progressing", "Healthy"} {
+				event := appendString(nil, 1, "MODIFIED")
+				event = appendMessage(event, 2, encodedApplication("guestbook", "team-a", health))
+				if err := stream.SendMsg(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return stream.SendMsg([]byte{})
+	})
+
+	c, err := NewClient(nil, map[string]string{"serverURL": serverURL, "token": "t", "transport": "grpc", "appNamespaces": "team-a"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	app, err := c.GetApplication(ctx, "team-a", "guestbook")
+	require.NoError(t, err)
+	assert.Equal(t, "guestbook", app.Metadata.Name)
+	assert.Equal(t, "team-a", app.Metadata.Namespace)
+	assert.Equal(t, "payments", app.Metadata.Labels["team"])
+	assert.Equal(t, "cluster1", app.Spec.Destination.Name)
+	assert.Equal(t, "default", app.Spec.Project)
+	require.NotNil(t, app.Spec.SyncPolicy)
+	assert.True(t, app.Spec.SyncPolicy.Automated.SelfHeal)
+	assert.Equal(t, "Synced", app.Status.Sync.Status)
+	assert.Equal(t, "abc123", app.Status.Sync.Revision)
+	assert.Equal(t, "Healthy", app.Status.Health.Status)
+
+	require.NoError(t, c.SyncApplication(ctx, "argocd", "guestbook"))
+	require.NoError(t, c.TerminateOperation(ctx, "team-a", "guestbook"))
+
+	tree, err := c.ResourceTree(ctx, "argocd", "guestbook")
+	require.NoError(t, err)
+	require.Len(t, tree.Nodes, 1)
+	assert.Equal(t, "Deployment", tree.Nodes[0].Kind)
+	assert.Equal(t, "web", tree.Nodes[0].Name)
+	assert.Equal(t, "Progressing", tree.Nodes[0].Health.Status)
+
+	var health []string
+	require.NoError(t, c.WatchApplication(ctx, "argocd", "guestbook", func(event *ApplicationWatchEvent) error {
+		assert.Equal(t, "MODIFIED", event.Type)
+		health = append(health, event.Application.Status.Health.Status)
+		return nil
+	}))
+	assert.Equal(t, []string{"Progressing", "Healthy"}, health)
+
+	assert.Equal(t, []string{
+		fmt.Sprintf("%s %x [Bearer t]", methodGet, applicationQuery("team-a", "guestbook")),
+		fmt.Sprintf("%s %x [Bearer t]", methodSync, appendString(nil, 1, "guestbook")),
+		fmt.Sprintf("%s %x [Bearer t]", methodTerminateOperation, appendString(appendString(nil, 1, "guestbook"), 2, "team-a")),
+		fmt.Sprintf("%s %x [Bearer t]", methodResourceTree, appendString(nil, 1, "guestbook")),
+		fmt.Sprintf("%s %x [Bearer t]", methodWatch, applicationQuery("", "guestbook")),
+	}, calls)
+
+	// Clients for the same server share the connection
+	other, err := NewClient(nil, map[string]string{"serverURL": serverURL, "token": "t", "transport": "grpc"})
+	require.NoError(t, err)
+	assert.Same(t, c.grpc.conn, other.grpc.conn)
+}
+
+func TestGRPCTransportFallsBackToHTTP(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PRI" {
+			// The HTTP/2 preface of the gRPC attempt
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/resource-tree"):
+			fmt.Fprint(w, `{"nodes":[{"kind":"Service","name":"web","health":{"status":"Healthy"}}]}`)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/stream/"):
+			fmt.Fprint(w, "data: {\"result\":{\"type\":\"ADDED\",\"application\":{\"metadata\":{\"name\":\"guestbook\"}}}}\n\n")
+		default:
+			fmt.Fprint(w, `{"metadata":{"name":"guestbook","namespace":"argocd"}}`)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"serverURL": server.URL, "token": "t", "transport": "grpc"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	app, err := c.GetApplication(ctx, "argocd", "guestbook")
+	require.NoError(t, err)
+	assert.Equal(t, "guestbook", app.Metadata.Name)
+
+	require.NoError(t, c.TerminateOperation(ctx, "argocd", "guestbook"))
+
+	tree, err := c.ResourceTree(ctx, "argocd", "guestbook")
+	require.NoError(t, err)
+	require.Len(t, tree.Nodes, 1)
+	assert.Equal(t, "Service", tree.Nodes[0].Kind)
+
+	var events []string
+	require.NoError(t, c.WatchApplication(ctx, "argocd", "guestbook", func(event *ApplicationWatchEvent) error {
+		events = append(events, event.Type+" "+event.Application.Metadata.Name)
+		return nil
+	}))
+	assert.Equal(t, []string{"ADDED guestbook"}, events)
+
+	assert.Equal(t, []string{
+		"GET /api/v1/applications/guestbook",
+		"DELETE /api/v1/applications/guestbook/operation",
+		"GET /api/v1/applications/guestbook/resource-tree",
+		"GET /api/v1/stream/applications?name=guestbook",
+	}, requests)
+}
+
+func TestNewClientTransportAndTLS(t *testing.T) {
+	_, err := NewClient(nil, map[string]string{"serverURL": "https://argocd.example.com", "transport": "websocket"})
+	assert.ErrorContains(t, err, `unsupported transport "websocket"`)
+
+	_, err = NewClient(nil, map[string]string{"serverURL": "https://argocd.example.com", "caCert": "not a certificate"})
+	assert.ErrorContains(t, err, "caCert does not contain a PEM certificate")
+}
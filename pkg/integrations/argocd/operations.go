@@ -0,0 +1,191 @@
+package argocd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ApplicationTree lists the resources managed by an application
+type ApplicationTree struct {
+	Nodes []ResourceNode `json:"nodes,omitempty"`
+}
+
+// ResourceNode is a resource in an application's resource tree
+type ResourceNode struct {
+	ResourceRef
+	ParentRefs []ResourceRef `json:"parentRefs,omitempty"`
+	Health     *HealthStatus `json:"health,omitempty"`
+}
+
+// ResourceRef identifies a Kubernetes resource
+type ResourceRef struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// ApplicationWatchEvent is a change to an application
+type ApplicationWatchEvent struct {
+	// Type is ADDED, MODIFIED or DELETED
+	Type        string      `json:"type"`
+	Application Application `json:"application"`
+}
+
+// TerminateOperation stops the running sync of the application name in namespace
+func (c *Client) TerminateOperation(ctx context.Context, namespace, name string) error {
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.grpc != nil {
+		err := c.grpc.terminateOperation(ctx, token, c.appNamespaceParam(namespace), name)
+		if err == nil || !fallbackToHTTP(err) {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.applicationURL(namespace, name, "/operation"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to terminate operation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to terminate operation, status: %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// ResourceTree returns the resources managed by the application name in namespace
+func (c *Client) ResourceTree(ctx context.Context, namespace, name string) (*ApplicationTree, error) {
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.grpc != nil {
+		tree, err := c.grpc.resourceTree(ctx, token, c.appNamespaceParam(namespace), name)
+		if err == nil || !fallbackToHTTP(err) {
+			return tree, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.applicationURL(namespace, name, "/resource-tree"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource tree: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get resource tree, status: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var tree ApplicationTree
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to decode resource tree: %w", err)
+	}
+	return &tree, nil
+}
+
+// WatchApplication calls fn for every change to the application name in namespace until
+// ctx is done, the server ends the stream or fn returns an error
+func (c *Client) WatchApplication(ctx context.Context, namespace, name string, fn func(event *ApplicationWatchEvent) error) error {
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.grpc != nil {
+		err := c.grpc.watch(ctx, token, c.appNamespaceParam(namespace), name, fn)
+		if err == nil || !fallbackToHTTP(err) {
+			return err
+		}
+	}
+
+	q := url.Values{"name": {name}}
+	if c.isAppNamespace(namespace) {
+		q.Set("appNamespace", namespace)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/stream/applications?%s", c.serverURL, q.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// The stream stays open, so it must not be cut off by the client timeout
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to watch application: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to watch application, status: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	return decodeEventStream(resp.Body, fn)
+}
+
+// decodeEventStream reads the server-sent events of the Argo CD streaming API, which
+// wrap each event as data: {"result": {...}}
+func decodeEventStream(r io.Reader, fn func(event *ApplicationWatchEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	// A single event carries a whole Application, which can be large
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			continue
+		}
+
+		var msg struct {
+			Result *ApplicationWatchEvent `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(bytes.TrimSpace(data), &msg); err != nil {
+			return fmt.Errorf("failed to decode watch event: %w", err)
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("watch failed: %s", msg.Error.Message)
+		}
+		if msg.Result == nil {
+			continue
+		}
+		if err := fn(msg.Result); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read watch stream: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package argocd
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,6 +24,35 @@ type SyncResult struct {
 	Timestamp time.Time
 }
 
+// SyncReport is the outcome of syncing the applications of a cluster
+type SyncReport struct {
+	Cluster string          `json:"cluster"`
+	Synced  []AppSyncResult `json:"synced,omitempty"`
+	Failed  []AppSyncResult `json:"failed,omitempty"`
+	Skipped []AppSyncResult `json:"skipped,omitempty"`
+}
+
+// AppSyncResult is one application of a SyncReport. Reason is empty for synced
+// applications.
+type AppSyncResult struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Err returns an error naming every application that failed to sync, or nil
+func (r *SyncReport) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	failures := make([]string, 0, len(r.Failed))
+	for _, app := range r.Failed {
+		failures = append(failures, fmt.Sprintf("%s/%s: %s", app.Namespace, app.Name, app.Reason))
+	}
+	return fmt.Errorf("failed to sync %d of %d applications on %s: %s",
+		len(r.Failed), len(r.Failed)+len(r.Synced), r.Cluster, strings.Join(failures, "; "))
+}
+
 // NewSyncer creates a new ArgoCD syncer
 func NewSyncer(c client.Client, argoClient *Client) *Syncer {
 	return &Syncer{
@@ -42,7 +72,7 @@ func (s *Syncer) Sync(ctx context.Context, namespace string, name string) error
 		return nil
 	}
 
-	return s.argoClient.SyncApplication(ctx, name)
+	return s.argoClient.SyncApplication(ctx, namespace, name)
 }
 
 // GetSyncResult returns the sync status of an application
@@ -84,19 +114,23 @@ func (s *Syncer) WaitForSync(ctx context.Context, namespace string, name string,
 	return fmt.Errorf("timeout waiting for sync")
 }
 
-// SyncAll syncs all applications
+// SyncAll syncs all applications. They are listed first, so that the syncs do not hold
+// the list response open.
 func (s *Syncer) SyncAll(ctx context.Context) error {
-	apps, err := s.argoClient.ListApplications(ctx)
+	var apps []*Application
+	err := s.argoClient.ForEachApplication(ctx, ListOptions{Fields: syncFields}, func(app *Application) error {
+		apps = append(apps, app)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to list applications: %w", err)
+		return fmt.Errorf("failed to sync applications: %w", err)
 	}
 
 	for _, app := range apps {
-		if err := s.argoClient.SyncApplication(ctx, app.Metadata.Name); err != nil {
-			return fmt.Errorf("failed to sync %s: %w", app.Metadata.Name, err)
+		if err := s.argoClient.SyncApplication(ctx, app.Metadata.Namespace, app.Metadata.Name); err != nil {
+			return fmt.Errorf("failed to sync applications: failed to sync %s: %w", app.Metadata.Name, err)
 		}
 	}
-
 	return nil
 }
 
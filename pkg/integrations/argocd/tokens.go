@@ -0,0 +1,302 @@
+package argocd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultTokenSubject is the Argo CD account, or the AppProject role, KSIT creates its
+// token for when tokenAccount or tokenRole is not set
+const DefaultTokenSubject = "ksit"
+
+// MinimumPolicies returns the Argo CD RBAC policy lines KSIT needs for subject: reading
+// Applications for health and sync status, and syncing them. project limits them to the
+// Applications of one AppProject; "*" allows every project.
+func MinimumPolicies(subject, project string) []string {
+	return []string{
+		fmt.Sprintf("p, %s, applications, get, %s/*, allow", subject, project),
+		fmt.Sprintf("p, %s, applications, sync, %s/*, allow", subject, project),
+	}
+}
+
+// ProjectRoleSubject is the RBAC subject of the tokens of an AppProject role
+func ProjectRoleSubject(project, role string) string {
+	return fmt.Sprintf("proj:%s:%s", project, role)
+}
+
+// bootstrapsToken reports whether the client creates its own token from admin credentials
+func (c *Client) bootstrapsToken() bool {
+	return c.adminSecretName != "" && c.secretName != ""
+}
+
+// bootstrapLocks serializes the bootstraps that store their token in the same Secret, so
+// that concurrent checks of one cluster create a single token
+var bootstrapLocks sync.Map
+
+// bootstrapToken creates a least-privilege token with the admin credentials in
+// adminSecretName, stores it in secretName and deletes the admin credentials, so they
+// are supplied once and only the scoped token is kept. With tokenProject set, the token
+// belongs to a role of that AppProject; otherwise to a dedicated local account. Either
+// way the subject gets exactly MinimumPolicies. A token stored meanwhile by another
+// bootstrap is returned as is.
+func (c *Client) bootstrapToken(ctx context.Context) (string, error) {
+	lock, _ := bootstrapLocks.LoadOrStore(c.serverURL+"|"+c.namespace+"/"+c.secretName, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := c.secretKey
+	if key == "" {
+		key = "token"
+	}
+	stored := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: c.secretName}, stored)
+	if err == nil && len(stored.Data[key]) > 0 {
+		return string(stored.Data[key]), nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get secret %s: %w", c.secretName, err)
+	}
+
+	admin := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: c.adminSecretName}, admin); err != nil {
+		return "", fmt.Errorf("failed to get admin credentials secret %s: %w", c.adminSecretName, err)
+	}
+	username := string(admin.Data["username"])
+	if username == "" {
+		username = "admin"
+	}
+	session, err := c.login(ctx, username, string(admin.Data["password"]))
+	if err != nil {
+		return "", err
+	}
+
+	// Token ids must be unique per subject, and every cluster of a shared Argo CD
+	// creates its own token
+	id := "ksit-" + string(uuid.NewUUID())
+	var token string
+	if c.tokenProject != "" {
+		if err := c.ensureProjectRole(ctx); err != nil {
+			return "", err
+		}
+		token, err = c.createToken(ctx, session,
+			fmt.Sprintf("/api/v1/projects/%s/roles/%s/token", url.PathEscape(c.tokenProject), url.PathEscape(c.tokenRole)),
+			map[string]string{"project": c.tokenProject, "role": c.tokenRole, "id": id, "description": "Created by KSIT"})
+	} else {
+		if err := c.ensureAccount(ctx); err != nil {
+			return "", err
+		}
+		token, err = c.createToken(ctx, session,
+			fmt.Sprintf("/api/v1/account/%s/token", url.PathEscape(c.tokenAccount)),
+			map[string]string{"name": c.tokenAccount, "id": id})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.storeToken(ctx, token); err != nil {
+		return "", err
+	}
+	if err := c.Delete(ctx, admin); err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to delete admin credentials secret %s: %w", c.adminSecretName, err)
+	}
+	return token, nil
+}
+
+// login returns a session token for username
+func (c *Client) login(ctx context.Context, username, password string) (string, error) {
+	token, err := c.postForToken(ctx, "", "/api/v1/session", map[string]string{"username": username, "password": password})
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to Argo CD as %s: %w", username, err)
+	}
+	return token, nil
+}
+
+// createToken creates an API token at path with the session token
+func (c *Client) createToken(ctx context.Context, session, path string, body map[string]string) (string, error) {
+	token, err := c.postForToken(ctx, session, path, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Argo CD token: %w", err)
+	}
+	return token, nil
+}
+
+// postForToken posts body as JSON to path and returns the token of the answer
+func (c *Client) postForToken(ctx context.Context, bearer, path string, body map[string]string) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.serverURL+path, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("status: %d, body: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	answer := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return "", fmt.Errorf("failed to decode answer: %w", err)
+	}
+	if answer.Token == "" {
+		return "", fmt.Errorf("answer holds no token")
+	}
+	return answer.Token, nil
+}
+
+// ensureProjectRole sets the KSIT role of the AppProject to MinimumPolicies, replacing
+// broader policies an earlier setup may have given it
+func (c *Client) ensureProjectRole(ctx context.Context) error {
+	project := &unstructured.Unstructured{}
+	project.SetGroupVersionKind(appProjectGVK)
+	if err := c.Get(ctx, types.NamespacedName{Name: c.tokenProject, Namespace: c.namespace}, project); err != nil {
+		return fmt.Errorf("failed to get AppProject %s: %w", c.tokenProject, err)
+	}
+
+	roles, _, err := unstructured.NestedSlice(project.Object, "spec", "roles")
+	if err != nil {
+		return fmt.Errorf("failed to read roles of AppProject %s: %w", c.tokenProject, err)
+	}
+	policies := []interface{}{}
+	for _, policy := range MinimumPolicies(ProjectRoleSubject(c.tokenProject, c.tokenRole), c.tokenProject) {
+		policies = append(policies, policy)
+	}
+
+	patch := client.MergeFrom(project.DeepCopy())
+	found := false
+	for i, r := range roles {
+		role, ok := r.(map[string]interface{})
+		if !ok || role["name"] != c.tokenRole {
+			continue
+		}
+		role["policies"] = policies
+		roles[i] = role
+		found = true
+	}
+	if !found {
+		roles = append(roles, map[string]interface{}{
+			"name":        c.tokenRole,
+			"description": "Least-privilege role of KSIT",
+			"policies":    policies,
+		})
+	}
+	if err := unstructured.SetNestedSlice(project.Object, roles, "spec", "roles"); err != nil {
+		return err
+	}
+	if err := c.Patch(ctx, project, patch); err != nil {
+		return fmt.Errorf("failed to update role %s of AppProject %s: %w", c.tokenRole, c.tokenProject, err)
+	}
+	return nil
+}
+
+// ensureAccount declares the KSIT account with API key login in argocd-cm and sets its
+// policies in argocd-rbac-cm to MinimumPolicies, dropping any other policy or role the
+// account was given
+func (c *Client) ensureAccount(ctx context.Context) error {
+	if err := c.patchConfigMap(ctx, "argocd-cm", func(data map[string]string) {
+		data["accounts."+c.tokenAccount] = "apiKey"
+	}); err != nil {
+		return err
+	}
+	return c.patchConfigMap(ctx, "argocd-rbac-cm", func(data map[string]string) {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimRight(data["policy.csv"], "\n"), "\n") {
+			if line != "" && policySubject(line) != c.tokenAccount {
+				lines = append(lines, line)
+			}
+		}
+		lines = append(lines, MinimumPolicies(c.tokenAccount, "*")...)
+		data["policy.csv"] = strings.Join(lines, "\n") + "\n"
+	})
+}
+
+// policySubject returns the subject of a p (policy) or g (role) line of policy.csv
+func policySubject(line string) string {
+	fields := strings.Split(line, ",")
+	if len(fields) < 2 {
+		return ""
+	}
+	if kind := strings.TrimSpace(fields[0]); kind != "p" && kind != "g" {
+		return ""
+	}
+	return strings.TrimSpace(fields[1])
+}
+
+// patchConfigMap changes the data of a ConfigMap in the Argo CD namespace
+func (c *Client) patchConfigMap(ctx context.Context, name string, change func(data map[string]string)) error {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: name}, cm); err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+	}
+	patch := client.MergeFrom(cm.DeepCopy())
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	change(cm.Data)
+	if err := c.Patch(ctx, cm, patch); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %w", name, err)
+	}
+	return nil
+}
+
+// storeToken writes the token into secretName, creating the Secret when needed
+func (c *Client) storeToken(ctx context.Context, token string) error {
+	key := c.secretKey
+	if key == "" {
+		key = "token"
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: c.secretName}, secret)
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: c.secretName, Namespace: c.namespace},
+			Data:       map[string][]byte{key: []byte(token)},
+		}
+		if err := c.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create secret %s: %w", c.secretName, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", c.secretName, err)
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[key] = []byte(token)
+	if err := c.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update secret %s: %w", c.secretName, err)
+	}
+	return nil
+}
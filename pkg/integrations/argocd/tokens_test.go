@@ -0,0 +1,188 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// tokenRequest is a token request recorded by newTokenServer
+type tokenRequest struct {
+	Path string
+	ID   string
+}
+
+// newTokenServer serves Argo CD's session and token endpoints and records the token requests
+func newTokenServer(requests *[]tokenRequest) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.URL.Path == "/api/v1/session":
+			if body["username"] != "admin" || body["password"] != "hunter2" {
+				http.Error(w, "invalid credentials", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"session"}`))
+		case r.Header.Get("Authorization") != "Bearer session":
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+		default:
+			mu.Lock()
+			*requests = append(*requests, tokenRequest{Path: r.URL.Path, ID: body["id"]})
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"token":"scoped"}`))
+		}
+	}))
+}
+
+func adminSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-admin", Namespace: "argocd"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+}
+
+func TestBootstrapProjectToken(t *testing.T) {
+	var requests []tokenRequest
+	server := newTokenServer(&requests)
+	defer server.Close()
+
+	project := &unstructured.Unstructured{}
+	project.SetGroupVersionKind(appProjectGVK)
+	project.SetName("fleet")
+	project.SetNamespace("argocd")
+	require.NoError(t, unstructured.SetNestedSlice(project.Object, []interface{}{
+		map[string]interface{}{"name": "ksit", "policies": []interface{}{"p, proj:fleet:ksit, *, *, fleet/*, allow"}},
+	}, "spec", "roles"))
+
+	k8sClient := fake.NewClientBuilder().WithObjects(project, adminSecret()).Build()
+	c, err := NewClient(k8sClient, map[string]string{
+		"serverURL":       server.URL,
+		"secretName":      "argocd-ksit-token",
+		"adminSecretName": "argocd-admin",
+		"tokenProject":    "fleet",
+	})
+	require.NoError(t, err)
+
+	token, err := c.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "scoped", token)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/api/v1/projects/fleet/roles/ksit/token", requests[0].Path)
+	assert.True(t, strings.HasPrefix(requests[0].ID, "ksit-"), requests[0].ID)
+
+	// The role is narrowed to the minimum policies
+	updated := &unstructured.Unstructured{}
+	updated.SetGroupVersionKind(appProjectGVK)
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "fleet", Namespace: "argocd"}, updated))
+	roles, _, _ := unstructured.NestedSlice(updated.Object, "spec", "roles")
+	require.Len(t, roles, 1)
+	assert.Equal(t, []interface{}{
+		"p, proj:fleet:ksit, applications, get, fleet/*, allow",
+		"p, proj:fleet:ksit, applications, sync, fleet/*, allow",
+	}, roles[0].(map[string]interface{})["policies"])
+
+	// Only the scoped token is kept
+	stored := &corev1.Secret{}
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "argocd-ksit-token", Namespace: "argocd"}, stored))
+	assert.Equal(t, "scoped", string(stored.Data["token"]))
+	err = k8sClient.Get(context.Background(), types.NamespacedName{Name: "argocd-admin", Namespace: "argocd"}, &corev1.Secret{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Later calls read the stored token
+	token, err = c.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "scoped", token)
+	assert.Len(t, requests, 1)
+}
+
+func TestBootstrapAccountToken(t *testing.T) {
+	var requests []tokenRequest
+	server := newTokenServer(&requests)
+	defer server.Close()
+
+	k8sClient := fake.NewClientBuilder().WithObjects(
+		adminSecret(),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "argocd-cm", Namespace: "argocd"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-rbac-cm", Namespace: "argocd"},
+			Data: map[string]string{"policy.csv": "g, platform, role:admin\n" +
+				"p, ksit, *, *, */*, allow\n" +
+				"g, ksit, role:admin\n"},
+		},
+	).Build()
+	c, err := NewClient(k8sClient, map[string]string{
+		"serverURL":       server.URL,
+		"secretName":      "argocd-ksit-token",
+		"adminSecretName": "argocd-admin",
+	})
+	require.NoError(t, err)
+
+	token, err := c.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "scoped", token)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/api/v1/account/ksit/token", requests[0].Path)
+	assert.True(t, strings.HasPrefix(requests[0].ID, "ksit-"), requests[0].ID)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "argocd-cm", Namespace: "argocd"}, cm))
+	assert.Equal(t, "apiKey", cm.Data["accounts.ksit"])
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "argocd-rbac-cm", Namespace: "argocd"}, cm))
+	assert.Equal(t, "g, platform, role:admin\np, ksit, applications, get, */*, allow\np, ksit, applications, sync, */*, allow\n", cm.Data["policy.csv"])
+}
+
+func TestBootstrapTokenConcurrently(t *testing.T) {
+	var requests []tokenRequest
+	server := newTokenServer(&requests)
+	defer server.Close()
+
+	k8sClient := fake.NewClientBuilder().WithObjects(
+		adminSecret(),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "argocd-cm", Namespace: "argocd"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "argocd-rbac-cm", Namespace: "argocd"}},
+	).Build()
+	c, err := NewClient(k8sClient, map[string]string{
+		"serverURL":       server.URL,
+		"secretName":      "argocd-ksit-token",
+		"adminSecretName": "argocd-admin",
+	})
+	require.NoError(t, err)
+
+	// Each caller gets the one stored token, and none fails on the deleted admin secret
+	tokens := make([]string, 5)
+	errs := make([]error, len(tokens))
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = c.GetToken(context.Background())
+		}(i)
+	}
+	wg.Wait()
+	for i := range tokens {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "scoped", tokens[i])
+	}
+	assert.Len(t, requests, 1)
+}
+
+func TestBootstrapTokenRequiresSecretName(t *testing.T) {
+	_, err := NewClient(nil, map[string]string{"serverURL": "https://argocd.example.com", "adminSecretName": "argocd-admin"})
+	assert.EqualError(t, err, "adminSecretName requires secretName to store the created token in")
+}
@@ -0,0 +1,223 @@
+package certmanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+var (
+	certificateGVK = schema.GroupVersionKind{
+		Group:   "cert-manager.io",
+		Version: "v1",
+		Kind:    "Certificate",
+	}
+	clusterIssuerGVK = schema.GroupVersionKind{
+		Group:   "cert-manager.io",
+		Version: "v1",
+		Kind:    "ClusterIssuer",
+	}
+)
+
+// Client manages cert-manager resources on one target cluster
+type Client struct {
+	client.Client
+}
+
+// NewClient creates a cert-manager client on top of a target cluster's Kubernetes client
+func NewClient(c client.Client) *Client {
+	return &Client{Client: c}
+}
+
+// EnsureCRDs verifies that the cert-manager CRDs are served by the cluster
+func (c *Client) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(c.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeCertManager)...)
+}
+
+// HealthCheck verifies that the cert-manager API answers by listing ClusterIssuers
+func (c *Client) HealthCheck(ctx context.Context) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(clusterIssuerGVK.GroupVersion().WithKind("ClusterIssuerList"))
+	if err := c.List(ctx, list, client.Limit(1)); err != nil {
+		return fmt.Errorf("cert-manager health check failed: %w", err)
+	}
+	return nil
+}
+
+// ClusterIssuer is a cluster-wide certificate issuer. Exactly one of SelfSigned, CA and
+// ACME should be set.
+type ClusterIssuer struct {
+	Name       string
+	Labels     map[string]string
+	SelfSigned bool
+	CA         *CAIssuer
+	ACME       *ACMEIssuer
+}
+
+// CAIssuer signs certificates with the key pair in a Secret of the cert-manager namespace
+type CAIssuer struct {
+	SecretName string
+}
+
+// ACMEIssuer obtains certificates from an ACME server such as Let's Encrypt, solving
+// HTTP-01 challenges through an ingress class, or the default one when IngressClass is empty
+type ACMEIssuer struct {
+	Server string
+	Email  string
+	// PrivateKeySecretName is the Secret cert-manager stores the ACME account key in
+	PrivateKeySecretName string
+	IngressClass         string
+}
+
+// ApplyClusterIssuer creates a ClusterIssuer, or updates its spec if it already exists
+func (c *Client) ApplyClusterIssuer(ctx context.Context, issuer *ClusterIssuer) error {
+	if err := c.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
+	spec, err := issuerSpec(issuer)
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(clusterIssuerGVK)
+	obj.SetName(issuer.Name)
+	obj.SetLabels(issuer.Labels)
+	obj.Object["spec"] = spec
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(clusterIssuerGVK)
+	err = c.Get(ctx, client.ObjectKey{Name: issuer.Name}, existing)
+	if errors.IsNotFound(err) {
+		if err := c.Create(ctx, obj); err != nil {
+			return fmt.Errorf("failed to create ClusterIssuer %s: %w", issuer.Name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ClusterIssuer %s: %w", issuer.Name, err)
+	}
+
+	existing.Object["spec"] = spec
+	if len(issuer.Labels) > 0 {
+		existing.SetLabels(issuer.Labels)
+	}
+	if err := c.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update ClusterIssuer %s: %w", issuer.Name, err)
+	}
+	return nil
+}
+
+// DeleteClusterIssuer deletes a ClusterIssuer
+func (c *Client) DeleteClusterIssuer(ctx context.Context, name string) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(clusterIssuerGVK)
+	obj.SetName(name)
+	return c.Delete(ctx, obj)
+}
+
+func issuerSpec(issuer *ClusterIssuer) (map[string]interface{}, error) {
+	switch {
+	case issuer.SelfSigned:
+		return map[string]interface{}{"selfSigned": map[string]interface{}{}}, nil
+	case issuer.CA != nil:
+		return map[string]interface{}{"ca": map[string]interface{}{"secretName": issuer.CA.SecretName}}, nil
+	case issuer.ACME != nil:
+		ingress := map[string]interface{}{}
+		if issuer.ACME.IngressClass != "" {
+			ingress["ingressClassName"] = issuer.ACME.IngressClass
+		}
+		acme := map[string]interface{}{
+			"server":              issuer.ACME.Server,
+			"privateKeySecretRef": map[string]interface{}{"name": issuer.ACME.PrivateKeySecretName},
+			"solvers": []interface{}{
+				map[string]interface{}{"http01": map[string]interface{}{"ingress": ingress}},
+			},
+		}
+		if issuer.ACME.Email != "" {
+			acme["email"] = issuer.ACME.Email
+		}
+		return map[string]interface{}{"acme": acme}, nil
+	default:
+		return nil, fmt.Errorf("ClusterIssuer %s sets none of selfSigned, ca and acme", issuer.Name)
+	}
+}
+
+// Certificate is what KSIT reads of a cert-manager Certificate
+type Certificate struct {
+	Name       string
+	Namespace  string
+	SecretName string
+	DNSNames   []string
+	// NotAfter is when the issued certificate expires; nil until one is issued
+	NotAfter *time.Time
+	Ready    bool
+	// Message is the message of the Ready condition
+	Message string
+}
+
+// ListCertificates returns the Certificates in a namespace, or in all namespaces when
+// namespace is empty, sorted by namespace and name
+func (c *Client) ListCertificates(ctx context.Context, namespace string) ([]Certificate, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind("CertificateList"))
+	if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list Certificates: %w", err)
+	}
+
+	certs := make([]Certificate, 0, len(list.Items))
+	for _, item := range list.Items {
+		cert := Certificate{Name: item.GetName(), Namespace: item.GetNamespace()}
+		cert.SecretName, _, _ = unstructured.NestedString(item.Object, "spec", "secretName")
+		cert.DNSNames, _, _ = unstructured.NestedStringSlice(item.Object, "spec", "dnsNames")
+		if notAfter, _, _ := unstructured.NestedString(item.Object, "status", "notAfter"); notAfter != "" {
+			if t, err := time.Parse(time.RFC3339, notAfter); err == nil {
+				cert.NotAfter = &t
+			}
+		}
+		conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+		for _, cond := range conditions {
+			condMap, ok := cond.(map[string]interface{})
+			if !ok || condMap["type"] != "Ready" {
+				continue
+			}
+			cert.Ready = condMap["status"] == "True"
+			cert.Message, _ = condMap["message"].(string)
+		}
+		certs = append(certs, cert)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		if certs[i].Namespace != certs[j].Namespace {
+			return certs[i].Namespace < certs[j].Namespace
+		}
+		return certs[i].Name < certs[j].Name
+	})
+	return certs, nil
+}
+
+// Expiring returns the certificates that expire within window of now, or have already
+// expired, soonest first. cert-manager renews certificates well before they expire, so
+// one that gets this close is usually failing to renew.
+func Expiring(certs []Certificate, now time.Time, window time.Duration) []Certificate {
+	deadline := now.Add(window)
+	var expiring []Certificate
+	for _, cert := range certs {
+		if cert.NotAfter != nil && cert.NotAfter.Before(deadline) {
+			expiring = append(expiring, cert)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].NotAfter.Before(*expiring[j].NotAfter)
+	})
+	return expiring
+}
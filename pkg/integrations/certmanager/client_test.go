@@ -0,0 +1,118 @@
+package certmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+func newTestClient(objs ...client.Object) *Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor("cert-manager") {
+		scope := meta.RESTScopeNamespace
+		if gvk.Kind == "ClusterIssuer" {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	return NewClient(c)
+}
+
+func certificate(namespace, name, notAfter, message string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": name + "-tls",
+			"dnsNames":   []interface{}{name + ".example.com"},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "message": message},
+			},
+		},
+	}}
+	if notAfter != "" {
+		_ = unstructured.SetNestedField(obj.Object, notAfter, "status", "notAfter")
+	}
+	obj.SetGroupVersionKind(certificateGVK)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestApplyClusterIssuer(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient()
+
+	require.NoError(t, c.ApplyClusterIssuer(ctx, &ClusterIssuer{Name: "selfsigned", SelfSigned: true}))
+
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(clusterIssuerGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "selfsigned"}, issuer))
+	_, found, _ := unstructured.NestedMap(issuer.Object, "spec", "selfSigned")
+	assert.True(t, found)
+
+	// Applying again switches the issuer to ACME
+	require.NoError(t, c.ApplyClusterIssuer(ctx, &ClusterIssuer{Name: "selfsigned", ACME: &ACMEIssuer{
+		Server:               "https://acme-staging-v02.api.letsencrypt.org/directory",
+		Email:                "ops@example.com",
+		PrivateKeySecretName: "letsencrypt-staging",
+		IngressClass:         "nginx",
+	}}))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "selfsigned"}, issuer))
+	_, found, _ = unstructured.NestedMap(issuer.Object, "spec", "selfSigned")
+	assert.False(t, found)
+	email, _, _ := unstructured.NestedString(issuer.Object, "spec", "acme", "email")
+	assert.Equal(t, "ops@example.com", email)
+	solvers, _, _ := unstructured.NestedSlice(issuer.Object, "spec", "acme", "solvers")
+	require.Len(t, solvers, 1)
+	class, _, _ := unstructured.NestedString(solvers[0].(map[string]interface{}), "http01", "ingress", "ingressClassName")
+	assert.Equal(t, "nginx", class)
+
+	assert.Error(t, c.ApplyClusterIssuer(ctx, &ClusterIssuer{Name: "empty"}))
+
+	require.NoError(t, c.DeleteClusterIssuer(ctx, "selfsigned"))
+	assert.Error(t, c.Get(ctx, client.ObjectKey{Name: "selfsigned"}, issuer))
+}
+
+func TestExpiringCertificates(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(
+		certificate("web", "shop", "2026-03-01T00:00:00Z", "Certificate is up to date and has not expired"),
+		certificate("web", "api", "2026-01-10T00:00:00Z", "Certificate is up to date and has not expired"),
+		certificate("ingress", "wildcard", "2025-12-31T00:00:00Z", "Certificate has expired"),
+		certificate("web", "pending", "", "Issuing certificate as Secret does not exist"),
+	)
+
+	certs, err := c.ListCertificates(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, certs, 4)
+	assert.Equal(t, "ingress", certs[0].Namespace)
+	assert.Equal(t, "web", certs[1].Namespace)
+	assert.Equal(t, "api", certs[1].Name)
+	assert.Equal(t, "api-tls", certs[1].SecretName)
+	assert.Equal(t, []string{"api.example.com"}, certs[1].DNSNames)
+	assert.True(t, certs[1].Ready)
+	assert.Nil(t, certs[2].NotAfter, "certificates that were never issued have no expiry")
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiring := Expiring(certs, now, 14*24*time.Hour)
+	require.Len(t, expiring, 2)
+	assert.Equal(t, "wildcard", expiring[0].Name, "expired certificates come first")
+	assert.Equal(t, "Certificate has expired", expiring[0].Message)
+	assert.Equal(t, "api", expiring[1].Name)
+
+	certs, err = c.ListCertificates(ctx, "ingress")
+	require.NoError(t, err)
+	assert.Len(t, certs, 1)
+}
@@ -0,0 +1,104 @@
+package crds
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// requiredCRDs lists the custom resources each integration type relies on
+var requiredCRDs = map[string][]schema.GroupVersionKind{
+	ksitv1alpha1.IntegrationTypeArgoCD: {
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"},
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "AppProject"},
+	},
+	ksitv1alpha1.IntegrationTypeFlux: {
+		{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"},
+		{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"},
+	},
+	ksitv1alpha1.IntegrationTypePrometheus: {
+		{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"},
+		{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"},
+	},
+	ksitv1alpha1.IntegrationTypeIstio: {
+		{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"},
+		{Group: "networking.istio.io", Version: "v1beta1", Kind: "DestinationRule"},
+		{Group: "security.istio.io", Version: "v1beta1", Kind: "PeerAuthentication"},
+	},
+	ksitv1alpha1.IntegrationTypeCertManager: {
+		{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+		{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"},
+		{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"},
+	},
+	ksitv1alpha1.IntegrationTypeKyverno: {
+		{Group: "kyverno.io", Version: "v1", Kind: "ClusterPolicy"},
+		{Group: "kyverno.io", Version: "v1", Kind: "Policy"},
+		{Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "PolicyReport"},
+		{Group: "wgpolicyk8s.io", Version: "v1alpha2", Kind: "ClusterPolicyReport"},
+	},
+}
+
+// MissingCRDsError is returned when one or more required CRDs are not served by a cluster
+type MissingCRDsError struct {
+	Missing []schema.GroupVersionKind
+}
+
+func (e *MissingCRDsError) Error() string {
+	names := make([]string, 0, len(e.Missing))
+	for _, gvk := range e.Missing {
+		names = append(names, fmt.Sprintf("%s/%s %s", gvk.Group, gvk.Version, gvk.Kind))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("missing CRDs: %s", strings.Join(names, ", "))
+}
+
+// IsMissingCRDs reports whether err (or any error it wraps) is a MissingCRDsError
+func IsMissingCRDs(err error) bool {
+	var missing *MissingCRDsError
+	return errors.As(err, &missing)
+}
+
+// RequiredFor returns the CRDs an integration type depends on
+func RequiredFor(integrationType string) []schema.GroupVersionKind {
+	return requiredCRDs[integrationType]
+}
+
+// EnsureCRDs verifies that every given kind is served by the API server behind mapper.
+// Kinds that are not served are collected into a single MissingCRDsError.
+func EnsureCRDs(mapper meta.RESTMapper, gvks ...schema.GroupVersionKind) error {
+	var missing []schema.GroupVersionKind
+	for _, gvk := range gvks {
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				missing = append(missing, gvk)
+				continue
+			}
+			return fmt.Errorf("failed to look up %s: %w", gvk.String(), err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingCRDsError{Missing: missing}
+	}
+	return nil
+}
+
+// EnsureCRDsWithDiscovery runs EnsureCRDs against a fresh discovery-backed mapper
+func EnsureCRDsWithDiscovery(dc discovery.DiscoveryInterface, gvks ...schema.GroupVersionKind) error {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	return EnsureCRDs(mapper, gvks...)
+}
+
+// EnsureForIntegration checks the CRDs required by the given integration type
+func EnsureForIntegration(dc discovery.DiscoveryInterface, integrationType string) error {
+	return EnsureCRDsWithDiscovery(dc, RequiredFor(integrationType)...)
+}
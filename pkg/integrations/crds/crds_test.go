@@ -0,0 +1,46 @@
+package crds
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestEnsureCRDs(t *testing.T) {
+	gitRepo := schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"}
+	kustomization := schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gitRepo, meta.RESTScopeNamespace)
+
+	t.Run("all present", func(t *testing.T) {
+		assert.NoError(t, EnsureCRDs(mapper, gitRepo))
+	})
+
+	t.Run("missing kind", func(t *testing.T) {
+		err := EnsureCRDs(mapper, gitRepo, kustomization)
+		require.Error(t, err)
+		assert.True(t, IsMissingCRDs(err))
+		assert.True(t, IsMissingCRDs(fmt.Errorf("wrapped: %w", err)))
+		assert.Contains(t, err.Error(), "kustomize.toolkit.fluxcd.io/v1 Kustomization")
+		assert.NotContains(t, err.Error(), "GitRepository")
+	})
+}
+
+func TestRequiredFor(t *testing.T) {
+	for _, integrationType := range []string{
+		ksitv1alpha1.IntegrationTypeArgoCD,
+		ksitv1alpha1.IntegrationTypeFlux,
+		ksitv1alpha1.IntegrationTypePrometheus,
+		ksitv1alpha1.IntegrationTypeIstio,
+	} {
+		assert.NotEmpty(t, RequiredFor(integrationType), integrationType)
+	}
+	assert.Empty(t, RequiredFor("unknown"))
+}
@@ -0,0 +1,246 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/argocd"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/certmanager"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/grafana"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/istio"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/kyverno"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+	"github.com/kubestellar/integration-toolkit/pkg/template"
+)
+
+// IntegrationClient is what the clients of every integration type have in common
+type IntegrationClient interface {
+	// EnsureCRDs verifies that the tool's CRDs are served by the target cluster
+	EnsureCRDs(ctx context.Context) error
+}
+
+// PrometheusClient is a Prometheus query client paired with the Kubernetes client of the
+// cluster Prometheus runs on, so it can check the operator CRDs like the other clients
+type PrometheusClient struct {
+	*prometheus.Client
+	kube client.Client
+}
+
+// EnsureCRDs verifies that the Prometheus operator CRDs are served by the cluster
+func (p *PrometheusClient) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(p.kube.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypePrometheus)...)
+}
+
+// Factory builds initialized integration clients for an Integration on one of its target clusters
+type Factory struct {
+	ClusterManager *cluster.ClusterManager
+	Scheme         *runtime.Scheme
+	Log            logr.Logger
+
+	// NewClient builds the Kubernetes client for a target cluster. Defaults to client.New.
+	NewClient func(config *rest.Config) (client.Client, error)
+	// Secrets reads the hub Secrets of spec.configSecretRefs. Integrations that have
+	// configSecretRefs fail without it.
+	Secrets client.Reader
+}
+
+// New creates a Factory that resolves clusters through the ClusterManager. Set Secrets to
+// support spec.configSecretRefs.
+func New(cm *cluster.ClusterManager, scheme *runtime.Scheme, log logr.Logger) *Factory {
+	return &Factory{
+		ClusterManager: cm,
+		Scheme:         scheme,
+		Log:            log,
+		NewClient: func(config *rest.Config) (client.Client, error) {
+			return client.New(config, client.Options{})
+		},
+	}
+}
+
+// Client returns the client for the Integration's type on a target cluster
+func (f *Factory) Client(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (IntegrationClient, error) {
+	switch integration.Spec.Type {
+	case ksitv1alpha1.IntegrationTypeArgoCD:
+		return f.ArgoCD(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypeFlux:
+		return f.Flux(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypePrometheus:
+		return f.Prometheus(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypeIstio:
+		return f.Istio(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypeGrafana:
+		return f.Grafana(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypeCertManager:
+		return f.CertManager(ctx, integration, clusterName)
+	case ksitv1alpha1.IntegrationTypeKyverno:
+		return f.Kyverno(ctx, integration, clusterName)
+	default:
+		return nil, fmt.Errorf("unsupported integration type: %s", integration.Spec.Type)
+	}
+}
+
+// Kubernetes returns a plain Kubernetes client for a target cluster
+func (f *Factory) Kubernetes(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (client.Client, error) {
+	c, _, err := f.clusterClient(ctx, integration, clusterName)
+	return c, err
+}
+
+// ArgoCD returns an Argo CD client for a target cluster. Its token is resolved up front,
+// so a missing credentials Secret is reported here rather than on first use.
+func (f *Factory) ArgoCD(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*argocd.Client, error) {
+	c, config, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	argoClient, err := argocd.NewClient(c, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ArgoCD client for %s: %w", clusterName, err)
+	}
+	if _, err := argoClient.GetToken(ctx); err != nil {
+		return nil, fmt.Errorf("failed to resolve ArgoCD credentials for %s: %w", clusterName, err)
+	}
+	return argoClient, nil
+}
+
+// Flux returns a Flux client for a target cluster
+func (f *Factory) Flux(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*flux.FluxClient, error) {
+	c, _, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return flux.NewFluxClient(c, f.Scheme, f.Log.WithValues("cluster", clusterName)), nil
+}
+
+// Prometheus returns a Prometheus client for the url configured for a target cluster. It
+// authenticates with config["bearerToken"], or config["username"] and config["password"].
+func (f *Factory) Prometheus(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*PrometheusClient, error) {
+	c, config, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	url := config["url"]
+	if url == "" {
+		return nil, fmt.Errorf("prometheus integration %s has no url configured", integration.Name)
+	}
+
+	promClient, err := prometheus.NewClientWithAuth(url, prometheus.Auth{
+		Username:    config["username"],
+		Password:    config["password"],
+		BearerToken: config["bearerToken"],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prometheus client for %s: %w", clusterName, err)
+	}
+	return &PrometheusClient{Client: promClient, kube: c}, nil
+}
+
+// Istio returns an Istio client for a target cluster
+func (f *Factory) Istio(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*istio.Client, error) {
+	restConfig, config, err := f.clusterConfig(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := config["namespace"]
+	if namespace == "" {
+		namespace = "istio-system"
+	}
+
+	istioClient, err := istio.NewClientWithConfig(restConfig, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Istio client for %s: %w", clusterName, err)
+	}
+	return istioClient, nil
+}
+
+// Grafana returns a Grafana API client for the url configured for a target cluster. Its
+// credentials are resolved up front, like those of Argo CD.
+func (f *Factory) Grafana(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*grafana.Client, error) {
+	c, config, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	if config["url"] == "" {
+		return nil, fmt.Errorf("grafana integration %s has no url configured", integration.Name)
+	}
+
+	grafanaClient, err := grafana.NewClient(c, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Grafana client for %s: %w", clusterName, err)
+	}
+	if err := grafanaClient.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to resolve Grafana credentials for %s: %w", clusterName, err)
+	}
+	return grafanaClient, nil
+}
+
+// CertManager returns a cert-manager client for a target cluster
+func (f *Factory) CertManager(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*certmanager.Client, error) {
+	c, _, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return certmanager.NewClient(c), nil
+}
+
+// Kyverno returns a Kyverno client for a target cluster
+func (f *Factory) Kyverno(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*kyverno.Client, error) {
+	c, _, err := f.clusterClient(ctx, integration, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return kyverno.NewClient(c), nil
+}
+
+// clusterConfig returns the rest.Config of a target cluster and the Integration's config
+// with its templates and Secret references resolved for that cluster
+func (f *Factory) clusterConfig(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*rest.Config, map[string]string, error) {
+	restConfig, err := f.ClusterManager.GetIntegrationConfig(clusterName, integration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get cluster config for %s: %w", clusterName, err)
+	}
+
+	var labels map[string]string
+	if c, err := f.ClusterManager.GetIntegrationCluster(clusterName, integration); err == nil {
+		labels = c.Labels
+	}
+	config, err := template.RenderMap(integration.Spec.Config, template.NewData(integration, clusterName, labels))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render config for %s: %w", clusterName, err)
+	}
+	config, err = ResolveConfigSecrets(ctx, f.Secrets, integration, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve config secrets for %s: %w", clusterName, err)
+	}
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	return restConfig, config, nil
+}
+
+// clusterClient returns a Kubernetes client for a target cluster and the resolved Integration config
+func (f *Factory) clusterClient(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (client.Client, map[string]string, error) {
+	restConfig, config, err := f.clusterConfig(ctx, integration, clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := f.NewClient(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create client for %s: %w", clusterName, err)
+	}
+	return c, config, nil
+}
@@ -0,0 +1,153 @@
+package factory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/argocd"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: https://edge-1.example.com
+contexts:
+- name: c
+  context:
+    cluster: c
+    user: u
+current-context: c
+users:
+- name: u
+  user:
+    token: t
+`
+
+func newTestFactory(t *testing.T, objs ...client.Object) *Factory {
+	cm := cluster.NewClusterManager(nil)
+	require.NoError(t, cm.AddCluster("edge-1", "ksit-system", kubeconfig))
+	require.NoError(t, cm.SetClusterLabels("edge-1", "ksit-system", map[string]string{"region": "eu"}))
+
+	target := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+	f := New(cm, scheme.Scheme, logr.Discard())
+	f.NewClient = func(config *rest.Config) (client.Client, error) {
+		assert.Equal(t, "https://edge-1.example.com", config.Host)
+		return target, nil
+	}
+	return f
+}
+
+func TestFactoryClient(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-token", Namespace: "argocd"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	f := newTestFactory(t, secret)
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type: ksitv1alpha1.IntegrationTypeArgoCD,
+			Config: map[string]string{
+				"serverURL":  "https://argocd.{{ .Cluster.Labels.region }}.example.com",
+				"secretName": "argocd-token",
+			},
+		},
+	}
+
+	c, err := f.Client(context.Background(), integration, "edge-1")
+	require.NoError(t, err)
+	argoClient, ok := c.(*argocd.Client)
+	require.True(t, ok)
+	token, err := argoClient.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", token)
+
+	integration.Spec.Config["secretName"] = "missing"
+	_, err = f.Client(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "failed to resolve ArgoCD credentials for edge-1")
+
+	_, err = f.Client(context.Background(), integration, "unknown")
+	assert.ErrorContains(t, err, "failed to get cluster config for unknown")
+
+	integration.Spec.Type = "linkerd"
+	_, err = f.Client(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "unsupported integration type")
+}
+
+func TestFactoryPrometheusResolvesURLPerCluster(t *testing.T) {
+	f := newTestFactory(t)
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:   ksitv1alpha1.IntegrationTypePrometheus,
+			Config: map[string]string{"url": "http://prometheus.{{ .Cluster.Name }}.example.com"},
+		},
+	}
+
+	c, err := f.Client(context.Background(), integration, "edge-1")
+	require.NoError(t, err)
+	assert.IsType(t, &PrometheusClient{}, c)
+
+	integration.Spec.Config = nil
+	_, err = f.Prometheus(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "has no url configured")
+}
+
+func TestFactoryResolvesConfigSecrets(t *testing.T) {
+	f := newTestFactory(t)
+	f.Secrets = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-auth", Namespace: "ksit-system"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t"), "server": []byte("https://argocd.example.com")},
+	}).Build()
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type: ksitv1alpha1.IntegrationTypeArgoCD,
+			ConfigSecretRefs: []ksitv1alpha1.ConfigSecretRef{
+				{Key: "token", SecretName: "argocd-auth"},
+				{Key: "serverURL", SecretName: "argocd-auth", SecretKey: "server"},
+				{Key: "caCert", SecretName: "argocd-ca", Optional: true},
+			},
+		},
+	}
+
+	argoClient, err := f.ArgoCD(context.Background(), integration, "edge-1")
+	require.NoError(t, err)
+	token, err := argoClient.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", token)
+
+	config, err := ResolveConfigSecrets(context.Background(), f.Secrets, integration, map[string]string{"namespace": "argocd"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"namespace": "argocd", "token": "s3cr3t", "serverURL": "https://argocd.example.com"}, config)
+
+	integration.Spec.ConfigSecretRefs[2].Optional = false
+	_, err = f.ArgoCD(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "failed to get secret argocd-ca for config key caCert")
+
+	integration.Spec.ConfigSecretRefs = integration.Spec.ConfigSecretRefs[:1]
+	integration.Spec.ConfigSecretRefs[0].SecretKey = "password"
+	_, err = f.ArgoCD(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "key password not found in secret argocd-auth")
+
+	f.Secrets = nil
+	_, err = f.ArgoCD(context.Background(), integration, "edge-1")
+	assert.ErrorContains(t, err, "has configSecretRefs but no client to read Secrets")
+}
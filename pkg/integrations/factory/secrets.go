@@ -0,0 +1,56 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// ResolveConfigSecrets returns a copy of config with the keys of the Integration's
+// configSecretRefs set from Secrets read through reader. Secret values are not rendered
+// as templates.
+func ResolveConfigSecrets(ctx context.Context, reader client.Reader, integration *ksitv1alpha1.Integration, config map[string]string) (map[string]string, error) {
+	refs := integration.Spec.ConfigSecretRefs
+	if len(refs) == 0 {
+		return config, nil
+	}
+	if reader == nil {
+		return nil, fmt.Errorf("integration %s has configSecretRefs but no client to read Secrets", integration.Name)
+	}
+
+	resolved := make(map[string]string, len(config)+len(refs))
+	for key, value := range config {
+		resolved[key] = value
+	}
+
+	for _, ref := range refs {
+		secretKey := ref.SecretKey
+		if secretKey == "" {
+			secretKey = ref.Key
+		}
+
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Name: ref.SecretName, Namespace: integration.Namespace}, secret); err != nil {
+			if errors.IsNotFound(err) && ref.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get secret %s for config key %s: %w", ref.SecretName, ref.Key, err)
+		}
+
+		value, ok := secret.Data[secretKey]
+		if !ok {
+			if ref.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("key %s not found in secret %s for config key %s", secretKey, ref.SecretName, ref.Key)
+		}
+		resolved[ref.Key] = string(value)
+	}
+	return resolved, nil
+}
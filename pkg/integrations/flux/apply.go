@@ -0,0 +1,151 @@
+package flux
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func gitRepositorySpec(repo *GitRepository) map[string]interface{} {
+	spec := map[string]interface{}{
+		"url":      repo.URL,
+		"interval": repo.Interval,
+		"ref": map[string]interface{}{
+			"branch": repo.Branch,
+		},
+	}
+
+	if repo.SecretRef != "" {
+		spec["secretRef"] = map[string]interface{}{
+			"name": repo.SecretRef,
+		}
+	}
+
+	return spec
+}
+
+func kustomizationSpec(ks *Kustomization) map[string]interface{} {
+	spec := map[string]interface{}{
+		"interval": ks.Interval,
+		"path":     ks.Path,
+		"prune":    ks.Prune,
+		"sourceRef": map[string]interface{}{
+			"kind": "GitRepository",
+			"name": ks.SourceRef,
+		},
+	}
+
+	if ks.TargetNamespace != "" {
+		spec["targetNamespace"] = ks.TargetNamespace
+	}
+
+	if len(ks.DependsOn) > 0 {
+		deps := make([]interface{}, 0, len(ks.DependsOn))
+		for _, dep := range ks.DependsOn {
+			deps = append(deps, map[string]interface{}{"name": dep})
+		}
+		spec["dependsOn"] = deps
+	}
+
+	return spec
+}
+
+// ApplyGitRepository creates the GitRepository, or brings the spec and labels of an
+// existing one in line with repo
+func (f *FluxClient) ApplyGitRepository(ctx context.Context, repo *GitRepository) error {
+	gitRepo, err := f.GetGitRepository(ctx, repo.Name, repo.Namespace)
+	if errors.IsNotFound(err) {
+		return f.CreateGitRepository(ctx, repo)
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.updateSpec(ctx, gitRepo, gitRepositorySpec(repo), repo.Labels)
+}
+
+// ApplyKustomization creates the Kustomization, or brings the spec and labels of an
+// existing one in line with ks
+func (f *FluxClient) ApplyKustomization(ctx context.Context, ks *Kustomization) error {
+	kustomization := &unstructured.Unstructured{}
+	kustomization.SetGroupVersionKind(kustomizationGVK)
+
+	err := f.Get(ctx, client.ObjectKey{Name: ks.Name, Namespace: ks.Namespace}, kustomization)
+	if errors.IsNotFound(err) {
+		return f.CreateKustomization(ctx, ks)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Kustomization: %w", err)
+	}
+
+	return f.updateSpec(ctx, kustomization, kustomizationSpec(ks), ks.Labels)
+}
+
+// BootstrapName names the GitRepository and root Kustomization bootstrapped for the
+// Integration namespace/name. The namespace is part of it, so Integrations with the same
+// name in different namespaces do not share them.
+func BootstrapName(namespace, name string) string {
+	return namespace + "-" + name
+}
+
+// OrphanKustomization suspends a Kustomization and turns pruning off, so Flux stops
+// reconciling it and leaves what it applied in place
+func (f *FluxClient) OrphanKustomization(ctx context.Context, name string, namespace string) error {
+	kustomization := &unstructured.Unstructured{}
+	kustomization.SetGroupVersionKind(kustomizationGVK)
+	if err := f.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, kustomization); err != nil {
+		return fmt.Errorf("failed to get Kustomization: %w", err)
+	}
+	if err := unstructured.SetNestedField(kustomization.Object, true, "spec", "suspend"); err != nil {
+		return fmt.Errorf("failed to set spec.suspend: %w", err)
+	}
+	if err := unstructured.SetNestedField(kustomization.Object, false, "spec", "prune"); err != nil {
+		return fmt.Errorf("failed to set spec.prune: %w", err)
+	}
+	if err := f.Update(ctx, kustomization); err != nil {
+		return fmt.Errorf("failed to orphan Kustomization: %w", err)
+	}
+	return nil
+}
+
+// DeleteKustomization deletes a Kustomization
+func (f *FluxClient) DeleteKustomization(ctx context.Context, name string, namespace string) error {
+	kustomization := &unstructured.Unstructured{}
+	kustomization.SetGroupVersionKind(kustomizationGVK)
+	kustomization.SetName(name)
+	kustomization.SetNamespace(namespace)
+
+	if err := f.Delete(ctx, kustomization); err != nil {
+		return fmt.Errorf("failed to delete Kustomization: %w", err)
+	}
+
+	return nil
+}
+
+// updateSpec replaces the spec of obj and adds labels, leaving fields outside spec
+// (such as status and annotations set by Flux) alone
+func (f *FluxClient) updateSpec(ctx context.Context, obj *unstructured.Unstructured, spec map[string]interface{}, labels map[string]string) error {
+	if err := unstructured.SetNestedMap(obj.Object, spec, "spec"); err != nil {
+		return fmt.Errorf("failed to set spec: %w", err)
+	}
+
+	if len(labels) > 0 {
+		merged := obj.GetLabels()
+		if merged == nil {
+			merged = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			merged[k] = v
+		}
+		obj.SetLabels(merged)
+	}
+
+	if err := f.Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update %s: %w", obj.GetKind(), err)
+	}
+
+	return nil
+}
@@ -10,6 +10,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
 )
 
 var (
@@ -38,6 +41,7 @@ type GitRepository struct {
 	Branch    string
 	Interval  string
 	SecretRef string
+	Labels    map[string]string
 }
 
 type Kustomization struct {
@@ -48,6 +52,8 @@ type Kustomization struct {
 	Interval        string
 	Prune           bool
 	TargetNamespace string
+	DependsOn       []string
+	Labels          map[string]string
 }
 
 func NewFluxClient(c client.Client, scheme *runtime.Scheme, log logr.Logger) *FluxClient {
@@ -58,6 +64,11 @@ func NewFluxClient(c client.Client, scheme *runtime.Scheme, log logr.Logger) *Fl
 	}
 }
 
+// EnsureCRDs verifies that the Flux source and kustomize CRDs are served by the cluster
+func (f *FluxClient) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(f.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeFlux)...)
+}
+
 func (f *FluxClient) GetGitRepository(ctx context.Context, name string, namespace string) (*unstructured.Unstructured, error) {
 	gitRepo := &unstructured.Unstructured{}
 	gitRepo.SetGroupVersionKind(gitRepositoryGVK)
@@ -71,26 +82,17 @@ func (f *FluxClient) GetGitRepository(ctx context.Context, name string, namespac
 }
 
 func (f *FluxClient) CreateGitRepository(ctx context.Context, repo *GitRepository) error {
+	if err := f.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	gitRepo := &unstructured.Unstructured{}
 	gitRepo.SetGroupVersionKind(gitRepositoryGVK)
 	gitRepo.SetName(repo.Name)
 	gitRepo.SetNamespace(repo.Namespace)
+	gitRepo.SetLabels(repo.Labels)
 
-	spec := map[string]interface{}{
-		"url":      repo.URL,
-		"interval": repo.Interval,
-		"ref": map[string]interface{}{
-			"branch": repo.Branch,
-		},
-	}
-
-	if repo.SecretRef != "" {
-		spec["secretRef"] = map[string]interface{}{
-			"name": repo.SecretRef,
-		}
-	}
-
-	if err := unstructured.SetNestedMap(gitRepo.Object, spec, "spec"); err != nil {
+	if err := unstructured.SetNestedMap(gitRepo.Object, gitRepositorySpec(repo), "spec"); err != nil {
 		return fmt.Errorf("failed to set spec: %w", err)
 	}
 
@@ -107,21 +109,7 @@ func (f *FluxClient) UpdateGitRepository(ctx context.Context, repo *GitRepositor
 		return err
 	}
 
-	spec := map[string]interface{}{
-		"url":      repo.URL,
-		"interval": repo.Interval,
-		"ref": map[string]interface{}{
-			"branch": repo.Branch,
-		},
-	}
-
-	if repo.SecretRef != "" {
-		spec["secretRef"] = map[string]interface{}{
-			"name": repo.SecretRef,
-		}
-	}
-
-	if err := unstructured.SetNestedMap(gitRepo.Object, spec, "spec"); err != nil {
+	if err := unstructured.SetNestedMap(gitRepo.Object, gitRepositorySpec(repo), "spec"); err != nil {
 		return fmt.Errorf("failed to set spec: %w", err)
 	}
 
@@ -146,26 +134,17 @@ func (f *FluxClient) DeleteGitRepository(ctx context.Context, name string, names
 }
 
 func (f *FluxClient) CreateKustomization(ctx context.Context, ks *Kustomization) error {
+	if err := f.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	kustomization := &unstructured.Unstructured{}
 	kustomization.SetGroupVersionKind(kustomizationGVK)
 	kustomization.SetName(ks.Name)
 	kustomization.SetNamespace(ks.Namespace)
+	kustomization.SetLabels(ks.Labels)
 
-	spec := map[string]interface{}{
-		"interval": ks.Interval,
-		"path":     ks.Path,
-		"prune":    ks.Prune,
-		"sourceRef": map[string]interface{}{
-			"kind": "GitRepository",
-			"name": ks.SourceRef,
-		},
-	}
-
-	if ks.TargetNamespace != "" {
-		spec["targetNamespace"] = ks.TargetNamespace
-	}
-
-	if err := unstructured.SetNestedMap(kustomization.Object, spec, "spec"); err != nil {
+	if err := unstructured.SetNestedMap(kustomization.Object, kustomizationSpec(ks), "spec"); err != nil {
 		return fmt.Errorf("failed to set spec: %w", err)
 	}
 
@@ -179,6 +158,10 @@ func (f *FluxClient) CreateKustomization(ctx context.Context, ks *Kustomization)
 func (f *FluxClient) ReconcileCluster(ctx context.Context, clusterName string) error {
 	f.Log.Info("reconciling flux for cluster", "cluster", clusterName)
 
+	if err := f.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	// For in-cluster mode, trigger reconciliation of all GitRepositories and Kustomizations
 	gitRepos, err := f.ListGitRepositories(ctx, "flux-system")
 	if err != nil {
@@ -0,0 +1,201 @@
+package flux
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KustomizationNode is a Kustomization and the Kustomizations it depends on
+type KustomizationNode struct {
+	Name      string
+	Namespace string
+	Ready     bool
+	Suspended bool
+	Reason    string
+	Message   string
+	DependsOn []string
+}
+
+// Key returns the namespace/name key of the node
+func (n *KustomizationNode) Key() string {
+	return n.Namespace + "/" + n.Name
+}
+
+// DependencyFailure is the first failed Kustomization in a dependsOn chain
+type DependencyFailure struct {
+	// Kustomization is the namespace/name of the failed Kustomization
+	Kustomization string
+	Reason        string
+	Message       string
+	// Blocked lists the not-ready Kustomizations that depend on the failure, sorted
+	Blocked []string
+}
+
+func (f DependencyFailure) String() string {
+	msg := fmt.Sprintf("Kustomization %s is not ready", f.Kustomization)
+	if f.Reason != "" || f.Message != "" {
+		msg += fmt.Sprintf(" (%s: %s)", f.Reason, f.Message)
+	}
+	if len(f.Blocked) > 0 {
+		msg += fmt.Sprintf("; blocking %s", strings.Join(f.Blocked, ", "))
+	}
+	return msg
+}
+
+// DependencyGraph is the dependsOn graph of the Kustomizations on a cluster
+type DependencyGraph struct {
+	nodes map[string]*KustomizationNode
+}
+
+// NewDependencyGraph builds the dependsOn graph from Kustomization objects
+func NewDependencyGraph(items []unstructured.Unstructured) *DependencyGraph {
+	g := &DependencyGraph{nodes: make(map[string]*KustomizationNode, len(items))}
+	for i := range items {
+		node := newKustomizationNode(&items[i])
+		g.nodes[node.Key()] = node
+	}
+	return g
+}
+
+func newKustomizationNode(obj *unstructured.Unstructured) *KustomizationNode {
+	node := &KustomizationNode{
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+	node.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+
+	deps, _, _ := unstructured.NestedSlice(obj.Object, "spec", "dependsOn")
+	for _, dep := range deps {
+		depMap, ok := dep.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(depMap, "name")
+		if name == "" {
+			continue
+		}
+		// dependsOn entries default to the namespace of the dependent
+		namespace, _, _ := unstructured.NestedString(depMap, "namespace")
+		if namespace == "" {
+			namespace = node.Namespace
+		}
+		node.DependsOn = append(node.DependsOn, namespace+"/"+name)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condType, _, _ := unstructured.NestedString(condMap, "type")
+		if condType != "Ready" {
+			continue
+		}
+		condStatus, _, _ := unstructured.NestedString(condMap, "status")
+		node.Ready = condStatus == "True"
+		node.Reason, _, _ = unstructured.NestedString(condMap, "reason")
+		node.Message, _, _ = unstructured.NestedString(condMap, "message")
+	}
+
+	return node
+}
+
+// failed reports whether the node counts as a failure; suspended
+// Kustomizations are left alone on purpose
+func (n *KustomizationNode) failed() bool {
+	return !n.Ready && !n.Suspended
+}
+
+// RootCauses returns the first failed Kustomization of every broken dependsOn
+// chain, together with the not-ready Kustomizations it blocks. A dependency
+// that does not exist on the cluster is reported as a root cause itself, and
+// a cycle of not-ready Kustomizations is reported at the node that closes it.
+func (g *DependencyGraph) RootCauses() []DependencyFailure {
+	roots := make(map[string]*DependencyFailure)
+	causesOf := make(map[string][]string)
+
+	var walk func(key string, path map[string]bool) []string
+	// walk returns the root causes reached from key through not-ready dependencies
+	walk = func(key string, path map[string]bool) []string {
+		node, ok := g.nodes[key]
+		if !ok {
+			if roots[key] == nil {
+				roots[key] = &DependencyFailure{
+					Kustomization: key,
+					Reason:        "NotFound",
+					Message:       "dependency does not exist",
+				}
+			}
+			return []string{key}
+		}
+		if path[key] {
+			if roots[key] == nil {
+				roots[key] = &DependencyFailure{
+					Kustomization: key,
+					Reason:        "DependencyCycle",
+					Message:       "dependsOn chain loops back to this Kustomization",
+				}
+			}
+			return []string{key}
+		}
+
+		if causes, ok := causesOf[key]; ok {
+			return causes
+		}
+
+		path[key] = true
+		defer delete(path, key)
+
+		var causes []string
+		for _, dep := range node.DependsOn {
+			if depNode, ok := g.nodes[dep]; ok && !depNode.failed() {
+				continue
+			}
+			causes = append(causes, walk(dep, path)...)
+		}
+		if len(causes) == 0 {
+			if roots[key] == nil {
+				roots[key] = &DependencyFailure{
+					Kustomization: key,
+					Reason:        node.Reason,
+					Message:       node.Message,
+				}
+			}
+			causes = []string{key}
+		}
+		causesOf[key] = causes
+		return causes
+	}
+
+	keys := make([]string, 0, len(g.nodes))
+	for key := range g.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !g.nodes[key].failed() {
+			continue
+		}
+		for _, cause := range walk(key, map[string]bool{}) {
+			if cause != key && !slices.Contains(roots[cause].Blocked, key) {
+				roots[cause].Blocked = append(roots[cause].Blocked, key)
+			}
+		}
+	}
+
+	failures := make([]DependencyFailure, 0, len(roots))
+	for _, root := range roots {
+		sort.Strings(root.Blocked)
+		failures = append(failures, *root)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Kustomization < failures[j].Kustomization
+	})
+	return failures
+}
@@ -0,0 +1,74 @@
+package flux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func kustomization(name string, ready bool, message string, dependsOn ...string) unstructured.Unstructured {
+	deps := make([]interface{}, 0, len(dependsOn))
+	for _, dep := range dependsOn {
+		deps = append(deps, map[string]interface{}{"name": dep})
+	}
+
+	status := "False"
+	reason := "ReconciliationFailed"
+	if ready {
+		status, reason = "True", "ReconciliationSucceeded"
+	}
+
+	obj := unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"dependsOn": deps},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": status, "reason": reason, "message": message},
+			},
+		},
+	}}
+	obj.SetGroupVersionKind(kustomizationGVK)
+	obj.SetName(name)
+	obj.SetNamespace("flux-system")
+	return obj
+}
+
+func TestRootCausesFollowsDependencyChain(t *testing.T) {
+	g := NewDependencyGraph([]unstructured.Unstructured{
+		kustomization("sources", true, ""),
+		kustomization("infra", false, "kustomize build failed", "sources"),
+		kustomization("config", false, "dependency 'flux-system/infra' is not ready", "infra"),
+		kustomization("apps", false, "dependency 'flux-system/config' is not ready", "config", "sources"),
+	})
+
+	failures := g.RootCauses()
+	require.Len(t, failures, 1)
+	assert.Equal(t, "flux-system/infra", failures[0].Kustomization)
+	assert.Equal(t, "kustomize build failed", failures[0].Message)
+	assert.Equal(t, []string{"flux-system/apps", "flux-system/config"}, failures[0].Blocked)
+	assert.Equal(t, "Kustomization flux-system/infra is not ready (ReconciliationFailed: kustomize build failed); blocking flux-system/apps, flux-system/config", failures[0].String())
+}
+
+func TestRootCausesMissingDependencyAndCycle(t *testing.T) {
+	suspended := kustomization("paused", false, "")
+	require.NoError(t, unstructured.SetNestedField(suspended.Object, true, "spec", "suspend"))
+
+	g := NewDependencyGraph([]unstructured.Unstructured{
+		kustomization("apps", false, "", "missing"),
+		kustomization("a", false, "", "b"),
+		kustomization("b", false, "", "a"),
+		suspended,
+	})
+
+	failures := g.RootCauses()
+	require.Len(t, failures, 2)
+	assert.Equal(t, "flux-system/a", failures[0].Kustomization)
+	assert.Equal(t, "DependencyCycle", failures[0].Reason)
+	assert.Equal(t, []string{"flux-system/b"}, failures[0].Blocked)
+	assert.Equal(t, "flux-system/missing", failures[1].Kustomization)
+	assert.Equal(t, "NotFound", failures[1].Reason)
+	assert.Equal(t, []string{"flux-system/apps"}, failures[1].Blocked)
+
+	assert.Empty(t, NewDependencyGraph([]unstructured.Unstructured{kustomization("apps", true, "")}).RootCauses())
+}
@@ -0,0 +1,331 @@
+package flux
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+var (
+	ociRepositoryGVK = schema.GroupVersionKind{
+		Group:   "source.toolkit.fluxcd.io",
+		Version: "v1beta2",
+		Kind:    "OCIRepository",
+	}
+	helmRepositoryGVK = schema.GroupVersionKind{
+		Group:   "source.toolkit.fluxcd.io",
+		Version: "v1",
+		Kind:    "HelmRepository",
+	}
+)
+
+// OCIRepository is an OCI artifact, such as manifests pushed with `flux push artifact`,
+// that Kustomizations can apply
+type OCIRepository struct {
+	Name      string
+	Namespace string
+	// URL is the artifact's repository, e.g. oci://ghcr.io/org/manifests
+	URL string
+	// Tag is pulled unless SemVer is set
+	Tag string
+	// SemVer pulls the highest tag in the range
+	SemVer    string
+	Interval  string
+	SecretRef string
+	Labels    map[string]string
+}
+
+// HelmRepository is a chart repository, served over HTTP or from an OCI registry
+type HelmRepository struct {
+	Name      string
+	Namespace string
+	URL       string
+	// OCI marks URL as an OCI registry rather than an index.yaml repository
+	OCI       bool
+	Interval  string
+	SecretRef string
+	Labels    map[string]string
+}
+
+// HelmRelease installs a chart from a HelmRepository and keeps it upgraded
+type HelmRelease struct {
+	Name      string
+	Namespace string
+	Chart     string
+	// Version is a version or semver range; empty means the latest
+	Version string
+	// SourceRef is the name of the HelmRepository in the same namespace
+	SourceRef       string
+	Interval        string
+	TargetNamespace string
+	Values          map[string]interface{}
+	DependsOn       []string
+	Labels          map[string]string
+}
+
+func ociRepositorySpec(repo *OCIRepository) map[string]interface{} {
+	ref := map[string]interface{}{}
+	if repo.SemVer != "" {
+		ref["semver"] = repo.SemVer
+	} else if repo.Tag != "" {
+		ref["tag"] = repo.Tag
+	}
+
+	spec := map[string]interface{}{
+		"url":      repo.URL,
+		"interval": repo.Interval,
+		"ref":      ref,
+	}
+
+	if repo.SecretRef != "" {
+		spec["secretRef"] = map[string]interface{}{
+			"name": repo.SecretRef,
+		}
+	}
+
+	return spec
+}
+
+func helmRepositorySpec(repo *HelmRepository) map[string]interface{} {
+	spec := map[string]interface{}{
+		"url":      repo.URL,
+		"interval": repo.Interval,
+	}
+
+	if repo.OCI {
+		spec["type"] = "oci"
+	}
+
+	if repo.SecretRef != "" {
+		spec["secretRef"] = map[string]interface{}{
+			"name": repo.SecretRef,
+		}
+	}
+
+	return spec
+}
+
+func helmReleaseSpec(release *HelmRelease) (map[string]interface{}, error) {
+	chartSpec := map[string]interface{}{
+		"chart": release.Chart,
+		"sourceRef": map[string]interface{}{
+			"kind": "HelmRepository",
+			"name": release.SourceRef,
+		},
+	}
+	if release.Version != "" {
+		chartSpec["version"] = release.Version
+	}
+
+	spec := map[string]interface{}{
+		"interval": release.Interval,
+		"chart": map[string]interface{}{
+			"spec": chartSpec,
+		},
+	}
+
+	if release.TargetNamespace != "" {
+		spec["targetNamespace"] = release.TargetNamespace
+	}
+
+	if len(release.Values) > 0 {
+		values, err := jsonValues(release.Values)
+		if err != nil {
+			return nil, err
+		}
+		spec["values"] = values
+	}
+
+	if len(release.DependsOn) > 0 {
+		deps := make([]interface{}, 0, len(release.DependsOn))
+		for _, dep := range release.DependsOn {
+			deps = append(deps, map[string]interface{}{"name": dep})
+		}
+		spec["dependsOn"] = deps
+	}
+
+	return spec, nil
+}
+
+// jsonValues converts Helm values to the JSON types that unstructured objects hold
+func jsonValues(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values: %w", err)
+	}
+
+	var converted map[string]interface{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil, fmt.Errorf("failed to decode values: %w", err)
+	}
+	return converted, nil
+}
+
+// CreateOCIRepository creates an OCIRepository
+func (f *FluxClient) CreateOCIRepository(ctx context.Context, repo *OCIRepository) error {
+	return f.create(ctx, ociRepositoryGVK, repo.Name, repo.Namespace, ociRepositorySpec(repo), repo.Labels)
+}
+
+// ApplyOCIRepository creates the OCIRepository, or brings the spec and labels of an
+// existing one in line with repo
+func (f *FluxClient) ApplyOCIRepository(ctx context.Context, repo *OCIRepository) error {
+	return f.apply(ctx, ociRepositoryGVK, repo.Name, repo.Namespace, ociRepositorySpec(repo), repo.Labels)
+}
+
+// GetOCIRepository returns an OCIRepository
+func (f *FluxClient) GetOCIRepository(ctx context.Context, name, namespace string) (*unstructured.Unstructured, error) {
+	return f.get(ctx, ociRepositoryGVK, name, namespace)
+}
+
+// DeleteOCIRepository deletes an OCIRepository
+func (f *FluxClient) DeleteOCIRepository(ctx context.Context, name, namespace string) error {
+	return f.delete(ctx, ociRepositoryGVK, name, namespace)
+}
+
+// GetOCIRepositoryStatus retrieves the status of an OCIRepository
+func (f *FluxClient) GetOCIRepositoryStatus(ctx context.Context, name, namespace string) (*SyncStatus, error) {
+	return f.getStatus(ctx, ociRepositoryGVK, name, namespace)
+}
+
+// WaitForOCIRepositoryReady waits for an OCIRepository to become ready
+func (f *FluxClient) WaitForOCIRepositoryReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	return f.waitForReady(ctx, ociRepositoryGVK, name, namespace, timeout)
+}
+
+// CreateHelmRepository creates a HelmRepository
+func (f *FluxClient) CreateHelmRepository(ctx context.Context, repo *HelmRepository) error {
+	return f.create(ctx, helmRepositoryGVK, repo.Name, repo.Namespace, helmRepositorySpec(repo), repo.Labels)
+}
+
+// ApplyHelmRepository creates the HelmRepository, or brings the spec and labels of an
+// existing one in line with repo
+func (f *FluxClient) ApplyHelmRepository(ctx context.Context, repo *HelmRepository) error {
+	return f.apply(ctx, helmRepositoryGVK, repo.Name, repo.Namespace, helmRepositorySpec(repo), repo.Labels)
+}
+
+// GetHelmRepository returns a HelmRepository
+func (f *FluxClient) GetHelmRepository(ctx context.Context, name, namespace string) (*unstructured.Unstructured, error) {
+	return f.get(ctx, helmRepositoryGVK, name, namespace)
+}
+
+// DeleteHelmRepository deletes a HelmRepository
+func (f *FluxClient) DeleteHelmRepository(ctx context.Context, name, namespace string) error {
+	return f.delete(ctx, helmRepositoryGVK, name, namespace)
+}
+
+// GetHelmRepositoryStatus retrieves the status of a HelmRepository
+func (f *FluxClient) GetHelmRepositoryStatus(ctx context.Context, name, namespace string) (*SyncStatus, error) {
+	return f.getStatus(ctx, helmRepositoryGVK, name, namespace)
+}
+
+// WaitForHelmRepositoryReady waits for a HelmRepository to become ready
+func (f *FluxClient) WaitForHelmRepositoryReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	return f.waitForReady(ctx, helmRepositoryGVK, name, namespace, timeout)
+}
+
+// CreateHelmRelease creates a HelmRelease
+func (f *FluxClient) CreateHelmRelease(ctx context.Context, release *HelmRelease) error {
+	spec, err := helmReleaseSpec(release)
+	if err != nil {
+		return err
+	}
+	return f.create(ctx, helmReleaseGVK, release.Name, release.Namespace, spec, release.Labels)
+}
+
+// ApplyHelmRelease creates the HelmRelease, or brings the spec and labels of an existing
+// one in line with release
+func (f *FluxClient) ApplyHelmRelease(ctx context.Context, release *HelmRelease) error {
+	spec, err := helmReleaseSpec(release)
+	if err != nil {
+		return err
+	}
+	return f.apply(ctx, helmReleaseGVK, release.Name, release.Namespace, spec, release.Labels)
+}
+
+// GetHelmRelease returns a HelmRelease
+func (f *FluxClient) GetHelmRelease(ctx context.Context, name, namespace string) (*unstructured.Unstructured, error) {
+	return f.get(ctx, helmReleaseGVK, name, namespace)
+}
+
+// DeleteHelmRelease deletes a HelmRelease
+func (f *FluxClient) DeleteHelmRelease(ctx context.Context, name, namespace string) error {
+	return f.delete(ctx, helmReleaseGVK, name, namespace)
+}
+
+// GetHelmReleaseStatus retrieves the status of a HelmRelease
+func (f *FluxClient) GetHelmReleaseStatus(ctx context.Context, name, namespace string) (*SyncStatus, error) {
+	return f.getStatus(ctx, helmReleaseGVK, name, namespace)
+}
+
+// WaitForHelmReleaseReady waits for a HelmRelease to become ready
+func (f *FluxClient) WaitForHelmReleaseReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	return f.waitForReady(ctx, helmReleaseGVK, name, namespace, timeout)
+}
+
+// create creates a Flux object of kind gvk once its CRD is known to be served
+func (f *FluxClient) create(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string, spec map[string]interface{}, labels map[string]string) error {
+	if err := crds.EnsureCRDs(f.RESTMapper(), gvk); err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+
+	if err := unstructured.SetNestedMap(obj.Object, spec, "spec"); err != nil {
+		return fmt.Errorf("failed to set spec: %w", err)
+	}
+
+	if err := f.Create(ctx, obj); err != nil {
+		return fmt.Errorf("failed to create %s: %w", gvk.Kind, err)
+	}
+
+	return nil
+}
+
+// apply creates a Flux object of kind gvk, or updates the spec and labels of an existing one
+func (f *FluxClient) apply(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string, spec map[string]interface{}, labels map[string]string) error {
+	obj, err := f.get(ctx, gvk, name, namespace)
+	if errors.IsNotFound(err) {
+		return f.create(ctx, gvk, name, namespace, spec, labels)
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.updateSpec(ctx, obj, spec, labels)
+}
+
+func (f *FluxClient) get(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	if err := f.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", gvk.Kind, err)
+	}
+
+	return obj, nil
+}
+
+func (f *FluxClient) delete(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	if err := f.Delete(ctx, obj); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", gvk.Kind, err)
+	}
+
+	return nil
+}
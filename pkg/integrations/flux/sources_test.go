@@ -0,0 +1,132 @@
+package flux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+func newSourcesClient() *FluxClient {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor("flux") {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	mapper.Add(ociRepositoryGVK, meta.RESTScopeNamespace)
+	mapper.Add(helmRepositoryGVK, meta.RESTScopeNamespace)
+	mapper.Add(helmReleaseGVK, meta.RESTScopeNamespace)
+
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+	return NewFluxClient(c, scheme.Scheme, logr.Discard())
+}
+
+func TestOCIRepository(t *testing.T) {
+	ctx := context.Background()
+	f := newSourcesClient()
+
+	repo := &OCIRepository{
+		Name:      "manifests",
+		Namespace: "flux-system",
+		URL:       "oci://ghcr.io/org/manifests",
+		Tag:       "latest",
+		SemVer:    ">=1.0.0",
+		Interval:  "5m",
+		SecretRef: "ghcr-auth",
+	}
+	require.NoError(t, f.CreateOCIRepository(ctx, repo))
+
+	obj, err := f.GetOCIRepository(ctx, "manifests", "flux-system")
+	require.NoError(t, err)
+	ref, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "ref")
+	assert.Equal(t, map[string]string{"semver": ">=1.0.0"}, ref)
+	secretRef, _, _ := unstructured.NestedString(obj.Object, "spec", "secretRef", "name")
+	assert.Equal(t, "ghcr-auth", secretRef)
+
+	repo.SemVer = ""
+	require.NoError(t, f.ApplyOCIRepository(ctx, repo))
+	obj, err = f.GetOCIRepository(ctx, "manifests", "flux-system")
+	require.NoError(t, err)
+	ref, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "ref")
+	assert.Equal(t, map[string]string{"tag": "latest"}, ref)
+
+	require.NoError(t, f.DeleteOCIRepository(ctx, "manifests", "flux-system"))
+	_, err = f.GetOCIRepository(ctx, "manifests", "flux-system")
+	assert.Error(t, err)
+}
+
+func TestHelmRelease(t *testing.T) {
+	ctx := context.Background()
+	f := newSourcesClient()
+
+	require.NoError(t, f.CreateHelmRepository(ctx, &HelmRepository{
+		Name:      "podinfo",
+		Namespace: "flux-system",
+		URL:       "oci://ghcr.io/stefanprodan/charts",
+		OCI:       true,
+		Interval:  "1h",
+	}))
+	repo, err := f.GetHelmRepository(ctx, "podinfo", "flux-system")
+	require.NoError(t, err)
+	repoType, _, _ := unstructured.NestedString(repo.Object, "spec", "type")
+	assert.Equal(t, "oci", repoType)
+
+	require.NoError(t, f.ApplyHelmRelease(ctx, &HelmRelease{
+		Name:      "podinfo",
+		Namespace: "flux-system",
+		Chart:     "podinfo",
+		Version:   "6.x",
+		SourceRef: "podinfo",
+		Interval:  "10m",
+		Values:    map[string]interface{}{"replicaCount": 2, "ingress": map[string]interface{}{"hosts": []string{"podinfo.example.com"}}},
+		DependsOn: []string{"cert-manager"},
+	}))
+
+	release, err := f.GetHelmRelease(ctx, "podinfo", "flux-system")
+	require.NoError(t, err)
+	assert.Equal(t, "HelmRelease", release.GetKind())
+	version, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "version")
+	assert.Equal(t, "6.x", version)
+	sourceKind, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "sourceRef", "kind")
+	assert.Equal(t, "HelmRepository", sourceKind)
+	replicas, _, _ := unstructured.NestedFieldNoCopy(release.Object, "spec", "values", "replicaCount")
+	assert.EqualValues(t, 2, replicas)
+	hosts, _, _ := unstructured.NestedStringSlice(release.Object, "spec", "values", "ingress", "hosts")
+	assert.Equal(t, []string{"podinfo.example.com"}, hosts)
+
+	status, err := f.GetHelmReleaseStatus(ctx, "podinfo", "flux-system")
+	require.NoError(t, err)
+	assert.False(t, status.Ready)
+
+	// The release is ready once helm-controller reports it
+	require.NoError(t, unstructured.SetNestedSlice(release.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": "True", "reason": "InstallSucceeded", "message": "Helm install succeeded"},
+	}, "status", "conditions"))
+	require.NoError(t, f.Update(ctx, release))
+
+	status, err = f.GetHelmReleaseStatus(ctx, "podinfo", "flux-system")
+	require.NoError(t, err)
+	assert.True(t, status.Ready)
+	assert.Equal(t, "Helm install succeeded", status.Message)
+	require.Len(t, status.Conditions, 1)
+	assert.Equal(t, "InstallSucceeded", status.Conditions[0].Reason)
+
+	require.NoError(t, f.DeleteHelmRelease(ctx, "podinfo", "flux-system"))
+	require.NoError(t, f.DeleteHelmRepository(ctx, "podinfo", "flux-system"))
+}
+
+func TestCreateRequiresServedCRD(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).Build()
+	f := NewFluxClient(c, scheme.Scheme, logr.Discard())
+
+	err := f.CreateHelmRepository(context.Background(), &HelmRepository{Name: "podinfo", Namespace: "flux-system"})
+	assert.True(t, crds.IsMissingCRDs(err))
+}
@@ -14,7 +14,7 @@ import (
 var (
 	helmReleaseGVK = schema.GroupVersionKind{
 		Group:   "helm.toolkit.fluxcd.io",
-		Version: "v2beta1",
+		Version: "v2",
 		Kind:    "HelmRelease",
 	}
 )
@@ -90,59 +90,30 @@ func (f *FluxClient) SyncKustomization(ctx context.Context, name, namespace stri
 
 // GetGitRepositoryStatus retrieves the status of a GitRepository
 func (f *FluxClient) GetGitRepositoryStatus(ctx context.Context, name, namespace string) (*SyncStatus, error) {
-	gitRepo, err := f.GetGitRepository(ctx, name, namespace)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get GitRepository: %w", err)
-	}
-
-	status := &SyncStatus{
-		LastUpdate: time.Now(),
-		Conditions: []Condition{},
-	}
-
-	// Extract status from the resource
-	statusMap, found, err := unstructured.NestedMap(gitRepo.Object, "status")
-	if err != nil || !found {
-		return status, nil
-	}
-
-	// Check ready condition
-	conditions, found, err := unstructured.NestedSlice(statusMap, "conditions")
-	if err == nil && found {
-		for _, cond := range conditions {
-			condMap, ok := cond.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			condType, _, _ := unstructured.NestedString(condMap, "type")
-			condStatus, _, _ := unstructured.NestedString(condMap, "status")
-			reason, _, _ := unstructured.NestedString(condMap, "reason")
-			message, _, _ := unstructured.NestedString(condMap, "message")
-
-			if condType == "Ready" && condStatus == "True" {
-				status.Ready = true
-			}
-
-			status.Conditions = append(status.Conditions, Condition{
-				Type:    condType,
-				Status:  condStatus,
-				Reason:  reason,
-				Message: message,
-			})
-		}
-	}
-
-	return status, nil
+	return f.getStatus(ctx, gitRepositoryGVK, name, namespace)
 }
 
 // GetKustomizationStatus retrieves the status of a Kustomization
 func (f *FluxClient) GetKustomizationStatus(ctx context.Context, name, namespace string) (*SyncStatus, error) {
-	kustomization := &unstructured.Unstructured{}
-	kustomization.SetGroupVersionKind(kustomizationGVK)
+	return f.getStatus(ctx, kustomizationGVK, name, namespace)
+}
 
-	if err := f.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, kustomization); err != nil {
-		return nil, fmt.Errorf("failed to get Kustomization: %w", err)
+// WaitForGitRepositoryReady waits for a GitRepository to become ready
+func (f *FluxClient) WaitForGitRepositoryReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	return f.waitForReady(ctx, gitRepositoryGVK, name, namespace, timeout)
+}
+
+// WaitForKustomizationReady waits for a Kustomization to become ready
+func (f *FluxClient) WaitForKustomizationReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
+	return f.waitForReady(ctx, kustomizationGVK, name, namespace, timeout)
+}
+
+// getStatus reads the conditions of a Flux object of kind gvk. The object is ready when
+// its Ready condition is True.
+func (f *FluxClient) getStatus(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string) (*SyncStatus, error) {
+	obj, err := f.get(ctx, gvk, name, namespace)
+	if err != nil {
+		return nil, err
 	}
 
 	status := &SyncStatus{
@@ -150,77 +121,41 @@ func (f *FluxClient) GetKustomizationStatus(ctx context.Context, name, namespace
 		Conditions: []Condition{},
 	}
 
-	statusMap, found, err := unstructured.NestedMap(kustomization.Object, "status")
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
 	if err != nil || !found {
 		return status, nil
 	}
 
-	conditions, found, err := unstructured.NestedSlice(statusMap, "conditions")
-	if err == nil && found {
-		for _, cond := range conditions {
-			condMap, ok := cond.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			condType, _, _ := unstructured.NestedString(condMap, "type")
-			condStatus, _, _ := unstructured.NestedString(condMap, "status")
-			reason, _, _ := unstructured.NestedString(condMap, "reason")
-			message, _, _ := unstructured.NestedString(condMap, "message")
-
-			if condType == "Ready" && condStatus == "True" {
-				status.Ready = true
-			}
-
-			status.Conditions = append(status.Conditions, Condition{
-				Type:    condType,
-				Status:  condStatus,
-				Reason:  reason,
-				Message: message,
-			})
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
 		}
-	}
-
-	return status, nil
-}
 
-// WaitForGitRepositoryReady waits for a GitRepository to become ready
-func (f *FluxClient) WaitForGitRepositoryReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
-	f.Log.Info("waiting for GitRepository to be ready", "name", name, "timeout", timeout)
+		condType, _, _ := unstructured.NestedString(condMap, "type")
+		condStatus, _, _ := unstructured.NestedString(condMap, "status")
+		reason, _, _ := unstructured.NestedString(condMap, "reason")
+		message, _, _ := unstructured.NestedString(condMap, "message")
 
-	deadline := time.Now().Add(timeout)
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for time.Now().Before(deadline) {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			status, err := f.GetGitRepositoryStatus(ctx, name, namespace)
-			if err != nil {
-				if errors.IsNotFound(err) {
-					f.Log.Info("GitRepository not found yet", "name", name)
-					continue
-				}
-				return err
-			}
-
-			if status.Ready {
-				f.Log.Info("GitRepository is ready", "name", name)
-				return nil
-			}
-
-			f.Log.Info("GitRepository not ready yet", "name", name, "message", status.Message)
+		if condType == "Ready" {
+			status.Ready = condStatus == "True"
+			status.Message = message
 		}
+
+		status.Conditions = append(status.Conditions, Condition{
+			Type:    condType,
+			Status:  condStatus,
+			Reason:  reason,
+			Message: message,
+		})
 	}
 
-	return fmt.Errorf("timeout waiting for GitRepository %s to be ready", name)
+	return status, nil
 }
 
-// WaitForKustomizationReady waits for a Kustomization to become ready
-func (f *FluxClient) WaitForKustomizationReady(ctx context.Context, name, namespace string, timeout time.Duration) error {
-	f.Log.Info("waiting for Kustomization to be ready", "name", name, "timeout", timeout)
+// waitForReady polls a Flux object of kind gvk until it is ready or timeout passes
+func (f *FluxClient) waitForReady(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string, timeout time.Duration) error {
+	f.Log.Info("waiting for "+gvk.Kind+" to be ready", "name", name, "timeout", timeout)
 
 	deadline := time.Now().Add(timeout)
 	ticker := time.NewTicker(5 * time.Second)
@@ -231,25 +166,25 @@ func (f *FluxClient) WaitForKustomizationReady(ctx context.Context, name, namesp
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			status, err := f.GetKustomizationStatus(ctx, name, namespace)
+			status, err := f.getStatus(ctx, gvk, name, namespace)
 			if err != nil {
 				if errors.IsNotFound(err) {
-					f.Log.Info("Kustomization not found yet", "name", name)
+					f.Log.Info(gvk.Kind+" not found yet", "name", name)
 					continue
 				}
 				return err
 			}
 
 			if status.Ready {
-				f.Log.Info("Kustomization is ready", "name", name)
+				f.Log.Info(gvk.Kind+" is ready", "name", name)
 				return nil
 			}
 
-			f.Log.Info("Kustomization not ready yet", "name", name, "message", status.Message)
+			f.Log.Info(gvk.Kind+" not ready yet", "name", name, "message", status.Message)
 		}
 	}
 
-	return fmt.Errorf("timeout waiting for Kustomization %s to be ready", name)
+	return fmt.Errorf("timeout waiting for %s %s to be ready", gvk.Kind, name)
 }
 
 // SuspendKustomization suspends a Kustomization
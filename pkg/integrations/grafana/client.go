@@ -0,0 +1,327 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNotFound is returned when Grafana has no datasource or dashboard with the requested uid
+var ErrNotFound = errors.New("not found")
+
+// Client talks to the Grafana HTTP API of one Grafana instance
+type Client struct {
+	client.Client
+	url        string
+	token      string
+	httpClient *http.Client
+	namespace  string
+	secretName string
+	secretKey  string
+}
+
+// NewClient creates a Grafana client. Requests authenticate with config["token"], or with
+// the credentials in config["secretName"]: a service account token under
+// config["secretKey"], or the admin-user and admin-password keys of the Grafana chart.
+func NewClient(c client.Client, config map[string]string) (*Client, error) {
+	serverURL := config["url"]
+	if serverURL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	namespace := config["namespace"]
+	if namespace == "" {
+		namespace = "grafana"
+	}
+
+	return &Client{
+		Client:     c,
+		url:        strings.TrimSuffix(serverURL, "/"),
+		token:      config["token"],
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		namespace:  namespace,
+		secretName: config["secretName"],
+		secretKey:  config["secretKey"],
+	}, nil
+}
+
+// EnsureCRDs is a no-op; Grafana does not rely on custom resources
+func (c *Client) EnsureCRDs(ctx context.Context) error {
+	return nil
+}
+
+// credentials holds either a bearer token or basic auth credentials
+type credentials struct {
+	token    string
+	user     string
+	password string
+}
+
+func (c *Client) credentials(ctx context.Context) (credentials, error) {
+	if c.token != "" {
+		return credentials{token: c.token}, nil
+	}
+	if c.secretName == "" {
+		return credentials{}, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: c.secretName, Namespace: c.namespace}, secret); err != nil {
+		return credentials{}, fmt.Errorf("failed to get secret %s: %w", c.secretName, err)
+	}
+
+	if c.secretKey != "" {
+		token, ok := secret.Data[c.secretKey]
+		if !ok {
+			return credentials{}, fmt.Errorf("key %s not found in secret %s", c.secretKey, c.secretName)
+		}
+		return credentials{token: string(token)}, nil
+	}
+
+	user, password := secret.Data["admin-user"], secret.Data["admin-password"]
+	if len(user) == 0 || len(password) == 0 {
+		return credentials{}, fmt.Errorf("secret %s has no admin-user and admin-password", c.secretName)
+	}
+	return credentials{user: string(user), password: string(password)}, nil
+}
+
+// Validate resolves the credentials, so a missing Secret is reported before the first request
+func (c *Client) Validate(ctx context.Context) error {
+	_, err := c.credentials(ctx)
+	return err
+}
+
+// do sends a request to the Grafana API and decodes a JSON response into out, if set
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case creds.token != "":
+		req.Header.Set("Authorization", "Bearer "+creds.token)
+	case creds.user != "":
+		req.SetBasicAuth(creds.user, creds.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s failed, status: %d, body: %s", method, path, resp.StatusCode, string(data))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// Health reports the state of the Grafana server and its database
+type Health struct {
+	Database string `json:"database"`
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+}
+
+// HealthCheck returns an error unless Grafana answers and its database is ok.
+// The health endpoint needs no credentials.
+func (c *Client) HealthCheck(ctx context.Context) (*Health, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/api/health", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || health.Database != "ok" {
+		return &health, fmt.Errorf("health check failed with status: %d, database: %s", resp.StatusCode, health.Database)
+	}
+	return &health, nil
+}
+
+// Datasource is a Grafana datasource
+type Datasource struct {
+	ID        int64                  `json:"id,omitempty"`
+	UID       string                 `json:"uid"`
+	Name      string                 `json:"name"`
+	Type      string                 `json:"type"`
+	URL       string                 `json:"url"`
+	Access    string                 `json:"access,omitempty"`
+	IsDefault bool                   `json:"isDefault"`
+	JSONData  map[string]interface{} `json:"jsonData,omitempty"`
+}
+
+// ListDatasources returns all datasources of the organization
+func (c *Client) ListDatasources(ctx context.Context) ([]Datasource, error) {
+	var datasources []Datasource
+	if err := c.do(ctx, http.MethodGet, "/api/datasources", nil, &datasources); err != nil {
+		return nil, err
+	}
+	return datasources, nil
+}
+
+// GetDatasource returns the datasource with the given uid, or ErrNotFound
+func (c *Client) GetDatasource(ctx context.Context, uid string) (*Datasource, error) {
+	var datasource Datasource
+	if err := c.do(ctx, http.MethodGet, "/api/datasources/uid/"+url.PathEscape(uid), nil, &datasource); err != nil {
+		return nil, err
+	}
+	return &datasource, nil
+}
+
+// ApplyDatasource creates the datasource, or updates the datasource with the same uid
+func (c *Client) ApplyDatasource(ctx context.Context, datasource Datasource) error {
+	if datasource.UID == "" {
+		return fmt.Errorf("datasource %s has no uid", datasource.Name)
+	}
+	if datasource.Access == "" {
+		datasource.Access = "proxy"
+	}
+
+	existing, err := c.GetDatasource(ctx, datasource.UID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		datasource.ID = 0
+		if err := c.do(ctx, http.MethodPost, "/api/datasources", datasource, nil); err != nil {
+			return fmt.Errorf("failed to create datasource %s: %w", datasource.UID, err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get datasource %s: %w", datasource.UID, err)
+	}
+
+	datasource.ID = existing.ID
+	if err := c.do(ctx, http.MethodPut, "/api/datasources/uid/"+url.PathEscape(datasource.UID), datasource, nil); err != nil {
+		return fmt.Errorf("failed to update datasource %s: %w", datasource.UID, err)
+	}
+	return nil
+}
+
+// DeleteDatasource deletes the datasource with the given uid. Missing datasources are ignored.
+func (c *Client) DeleteDatasource(ctx context.Context, uid string) error {
+	err := c.do(ctx, http.MethodDelete, "/api/datasources/uid/"+url.PathEscape(uid), nil, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete datasource %s: %w", uid, err)
+	}
+	return nil
+}
+
+// DashboardHit is a dashboard as listed by the search API
+type DashboardHit struct {
+	UID         string   `json:"uid"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	FolderUID   string   `json:"folderUid,omitempty"`
+	FolderTitle string   `json:"folderTitle,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// SearchDashboards returns the dashboards whose title matches query; an empty query
+// returns all dashboards
+func (c *Client) SearchDashboards(ctx context.Context, query string) ([]DashboardHit, error) {
+	q := url.Values{"type": {"dash-db"}}
+	if query != "" {
+		q.Set("query", query)
+	}
+	var hits []DashboardHit
+	if err := c.do(ctx, http.MethodGet, "/api/search?"+q.Encode(), nil, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// GetDashboard returns the model of the dashboard with the given uid, or ErrNotFound
+func (c *Client) GetDashboard(ctx context.Context, uid string) (map[string]interface{}, error) {
+	var result struct {
+		Dashboard map[string]interface{} `json:"dashboard"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/dashboards/uid/"+url.PathEscape(uid), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Dashboard, nil
+}
+
+// ApplyDashboard creates or overwrites a dashboard. The model must carry a uid, so the
+// dashboard keeps its identity across updates. folderUID may be empty for the General folder.
+func (c *Client) ApplyDashboard(ctx context.Context, dashboard map[string]interface{}, folderUID string) error {
+	uid, _ := dashboard["uid"].(string)
+	if uid == "" {
+		return fmt.Errorf("dashboard has no uid")
+	}
+
+	model := make(map[string]interface{}, len(dashboard))
+	for key, value := range dashboard {
+		model[key] = value
+	}
+	// Grafana matches dashboards by id before uid; ids differ between instances
+	delete(model, "id")
+
+	body := map[string]interface{}{
+		"dashboard": model,
+		"overwrite": true,
+		"message":   "Updated by KSIT",
+	}
+	if folderUID != "" {
+		body["folderUid"] = folderUID
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/dashboards/db", body, nil); err != nil {
+		return fmt.Errorf("failed to apply dashboard %s: %w", uid, err)
+	}
+	return nil
+}
+
+// DeleteDashboard deletes the dashboard with the given uid. Missing dashboards are ignored.
+func (c *Client) DeleteDashboard(ctx context.Context, uid string) error {
+	err := c.do(ctx, http.MethodDelete, "/api/dashboards/uid/"+url.PathEscape(uid), nil, nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete dashboard %s: %w", uid, err)
+	}
+	return nil
+}
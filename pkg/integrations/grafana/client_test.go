@@ -0,0 +1,109 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHealthCheck(t *testing.T) {
+	database := "ok"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"database":%q,"version":"10.2.2"}`, database)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"url": server.URL + "/"})
+	require.NoError(t, err)
+
+	health, err := c.HealthCheck(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "10.2.2", health.Version)
+
+	database = "failing"
+	_, err = c.HealthCheck(context.Background())
+	assert.EqualError(t, err, "health check failed with status: 200, database: failing")
+}
+
+func TestApplyDatasource(t *testing.T) {
+	var requests []string
+	var created Datasource
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		user, password, _ := r.BasicAuth()
+		if user != "admin" || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && created.UID == "":
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet:
+			created.ID = 7
+			_ = json.NewEncoder(w).Encode(created)
+		default:
+			_ = json.NewDecoder(r.Body).Decode(&created)
+		}
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafana", Namespace: "monitoring"},
+		Data:       map[string][]byte{"admin-user": []byte("admin"), "admin-password": []byte("s3cret")},
+	}
+	kube := fake.NewClientBuilder().WithObjects(secret).Build()
+	c, err := NewClient(kube, map[string]string{"url": server.URL, "namespace": "monitoring", "secretName": "grafana"})
+	require.NoError(t, err)
+
+	datasource := Datasource{UID: "prometheus", Name: "Prometheus", Type: "prometheus", URL: "http://prometheus:9090"}
+	require.NoError(t, c.ApplyDatasource(context.Background(), datasource))
+	require.NoError(t, c.ApplyDatasource(context.Background(), datasource))
+
+	// The first apply creates the datasource, the second updates it by uid
+	assert.Equal(t, []string{
+		"GET /api/datasources/uid/prometheus",
+		"POST /api/datasources",
+		"GET /api/datasources/uid/prometheus",
+		"PUT /api/datasources/uid/prometheus",
+	}, requests)
+	assert.Equal(t, int64(7), created.ID)
+	assert.Equal(t, "proxy", created.Access)
+}
+
+func TestApplyDashboard(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer glsa_token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/dashboards/db":
+			_ = json.NewDecoder(r.Body).Decode(&body)
+		case "/api/dashboards/uid/gone":
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(nil, map[string]string{"url": server.URL, "token": "glsa_token"})
+	require.NoError(t, err)
+
+	err = c.ApplyDashboard(context.Background(), map[string]interface{}{"title": "Fleet"}, "")
+	assert.EqualError(t, err, "dashboard has no uid")
+
+	dashboard := map[string]interface{}{"id": float64(3), "uid": "fleet", "title": "Fleet"}
+	require.NoError(t, c.ApplyDashboard(context.Background(), dashboard, "ksit"))
+	assert.Equal(t, map[string]interface{}{"uid": "fleet", "title": "Fleet"}, body["dashboard"])
+	assert.Equal(t, "ksit", body["folderUid"])
+	assert.Equal(t, true, body["overwrite"])
+
+	// Deleting a dashboard that is already gone is not an error
+	assert.NoError(t, c.DeleteDashboard(context.Background(), "gone"))
+}
@@ -8,6 +8,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
 )
 
 var (
@@ -63,6 +66,11 @@ func NewClientWithConfig(config *rest.Config, namespace string) (*Client, error)
 	}, nil
 }
 
+// EnsureCRDs verifies that the Istio networking and security CRDs are served by the cluster
+func (c *Client) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(c.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeIstio)...)
+}
+
 // HealthCheck performs a health check on Istio
 func (c *Client) HealthCheck() error {
 	vsList := &unstructured.UnstructuredList{}
@@ -116,6 +124,10 @@ type HTTPRouteDestination struct {
 
 // CreateVirtualService creates a VirtualService
 func (c *Client) CreateVirtualService(ctx context.Context, vs *VirtualService) error {
+	if err := c.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	obj := &unstructured.Unstructured{}
 	obj.SetGroupVersionKind(virtualServiceGVK)
 	obj.SetName(vs.Name)
@@ -198,6 +210,10 @@ func (c *Client) DeleteVirtualService(ctx context.Context, name, namespace strin
 
 // ReconcileCluster reconciles Istio configuration for a cluster
 func (c *Client) ReconcileCluster(ctx context.Context, clusterName string) error {
+	if err := c.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	// Perform health check
 	if err := c.HealthCheck(); err != nil {
 		return fmt.Errorf("health check failed: %w", err)
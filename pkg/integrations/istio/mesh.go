@@ -8,6 +8,9 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
 )
 
 var (
@@ -93,7 +96,16 @@ func NewServiceMesh(c client.Client) *ServiceMesh {
 	}
 }
 
+// EnsureCRDs verifies that the Istio CRDs used by the mesh helpers are served by the cluster
+func (sm *ServiceMesh) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(sm.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeIstio)...)
+}
+
 func (sm *ServiceMesh) ConfigureMesh(ctx context.Context, config *MeshConfig) error {
+	if err := sm.EnsureCRDs(ctx); err != nil {
+		return err
+	}
+
 	if config.EnableAutoMTLS {
 		if err := sm.enableAutoMTLS(ctx, config.Namespace); err != nil {
 			return fmt.Errorf("failed to enable auto mTLS: %w", err)
@@ -0,0 +1,200 @@
+package kyverno
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+// reportPageSize is how many policy reports are listed per request
+const reportPageSize = 500
+
+var (
+	// ClusterPolicyGVK is the kind of the policies KSIT distributes
+	ClusterPolicyGVK = schema.GroupVersionKind{
+		Group:   "kyverno.io",
+		Version: "v1",
+		Kind:    "ClusterPolicy",
+	}
+	policyReportGVK = schema.GroupVersionKind{
+		Group:   "wgpolicyk8s.io",
+		Version: "v1alpha2",
+		Kind:    "PolicyReport",
+	}
+	clusterPolicyReportGVK = schema.GroupVersionKind{
+		Group:   "wgpolicyk8s.io",
+		Version: "v1alpha2",
+		Kind:    "ClusterPolicyReport",
+	}
+)
+
+// Client manages Kyverno policies and reads policy reports on one target cluster
+type Client struct {
+	client.Client
+}
+
+// NewClient creates a Kyverno client on top of a target cluster's Kubernetes client
+func NewClient(c client.Client) *Client {
+	return &Client{Client: c}
+}
+
+// EnsureCRDs verifies that the Kyverno and policy report CRDs are served by the cluster
+func (c *Client) EnsureCRDs(ctx context.Context) error {
+	return crds.EnsureCRDs(c.RESTMapper(), crds.RequiredFor(ksitv1alpha1.IntegrationTypeKyverno)...)
+}
+
+// HealthCheck verifies that the Kyverno API answers by listing ClusterPolicies
+func (c *Client) HealthCheck(ctx context.Context) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(ClusterPolicyGVK.GroupVersion().WithKind("ClusterPolicyList"))
+	if err := c.List(ctx, list, client.Limit(1)); err != nil {
+		return fmt.Errorf("kyverno health check failed: %w", err)
+	}
+	return nil
+}
+
+// ApplyClusterPolicy creates a ClusterPolicy labelled with owner, or updates its spec,
+// labels and annotations if it already exists. An existing ClusterPolicy without the
+// owner labels was created by someone else and is not taken over.
+func (c *Client) ApplyClusterPolicy(ctx context.Context, policy *unstructured.Unstructured, owner map[string]string) error {
+	if policy.GroupVersionKind().GroupKind() != ClusterPolicyGVK.GroupKind() {
+		return fmt.Errorf("%s %s is not a ClusterPolicy", policy.GetKind(), policy.GetName())
+	}
+
+	policy = policy.DeepCopy()
+	policyLabels := policy.GetLabels()
+	if policyLabels == nil {
+		policyLabels = map[string]string{}
+	}
+	for k, v := range owner {
+		policyLabels[k] = v
+	}
+	policy.SetLabels(policyLabels)
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(policy.GroupVersionKind())
+	err := c.Get(ctx, client.ObjectKey{Name: policy.GetName()}, existing)
+	if errors.IsNotFound(err) {
+		if err := c.Create(ctx, policy); err != nil {
+			return fmt.Errorf("failed to create ClusterPolicy %s: %w", policy.GetName(), err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ClusterPolicy %s: %w", policy.GetName(), err)
+	}
+	if !labels.SelectorFromSet(owner).Matches(labels.Set(existing.GetLabels())) {
+		return fmt.Errorf("ClusterPolicy %s already exists without labels %s", policy.GetName(), labels.Set(owner))
+	}
+
+	existing.Object["spec"] = policy.Object["spec"]
+	existing.SetLabels(policy.GetLabels())
+	existing.SetAnnotations(policy.GetAnnotations())
+	if err := c.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update ClusterPolicy %s: %w", policy.GetName(), err)
+	}
+	return nil
+}
+
+// DeleteClusterPolicy deletes a ClusterPolicy
+func (c *Client) DeleteClusterPolicy(ctx context.Context, name string) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ClusterPolicyGVK)
+	obj.SetName(name)
+	return c.Delete(ctx, obj)
+}
+
+// ListClusterPolicies returns the names of the ClusterPolicies with the given labels, sorted
+func (c *Client) ListClusterPolicies(ctx context.Context, labels map[string]string) ([]string, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(ClusterPolicyGVK.GroupVersion().WithKind("ClusterPolicyList"))
+	if err := c.List(ctx, list, client.MatchingLabels(labels)); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterPolicies: %w", err)
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReportSummary counts policy results by outcome
+type ReportSummary struct {
+	Pass  int
+	Fail  int
+	Warn  int
+	Error int
+	Skip  int
+	// FailuresByPolicy counts the failed results of each policy
+	FailuresByPolicy map[string]int
+}
+
+// Summarize sums the PolicyReports of all namespaces and the ClusterPolicyReports of the
+// cluster, as written by the Kyverno reports controller. Reports are listed in pages of
+// reportPageSize, as large clusters hold one PolicyReport per resource.
+func (c *Client) Summarize(ctx context.Context) (*ReportSummary, error) {
+	summary := &ReportSummary{FailuresByPolicy: map[string]int{}}
+	for _, gvk := range []schema.GroupVersionKind{policyReportGVK, clusterPolicyReportGVK} {
+		continueToken := ""
+		for {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := c.List(ctx, list, client.Limit(reportPageSize), client.Continue(continueToken)); err != nil {
+				return nil, fmt.Errorf("failed to list %ss: %w", gvk.Kind, err)
+			}
+			for _, item := range list.Items {
+				summary.add(item)
+			}
+			if continueToken = list.GetContinue(); continueToken == "" {
+				break
+			}
+		}
+	}
+	return summary, nil
+}
+
+func (s *ReportSummary) add(report unstructured.Unstructured) {
+	counts, _, _ := unstructured.NestedMap(report.Object, "summary")
+	s.Pass += count(counts["pass"])
+	s.Fail += count(counts["fail"])
+	s.Warn += count(counts["warn"])
+	s.Error += count(counts["error"])
+	s.Skip += count(counts["skip"])
+
+	results, _, _ := unstructured.NestedSlice(report.Object, "results")
+	for _, result := range results {
+		resultMap, ok := result.(map[string]interface{})
+		if !ok || resultMap["result"] != "fail" {
+			continue
+		}
+		if policy, ok := resultMap["policy"].(string); ok {
+			s.FailuresByPolicy[policy]++
+		}
+	}
+}
+
+// count reads a summary count, which decodes as int64 from JSON via the API server and
+// as float64 from YAML or JSON decoded without type information
+func count(v interface{}) int {
+	switch n := v.(type) {
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
+	}
+}
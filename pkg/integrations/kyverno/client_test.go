@@ -0,0 +1,159 @@
+package kyverno
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+)
+
+func newTestClient(objs ...client.Object) *Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor("kyverno") {
+		scope := meta.RESTScopeNamespace
+		if gvk.Kind == "ClusterPolicy" || gvk.Kind == "ClusterPolicyReport" {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	return NewClient(c)
+}
+
+func clusterPolicy(name, action string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"validationFailureAction": action},
+	}}
+	obj.SetGroupVersionKind(ClusterPolicyGVK)
+	obj.SetName(name)
+	return obj
+}
+
+func report(namespace, name string, pass, fail int64, failedPolicies ...string) *unstructured.Unstructured {
+	results := make([]interface{}, 0, len(failedPolicies))
+	for _, policy := range failedPolicies {
+		results = append(results, map[string]interface{}{"policy": policy, "result": "fail"})
+	}
+	results = append(results, map[string]interface{}{"policy": "require-labels", "result": "pass"})
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"summary": map[string]interface{}{"pass": pass, "fail": fail, "warn": int64(0), "error": int64(0), "skip": int64(1)},
+		"results": results,
+	}}
+	// Reports without a namespace are ClusterPolicyReports
+	kind := "PolicyReport"
+	if namespace == "" {
+		kind = "ClusterPolicyReport"
+	}
+	obj.SetAPIVersion("wgpolicyk8s.io/v1alpha2")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestApplyClusterPolicy(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient()
+
+	owner := map[string]string{"ksit.io/integration": "policies", "ksit.io/integration-namespace": "ksit-system"}
+
+	require.NoError(t, c.ApplyClusterPolicy(ctx, clusterPolicy("require-labels", "Audit"), owner))
+	require.NoError(t, c.ApplyClusterPolicy(ctx, clusterPolicy("disallow-latest-tag", "Audit"), owner))
+	require.NoError(t, c.ApplyClusterPolicy(ctx, clusterPolicy("require-labels", "Enforce"), owner))
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ClusterPolicyGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "require-labels"}, policy))
+	action, _, _ := unstructured.NestedString(policy.Object, "spec", "validationFailureAction")
+	assert.Equal(t, "Enforce", action)
+
+	names, err := c.ListClusterPolicies(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"disallow-latest-tag", "require-labels"}, names)
+
+	require.NoError(t, c.DeleteClusterPolicy(ctx, "require-labels"))
+	names, err = c.ListClusterPolicies(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"disallow-latest-tag"}, names)
+
+	notAPolicy := clusterPolicy("pods", "Audit")
+	notAPolicy.SetKind("Pod")
+	assert.Error(t, c.ApplyClusterPolicy(ctx, notAPolicy, owner))
+}
+
+func TestApplyClusterPolicyLeavesOtherPolicies(t *testing.T) {
+	ctx := context.Background()
+	unlabelled := clusterPolicy("require-labels", "Audit")
+	sameName := clusterPolicy("disallow-latest-tag", "Audit")
+	sameName.SetLabels(map[string]string{"ksit.io/integration": "policies", "ksit.io/integration-namespace": "team-b"})
+	c := newTestClient(unlabelled, sameName)
+	owner := map[string]string{"ksit.io/integration": "policies", "ksit.io/integration-namespace": "team-a"}
+
+	assert.EqualError(t, c.ApplyClusterPolicy(ctx, clusterPolicy("require-labels", "Enforce"), owner),
+		"ClusterPolicy require-labels already exists without labels ksit.io/integration-namespace=team-a,ksit.io/integration=policies")
+	assert.Error(t, c.ApplyClusterPolicy(ctx, clusterPolicy("disallow-latest-tag", "Enforce"), owner),
+		"a same-named Integration in another namespace does not own it")
+
+	for _, name := range []string{"require-labels", "disallow-latest-tag"} {
+		policy := &unstructured.Unstructured{}
+		policy.SetGroupVersionKind(ClusterPolicyGVK)
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name}, policy))
+		action, _, _ := unstructured.NestedString(policy.Object, "spec", "validationFailureAction")
+		assert.Equal(t, "Audit", action, name)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	c := newTestClient(
+		report("web", "web-report", 10, 2, "disallow-latest-tag", "disallow-latest-tag"),
+		report("api", "api-report", 5, 1, "require-limits"),
+		report("", "cluster-report", 3, 0),
+	)
+
+	summary, err := c.Summarize(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 18, summary.Pass)
+	assert.Equal(t, 3, summary.Fail)
+	assert.Equal(t, 3, summary.Skip)
+	assert.Equal(t, map[string]int{"disallow-latest-tag": 2, "require-limits": 1}, summary.FailuresByPolicy)
+}
+
+func TestSummarizePages(t *testing.T) {
+	pages := map[string]*unstructured.Unstructured{
+		"":       report("web", "web-report", 10, 2, "disallow-latest-tag", "disallow-latest-tag"),
+		"page-2": report("api", "api-report", 5, 1, "require-limits"),
+	}
+	var limits []int64
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, _ client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listOpts := &client.ListOptions{}
+			listOpts.ApplyOptions(opts)
+			limits = append(limits, listOpts.Limit)
+			items := list.(*unstructured.UnstructuredList)
+			if items.GetKind() != "PolicyReportList" {
+				return nil
+			}
+			items.Items = []unstructured.Unstructured{*pages[listOpts.Continue]}
+			if listOpts.Continue == "" {
+				items.SetContinue("page-2")
+			}
+			return nil
+		},
+	}).Build()
+
+	summary, err := NewClient(c).Summarize(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 15, summary.Pass)
+	assert.Equal(t, map[string]int{"disallow-latest-tag": 2, "require-limits": 1}, summary.FailuresByPolicy)
+	assert.Equal(t, []int64{reportPageSize, reportPageSize, reportPageSize}, limits)
+}
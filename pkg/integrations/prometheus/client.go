@@ -27,10 +27,26 @@ type SamplePair struct {
 	Value     float64
 }
 
+// Auth holds the credentials sent with every request, for servers behind an
+// authenticating proxy. BearerToken takes precedence over Username and Password.
+type Auth struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
 func NewClient(prometheusURL string) (*Client, error) {
+	return NewClientWithAuth(prometheusURL, Auth{})
+}
+
+// NewClientWithAuth creates a client that authenticates its requests with auth
+func NewClientWithAuth(prometheusURL string, auth Auth) (*Client, error) {
 	cfg := api.Config{
 		Address: prometheusURL,
 	}
+	if auth != (Auth{}) {
+		cfg.RoundTripper = &authRoundTripper{auth: auth, next: api.DefaultRoundTripper}
+	}
 
 	apiClient, err := api.NewClient(cfg)
 	if err != nil {
@@ -44,6 +60,21 @@ func NewClient(prometheusURL string) (*Client, error) {
 	}, nil
 }
 
+type authRoundTripper struct {
+	auth Auth
+	next http.RoundTripper
+}
+
+func (t *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.auth.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.auth.BearerToken)
+	} else {
+		req.SetBasicAuth(t.auth.Username, t.auth.Password)
+	}
+	return t.next.RoundTrip(req)
+}
+
 func (c *Client) Query(ctx context.Context, query string, ts time.Time) ([]QueryResult, error) {
 	result, warnings, err := c.api.Query(ctx, query, ts)
 	if err != nil {
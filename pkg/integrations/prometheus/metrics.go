@@ -1,80 +1,332 @@
 package prometheus
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+	"github.com/kubestellar/integration-toolkit/pkg/slo"
 )
 
+// DefaultNamespace prefixes the metric names unless metrics.namespace is configured
+const DefaultNamespace = "ksit"
+
 var (
-	integrationReconcileTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Namespace: "ksit",
-			Subsystem: "integration",
-			Name:      "reconcile_total",
-			Help:      "Total number of integration reconciliations",
-		},
-		[]string{"integration", "type", "status"},
-	)
-
-	integrationReconcileDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Namespace: "ksit",
-			Subsystem: "integration",
-			Name:      "reconcile_duration_seconds",
-			Help:      "Duration of integration reconciliation in seconds",
-			Buckets:   prometheus.DefBuckets,
-		},
-		[]string{"integration", "type"},
-	)
-
-	integrationStatus = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: "ksit",
-			Subsystem: "integration",
-			Name:      "status",
-			Help:      "Current status of integrations (1=running, 0=not running)",
-		},
-		[]string{"integration", "type", "cluster"},
-	)
-
-	clusterConnectionStatus = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: "ksit",
-			Subsystem: "cluster",
-			Name:      "connection_status",
-			Help:      "Cluster connection status (1=connected, 0=disconnected)",
-		},
-		[]string{"cluster"},
-	)
-
-	syncOperationsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Namespace: "ksit",
-			Subsystem: "sync",
-			Name:      "operations_total",
-			Help:      "Total number of sync operations",
-		},
-		[]string{"integration", "cluster", "status"},
-	)
-
-	syncLatencySeconds = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Namespace: "ksit",
-			Subsystem: "sync",
-			Name:      "latency_seconds",
-			Help:      "Sync operation latency in seconds",
-			Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
-		},
-		[]string{"integration", "cluster"},
-	)
+	// DefaultReconcileDurationBuckets are the buckets of the reconcile duration histogram, in seconds
+	DefaultReconcileDurationBuckets = prometheus.DefBuckets
+	// DefaultSyncLatencyBuckets are the buckets of the sync latency histogram, in seconds
+	DefaultSyncLatencyBuckets = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60}
 )
 
+// metricSet holds the collectors of the controller
+type metricSet struct {
+	// namespace prefixes the names of the metrics
+	namespace string
+
+	integrationReconcileTotal      *prometheus.CounterVec
+	integrationReconcileDuration   *prometheus.HistogramVec
+	integrationStatus              *prometheus.GaugeVec
+	clusterConnectionStatus        *prometheus.GaugeVec
+	syncOperationsTotal            *prometheus.CounterVec
+	syncLatencySeconds             *prometheus.HistogramVec
+	integrationAvailability        *prometheus.GaugeVec
+	integrationReconcileErrorRatio *prometheus.GaugeVec
+	integrationMTTR                *prometheus.GaugeVec
+	integrationIncidents           *prometheus.GaugeVec
+	integrationHealthScore         *prometheus.GaugeVec
+	integrationFleetHealthScore    *prometheus.GaugeVec
+	clusterCircuitOpen             *prometheus.GaugeVec
+	clusterOperationTimeouts       *prometheus.CounterVec
+	clusterCheckRetries            *prometheus.CounterVec
+	installDrifts                  *prometheus.CounterVec
+	integrationRequeueInterval     *prometheus.GaugeVec
+	buildInfo                      *prometheus.GaugeVec
+}
+
+// metrics are the registered collectors. Configure replaces them.
+var metrics = mustRegister(newMetricSet(config.MetricsConfig{}))
+
+func newMetricSet(cfg config.MetricsConfig) *metricSet {
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	reconcileBuckets := cfg.ReconcileDurationBuckets
+	if len(reconcileBuckets) == 0 {
+		reconcileBuckets = DefaultReconcileDurationBuckets
+	}
+	syncBuckets := cfg.SyncLatencyBuckets
+	if len(syncBuckets) == 0 {
+		syncBuckets = DefaultSyncLatencyBuckets
+	}
+
+	return &metricSet{
+		namespace: namespace,
+
+		integrationReconcileTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "reconcile_total",
+				Help:      "Total number of integration reconciliations",
+			},
+			[]string{"integration", "type", "status"},
+		),
+
+		integrationReconcileDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "reconcile_duration_seconds",
+				Help:      "Duration of integration reconciliation in seconds",
+				Buckets:   reconcileBuckets,
+			},
+			[]string{"integration", "type"},
+		),
+
+		integrationStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "status",
+				Help:      "Current status of integrations (1=running, 0=not running)",
+			},
+			[]string{"integration", "type", "cluster"},
+		),
+
+		clusterConnectionStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "connection_status",
+				Help:      "Cluster connection status (1=connected, 0=disconnected)",
+			},
+			[]string{"cluster"},
+		),
+
+		syncOperationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "sync",
+				Name:      "operations_total",
+				Help:      "Total number of sync operations",
+			},
+			[]string{"integration", "cluster", "status"},
+		),
+
+		syncLatencySeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "sync",
+				Name:      "latency_seconds",
+				Help:      "Sync operation latency in seconds",
+				Buckets:   syncBuckets,
+			},
+			[]string{"integration", "cluster"},
+		),
+
+		integrationAvailability: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "availability_ratio",
+				Help:      "Fraction of time the integration was Running in the current calendar month",
+			},
+			[]string{"integration", "type"},
+		),
+
+		integrationReconcileErrorRatio: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "reconcile_error_ratio",
+				Help:      "Fraction of reconciliations that failed in the current calendar month",
+			},
+			[]string{"integration", "type"},
+		),
+
+		integrationMTTR: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "mttr_seconds",
+				Help:      "Mean time for the integration to return to Running in the current calendar month",
+			},
+			[]string{"integration", "type"},
+		),
+
+		integrationIncidents: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "incidents",
+				Help:      "Number of times the integration left the Running phase in the current calendar month",
+			},
+			[]string{"integration", "type"},
+		),
+
+		integrationHealthScore: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "health_score",
+				Help:      "Health score (0-100) of the integration on a cluster",
+			},
+			[]string{"integration", "type", "cluster"},
+		),
+
+		integrationFleetHealthScore: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "fleet_health_score",
+				Help:      "Health score (0-100) of the integration across all its target clusters",
+			},
+			[]string{"integration", "type"},
+		),
+
+		clusterCircuitOpen: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "circuit_open",
+				Help:      "Whether checks of the integration skip the cluster after repeated failures (1=open, 0=closed)",
+			},
+			[]string{"integration", "cluster"},
+		),
+
+		clusterOperationTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "operation_timeouts_total",
+				Help:      "Total number of integration checks on a cluster that exceeded the cluster timeout",
+			},
+			[]string{"integration", "cluster"},
+		),
+
+		clusterCheckRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "cluster",
+				Name:      "check_retries_total",
+				Help:      "Total number of integration checks on a cluster retried after a transient error",
+			},
+			[]string{"integration", "cluster", "check"},
+		),
+
+		installDrifts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "install",
+				Name:      "drifts_total",
+				Help:      "Total number of auto-installs reinstalled on a cluster after drifting from their spec",
+			},
+			[]string{"integration", "cluster"},
+		),
+
+		integrationRequeueInterval: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "integration",
+				Name:      "requeue_interval_seconds",
+				Help:      "Interval until the next periodic reconcile of the integration, stretched as the fleet grows",
+			},
+			[]string{"integration", "type"},
+		),
+
+		buildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "build_info",
+				Help:      "Build information of the running KSIT controller (always 1)",
+			},
+			[]string{"version", "commit", "go_version"},
+		),
+	}
+}
+
+func (s *metricSet) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		s.integrationReconcileTotal,
+		s.integrationReconcileDuration,
+		s.integrationStatus,
+		s.clusterConnectionStatus,
+		s.syncOperationsTotal,
+		s.syncLatencySeconds,
+		s.integrationAvailability,
+		s.integrationReconcileErrorRatio,
+		s.integrationMTTR,
+		s.integrationIncidents,
+		s.integrationHealthScore,
+		s.integrationFleetHealthScore,
+		s.clusterCircuitOpen,
+		s.clusterOperationTimeouts,
+		s.clusterCheckRetries,
+		s.installDrifts,
+		s.integrationRequeueInterval,
+		s.buildInfo,
+	}
+}
+
+func (s *metricSet) register(registerer prometheus.Registerer) error {
+	for i, collector := range s.collectors() {
+		if err := registerer.Register(collector); err != nil {
+			for _, registered := range s.collectors()[:i] {
+				registerer.Unregister(registered)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *metricSet) unregister(registerer prometheus.Registerer) {
+	for _, collector := range s.collectors() {
+		registerer.Unregister(collector)
+	}
+}
+
+// mustRegister registers s on the controller-runtime registry, the one the manager's
+// metrics server exposes
+func mustRegister(s *metricSet) *metricSet {
+	if err := s.register(ctrlmetrics.Registry); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Configure recreates the metrics with the namespace and histogram buckets of cfg,
+// replacing the default ones. Series recorded before are dropped, so it is meant to be
+// called once at startup, before anything is recorded.
+func Configure(cfg config.MetricsConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	next := newMetricSet(cfg)
+	metrics.unregister(ctrlmetrics.Registry)
+	if err := next.register(ctrlmetrics.Registry); err != nil {
+		// Keep the previous metrics serving
+		if restoreErr := metrics.register(ctrlmetrics.Registry); restoreErr != nil {
+			return fmt.Errorf("failed to register metrics: %w (restoring the previous metrics also failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("failed to register metrics: %w", err)
+	}
+	metrics = next
+	return nil
+}
+
 func RecordReconcile(integration, integrationType, status string) {
-	integrationReconcileTotal.WithLabelValues(integration, integrationType, status).Inc()
+	metrics.integrationReconcileTotal.WithLabelValues(integration, integrationType, status).Inc()
 }
 
 func RecordReconcileDuration(integration, integrationType string, durationSeconds float64) {
-	integrationReconcileDuration.WithLabelValues(integration, integrationType).Observe(durationSeconds)
+	metrics.integrationReconcileDuration.WithLabelValues(integration, integrationType).Observe(durationSeconds)
 }
 
 func SetIntegrationStatus(integration, integrationType, cluster string, running bool) {
@@ -82,7 +334,12 @@ func SetIntegrationStatus(integration, integrationType, cluster string, running
 	if running {
 		value = 1.0
 	}
-	integrationStatus.WithLabelValues(integration, integrationType, cluster).Set(value)
+	metrics.integrationStatus.WithLabelValues(integration, integrationType, cluster).Set(value)
+}
+
+// DeleteIntegrationStatus drops the status of an integration on every cluster
+func DeleteIntegrationStatus(integration, integrationType string) {
+	metrics.integrationStatus.DeletePartialMatch(prometheus.Labels{"integration": integration, "type": integrationType})
 }
 
 func SetClusterConnectionStatus(cluster string, connected bool) {
@@ -90,13 +347,139 @@ func SetClusterConnectionStatus(cluster string, connected bool) {
 	if connected {
 		value = 1.0
 	}
-	clusterConnectionStatus.WithLabelValues(cluster).Set(value)
+	metrics.clusterConnectionStatus.WithLabelValues(cluster).Set(value)
 }
 
 func RecordSyncOperation(integration, cluster, status string) {
-	syncOperationsTotal.WithLabelValues(integration, cluster, status).Inc()
+	metrics.syncOperationsTotal.WithLabelValues(integration, cluster, status).Inc()
 }
 
 func RecordSyncLatency(integration, cluster string, latencySeconds float64) {
-	syncLatencySeconds.WithLabelValues(integration, cluster).Observe(latencySeconds)
+	metrics.syncLatencySeconds.WithLabelValues(integration, cluster).Observe(latencySeconds)
+}
+
+// SetSLO publishes the service level indicators of an integration. Indicators that
+// have no value yet, such as MTTR before the first recovery, are not exported.
+func SetSLO(integration, integrationType string, window *ksitv1alpha1.SLOWindow) {
+	if availability, ok := slo.Availability(window); ok {
+		metrics.integrationAvailability.WithLabelValues(integration, integrationType).Set(availability)
+	} else {
+		metrics.integrationAvailability.DeleteLabelValues(integration, integrationType)
+	}
+	if errorRate, ok := slo.ErrorRate(window); ok {
+		metrics.integrationReconcileErrorRatio.WithLabelValues(integration, integrationType).Set(errorRate)
+	} else {
+		metrics.integrationReconcileErrorRatio.DeleteLabelValues(integration, integrationType)
+	}
+	if mttr, ok := slo.MTTR(window); ok {
+		metrics.integrationMTTR.WithLabelValues(integration, integrationType).Set(mttr.Seconds())
+	} else {
+		metrics.integrationMTTR.DeleteLabelValues(integration, integrationType)
+	}
+	metrics.integrationIncidents.WithLabelValues(integration, integrationType).Set(float64(window.Incidents))
+}
+
+// DeleteSLO drops the service level indicators of a deleted integration
+func DeleteSLO(integration, integrationType string) {
+	metrics.integrationAvailability.DeleteLabelValues(integration, integrationType)
+	metrics.integrationReconcileErrorRatio.DeleteLabelValues(integration, integrationType)
+	metrics.integrationMTTR.DeleteLabelValues(integration, integrationType)
+	metrics.integrationIncidents.DeleteLabelValues(integration, integrationType)
+}
+
+// healthScoreClusters remembers the clusters scored for each integration, so scores of
+// clusters that are no longer targeted can be dropped
+var healthScoreClusters = struct {
+	sync.Mutex
+	clusters map[[2]string]map[string]bool
+}{clusters: make(map[[2]string]map[string]bool)}
+
+// SetHealthScore publishes the health scores of an integration. clusters must cover
+// every target cluster; scores of clusters missing from it are dropped.
+func SetHealthScore(integration, integrationType string, clusters []ksitv1alpha1.ClusterHealthScore, fleet int32) {
+	key := [2]string{integration, integrationType}
+	scored := make(map[string]bool, len(clusters))
+	for _, cluster := range clusters {
+		metrics.integrationHealthScore.WithLabelValues(integration, integrationType, cluster.Name).Set(float64(cluster.Score))
+		scored[cluster.Name] = true
+	}
+	metrics.integrationFleetHealthScore.WithLabelValues(integration, integrationType).Set(float64(fleet))
+
+	healthScoreClusters.Lock()
+	defer healthScoreClusters.Unlock()
+	for cluster := range healthScoreClusters.clusters[key] {
+		if !scored[cluster] {
+			metrics.integrationHealthScore.DeleteLabelValues(integration, integrationType, cluster)
+		}
+	}
+	healthScoreClusters.clusters[key] = scored
+}
+
+// DeleteHealthScore drops the health scores of a deleted integration
+func DeleteHealthScore(integration, integrationType string) {
+	metrics.integrationHealthScore.DeletePartialMatch(prometheus.Labels{"integration": integration, "type": integrationType})
+	metrics.integrationFleetHealthScore.DeleteLabelValues(integration, integrationType)
+
+	healthScoreClusters.Lock()
+	defer healthScoreClusters.Unlock()
+	delete(healthScoreClusters.clusters, [2]string{integration, integrationType})
+}
+
+func SetCircuitOpen(integration, cluster string, open bool) {
+	value := 0.0
+	if open {
+		value = 1.0
+	}
+	metrics.clusterCircuitOpen.WithLabelValues(integration, cluster).Set(value)
+}
+
+func RecordClusterTimeout(integration, cluster string) {
+	metrics.clusterOperationTimeouts.WithLabelValues(integration, cluster).Inc()
+}
+
+func RecordCheckRetry(integration, cluster, check string) {
+	metrics.clusterCheckRetries.WithLabelValues(integration, cluster, check).Inc()
+}
+
+// RecordInstallDrift counts a drifted install that is reinstalled on a cluster
+func RecordInstallDrift(integration, cluster string) {
+	metrics.installDrifts.WithLabelValues(integration, cluster).Inc()
+}
+
+// DeleteCircuits drops the circuit breaker series of a deleted integration
+func DeleteCircuits(integration string) {
+	labels := prometheus.Labels{"integration": integration}
+	metrics.clusterCircuitOpen.DeletePartialMatch(labels)
+	metrics.clusterOperationTimeouts.DeletePartialMatch(labels)
+	metrics.clusterCheckRetries.DeletePartialMatch(labels)
+	metrics.installDrifts.DeletePartialMatch(labels)
+}
+
+// SetRequeueInterval records when the integration is reconciled next
+func SetRequeueInterval(integration, integrationType string, seconds float64) {
+	metrics.integrationRequeueInterval.WithLabelValues(integration, integrationType).Set(seconds)
+}
+
+// DeleteRequeueInterval drops the requeue interval of a deleted integration
+func DeleteRequeueInterval(integration, integrationType string) {
+	metrics.integrationRequeueInterval.DeleteLabelValues(integration, integrationType)
+}
+
+func SetBuildInfo(version, commit, goVersion string) {
+	metrics.buildInfo.WithLabelValues(version, commit, goVersion).Set(1)
+}
+
+// DeleteClusterMetrics drops every series labeled with the cluster, so clusters that
+// left the fleet stop showing up as disconnected
+func DeleteClusterMetrics(cluster string) {
+	labels := prometheus.Labels{"cluster": cluster}
+	metrics.integrationStatus.DeletePartialMatch(labels)
+	metrics.clusterConnectionStatus.DeletePartialMatch(labels)
+	metrics.syncOperationsTotal.DeletePartialMatch(labels)
+	metrics.syncLatencySeconds.DeletePartialMatch(labels)
+	metrics.integrationHealthScore.DeletePartialMatch(labels)
+	metrics.clusterCircuitOpen.DeletePartialMatch(labels)
+	metrics.clusterOperationTimeouts.DeletePartialMatch(labels)
+	metrics.clusterCheckRetries.DeletePartialMatch(labels)
+	metrics.installDrifts.DeletePartialMatch(labels)
 }
@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+func gather(t *testing.T, name string) *dto.MetricFamily {
+	families, err := ctrlmetrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family
+		}
+	}
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, Configure(config.MetricsConfig{})) })
+
+	require.NoError(t, Configure(config.MetricsConfig{
+		Namespace:                "acme_gitops",
+		ReconcileDurationBuckets: []float64{1, 5, 30},
+	}))
+	RecordReconcileDuration("argocd", "argocd", 2)
+	RecordSyncLatency("argocd", "edge-1", 2)
+
+	assert.Nil(t, gather(t, "ksit_integration_reconcile_duration_seconds"))
+	family := gather(t, "acme_gitops_integration_reconcile_duration_seconds")
+	require.NotNil(t, family)
+	var bounds []float64
+	for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+		bounds = append(bounds, bucket.GetUpperBound())
+	}
+	assert.Equal(t, []float64{1, 5, 30}, bounds)
+
+	// Unset buckets keep their defaults
+	family = gather(t, "acme_gitops_sync_latency_seconds")
+	require.NotNil(t, family)
+	assert.Len(t, family.GetMetric()[0].GetHistogram().GetBucket(), len(DefaultSyncLatencyBuckets))
+
+	assert.EqualError(t, Configure(config.MetricsConfig{Namespace: "acme-gitops"}), `invalid metrics.namespace "acme-gitops"`)
+	assert.EqualError(t, Configure(config.MetricsConfig{SyncLatencyBuckets: []float64{5, 1}}), "metrics.syncLatencyBuckets must be in increasing order")
+	// A rejected config leaves the metrics alone
+	assert.NotNil(t, gather(t, "acme_gitops_integration_reconcile_duration_seconds"))
+}
+
+func TestMetricsServed(t *testing.T) {
+	server, err := metricsserver.NewServer(metricsserver.Options{BindAddress: "127.0.0.1:0"}, nil, nil)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Start(ctx) }()
+
+	// The bind address is known once the server listens
+	bound := server.(interface{ GetBindAddr() string })
+	require.Eventually(t, func() bool { return bound.GetBindAddr() != "" }, 5*time.Second, 10*time.Millisecond)
+
+	RecordReconcile("argocd", "argocd", "success")
+	resp, err := http.Get("http://" + bound.GetBindAddr() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `ksit_integration_reconcile_total{integration="argocd",status="success",type="argocd"}`)
+}
@@ -0,0 +1,155 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultTextfileInterval is how often the metrics are written when no interval is configured
+const DefaultTextfileInterval = time.Minute
+
+// ObjectWriter writes an object to a bucket, such as ledger.S3Store
+type ObjectWriter interface {
+	PutObject(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// TextfileExporter periodically writes the KSIT metrics to a file, for the textfile
+// collector of node-exporter, and/or to an object store, so hubs without a scrapeable
+// network path stay observable. Go runtime and process metrics are left out.
+type TextfileExporter struct {
+	// Gatherer defaults to the controller-runtime registry
+	Gatherer prometheus.Gatherer
+	// Path of the file; empty writes no file
+	Path string
+	// Objects and Key name the object to write; a nil Objects writes no object
+	Objects ObjectWriter
+	Key     string
+	// OpenMetrics writes the OpenMetrics format instead of the Prometheus text format
+	OpenMetrics bool
+	// Interval defaults to DefaultTextfileInterval
+	Interval time.Duration
+	Log      logr.Logger
+}
+
+// Start writes the metrics every interval until ctx is done, and once more on the way out
+func (e *TextfileExporter) Start(ctx context.Context) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = DefaultTextfileInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Export(ctx); err != nil {
+			e.Log.Error(err, "failed to export metrics")
+		}
+		select {
+		case <-ctx.Done():
+			// The last write gets its own deadline, since ctx is already done
+			final, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := e.Export(final); err != nil {
+				e.Log.Error(err, "failed to export metrics on shutdown")
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection is true: only the leader records the Integration metrics, and a
+// standby would overwrite a shared object with empty ones
+func (e *TextfileExporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Export writes the current metrics once
+func (e *TextfileExporter) Export(ctx context.Context) error {
+	body, format, err := e.encode()
+	if err != nil {
+		return err
+	}
+	if e.Path != "" {
+		if err := writeFileAtomic(e.Path, body); err != nil {
+			return err
+		}
+	}
+	if e.Objects != nil {
+		if err := e.Objects.PutObject(ctx, e.Key, string(format), body); err != nil {
+			return fmt.Errorf("failed to write metrics object %s: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// encode gathers the KSIT metric families and encodes them
+func (e *TextfileExporter) encode() ([]byte, expfmt.Format, error) {
+	gatherer := e.Gatherer
+	if gatherer == nil {
+		gatherer = ctrlmetrics.Registry
+	}
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	format := expfmt.FmtText
+	if e.OpenMetrics {
+		format = expfmt.FmtOpenMetrics_1_0_0
+	}
+	prefix := metrics.namespace + "_"
+	buf := &bytes.Buffer{}
+	encoder := expfmt.NewEncoder(buf, format)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), prefix) {
+			continue
+		}
+		if err := encoder.Encode(family); err != nil {
+			return nil, "", fmt.Errorf("failed to encode metric %s: %w", family.GetName(), err)
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	return buf.Bytes(), format, nil
+}
+
+// writeFileAtomic replaces path by renaming a temporary file over it, so the textfile
+// collector never reads a half-written file. The temporary name does not end in .prom,
+// which the collector would pick up.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace metrics file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package prometheus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedObjects map[string]string
+
+func (o recordedObjects) PutObject(_ context.Context, key, contentType string, body []byte) error {
+	o[key] = contentType + "\n" + string(body)
+	return nil
+}
+
+func TestTextfileExporter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	status := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "ksit_integration_status", Help: "Integration status"}, []string{"integration"})
+	status.WithLabelValues("argocd").Set(1)
+	registry.MustRegister(status, prometheus.NewGoCollector())
+
+	dir := t.TempDir()
+	objects := recordedObjects{}
+	exporter := &TextfileExporter{
+		Gatherer: registry,
+		Path:     filepath.Join(dir, "ksit.prom"),
+		Objects:  objects,
+		Key:      "hub-1/ksit.prom",
+	}
+	require.NoError(t, exporter.Export(context.Background()))
+
+	data, err := os.ReadFile(exporter.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "# HELP ksit_integration_status Integration status\n# TYPE ksit_integration_status gauge\nksit_integration_status{integration=\"argocd\"} 1\n", string(data))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+	assert.True(t, strings.HasPrefix(objects["hub-1/ksit.prom"], "text/plain; version=0.0.4"))
+
+	exporter.OpenMetrics = true
+	require.NoError(t, exporter.Export(context.Background()))
+	data, err = os.ReadFile(exporter.Path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), "# EOF\n"))
+	assert.True(t, strings.HasPrefix(objects["hub-1/ksit.prom"], "application/openmetrics-text"))
+}
@@ -63,7 +63,8 @@ type SelectorRequirement struct {
 	Values   []string
 }
 
-// DownSyncRule defines what to sync down to clusters
+// DownSyncRule defines what to sync down to clusters. Build one with ForResource or a
+// shorthand like ForDeployment rather than by hand.
 type DownSyncRule struct {
 	APIGroup       string
 	Resources      []string
@@ -72,67 +73,93 @@ type DownSyncRule struct {
 	LabelSelectors []metav1.LabelSelector
 }
 
-// CreateBindingPolicy creates a new BindingPolicy
-func (kc *KubeStellarClient) CreateBindingPolicy(ctx context.Context, bp *BindingPolicy) error {
-	bindingPolicy := &unstructured.Unstructured{}
-	bindingPolicy.SetGroupVersionKind(bindingPolicyGVK)
-	bindingPolicy.SetName(bp.Name)
-	bindingPolicy.SetNamespace(bp.Namespace)
-
+// ToUnstructured returns the BindingPolicy as the object stored in the WDS
+func (bp *BindingPolicy) ToUnstructured() (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(bindingPolicyGVK)
+	obj.SetName(bp.Name)
+	obj.SetNamespace(bp.Namespace)
 	if bp.Labels != nil {
-		bindingPolicy.SetLabels(bp.Labels)
+		obj.SetLabels(bp.Labels)
 	}
 	if bp.Annotations != nil {
-		bindingPolicy.SetAnnotations(bp.Annotations)
+		obj.SetAnnotations(bp.Annotations)
+	}
+
+	spec, err := bp.spec()
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedMap(obj.Object, spec, "spec"); err != nil {
+		return nil, fmt.Errorf("failed to set spec: %w", err)
 	}
+	return obj, nil
+}
 
+// spec returns the spec of the BindingPolicy object
+func (bp *BindingPolicy) spec() (map[string]interface{}, error) {
 	spec := make(map[string]interface{})
 
-	// Set cluster selectors
 	if len(bp.ClusterSelectors) > 0 {
 		selectors := make([]interface{}, 0, len(bp.ClusterSelectors))
 		for _, selector := range bp.ClusterSelectors {
-			s := make(map[string]interface{})
-			if len(selector.MatchLabels) > 0 {
-				s["matchLabels"] = selector.MatchLabels
-			}
-			if len(selector.MatchExpressions) > 0 {
-				expressions := make([]interface{}, 0, len(selector.MatchExpressions))
-				for _, expr := range selector.MatchExpressions {
-					expressions = append(expressions, map[string]interface{}{
-						"key":      expr.Key,
-						"operator": expr.Operator,
-						"values":   expr.Values,
-					})
-				}
-				s["matchExpressions"] = expressions
+			s, err := selector.spec()
+			if err != nil {
+				return nil, err
 			}
 			selectors = append(selectors, s)
 		}
 		spec["clusterSelectors"] = selectors
 	}
 
-	// Set downsync rules
 	if len(bp.DownSyncRules) > 0 {
 		rules := make([]interface{}, 0, len(bp.DownSyncRules))
 		for _, rule := range bp.DownSyncRules {
-			r := map[string]interface{}{
-				"apiGroup":  rule.APIGroup,
-				"resources": rule.Resources,
-			}
-			if len(rule.Namespaces) > 0 {
-				r["namespaces"] = rule.Namespaces
-			}
-			if len(rule.ObjectNames) > 0 {
-				r["objectNames"] = rule.ObjectNames
+			r, err := rule.spec()
+			if err != nil {
+				return nil, err
 			}
 			rules = append(rules, r)
 		}
 		spec["downsync"] = rules
 	}
 
-	if err := unstructured.SetNestedMap(bindingPolicy.Object, spec, "spec"); err != nil {
-		return fmt.Errorf("failed to set spec: %w", err)
+	return spec, nil
+}
+
+// spec returns the selector as it appears in a BindingPolicy
+func (cs ClusterSelector) spec() (map[string]interface{}, error) {
+	selector := &metav1.LabelSelector{MatchLabels: cs.MatchLabels}
+	for _, expr := range cs.MatchExpressions {
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: metav1.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+	s, err := runtime.DefaultUnstructuredConverter.ToUnstructured(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert cluster selector: %w", err)
+	}
+	return s, nil
+}
+
+// ValidateBindingPolicy checks the downsync rules of bp against the resources served by the WDS
+func (kc *KubeStellarClient) ValidateBindingPolicy(bp *BindingPolicy) error {
+	if err := ValidateDownSyncRules(kc.RESTMapper(), bp.DownSyncRules...); err != nil {
+		return fmt.Errorf("invalid BindingPolicy %s: %w", bp.Name, err)
+	}
+	return nil
+}
+
+// CreateBindingPolicy validates and creates a new BindingPolicy
+func (kc *KubeStellarClient) CreateBindingPolicy(ctx context.Context, bp *BindingPolicy) error {
+	if err := kc.ValidateBindingPolicy(bp); err != nil {
+		return err
+	}
+	bindingPolicy, err := bp.ToUnstructured()
+	if err != nil {
+		return err
 	}
 
 	if err := kc.Create(ctx, bindingPolicy); err != nil {
@@ -154,8 +181,11 @@ func (kc *KubeStellarClient) GetBindingPolicy(ctx context.Context, name, namespa
 	return bp, nil
 }
 
-// UpdateBindingPolicy updates an existing BindingPolicy
+// UpdateBindingPolicy validates bp and replaces the spec of the existing BindingPolicy with it
 func (kc *KubeStellarClient) UpdateBindingPolicy(ctx context.Context, bp *BindingPolicy) error {
+	if err := kc.ValidateBindingPolicy(bp); err != nil {
+		return err
+	}
 	existing, err := kc.GetBindingPolicy(ctx, bp.Name, bp.Namespace)
 	if err != nil {
 		return err
@@ -168,37 +198,10 @@ func (kc *KubeStellarClient) UpdateBindingPolicy(ctx context.Context, bp *Bindin
 		existing.SetAnnotations(bp.Annotations)
 	}
 
-	spec := make(map[string]interface{})
-
-	// Update cluster selectors
-	if len(bp.ClusterSelectors) > 0 {
-		selectors := make([]interface{}, 0, len(bp.ClusterSelectors))
-		for _, selector := range bp.ClusterSelectors {
-			s := make(map[string]interface{})
-			if len(selector.MatchLabels) > 0 {
-				s["matchLabels"] = selector.MatchLabels
-			}
-			selectors = append(selectors, s)
-		}
-		spec["clusterSelectors"] = selectors
-	}
-
-	// Update downsync rules
-	if len(bp.DownSyncRules) > 0 {
-		rules := make([]interface{}, 0, len(bp.DownSyncRules))
-		for _, rule := range bp.DownSyncRules {
-			r := map[string]interface{}{
-				"apiGroup":  rule.APIGroup,
-				"resources": rule.Resources,
-			}
-			if len(rule.Namespaces) > 0 {
-				r["namespaces"] = rule.Namespaces
-			}
-			rules = append(rules, r)
-		}
-		spec["downsync"] = rules
+	spec, err := bp.spec()
+	if err != nil {
+		return err
 	}
-
 	if err := unstructured.SetNestedMap(existing.Object, spec, "spec"); err != nil {
 		return fmt.Errorf("failed to set spec: %w", err)
 	}
@@ -256,9 +259,9 @@ func (kc *KubeStellarClient) AddClusterSelector(ctx context.Context, name, names
 
 	selectors, _, _ := unstructured.NestedSlice(spec, "clusterSelectors")
 
-	newSelector := make(map[string]interface{})
-	if len(selector.MatchLabels) > 0 {
-		newSelector["matchLabels"] = selector.MatchLabels
+	newSelector, err := selector.spec()
+	if err != nil {
+		return err
 	}
 
 	selectors = append(selectors, newSelector)
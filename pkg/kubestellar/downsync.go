@@ -0,0 +1,212 @@
+package kubestellar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ForResource starts a DownSyncRule for the resource, in plural form, of apiGroup. The
+// core group is "". Chain InNamespace, Named and WithLabels to narrow it down:
+//
+//	kubestellar.ForDeployment().InNamespace("monitoring").WithLabels(map[string]string{"app": "agent"})
+func ForResource(apiGroup string, resources ...string) DownSyncRule {
+	return DownSyncRule{APIGroup: apiGroup, Resources: resources}
+}
+
+// ForDeployment starts a DownSyncRule for Deployments
+func ForDeployment() DownSyncRule { return ForResource("apps", "deployments") }
+
+// ForStatefulSet starts a DownSyncRule for StatefulSets
+func ForStatefulSet() DownSyncRule { return ForResource("apps", "statefulsets") }
+
+// ForDaemonSet starts a DownSyncRule for DaemonSets
+func ForDaemonSet() DownSyncRule { return ForResource("apps", "daemonsets") }
+
+// ForService starts a DownSyncRule for Services
+func ForService() DownSyncRule { return ForResource("", "services") }
+
+// ForConfigMap starts a DownSyncRule for ConfigMaps
+func ForConfigMap() DownSyncRule { return ForResource("", "configmaps") }
+
+// ForSecret starts a DownSyncRule for Secrets
+func ForSecret() DownSyncRule { return ForResource("", "secrets") }
+
+// ForServiceAccount starts a DownSyncRule for ServiceAccounts
+func ForServiceAccount() DownSyncRule { return ForResource("", "serviceaccounts") }
+
+// ForNamespace starts a DownSyncRule for Namespaces
+func ForNamespace() DownSyncRule { return ForResource("", "namespaces") }
+
+// InNamespace returns a copy of the rule that also matches objects in namespaces
+func (r DownSyncRule) InNamespace(namespaces ...string) DownSyncRule {
+	r.Namespaces = append(append([]string(nil), r.Namespaces...), namespaces...)
+	return r
+}
+
+// Named returns a copy of the rule that also matches the objects called names
+func (r DownSyncRule) Named(names ...string) DownSyncRule {
+	r.ObjectNames = append(append([]string(nil), r.ObjectNames...), names...)
+	return r
+}
+
+// WithLabels returns a copy of the rule that also matches objects carrying all of labels
+func (r DownSyncRule) WithLabels(labels map[string]string) DownSyncRule {
+	return r.WithSelector(metav1.LabelSelector{MatchLabels: labels})
+}
+
+// WithSelector returns a copy of the rule that also matches objects selected by selector
+func (r DownSyncRule) WithSelector(selector metav1.LabelSelector) DownSyncRule {
+	r.LabelSelectors = append(append([]metav1.LabelSelector(nil), r.LabelSelectors...), selector)
+	return r
+}
+
+// Validate checks the rule without contacting a cluster: resources must be lowercase
+// plurals, and namespaces, names and selectors must be well formed
+func (r DownSyncRule) Validate() error {
+	var errs []error
+	if len(r.Resources) == 0 {
+		errs = append(errs, fmt.Errorf("no resources"))
+	}
+	for _, resource := range r.Resources {
+		if resource != strings.ToLower(resource) {
+			errs = append(errs, fmt.Errorf("resource %q must be lowercase, e.g. %q", resource, strings.ToLower(resource)+"s"))
+		}
+	}
+	for _, namespace := range r.Namespaces {
+		if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(msgs, ", ")))
+		}
+	}
+	for _, name := range r.ObjectNames {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("empty object name"))
+		}
+	}
+	for i := range r.LabelSelectors {
+		if _, err := metav1.LabelSelectorAsSelector(&r.LabelSelectors[i]); err != nil {
+			errs = append(errs, fmt.Errorf("invalid label selector: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateDownSyncRules checks every rule with Validate, then checks with mapper, which
+// is backed by discovery of the WDS, that its resources are served in its API group and
+// that rules for cluster-scoped resources do not list namespaces
+func ValidateDownSyncRules(mapper meta.RESTMapper, rules ...DownSyncRule) error {
+	var errs []error
+	for i, rule := range rules {
+		err := rule.Validate()
+		if err == nil && mapper != nil {
+			err = validateResources(mapper, rule)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("downsync[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateResources checks the resources of a rule against the API served by the cluster
+func validateResources(mapper meta.RESTMapper, rule DownSyncRule) error {
+	var errs []error
+	for _, resource := range rule.Resources {
+		// The mapper matches the core group "" against every group, and group prefixes
+		matches, _ := mapper.ResourcesFor(schema.GroupVersionResource{Group: rule.APIGroup, Resource: resource})
+		var match *schema.GroupVersionResource
+		for i := range matches {
+			if matches[i].Group == rule.APIGroup {
+				match = &matches[i]
+				break
+			}
+		}
+		if match == nil {
+			errs = append(errs, unknownResource(mapper, rule.APIGroup, resource))
+			continue
+		}
+		// The mapper also accepts singular names, which KubeStellar does not
+		if match.Resource != resource {
+			errs = append(errs, fmt.Errorf("resource %q must be the plural %q", resource, match.Resource))
+			continue
+		}
+
+		gvk, err := mapper.KindFor(*match)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get kind of %s: %w", groupResource(rule.APIGroup, resource), err))
+			continue
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get mapping of %s: %w", groupResource(rule.APIGroup, resource), err))
+			continue
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot && len(rule.Namespaces) > 0 {
+			errs = append(errs, fmt.Errorf("%s is cluster-scoped, so namespaces do not apply", groupResource(rule.APIGroup, resource)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unknownResource describes a resource that is not served in its API group, naming the
+// groups it is served in if any
+func unknownResource(mapper meta.RESTMapper, apiGroup, resource string) error {
+	var groups []string
+	if matches, err := mapper.ResourcesFor(schema.GroupVersionResource{Resource: resource}); err == nil {
+		seen := map[string]bool{}
+		for _, match := range matches {
+			if !seen[match.Group] {
+				seen[match.Group] = true
+				groups = append(groups, fmt.Sprintf("%q", match.Group))
+			}
+		}
+	}
+	if len(groups) > 0 {
+		return fmt.Errorf("%s is not served; %s is in apiGroup %s", groupResource(apiGroup, resource), resource, strings.Join(groups, ", "))
+	}
+	return fmt.Errorf("%s is not served", groupResource(apiGroup, resource))
+}
+
+func groupResource(apiGroup, resource string) string {
+	return schema.GroupResource{Group: apiGroup, Resource: resource}.String()
+}
+
+// spec returns the downsync clause of the rule as it appears in a BindingPolicy
+func (r DownSyncRule) spec() (map[string]interface{}, error) {
+	clause := map[string]interface{}{
+		"apiGroup":  r.APIGroup,
+		"resources": stringsToInterfaces(r.Resources),
+	}
+	if len(r.Namespaces) > 0 {
+		clause["namespaces"] = stringsToInterfaces(r.Namespaces)
+	}
+	if len(r.ObjectNames) > 0 {
+		clause["objectNames"] = stringsToInterfaces(r.ObjectNames)
+	}
+	if len(r.LabelSelectors) > 0 {
+		selectors := make([]interface{}, 0, len(r.LabelSelectors))
+		for i := range r.LabelSelectors {
+			selector, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&r.LabelSelectors[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert label selector: %w", err)
+			}
+			selectors = append(selectors, selector)
+		}
+		clause["objectSelectors"] = selectors
+	}
+	return clause, nil
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		out = append(out, value)
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package kubestellar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newWDSMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+func TestDownSyncRuleBuilder(t *testing.T) {
+	base := ForDeployment().InNamespace("monitoring")
+	rule := base.WithLabels(map[string]string{"app": "agent"}).Named("agent")
+	other := base.InNamespace("logging")
+
+	assert.Equal(t, DownSyncRule{
+		APIGroup:       "apps",
+		Resources:      []string{"deployments"},
+		Namespaces:     []string{"monitoring"},
+		ObjectNames:    []string{"agent"},
+		LabelSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "agent"}}},
+	}, rule)
+	// Rules derived from the same base do not share slices
+	assert.Equal(t, []string{"monitoring", "logging"}, other.Namespaces)
+	assert.Equal(t, []string{"monitoring"}, rule.Namespaces)
+	assert.Empty(t, other.LabelSelectors)
+}
+
+func TestValidateDownSyncRules(t *testing.T) {
+	mapper := newWDSMapper()
+
+	require.NoError(t, ValidateDownSyncRules(mapper,
+		ForDeployment().InNamespace("monitoring"),
+		ForService().WithLabels(map[string]string{"app": "agent"}),
+		ForNamespace().Named("monitoring"),
+	))
+
+	err := ValidateDownSyncRules(mapper, ForResource("apps", "deployment"))
+	assert.EqualError(t, err, `downsync[0]: resource "deployment" must be the plural "deployments"`)
+
+	err = ValidateDownSyncRules(mapper, ForResource("", "deployments"))
+	assert.EqualError(t, err, `downsync[0]: deployments is not served; deployments is in apiGroup "apps"`)
+
+	err = ValidateDownSyncRules(mapper, ForResource("example.com", "widgets"))
+	assert.EqualError(t, err, "downsync[0]: widgets.example.com is not served")
+
+	err = ValidateDownSyncRules(mapper, ForNamespace().InNamespace("monitoring"))
+	assert.EqualError(t, err, "downsync[0]: namespaces is cluster-scoped, so namespaces do not apply")
+
+	// Rules that are malformed are rejected without discovery
+	err = ValidateDownSyncRules(nil, ForService(), ForResource("apps", "Deployment").InNamespace("Monitoring"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `downsync[1]: resource "Deployment" must be lowercase`)
+	assert.Contains(t, err.Error(), `invalid namespace "Monitoring"`)
+	assert.NotContains(t, err.Error(), "downsync[0]")
+}
+
+func TestBindingPolicyToUnstructured(t *testing.T) {
+	bp := &BindingPolicy{
+		Name:             "agents",
+		ClusterSelectors: []ClusterSelector{{MatchLabels: map[string]string{"location-group": "edge"}}},
+		DownSyncRules: []DownSyncRule{
+			ForDeployment().InNamespace("monitoring").WithLabels(map[string]string{"app": "agent"}),
+		},
+	}
+
+	obj, err := bp.ToUnstructured()
+	require.NoError(t, err)
+	assert.Equal(t, bindingPolicyGVK, obj.GroupVersionKind())
+	assert.Equal(t, map[string]interface{}{
+		"clusterSelectors": []interface{}{
+			map[string]interface{}{"matchLabels": map[string]interface{}{"location-group": "edge"}},
+		},
+		"downsync": []interface{}{
+			map[string]interface{}{
+				"apiGroup":   "apps",
+				"resources":  []interface{}{"deployments"},
+				"namespaces": []interface{}{"monitoring"},
+				"objectSelectors": []interface{}{
+					map[string]interface{}{"matchLabels": map[string]interface{}{"app": "agent"}},
+				},
+			},
+		},
+	}, obj.Object["spec"])
+
+	// The object round-trips through the selectors used to place workloads
+	selectors, err := ClusterSelectors(obj)
+	require.NoError(t, err)
+	require.Len(t, selectors, 1)
+	assert.Equal(t, "location-group=edge", selectors[0].String())
+}
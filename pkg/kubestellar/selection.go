@@ -0,0 +1,61 @@
+package kubestellar
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+// ClusterSelectors returns the cluster selectors of a BindingPolicy
+func ClusterSelectors(bp *unstructured.Unstructured) ([]labels.Selector, error) {
+	raw, _, err := unstructured.NestedSlice(bp.Object, "spec", "clusterSelectors")
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterSelectors in BindingPolicy %s: %w", bp.GetName(), err)
+	}
+
+	selectors := make([]labels.Selector, 0, len(raw))
+	for i, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid clusterSelectors[%d] in BindingPolicy %s", i, bp.GetName())
+		}
+		ls := &metav1.LabelSelector{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, ls); err != nil {
+			return nil, fmt.Errorf("invalid clusterSelectors[%d] in BindingPolicy %s: %w", i, bp.GetName(), err)
+		}
+		selector, err := metav1.LabelSelectorAsSelector(ls)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clusterSelectors[%d] in BindingPolicy %s: %w", i, bp.GetName(), err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	return selectors, nil
+}
+
+// SelectClusters returns the sorted names of the clusters matched by any of a BindingPolicy's
+// cluster selectors, using the labels the clusters are registered with
+func SelectClusters(bp *unstructured.Unstructured, clusters []*cluster.Cluster) ([]string, error) {
+	selectors, err := ClusterSelectors(bp)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, c := range clusters {
+		for _, selector := range selectors {
+			if selector.Matches(labels.Set(c.Labels)) {
+				names = append(names, c.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
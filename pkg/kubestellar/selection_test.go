@@ -0,0 +1,45 @@
+package kubestellar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/integration-toolkit/pkg/cluster"
+)
+
+func TestSelectClusters(t *testing.T) {
+	bp := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterSelectors": []interface{}{
+				map[string]interface{}{"matchLabels": map[string]interface{}{"location-group": "edge"}},
+				map[string]interface{}{"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "env", "operator": "In", "values": []interface{}{"prod"}},
+				}},
+			},
+		},
+	}}
+	bp.SetGroupVersionKind(bindingPolicyGVK)
+	bp.SetName("edge-agents")
+
+	clusters := []*cluster.Cluster{
+		{Name: "edge-2", Labels: map[string]string{"location-group": "edge"}},
+		{Name: "core-1", Labels: map[string]string{"env": "prod"}},
+		{Name: "dev-1", Labels: map[string]string{"env": "dev"}},
+		{Name: "edge-1", Labels: map[string]string{"location-group": "edge", "env": "prod"}},
+	}
+
+	names, err := SelectClusters(bp, clusters)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"core-1", "edge-1", "edge-2"}, names)
+
+	require.NoError(t, unstructured.SetNestedSlice(bp.Object, []interface{}{
+		map[string]interface{}{"matchExpressions": []interface{}{
+			map[string]interface{}{"key": "env", "operator": "Bogus"},
+		}},
+	}, "spec", "clusterSelectors"))
+	_, err = SelectClusters(bp, clusters)
+	assert.Error(t, err)
+}
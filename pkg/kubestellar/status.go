@@ -0,0 +1,182 @@
+package kubestellar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// bindingGVK is the GroupVersionKind of the Binding KubeStellar resolves each
+	// BindingPolicy into, in the WDS
+	bindingGVK = schema.GroupVersionKind{Group: "control.kubestellar.io", Version: "v1alpha1", Kind: "Binding"}
+	// workStatusGVK is the GroupVersionKind of the status a WEC reports back for one
+	// workload object, in the ITS
+	workStatusGVK = schema.GroupVersionKind{Group: "control.kubestellar.io", Version: "v1alpha1", Kind: "WorkStatus"}
+)
+
+// ObjectRef identifies a workload object downsynced by KubeStellar
+type ObjectRef struct {
+	Group     string
+	Version   string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// String returns the reference as resource.group/namespace/name, or resource.group/name
+// for a cluster-scoped object
+func (r ObjectRef) String() string {
+	resource := schema.GroupResource{Group: r.Group, Resource: r.Resource}.String()
+	if r.Namespace == "" {
+		return resource + "/" + r.Name
+	}
+	return resource + "/" + r.Namespace + "/" + r.Name
+}
+
+// key identifies the object regardless of the version it is served at
+func (r ObjectRef) key() ObjectRef {
+	r.Version = ""
+	return r
+}
+
+// Binding is what KubeStellar resolved a BindingPolicy into: the workload objects it
+// selects and the WECs they are sent to
+type Binding struct {
+	Name         string
+	Workload     []ObjectRef
+	Destinations []string
+}
+
+// WorkStatus is the status a WEC reported for one workload object
+type WorkStatus struct {
+	Name    string
+	Cluster string
+	Source  ObjectRef
+	Status  map[string]interface{}
+}
+
+// ClusterDelivery tells which workload objects of a Binding a WEC has reported status for
+type ClusterDelivery struct {
+	Cluster   string
+	Delivered []ObjectRef
+	Missing   []ObjectRef
+}
+
+// GetBinding returns the Binding of the BindingPolicy called name. Bindings are
+// cluster-scoped and named after their BindingPolicy.
+func (kc *KubeStellarClient) GetBinding(ctx context.Context, name string) (*Binding, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(bindingGVK)
+	if err := kc.Get(ctx, client.ObjectKey{Name: name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get Binding: %w", err)
+	}
+	return bindingFromUnstructured(obj)
+}
+
+// ListWorkStatuses returns the WorkStatuses a WEC reported. kc must be a client of the
+// ITS, where each WEC reports into the mailbox namespace named after it.
+func (kc *KubeStellarClient) ListWorkStatuses(ctx context.Context, cluster string) ([]WorkStatus, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(workStatusGVK.GroupVersion().WithKind(workStatusGVK.Kind + "List"))
+	if err := kc.List(ctx, list, client.InNamespace(cluster)); err != nil {
+		return nil, fmt.Errorf("failed to list WorkStatuses of %s: %w", cluster, err)
+	}
+
+	statuses := make([]WorkStatus, 0, len(list.Items))
+	for i := range list.Items {
+		obj := &list.Items[i]
+		source, _, err := unstructured.NestedStringMap(obj.Object, "spec", "sourceRef")
+		if err != nil {
+			return nil, fmt.Errorf("invalid WorkStatus %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+		status, _, _ := unstructured.NestedMap(obj.Object, "status")
+		statuses = append(statuses, WorkStatus{
+			Name:    obj.GetName(),
+			Cluster: cluster,
+			Source: ObjectRef{
+				Group:     source["group"],
+				Version:   source["version"],
+				Resource:  source["resource"],
+				Namespace: source["namespace"],
+				Name:      source["name"],
+			},
+			Status: status,
+		})
+	}
+	return statuses, nil
+}
+
+// Delivery matches the workload of a Binding against the WorkStatuses of each of its
+// destinations, keyed by cluster, and returns one ClusterDelivery per destination in
+// name order. An object counts as delivered once its WEC reported status for it.
+func Delivery(binding *Binding, statuses map[string][]WorkStatus) []ClusterDelivery {
+	destinations := append([]string(nil), binding.Destinations...)
+	sort.Strings(destinations)
+
+	deliveries := make([]ClusterDelivery, 0, len(destinations))
+	for _, cluster := range destinations {
+		reported := make(map[ObjectRef]bool, len(statuses[cluster]))
+		for _, ws := range statuses[cluster] {
+			reported[ws.Source.key()] = true
+		}
+
+		delivery := ClusterDelivery{Cluster: cluster}
+		for _, ref := range binding.Workload {
+			if reported[ref.key()] {
+				delivery.Delivered = append(delivery.Delivered, ref)
+			} else {
+				delivery.Missing = append(delivery.Missing, ref)
+			}
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// bindingFromUnstructured reads the workload and destinations of a Binding
+func bindingFromUnstructured(obj *unstructured.Unstructured) (*Binding, error) {
+	binding := &Binding{Name: obj.GetName()}
+
+	for _, scope := range []string{"clusterScope", "namespaceScope"} {
+		refs, _, err := unstructured.NestedSlice(obj.Object, "spec", "workload", scope)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Binding %s: %w", obj.GetName(), err)
+		}
+		for _, item := range refs {
+			ref, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid Binding %s: spec.workload.%s has a %T", obj.GetName(), scope, item)
+			}
+			binding.Workload = append(binding.Workload, ObjectRef{
+				Group:     stringField(ref, "group"),
+				Version:   stringField(ref, "version"),
+				Resource:  stringField(ref, "resource"),
+				Namespace: stringField(ref, "namespace"),
+				Name:      stringField(ref, "name"),
+			})
+		}
+	}
+
+	destinations, _, err := unstructured.NestedSlice(obj.Object, "spec", "destinations")
+	if err != nil {
+		return nil, fmt.Errorf("invalid Binding %s: %w", obj.GetName(), err)
+	}
+	for _, item := range destinations {
+		if destination, ok := item.(map[string]interface{}); ok {
+			if cluster := stringField(destination, "clusterId"); cluster != "" {
+				binding.Destinations = append(binding.Destinations, cluster)
+			}
+		}
+	}
+	return binding, nil
+}
+
+func stringField(obj map[string]interface{}, field string) string {
+	value, _ := obj[field].(string)
+	return value
+}
@@ -0,0 +1,78 @@
+package kubestellar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newBinding(name string, workload []interface{}, clusters ...string) *unstructured.Unstructured {
+	destinations := make([]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		destinations = append(destinations, map[string]interface{}{"clusterId": cluster})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"workload":     map[string]interface{}{"namespaceScope": workload},
+			"destinations": destinations,
+		},
+	}}
+	obj.SetGroupVersionKind(bindingGVK)
+	obj.SetName(name)
+	return obj
+}
+
+func newWorkStatus(cluster, name string, source map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"sourceRef": source},
+		"status": map[string]interface{}{"availableReplicas": int64(1)},
+	}}
+	obj.SetGroupVersionKind(workStatusGVK)
+	obj.SetNamespace(cluster)
+	obj.SetName(name)
+	return obj
+}
+
+func TestDelivery(t *testing.T) {
+	ctx := context.Background()
+	agent := map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "monitoring", "name": "agent"}
+	config := map[string]interface{}{"group": "", "version": "v1", "resource": "configmaps", "namespace": "monitoring", "name": "agent-config"}
+
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(
+		newBinding("edge-agents", []interface{}{agent, config}, "edge-2", "edge-1"),
+		newWorkStatus("edge-1", "appsv1-deployment-monitoring-agent", agent),
+		newWorkStatus("edge-1", "v1-configmap-monitoring-agent-config", config),
+		newWorkStatus("edge-2", "appsv1-deployment-monitoring-agent", agent),
+	).Build()
+	kc := &KubeStellarClient{Client: c}
+
+	binding, err := kc.GetBinding(ctx, "edge-agents")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edge-2", "edge-1"}, binding.Destinations)
+	require.Len(t, binding.Workload, 2)
+	assert.Equal(t, "deployments.apps/monitoring/agent", binding.Workload[0].String())
+
+	statuses := map[string][]WorkStatus{}
+	for _, cluster := range binding.Destinations {
+		statuses[cluster], err = kc.ListWorkStatuses(ctx, cluster)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, map[string]interface{}{"availableReplicas": int64(1)}, statuses["edge-2"][0].Status)
+
+	deliveries := Delivery(binding, statuses)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "edge-1", deliveries[0].Cluster)
+	assert.Len(t, deliveries[0].Delivered, 2)
+	assert.Empty(t, deliveries[0].Missing)
+	assert.Equal(t, "edge-2", deliveries[1].Cluster)
+	require.Len(t, deliveries[1].Missing, 1)
+	assert.Equal(t, "configmaps/monitoring/agent-config", deliveries[1].Missing[0].String())
+
+	_, err = kc.GetBinding(ctx, "missing")
+	assert.Error(t, err)
+}
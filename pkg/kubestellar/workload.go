@@ -0,0 +1,19 @@
+package kubestellar
+
+import (
+	"context"
+	"time"
+
+	"github.com/kubestellar/integration-toolkit/pkg/health"
+)
+
+// GetWorkloadStatus reports the readiness of a Deployment, StatefulSet, DaemonSet or Job
+func (kc *KubeStellarClient) GetWorkloadStatus(ctx context.Context, kind, namespace, name string) (health.Status, error) {
+	return health.Check(ctx, kc.Client, health.Workload{Kind: kind, Namespace: namespace, Name: name})
+}
+
+// WaitForWorkloadReady waits for a Deployment, StatefulSet or DaemonSet to be ready, or for a
+// Job to complete. A failed Job is returned as an error wrapping health.ErrJobFailed right away.
+func (kc *KubeStellarClient) WaitForWorkloadReady(ctx context.Context, kind, namespace, name string, timeout time.Duration) error {
+	return health.WaitForWorkloadReady(ctx, kc.Client, health.Workload{Kind: kind, Namespace: namespace, Name: name}, timeout)
+}
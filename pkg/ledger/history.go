@@ -0,0 +1,55 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+// HistoryRecord is one install action as kept by a HistoryStore. InstalledComponents only
+// hold the latest action per integration and cluster; the store keeps every action.
+type HistoryRecord struct {
+	Time        time.Time `json:"time"`
+	Namespace   string    `json:"namespace"`
+	Integration string    `json:"integration"`
+	Cluster     string    `json:"cluster"`
+	Type        string    `json:"type"`
+	Action      string    `json:"action"`
+	Result      string    `json:"result"`
+	Method      string    `json:"method,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	ValuesHash  string    `json:"valuesHash,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// HistoryStore persists install history outside the hub cluster
+type HistoryStore interface {
+	Append(ctx context.Context, record HistoryRecord) error
+}
+
+// NewHistoryStore creates the store selected by cfg. It returns nil when no backend is configured.
+func NewHistoryStore(ctx context.Context, cfg config.HistoryConfig) (HistoryStore, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Backend {
+	case config.HistoryBackendFile:
+		return NewFileStore(cfg.File.Path), nil
+	case config.HistoryBackendS3:
+		store, err := NewS3Store(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case config.HistoryBackendPostgres:
+		store, err := NewPostgresStore(ctx, cfg.Postgres.DSN, cfg.Postgres.Table)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, nil
+	}
+}
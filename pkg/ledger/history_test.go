@@ -0,0 +1,146 @@
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/config"
+)
+
+type memoryStore struct {
+	records []HistoryRecord
+}
+
+func (s *memoryStore) Append(_ context.Context, record HistoryRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestRecordAppendsHistory(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = ksitv1alpha1.AddToScheme(scheme)
+	store := &memoryStore{}
+	l := NewLedger(fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksitv1alpha1.InstalledComponent{}).Build())
+	l.History = store
+	ctx := context.Background()
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system", UID: "uid-1"},
+		Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD},
+	}
+
+	require.NoError(t, l.Record(ctx, integration, "cluster1", Entry{Action: ksitv1alpha1.InstallActionInstall, Method: "helm", Version: "5.51.0"}))
+	require.NoError(t, l.Record(ctx, integration, "cluster1", Entry{Action: ksitv1alpha1.InstallActionUpgrade, Method: "helm", Version: "6.0.0", Err: fmt.Errorf("timed out")}))
+
+	// The InstalledComponent only keeps the last action; the store keeps both
+	require.Len(t, store.records, 2)
+	assert.Equal(t, "ksit-system", store.records[0].Namespace)
+	assert.Equal(t, "cluster1", store.records[0].Cluster)
+	assert.Equal(t, ksitv1alpha1.InstallResultSucceeded, store.records[0].Result)
+	assert.Equal(t, "6.0.0", store.records[1].Version)
+	assert.Equal(t, ksitv1alpha1.InstallResultFailed, store.records[1].Result)
+	assert.Equal(t, "timed out", store.records[1].Message)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewFileStore(path)
+
+	for _, action := range []string{ksitv1alpha1.InstallActionInstall, ksitv1alpha1.InstallActionUninstall} {
+		require.NoError(t, store.Append(context.Background(), HistoryRecord{Integration: "flux", Cluster: "cluster1", Action: action}))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var actions []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record HistoryRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		actions = append(actions, record.Action)
+	}
+	assert.Equal(t, []string{ksitv1alpha1.InstallActionInstall, ksitv1alpha1.InstallActionUninstall}, actions)
+
+	records, err := ReadHistoryFile(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, ksitv1alpha1.InstallActionUninstall, records[1].Action)
+}
+
+func TestS3Store(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(gotBody) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	store, err := NewS3Store(config.S3HistoryConfig{Endpoint: server.URL, Region: "eu-west-1", Bucket: "audit", Prefix: "/ksit/"})
+	require.NoError(t, err)
+
+	record := HistoryRecord{
+		Time:        time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC),
+		Namespace:   "ksit-system",
+		Integration: "argocd",
+		Cluster:     "cluster1",
+		Action:      ksitv1alpha1.InstallActionInstall,
+	}
+	require.NoError(t, store.Append(context.Background(), record))
+
+	assert.Equal(t, "/audit/ksit/2024/03/05/20240305T100000.000000000Z-ksit-system-argocd-cluster1.json", gotPath)
+	assert.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20"), gotAuth)
+	assert.Contains(t, gotAuth, "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+
+	var stored HistoryRecord
+	require.NoError(t, json.Unmarshal(gotBody, &stored))
+	assert.Equal(t, record, stored)
+
+	// Errors from the store are returned with its message
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	})
+	err = store.Append(context.Background(), record)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+func TestNewHistoryStore(t *testing.T) {
+	store, err := NewHistoryStore(context.Background(), config.HistoryConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	_, err = NewHistoryStore(context.Background(), config.HistoryConfig{Backend: config.HistoryBackendFile})
+	assert.EqualError(t, err, "history.file.path is required for the file backend")
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = NewHistoryStore(context.Background(), config.HistoryConfig{
+		Backend: config.HistoryBackendS3,
+		S3:      config.S3HistoryConfig{Region: "us-east-1", Bucket: "audit"},
+	})
+	assert.Error(t, err)
+}
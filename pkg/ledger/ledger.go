@@ -0,0 +1,239 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// Ledger records installs in InstalledComponent objects on the hub, one per integration per cluster
+type Ledger struct {
+	client.Client
+	// History, when set, also keeps every recorded action outside the hub
+	History HistoryStore
+}
+
+// Entry describes one install action to record
+type Entry struct {
+	Action     string
+	Method     string
+	Version    string
+	ValuesHash string
+	// Err is the action's error; nil records a successful action
+	Err error
+}
+
+// NewLedger creates a new Ledger
+func NewLedger(c client.Client) *Ledger {
+	return &Ledger{
+		Client: c,
+	}
+}
+
+// ComponentName returns the name of a new InstalledComponent for an integration and
+// cluster: as much of both names as fits, followed by a hash of the pair, so that
+// distinct pairs never share a name. Entries are looked up by the names recorded in
+// their spec rather than by this name.
+func ComponentName(integrationName, clusterName string) string {
+	// The separator cannot occur in either name
+	sum := sha256.Sum256([]byte(integrationName + "\x00" + clusterName))
+	suffix := hex.EncodeToString(sum[:])[:10]
+
+	prefix := strings.ToLower(integrationName + "-" + clusterName)
+	if limit := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(prefix) > limit {
+		prefix = prefix[:limit]
+	}
+	return strings.TrimRight(prefix, "-.") + "-" + suffix
+}
+
+// LabelValue returns the value of the integration or cluster label for a name. Names
+// that are valid label values are used as they are; longer ones are cut to fit and end
+// in a hash of the whole name, so that distinct names keep distinct values. The names
+// themselves are kept in the spec.
+func LabelValue(name string) string {
+	if len(validation.IsValidLabelValue(name)) == 0 {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(sum[:])[:10]
+
+	prefix := name
+	if limit := validation.LabelValueMaxLength - len(suffix) - 1; len(prefix) > limit {
+		prefix = prefix[:limit]
+	}
+	return strings.TrimRight(prefix, "-._") + "-" + suffix
+}
+
+// Get returns the ledger entry for an integration and cluster, or nil if none exists.
+// It is found by the integration and cluster labels and the names in its spec, which
+// also finds entries named before ComponentName hashed every name.
+func (l *Ledger) Get(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string) (*ksitv1alpha1.InstalledComponent, error) {
+	components := &ksitv1alpha1.InstalledComponentList{}
+	if err := l.List(ctx, components, client.InNamespace(integration.Namespace),
+		client.MatchingLabels{
+			ksitv1alpha1.LabelIntegration: LabelValue(integration.Name),
+			ksitv1alpha1.LabelCluster:     LabelValue(clusterName),
+		}); err != nil {
+		return nil, fmt.Errorf("failed to list installed components of %s/%s: %w", integration.Namespace, integration.Name, err)
+	}
+
+	for i := range components.Items {
+		spec := components.Items[i].Spec
+		if spec.IntegrationName == integration.Name && spec.ClusterName == clusterName {
+			return &components.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Record creates or updates the ledger entry for an integration and cluster, and appends
+// the action to the history store. The action is appended even when the entry cannot be
+// written, since it happened either way.
+func (l *Ledger) Record(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, entry Entry) error {
+	historyErr := l.appendHistory(ctx, integration, clusterName, entry)
+	if err := l.recordComponent(ctx, integration, clusterName, entry); err != nil {
+		return err
+	}
+	return historyErr
+}
+
+func (l *Ledger) appendHistory(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, entry Entry) error {
+	if l.History == nil {
+		return nil
+	}
+
+	record := HistoryRecord{
+		Time:        time.Now(),
+		Namespace:   integration.Namespace,
+		Integration: integration.Name,
+		Cluster:     clusterName,
+		Type:        integration.Spec.Type,
+		Action:      entry.Action,
+		Result:      ksitv1alpha1.InstallResultSucceeded,
+		Method:      entry.Method,
+		Version:     entry.Version,
+		ValuesHash:  entry.ValuesHash,
+	}
+	if entry.Err != nil {
+		record.Result = ksitv1alpha1.InstallResultFailed
+		record.Message = entry.Err.Error()
+	}
+	if err := l.History.Append(ctx, record); err != nil {
+		return fmt.Errorf("failed to append install history: %w", err)
+	}
+	return nil
+}
+
+func (l *Ledger) recordComponent(ctx context.Context, integration *ksitv1alpha1.Integration, clusterName string, entry Entry) error {
+	component, err := l.Get(ctx, integration, clusterName)
+	if err != nil {
+		return err
+	}
+
+	create := component == nil
+	if create {
+		component = &ksitv1alpha1.InstalledComponent{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ComponentName(integration.Name, clusterName),
+				Namespace: integration.Namespace,
+				Labels: map[string]string{
+					ksitv1alpha1.LabelIntegration: LabelValue(integration.Name),
+					ksitv1alpha1.LabelCluster:     LabelValue(clusterName),
+				},
+			},
+			Spec: ksitv1alpha1.InstalledComponentSpec{
+				IntegrationName: integration.Name,
+				ClusterName:     clusterName,
+				Type:            integration.Spec.Type,
+			},
+		}
+	}
+
+	now := metav1.Now()
+	component.Status.LastAction = entry.Action
+	component.Status.LastActionTime = &now
+
+	if entry.Err != nil {
+		component.Status.LastActionResult = ksitv1alpha1.InstallResultFailed
+		component.Status.Message = entry.Err.Error()
+	} else {
+		component.Status.LastActionResult = ksitv1alpha1.InstallResultSucceeded
+		component.Status.Message = ""
+
+		// Only a successful action changes what is recorded as installed
+		component.Spec.Method = entry.Method
+		component.Spec.Version = entry.Version
+		component.Spec.ValuesHash = entry.ValuesHash
+
+		if component.Status.InstalledAt == nil {
+			component.Status.InstalledAt = &now
+		}
+		if entry.Action == ksitv1alpha1.InstallActionAdopt {
+			component.Status.Adopted = true
+		}
+	}
+
+	component.Status.Ready = component.Status.LastActionResult == ksitv1alpha1.InstallResultSucceeded &&
+		entry.Action != ksitv1alpha1.InstallActionUninstall
+
+	// Owned by the Integration, so ledger entries are garbage collected with it
+	if err := controllerutil.SetControllerReference(integration, component, l.Scheme()); err != nil {
+		return fmt.Errorf("failed to set owner of installed component %s: %w", component.Name, err)
+	}
+
+	// Create and Update replace the object with what the API server returns, which
+	// leaves out the status
+	status := component.Status
+	if create {
+		if err := l.Create(ctx, component); err != nil {
+			return fmt.Errorf("failed to create installed component %s: %w", component.Name, err)
+		}
+	} else if err := l.Update(ctx, component); err != nil {
+		return fmt.Errorf("failed to update installed component %s: %w", component.Name, err)
+	}
+
+	component.Status = status
+	if err := l.Status().Update(ctx, component); err != nil {
+		return fmt.Errorf("failed to update status of installed component %s: %w", component.Name, err)
+	}
+	return nil
+}
+
+// ValuesHash returns a stable hash of the install values, overrides and profile of an integration
+func ValuesHash(integration *ksitv1alpha1.Integration) string {
+	var hashed interface{} = map[string]string{}
+	if install := integration.Spec.AutoInstall; install != nil {
+		if install.HelmConfig != nil {
+			hashed = install.HelmConfig.Values
+		}
+		// Installs without a valuesObject or overrides keep the hash they had before these existed
+		if helmConfig := install.HelmConfig; helmConfig != nil && helmConfig.ValuesObject != nil {
+			hashed = map[string]interface{}{"values": hashed, "valuesObject": helmConfig.ValuesObject}
+		}
+		if install.Overrides != nil {
+			hashed = map[string]interface{}{"values": hashed, "overrides": install.Overrides}
+		}
+		if install.Profile != "" {
+			hashed = map[string]interface{}{"values": hashed, "profile": install.Profile}
+		}
+	}
+
+	// encoding/json sorts map keys, so equal values always hash the same
+	data, err := json.Marshal(hashed)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestComponentName(t *testing.T) {
+	assert.Regexp(t, `^argocd-cluster1-[0-9a-f]{10}$`, ComponentName("argocd", "Cluster1"))
+	// Pairs that join to the same string still get distinct names
+	assert.NotEqual(t, ComponentName("a-b", "c"), ComponentName("a", "b-c"))
+
+	long := strings.Repeat("a", 200)
+	name := ComponentName(long, "cluster1")
+	assert.LessOrEqual(t, len(name), validation.DNS1123SubdomainMaxLength)
+	assert.NotEqual(t, name, ComponentName(long, "cluster2"))
+
+	// Truncating at a separator does not leave "--" before the hash
+	name = ComponentName(strings.Repeat("a", 242), "cluster1")
+	assert.NotContains(t, name, "--")
+	assert.Empty(t, validation.IsDNS1123Subdomain(name))
+}
+
+func TestLabelValue(t *testing.T) {
+	assert.Equal(t, "argocd", LabelValue("argocd"))
+	assert.Equal(t, "Cluster_1", LabelValue("Cluster_1"))
+
+	long := strings.Repeat("a", 61) + "." + strings.Repeat("b", 100)
+	value := LabelValue(long)
+	assert.Empty(t, validation.IsValidLabelValue(value))
+	assert.Regexp(t, `^a{52}-[0-9a-f]{10}$`, value)
+	assert.NotEqual(t, value, LabelValue(strings.Repeat("a", 61)+"."+strings.Repeat("c", 100)))
+
+	// Cutting at a separator does not leave it before the hash
+	assert.Regexp(t, `^a{51}-[0-9a-f]{10}$`, LabelValue(strings.Repeat("a", 51)+"."+strings.Repeat("b", 100)))
+}
+
+func TestRecordLongNames(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = ksitv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksitv1alpha1.InstalledComponent{}).Build()
+	l := NewLedger(c)
+	ctx := context.Background()
+
+	// Integrations differing only past the 63rd character
+	prefix := strings.Repeat("a", 70)
+	first := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-first", Namespace: "ksit-system", UID: "uid-1"}}
+	second := &ksitv1alpha1.Integration{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-second", Namespace: "ksit-system", UID: "uid-2"}}
+	clusterName := "edge." + strings.Repeat("c", 80)
+
+	require.NoError(t, l.Record(ctx, first, clusterName, Entry{Action: ksitv1alpha1.InstallActionInstall, Version: "1.0.0"}))
+	require.NoError(t, l.Record(ctx, second, clusterName, Entry{Action: ksitv1alpha1.InstallActionInstall, Version: "2.0.0"}))
+
+	component, err := l.Get(ctx, first, clusterName)
+	require.NoError(t, err)
+	require.NotNil(t, component)
+	assert.Equal(t, "1.0.0", component.Spec.Version)
+	assert.Equal(t, first.Name, component.Spec.IntegrationName)
+	assert.Equal(t, clusterName, component.Spec.ClusterName)
+	for _, value := range component.Labels {
+		assert.Empty(t, validation.IsValidLabelValue(value), value)
+	}
+
+	components := &ksitv1alpha1.InstalledComponentList{}
+	require.NoError(t, c.List(ctx, components, client.MatchingLabels{ksitv1alpha1.LabelIntegration: LabelValue(second.Name)}))
+	require.Len(t, components.Items, 1)
+	assert.Equal(t, "2.0.0", components.Items[0].Spec.Version)
+}
+
+func TestGetFindsLegacyNames(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = ksitv1alpha1.AddToScheme(scheme)
+	// Named before ComponentName hashed every name
+	legacy := &ksitv1alpha1.InstalledComponent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "argocd-cluster1",
+			Namespace: "ksit-system",
+			Labels:    map[string]string{ksitv1alpha1.LabelIntegration: "argocd", ksitv1alpha1.LabelCluster: "cluster1"},
+		},
+		Spec: ksitv1alpha1.InstalledComponentSpec{IntegrationName: "argocd", ClusterName: "cluster1", Version: "5.51.0"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(legacy).WithStatusSubresource(legacy).Build()
+	l := NewLedger(c)
+	ctx := context.Background()
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system", UID: "uid-1"},
+		Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD},
+	}
+
+	component, err := l.Get(ctx, integration, "cluster1")
+	require.NoError(t, err)
+	require.NotNil(t, component)
+	assert.Equal(t, "argocd-cluster1", component.Name)
+	component, err = l.Get(ctx, integration, "cluster2")
+	require.NoError(t, err)
+	assert.Nil(t, component)
+
+	// Recording updates the entry in place
+	require.NoError(t, l.Record(ctx, integration, "cluster1", Entry{Action: ksitv1alpha1.InstallActionUpgrade, Method: "helm", Version: "6.0.0"}))
+	components := &ksitv1alpha1.InstalledComponentList{}
+	require.NoError(t, c.List(ctx, components))
+	require.Len(t, components.Items, 1)
+	assert.Equal(t, "6.0.0", components.Items[0].Spec.Version)
+}
+
+func TestRecord(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = ksitv1alpha1.AddToScheme(scheme)
+	l := NewLedger(fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&ksitv1alpha1.InstalledComponent{}).Build())
+	ctx := context.Background()
+
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system", UID: "uid-1"},
+		Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD},
+	}
+
+	component, err := l.Get(ctx, integration, "cluster1")
+	require.NoError(t, err)
+	assert.Nil(t, component)
+
+	require.NoError(t, l.Record(ctx, integration, "cluster1", Entry{
+		Action:  ksitv1alpha1.InstallActionInstall,
+		Method:  "helm",
+		Version: "5.51.0",
+	}))
+
+	component, err = l.Get(ctx, integration, "cluster1")
+	require.NoError(t, err)
+	require.NotNil(t, component)
+	assert.Equal(t, "cluster1", component.Labels[ksitv1alpha1.LabelCluster])
+	assert.Equal(t, "5.51.0", component.Spec.Version)
+	assert.Equal(t, ksitv1alpha1.InstallResultSucceeded, component.Status.LastActionResult)
+	require.NotNil(t, component.Status.InstalledAt)
+	assert.True(t, component.Status.Ready)
+	require.Len(t, component.OwnerReferences, 1)
+	assert.Equal(t, "argocd", component.OwnerReferences[0].Name)
+	assert.Equal(t, "Integration", component.OwnerReferences[0].Kind)
+
+	// A failed upgrade keeps the previously installed version
+	require.NoError(t, l.Record(ctx, integration, "cluster1", Entry{
+		Action:  ksitv1alpha1.InstallActionUpgrade,
+		Method:  "helm",
+		Version: "6.0.0",
+		Err:     fmt.Errorf("timed out"),
+	}))
+
+	component, err = l.Get(ctx, integration, "cluster1")
+	require.NoError(t, err)
+	assert.Equal(t, "5.51.0", component.Spec.Version)
+	assert.Equal(t, ksitv1alpha1.InstallActionUpgrade, component.Status.LastAction)
+	assert.Equal(t, ksitv1alpha1.InstallResultFailed, component.Status.LastActionResult)
+	assert.Equal(t, "timed out", component.Status.Message)
+	assert.False(t, component.Status.Ready)
+}
@@ -0,0 +1,71 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// defaultHistoryTable is used when no table is configured
+const defaultHistoryTable = "ksit_install_history"
+
+// PostgresStore inserts install history into a PostgreSQL table, creating the table if needed
+type PostgresStore struct {
+	db     *sql.DB
+	insert string
+}
+
+// NewPostgresStore connects to the database at dsn. Connection settings missing from dsn,
+// such as the password, are read from the standard PG* environment variables.
+func NewPostgresStore(ctx context.Context, dsn, table string) (*PostgresStore, error) {
+	if table == "" {
+		table = defaultHistoryTable
+	}
+	quoted := pq.QuoteIdentifier(table)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to history database: %w", err)
+	}
+
+	create := `CREATE TABLE IF NOT EXISTS ` + quoted + ` (
+		id          BIGSERIAL PRIMARY KEY,
+		time        TIMESTAMPTZ NOT NULL,
+		namespace   TEXT NOT NULL,
+		integration TEXT NOT NULL,
+		cluster     TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		action      TEXT NOT NULL,
+		result      TEXT NOT NULL,
+		method      TEXT NOT NULL DEFAULT '',
+		version     TEXT NOT NULL DEFAULT '',
+		values_hash TEXT NOT NULL DEFAULT '',
+		message     TEXT NOT NULL DEFAULT ''
+	)`
+	if _, err := db.ExecContext(ctx, create); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history table %s: %w", table, err)
+	}
+
+	return &PostgresStore{
+		db: db,
+		insert: `INSERT INTO ` + quoted + ` (time, namespace, integration, cluster, type, action, result, method, version, values_hash, message)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+	}, nil
+}
+
+func (s *PostgresStore) Append(ctx context.Context, record HistoryRecord) error {
+	if _, err := s.db.ExecContext(ctx, s.insert,
+		record.Time, record.Namespace, record.Integration, record.Cluster, record.Type,
+		record.Action, record.Result, record.Method, record.Version, record.ValuesHash, record.Message,
+	); err != nil {
+		return fmt.Errorf("failed to insert history record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Output formats of a report
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatHTML     = "html"
+)
+
+// Render writes the report in the given format
+func Render(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return markdownTemplate.Execute(w, r)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	default:
+		return fmt.Errorf("unsupported report format %q, must be %s, %s or %s", format, FormatMarkdown, FormatJSON, FormatHTML)
+	}
+}
+
+var funcs = map[string]interface{}{
+	"time": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+	"scope": func(namespace string) string {
+		if namespace == "" {
+			return "all namespaces"
+		}
+		return "namespace " + namespace
+	},
+	"score": func(score *int32) string {
+		if score == nil {
+			return "-"
+		}
+		return fmt.Sprint(*score)
+	},
+	"versions": func(versions []VersionCount) string {
+		if len(versions) == 0 {
+			return "-"
+		}
+		parts := make([]string, 0, len(versions))
+		for _, v := range versions {
+			parts = append(parts, fmt.Sprintf("%s (%d)", v.Version, v.Clusters))
+		}
+		return strings.Join(parts, ", ")
+	},
+	"ready": func(ready bool) string {
+		if ready {
+			return "Ready"
+		}
+		return "Not ready"
+	},
+	"join": func(values []string) string {
+		if len(values) == 0 {
+			return "-"
+		}
+		return strings.Join(values, ", ")
+	},
+	"orDash": func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	},
+	// cell keeps a value from breaking out of its Markdown table cell
+	"cell": func(value string) string {
+		value = strings.ReplaceAll(value, "|", `\|`)
+		return strings.Join(strings.Fields(value), " ")
+	},
+}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(funcs).Parse(`# Fleet report
+
+Generated {{ time .GeneratedAt }} for {{ scope .Namespace }}. Changes since {{ time .Since }}.
+
+## Summary
+
+| | |
+|---|---|
+| Clusters | {{ .Summary.Clusters }} ({{ .Summary.ReadyClusters }} ready) |
+| Integrations | {{ .Summary.Integrations }} ({{ .Summary.FailingIntegrations }} failing) |
+| Pending upgrades | {{ .Summary.PendingUpgrades }} |
+| Changes | {{ .Summary.Changes }} ({{ .Summary.FailedChanges }} failed) |
+
+## Clusters
+{{ if .Clusters }}
+| Cluster | Status | Integrations | Failing |
+|---|---|---|---|
+{{- range .Clusters }}
+| {{ cell .Name }} | {{ ready .Ready }}{{ if .Message }}: {{ cell .Message }}{{ end }} | {{ .Integrations }} | {{ cell (join .Failing) }} |
+{{- end }}
+{{ else }}
+No clusters.
+{{ end }}
+## Integrations
+{{ if .Integrations }}
+| Integration | Type | Phase | Health | Clusters | Failing | Versions |
+|---|---|---|---|---|---|---|
+{{- range .Integrations }}
+| {{ .Namespace }}/{{ .Name }} | {{ .Type }} | {{ orDash .Phase }} | {{ score .Score }} | {{ .Clusters }} | {{ .FailingClusters }} | {{ cell (versions .Versions) }} |
+{{- end }}
+{{ else }}
+No integrations.
+{{ end }}
+## Pending upgrades
+{{ if .PendingUpgrades }}
+| Campaign | Type | Version | Phase | Wave | Succeeded | Failed | Pending |
+|---|---|---|---|---|---|---|---|
+{{- range .PendingUpgrades }}
+| {{ .Namespace }}/{{ .Name }} | {{ .Type }} | {{ cell (orDash .Version) }} | {{ .Phase }} | {{ .CurrentWave }}/{{ .TotalWaves }} | {{ .Succeeded }} | {{ .Failed }} | {{ .Pending }} |
+{{- end }}
+{{ else }}
+No pending upgrades.
+{{ end }}
+## Recent changes
+{{ if .Changes }}
+| Time | Integration | Cluster | Action | Result | Version | Message |
+|---|---|---|---|---|---|---|
+{{- range .Changes }}
+| {{ time .Time }} | {{ .Namespace }}/{{ .Integration }} | {{ cell .Cluster }} | {{ .Action }} | {{ .Result }} | {{ cell (orDash .Version) }} | {{ cell .Message }} |
+{{- end }}
+{{ else }}
+No changes.
+{{ end -}}
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Fleet report {{ time .GeneratedAt }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #f3f3f3; }
+.bad { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Fleet report</h1>
+<p>Generated {{ time .GeneratedAt }} for {{ scope .Namespace }}. Changes since {{ time .Since }}.</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Clusters</th><td>{{ .Summary.Clusters }} ({{ .Summary.ReadyClusters }} ready)</td></tr>
+<tr><th>Integrations</th><td>{{ .Summary.Integrations }} ({{ .Summary.FailingIntegrations }} failing)</td></tr>
+<tr><th>Pending upgrades</th><td>{{ .Summary.PendingUpgrades }}</td></tr>
+<tr><th>Changes</th><td>{{ .Summary.Changes }} ({{ .Summary.FailedChanges }} failed)</td></tr>
+</table>
+
+<h2>Clusters</h2>
+{{ if .Clusters -}}
+<table>
+<tr><th>Cluster</th><th>Status</th><th>Integrations</th><th>Failing</th></tr>
+{{- range .Clusters }}
+<tr><td>{{ .Name }}</td><td{{ if not .Ready }} class="bad"{{ end }}>{{ ready .Ready }}{{ if .Message }}: {{ .Message }}{{ end }}</td><td>{{ .Integrations }}</td><td{{ if .Failing }} class="bad"{{ end }}>{{ join .Failing }}</td></tr>
+{{- end }}
+</table>
+{{- else -}}
+<p>No clusters.</p>
+{{- end }}
+
+<h2>Integrations</h2>
+{{ if .Integrations -}}
+<table>
+<tr><th>Integration</th><th>Type</th><th>Phase</th><th>Health</th><th>Clusters</th><th>Failing</th><th>Versions</th></tr>
+{{- range .Integrations }}
+<tr><td>{{ .Namespace }}/{{ .Name }}</td><td>{{ .Type }}</td><td>{{ orDash .Phase }}</td><td>{{ score .Score }}</td><td>{{ .Clusters }}</td><td{{ if .FailingClusters }} class="bad"{{ end }}>{{ .FailingClusters }}</td><td>{{ versions .Versions }}</td></tr>
+{{- end }}
+</table>
+{{- else -}}
+<p>No integrations.</p>
+{{- end }}
+
+<h2>Pending upgrades</h2>
+{{ if .PendingUpgrades -}}
+<table>
+<tr><th>Campaign</th><th>Type</th><th>Version</th><th>Phase</th><th>Wave</th><th>Succeeded</th><th>Failed</th><th>Pending</th></tr>
+{{- range .PendingUpgrades }}
+<tr><td>{{ .Namespace }}/{{ .Name }}</td><td>{{ .Type }}</td><td>{{ orDash .Version }}</td><td>{{ .Phase }}</td><td>{{ .CurrentWave }}/{{ .TotalWaves }}</td><td>{{ .Succeeded }}</td><td{{ if .Failed }} class="bad"{{ end }}>{{ .Failed }}</td><td>{{ .Pending }}</td></tr>
+{{- end }}
+</table>
+{{- else -}}
+<p>No pending upgrades.</p>
+{{- end }}
+
+<h2>Recent changes</h2>
+{{ if .Changes -}}
+<table>
+<tr><th>Time</th><th>Integration</th><th>Cluster</th><th>Action</th><th>Result</th><th>Version</th><th>Message</th></tr>
+{{- range .Changes }}
+<tr><td>{{ time .Time }}</td><td>{{ .Namespace }}/{{ .Integration }}</td><td>{{ .Cluster }}</td><td>{{ .Action }}</td><td{{ if eq .Result "Failed" }} class="bad"{{ end }}>{{ .Result }}</td><td>{{ orDash .Version }}</td><td>{{ .Message }}</td></tr>
+{{- end }}
+</table>
+{{- else -}}
+<p>No changes.</p>
+{{- end }}
+</body>
+</html>
+`))
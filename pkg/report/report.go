@@ -0,0 +1,362 @@
+// Package report builds point-in-time fleet reports for change reviews
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+	"github.com/kubestellar/integration-toolkit/pkg/topology"
+)
+
+// Report is the state of the fleet at one point in time, with the changes that led to it
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Namespace the report covers; empty for all namespaces
+	Namespace string `json:"namespace,omitempty"`
+	// Since is the start of the period covered by Changes
+	Since time.Time `json:"since"`
+
+	Summary         Summary       `json:"summary"`
+	Clusters        []Cluster     `json:"clusters"`
+	Integrations    []Integration `json:"integrations"`
+	PendingUpgrades []Upgrade     `json:"pendingUpgrades"`
+	Changes         []Change      `json:"changes"`
+}
+
+// Summary holds the headline numbers of a report
+type Summary struct {
+	Clusters            int `json:"clusters"`
+	ReadyClusters       int `json:"readyClusters"`
+	Integrations        int `json:"integrations"`
+	FailingIntegrations int `json:"failingIntegrations"`
+	PendingUpgrades     int `json:"pendingUpgrades"`
+	Changes             int `json:"changes"`
+	FailedChanges       int `json:"failedChanges"`
+}
+
+// Cluster is one cluster of the fleet
+type Cluster struct {
+	Name string `json:"name"`
+	// Ready is the readiness of the cluster's IntegrationTarget. Clusters that are only
+	// targeted by Integrations have none and are not ready.
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+	// Integrations is the number of Integrations targeting the cluster
+	Integrations int `json:"integrations"`
+	// Failing are the Integrations failing on the cluster, as namespace/name
+	Failing []string `json:"failing,omitempty"`
+}
+
+// Integration is one Integration with its health and installed versions
+type Integration struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Phase     string `json:"phase"`
+	// Score is the fleet health score, when the Integration is scored
+	Score           *int32 `json:"score,omitempty"`
+	Clusters        int    `json:"clusters"`
+	FailingClusters int    `json:"failingClusters"`
+	// Versions are the versions the install ledger recorded, most common first
+	Versions []VersionCount `json:"versions,omitempty"`
+}
+
+// VersionCount is the number of clusters an Integration runs a version on
+type VersionCount struct {
+	Version  string `json:"version"`
+	Clusters int    `json:"clusters"`
+}
+
+// Upgrade is an UpgradeCampaign that has not finished
+type Upgrade struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Version     string `json:"version,omitempty"`
+	Phase       string `json:"phase"`
+	CurrentWave int32  `json:"currentWave"`
+	TotalWaves  int32  `json:"totalWaves"`
+	Succeeded   int32  `json:"succeeded"`
+	Failed      int32  `json:"failed"`
+	// Pending is the number of clusters still to upgrade
+	Pending int32 `json:"pending"`
+}
+
+// Change is an install action of the audit log
+type Change struct {
+	Time        time.Time `json:"time"`
+	Namespace   string    `json:"namespace"`
+	Integration string    `json:"integration"`
+	Cluster     string    `json:"cluster"`
+	Type        string    `json:"type"`
+	Action      string    `json:"action"`
+	Result      string    `json:"result"`
+	Version     string    `json:"version,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// Options select what a report covers
+type Options struct {
+	// Namespace to report on; empty for all namespaces
+	Namespace string
+	// Now is when the report is generated
+	Now time.Time
+	// Period is how far back Changes go
+	Period time.Duration
+	// History, when set, is the install history the changes are read from. Without it
+	// the changes are the last action of each InstalledComponent.
+	History []ledger.HistoryRecord
+}
+
+// Build reads the fleet from the hub and computes the report
+func Build(ctx context.Context, c client.Reader, opts Options) (*Report, error) {
+	var listOpts []client.ListOption
+	if opts.Namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(opts.Namespace))
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.List(ctx, integrations, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := c.List(ctx, targets, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+	components := &ksitv1alpha1.InstalledComponentList{}
+	if err := c.List(ctx, components, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list installed components: %w", err)
+	}
+	campaigns := &ksitv1alpha1.UpgradeCampaignList{}
+	if err := c.List(ctx, campaigns, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list upgrade campaigns: %w", err)
+	}
+
+	return Compute(opts, integrations.Items, targets.Items, components.Items, campaigns.Items), nil
+}
+
+// Compute builds the report from already listed objects
+func Compute(opts Options, integrations []ksitv1alpha1.Integration, targets []ksitv1alpha1.IntegrationTarget,
+	components []ksitv1alpha1.InstalledComponent, campaigns []ksitv1alpha1.UpgradeCampaign) *Report {
+	topo := topology.Compute(integrations, targets, components)
+
+	r := &Report{
+		GeneratedAt: opts.Now,
+		Namespace:   opts.Namespace,
+		Since:       opts.Now.Add(-opts.Period),
+	}
+	r.Integrations = integrationRows(integrations, topo)
+	r.Clusters = clusterRows(targets, topo)
+	r.PendingUpgrades = pendingUpgrades(campaigns)
+	if opts.History != nil {
+		r.Changes = historyChanges(opts.History, opts.Namespace, r.Since)
+	} else {
+		r.Changes = componentChanges(components, r.Since)
+	}
+
+	r.Summary = Summary{
+		Clusters:        len(r.Clusters),
+		Integrations:    len(r.Integrations),
+		PendingUpgrades: len(r.PendingUpgrades),
+		Changes:         len(r.Changes),
+	}
+	for _, cluster := range r.Clusters {
+		if cluster.Ready {
+			r.Summary.ReadyClusters++
+		}
+	}
+	for _, integration := range r.Integrations {
+		if integration.FailingClusters > 0 || integration.Phase == ksitv1alpha1.PhaseFailed {
+			r.Summary.FailingIntegrations++
+		}
+	}
+	for _, change := range r.Changes {
+		if change.Result == ksitv1alpha1.InstallResultFailed {
+			r.Summary.FailedChanges++
+		}
+	}
+	return r
+}
+
+func integrationRows(integrations []ksitv1alpha1.Integration, topo *topology.Topology) []Integration {
+	rows := make([]Integration, 0, len(integrations))
+	for i := range integrations {
+		integration := &integrations[i]
+		row := Integration{
+			Namespace: integration.Namespace,
+			Name:      integration.Name,
+			Type:      integration.Spec.Type,
+			Phase:     integration.Status.Phase,
+		}
+		if health := integration.Status.Health; health != nil {
+			score := health.Score
+			row.Score = &score
+		}
+
+		versions := map[string]int{}
+		for _, clusterName := range integration.Targets() {
+			row.Clusters++
+			for _, entry := range topo.Cell(clusterName, integration.Spec.Type) {
+				if entry.Namespace != integration.Namespace || entry.Integration != integration.Name {
+					continue
+				}
+				if entry.Health == topology.HealthFailing {
+					row.FailingClusters++
+				}
+				if entry.Version != "" {
+					versions[entry.Version]++
+				}
+			}
+		}
+		for version, clusters := range versions {
+			row.Versions = append(row.Versions, VersionCount{Version: version, Clusters: clusters})
+		}
+		sort.Slice(row.Versions, func(i, j int) bool {
+			if row.Versions[i].Clusters != row.Versions[j].Clusters {
+				return row.Versions[i].Clusters > row.Versions[j].Clusters
+			}
+			return row.Versions[i].Version < row.Versions[j].Version
+		})
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Namespace != rows[j].Namespace {
+			return rows[i].Namespace < rows[j].Namespace
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+func clusterRows(targets []ksitv1alpha1.IntegrationTarget, topo *topology.Topology) []Cluster {
+	targetsByCluster := make(map[string]*ksitv1alpha1.IntegrationTarget, len(targets))
+	for i := range targets {
+		targetsByCluster[targets[i].Spec.ClusterName] = &targets[i]
+	}
+
+	rows := make([]Cluster, 0, len(topo.Clusters))
+	for _, clusterName := range topo.Clusters {
+		row := Cluster{Name: clusterName}
+		if target, ok := targetsByCluster[clusterName]; ok {
+			row.Ready = target.Status.Ready
+			row.Message = target.Status.Message
+		} else {
+			row.Message = "No IntegrationTarget"
+		}
+		for _, integrationType := range topo.Types {
+			for _, entry := range topo.Cell(clusterName, integrationType) {
+				row.Integrations++
+				if entry.Health == topology.HealthFailing {
+					row.Failing = append(row.Failing, entry.Namespace+"/"+entry.Integration)
+				}
+			}
+		}
+		sort.Strings(row.Failing)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// pendingUpgrades returns the campaigns that have not succeeded, failed or been aborted
+func pendingUpgrades(campaigns []ksitv1alpha1.UpgradeCampaign) []Upgrade {
+	var upgrades []Upgrade
+	for _, campaign := range campaigns {
+		switch campaign.Status.Phase {
+		case ksitv1alpha1.CampaignPhaseSucceeded, ksitv1alpha1.CampaignPhaseFailed, ksitv1alpha1.CampaignPhaseAborted:
+			continue
+		}
+
+		upgrade := Upgrade{
+			Namespace:   campaign.Namespace,
+			Name:        campaign.Name,
+			Type:        campaign.Spec.IntegrationType,
+			Version:     campaign.Spec.Version,
+			Phase:       campaign.Status.Phase,
+			CurrentWave: campaign.Status.CurrentWave,
+			TotalWaves:  campaign.Status.TotalWaves,
+			Succeeded:   campaign.Status.Succeeded,
+			Failed:      campaign.Status.Failed,
+		}
+		if upgrade.Phase == "" {
+			upgrade.Phase = ksitv1alpha1.CampaignPhasePending
+		}
+		if upgrade.Version == "" {
+			upgrade.Version = campaign.Spec.ManifestURL
+		}
+		for _, cluster := range campaign.Status.Clusters {
+			if cluster.State == ksitv1alpha1.CampaignClusterPending {
+				upgrade.Pending++
+			}
+		}
+		upgrades = append(upgrades, upgrade)
+	}
+
+	sort.Slice(upgrades, func(i, j int) bool {
+		if upgrades[i].Namespace != upgrades[j].Namespace {
+			return upgrades[i].Namespace < upgrades[j].Namespace
+		}
+		return upgrades[i].Name < upgrades[j].Name
+	})
+	return upgrades
+}
+
+// historyChanges returns the history records of the namespace since the given time, newest first
+func historyChanges(history []ledger.HistoryRecord, namespace string, since time.Time) []Change {
+	var changes []Change
+	for _, record := range history {
+		if record.Time.Before(since) || (namespace != "" && record.Namespace != namespace) {
+			continue
+		}
+		changes = append(changes, Change{
+			Time:        record.Time,
+			Namespace:   record.Namespace,
+			Integration: record.Integration,
+			Cluster:     record.Cluster,
+			Type:        record.Type,
+			Action:      record.Action,
+			Result:      record.Result,
+			Version:     record.Version,
+			Message:     record.Message,
+		})
+	}
+	sortChanges(changes)
+	return changes
+}
+
+// componentChanges returns the last action of each InstalledComponent since the given
+// time, newest first. Earlier actions on the same component are only kept by the history.
+func componentChanges(components []ksitv1alpha1.InstalledComponent, since time.Time) []Change {
+	var changes []Change
+	for _, component := range components {
+		actionTime := component.Status.LastActionTime
+		if actionTime == nil || actionTime.Time.Before(since) {
+			continue
+		}
+		changes = append(changes, Change{
+			Time:        actionTime.Time,
+			Namespace:   component.Namespace,
+			Integration: component.Spec.IntegrationName,
+			Cluster:     component.Spec.ClusterName,
+			Type:        component.Spec.Type,
+			Action:      component.Status.LastAction,
+			Result:      component.Status.LastActionResult,
+			Version:     component.Spec.Version,
+			Message:     component.Status.Message,
+		})
+	}
+	sortChanges(changes)
+	return changes
+}
+
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Time.After(changes[j].Time)
+	})
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
+)
+
+var now = time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+func fleet() ([]ksitv1alpha1.Integration, []ksitv1alpha1.IntegrationTarget, []ksitv1alpha1.InstalledComponent, []ksitv1alpha1.UpgradeCampaign) {
+	integrations := []ksitv1alpha1.Integration{{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			Enabled:        true,
+			TargetClusters: []string{"edge-1", "edge-2", "edge-3"},
+		},
+		Status: ksitv1alpha1.IntegrationStatus{
+			Phase: ksitv1alpha1.PhaseRunning,
+			ClusterStatuses: []ksitv1alpha1.ClusterStatus{
+				{Name: "edge-3", Connected: false, Message: "argocd-server | not ready"},
+				{Name: "edge-1", Connected: true},
+				{Name: "edge-2", Connected: true},
+			},
+			Health: &ksitv1alpha1.HealthScore{Score: 67},
+		},
+	}}
+	targets := []ksitv1alpha1.IntegrationTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-1", Namespace: "ksit-system"},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-1"},
+			Status:     ksitv1alpha1.IntegrationTargetStatus{Ready: true},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-2", Namespace: "ksit-system"},
+			Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-2"},
+			Status:     ksitv1alpha1.IntegrationTargetStatus{Ready: true},
+		},
+	}
+	component := func(cluster, version, action, result string, at time.Time) ksitv1alpha1.InstalledComponent {
+		actionTime := metav1.NewTime(at)
+		return ksitv1alpha1.InstalledComponent{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-" + cluster, Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.InstalledComponentSpec{
+				IntegrationName: "argocd", ClusterName: cluster, Type: ksitv1alpha1.IntegrationTypeArgoCD, Version: version,
+			},
+			Status: ksitv1alpha1.InstalledComponentStatus{LastAction: action, LastActionResult: result, LastActionTime: &actionTime},
+		}
+	}
+	components := []ksitv1alpha1.InstalledComponent{
+		component("edge-1", "5.51.6", ksitv1alpha1.InstallActionUpgrade, ksitv1alpha1.InstallResultSucceeded, now.Add(-time.Hour)),
+		component("edge-2", "5.46.0", ksitv1alpha1.InstallActionInstall, ksitv1alpha1.InstallResultSucceeded, now.Add(-30*24*time.Hour)),
+		component("edge-3", "5.46.0", ksitv1alpha1.InstallActionUpgrade, ksitv1alpha1.InstallResultFailed, now.Add(-2*time.Hour)),
+	}
+	campaigns := []ksitv1alpha1.UpgradeCampaign{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-5-51", Namespace: "ksit-system"},
+			Spec:       ksitv1alpha1.UpgradeCampaignSpec{IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD, Version: "5.51.6"},
+			Status: ksitv1alpha1.UpgradeCampaignStatus{
+				Phase: ksitv1alpha1.CampaignPhaseProgressing, CurrentWave: 1, TotalWaves: 3, Succeeded: 1, Failed: 1,
+				Clusters: []ksitv1alpha1.CampaignClusterStatus{
+					{Cluster: "edge-1", State: ksitv1alpha1.CampaignClusterSucceeded},
+					{Cluster: "edge-3", State: ksitv1alpha1.CampaignClusterFailed},
+					{Cluster: "edge-2", State: ksitv1alpha1.CampaignClusterPending},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-5-46", Namespace: "ksit-system"},
+			Spec:       ksitv1alpha1.UpgradeCampaignSpec{IntegrationType: ksitv1alpha1.IntegrationTypeArgoCD, Version: "5.46.0"},
+			Status:     ksitv1alpha1.UpgradeCampaignStatus{Phase: ksitv1alpha1.CampaignPhaseSucceeded},
+		},
+	}
+	return integrations, targets, components, campaigns
+}
+
+func TestCompute(t *testing.T) {
+	integrations, targets, components, campaigns := fleet()
+	r := Compute(Options{Namespace: "ksit-system", Now: now, Period: 7 * 24 * time.Hour}, integrations, targets, components, campaigns)
+
+	assert.Equal(t, Summary{
+		Clusters: 3, ReadyClusters: 2, Integrations: 1, FailingIntegrations: 1,
+		PendingUpgrades: 1, Changes: 2, FailedChanges: 1,
+	}, r.Summary)
+
+	require.Len(t, r.Clusters, 3)
+	assert.Equal(t, Cluster{Name: "edge-3", Message: "No IntegrationTarget", Integrations: 1, Failing: []string{"ksit-system/argocd"}}, r.Clusters[2])
+
+	require.Len(t, r.Integrations, 1)
+	assert.Equal(t, 1, r.Integrations[0].FailingClusters)
+	assert.Equal(t, []VersionCount{{Version: "5.46.0", Clusters: 2}, {Version: "5.51.6", Clusters: 1}}, r.Integrations[0].Versions)
+
+	require.Len(t, r.PendingUpgrades, 1)
+	assert.Equal(t, int32(1), r.PendingUpgrades[0].Pending)
+
+	// Newest first; the install on edge-2 is older than the period
+	require.Len(t, r.Changes, 2)
+	assert.Equal(t, "edge-1", r.Changes[0].Cluster)
+	assert.Equal(t, "edge-3", r.Changes[1].Cluster)
+}
+
+func TestComputeFromHistory(t *testing.T) {
+	integrations, targets, components, campaigns := fleet()
+	history := []ledger.HistoryRecord{
+		{Time: now.Add(-3 * time.Hour), Namespace: "ksit-system", Integration: "argocd", Cluster: "edge-1", Action: ksitv1alpha1.InstallActionUpgrade, Result: ksitv1alpha1.InstallResultFailed},
+		{Time: now.Add(-time.Hour), Namespace: "ksit-system", Integration: "argocd", Cluster: "edge-1", Action: ksitv1alpha1.InstallActionUpgrade, Result: ksitv1alpha1.InstallResultSucceeded},
+		{Time: now.Add(-time.Hour), Namespace: "team-a", Integration: "flux", Cluster: "edge-1", Action: ksitv1alpha1.InstallActionInstall, Result: ksitv1alpha1.InstallResultSucceeded},
+		{Time: now.Add(-8 * 24 * time.Hour), Namespace: "ksit-system", Integration: "argocd", Cluster: "edge-2", Action: ksitv1alpha1.InstallActionInstall, Result: ksitv1alpha1.InstallResultSucceeded},
+	}
+	r := Compute(Options{Namespace: "ksit-system", Now: now, Period: 7 * 24 * time.Hour, History: history}, integrations, targets, components, campaigns)
+
+	require.Len(t, r.Changes, 2, "every action in the period, not only the last one")
+	assert.Equal(t, ksitv1alpha1.InstallResultSucceeded, r.Changes[0].Result)
+	assert.Equal(t, ksitv1alpha1.InstallResultFailed, r.Changes[1].Result)
+}
+
+func TestBuildAndRender(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	integrations, targets, components, campaigns := fleet()
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for i := range integrations {
+		builder.WithObjects(&integrations[i])
+	}
+	for i := range targets {
+		builder.WithObjects(&targets[i])
+	}
+	for i := range components {
+		builder.WithObjects(&components[i])
+	}
+	for i := range campaigns {
+		builder.WithObjects(&campaigns[i])
+	}
+
+	r, err := Build(context.Background(), builder.Build(), Options{Now: now, Period: 7 * 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 3, r.Summary.Clusters)
+
+	var markdown bytes.Buffer
+	require.NoError(t, Render(&markdown, r, FormatMarkdown))
+	assert.Contains(t, markdown.String(), "Generated 2026-03-09 09:00 UTC for all namespaces")
+	assert.Contains(t, markdown.String(), "| ksit-system/argocd | argocd | Running | 67 | 3 | 1 | 5.46.0 (2), 5.51.6 (1) |")
+	assert.Contains(t, markdown.String(), "| ksit-system/argocd-5-51 | argocd | 5.51.6 | Progressing | 1/3 | 1 | 1 | 1 |")
+	assert.Contains(t, markdown.String(), "## Recent changes")
+
+	var html bytes.Buffer
+	require.NoError(t, Render(&html, r, FormatHTML))
+	assert.Contains(t, html.String(), "<td>ksit-system/argocd</td>")
+
+	var out bytes.Buffer
+	require.NoError(t, Render(&out, r, FormatJSON))
+	decoded := &Report{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), decoded))
+	assert.Equal(t, r.Summary, decoded.Summary)
+
+	assert.Error(t, Render(&out, r, "pdf"))
+}
@@ -0,0 +1,66 @@
+// Package rollout plans wave-by-wave rollouts across clusters
+package rollout
+
+import (
+	"sort"
+)
+
+// Target is one unit of a rollout: an Integration on a cluster
+type Target struct {
+	Integration string
+	Cluster     string
+}
+
+// Strategy controls how targets are grouped into waves and when a rollout stops
+type Strategy struct {
+	// WaveSize is the number of clusters per wave; values below 1 mean 1
+	WaveSize int
+	// MaxFailures is the number of failed targets tolerated before the rollout halts
+	MaxFailures int
+}
+
+// Plan groups targets into waves of at most WaveSize clusters. Clusters are taken
+// in name order, and every target on a cluster lands in the same wave so a cluster
+// is never left half upgraded between waves.
+func (s Strategy) Plan(targets []Target) [][]Target {
+	waveSize := s.WaveSize
+	if waveSize < 1 {
+		waveSize = 1
+	}
+
+	byCluster := map[string][]Target{}
+	for _, t := range targets {
+		byCluster[t.Cluster] = append(byCluster[t.Cluster], t)
+	}
+
+	clusters := make([]string, 0, len(byCluster))
+	for cluster := range byCluster {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var waves [][]Target
+	for i := 0; i < len(clusters); i += waveSize {
+		end := i + waveSize
+		if end > len(clusters) {
+			end = len(clusters)
+		}
+
+		var wave []Target
+		for _, cluster := range clusters[i:end] {
+			clusterTargets := byCluster[cluster]
+			sort.Slice(clusterTargets, func(a, b int) bool {
+				return clusterTargets[a].Integration < clusterTargets[b].Integration
+			})
+			wave = append(wave, clusterTargets...)
+		}
+		waves = append(waves, wave)
+	}
+
+	return waves
+}
+
+// Halted reports whether a rollout with the given number of failures must stop
+func (s Strategy) Halted(failures int) bool {
+	return failures > s.MaxFailures
+}
@@ -0,0 +1,37 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	targets := []Target{
+		{Integration: "argocd-prod", Cluster: "cluster-c"},
+		{Integration: "argocd-prod", Cluster: "cluster-a"},
+		{Integration: "argocd-dev", Cluster: "cluster-a"},
+		{Integration: "argocd-prod", Cluster: "cluster-b"},
+	}
+
+	waves := Strategy{WaveSize: 2}.Plan(targets)
+	require.Len(t, waves, 2)
+	assert.Equal(t, []Target{
+		{Integration: "argocd-dev", Cluster: "cluster-a"},
+		{Integration: "argocd-prod", Cluster: "cluster-a"},
+		{Integration: "argocd-prod", Cluster: "cluster-b"},
+	}, waves[0])
+	assert.Equal(t, []Target{{Integration: "argocd-prod", Cluster: "cluster-c"}}, waves[1])
+
+	// A zero wave size rolls out one cluster at a time
+	assert.Len(t, Strategy{}.Plan(targets), 3)
+	assert.Empty(t, Strategy{WaveSize: 2}.Plan(nil))
+}
+
+func TestHalted(t *testing.T) {
+	s := Strategy{MaxFailures: 1}
+	assert.False(t, s.Halted(1))
+	assert.True(t, s.Halted(2))
+	assert.True(t, Strategy{}.Halted(1))
+}
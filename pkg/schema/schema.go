@@ -0,0 +1,187 @@
+// Package schema publishes JSON Schemas of the KSIT resources that users write, so
+// that Terraform, Crossplane and Pulumi providers, form UIs and editors can validate
+// specs without vendoring the Go module.
+//
+// The schemas are generated from the CRDs controller-gen generates from the API types,
+// and embedded in the binary; run "make schemas" after changing the types.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Draft is the JSON Schema dialect of the published schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Kinds are the published resources, by schema name
+var Kinds = map[string]string{
+	"integration":       "Integration",
+	"integrationtarget": "IntegrationTarget",
+}
+
+//go:embed *.schema.json
+var files embed.FS
+
+// Names returns the names of the published schemas, sorted
+func Names() []string {
+	names := make([]string, 0, len(Kinds))
+	for name := range Kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the JSON Schema published as name, e.g. integration
+func Get(name string) ([]byte, bool) {
+	if _, ok := Kinds[name]; !ok {
+		return nil, false
+	}
+	data, err := files.ReadFile(FileName(name))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// FileName is the file the schema published as name is stored in
+func FileName(name string) string {
+	return name + ".schema.json"
+}
+
+// FromCRDDir generates the schema published as name from its CRD in dir, e.g.
+// config/crd/bases
+func FromCRDDir(dir, name string) ([]byte, error) {
+	path := filepath.Join(dir, "ksit.io_"+name+"s.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRD: %w", err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(data, crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD %s: %w", path, err)
+	}
+	return FromCRD(crd)
+}
+
+// FromCRD converts the schema of the storage version of crd to a standalone JSON
+// Schema of the resource a user writes: apiVersion and kind are pinned, status is left
+// out, and fields the CRD does not declare are rejected rather than pruned.
+func FromCRD(crd *apiextensionsv1.CustomResourceDefinition) ([]byte, error) {
+	var version *apiextensionsv1.CustomResourceDefinitionVersion
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Storage {
+			version = &crd.Spec.Versions[i]
+		}
+	}
+	if version == nil || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+		return nil, fmt.Errorf("CRD %s has no storage version schema", crd.Name)
+	}
+
+	data, err := json.Marshal(version.Schema.OpenAPIV3Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema of CRD %s: %w", crd.Name, err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schema of CRD %s: %w", crd.Name, err)
+	}
+	convert(schema)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	if properties == nil {
+		return nil, fmt.Errorf("CRD %s has no properties", crd.Name)
+	}
+	delete(properties, "status")
+	properties["apiVersion"] = map[string]interface{}{"const": crd.Spec.Group + "/" + version.Name}
+	properties["kind"] = map[string]interface{}{"const": crd.Spec.Names.Kind}
+	properties["metadata"] = metadataSchema(crd.Spec.Scope == apiextensionsv1.NamespaceScoped)
+	required := []string{"apiVersion", "kind", "metadata"}
+	if _, ok := properties["spec"]; ok {
+		required = append(required, "spec")
+	}
+
+	schema["$schema"] = Draft
+	schema["title"] = crd.Spec.Names.Kind
+	schema["required"] = required
+	schema["additionalProperties"] = false
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON schema of CRD %s: %w", crd.Name, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// convert rewrites an OpenAPI v3 schema of a CRD in place to JSON Schema: nullable
+// becomes a null type, the Kubernetes extensions are dropped, and objects with declared
+// properties are closed unless they preserve unknown fields.
+func convert(schema map[string]interface{}) {
+	preserveUnknown, _ := schema["x-kubernetes-preserve-unknown-fields"].(bool)
+	for key := range schema {
+		if strings.HasPrefix(key, "x-kubernetes-") {
+			delete(schema, key)
+		}
+	}
+	if nullable, _ := schema["nullable"].(bool); nullable {
+		if t, ok := schema["type"].(string); ok {
+			schema["type"] = []interface{}{t, "null"}
+		}
+	}
+	delete(schema, "nullable")
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			if property, ok := property.(map[string]interface{}); ok {
+				convert(property)
+			}
+		}
+		if _, ok := schema["additionalProperties"]; !ok && !preserveUnknown {
+			schema["additionalProperties"] = false
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := schema[key].(map[string]interface{}); ok {
+			convert(sub)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if subs, ok := schema[key].([]interface{}); ok {
+			for _, sub := range subs {
+				if sub, ok := sub.(map[string]interface{}); ok {
+					convert(sub)
+				}
+			}
+		}
+	}
+}
+
+// metadataSchema is the schema of the object metadata a user sets; CRDs leave it
+// undeclared
+func metadataSchema(namespaced bool) map[string]interface{} {
+	stringMap := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+	properties := map[string]interface{}{
+		"name":        map[string]interface{}{"type": "string", "minLength": 1},
+		"labels":      stringMap,
+		"annotations": stringMap,
+	}
+	if namespaced {
+		properties["namespace"] = map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   []string{"name"},
+	}
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemasUpToDate(t *testing.T) {
+	for _, name := range Names() {
+		generated, err := FromCRDDir("../../config/crd/bases", name)
+		require.NoError(t, err)
+		published, ok := Get(name)
+		require.True(t, ok, name)
+		assert.Equal(t, string(generated), string(published), "%s schema is stale, run make schemas", name)
+	}
+}
+
+func TestIntegrationSchema(t *testing.T) {
+	data, ok := Get("integration")
+	require.True(t, ok)
+	var schema struct {
+		Schema     string   `json:"$schema"`
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Const                string                     `json:"const"`
+			AdditionalProperties *bool                      `json:"additionalProperties"`
+			Properties           map[string]json.RawMessage `json:"properties"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	assert.Equal(t, Draft, schema.Schema)
+	assert.Equal(t, []string{"apiVersion", "kind", "metadata", "spec"}, schema.Required)
+	assert.Equal(t, "ksit.io/v1alpha1", schema.Properties["apiVersion"].Const)
+	assert.Equal(t, "Integration", schema.Properties["kind"].Const)
+	assert.NotContains(t, schema.Properties, "status")
+
+	spec := schema.Properties["spec"]
+	require.NotNil(t, spec.AdditionalProperties)
+	assert.False(t, *spec.AdditionalProperties, "unknown spec fields are rejected")
+	assert.JSONEq(t, `{"description":"Type specifies the integration type (argocd, flux, prometheus, istio, grafana, cert-manager, kyverno)","enum":["argocd","flux","prometheus","istio","grafana","cert-manager","kyverno"],"type":"string"}`,
+		string(spec.Properties["type"]))
+
+	_, ok = Get("upgradecampaign")
+	assert.False(t, ok)
+}
+
+func TestConvert(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"port": map[string]interface{}{
+				"anyOf":                      []interface{}{map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"}},
+				"x-kubernetes-int-or-string": true,
+			},
+			"expiry": map[string]interface{}{"type": "string", "nullable": true},
+			"values": map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true, "properties": map[string]interface{}{}},
+		},
+	}
+	convert(schema)
+
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","additionalProperties":false,"properties":{
+		"port":{"anyOf":[{"type":"integer"},{"type":"string"}]},
+		"expiry":{"type":["string","null"]},
+		"values":{"type":"object","properties":{}}}}`, string(data))
+}
@@ -0,0 +1,481 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// FleetFile lists the clusters to onboard at once, as read by `ksit onboard --fleet-file`
+type FleetFile struct {
+	// Defaults apply to every cluster that does not set them; labels are merged
+	Defaults FleetCluster `json:"defaults,omitempty"`
+	// Clusters to onboard
+	Clusters []FleetCluster `json:"clusters"`
+}
+
+// FleetCluster is one cluster of a FleetFile
+type FleetCluster struct {
+	// Name is the cluster name Integrations refer to in targetClusters
+	Name string `json:"name,omitempty"`
+	// Kubeconfig is the path of the cluster's kubeconfig, relative to the fleet file
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Context of the kubeconfig to use. Defaults to the context named like the cluster
+	// when there is one, and to the current context otherwise.
+	Context string `json:"context,omitempty"`
+	// Namespace is the default namespace on the target cluster
+	Namespace string `json:"namespace,omitempty"`
+	// Labels are applied to resources KSIT creates on the cluster
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// fleetCSVColumns are the columns a CSV fleet file may have. Labels are written as
+// key=value pairs separated by semicolons.
+var fleetCSVColumns = []string{"name", "kubeconfig", "context", "namespace", "labels"}
+
+// ParseFleetFile parses a fleet file in YAML, or in CSV with a header row naming
+// fleetCSVColumns when csvFormat is set
+func ParseFleetFile(data []byte, csvFormat bool) (*FleetFile, error) {
+	if !csvFormat {
+		fleet := &FleetFile{}
+		if err := yaml.UnmarshalStrict(data, fleet); err != nil {
+			return nil, fmt.Errorf("failed to parse fleet file: %w", err)
+		}
+		return fleet, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet file header: %w", err)
+	}
+	for _, column := range header {
+		if !slices.Contains(fleetCSVColumns, column) {
+			return nil, fmt.Errorf("unknown fleet file column %q, must be one of: %s", column, strings.Join(fleetCSVColumns, ", "))
+		}
+	}
+
+	fleet := &FleetFile{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fleet file: %w", err)
+		}
+		cluster := FleetCluster{}
+		for i, value := range record {
+			switch header[i] {
+			case "name":
+				cluster.Name = value
+			case "kubeconfig":
+				cluster.Kubeconfig = value
+			case "context":
+				cluster.Context = value
+			case "namespace":
+				cluster.Namespace = value
+			case "labels":
+				if cluster.Labels, err = parseLabels(value); err != nil {
+					line, _ := reader.FieldPos(i)
+					return nil, fmt.Errorf("invalid labels on line %d: %w", line, err)
+				}
+			}
+		}
+		fleet.Clusters = append(fleet.Clusters, cluster)
+	}
+	return fleet, nil
+}
+
+// parseLabels parses key=value pairs separated by semicolons
+func parseLabels(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not key=value", pair)
+		}
+		labels[key] = val
+	}
+	return labels, nil
+}
+
+// LoadFleet reads a fleet file, .csv files as CSV and others as YAML, and returns a
+// registration per cluster. Each registration holds a flattened kubeconfig with only
+// its cluster's context, so that a merged kubeconfig shared by the fleet is not copied
+// into every cluster's Secret. Every invalid cluster is reported, not just the first.
+func LoadFleet(path string) ([]ClusterRegistration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet file: %w", err)
+	}
+	fleet, err := ParseFleetFile(data, strings.EqualFold(filepath.Ext(path), ".csv"))
+	if err != nil {
+		return nil, err
+	}
+	if len(fleet.Clusters) == 0 {
+		return nil, fmt.Errorf("fleet file %s lists no clusters", path)
+	}
+
+	baseDir := filepath.Dir(path)
+	kubeconfigs := map[string]*clientcmdapi.Config{}
+	regs := make([]ClusterRegistration, 0, len(fleet.Clusters))
+	var errs []error
+	for i, cluster := range fleet.Clusters {
+		cluster = fleet.withDefaults(cluster)
+		if cluster.Name == "" {
+			errs = append(errs, fmt.Errorf("cluster %d: name is required", i+1))
+			continue
+		}
+		if cluster.Kubeconfig == "" {
+			errs = append(errs, fmt.Errorf("cluster %s: kubeconfig is required", cluster.Name))
+			continue
+		}
+
+		kubeconfigPath := cluster.Kubeconfig
+		if !filepath.IsAbs(kubeconfigPath) {
+			kubeconfigPath = filepath.Join(baseDir, kubeconfigPath)
+		}
+		config, ok := kubeconfigs[kubeconfigPath]
+		if !ok {
+			if config, err = clientcmd.LoadFromFile(kubeconfigPath); err != nil {
+				errs = append(errs, fmt.Errorf("cluster %s: failed to load kubeconfig: %w", cluster.Name, err))
+				continue
+			}
+			kubeconfigs[kubeconfigPath] = config
+		}
+		kubeconfig, err := minifyKubeconfig(config, cluster)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster.Name, err))
+			continue
+		}
+
+		regs = append(regs, ClusterRegistration{
+			Name:       cluster.Name,
+			Kubeconfig: kubeconfig,
+			Namespace:  cluster.Namespace,
+			Labels:     cluster.Labels,
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return regs, nil
+}
+
+// withDefaults fills the fields cluster does not set from the fleet defaults
+func (f *FleetFile) withDefaults(cluster FleetCluster) FleetCluster {
+	if cluster.Kubeconfig == "" {
+		cluster.Kubeconfig = f.Defaults.Kubeconfig
+	}
+	if cluster.Context == "" {
+		cluster.Context = f.Defaults.Context
+	}
+	if cluster.Namespace == "" {
+		cluster.Namespace = f.Defaults.Namespace
+	}
+	if len(f.Defaults.Labels) > 0 {
+		labels := make(map[string]string, len(f.Defaults.Labels)+len(cluster.Labels))
+		for key, value := range f.Defaults.Labels {
+			labels[key] = value
+		}
+		for key, value := range cluster.Labels {
+			labels[key] = value
+		}
+		cluster.Labels = labels
+	}
+	return cluster
+}
+
+// minifyKubeconfig returns config reduced to the context of cluster, with the
+// certificates and keys it references inlined
+func minifyKubeconfig(config *clientcmdapi.Config, cluster FleetCluster) ([]byte, error) {
+	minified := config.DeepCopy()
+	switch {
+	case cluster.Context != "":
+		minified.CurrentContext = cluster.Context
+	case minified.Contexts[cluster.Name] != nil:
+		minified.CurrentContext = cluster.Name
+	}
+	if err := clientcmdapi.MinifyConfig(minified); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	if err := clientcmdapi.FlattenConfig(minified); err != nil {
+		return nil, fmt.Errorf("failed to inline kubeconfig files: %w", err)
+	}
+	data, err := clientcmd.Write(*minified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+	return data, nil
+}
+
+// OnboardAction is what onboarding did to a cluster
+type OnboardAction string
+
+const (
+	// OnboardCreated means the cluster's IntegrationTarget was created
+	OnboardCreated OnboardAction = "created"
+	// OnboardUpdated means its kubeconfig or IntegrationTarget changed
+	OnboardUpdated OnboardAction = "updated"
+	// OnboardUnchanged means it was already onboarded as requested
+	OnboardUnchanged OnboardAction = "unchanged"
+	// OnboardFailed means onboarding the cluster failed
+	OnboardFailed OnboardAction = "failed"
+	// OnboardRolledBack means the cluster was onboarded, then restored because
+	// another cluster failed
+	OnboardRolledBack OnboardAction = "rolled back"
+	// OnboardSkipped means the cluster was not onboarded because another one failed
+	OnboardSkipped OnboardAction = "skipped"
+)
+
+// OnboardOptions tune OnboardFleet
+type OnboardOptions struct {
+	// DryRun sends every write as a server-side dry run: nothing is persisted, but
+	// the result reports what would change and the API server's admission errors
+	DryRun bool
+	// ContinueOnError onboards the remaining clusters after one fails, instead of
+	// stopping and rolling back the clusters onboarded so far
+	ContinueOnError bool
+	// Progress, when set, is called after each cluster with the number of clusters done
+	Progress func(done, total int, result ClusterResult)
+}
+
+// ClusterResult is the outcome of onboarding one cluster
+type ClusterResult struct {
+	Name   string
+	Action OnboardAction
+	// Err is why the cluster failed, or why rolling it back failed
+	Err error
+}
+
+// OnboardResult is the outcome of onboarding a fleet, one result per cluster in order
+type OnboardResult struct {
+	Clusters []ClusterResult
+}
+
+// Count returns how many clusters ended with action
+func (r *OnboardResult) Count(action OnboardAction) int {
+	count := 0
+	for _, cluster := range r.Clusters {
+		if cluster.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// OnboardFleet registers many clusters as RegisterCluster does, all or nothing: every
+// registration is validated before anything is written, and when a cluster fails the
+// clusters onboarded before it are restored to their previous state, unless
+// opts.ContinueOnError is set. The result reports what happened to each cluster even
+// when an error is returned.
+func (c *Client) OnboardFleet(ctx context.Context, regs []ClusterRegistration, opts OnboardOptions) (*OnboardResult, error) {
+	if err := validateRegistrations(regs); err != nil {
+		return nil, err
+	}
+
+	result := &OnboardResult{Clusters: make([]ClusterResult, len(regs))}
+	for i, reg := range regs {
+		result.Clusters[i] = ClusterResult{Name: reg.Name, Action: OnboardSkipped}
+	}
+
+	var undos []func(context.Context) error
+	failed := 0
+	for i, reg := range regs {
+		action, undo, err := c.onboardCluster(ctx, reg, opts.DryRun)
+		if err != nil {
+			failed++
+			result.Clusters[i] = ClusterResult{Name: reg.Name, Action: OnboardFailed, Err: err}
+		} else {
+			result.Clusters[i].Action = action
+			undos = append(undos, undo)
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(regs), result.Clusters[i])
+		}
+		if err != nil && !opts.ContinueOnError {
+			c.rollBack(ctx, result, undos)
+			return result, fmt.Errorf("failed to onboard cluster %s, the clusters onboarded before it were rolled back: %w", reg.Name, err)
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("failed to onboard %d of %d clusters", failed, len(regs))
+	}
+	return result, nil
+}
+
+// validateRegistrations checks every registration before anything is written, and
+// reports all the invalid ones
+func validateRegistrations(regs []ClusterRegistration) error {
+	var errs []error
+	seen := make(map[string]bool, len(regs))
+	for _, reg := range regs {
+		switch {
+		case reg.Name == "":
+			errs = append(errs, fmt.Errorf("cluster name is required"))
+			continue
+		case seen[reg.Name]:
+			errs = append(errs, fmt.Errorf("cluster %s is listed more than once", reg.Name))
+		case len(reg.Kubeconfig) == 0:
+			errs = append(errs, fmt.Errorf("kubeconfig is required for cluster %s", reg.Name))
+		}
+		seen[reg.Name] = true
+		if problems := validation.IsDNS1123Subdomain(reg.Name + "-kubeconfig"); len(problems) > 0 {
+			errs = append(errs, fmt.Errorf("cluster name %s is invalid: %s", reg.Name, strings.Join(problems, "; ")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid fleet, nothing was onboarded: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// onboardCluster writes the kubeconfig Secret and IntegrationTarget of reg, and
+// returns a function restoring them to their state before
+func (c *Client) onboardCluster(ctx context.Context, reg ClusterRegistration, dryRun bool) (OnboardAction, func(context.Context) error, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: reg.Name + "-kubeconfig", Namespace: c.namespace},
+		Data:       map[string][]byte{kubeconfigKey: reg.Kubeconfig},
+	}
+	secretChanged, undoSecret, err := c.apply(ctx, secret, dryRun, func(existing client.Object) bool {
+		existingSecret := existing.(*corev1.Secret)
+		if bytes.Equal(existingSecret.Data[kubeconfigKey], reg.Kubeconfig) {
+			return false
+		}
+		if existingSecret.Data == nil {
+			existingSecret.Data = map[string][]byte{}
+		}
+		existingSecret.Data[kubeconfigKey] = reg.Kubeconfig
+		return true
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store kubeconfig for cluster %s: %w", reg.Name, err)
+	}
+
+	spec := ksitv1alpha1.IntegrationTargetSpec{
+		ClusterName:       reg.Name,
+		Namespace:         reg.Namespace,
+		Labels:            reg.Labels,
+		KubeconfigContext: reg.KubeconfigContext,
+	}
+	target := &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: reg.Name, Namespace: c.namespace},
+		Spec:       spec,
+	}
+	targetChanged, undoTarget, err := c.apply(ctx, target, dryRun, func(existing client.Object) bool {
+		existingTarget := existing.(*ksitv1alpha1.IntegrationTarget)
+		if equality.Semantic.DeepEqual(existingTarget.Spec, spec) {
+			return false
+		}
+		existingTarget.Spec = spec
+		return true
+	})
+	if err != nil {
+		if undoErr := undoSecret(ctx); undoErr != nil {
+			err = errors.Join(err, undoErr)
+		}
+		return "", nil, fmt.Errorf("failed to register cluster %s: %w", reg.Name, err)
+	}
+
+	undo := func(ctx context.Context) error {
+		return errors.Join(undoTarget(ctx), undoSecret(ctx))
+	}
+	switch {
+	case targetChanged == OnboardCreated:
+		return OnboardCreated, undo, nil
+	case targetChanged == OnboardUpdated || secretChanged != OnboardUnchanged:
+		return OnboardUpdated, undo, nil
+	default:
+		return OnboardUnchanged, undo, nil
+	}
+}
+
+// apply creates obj, or, when it exists, lets mutate update the existing object and
+// writes it if mutate reports a change. It returns what it did and a function undoing
+// it: deleting a created object, or writing back the previous version of an updated one.
+func (c *Client) apply(ctx context.Context, obj client.Object, dryRun bool, mutate func(existing client.Object) bool) (OnboardAction, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	var createOpts []client.CreateOption
+	var updateOpts []client.UpdateOption
+	if dryRun {
+		createOpts = append(createOpts, client.DryRunAll)
+		updateOpts = append(updateOpts, client.DryRunAll)
+	}
+
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := c.client.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", nil, err
+		}
+		if err := c.client.Create(ctx, obj, createOpts...); err != nil {
+			return "", nil, err
+		}
+		if dryRun {
+			return OnboardCreated, noop, nil
+		}
+		return OnboardCreated, func(ctx context.Context) error {
+			if err := c.client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to roll back %s: %w", obj.GetName(), err)
+			}
+			return nil
+		}, nil
+	}
+
+	previous := existing.DeepCopyObject().(client.Object)
+	if !mutate(existing) {
+		return OnboardUnchanged, noop, nil
+	}
+	if err := c.client.Update(ctx, existing, updateOpts...); err != nil {
+		return "", nil, err
+	}
+	if dryRun {
+		return OnboardUpdated, noop, nil
+	}
+	return OnboardUpdated, func(ctx context.Context) error {
+		previous.SetResourceVersion(existing.GetResourceVersion())
+		if err := c.client.Update(ctx, previous); err != nil {
+			return fmt.Errorf("failed to roll back %s: %w", obj.GetName(), err)
+		}
+		return nil
+	}, nil
+}
+
+// rollBack undoes the clusters onboarded so far, latest first. undos holds one
+// function per cluster before the failed one.
+func (c *Client) rollBack(ctx context.Context, result *OnboardResult, undos []func(context.Context) error) {
+	for i := len(undos) - 1; i >= 0; i-- {
+		cluster := &result.Clusters[i]
+		if err := undos[i](ctx); err != nil {
+			cluster.Err = err
+			continue
+		}
+		if cluster.Action != OnboardUnchanged {
+			cluster.Action = OnboardRolledBack
+		}
+	}
+}
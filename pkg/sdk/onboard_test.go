@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+const mergedKubeconfig = `apiVersion: v1
+kind: Config
+current-context: edge-1
+clusters:
+- name: edge-1
+  cluster:
+    server: https://edge-1.example.com
+- name: edge-2
+  cluster:
+    server: https://edge-2.example.com
+contexts:
+- name: edge-1
+  context: {cluster: edge-1, user: edge-1}
+- name: edge-2-admin
+  context: {cluster: edge-2, user: edge-2}
+users:
+- name: edge-1
+  user: {token: edge-1-token}
+- name: edge-2
+  user: {client-certificate: edge-2.crt, client-key: edge-2.key}
+`
+
+func TestLoadFleet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		return path
+	}
+	write("fleet.kubeconfig", mergedKubeconfig)
+	write("edge-2.crt", "edge-2 certificate")
+	write("edge-2.key", "edge-2 key")
+
+	regs, err := LoadFleet(write("fleet.yaml", `
+defaults:
+  kubeconfig: fleet.kubeconfig
+  labels: {tier: edge, region: eu}
+clusters:
+- name: edge-1
+- name: edge-2
+  context: edge-2-admin
+  namespace: apps
+  labels: {region: us}
+`))
+	require.NoError(t, err)
+	require.Len(t, regs, 2)
+	assert.Equal(t, map[string]string{"tier": "edge", "region": "us"}, regs[1].Labels)
+	assert.Equal(t, "apps", regs[1].Namespace)
+
+	edge1, err := clientcmd.Load(regs[0].Kubeconfig)
+	require.NoError(t, err)
+	assert.Equal(t, "edge-1", edge1.CurrentContext, "the context named like the cluster")
+	assert.Len(t, edge1.Clusters, 1, "other clusters are not copied")
+	edge2, err := clientcmd.Load(regs[1].Kubeconfig)
+	require.NoError(t, err)
+	assert.Equal(t, "https://edge-2.example.com", edge2.Clusters["edge-2"].Server)
+	assert.Equal(t, "edge-2 certificate", string(edge2.AuthInfos["edge-2"].ClientCertificateData), "files are inlined")
+
+	regs, err = LoadFleet(write("fleet.csv", "name,kubeconfig,context,labels\n"+
+		"edge-2,fleet.kubeconfig,edge-2-admin,tier=edge;region=us\n"))
+	require.NoError(t, err)
+	require.Len(t, regs, 1)
+	assert.Equal(t, map[string]string{"tier": "edge", "region": "us"}, regs[0].Labels)
+
+	_, err = LoadFleet(write("invalid.yaml", `
+clusters:
+- name: edge-1
+  kubeconfig: missing.kubeconfig
+- name: edge-2
+  kubeconfig: fleet.kubeconfig
+  context: edge-3
+- kubeconfig: fleet.kubeconfig
+`))
+	assert.ErrorContains(t, err, "cluster edge-1: failed to load kubeconfig")
+	assert.ErrorContains(t, err, "cluster edge-2: invalid kubeconfig")
+	assert.ErrorContains(t, err, "cluster 3: name is required")
+
+	_, err = LoadFleet(write("columns.csv", "name,server\nedge-1,https://edge-1.example.com\n"))
+	assert.ErrorContains(t, err, `unknown fleet file column "server"`)
+}
+
+func TestOnboardFleet(t *testing.T) {
+	ctx := context.Background()
+	existing := &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-2", Namespace: "ksit-system"},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-2", Labels: map[string]string{"tier": "core"}},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge-2-kubeconfig", Namespace: "ksit-system"},
+			Data:       map[string][]byte{"kubeconfig": []byte("edge-2")},
+		}).Build()
+	c := NewForClient(fakeClient, "ksit-system")
+
+	regs := []ClusterRegistration{
+		{Name: "edge-1", Kubeconfig: []byte("edge-1")},
+		{Name: "edge-2", Kubeconfig: []byte("edge-2"), Labels: map[string]string{"tier": "edge"}},
+	}
+	actions := func(result *OnboardResult) []OnboardAction {
+		var actions []OnboardAction
+		for _, cluster := range result.Clusters {
+			actions = append(actions, cluster.Action)
+		}
+		return actions
+	}
+
+	var progress []int
+	result, err := c.OnboardFleet(ctx, regs, OnboardOptions{DryRun: true, Progress: func(done, total int, _ ClusterResult) {
+		progress = append(progress, done)
+		assert.Equal(t, 2, total)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []OnboardAction{OnboardCreated, OnboardUpdated}, actions(result))
+	assert.Equal(t, []int{1, 2}, progress)
+	err = fakeClient.Get(ctx, types.NamespacedName{Name: "edge-1", Namespace: "ksit-system"}, &ksitv1alpha1.IntegrationTarget{})
+	assert.True(t, apierrors.IsNotFound(err), "a dry run writes nothing")
+
+	result, err = c.OnboardFleet(ctx, regs, OnboardOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []OnboardAction{OnboardCreated, OnboardUpdated}, actions(result))
+	result, err = c.OnboardFleet(ctx, regs, OnboardOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []OnboardAction{OnboardUnchanged, OnboardUnchanged}, actions(result))
+
+	_, err = c.OnboardFleet(ctx, append(regs, ClusterRegistration{Name: "edge-1"}, ClusterRegistration{Name: "Edge_3", Kubeconfig: []byte("x")}), OnboardOptions{})
+	assert.ErrorContains(t, err, "cluster edge-1 is listed more than once")
+	assert.ErrorContains(t, err, "cluster name Edge_3 is invalid")
+}
+
+func TestOnboardFleetRollsBack(t *testing.T) {
+	ctx := context.Background()
+	failing := interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if obj.GetName() == "edge-3" {
+				return errors.New("admission denied")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-2", Namespace: "ksit-system"},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-2", Namespace: "apps"},
+	}).WithInterceptorFuncs(failing).Build()
+	c := NewForClient(fakeClient, "ksit-system")
+
+	regs := []ClusterRegistration{
+		{Name: "edge-1", Kubeconfig: []byte("edge-1")},
+		{Name: "edge-2", Kubeconfig: []byte("edge-2")},
+		{Name: "edge-3", Kubeconfig: []byte("edge-3")},
+		{Name: "edge-4", Kubeconfig: []byte("edge-4")},
+	}
+	result, err := c.OnboardFleet(ctx, regs, OnboardOptions{})
+	assert.ErrorContains(t, err, "admission denied")
+	require.NotNil(t, result)
+	assert.Equal(t, 2, result.Count(OnboardRolledBack))
+	assert.Equal(t, OnboardFailed, result.Clusters[2].Action)
+	assert.Equal(t, OnboardSkipped, result.Clusters[3].Action)
+
+	for _, name := range []string{"edge-1", "edge-3"} {
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "ksit-system"}, &ksitv1alpha1.IntegrationTarget{})
+		assert.True(t, apierrors.IsNotFound(err), name)
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: name + "-kubeconfig", Namespace: "ksit-system"}, &corev1.Secret{})
+		assert.True(t, apierrors.IsNotFound(err), name)
+	}
+	target := &ksitv1alpha1.IntegrationTarget{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "edge-2", Namespace: "ksit-system"}, target))
+	assert.Equal(t, "apps", target.Spec.Namespace, "updated targets are restored")
+
+	result, err = c.OnboardFleet(ctx, regs, OnboardOptions{ContinueOnError: true})
+	assert.ErrorContains(t, err, "failed to onboard 1 of 4 clusters")
+	assert.Equal(t, 3, result.Count(OnboardCreated)+result.Count(OnboardUpdated))
+}
@@ -0,0 +1,249 @@
+// Package sdk lets other programs drive KSIT on a hub cluster without shelling out
+// to kubectl. It only reads and writes KSIT resources; the controller running on
+// the hub does the actual work.
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// kubeconfigKey is the secret key the IntegrationTarget controller reads the kubeconfig from
+const kubeconfigKey = "kubeconfig"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ksitv1alpha1.AddToScheme(scheme))
+}
+
+// Client manages KSIT resources in one namespace of a hub cluster
+type Client struct {
+	client    client.WithWatch
+	namespace string
+}
+
+// New creates a Client for the hub cluster at config, operating in namespace
+func New(config *rest.Config, namespace string) (*Client, error) {
+	c, err := client.NewWithWatch(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return NewForClient(c, namespace), nil
+}
+
+// NewForClient creates a Client from an existing controller-runtime client. The
+// client's scheme must include the KSIT and core Kubernetes types.
+func NewForClient(c client.WithWatch, namespace string) *Client {
+	return &Client{
+		client:    c,
+		namespace: namespace,
+	}
+}
+
+// ClusterRegistration describes a workload cluster to register with KSIT
+type ClusterRegistration struct {
+	// Name is the cluster name Integrations refer to in targetClusters
+	Name string
+	// Kubeconfig grants KSIT access to the cluster
+	Kubeconfig []byte
+	// Namespace is the default namespace on the target cluster (optional)
+	Namespace string
+	// Labels are applied to resources KSIT creates on the cluster (optional)
+	Labels map[string]string
+	// KubeconfigContext selects a context of Kubeconfig instead of its current context (optional)
+	KubeconfigContext string
+}
+
+// RegisterCluster stores the kubeconfig of a cluster and creates or updates its
+// IntegrationTarget. It is safe to call repeatedly.
+func (c *Client) RegisterCluster(ctx context.Context, reg ClusterRegistration) error {
+	if reg.Name == "" {
+		return fmt.Errorf("cluster name is required")
+	}
+	if len(reg.Kubeconfig) == 0 {
+		return fmt.Errorf("kubeconfig is required for cluster %s", reg.Name)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reg.Name + "-kubeconfig",
+			Namespace: c.namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.client, secret, func() error {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[kubeconfigKey] = reg.Kubeconfig
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to store kubeconfig for cluster %s: %w", reg.Name, err)
+	}
+
+	target := &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reg.Name,
+			Namespace: c.namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.client, target, func() error {
+		target.Spec.ClusterName = reg.Name
+		target.Spec.Namespace = reg.Namespace
+		target.Spec.Labels = reg.Labels
+		target.Spec.KubeconfigContext = reg.KubeconfigContext
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to register cluster %s: %w", reg.Name, err)
+	}
+
+	return nil
+}
+
+// EnsureIntegration creates the Integration or updates its spec to match
+func (c *Client) EnsureIntegration(ctx context.Context, name string, spec ksitv1alpha1.IntegrationSpec) (*ksitv1alpha1.Integration, error) {
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, c.client, integration, func() error {
+		integration.Spec = spec
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to ensure integration %s: %w", name, err)
+	}
+
+	return integration, nil
+}
+
+// FleetStatus is a point-in-time view of the clusters and Integrations in a namespace
+type FleetStatus struct {
+	Clusters     []ClusterState
+	Integrations []IntegrationState
+}
+
+// ClusterState is the registration state of one cluster
+type ClusterState struct {
+	Name    string
+	Ready   bool
+	Message string
+}
+
+// IntegrationState is the state of one Integration across its target clusters
+type IntegrationState struct {
+	Name    string
+	Type    string
+	Phase   string
+	Ready   bool
+	Message string
+	// Summary aggregates the per-cluster results; nil until the controller reports them
+	Summary *ksitv1alpha1.ClusterSummary
+}
+
+// GetFleetStatus returns the state of every cluster and Integration, sorted by name
+func (c *Client) GetFleetStatus(ctx context.Context) (*FleetStatus, error) {
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := c.client.List(ctx, targets, client.InNamespace(c.namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.client.List(ctx, integrations, client.InNamespace(c.namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+
+	status := &FleetStatus{}
+	for _, target := range targets.Items {
+		status.Clusters = append(status.Clusters, ClusterState{
+			Name:    target.Spec.ClusterName,
+			Ready:   target.Status.Ready,
+			Message: target.Status.Message,
+		})
+	}
+	for i := range integrations.Items {
+		status.Integrations = append(status.Integrations, integrationState(&integrations.Items[i]))
+	}
+
+	sort.Slice(status.Clusters, func(i, j int) bool { return status.Clusters[i].Name < status.Clusters[j].Name })
+	sort.Slice(status.Integrations, func(i, j int) bool { return status.Integrations[i].Name < status.Integrations[j].Name })
+
+	return status, nil
+}
+
+// WatchIntegration sends the state of the named Integration every time it changes,
+// starting with the current state. The channel is closed when ctx is done, the
+// Integration is deleted, or the watch ends; callers may re-watch after a close.
+func (c *Client) WatchIntegration(ctx context.Context, name string) (<-chan IntegrationState, error) {
+	w, err := c.client.Watch(ctx, &ksitv1alpha1.IntegrationList{},
+		client.InNamespace(c.namespace),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", name)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch integration %s: %w", name, err)
+	}
+
+	states := make(chan IntegrationState)
+	go func() {
+		defer close(states)
+		defer w.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+
+				integration, isIntegration := event.Object.(*ksitv1alpha1.Integration)
+				if !isIntegration || integration.Name != name {
+					continue
+				}
+				if event.Type == watch.Deleted {
+					return
+				}
+
+				select {
+				case states <- integrationState(integration):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return states, nil
+}
+
+func integrationState(integration *ksitv1alpha1.Integration) IntegrationState {
+	state := IntegrationState{
+		Name:    integration.Name,
+		Type:    integration.Spec.Type,
+		Phase:   integration.Status.Phase,
+		Message: integration.Status.Message,
+		Summary: integration.Status.ClusterSummary,
+	}
+	if ready := meta.FindStatusCondition(integration.Status.Conditions, ksitv1alpha1.ConditionTypeReady); ready != nil {
+		state.Ready = ready.Status == metav1.ConditionTrue
+	}
+	return state
+}
@@ -0,0 +1,82 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestRegisterClusterAndFleetStatus(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := NewForClient(fakeClient, "ksit-system")
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.RegisterCluster(ctx, ClusterRegistration{
+			Name:       "cluster1",
+			Kubeconfig: []byte("apiVersion: v1"),
+		}))
+	}
+
+	secret := &corev1.Secret{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "cluster1-kubeconfig", Namespace: "ksit-system"}, secret))
+	assert.Equal(t, "apiVersion: v1", string(secret.Data["kubeconfig"]))
+
+	_, err := c.EnsureIntegration(ctx, "argocd", ksitv1alpha1.IntegrationSpec{
+		Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+		TargetClusters: []string{"cluster1"},
+	})
+	require.NoError(t, err)
+
+	status, err := c.GetFleetStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Clusters, 1)
+	assert.Equal(t, "cluster1", status.Clusters[0].Name)
+	require.Len(t, status.Integrations, 1)
+	assert.Equal(t, ksitv1alpha1.IntegrationTypeArgoCD, status.Integrations[0].Type)
+	assert.False(t, status.Integrations[0].Ready)
+
+	assert.Error(t, c.RegisterCluster(ctx, ClusterRegistration{Name: "cluster2"}))
+}
+
+func TestWatchIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	c := NewForClient(fakeClient, "ksit-system")
+
+	integration, err := c.EnsureIntegration(ctx, "argocd", ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD})
+	require.NoError(t, err)
+
+	states, err := c.WatchIntegration(ctx, "argocd")
+	require.NoError(t, err)
+
+	go func() {
+		integration.Status.Phase = ksitv1alpha1.PhaseRunning
+		meta.SetStatusCondition(&integration.Status.Conditions, metav1.Condition{
+			Type:   ksitv1alpha1.ConditionTypeReady,
+			Status: metav1.ConditionTrue,
+			Reason: "Healthy",
+		})
+		_ = fakeClient.Update(ctx, integration)
+	}()
+
+	for state := range states {
+		if state.Ready {
+			assert.Equal(t, ksitv1alpha1.PhaseRunning, state.Phase)
+			return
+		}
+	}
+	t.Fatal("watch closed before the Integration became Ready")
+}
@@ -0,0 +1,174 @@
+// Package slo derives service level indicators of Integrations from their phase
+// transitions: availability, reconcile error rate and mean time to recovery, per
+// calendar month so platform teams can report them monthly
+package slo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// PhaseDisabled is recorded for Integrations with spec.enabled set to false
+const PhaseDisabled = ksitv1alpha1.PhaseDisabled
+
+// Tracker accumulates reconcile outcomes per Integration. Status writes are batched,
+// so the tracker keeps the running totals in memory and the status only receives a
+// copy; after a restart the totals are seeded from the persisted status.
+type Tracker struct {
+	mu     sync.Mutex
+	states map[types.NamespacedName]*ksitv1alpha1.SLOStatus
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{states: make(map[types.NamespacedName]*ksitv1alpha1.SLOStatus)}
+}
+
+// Observe records the phase an Integration ended a reconcile in and returns the
+// updated summary. seed is the summary from the Integration's status; it is only
+// used the first time the tracker sees the Integration.
+func (t *Tracker) Observe(key types.NamespacedName, seed *ksitv1alpha1.SLOStatus, phase string, now time.Time) *ksitv1alpha1.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[key]
+	switch {
+	case ok:
+	case seed != nil:
+		state = seed.DeepCopy()
+	default:
+		state = &ksitv1alpha1.SLOStatus{
+			Current:          ksitv1alpha1.SLOWindow{Start: metav1.NewTime(monthStart(now))},
+			LastObservedTime: metav1.NewTime(now),
+			LastPhase:        phase,
+		}
+	}
+	t.states[key] = state
+
+	observe(state, phase, now.UTC())
+	return state.DeepCopy()
+}
+
+// Forget drops the totals of a deleted Integration
+func (t *Tracker) Forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, key)
+}
+
+func observe(state *ksitv1alpha1.SLOStatus, phase string, now time.Time) {
+	last := state.LastObservedTime.Time.UTC()
+	if now.Before(last) {
+		now = last
+	}
+
+	// Close every month that ended since the last observation
+	for {
+		end := monthStart(state.Current.Start.Time).AddDate(0, 1, 0)
+		if now.Before(end) {
+			break
+		}
+		accrue(&state.Current, state.LastPhase, last, end)
+		last = end
+
+		closed := state.Current
+		closed.End = &metav1.Time{Time: end}
+		summarize(&closed)
+		state.Previous = &closed
+		state.Current = ksitv1alpha1.SLOWindow{Start: metav1.NewTime(end)}
+	}
+	accrue(&state.Current, state.LastPhase, last, now)
+
+	window := &state.Current
+	switch {
+	case !observed(phase):
+		// Time spent disabled or initializing is not downtime, nor part of a recovery
+		state.DownSince = nil
+	case phase == ksitv1alpha1.PhaseRunning:
+		window.Reconciles++
+		if state.DownSince != nil {
+			window.Recoveries++
+			window.RecoverySeconds += int64(now.Sub(state.DownSince.Time).Seconds())
+			state.DownSince = nil
+		}
+	default:
+		window.Reconciles++
+		window.FailedReconciles++
+		if state.LastPhase == ksitv1alpha1.PhaseRunning {
+			window.Incidents++
+			state.DownSince = &metav1.Time{Time: now}
+		}
+	}
+
+	state.LastPhase = phase
+	state.LastObservedTime = metav1.NewTime(now)
+	summarize(window)
+}
+
+// observed reports whether time spent in phase counts towards the indicators
+func observed(phase string) bool {
+	return phase == ksitv1alpha1.PhaseRunning || phase == ksitv1alpha1.PhaseFailed
+}
+
+// accrue adds the time between from and to, spent in phase, to the window
+func accrue(window *ksitv1alpha1.SLOWindow, phase string, from, to time.Time) {
+	seconds := int64(to.Sub(from).Seconds())
+	if seconds <= 0 || !observed(phase) {
+		return
+	}
+	window.ObservedSeconds += seconds
+	if phase == ksitv1alpha1.PhaseRunning {
+		window.RunningSeconds += seconds
+	}
+}
+
+func summarize(window *ksitv1alpha1.SLOWindow) {
+	window.Availability, window.ErrorRate, window.MTTR = "", "", ""
+	if availability, ok := Availability(window); ok {
+		window.Availability = percent(availability)
+	}
+	if errorRate, ok := ErrorRate(window); ok {
+		window.ErrorRate = percent(errorRate)
+	}
+	if mttr, ok := MTTR(window); ok {
+		window.MTTR = mttr.String()
+	}
+}
+
+// Availability returns the fraction of observed time spent Running
+func Availability(window *ksitv1alpha1.SLOWindow) (float64, bool) {
+	if window.ObservedSeconds == 0 {
+		return 0, false
+	}
+	return float64(window.RunningSeconds) / float64(window.ObservedSeconds), true
+}
+
+// ErrorRate returns the fraction of reconciles that failed
+func ErrorRate(window *ksitv1alpha1.SLOWindow) (float64, bool) {
+	if window.Reconciles == 0 {
+		return 0, false
+	}
+	return float64(window.FailedReconciles) / float64(window.Reconciles), true
+}
+
+// MTTR returns the mean time to recovery
+func MTTR(window *ksitv1alpha1.SLOWindow) (time.Duration, bool) {
+	if window.Recoveries == 0 {
+		return 0, false
+	}
+	return time.Duration(window.RecoverySeconds/int64(window.Recoveries)) * time.Second, true
+}
+
+func percent(ratio float64) string {
+	return fmt.Sprintf("%.2f%%", ratio*100)
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
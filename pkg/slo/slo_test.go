@@ -0,0 +1,75 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestTrackerIndicators(t *testing.T) {
+	key := types.NamespacedName{Name: "argocd", Namespace: "default"}
+	tracker := NewTracker()
+	start := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tracker.Observe(key, nil, ksitv1alpha1.PhaseInitializing, start)
+	tracker.Observe(key, nil, ksitv1alpha1.PhaseRunning, start.Add(10*time.Minute))
+	tracker.Observe(key, nil, ksitv1alpha1.PhaseFailed, start.Add(100*time.Minute))
+	tracker.Observe(key, nil, ksitv1alpha1.PhaseFailed, start.Add(105*time.Minute))
+	status := tracker.Observe(key, nil, ksitv1alpha1.PhaseRunning, start.Add(110*time.Minute))
+
+	window := status.Current
+	assert.Equal(t, int64(100*60), window.ObservedSeconds, "time spent Initializing is not observed")
+	assert.Equal(t, int64(90*60), window.RunningSeconds)
+	assert.Equal(t, "90.00%", window.Availability)
+	assert.Equal(t, int64(4), window.Reconciles)
+	assert.Equal(t, int64(2), window.FailedReconciles)
+	assert.Equal(t, "50.00%", window.ErrorRate)
+	assert.Equal(t, int32(1), window.Incidents)
+	assert.Equal(t, int32(1), window.Recoveries)
+	assert.Equal(t, "10m0s", window.MTTR)
+	assert.Nil(t, status.DownSince)
+}
+
+func TestTrackerClosesMonths(t *testing.T) {
+	key := types.NamespacedName{Name: "flux", Namespace: "default"}
+	tracker := NewTracker()
+	endOfMarch := time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC)
+
+	tracker.Observe(key, nil, ksitv1alpha1.PhaseRunning, endOfMarch)
+	status := tracker.Observe(key, nil, ksitv1alpha1.PhaseRunning, endOfMarch.Add(2*time.Hour))
+
+	require.NotNil(t, status.Previous)
+	assert.Equal(t, time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), status.Previous.End.Time)
+	assert.Equal(t, int64(3600), status.Previous.RunningSeconds)
+	assert.Equal(t, "100.00%", status.Previous.Availability)
+	assert.Equal(t, status.Previous.End.Time, status.Current.Start.Time)
+	assert.Equal(t, int64(3600), status.Current.RunningSeconds)
+	assert.Equal(t, int64(1), status.Current.Reconciles)
+}
+
+func TestTrackerSeedsFromStatus(t *testing.T) {
+	key := types.NamespacedName{Name: "istio", Namespace: "default"}
+	now := time.Date(2026, time.May, 2, 8, 0, 0, 0, time.UTC)
+
+	first := NewTracker().Observe(key, nil, ksitv1alpha1.PhaseRunning, now)
+	first = NewTracker().Observe(key, first, ksitv1alpha1.PhaseFailed, now.Add(time.Hour))
+
+	// A restarted controller continues from the persisted summary
+	status := NewTracker().Observe(key, first, ksitv1alpha1.PhaseRunning, now.Add(90*time.Minute))
+	assert.Equal(t, int64(3), status.Current.Reconciles)
+	assert.Equal(t, int32(1), status.Current.Recoveries)
+	assert.Equal(t, "30m0s", status.Current.MTTR)
+
+	// Time spent disabled neither counts against availability nor extends a recovery
+	tracker := NewTracker()
+	tracker.Observe(key, status, ksitv1alpha1.PhaseFailed, now.Add(2*time.Hour))
+	tracker.Observe(key, nil, PhaseDisabled, now.Add(3*time.Hour))
+	status = tracker.Observe(key, nil, ksitv1alpha1.PhaseRunning, now.Add(10*time.Hour))
+	assert.Equal(t, int32(1), status.Current.Recoveries)
+	assert.Equal(t, int64(180*60), status.Current.ObservedSeconds)
+}
@@ -0,0 +1,342 @@
+// Package smoketest exercises a freshly installed integration tool on a target
+// cluster, so an install is only trusted once the tool actually does its job
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+// Defaults for Options fields left empty
+const (
+	DefaultRepoURL = "https://github.com/stefanprodan/podinfo"
+	DefaultBranch  = "master"
+	DefaultPath    = "kustomize"
+	DefaultTimeout = 3 * time.Minute
+)
+
+// pollInterval is how often a smoke test checks whether the tool has acted
+var pollInterval = 5 * time.Second
+
+var applicationGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}
+
+// Options configures a smoke test
+type Options struct {
+	// Name of the objects the smoke test creates
+	Name string
+	// Namespace the tool runs in; Argo CD Applications and Flux sources are created there
+	Namespace string
+	// RepoURL, Branch and Path are what the Argo CD and Flux smoke tests deploy
+	RepoURL string
+	Branch  string
+	Path    string
+	// Timeout bounds the whole smoke test
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.RepoURL == "" {
+		o.RepoURL = DefaultRepoURL
+	}
+	if o.Branch == "" {
+		o.Branch = DefaultBranch
+	}
+	if o.Path == "" {
+		o.Path = DefaultPath
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return o
+}
+
+// Check is the outcome of one step of a smoke test
+type Check struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Result lists the checks of a smoke test in the order they ran. A smoke test stops
+// at the first failed check, except for cleanup, which always runs.
+type Result struct {
+	Checks []Check
+}
+
+// Passed reports whether every check passed
+func (r *Result) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+// Err returns an error describing the first failed check, or nil
+func (r *Result) Err() error {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return fmt.Errorf("%s: %s", check.Name, check.Message)
+		}
+	}
+	return nil
+}
+
+// record adds a check and reports whether it passed
+func (r *Result) record(name string, err error, message string) bool {
+	check := Check{Name: name, Passed: err == nil, Message: message}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+	return check.Passed
+}
+
+// poll calls condition until it reports done, returns an error, or ctx expires.
+// On expiry the last message from condition explains what was still missing.
+func poll(ctx context.Context, condition func() (done bool, message string, err error)) (string, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, message, err := condition()
+		if err != nil || done {
+			return message, err
+		}
+
+		select {
+		case <-ctx.Done():
+			if message == "" {
+				message = ctx.Err().Error()
+			}
+			return "", fmt.Errorf("timed out: %s", message)
+		case <-ticker.C:
+		}
+	}
+}
+
+// ArgoCD creates an Application from the smoke test repository, waits for the Argo CD
+// application controller to compare it with the cluster, and deletes it again.
+// The Application is never synced, so nothing is deployed.
+func ArgoCD(ctx context.Context, c client.Client, opts Options) (result Result) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(applicationGVK)
+	app.SetName(opts.Name)
+	app.SetNamespace(opts.Namespace)
+	app.Object["spec"] = map[string]interface{}{
+		"project": "default",
+		"source": map[string]interface{}{
+			"repoURL":        opts.RepoURL,
+			"path":           opts.Path,
+			"targetRevision": opts.Branch,
+		},
+		"destination": map[string]interface{}{
+			"server":    "https://kubernetes.default.svc",
+			"namespace": opts.Namespace,
+		},
+	}
+
+	if !result.record("CreateApplication", c.Create(ctx, app), fmt.Sprintf("created Application %s/%s", opts.Namespace, opts.Name)) {
+		return result
+	}
+	defer func() {
+		err := c.Delete(context.WithoutCancel(ctx), app)
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+		result.record("DeleteApplication", err, "deleted Application")
+	}()
+
+	message, err := poll(ctx, func() (bool, string, error) {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(applicationGVK)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(app), current); err != nil {
+			return false, "", err
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+		for _, raw := range conditions {
+			cond, _ := raw.(map[string]interface{})
+			if cond["type"] == "ComparisonError" || cond["type"] == "InvalidSpecError" {
+				return false, "", fmt.Errorf("%v: %v", cond["type"], cond["message"])
+			}
+		}
+
+		syncStatus, _, _ := unstructured.NestedString(current.Object, "status", "sync", "status")
+		if syncStatus == "" || syncStatus == "Unknown" {
+			return false, "application controller has not compared the Application yet", nil
+		}
+		return true, fmt.Sprintf("application controller compared the Application (sync status %s)", syncStatus), nil
+	})
+	result.record("ApplicationReconciled", err, message)
+
+	return result
+}
+
+// Flux creates a GitRepository for the smoke test repository and a Kustomization
+// applying its path, waits for both to become ready, and deletes them again. The
+// Kustomization prunes, so what it applied is removed with it.
+func Flux(ctx context.Context, f *flux.FluxClient, opts Options) (result Result) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	repo := &flux.GitRepository{
+		Name:      opts.Name,
+		Namespace: opts.Namespace,
+		URL:       opts.RepoURL,
+		Branch:    opts.Branch,
+		Interval:  "1m",
+	}
+	if !result.record("CreateGitRepository", f.ApplyGitRepository(ctx, repo), fmt.Sprintf("created GitRepository %s/%s", opts.Namespace, opts.Name)) {
+		return result
+	}
+	defer func() {
+		err := f.DeleteGitRepository(context.WithoutCancel(ctx), repo.Name, repo.Namespace)
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+		result.record("DeleteGitRepository", err, "deleted GitRepository")
+	}()
+
+	message, err := poll(ctx, fluxReady("GitRepository", func() (*flux.SyncStatus, error) {
+		return f.GetGitRepositoryStatus(ctx, repo.Name, repo.Namespace)
+	}))
+	if !result.record("GitRepositoryReady", err, message) {
+		return result
+	}
+
+	ks := &flux.Kustomization{
+		Name:            opts.Name,
+		Namespace:       opts.Namespace,
+		SourceRef:       repo.Name,
+		Path:            opts.Path,
+		Interval:        "10m",
+		Prune:           true,
+		TargetNamespace: opts.Namespace,
+	}
+	if !result.record("CreateKustomization", f.ApplyKustomization(ctx, ks), fmt.Sprintf("created Kustomization %s/%s", opts.Namespace, opts.Name)) {
+		return result
+	}
+	defer func() {
+		err := f.DeleteKustomization(context.WithoutCancel(ctx), ks.Name, ks.Namespace)
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+		result.record("DeleteKustomization", err, "deleted Kustomization")
+	}()
+
+	message, err = poll(ctx, fluxReady("Kustomization", func() (*flux.SyncStatus, error) {
+		return f.GetKustomizationStatus(ctx, ks.Name, ks.Namespace)
+	}))
+	result.record("KustomizationReady", err, message)
+
+	return result
+}
+
+func fluxReady(kind string, get func() (*flux.SyncStatus, error)) func() (bool, string, error) {
+	return func() (bool, string, error) {
+		status, err := get()
+		if err != nil {
+			return false, "", err
+		}
+		if status.Ready {
+			return true, fmt.Sprintf("%s is ready", kind), nil
+		}
+		for _, cond := range status.Conditions {
+			if cond.Type == "Ready" && cond.Message != "" {
+				return false, fmt.Sprintf("%s is not ready: %s", kind, cond.Message), nil
+			}
+		}
+		return false, fmt.Sprintf("%s is not ready", kind), nil
+	}
+}
+
+// Querier runs PromQL queries
+type Querier interface {
+	Query(ctx context.Context, query string, ts time.Time) ([]prometheus.QueryResult, error)
+}
+
+// Prometheus checks that Prometheus answers queries and scrapes at least one healthy target
+func Prometheus(ctx context.Context, q Querier, opts Options) (result Result) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	message, err := poll(ctx, func() (bool, string, error) {
+		series, err := q.Query(ctx, "up", time.Now())
+		if err != nil {
+			return false, err.Error(), nil
+		}
+
+		up := 0
+		for _, s := range series {
+			if len(s.Values) > 0 && s.Values[len(s.Values)-1].Value == 1 {
+				up++
+			}
+		}
+		if up == 0 {
+			return false, fmt.Sprintf("none of %d scrape targets is up", len(series)), nil
+		}
+		return true, fmt.Sprintf("%d of %d scrape targets are up", up, len(series)), nil
+	})
+	result.record("QueryUp", err, message)
+
+	return result
+}
+
+// Istio creates a Pod that asks for sidecar injection in dry-run mode and checks that
+// the injection webhook added the istio-proxy container. Nothing is persisted.
+func Istio(ctx context.Context, c client.Client, opts Options) (result Result) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Labels:    map[string]string{"sidecar.istio.io/inject": "true"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "smoke-test", Image: "busybox"}},
+		},
+	}
+	if !result.record("CreatePod", c.Create(ctx, pod, client.DryRunAll), fmt.Sprintf("created Pod %s/%s in dry-run mode", opts.Namespace, opts.Name)) {
+		return result
+	}
+
+	var err error
+	message := "istio-proxy sidecar was injected"
+	if !hasContainer(pod.Spec.Containers, "istio-proxy") && !hasContainer(pod.Spec.InitContainers, "istio-proxy") {
+		err = fmt.Errorf("istio-proxy sidecar was not injected; check the istio-sidecar-injector webhook")
+	}
+	result.record("SidecarInjected", err, message)
+
+	return result
+}
+
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, c := range containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
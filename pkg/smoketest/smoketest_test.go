@@ -0,0 +1,174 @@
+package smoketest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/crds"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/flux"
+	"github.com/kubestellar/integration-toolkit/pkg/integrations/prometheus"
+)
+
+func init() {
+	pollInterval = 10 * time.Millisecond
+}
+
+func checkNames(result Result) []string {
+	var names []string
+	for _, check := range result.Checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
+
+// argoCDCluster returns a fake cluster whose application controller sets the given Application status
+func argoCDCluster(status map[string]interface{}) client.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(applicationGVK, meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			if app, ok := obj.(*unstructured.Unstructured); ok && status != nil {
+				app.Object["status"] = status
+			}
+			return nil
+		},
+	}).Build()
+}
+
+func TestArgoCD(t *testing.T) {
+	opts := Options{Name: "ksit-smoketest-argocd", Namespace: "argocd", Timeout: time.Second}
+
+	c := argoCDCluster(map[string]interface{}{"sync": map[string]interface{}{"status": "OutOfSync"}})
+	result := ArgoCD(context.Background(), c, opts)
+	assert.True(t, result.Passed(), result.Err())
+	assert.Equal(t, []string{"CreateApplication", "ApplicationReconciled", "DeleteApplication"}, checkNames(result))
+
+	// The Application is deleted again
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(applicationGVK)
+	err := c.Get(context.Background(), client.ObjectKey{Name: opts.Name, Namespace: opts.Namespace}, app)
+	assert.True(t, apierrors.IsNotFound(err))
+
+	c = argoCDCluster(map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "ComparisonError", "message": "repository not accessible"},
+	}})
+	result = ArgoCD(context.Background(), c, opts)
+	assert.False(t, result.Passed())
+	assert.EqualError(t, result.Err(), "ApplicationReconciled: ComparisonError: repository not accessible")
+	assert.Equal(t, "DeleteApplication", result.Checks[2].Name)
+	assert.True(t, result.Checks[2].Passed)
+
+	result = ArgoCD(context.Background(), argoCDCluster(nil), Options{Name: "app", Namespace: "argocd", Timeout: 50 * time.Millisecond})
+	assert.EqualError(t, result.Err(), "ApplicationReconciled: timed out: application controller has not compared the Application yet")
+}
+
+func TestFlux(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range crds.RequiredFor(ksitv1alpha1.IntegrationTypeFlux) {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	ready := map[string]string{"GitRepository": "True", "Kustomization": "False"}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRESTMapper(mapper).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			u := obj.(*unstructured.Unstructured)
+			u.Object["status"] = map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": ready[u.GetKind()], "message": "kustomize build failed"},
+			}}
+			return nil
+		},
+	}).Build()
+	f := flux.NewFluxClient(c, scheme.Scheme, logr.Discard())
+	opts := Options{Name: "ksit-smoketest-flux", Namespace: "flux-system", Timeout: 50 * time.Millisecond}
+
+	result := Flux(context.Background(), f, opts)
+	assert.EqualError(t, result.Err(), "KustomizationReady: timed out: Kustomization is not ready: kustomize build failed")
+	assert.Equal(t, []string{
+		"CreateGitRepository", "GitRepositoryReady", "CreateKustomization", "KustomizationReady",
+		"DeleteKustomization", "DeleteGitRepository",
+	}, checkNames(result))
+
+	ready["Kustomization"] = "True"
+	result = Flux(context.Background(), f, opts)
+	assert.True(t, result.Passed(), result.Err())
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepositoryList"})
+	require.NoError(t, c.List(context.Background(), list))
+	assert.Empty(t, list.Items)
+}
+
+type fakeQuerier struct {
+	series []prometheus.QueryResult
+	err    error
+}
+
+func (q fakeQuerier) Query(context.Context, string, time.Time) ([]prometheus.QueryResult, error) {
+	return q.series, q.err
+}
+
+func TestPrometheus(t *testing.T) {
+	opts := Options{Timeout: 50 * time.Millisecond}
+	sample := func(value float64) prometheus.QueryResult {
+		return prometheus.QueryResult{Values: []prometheus.SamplePair{{Value: value}}}
+	}
+
+	result := Prometheus(context.Background(), fakeQuerier{series: []prometheus.QueryResult{sample(1), sample(0)}}, opts)
+	require.True(t, result.Passed())
+	assert.Equal(t, "1 of 2 scrape targets are up", result.Checks[0].Message)
+
+	result = Prometheus(context.Background(), fakeQuerier{series: []prometheus.QueryResult{sample(0)}}, opts)
+	assert.EqualError(t, result.Err(), "QueryUp: timed out: none of 1 scrape targets is up")
+
+	result = Prometheus(context.Background(), fakeQuerier{err: errors.New("connection refused")}, opts)
+	assert.EqualError(t, result.Err(), "QueryUp: timed out: connection refused")
+}
+
+func TestIstio(t *testing.T) {
+	opts := Options{Name: "ksit-smoketest-istio", Namespace: "default"}
+
+	var dryRun bool
+	injecting := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			createOpts := &client.CreateOptions{}
+			createOpts.ApplyOptions(opts)
+			dryRun = len(createOpts.DryRun) > 0
+
+			pod := obj.(*corev1.Pod)
+			if pod.Labels["sidecar.istio.io/inject"] == "true" {
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "istio-proxy"})
+			}
+			return nil
+		},
+	}).Build()
+	result := Istio(context.Background(), injecting, opts)
+	assert.True(t, result.Passed(), result.Err())
+	assert.True(t, dryRun)
+
+	plain := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	result = Istio(context.Background(), plain, opts)
+	assert.False(t, result.Passed())
+	assert.Equal(t, []string{"CreatePod", "SidecarInjected"}, checkNames(result))
+}
@@ -0,0 +1,132 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// Cluster is the target cluster as seen by templates, e.g. {{ .Cluster.Labels.region }}
+type Cluster struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+}
+
+// Integration is the Integration as seen by templates, e.g. {{ .Integration.Name }}
+type Integration struct {
+	Name      string
+	Namespace string
+	Type      string
+}
+
+// Data is the root object templates are executed against
+type Data struct {
+	Cluster     Cluster
+	Integration Integration
+}
+
+var funcs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+// NewData returns the template data for installing an Integration on one cluster
+func NewData(integration *ksitv1alpha1.Integration, clusterName string, labels map[string]string) Data {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return Data{
+		Cluster: Cluster{
+			Name:      clusterName,
+			Namespace: integration.Namespace,
+			Labels:    labels,
+		},
+		Integration: Integration{
+			Name:      integration.Name,
+			Namespace: integration.Namespace,
+			Type:      integration.Spec.Type,
+		},
+	}
+}
+
+func parse(name, text string) (*template.Template, error) {
+	// A missing label is an error rather than "<no value>" ending up in an install
+	return template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+}
+
+// Validate checks that text is a well-formed template without executing it
+func Validate(text string) error {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+	_, err := parse("", text)
+	return err
+}
+
+// Render executes text against data. Strings without template actions are returned unchanged.
+func Render(text string, data Data) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parse("", text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderMap renders every value of m into a new map
+func RenderMap(m map[string]string, data Data) (map[string]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	out := make(map[string]string, len(m))
+	for key, value := range m {
+		rendered, err := Render(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", key, err)
+		}
+		out[key] = rendered
+	}
+	return out, nil
+}
+
+// RenderIntegration returns a copy of the Integration with its config and Helm values
+// resolved for one target cluster. The original is left untouched.
+func RenderIntegration(integration *ksitv1alpha1.Integration, data Data) (*ksitv1alpha1.Integration, error) {
+	rendered := integration.DeepCopy()
+
+	config, err := RenderMap(integration.Spec.Config, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render config for cluster %s: %w", data.Cluster.Name, err)
+	}
+	rendered.Spec.Config = config
+
+	if install := rendered.Spec.AutoInstall; install != nil && install.HelmConfig != nil {
+		values, err := RenderMap(install.HelmConfig.Values, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render helm values for cluster %s: %w", data.Cluster.Name, err)
+		}
+		install.HelmConfig.Values = values
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,61 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestRenderIntegration(t *testing.T) {
+	integration := &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type: ksitv1alpha1.IntegrationTypePrometheus,
+			Config: map[string]string{
+				"url":       "http://prometheus.{{ .Cluster.Name }}.example.com",
+				"namespace": "monitoring",
+			},
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Values: map[string]string{
+						"prometheus.prometheusSpec.externalLabels.cluster": "{{ .Cluster.Name }}",
+						"prometheus.prometheusSpec.externalLabels.region":  "{{ .Cluster.Labels.region | upper }}",
+						"grafana.ingress.hosts[0]":                         `grafana.{{ index .Cluster.Labels "zone" | default "global" }}.example.com`,
+					},
+				},
+			},
+		},
+	}
+
+	data := NewData(integration, "edge-1", map[string]string{"region": "eu-west"})
+	rendered, err := RenderIntegration(integration, data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://prometheus.edge-1.example.com", rendered.Spec.Config["url"])
+	assert.Equal(t, "monitoring", rendered.Spec.Config["namespace"])
+	values := rendered.Spec.AutoInstall.HelmConfig.Values
+	assert.Equal(t, "edge-1", values["prometheus.prometheusSpec.externalLabels.cluster"])
+	assert.Equal(t, "EU-WEST", values["prometheus.prometheusSpec.externalLabels.region"])
+	assert.Equal(t, "grafana.global.example.com", values["grafana.ingress.hosts[0]"])
+
+	// The original keeps its templates
+	assert.Equal(t, "{{ .Cluster.Name }}", integration.Spec.AutoInstall.HelmConfig.Values["prometheus.prometheusSpec.externalLabels.cluster"])
+}
+
+func TestRenderMissingLabel(t *testing.T) {
+	_, err := Render("{{ .Cluster.Labels.region }}", Data{Cluster: Cluster{Name: "edge-1", Labels: map[string]string{}}})
+	assert.Error(t, err)
+
+	out, err := Render("plain", Data{})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", out)
+
+	assert.Error(t, Validate("{{ .Cluster.Name"))
+	assert.NoError(t, Validate("{{ .Cluster.Name }}"))
+}
@@ -0,0 +1,181 @@
+// Package topology computes which integrations run on which clusters of the fleet
+package topology
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// Health of an integration on one cluster
+const (
+	HealthHealthy  = "Healthy"
+	HealthFailing  = "Failing"
+	HealthDisabled = "Disabled"
+	// HealthUnknown is reported until the integration has been checked on the cluster
+	HealthUnknown = "Unknown"
+)
+
+// Topology is the matrix of clusters × integration types
+type Topology struct {
+	// Clusters are the registered and targeted clusters, sorted
+	Clusters []string `json:"clusters"`
+	// Types are the integration types in use, sorted
+	Types []string `json:"types"`
+	// Matrix maps cluster and integration type to the integrations of that type on the cluster.
+	// Cells without integrations are left out.
+	Matrix map[string]map[string][]Entry `json:"matrix"`
+}
+
+// Entry is one integration on one cluster
+type Entry struct {
+	Namespace   string `json:"namespace"`
+	Integration string `json:"integration"`
+	// Version is the version the install ledger recorded for the cluster, if KSIT installed it
+	Version string `json:"version,omitempty"`
+	Health  string `json:"health"`
+	// Score is the cluster's health score, when the integration is scored
+	Score   *int32 `json:"score,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Cell returns the integrations of a type on a cluster
+func (t *Topology) Cell(cluster, integrationType string) []Entry {
+	return t.Matrix[cluster][integrationType]
+}
+
+// Build reads Integrations, IntegrationTargets and InstalledComponents in namespace,
+// or in all namespaces when namespace is empty, and computes the topology
+func Build(ctx context.Context, c client.Reader, namespace string) (*Topology, error) {
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	integrations := &ksitv1alpha1.IntegrationList{}
+	if err := c.List(ctx, integrations, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	targets := &ksitv1alpha1.IntegrationTargetList{}
+	if err := c.List(ctx, targets, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list integration targets: %w", err)
+	}
+	components := &ksitv1alpha1.InstalledComponentList{}
+	if err := c.List(ctx, components, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list installed components: %w", err)
+	}
+
+	return Compute(integrations.Items, targets.Items, components.Items), nil
+}
+
+// Compute builds the topology from already listed objects
+func Compute(integrations []ksitv1alpha1.Integration, targets []ksitv1alpha1.IntegrationTarget, components []ksitv1alpha1.InstalledComponent) *Topology {
+	type componentKey struct{ namespace, integration, cluster string }
+	versions := make(map[componentKey]string, len(components))
+	for _, component := range components {
+		key := componentKey{component.Namespace, component.Spec.IntegrationName, component.Spec.ClusterName}
+		versions[key] = component.Spec.Version
+	}
+
+	clusters := map[string]bool{}
+	for _, target := range targets {
+		clusters[target.Spec.ClusterName] = true
+	}
+
+	types := map[string]bool{}
+	matrix := map[string]map[string][]Entry{}
+	for i := range integrations {
+		integration := &integrations[i]
+		types[integration.Spec.Type] = true
+
+		for _, clusterName := range integration.Targets() {
+			clusters[clusterName] = true
+
+			entry := clusterEntry(integration, clusterName)
+			entry.Version = versions[componentKey{integration.Namespace, integration.Name, clusterName}]
+
+			if matrix[clusterName] == nil {
+				matrix[clusterName] = map[string][]Entry{}
+			}
+			matrix[clusterName][integration.Spec.Type] = append(matrix[clusterName][integration.Spec.Type], entry)
+		}
+	}
+
+	for _, cells := range matrix {
+		for _, entries := range cells {
+			sort.Slice(entries, func(i, j int) bool {
+				if entries[i].Namespace != entries[j].Namespace {
+					return entries[i].Namespace < entries[j].Namespace
+				}
+				return entries[i].Integration < entries[j].Integration
+			})
+		}
+	}
+
+	return &Topology{
+		Clusters: sortedKeys(clusters),
+		Types:    sortedKeys(types),
+		Matrix:   matrix,
+	}
+}
+
+// clusterEntry reads the health of an integration on one cluster from its status
+func clusterEntry(integration *ksitv1alpha1.Integration, clusterName string) Entry {
+	entry := Entry{
+		Namespace:   integration.Namespace,
+		Integration: integration.Name,
+		Health:      HealthUnknown,
+	}
+
+	if health := integration.Status.Health; health != nil {
+		for _, score := range health.Clusters {
+			if score.Name == clusterName {
+				score := score.Score
+				entry.Score = &score
+				break
+			}
+		}
+	}
+
+	if !integration.Spec.Enabled {
+		entry.Health = HealthDisabled
+		return entry
+	}
+
+	listedFailing := int32(0)
+	for _, cs := range integration.Status.ClusterStatuses {
+		if !cs.Connected {
+			listedFailing++
+		}
+		if cs.Name != clusterName {
+			continue
+		}
+		if cs.Connected {
+			entry.Health = HealthHealthy
+		} else {
+			entry.Health = HealthFailing
+			entry.Message = cs.Message
+		}
+		return entry
+	}
+
+	// Large fleets only list the worst clusters, failing ones first. When every failing
+	// cluster is listed, the omitted ones are healthy.
+	if summary := integration.Status.ClusterSummary; summary != nil && summary.Omitted > 0 && summary.Failing == listedFailing {
+		entry.Health = HealthHealthy
+	}
+	return entry
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
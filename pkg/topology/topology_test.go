@@ -0,0 +1,126 @@
+package topology
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+func TestCompute(t *testing.T) {
+	integrations := []ksitv1alpha1.Integration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+				Enabled:        true,
+				TargetClusters: []string{"edge-1", "edge-2"},
+			},
+			Status: ksitv1alpha1.IntegrationStatus{
+				ClusterStatuses: []ksitv1alpha1.ClusterStatus{
+					{Name: "edge-2", Connected: false, Message: "argocd-server is not ready"},
+					{Name: "edge-1", Connected: true},
+				},
+				Health: &ksitv1alpha1.HealthScore{
+					Score: 50,
+					Clusters: []ksitv1alpha1.ClusterHealthScore{
+						{Name: "edge-2", Score: 0},
+						{Name: "edge-1", Score: 100},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "flux", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypeFlux,
+				TargetClusters: []string{"edge-1"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypePrometheus,
+				Enabled:        true,
+				TargetClusters: []string{"edge-1", "edge-2", "edge-3"},
+			},
+			Status: ksitv1alpha1.IntegrationStatus{
+				// edge-3 was left out of the statuses, and the only failing cluster is listed
+				ClusterStatuses: []ksitv1alpha1.ClusterStatus{
+					{Name: "edge-2", Connected: false, Message: "timed out"},
+					{Name: "edge-1", Connected: true},
+				},
+				ClusterSummary: &ksitv1alpha1.ClusterSummary{Total: 3, Connected: 2, Failing: 1, Omitted: 1},
+			},
+		},
+	}
+	targets := []ksitv1alpha1.IntegrationTarget{
+		{Spec: ksitv1alpha1.IntegrationTargetSpec{ClusterName: "edge-1"}},
+		{Spec: ksitv1alpha1.IntegrationTargetSpec{ClusterName: "idle"}},
+	}
+	components := []ksitv1alpha1.InstalledComponent{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd-edge-1", Namespace: "ksit-system"},
+			Spec: ksitv1alpha1.InstalledComponentSpec{
+				IntegrationName: "argocd",
+				ClusterName:     "edge-1",
+				Type:            ksitv1alpha1.IntegrationTypeArgoCD,
+				Version:         "7.0.0",
+			},
+		},
+	}
+
+	topo := Compute(integrations, targets, components)
+
+	assert.Equal(t, []string{"edge-1", "edge-2", "edge-3", "idle"}, topo.Clusters)
+	assert.Equal(t, []string{"argocd", "flux", "prometheus"}, topo.Types)
+	assert.Empty(t, topo.Matrix["idle"])
+
+	cell := topo.Cell("edge-1", ksitv1alpha1.IntegrationTypeArgoCD)
+	require.Len(t, cell, 1)
+	assert.Equal(t, "7.0.0", cell[0].Version)
+	assert.Equal(t, HealthHealthy, cell[0].Health)
+	require.NotNil(t, cell[0].Score)
+	assert.EqualValues(t, 100, *cell[0].Score)
+
+	cell = topo.Cell("edge-2", ksitv1alpha1.IntegrationTypeArgoCD)
+	require.Len(t, cell, 1)
+	assert.Empty(t, cell[0].Version)
+	assert.Equal(t, HealthFailing, cell[0].Health)
+	assert.Equal(t, "argocd-server is not ready", cell[0].Message)
+
+	assert.Equal(t, HealthDisabled, topo.Cell("edge-1", ksitv1alpha1.IntegrationTypeFlux)[0].Health)
+	assert.Equal(t, HealthHealthy, topo.Cell("edge-3", ksitv1alpha1.IntegrationTypePrometheus)[0].Health)
+	assert.Nil(t, topo.Cell("edge-3", ksitv1alpha1.IntegrationTypeArgoCD))
+}
+
+func TestBuildFiltersNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "argocd", Namespace: "team-a"},
+			Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeArgoCD, TargetClusters: []string{"edge-1"}},
+		},
+		&ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio", Namespace: "team-b"},
+			Spec:       ksitv1alpha1.IntegrationSpec{Type: ksitv1alpha1.IntegrationTypeIstio, TargetClusters: []string{"edge-2"}},
+		},
+	).Build()
+
+	topo, err := Build(context.Background(), c, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edge-1"}, topo.Clusters)
+	assert.Equal(t, []string{"argocd"}, topo.Types)
+
+	topo, err = Build(context.Background(), c, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"edge-1", "edge-2"}, topo.Clusters)
+}
@@ -0,0 +1,46 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// These are set at build time via -ldflags "-X github.com/kubestellar/integration-toolkit/pkg/version.Version=..."
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the build of a KSIT binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String returns the identity in semver build-metadata form, e.g. "0.1.0+3f2a9c1"
+func (i Info) String() string {
+	if i.GitCommit == "" || i.GitCommit == "unknown" {
+		return i.Version
+	}
+	return fmt.Sprintf("%s+%s", i.Version, i.GitCommit)
+}
+
+// ParseIdentity splits an identity produced by Info.String back into version and commit
+func ParseIdentity(identity string) (string, string) {
+	version, commit, _ := strings.Cut(identity, "+")
+	return version, commit
+}
@@ -0,0 +1,62 @@
+#!/bin/bash
+set -e
+
+# Runs the scale harness in test/scale against a KSIT controller running locally on a
+# kwok cluster. Extra arguments are passed to the harness, e.g.
+#   ./scripts/scale-test.sh -scale.targets=1000 -scale.min-reconciles-per-second=20
+
+CLUSTER_NAME=${CLUSTER_NAME:-ksit-scale}
+NODES=${NODES:-10}
+RESULTS=${RESULTS:-scale-results.json}
+
+for tool in kwokctl kubectl; do
+    if ! command -v "$tool" &> /dev/null; then
+        echo "Error: $tool is not installed. See https://kwok.sigs.k8s.io/docs/user/installation/"
+        exit 1
+    fi
+done
+
+echo "Creating kwok cluster $CLUSTER_NAME..."
+kwokctl delete cluster --name "$CLUSTER_NAME" 2>/dev/null || true
+kwokctl create cluster --name "$CLUSTER_NAME"
+export KUBECONFIG=$(mktemp)
+kwokctl get kubeconfig --name "$CLUSTER_NAME" > "$KUBECONFIG"
+
+CONTROLLER_PID=""
+cleanup() {
+    if [ -n "$CONTROLLER_PID" ]; then
+        kill "$CONTROLLER_PID" 2>/dev/null || true
+    fi
+    kwokctl delete cluster --name "$CLUSTER_NAME" || true
+    rm -f "$KUBECONFIG"
+}
+trap cleanup EXIT
+
+echo "Creating $NODES fake nodes..."
+for i in $(seq 1 "$NODES"); do
+    cat <<NODE | kubectl apply -f -
+apiVersion: v1
+kind: Node
+metadata:
+  name: kwok-node-$i
+  annotations:
+    kwok.x-k8s.io/node: fake
+  labels:
+    type: kwok
+status:
+  allocatable: {cpu: "32", memory: 256Gi, pods: "110"}
+  capacity: {cpu: "32", memory: 256Gi, pods: "110"}
+NODE
+done
+
+echo "Installing KSIT CRDs..."
+kubectl apply -f config/crd/bases/
+
+echo "Starting the controller..."
+go build -o bin/ksit-scale ./cmd/ksit
+./bin/ksit-scale --metrics-bind-address=:8080 > scale-controller.log 2>&1 &
+CONTROLLER_PID=$!
+
+echo "Running the scale harness..."
+go test -tags scale ./test/scale/ -v -count=1 -timeout=60m -args -scale.out="$RESULTS" "$@"
+echo "Results written to $RESULTS, controller logs to scale-controller.log"
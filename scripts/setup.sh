@@ -123,7 +123,7 @@ fi
 
 # Build the project
 echo "🔨 Building project..."
-if go build -o bin/ksit ./cmd/ksit/main.go; then
+if go build -o bin/ksit ./cmd/ksit; then
     echo "✅ Build successful: bin/ksit"
 else
     echo "⚠️  Build failed, but setup can continue"
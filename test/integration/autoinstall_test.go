@@ -0,0 +1,329 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+// recordingInstaller tracks what is installed on each API server host. Installs fail
+// for the hosts in failHosts.
+type recordingInstaller struct {
+	mu         sync.Mutex
+	installed  map[string]bool
+	failHosts  map[string]bool
+	installs   map[string]int
+	uninstalls map[string]int
+}
+
+func newRecordingInstaller() *recordingInstaller {
+	return &recordingInstaller{
+		installed:  map[string]bool{},
+		failHosts:  map[string]bool{},
+		installs:   map[string]int{},
+		uninstalls: map[string]int{},
+	}
+}
+
+func (f *recordingInstaller) Install(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.installs[config.Host]++
+	if f.failHosts[config.Host] {
+		return fmt.Errorf("helm install failed")
+	}
+	f.installed[config.Host] = true
+	return nil
+}
+
+func (f *recordingInstaller) Uninstall(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uninstalls[config.Host]++
+	delete(f.installed, config.Host)
+	return nil
+}
+
+func (f *recordingInstaller) IsInstalled(ctx context.Context, config *rest.Config, integration *ksitv1alpha1.Integration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.installed[config.Host], nil
+}
+
+func (f *recordingInstaller) setInstalled(host string, installed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.installed[host] = installed
+}
+
+func (f *recordingInstaller) setFailing(host string, failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failHosts[host] = failing
+}
+
+func (f *recordingInstaller) installCount(host string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.installs[host]
+}
+
+func (f *recordingInstaller) uninstallCount(host string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.uninstalls[host]
+}
+
+// argoCDDiscovery holds the discovery documents of a cluster with the Argo CD CRDs,
+// which is all the controller reads from a target cluster when the installer is faked
+var argoCDDiscovery = map[string]string{
+	"/api":    `{"kind":"APIVersions","versions":["v1"]}`,
+	"/api/v1": `{"kind":"APIResourceList","groupVersion":"v1","resources":[]}`,
+	"/apis": `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"argoproj.io",` +
+		`"versions":[{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}],` +
+		`"preferredVersion":{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}}]}`,
+	"/apis/argoproj.io/v1alpha1": `{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"argoproj.io/v1alpha1","resources":[` +
+		`{"name":"applications","singularName":"application","namespaced":true,"kind":"Application","verbs":["get","list"]},` +
+		`{"name":"appprojects","singularName":"appproject","namespaced":true,"kind":"AppProject","verbs":["get","list"]}]}`,
+}
+
+// registerArgoCDCluster registers a cluster served by a fake API server with the Argo
+// CD CRDs and returns its API server host. Both are removed when the spec ends.
+func registerArgoCDCluster(name string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		document, ok := argoCDDiscovery[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, document)
+	}))
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: %s
+  name: %s
+contexts:
+- context:
+    cluster: %s
+    user: test-user
+  name: %s
+current-context: %s
+users:
+- name: test-user
+  user:
+    token: test-token
+`, server.URL, name, name, name, name)
+	Expect(clusterMgr.AddCluster(name, testNamespace, kubeconfig)).To(Succeed())
+	DeferCleanup(func() {
+		Expect(clusterMgr.RemoveCluster(name, testNamespace)).To(Succeed())
+		server.Close()
+	})
+	return server.URL
+}
+
+func autoInstallIntegration(name string, clusters ...string) *ksitv1alpha1.Integration {
+	return &ksitv1alpha1.Integration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+		},
+		Spec: ksitv1alpha1.IntegrationSpec{
+			Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+			Enabled:        true,
+			TargetClusters: clusters,
+			AutoInstall: &ksitv1alpha1.InstallConfig{
+				Enabled: true,
+				Method:  "helm",
+				HelmConfig: &ksitv1alpha1.HelmInstallConfig{
+					Repository:  "https://argoproj.github.io/argo-helm",
+					Chart:       "argo-cd",
+					Version:     "5.51.6",
+					ReleaseName: "argocd",
+				},
+			},
+		},
+	}
+}
+
+// deleteIntegration deletes an Integration and waits until its finalizer is released
+func deleteIntegration(ctx context.Context, integration *ksitv1alpha1.Integration) {
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, integration))).To(Succeed())
+	Eventually(func() bool {
+		err := k8sClient.Get(ctx, client.ObjectKeyFromObject(integration), &ksitv1alpha1.Integration{})
+		return apierrors.IsNotFound(err)
+	}, 30*time.Second, 250*time.Millisecond).Should(BeTrue())
+}
+
+var _ = Describe("Auto-install Tests", func() {
+	const (
+		timeout  = time.Second * 30
+		interval = time.Millisecond * 250
+	)
+
+	Context("When an Integration has autoInstall enabled", func() {
+		It("Should install on every target cluster once", func() {
+			ctx := context.Background()
+			hostA := registerArgoCDCluster("install-a")
+			hostB := registerArgoCDCluster("install-b")
+
+			integration := autoInstallIntegration("install-every-cluster", "install-a", "install-b")
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			DeferCleanup(deleteIntegration, ctx, integration)
+
+			Eventually(func() bool {
+				return fakeInstaller.installCount(hostA) == 1 && fakeInstaller.installCount(hostB) == 1
+			}, timeout, interval).Should(BeTrue())
+
+			for _, clusterName := range integration.Spec.TargetClusters {
+				Eventually(func(g Gomega) {
+					recorded, err := installLedger.Get(ctx, integration, clusterName)
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(recorded).NotTo(BeNil())
+					g.Expect(recorded.Status.LastAction).To(Equal(ksitv1alpha1.InstallActionInstall))
+					g.Expect(recorded.Status.LastActionResult).To(Equal(ksitv1alpha1.InstallResultSucceeded))
+					g.Expect(recorded.Spec.Version).To(Equal("5.51.6"))
+				}, timeout, interval).Should(Succeed())
+			}
+
+			// Installed clusters whose CRDs are served are skipped on later reconciles
+			Consistently(func() int {
+				return fakeInstaller.installCount(hostA) + fakeInstaller.installCount(hostB)
+			}, 3*time.Second, interval).Should(Equal(2))
+		})
+
+		It("Should adopt an existing install without installing again", func() {
+			ctx := context.Background()
+			hostA := registerArgoCDCluster("adopt-a")
+			hostB := registerArgoCDCluster("adopt-b")
+			fakeInstaller.setInstalled(hostA, true)
+
+			integration := autoInstallIntegration("adopt-existing", "adopt-a", "adopt-b")
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			DeferCleanup(deleteIntegration, ctx, integration)
+
+			Eventually(func() int {
+				return fakeInstaller.installCount(hostB)
+			}, timeout, interval).Should(Equal(1))
+			Eventually(func(g Gomega) {
+				recorded, err := installLedger.Get(ctx, integration, "adopt-a")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(recorded).NotTo(BeNil())
+				g.Expect(recorded.Status.LastAction).To(Equal(ksitv1alpha1.InstallActionAdopt))
+				g.Expect(recorded.Status.Adopted).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+			Expect(fakeInstaller.installCount(hostA)).To(BeZero())
+		})
+
+		It("Should report a failed install in the status and retry only that cluster", func() {
+			ctx := context.Background()
+			hostA := registerArgoCDCluster("failed-a")
+			hostB := registerArgoCDCluster("failed-b")
+			fakeInstaller.setFailing(hostB, true)
+
+			integration := autoInstallIntegration("failed-install", "failed-a", "failed-b")
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			DeferCleanup(deleteIntegration, ctx, integration)
+			key := types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace}
+
+			Eventually(func(g Gomega) {
+				current := &ksitv1alpha1.Integration{}
+				g.Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+				g.Expect(current.Status.Phase).To(Equal(ksitv1alpha1.PhaseFailed))
+				g.Expect(current.Status.Message).To(Equal("Auto-install failed: failed to install on cluster failed-b: helm install failed"))
+			}, timeout, interval).Should(Succeed())
+
+			recorded, err := installLedger.Get(ctx, integration, "failed-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorded).NotTo(BeNil())
+			Expect(recorded.Status.LastActionResult).To(Equal(ksitv1alpha1.InstallResultFailed))
+			Expect(recorded.Status.Message).To(Equal("helm install failed"))
+
+			// Once the install works, only the failed cluster is installed again
+			fakeInstaller.setFailing(hostB, false)
+			Expect(k8sClient.Get(ctx, key, integration)).To(Succeed())
+			integration.Annotations = map[string]string{ksitv1alpha1.ReconcileRequestAnnotation: "retry"}
+			Expect(k8sClient.Update(ctx, integration)).To(Succeed())
+			Eventually(func(g Gomega) {
+				recorded, err := installLedger.Get(ctx, integration, "failed-b")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(recorded).NotTo(BeNil())
+				g.Expect(recorded.Status.LastActionResult).To(Equal(ksitv1alpha1.InstallResultSucceeded))
+			}, timeout, interval).Should(Succeed())
+			Expect(fakeInstaller.installCount(hostA)).To(Equal(1))
+		})
+
+		It("Should report a target cluster that is not registered", func() {
+			ctx := context.Background()
+			registerArgoCDCluster("unknown-a")
+
+			integration := autoInstallIntegration("unknown-cluster", "unknown-a", "unknown-missing")
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			DeferCleanup(deleteIntegration, ctx, integration)
+			key := types.NamespacedName{Name: integration.Name, Namespace: integration.Namespace}
+
+			Eventually(func(g Gomega) {
+				current := &ksitv1alpha1.Integration{}
+				g.Expect(k8sClient.Get(ctx, key, current)).To(Succeed())
+				g.Expect(current.Status.Phase).To(Equal(ksitv1alpha1.PhaseFailed))
+				g.Expect(current.Status.Message).To(ContainSubstring("failed to get config for cluster unknown-missing"))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	Context("When an auto-installed Integration is deleted", func() {
+		It("Should uninstall what KSIT installed with uninstallOnDelete", func() {
+			ctx := context.Background()
+			hostA := registerArgoCDCluster("delete-a")
+			hostB := registerArgoCDCluster("delete-b")
+			// Argo CD was on delete-b before KSIT, so it is adopted and stays
+			fakeInstaller.setInstalled(hostB, true)
+
+			integration := autoInstallIntegration("uninstall-on-delete", "delete-a", "delete-b")
+			uninstall := true
+			integration.Spec.AutoInstall.UninstallOnDelete = &uninstall
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			Eventually(func() int {
+				return fakeInstaller.installCount(hostA)
+			}, timeout, interval).Should(Equal(1))
+			Eventually(func(g Gomega) {
+				recorded, err := installLedger.Get(ctx, integration, "delete-b")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(recorded).NotTo(BeNil())
+			}, timeout, interval).Should(Succeed())
+
+			deleteIntegration(ctx, integration)
+			Expect(fakeInstaller.uninstallCount(hostA)).To(Equal(1))
+			Expect(fakeInstaller.uninstallCount(hostB)).To(BeZero())
+		})
+
+		It("Should leave the tool in place without uninstallOnDelete", func() {
+			ctx := context.Background()
+			host := registerArgoCDCluster("retain")
+
+			integration := autoInstallIntegration("retain-on-delete", "retain")
+			Expect(k8sClient.Create(ctx, integration)).To(Succeed())
+			Eventually(func() int {
+				return fakeInstaller.installCount(host)
+			}, timeout, interval).Should(Equal(1))
+
+			deleteIntegration(ctx, integration)
+			Expect(fakeInstaller.uninstallCount(host)).To(BeZero())
+		})
+	})
+})
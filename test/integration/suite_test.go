@@ -27,6 +27,7 @@ import (
 	"github.com/kubestellar/integration-toolkit/pkg/cluster"
 	"github.com/kubestellar/integration-toolkit/pkg/controller"
 	"github.com/kubestellar/integration-toolkit/pkg/installer"
+	"github.com/kubestellar/integration-toolkit/pkg/ledger"
 )
 
 var (
@@ -37,6 +38,8 @@ var (
 	cancel        context.CancelFunc
 	clusterMgr    *cluster.ClusterManager
 	testNamespace string
+	installLedger *ledger.Ledger
+	fakeInstaller *recordingInstaller
 )
 
 func TestIntegration(t *testing.T) {
@@ -166,10 +169,15 @@ var _ = BeforeSuite(func() {
 	targetSetupErr := integrationTargetReconciler.SetupWithManager(k8sManager)
 	Expect(targetSetupErr).NotTo(HaveOccurred())
 
-	// Create installer factory
+	// Create installer factory, with a fake installer for Argo CD so auto-install can
+	// run without pulling charts
 	installerFactory := installer.NewInstallerFactory()
+	fakeInstaller = newRecordingInstaller()
+	installerFactory.Register(ksitv1alpha1.IntegrationTypeArgoCD, fakeInstaller)
 	logf.Log.Info("✅ created installer factory")
 
+	installLedger = ledger.NewLedger(k8sManager.GetClient())
+
 	// Setup Integration reconciler
 	integrationReconciler := &controller.IntegrationReconciler{
 		Client:           k8sManager.GetClient(),
@@ -178,6 +186,7 @@ var _ = BeforeSuite(func() {
 		ClusterManager:   clusterMgr,
 		ClusterInventory: cluster.NewClusterInventory(),
 		InstallerFactory: installerFactory,
+		Ledger:           installLedger,
 	}
 	integrationSetupErr := integrationReconciler.SetupWithManager(k8sManager)
 	Expect(integrationSetupErr).NotTo(HaveOccurred())
@@ -0,0 +1,282 @@
+//go:build scale
+
+// Package scale is a scale harness for a running KSIT controller. It registers a fleet
+// of IntegrationTargets and Argo CD Integrations on a kwok cluster, whose fake nodes run
+// the Argo CD Deployments the health checks look for, and measures how the controller
+// keeps up: time to converge, reconcile throughput, API requests per reconcile and heap.
+// scripts/scale-test.sh sets up the cluster and the controller and runs it.
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ksitv1alpha1 "github.com/kubestellar/integration-toolkit/api/v1alpha1"
+)
+
+var (
+	targets                = flag.Int("scale.targets", 500, "IntegrationTargets to register")
+	integrations           = flag.Int("scale.integrations", 100, "Argo CD Integrations to create")
+	clustersPerIntegration = flag.Int("scale.clusters-per-integration", 25, "Target clusters of each Integration")
+	namespace              = flag.String("scale.namespace", "ksit-scale", "Namespace of the IntegrationTargets and Integrations")
+	metricsURL             = flag.String("scale.metrics-url", "http://localhost:8080/metrics", "Metrics endpoint of the controller")
+	convergeTimeout        = flag.Duration("scale.converge-timeout", 15*time.Minute, "How long every Integration may take to become Running")
+	window                 = flag.Duration("scale.window", 2*time.Minute, "How long steady-state reconciles are measured")
+	out                    = flag.String("scale.out", "", "File the results are written to as JSON")
+
+	minThroughput           = flag.Float64("scale.min-reconciles-per-second", 0, "Fail below this steady-state reconcile throughput; 0 disables the gate")
+	maxRequestsPerReconcile = flag.Float64("scale.max-requests-per-reconcile", 0, "Fail above this many API requests per reconcile; 0 disables the gate")
+	maxHeapMiB              = flag.Float64("scale.max-heap-mib", 0, "Fail above this controller heap in MiB; 0 disables the gate")
+)
+
+// Results are what a scale run measured
+type Results struct {
+	Targets              int     `json:"targets"`
+	Integrations         int     `json:"integrations"`
+	ConvergeSeconds      float64 `json:"convergeSeconds"`
+	ReconcilesPerSecond  float64 `json:"reconcilesPerSecond"`
+	RequestsPerReconcile float64 `json:"requestsPerReconcile"`
+	HeapInUseMiB         float64 `json:"heapInUseMiB"`
+	ReconcileErrors      float64 `json:"reconcileErrors"`
+}
+
+func TestScale(t *testing.T) {
+	ctx := context.Background()
+	c, kubeconfig := newClient(t)
+
+	require.NoError(t, ensure(ctx, c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: *namespace}}))
+	createArgoCD(ctx, t, c)
+
+	clusterNames := make([]string, *targets)
+	for i := range clusterNames {
+		clusterNames[i] = fmt.Sprintf("kwok-%04d", i)
+		createTarget(ctx, t, c, clusterNames[i], kubeconfig)
+	}
+
+	start := time.Now()
+	names := make([]string, *integrations)
+	for i := range names {
+		names[i] = fmt.Sprintf("argocd-%03d", i)
+		var targetClusters []string
+		for j := 0; j < *clustersPerIntegration && j < len(clusterNames); j++ {
+			targetClusters = append(targetClusters, clusterNames[(i*(*clustersPerIntegration)/5+j)%len(clusterNames)])
+		}
+		require.NoError(t, ensure(ctx, c, &ksitv1alpha1.Integration{
+			ObjectMeta: metav1.ObjectMeta{Name: names[i], Namespace: *namespace},
+			Spec: ksitv1alpha1.IntegrationSpec{
+				Type:           ksitv1alpha1.IntegrationTypeArgoCD,
+				Enabled:        true,
+				TargetClusters: targetClusters,
+			},
+		}))
+	}
+
+	t.Logf("waiting for %d Integrations over %d targets to become Running", *integrations, *targets)
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, *convergeTimeout, true, func(ctx context.Context) (bool, error) {
+		list := &ksitv1alpha1.IntegrationList{}
+		if err := c.List(ctx, list, client.InNamespace(*namespace)); err != nil {
+			return false, err
+		}
+		running := 0
+		for _, integration := range list.Items {
+			if integration.Status.Phase == ksitv1alpha1.PhaseRunning {
+				running++
+			}
+		}
+		return running == len(names), nil
+	})
+	require.NoError(t, err, "Integrations did not converge")
+	results := Results{Targets: *targets, Integrations: *integrations, ConvergeSeconds: time.Since(start).Seconds()}
+
+	before := scrape(t)
+	time.Sleep(*window)
+	after := scrape(t)
+
+	reconciles := sum(after, "controller_runtime_reconcile_total", "controller", "integration") -
+		sum(before, "controller_runtime_reconcile_total", "controller", "integration")
+	requests := sum(after, "rest_client_requests_total", "", "") - sum(before, "rest_client_requests_total", "", "")
+	results.ReconcilesPerSecond = reconciles / window.Seconds()
+	if reconciles > 0 {
+		results.RequestsPerReconcile = requests / reconciles
+	}
+	results.HeapInUseMiB = sum(after, "go_memstats_heap_inuse_bytes", "", "") / (1 << 20)
+	results.ReconcileErrors = sum(after, "controller_runtime_reconcile_errors_total", "controller", "integration") -
+		sum(before, "controller_runtime_reconcile_errors_total", "controller", "integration")
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	require.NoError(t, err)
+	t.Logf("results:\n%s", data)
+	if *out != "" {
+		require.NoError(t, os.WriteFile(*out, data, 0o644))
+	}
+
+	if *minThroughput > 0 && results.ReconcilesPerSecond < *minThroughput {
+		t.Errorf("reconcile throughput %.2f/s is below %.2f/s", results.ReconcilesPerSecond, *minThroughput)
+	}
+	if *maxRequestsPerReconcile > 0 && results.RequestsPerReconcile > *maxRequestsPerReconcile {
+		t.Errorf("%.1f API requests per reconcile exceed %.1f", results.RequestsPerReconcile, *maxRequestsPerReconcile)
+	}
+	if *maxHeapMiB > 0 && results.HeapInUseMiB > *maxHeapMiB {
+		t.Errorf("controller heap %.1fMiB exceeds %.1fMiB", results.HeapInUseMiB, *maxHeapMiB)
+	}
+}
+
+// newClient returns a client of the kwok cluster and its kubeconfig, which every
+// IntegrationTarget of the fleet registers
+func newClient(t *testing.T) (client.Client, []byte) {
+	path := os.Getenv("KUBECONFIG")
+	require.NotEmpty(t, path, "KUBECONFIG must point to the kwok cluster")
+	kubeconfig, err := os.ReadFile(path)
+	require.NoError(t, err)
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	require.NoError(t, err)
+	config.QPS, config.Burst = 100, 200
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	require.NoError(t, ksitv1alpha1.AddToScheme(scheme))
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	require.NoError(t, err)
+	return c, kubeconfig
+}
+
+// ensure creates obj unless it exists
+func ensure(ctx context.Context, c client.Client, obj client.Object) error {
+	if err := c.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+func createTarget(ctx context.Context, t *testing.T, c client.Client, clusterName string, kubeconfig []byte) {
+	require.NoError(t, ensure(ctx, c, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-kubeconfig", Namespace: *namespace},
+		Data:       map[string][]byte{"kubeconfig": kubeconfig},
+	}))
+	require.NoError(t, ensure(ctx, c, &ksitv1alpha1.IntegrationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName, Namespace: *namespace},
+		Spec:       ksitv1alpha1.IntegrationTargetSpec{ClusterName: clusterName, Labels: map[string]string{"ksit.io/scale": "true"}},
+	}))
+}
+
+// createArgoCD creates what the Argo CD health check looks for: the Argo CD CRDs, and
+// the argocd-server Service and Argo CD Deployments, whose Pods kwok runs
+func createArgoCD(ctx context.Context, t *testing.T, c client.Client) {
+	for _, kind := range []string{"Application", "AppProject"} {
+		require.NoError(t, ensure(ctx, c, argoCDCRD(kind)))
+	}
+	require.NoError(t, ensure(ctx, c, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "argocd"}}))
+	for _, name := range []string{"argocd-server", "argocd-repo-server", "argocd-application-controller"} {
+		require.NoError(t, ensure(ctx, c, argoCDDeployment(name)))
+	}
+	require.NoError(t, ensure(ctx, c, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "argocd-server", Namespace: "argocd"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app.kubernetes.io/name": "argocd-server"},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}))
+}
+
+func argoCDCRD(kind string) *apiextensionsv1.CustomResourceDefinition {
+	plural := map[string]string{"Application": "applications", "AppProject": "appprojects"}[kind]
+	preserve := true
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: plural + ".argoproj.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "argoproj.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: kind, ListKind: kind + "List", Plural: plural},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    "v1alpha1",
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Type:                   "object",
+					XPreserveUnknownFields: &preserve,
+				}},
+			}},
+		},
+	}
+}
+
+func argoCDDeployment(name string) *appsv1.Deployment {
+	labels := map[string]string{"app.kubernetes.io/name": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "argocd"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					// kwok only runs Pods on its fake nodes
+					NodeSelector: map[string]string{"type": "kwok"},
+					Containers:   []corev1.Container{{Name: name, Image: "quay.io/argoproj/argocd:v2.9.3"}},
+				},
+			},
+		},
+	}
+}
+
+// scrape reads the metric families of the controller
+func scrape(t *testing.T) map[string]*dto.MetricFamily {
+	resp, err := http.Get(*metricsURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	require.NoError(t, err)
+	return families
+}
+
+// sum adds up the counters or gauges of a metric family whose label has the value;
+// an empty label adds up all of them
+func sum(families map[string]*dto.MetricFamily, name, label, value string) float64 {
+	family, ok := families[name]
+	if !ok {
+		return 0
+	}
+	total := 0.0
+	for _, metric := range family.GetMetric() {
+		if label != "" && !hasLabel(metric, label, value) {
+			continue
+		}
+		switch {
+		case metric.Counter != nil:
+			total += metric.GetCounter().GetValue()
+		case metric.Gauge != nil:
+			total += metric.GetGauge().GetValue()
+		}
+	}
+	return total
+}
+
+func hasLabel(metric *dto.Metric, label, value string) bool {
+	for _, pair := range metric.GetLabel() {
+		if pair.GetName() == label {
+			return pair.GetValue() == value
+		}
+	}
+	return false
+}